@@ -29,6 +29,7 @@ import (
 	"syscall"
 
 	"github.com/pingcap/log"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	flag "github.com/spf13/pflag"
 	"go.etcd.io/etcd/pkg/transport"
 	"go.uber.org/zap"
@@ -64,11 +65,40 @@ func NewCLIConfig() *DashboardCLIConfig {
 	flag.StringVar(&cfg.CoreConfig.DataDir, "data-dir", cfg.CoreConfig.DataDir, "path to the Dashboard Server data directory")
 	flag.StringVar(&cfg.CoreConfig.TempDir, "temp-dir", cfg.CoreConfig.TempDir, "path to the Dashboard Server temporary directory, used to store the searched logs")
 	flag.StringVar(&cfg.CoreConfig.PublicPathPrefix, "path-prefix", cfg.CoreConfig.PublicPathPrefix, "public URL path prefix for reverse proxies")
+	flag.StringSliceVar(&cfg.CoreConfig.CORSAllowedOrigins, "cors-allowed-origins", cfg.CoreConfig.CORSAllowedOrigins, "comma-delimited origins allowed to call the API cross-origin, e.g. https://*.example.com, default to any origin")
 	flag.StringVar(&cfg.CoreConfig.PDEndPoint, "pd", cfg.CoreConfig.PDEndPoint, "PD endpoint address that Dashboard Server connects to")
 	flag.BoolVar(&cfg.CoreConfig.EnableTelemetry, "telemetry", cfg.CoreConfig.EnableTelemetry, "allow telemetry")
 	flag.BoolVar(&cfg.CoreConfig.EnableExperimental, "experimental", cfg.CoreConfig.EnableExperimental, "allow experimental features")
 	flag.StringVar(&cfg.CoreConfig.FeatureVersion, "feature-version", cfg.CoreConfig.FeatureVersion, "target TiDB version for standalone mode")
+	flag.StringSliceVar(&cfg.CoreConfig.DisabledFeatures, "disabled-features", cfg.CoreConfig.DisabledFeatures, "comma-delimited features to disable, e.g. profiling,log_search,query_editor,conprof")
 	flag.IntVar(&cfg.CoreConfig.NgmTimeout, "ngm-timeout", cfg.CoreConfig.NgmTimeout, "timeout secs for accessing the ngm API")
+	flag.DurationVar(&cfg.CoreConfig.SlowRequestThreshold, "slow-request-threshold", cfg.CoreConfig.SlowRequestThreshold, "API requests slower than it are logged as warnings and kept, 0 disables it")
+	flag.IntVar(&cfg.CoreConfig.SlowRequestCapacity, "slow-request-capacity", cfg.CoreConfig.SlowRequestCapacity, "max number of recent slow API requests kept in memory")
+	flag.BoolVar(&cfg.CoreConfig.EnableRestrictedLogin, "restricted-login", cfg.CoreConfig.EnableRestrictedLogin, "allow SQL users without the dashboard privileges to sign in, who are only able to view their statements and slow queries")
+	flag.IntVar(&cfg.CoreConfig.LoginRateLimitPerIP, "login-rate-limit-per-ip", cfg.CoreConfig.LoginRateLimitPerIP, "max number of sign in attempts per minute from a source IP, 0 disables it")
+	flag.IntVar(&cfg.CoreConfig.LoginMaxFailuresPerIP, "login-max-failures-per-ip", cfg.CoreConfig.LoginMaxFailuresPerIP, "max number of consecutive failed sign in attempts from a source IP before it is locked out, 0 disables it")
+	flag.IntVar(&cfg.CoreConfig.LoginMaxFailuresPerUser, "login-max-failures-per-user", cfg.CoreConfig.LoginMaxFailuresPerUser, "max number of consecutive failed sign in attempts of a username before it is locked out, 0 disables it")
+	flag.StringSliceVar(&cfg.CoreConfig.TrustedProxies, "trusted-proxies", cfg.CoreConfig.TrustedProxies, "comma-delimited IPs or CIDRs of reverse proxies trusted to forward the client IP, default to none")
+	flag.DurationVar(&cfg.CoreConfig.LoginLockoutDuration, "login-lockout-duration", cfg.CoreConfig.LoginLockoutDuration, "how long a source IP or a username is locked out after too many failed sign in attempts")
+	flag.StringVar(&cfg.CoreConfig.AdvertiseAddress, "advertise-address", cfg.CoreConfig.AdvertiseAddress, "address of the Dashboard Server seen by other components, default to host:port")
+	flag.DurationVar(&cfg.CoreConfig.TopologyCacheTTL, "topology-cache-ttl", cfg.CoreConfig.TopologyCacheTTL, "how long topology responses are cached, 0 disables the cache")
+	flag.IntVar(&cfg.CoreConfig.TopologyProbeConcurrency, "topology-probe-concurrency", cfg.CoreConfig.TopologyProbeConcurrency, "max number of concurrent instance liveness probes")
+	flag.IntVar(&cfg.CoreConfig.TopologyEventsCapacity, "topology-events-capacity", cfg.CoreConfig.TopologyEventsCapacity, "max number of recent topology change events kept in memory")
+	flag.IntVar(&cfg.CoreConfig.TopologyDownGraceProbes, "topology-down-grace-probes", cfg.CoreConfig.TopologyDownGraceProbes, "number of consecutive failed liveness probes before an instance is reported down")
+	flag.DurationVar(&cfg.CoreConfig.TopologyProbeInterval, "topology-probe-interval", cfg.CoreConfig.TopologyProbeInterval, "interval of probing the liveness of all instances in background, 0 disables it")
+	flag.DurationVar(&cfg.CoreConfig.TopologyProbeHistoryRetention, "topology-probe-history-retention", cfg.CoreConfig.TopologyProbeHistoryRetention, "how long the liveness history of an instance vanished from the topology is kept")
+	flag.IntVar(&cfg.CoreConfig.TopologyFetchMaxRetries, "topology-fetch-max-retries", cfg.CoreConfig.TopologyFetchMaxRetries, "max number of retries when fetching the topology of each component")
+	flag.DurationVar(&cfg.CoreConfig.TopologyFetchRetryBudget, "topology-fetch-retry-budget", cfg.CoreConfig.TopologyFetchRetryBudget, "max total retry time when fetching the topology of each component")
+	flag.BoolVar(&cfg.CoreConfig.TopologyDependencyAwareFetch, "topology-dependency-aware-fetch", cfg.CoreConfig.TopologyDependencyAwareFetch, "skip fetching components whose dependencies are unavailable, e.g. TiKV when PD is down")
+	flag.DurationVar(&cfg.CoreConfig.TopologyFetchTimeout, "topology-fetch-timeout", cfg.CoreConfig.TopologyFetchTimeout, "timeout of fetching the topology of all components, 0 means no timeout")
+	flag.DurationVar(&cfg.CoreConfig.TopologyFetchSourceTimeout, "topology-fetch-source-timeout", cfg.CoreConfig.TopologyFetchSourceTimeout, "timeout of fetching the topology of each source, 0 means no timeout")
+	flag.StringSliceVar(&cfg.CoreConfig.TopologyDisabledComponents, "topology-disabled-components", cfg.CoreConfig.TopologyDisabledComponents, "comma-delimited components not to be discovered, e.g. alert_manager,grafana")
+	flag.StringVar(&cfg.CoreConfig.TopologyStaticFile, "topology-static-file", cfg.CoreConfig.TopologyStaticFile, "(debug) load the cluster topology from a JSON file instead of etcd and PD")
+	flag.StringVar(&cfg.CoreConfig.TopologyTiUPMetaFile, "topology-tiup-meta", cfg.CoreConfig.TopologyTiUPMetaFile, "(debug) load the cluster topology from a tiup cluster meta.yaml instead of etcd and PD")
+	flag.StringVar(&cfg.CoreConfig.TopologyStoreInventory, "topology-store-inventory", cfg.CoreConfig.TopologyStoreInventory, "URL or file path of the authoritative store list to be compared with PD")
+	flag.BoolVar(&cfg.CoreConfig.TopologyMaskAddresses, "topology-mask-addresses", cfg.CoreConfig.TopologyMaskAddresses, "mask node IPs in the cluster topology for users without the write privilege")
+	flag.StringToIntVar(&cfg.CoreConfig.TopologyExpectedMinimum, "topology-expected-minimum", cfg.CoreConfig.TopologyExpectedMinimum, "minimum number of up instances of each component, e.g. tidb=2,tikv=3")
+	flag.BoolVar(&cfg.CoreConfig.ExcludeSelfFromTopology, "exclude-self-from-topology", cfg.CoreConfig.ExcludeSelfFromTopology, "exclude the Dashboard Server itself from the cluster topology")
 
 	showVersion := flag.BoolP("version", "v", false, "print version information and exit")
 
@@ -97,6 +127,10 @@ func NewCLIConfig() *DashboardCLIConfig {
 
 	cfg.CoreConfig.NormalizePublicPathPrefix()
 
+	if cfg.CoreConfig.AdvertiseAddress == "" {
+		cfg.CoreConfig.AdvertiseAddress = net.JoinHostPort(cfg.ListenHost, strconv.Itoa(cfg.ListenPort))
+	}
+
 	// setup TLS config for TiDB components
 	if len(*clusterCaPath) != 0 && len(*clusterCertPath) != 0 && len(*clusterKeyPath) != 0 {
 		tlsInfo := &transport.TLSInfo{
@@ -273,15 +307,20 @@ func main() {
 
 	mux := http.DefaultServeMux
 	uiHandler := http.StripPrefix(strings.TrimRight(config.UIPathPrefix, "/"), uiserver.Handler(assets))
-	mux.Handle("/", http.RedirectHandler(config.UIPathPrefix, http.StatusFound))
+	mux.Handle("/", http.RedirectHandler(cliConfig.CoreConfig.PublicURL("/"), http.StatusFound))
+	mux.Handle(strings.TrimRight(config.UIPathPrefix, "/"), http.RedirectHandler(cliConfig.CoreConfig.PublicURL("/"), http.StatusMovedPermanently))
 	mux.Handle(config.UIPathPrefix, uiHandler)
 	mux.Handle(config.APIPathPrefix, apiserver.Handler(s))
 	mux.Handle(config.SwaggerPathPrefix, swaggerserver.Handler())
+	mux.Handle(config.SpecPath, swaggerserver.SpecHandler())
+	mux.Handle(config.MetricsPath, promhttp.Handler())
 
 	log.Info(fmt.Sprintf("Dashboard server is listening at %s", listenAddr))
 	log.Info(fmt.Sprintf("UI:      http://%s/dashboard/", net.JoinHostPort(cliConfig.ListenHost, strconv.Itoa(cliConfig.ListenPort))))
 	log.Info(fmt.Sprintf("API:     http://%s/dashboard/api/", net.JoinHostPort(cliConfig.ListenHost, strconv.Itoa(cliConfig.ListenPort))))
 	log.Info(fmt.Sprintf("Swagger: http://%s/dashboard/api/swagger/", net.JoinHostPort(cliConfig.ListenHost, strconv.Itoa(cliConfig.ListenPort))))
+	log.Info(fmt.Sprintf("Spec:    http://%s/dashboard/api/spec.json", net.JoinHostPort(cliConfig.ListenHost, strconv.Itoa(cliConfig.ListenPort))))
+	log.Info(fmt.Sprintf("Metrics: http://%s/metrics", net.JoinHostPort(cliConfig.ListenHost, strconv.Itoa(cliConfig.ListenPort))))
 
 	srv := &http.Server{Handler: mux} // nolint:gosec
 	var wg sync.WaitGroup
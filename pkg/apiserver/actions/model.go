@@ -0,0 +1,165 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package actions
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pingcap/tidb-dashboard/util/topo"
+)
+
+type ActionType string
+
+const (
+	ActionRestart ActionType = "restart"
+	ActionKill    ActionType = "kill"
+	// ActionEvict moves the workload away from the node, e.g. evicting leaders of a TiKV store.
+	ActionEvict ActionType = "evict"
+)
+
+var supportedActions = map[ActionType]struct{}{
+	ActionRestart: {},
+	ActionKill:    {},
+	ActionEvict:   {},
+}
+
+// supportedComponents are components whose nodes can be operated by hooks.
+var supportedComponents = map[topo.Kind]struct{}{
+	topo.KindTiDB:    {},
+	topo.KindTiKV:    {},
+	topo.KindPD:      {},
+	topo.KindTiFlash: {},
+	topo.KindTiCDC:   {},
+	topo.KindTiProxy: {},
+}
+
+func scanJSONList(src interface{}, v interface{}) error {
+	switch s := src.(type) {
+	case string:
+		return json.Unmarshal([]byte(s), v)
+	case []byte:
+		return json.Unmarshal(s, v)
+	default:
+		return fmt.Errorf("unsupported type %T of list", src)
+	}
+}
+
+type ActionTypeList []ActionType
+
+func (l *ActionTypeList) Scan(src interface{}) error {
+	return scanJSONList(src, l)
+}
+
+func (l ActionTypeList) Value() (driver.Value, error) {
+	val, err := json.Marshal(l)
+	return string(val), err
+}
+
+type ComponentList []topo.Kind
+
+func (l *ComponentList) Scan(src interface{}) error {
+	return scanJSONList(src, l)
+}
+
+func (l ComponentList) Value() (driver.Value, error) {
+	val, err := json.Marshal(l)
+	return string(val), err
+}
+
+// HookModel is an external operation endpoint registered by the deployment, e.g. a webhook of TiUP or the
+// Kubernetes operator, which performs actions on nodes.
+type HookModel struct {
+	ID   uint   `json:"id" gorm:"primary_key"`
+	Name string `json:"name"`
+	URL  string `json:"url" gorm:"type:text"`
+	// Actions are the actions performed by the hook.
+	Actions ActionTypeList `json:"actions" gorm:"type:text"`
+	// Components are the components whose nodes are operated by the hook. All components are operated when empty.
+	Components ComponentList `json:"components" gorm:"type:text"`
+	// Secret signs the requests to the hook. It is generated by the dashboard and returned only when created.
+	Secret    string    `json:"-"`
+	CreatedBy string    `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+
+	LastRunAt    time.Time `json:"last_run_at"`
+	LastRunError string    `json:"last_run_error" gorm:"type:text"`
+}
+
+func (HookModel) TableName() string {
+	return "action_hooks"
+}
+
+// maskedValue replaces secrets in responses to users not allowed to see them.
+const maskedValue = "******"
+
+// maskHookURL keeps the scheme and the host of the hook URL, as the path and the query may contain the token of
+// the hook.
+func maskHookURL(hookURL string) string {
+	u, err := url.Parse(hookURL)
+	if err != nil || u.Host == "" {
+		return maskedValue
+	}
+	return u.Scheme + "://" + u.Host + "/" + maskedValue
+}
+
+// mask hides the URL of the hook, including in the error of the last run.
+func (m *HookModel) mask() {
+	masked := maskHookURL(m.URL)
+	if m.URL != "" {
+		m.LastRunError = strings.ReplaceAll(m.LastRunError, m.URL, masked)
+	}
+	m.URL = masked
+}
+
+func (m *HookModel) supports(action ActionType, component topo.Kind) bool {
+	supported := false
+	for _, a := range m.Actions {
+		if a == action {
+			supported = true
+			break
+		}
+	}
+	if !supported || len(m.Components) == 0 {
+		return supported
+	}
+	for _, c := range m.Components {
+		if c == component {
+			return true
+		}
+	}
+	return false
+}
+
+// AvailableAction is an action of a hook available to a node.
+type AvailableAction struct {
+	HookID   uint       `json:"hook_id"`
+	HookName string     `json:"hook_name"`
+	Action   ActionType `json:"action"`
+}
+
+// Payload is posted to the hook in JSON to perform the action. The hex encoded HMAC-SHA256 of the body signed by the
+// secret of the hook is sent in the `X-Dashboard-Signature` header, so that the hook can verify the request.
+type Payload struct {
+	Action    ActionType `json:"action"`
+	Component topo.Kind  `json:"component"`
+	// Address is the `host:port` address of the node, as listed in the topology.
+	Address string `json:"address"`
+	// ClusterID and PDEndpoint identify the cluster of the node, where the ClusterID of the default cluster is
+	// `default`.
+	ClusterID   string    `json:"cluster_id"`
+	PDEndpoint  string    `json:"pd_endpoint"`
+	RequestedBy string    `json:"requested_by"`
+	RequestedAt time.Time `json:"requested_at"`
+}
+
+// RunResult is the response of the hook.
+type RunResult struct {
+	HookID uint `json:"hook_id"`
+	// Response is the response body of the hook, which is truncated if too long.
+	Response string `json:"response"`
+}
@@ -0,0 +1,224 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package actions
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/audit"
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/cluster"
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/user"
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/utils"
+	"github.com/pingcap/tidb-dashboard/util/rest"
+	"github.com/pingcap/tidb-dashboard/util/topo"
+)
+
+func registerRouter(r *gin.RouterGroup, auth *user.AuthService, a *audit.Service, s *Service) {
+	endpoint := r.Group("/actions")
+	endpoint.Use(auth.MWAuthRequired(), s.params.Registry.MWResolveCluster())
+	endpoint.GET("/hooks", s.listHooksHandler)
+	endpoint.POST("/hooks", auth.MWRequireCapability(utils.CapabilityManageTopology), a.MWRecord("actions.hook.create", audit.RedactJSONFields("url")), s.createHookHandler)
+	endpoint.PUT("/hooks/:id", auth.MWRequireCapability(utils.CapabilityManageTopology), a.MWRecord("actions.hook.update", audit.RedactJSONFields("url")), s.updateHookHandler)
+	endpoint.DELETE("/hooks/:id", auth.MWRequireCapability(utils.CapabilityManageTopology), a.MWRecord("actions.hook.delete"), s.deleteHookHandler)
+	endpoint.GET("/available", s.listAvailableActionsHandler)
+	endpoint.POST("/run", auth.MWRequireCapability(utils.CapabilityManageTopology), a.MWRecord("actions.run"), s.runHandler)
+}
+
+func parseID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		rest.Error(c, rest.ErrBadRequest.New("invalid id %s", c.Param("id")))
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// @ID actionsListHooks
+// @Summary List action hooks
+// @Description Hook URLs are masked for users without the capability to manage the topology.
+// @Security JwtAuth
+// @Success 200 {array} HookModel
+// @Failure 401 {object} rest.ErrorResponse
+// @Router /actions/hooks [get]
+func (s *Service) listHooksHandler(c *gin.Context) {
+	hooks, err := s.ListHooks()
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	if !utils.GetSession(c).HasCapability(utils.CapabilityManageTopology) {
+		for i := range hooks {
+			hooks[i].mask()
+		}
+	}
+	c.JSON(http.StatusOK, hooks)
+}
+
+type HookRequest struct {
+	Name       string         `json:"name" binding:"required"`
+	URL        string         `json:"url" binding:"required"`
+	Actions    ActionTypeList `json:"actions" binding:"required"`
+	Components ComponentList  `json:"components"`
+}
+
+func (r *HookRequest) toModel() *HookModel {
+	return &HookModel{
+		Name:       r.Name,
+		URL:        r.URL,
+		Actions:    r.Actions,
+		Components: r.Components,
+	}
+}
+
+type CreateHookResponse struct {
+	Hook *HookModel `json:"hook"`
+	// Secret verifies the signature of requests to the hook. It is not retrievable later.
+	Secret string `json:"secret"`
+}
+
+// @ID actionsCreateHook
+// @Summary Register an action hook
+// @Description Actions on nodes are posted to the hook in JSON, signed by the returned secret in the X-Dashboard-Signature header.
+// @Description All components are operated by the hook when components is empty.
+// @Param request body HookRequest true "Request body"
+// @Security JwtAuth
+// @Success 200 {object} CreateHookResponse
+// @Failure 400 {object} rest.ErrorResponse
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 403 {object} rest.ErrorResponse
+// @Router /actions/hooks [post]
+func (s *Service) createHookHandler(c *gin.Context) {
+	var req HookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		rest.Error(c, rest.ErrBadRequest.NewWithNoMessage())
+		return
+	}
+	m := req.toModel()
+	m.CreatedBy = utils.GetSession(c).DisplayName
+	secret, err := s.CreateHook(m)
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, CreateHookResponse{Hook: m, Secret: secret})
+}
+
+// @ID actionsUpdateHook
+// @Summary Update an action hook
+// @Param id path integer true "Hook ID"
+// @Param request body HookRequest true "Request body"
+// @Security JwtAuth
+// @Success 200 {object} HookModel
+// @Failure 400 {object} rest.ErrorResponse
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 403 {object} rest.ErrorResponse
+// @Failure 404 {object} rest.ErrorResponse
+// @Router /actions/hooks/{id} [put]
+func (s *Service) updateHookHandler(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	var req HookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		rest.Error(c, rest.ErrBadRequest.NewWithNoMessage())
+		return
+	}
+	m := req.toModel()
+	m.ID = id
+	if err := s.UpdateHook(m); err != nil {
+		rest.Error(c, err)
+		return
+	}
+	updated, err := s.GetHook(id)
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, updated)
+}
+
+// @ID actionsDeleteHook
+// @Summary Delete an action hook
+// @Param id path integer true "Hook ID"
+// @Security JwtAuth
+// @Success 200 {string} string
+// @Failure 400 {object} rest.ErrorResponse
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 403 {object} rest.ErrorResponse
+// @Failure 404 {object} rest.ErrorResponse
+// @Router /actions/hooks/{id} [delete]
+func (s *Service) deleteHookHandler(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	found, err := s.DeleteHook(id)
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	if !found {
+		rest.Error(c, rest.ErrNotFound.New("hook %d not found", id))
+		return
+	}
+	c.JSON(http.StatusOK, nil)
+}
+
+// @ID actionsListAvailable
+// @Summary List actions available to nodes of a component
+// @Param component query string true "Component kind, e.g. tikv"
+// @Security JwtAuth
+// @Success 200 {array} AvailableAction
+// @Failure 401 {object} rest.ErrorResponse
+// @Router /actions/available [get]
+func (s *Service) listAvailableActionsHandler(c *gin.Context) {
+	actions, err := s.ListAvailableActions(topo.Kind(c.Query("component")))
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, actions)
+}
+
+type RunRequest struct {
+	HookID    uint       `json:"hook_id" binding:"required"`
+	Action    ActionType `json:"action" binding:"required"`
+	Component topo.Kind  `json:"component" binding:"required"`
+	Address   string     `json:"address" binding:"required"`
+}
+
+// @ID actionsRun
+// @Summary Run an action on a node by a hook
+// @Description The action is forwarded to the hook, and the response of the hook is returned.
+// @Description The node must be in the topology of the cluster selected in the session.
+// @Param request body RunRequest true "Request body"
+// @Security JwtAuth
+// @Success 200 {object} RunResult
+// @Failure 400 {object} rest.ErrorResponse
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 403 {object} rest.ErrorResponse
+// @Failure 404 {object} rest.ErrorResponse
+// @Failure 500 {object} rest.ErrorResponse
+// @Router /actions/run [post]
+func (s *Service) runHandler(c *gin.Context) {
+	var req RunRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		rest.Error(c, rest.ErrBadRequest.NewWithNoMessage())
+		return
+	}
+	r, err := s.Run(c.Request.Context(), cluster.GetClients(c), req.HookID, &Payload{
+		Action:      req.Action,
+		Component:   req.Component,
+		Address:     req.Address,
+		RequestedBy: utils.GetSession(c).DisplayName,
+	})
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, r)
+}
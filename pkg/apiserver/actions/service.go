@@ -0,0 +1,237 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+// Package actions forwards operations on nodes, e.g. restarting a TiKV node, to external hooks registered by the
+// deployment, since the dashboard itself is not able to manage processes.
+package actions
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/joomcode/errorx"
+	"go.uber.org/fx"
+
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/cluster"
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/clusterinfo"
+	"github.com/pingcap/tidb-dashboard/pkg/dbstore"
+	"github.com/pingcap/tidb-dashboard/pkg/httpc"
+	"github.com/pingcap/tidb-dashboard/util/rest"
+	"github.com/pingcap/tidb-dashboard/util/topo"
+)
+
+var (
+	ErrNS          = errorx.NewNamespace("error.api.actions")
+	ErrHookRequest = ErrNS.NewType("hook_request")
+)
+
+const (
+	// SignatureHeader carries the signature of the payload.
+	SignatureHeader = "X-Dashboard-Signature"
+
+	// hookTimeout is long enough for hooks performing the action synchronously, e.g. a rolling restart of a node.
+	hookTimeout        = 5 * time.Minute
+	maxResponseLength  = 4096
+	secretLengthInByte = 32
+)
+
+type ServiceParams struct {
+	fx.In
+	LocalStore  *dbstore.DB
+	HTTPClient  *httpc.Client
+	ClusterInfo *clusterinfo.Service
+	Registry    *cluster.Registry
+}
+
+type Service struct {
+	params ServiceParams
+	now    func() time.Time
+	// hasNode returns whether the node is in the topology, so that actions are only run on known nodes.
+	hasNode func(ctx context.Context, clients *cluster.Clients, kind topo.Kind, address string) (bool, error)
+}
+
+func newService(p ServiceParams) (*Service, error) {
+	if err := p.LocalStore.AutoMigrate(&HookModel{}); err != nil {
+		return nil, err
+	}
+	return &Service{params: p, now: time.Now, hasNode: p.ClusterInfo.HasNode}, nil
+}
+
+var Module = fx.Options(
+	fx.Provide(newService),
+	fx.Invoke(registerRouter),
+)
+
+func validateHook(m *HookModel) error {
+	if m.Name == "" {
+		return rest.ErrBadRequest.New("name is required")
+	}
+	u, err := url.Parse(m.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return rest.ErrBadRequest.New("invalid hook url %s", m.URL)
+	}
+	if len(m.Actions) == 0 {
+		return rest.ErrBadRequest.New("actions are required")
+	}
+	for _, a := range m.Actions {
+		if _, ok := supportedActions[a]; !ok {
+			return rest.ErrBadRequest.New("unsupported action %s", a)
+		}
+	}
+	for _, c := range m.Components {
+		if _, ok := supportedComponents[c]; !ok {
+			return rest.ErrBadRequest.New("unsupported component %s", c)
+		}
+	}
+	return nil
+}
+
+// CreateHook registers the hook with a new secret, which is returned only once.
+func (s *Service) CreateHook(m *HookModel) (string, error) {
+	if err := validateHook(m); err != nil {
+		return "", err
+	}
+	b := make([]byte, secretLengthInByte)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	m.ID = 0
+	m.Secret = hex.EncodeToString(b)
+	m.CreatedAt = s.now()
+	m.LastRunAt = time.Time{}
+	m.LastRunError = ""
+	if err := s.params.LocalStore.Create(m).Error; err != nil {
+		return "", err
+	}
+	return m.Secret, nil
+}
+
+// UpdateHook replaces the name, URL, actions and components of an existing hook. The secret is kept.
+func (s *Service) UpdateHook(m *HookModel) error {
+	if err := validateHook(m); err != nil {
+		return err
+	}
+	result := s.params.LocalStore.Model(&HookModel{}).Where("id = ?", m.ID).Updates(map[string]interface{}{
+		"name":       m.Name,
+		"url":        m.URL,
+		"actions":    m.Actions,
+		"components": m.Components,
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return rest.ErrNotFound.New("hook %d not found", m.ID)
+	}
+	return nil
+}
+
+func (s *Service) GetHook(id uint) (*HookModel, error) {
+	var hooks []HookModel
+	if err := s.params.LocalStore.Where("id = ?", id).Find(&hooks).Error; err != nil {
+		return nil, err
+	}
+	if len(hooks) == 0 {
+		return nil, rest.ErrNotFound.New("hook %d not found", id)
+	}
+	return &hooks[0], nil
+}
+
+func (s *Service) ListHooks() ([]HookModel, error) {
+	var hooks []HookModel
+	if err := s.params.LocalStore.Order("id").Find(&hooks).Error; err != nil {
+		return nil, err
+	}
+	return hooks, nil
+}
+
+// DeleteHook returns false if the hook does not exist.
+func (s *Service) DeleteHook(id uint) (bool, error) {
+	result := s.params.LocalStore.Where("id = ?", id).Delete(&HookModel{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// ListAvailableActions lists the actions of all hooks available to nodes of the component.
+func (s *Service) ListAvailableActions(component topo.Kind) ([]AvailableAction, error) {
+	hooks, err := s.ListHooks()
+	if err != nil {
+		return nil, err
+	}
+	actions := make([]AvailableAction, 0)
+	for i := range hooks {
+		h := &hooks[i]
+		for _, a := range h.Actions {
+			if h.supports(a, component) {
+				actions = append(actions, AvailableAction{HookID: h.ID, HookName: h.Name, Action: a})
+			}
+		}
+	}
+	return actions, nil
+}
+
+func sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Run forwards the action on the node of the cluster to the hook, and records the result in the hook. The node must
+// be in the topology of the cluster.
+func (s *Service) Run(ctx context.Context, clients *cluster.Clients, hookID uint, p *Payload) (*RunResult, error) {
+	h, err := s.GetHook(hookID)
+	if err != nil {
+		return nil, err
+	}
+	if !h.supports(p.Action, p.Component) {
+		return nil, rest.ErrBadRequest.New("hook %s does not support %s on %s nodes", h.Name, p.Action, p.Component)
+	}
+	found, err := s.hasNode(ctx, clients, p.Component, p.Address)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, rest.ErrBadRequest.New("%s node %s is not in the topology", p.Component, p.Address)
+	}
+	p.ClusterID = clients.ClusterID
+	p.PDEndpoint = clients.PDEndpoint
+	p.RequestedAt = s.now()
+	body, err := json.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, runErr := s.params.HTTPClient.
+		WithTimeout(hookTimeout).
+		CloneAndAddRequestHeader("Content-Type", "application/json").
+		CloneAndAddRequestHeader(SignatureHeader, sign(h.Secret, body)).
+		SendRequest(ctx, h.URL, http.MethodPost, bytes.NewReader(body), ErrHookRequest, "action hook")
+
+	lastRunError := ""
+	if runErr != nil {
+		lastRunError = runErr.Error()
+	}
+	err = s.params.LocalStore.Model(&HookModel{}).Where("id = ?", h.ID).Updates(map[string]interface{}{
+		"last_run_at":    p.RequestedAt,
+		"last_run_error": lastRunError,
+	}).Error
+	if runErr != nil {
+		return nil, runErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(resp) > maxResponseLength {
+		resp = resp[:maxResponseLength]
+	}
+	return &RunResult{HookID: h.ID, Response: string(resp)}, nil
+}
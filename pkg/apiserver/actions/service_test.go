@@ -0,0 +1,181 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package actions
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/joomcode/errorx"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/fx/fxtest"
+
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/cluster"
+	"github.com/pingcap/tidb-dashboard/pkg/config"
+	"github.com/pingcap/tidb-dashboard/pkg/dbstore/dbstoretest"
+	"github.com/pingcap/tidb-dashboard/pkg/httpc"
+	"github.com/pingcap/tidb-dashboard/util/rest"
+	"github.com/pingcap/tidb-dashboard/util/topo"
+)
+
+func newTestService(t *testing.T) *Service {
+	s, err := newService(ServiceParams{
+		LocalStore: dbstoretest.New(t),
+		HTTPClient: httpc.NewHTTPClient(fxtest.NewLifecycle(t), &config.Config{}),
+	})
+	require.NoError(t, err)
+	s.hasNode = func(ctx context.Context, clients *cluster.Clients, kind topo.Kind, address string) (bool, error) {
+		// Nodes of registered clusters are not in the topology of the default cluster.
+		return address != "10.0.1.8:20160" && (clients.IsDefault() || address == "10.0.2.1:20160"), nil
+	}
+	return s
+}
+
+func TestHookValidation(t *testing.T) {
+	s := newTestService(t)
+
+	for _, m := range []*HookModel{
+		{URL: "https://example.com/hook", Actions: ActionTypeList{ActionRestart}},
+		{Name: "a", URL: "example.com/hook", Actions: ActionTypeList{ActionRestart}},
+		{Name: "a", URL: "https://example.com/hook"},
+		{Name: "a", URL: "https://example.com/hook", Actions: ActionTypeList{"scale_out"}},
+		{Name: "a", URL: "https://example.com/hook", Actions: ActionTypeList{ActionRestart}, Components: ComponentList{topo.KindGrafana}},
+	} {
+		_, err := s.CreateHook(m)
+		require.True(t, errorx.IsOfType(err, rest.ErrBadRequest))
+	}
+
+	m := &HookModel{Name: "tiup", URL: "https://example.com/hook", Actions: ActionTypeList{ActionRestart}}
+	secret, err := s.CreateHook(m)
+	require.NoError(t, err)
+	require.Len(t, secret, 64)
+
+	m.Actions = ActionTypeList{ActionRestart, ActionEvict}
+	m.Components = ComponentList{topo.KindTiKV}
+	require.NoError(t, s.UpdateHook(m))
+	hooks, err := s.ListHooks()
+	require.NoError(t, err)
+	require.Len(t, hooks, 1)
+	require.Equal(t, ActionTypeList{ActionRestart, ActionEvict}, hooks[0].Actions)
+	require.Equal(t, ComponentList{topo.KindTiKV}, hooks[0].Components)
+	// The secret is kept but not exposed.
+	require.Equal(t, secret, hooks[0].Secret)
+	b, err := json.Marshal(hooks[0])
+	require.NoError(t, err)
+	require.NotContains(t, string(b), secret)
+
+	m.ID = 100
+	require.True(t, errorx.IsOfType(s.UpdateHook(m), rest.ErrNotFound))
+	found, err := s.DeleteHook(100)
+	require.NoError(t, err)
+	require.False(t, found)
+}
+
+func TestListAvailableActions(t *testing.T) {
+	s := newTestService(t)
+	_, err := s.CreateHook(&HookModel{Name: "tiup", URL: "https://example.com/tiup", Actions: ActionTypeList{ActionRestart, ActionKill}})
+	require.NoError(t, err)
+	_, err = s.CreateHook(&HookModel{Name: "evict", URL: "https://example.com/evict", Actions: ActionTypeList{ActionEvict}, Components: ComponentList{topo.KindTiKV}})
+	require.NoError(t, err)
+
+	actions, err := s.ListAvailableActions(topo.KindTiKV)
+	require.NoError(t, err)
+	require.Equal(t, []AvailableAction{
+		{HookID: 1, HookName: "tiup", Action: ActionRestart},
+		{HookID: 1, HookName: "tiup", Action: ActionKill},
+		{HookID: 2, HookName: "evict", Action: ActionEvict},
+	}, actions)
+
+	actions, err = s.ListAvailableActions(topo.KindTiDB)
+	require.NoError(t, err)
+	require.Len(t, actions, 2)
+}
+
+var defaultClients = &cluster.Clients{ClusterID: cluster.DefaultClusterID, PDEndpoint: "http://127.0.0.1:2379"}
+
+func TestRun(t *testing.T) {
+	var receivedBody []byte
+	var signature string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedBody, _ = io.ReadAll(r.Body)
+		signature = r.Header.Get(SignatureHeader)
+		var received Payload
+		_ = json.Unmarshal(receivedBody, &received)
+		if received.Address == "10.0.1.9:20160" {
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte("node not found"))
+			return
+		}
+		_, _ = w.Write([]byte(`{"task":"restart-1"}`))
+	}))
+	defer ts.Close()
+
+	s := newTestService(t)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+	m := &HookModel{Name: "tiup", URL: ts.URL + "/hook", Actions: ActionTypeList{ActionRestart}, Components: ComponentList{topo.KindTiKV}}
+	secret, err := s.CreateHook(m)
+	require.NoError(t, err)
+
+	r, err := s.Run(context.Background(), defaultClients, m.ID, &Payload{Action: ActionRestart, Component: topo.KindTiKV, Address: "10.0.1.1:20160", RequestedBy: "root"})
+	require.NoError(t, err)
+	require.Equal(t, &RunResult{HookID: m.ID, Response: `{"task":"restart-1"}`}, r)
+	var received Payload
+	require.NoError(t, json.Unmarshal(receivedBody, &received))
+	require.Equal(t, Payload{Action: ActionRestart, Component: topo.KindTiKV, Address: "10.0.1.1:20160", ClusterID: "default", PDEndpoint: "http://127.0.0.1:2379", RequestedBy: "root", RequestedAt: now}, received)
+	// The hook verifies the request by the secret.
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(receivedBody)
+	require.Equal(t, hex.EncodeToString(mac.Sum(nil)), signature)
+	h, err := s.GetHook(m.ID)
+	require.NoError(t, err)
+	require.True(t, now.Equal(h.LastRunAt))
+	require.Empty(t, h.LastRunError)
+
+	_, err = s.Run(context.Background(), defaultClients, m.ID, &Payload{Action: ActionRestart, Component: topo.KindTiKV, Address: "10.0.1.9:20160"})
+	require.True(t, errorx.IsOfType(err, ErrHookRequest))
+	h, err = s.GetHook(m.ID)
+	require.NoError(t, err)
+	require.Contains(t, h.LastRunError, "node not found")
+
+	_, err = s.Run(context.Background(), defaultClients, m.ID, &Payload{Action: ActionKill, Component: topo.KindTiKV, Address: "10.0.1.1:20160"})
+	require.True(t, errorx.IsOfType(err, rest.ErrBadRequest))
+	_, err = s.Run(context.Background(), defaultClients, m.ID, &Payload{Action: ActionRestart, Component: topo.KindTiDB, Address: "10.0.1.1:4000"})
+	require.True(t, errorx.IsOfType(err, rest.ErrBadRequest))
+	_, err = s.Run(context.Background(), defaultClients, 100, &Payload{Action: ActionRestart, Component: topo.KindTiKV})
+	require.True(t, errorx.IsOfType(err, rest.ErrNotFound))
+	// Nodes not in the topology are rejected without calling the hook.
+	receivedBody = nil
+	_, err = s.Run(context.Background(), defaultClients, m.ID, &Payload{Action: ActionRestart, Component: topo.KindTiKV, Address: "10.0.1.8:20160"})
+	require.True(t, errorx.IsOfType(err, rest.ErrBadRequest))
+	require.Nil(t, receivedBody)
+
+	// Nodes are validated against the topology of the selected cluster.
+	otherClients := &cluster.Clients{ClusterID: "c1", PDEndpoint: "http://10.0.2.1:2379"}
+	_, err = s.Run(context.Background(), otherClients, m.ID, &Payload{Action: ActionRestart, Component: topo.KindTiKV, Address: "10.0.1.1:20160"})
+	require.True(t, errorx.IsOfType(err, rest.ErrBadRequest))
+	require.Nil(t, receivedBody)
+	_, err = s.Run(context.Background(), otherClients, m.ID, &Payload{Action: ActionRestart, Component: topo.KindTiKV, Address: "10.0.2.1:20160"})
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(receivedBody, &received))
+	require.Equal(t, "c1", received.ClusterID)
+	require.Equal(t, "http://10.0.2.1:2379", received.PDEndpoint)
+}
+
+func TestMaskHook(t *testing.T) {
+	m := HookModel{
+		URL:          "https://tiup.example.com/hook?token=abc",
+		LastRunError: `Post "https://tiup.example.com/hook?token=abc": EOF`,
+	}
+	m.mask()
+	require.Equal(t, "https://tiup.example.com/******", m.URL)
+	require.Equal(t, `Post "https://tiup.example.com/******": EOF`, m.LastRunError)
+}
@@ -11,23 +11,42 @@ import (
 	"github.com/gin-contrib/gzip"
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
+	"github.com/pingcap/log"
 	cors "github.com/rs/cors/wrapper/gin"
 	"go.uber.org/fx"
+	"go.uber.org/zap"
 
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/actions"
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/audit"
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/backup"
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/cluster"
 	"github.com/pingcap/tidb-dashboard/pkg/apiserver/clusterinfo"
 	"github.com/pingcap/tidb-dashboard/pkg/apiserver/configuration"
 	"github.com/pingcap/tidb-dashboard/pkg/apiserver/conprof"
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/ddl"
 	"github.com/pingcap/tidb-dashboard/pkg/apiserver/deadlock"
 	"github.com/pingcap/tidb-dashboard/pkg/apiserver/debugapi"
 	"github.com/pingcap/tidb-dashboard/pkg/apiserver/diagnose"
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/dxf"
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/hotregion"
 	"github.com/pingcap/tidb-dashboard/pkg/apiserver/info"
 	"github.com/pingcap/tidb-dashboard/pkg/apiserver/logsearch"
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/memory"
 	"github.com/pingcap/tidb-dashboard/pkg/apiserver/metrics"
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/notification"
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/pdmanage"
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/placement"
 	"github.com/pingcap/tidb-dashboard/pkg/apiserver/profiling"
 	"github.com/pingcap/tidb-dashboard/pkg/apiserver/queryeditor"
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/region"
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/requestlog"
+	apiticdc "github.com/pingcap/tidb-dashboard/pkg/apiserver/ticdc"
 	"github.com/pingcap/tidb-dashboard/pkg/apiserver/topsql"
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/transaction"
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/user/apikey"
 	"github.com/pingcap/tidb-dashboard/pkg/apiserver/user/code"
 	"github.com/pingcap/tidb-dashboard/pkg/apiserver/user/code/codeauth"
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/user/session"
 	"github.com/pingcap/tidb-dashboard/pkg/apiserver/user/sqlauth"
 	"github.com/pingcap/tidb-dashboard/pkg/apiserver/user/sso"
 	"github.com/pingcap/tidb-dashboard/pkg/apiserver/user/sso/ssoauth"
@@ -51,6 +70,10 @@ import (
 	resourcemanager "github.com/pingcap/tidb-dashboard/pkg/apiserver/resource_manager"
 	"github.com/pingcap/tidb-dashboard/pkg/apiserver/slowquery"
 	"github.com/pingcap/tidb-dashboard/pkg/apiserver/statement"
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/statement/history"
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/statement/watch"
+	storagemanager "github.com/pingcap/tidb-dashboard/pkg/apiserver/storage_manager"
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/timeline"
 	"github.com/pingcap/tidb-dashboard/pkg/apiserver/user"
 	apiutils "github.com/pingcap/tidb-dashboard/pkg/apiserver/utils"
 	"github.com/pingcap/tidb-dashboard/pkg/config"
@@ -111,9 +134,9 @@ var Modules = fx.Options(
 		newClients,
 		dbstore.NewDBStore,
 		httpc.NewHTTPClient,
-		pd.NewEtcdClient,
+		pd.NewEtcdClientManager,
 		pd.NewPDClient,
-		config.NewDynamicConfigManager,
+		newDynamicConfigManager,
 		tidb.NewTiDBClient,
 		tikv.NewTiKVClient,
 		tiflash.NewTiFlashClient,
@@ -132,11 +155,20 @@ var Modules = fx.Options(
 		// __APP_NAME__.NewService,
 		// NOTE: Don't remove above comment line, it is a placeholder for code generator
 	),
+	requestlog.Module,
 	user.Module,
+	audit.Module,
 	codeauth.Module,
 	sqlauth.Module,
 	ssoauth.Module,
 	code.Module,
+	apikey.Module,
+	session.Module,
+	cluster.Module,
+	notification.Module,
+	actions.Module,
+	watch.Module,
+	history.Module,
 	sso.Module,
 	profiling.Module,
 	conprof.Module,
@@ -146,7 +178,19 @@ var Modules = fx.Options(
 	topsql.Module,
 	visualplan.Module,
 	deadlock.Module,
+	ddl.Module,
+	dxf.Module,
+	region.Module,
+	hotregion.Module,
+	pdmanage.Module,
+	placement.Module,
+	apiticdc.Module,
+	backup.Module,
 	resourcemanager.Module,
+	storagemanager.Module,
+	timeline.Module,
+	transaction.Module,
+	memory.Module,
 )
 
 func (s *Service) Start(ctx context.Context) error {
@@ -156,9 +200,12 @@ func (s *Service) Start(ctx context.Context) error {
 
 	s.ctx, s.cancel = context.WithCancel(ctx)
 
+	featureFlags := featureflag.NewRegistry(s.config.FeatureVersion)
+	featureFlags.Disable(s.config.DisabledFeatures...)
+
 	s.app = fx.New(
 		fx.Logger(utils.NewFxPrinter()),
-		fx.Supply(featureflag.NewRegistry(s.config.FeatureVersion)),
+		fx.Supply(featureFlags),
 		Modules,
 		fx.Provide(
 			s.provideLocals,
@@ -221,6 +268,12 @@ func newClients(lc fx.Lifecycle, config *config.Config) (
 	return
 }
 
+// newDynamicConfigManager provides the dynamic config manager with the current etcd client, as the config
+// package can not depend on the pd package.
+func newDynamicConfigManager(lc fx.Lifecycle, cfg *config.Config, etcdClients *pd.EtcdClientManager) *config.DynamicConfigManager {
+	return config.NewDynamicConfigManager(lc, cfg, etcdClients.Client)
+}
+
 func (s *Service) cleanAfterError() {
 	s.cancel()
 
@@ -260,10 +313,36 @@ func (s *Service) provideLocals() (*config.Config, http.FileSystem, *keyvisualre
 	return s.config, s.uiAssetFS, s.customKeyVisualProvider
 }
 
-func newAPIHandlerEngine() (apiHandlerEngine *gin.Engine, endpoint *gin.RouterGroup) {
+func newCORSHandler(allowedOrigins []string) gin.HandlerFunc {
+	if len(allowedOrigins) == 0 {
+		return cors.AllowAll()
+	}
+	return cors.New(cors.Options{
+		AllowedOrigins: allowedOrigins,
+		AllowedMethods: []string{
+			http.MethodHead,
+			http.MethodGet,
+			http.MethodPost,
+			http.MethodPut,
+			http.MethodPatch,
+			http.MethodDelete,
+		},
+		AllowedHeaders:   []string{"*"},
+		AllowCredentials: true,
+	})
+}
+
+func newAPIHandlerEngine(cfg *config.Config, recorder *requestlog.Recorder) (apiHandlerEngine *gin.Engine, endpoint *gin.RouterGroup) {
 	apiHandlerEngine = gin.New()
+	// The client IP is used by sign in limits and audit logs, so that forwarded headers are only trusted from the
+	// configured proxies.
+	if err := apiHandlerEngine.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		log.Warn("Invalid trusted proxies, no proxy is trusted", zap.Strings("proxies", cfg.TrustedProxies), zap.Error(err))
+		_ = apiHandlerEngine.SetTrustedProxies(nil)
+	}
 	apiHandlerEngine.Use(gin.Recovery())
-	apiHandlerEngine.Use(cors.AllowAll())
+	apiHandlerEngine.Use(recorder.MWRecord())
+	apiHandlerEngine.Use(newCORSHandler(cfg.CORSAllowedOrigins))
 	apiHandlerEngine.Use(gzip.Gzip(gzip.DefaultCompression))
 	apiHandlerEngine.Use(rest.ErrorHandlerFn())
 
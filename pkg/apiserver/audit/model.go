@@ -0,0 +1,69 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package audit
+
+import (
+	"time"
+
+	"github.com/pingcap/tidb-dashboard/pkg/dbstore"
+)
+
+type EntryModel struct {
+	ID     uint      `json:"id" gorm:"primary_key"`
+	Time   time.Time `json:"time" gorm:"index"`
+	User   string    `json:"user" gorm:"index"`
+	Action string    `json:"action" gorm:"index"`
+	Method string    `json:"method"`
+	Path   string    `json:"path"`
+	// Params is a JSON object containing path params, query and the request body.
+	Params     string `json:"params"`
+	StatusCode int    `json:"status_code"`
+	Error      string `json:"error,omitempty"`
+}
+
+func (EntryModel) TableName() string {
+	return "audit_entries"
+}
+
+func autoMigrate(db *dbstore.DB) error {
+	return db.AutoMigrate(&EntryModel{})
+}
+
+type ListFilter struct {
+	User   string `json:"user" form:"user"`
+	Action string `json:"action" form:"action"`
+	// BeginTime and EndTime are unix seconds. Zero means unbounded.
+	BeginTime int64 `json:"begin_time" form:"begin_time"`
+	EndTime   int64 `json:"end_time" form:"end_time"`
+	Limit     int   `json:"limit" form:"limit"`
+	Offset    int   `json:"offset" form:"offset"`
+}
+
+func listEntries(db *dbstore.DB, f ListFilter) ([]EntryModel, error) {
+	tx := db.Order("id DESC")
+	if f.User != "" {
+		tx = tx.Where("user = ?", f.User)
+	}
+	if f.Action != "" {
+		tx = tx.Where("action = ?", f.Action)
+	}
+	if f.BeginTime > 0 {
+		tx = tx.Where("time >= ?", time.Unix(f.BeginTime, 0))
+	}
+	if f.EndTime > 0 {
+		tx = tx.Where("time <= ?", time.Unix(f.EndTime, 0))
+	}
+	if f.Limit > 0 {
+		tx = tx.Limit(f.Limit)
+	}
+	if f.Offset > 0 {
+		tx = tx.Offset(f.Offset)
+	}
+	entries := make([]EntryModel, 0)
+	err := tx.Find(&entries).Error
+	return entries, err
+}
+
+func deleteEntriesBefore(db *dbstore.DB, t time.Time) error {
+	return db.Where("time < ?", t).Delete(&EntryModel{}).Error
+}
@@ -0,0 +1,159 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pingcap/log"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/utils"
+	"github.com/pingcap/tidb-dashboard/pkg/dbstore"
+	"github.com/pingcap/tidb-dashboard/util/rest"
+)
+
+const (
+	// Entries older than this are removed when the service starts.
+	entryRetention = 90 * 24 * time.Hour
+	// Request bodies longer than this are truncated in the audit trail.
+	maxRecordedBodySize = 4096
+	// Values of redacted fields are recorded as this.
+	redactedValue = "******"
+)
+
+type Service struct {
+	db  *dbstore.DB
+	now func() time.Time
+}
+
+func NewService(db *dbstore.DB) *Service {
+	if err := autoMigrate(db); err != nil {
+		log.Fatal("Failed to initialize database", zap.Error(err))
+	}
+	s := &Service{db: db, now: time.Now}
+	if err := deleteEntriesBefore(db, s.now().Add(-entryRetention)); err != nil {
+		log.Warn("Failed to remove outdated audit entries", zap.Error(err))
+	}
+	return s
+}
+
+var Module = fx.Options(
+	fx.Provide(NewService),
+	fx.Invoke(registerRouter),
+)
+
+// ListEntries returns the audit entries matching the filter, latest first.
+func (s *Service) ListEntries(f ListFilter) ([]EntryModel, error) {
+	return listEntries(s.db, f)
+}
+
+type recordedParams struct {
+	Params map[string]string   `json:"params,omitempty"`
+	Query  map[string][]string `json:"query,omitempty"`
+	Body   string              `json:"body,omitempty"`
+}
+
+// BodyRedactor returns the request body to be recorded, e.g. with secrets removed. The returned body must not
+// share the memory of the original body, which is still to be read by the handler.
+type BodyRedactor func(body []byte) []byte
+
+// RedactJSONFields returns a redactor replacing values of the fields at any depth of a JSON body. The body is
+// dropped when it is not valid JSON, so that secrets are never recorded.
+func RedactJSONFields(fields ...string) BodyRedactor {
+	redacted := make(map[string]struct{}, len(fields))
+	for _, f := range fields {
+		redacted[f] = struct{}{}
+	}
+	var redact func(v interface{}) interface{}
+	redact = func(v interface{}) interface{} {
+		switch v := v.(type) {
+		case map[string]interface{}:
+			for k, child := range v {
+				if _, ok := redacted[k]; ok {
+					v[k] = redactedValue
+				} else {
+					v[k] = redact(child)
+				}
+			}
+		case []interface{}:
+			for i, child := range v {
+				v[i] = redact(child)
+			}
+		}
+		return v
+	}
+	return func(body []byte) []byte {
+		if len(body) == 0 {
+			return nil
+		}
+		var v interface{}
+		if err := json.Unmarshal(body, &v); err != nil {
+			return nil
+		}
+		b, err := json.Marshal(redact(v))
+		if err != nil {
+			return nil
+		}
+		return b
+	}
+}
+
+// MWRecord creates a middleware that records the request as the action in the audit trail, together with
+// the signed in user and the response status. It must be placed after the authentication middleware.
+// Redactors are applied to the request body in order before it is recorded.
+func (s *Service) MWRecord(action string, redactors ...BodyRedactor) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var body []byte
+		if c.Request.Body != nil {
+			var err error
+			body, err = io.ReadAll(c.Request.Body)
+			if err != nil {
+				log.Warn("Failed to read request body for auditing", zap.Error(err))
+			}
+			c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		}
+		for _, redact := range redactors {
+			body = redact(body)
+		}
+		if len(body) > maxRecordedBodySize {
+			body = body[:maxRecordedBodySize]
+		}
+
+		c.Next()
+
+		entry := EntryModel{
+			Time:       s.now(),
+			Action:     action,
+			Method:     c.Request.Method,
+			Path:       c.Request.URL.Path,
+			StatusCode: rest.ResponseStatus(c),
+		}
+		if u := utils.GetSession(c); u != nil {
+			entry.User = u.DisplayName
+		}
+		if err := c.Errors.Last(); err != nil {
+			entry.Error = err.Error()
+		}
+		params := recordedParams{
+			Params: make(map[string]string, len(c.Params)),
+			Query:  c.Request.URL.Query(),
+			Body:   string(body),
+		}
+		for _, p := range c.Params {
+			params.Params[p.Key] = p.Value
+		}
+		if b, err := json.Marshal(params); err == nil {
+			entry.Params = string(b)
+		}
+
+		if err := s.db.Create(&entry).Error; err != nil {
+			log.Warn("Failed to record audit entry", zap.String("action", action), zap.Error(err))
+		}
+	}
+}
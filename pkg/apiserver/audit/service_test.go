@@ -0,0 +1,116 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package audit
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/utils"
+	"github.com/pingcap/tidb-dashboard/pkg/dbstore/dbstoretest"
+	"github.com/pingcap/tidb-dashboard/util/rest"
+)
+
+func newTestService(t *testing.T) *Service {
+	return NewService(dbstoretest.New(t))
+}
+
+func TestRecord(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := newTestService(t)
+
+	engine := gin.New()
+	engine.Use(rest.ErrorHandlerFn())
+	engine.Use(func(c *gin.Context) {
+		c.Set(utils.SessionUserKey, &utils.SessionUser{DisplayName: "root"})
+	})
+	engine.DELETE("/topology/tidb/:address", s.MWRecord("topology.delete_tidb"), func(c *gin.Context) {
+		rest.Error(c, rest.ErrNotFound.New("not found"))
+	})
+	engine.POST("/configuration/edit", s.MWRecord("configuration.edit"), func(c *gin.Context) {
+		// The handler still receives the body after it is recorded.
+		body, _ := io.ReadAll(c.Request.Body)
+		c.String(http.StatusOK, string(body))
+	})
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/topology/tidb/10.0.1.1:4000", nil))
+	require.Equal(t, http.StatusNotFound, w.Code)
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/configuration/edit?dry=1", strings.NewReader(`{"id":"a"}`)))
+	require.Equal(t, `{"id":"a"}`, w.Body.String())
+
+	entries, err := listEntries(s.db, ListFilter{})
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	require.Equal(t, "configuration.edit", entries[0].Action)
+	require.Equal(t, "root", entries[0].User)
+	require.Equal(t, http.StatusOK, entries[0].StatusCode)
+	var params recordedParams
+	require.NoError(t, json.Unmarshal([]byte(entries[0].Params), &params))
+	require.Equal(t, `{"id":"a"}`, params.Body)
+	require.Equal(t, []string{"1"}, params.Query["dry"])
+
+	require.Equal(t, "topology.delete_tidb", entries[1].Action)
+	require.Equal(t, http.StatusNotFound, entries[1].StatusCode)
+	require.NotEmpty(t, entries[1].Error)
+	require.NoError(t, json.Unmarshal([]byte(entries[1].Params), &params))
+	require.Equal(t, "10.0.1.1:4000", params.Params["address"])
+
+	entries, err = listEntries(s.db, ListFilter{Action: "topology.delete_tidb"})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+
+	require.NoError(t, deleteEntriesBefore(s.db, time.Now().Add(time.Hour)))
+	entries, err = listEntries(s.db, ListFilter{})
+	require.NoError(t, err)
+	require.Empty(t, entries)
+}
+
+func TestRecordRedacted(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := newTestService(t)
+
+	engine := gin.New()
+	engine.PUT("/user/sso/config", s.MWRecord("user.sso.set_config", RedactJSONFields("client_secret")), func(c *gin.Context) {
+		// The handler still receives the secret.
+		body, _ := io.ReadAll(c.Request.Body)
+		c.String(http.StatusOK, string(body))
+	})
+
+	const body = `{"config":{"client_id":"dashboard","client_secret":"s3cr3t"}}`
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/user/sso/config", strings.NewReader(body)))
+	require.Equal(t, body, w.Body.String())
+	// Bodies that can not be redacted are not recorded.
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/user/sso/config", strings.NewReader(`client_secret=s3cr3t`)))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	entries, err := listEntries(s.db, ListFilter{})
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	var params recordedParams
+	require.NoError(t, json.Unmarshal([]byte(entries[0].Params), &params))
+	require.Empty(t, params.Body)
+	require.NoError(t, json.Unmarshal([]byte(entries[1].Params), &params))
+	require.JSONEq(t, `{"config":{"client_id":"dashboard","client_secret":"******"}}`, params.Body)
+	require.NotContains(t, entries[1].Params, "s3cr3t")
+}
+
+func TestRedactJSONFields(t *testing.T) {
+	redact := RedactJSONFields("url", "password")
+	require.JSONEq(t,
+		`{"name":"a","url":"******","items":[{"password":"******","user":"root"}]}`,
+		string(redact([]byte(`{"name":"a","url":"https://hooks/token","items":[{"password":"p","user":"root"}]}`))))
+	require.Nil(t, redact(nil))
+	require.Nil(t, redact([]byte(`{"url":`)))
+}
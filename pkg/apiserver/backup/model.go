@@ -0,0 +1,129 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package backup
+
+import (
+	"encoding/binary"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Keys written by BR for log backup tasks in PD etcd.
+const (
+	streamKeyPrefix        = "/tidb/br-stream/"
+	streamTaskInfoPrefix   = "/tidb/br-stream/info/"
+	streamCheckpointPrefix = "/tidb/br-stream/checkpoint/"
+	streamPausePrefix      = "/tidb/br-stream/pause/"
+	streamLastErrorPrefix  = "/tidb/br-stream/last-error/"
+
+	globalCheckpointKey = "central_global"
+)
+
+type LogBackupTask struct {
+	Name    string `json:"name"`
+	StartTS uint64 `json:"start_ts"`
+	EndTS   uint64 `json:"end_ts"`
+	Paused  bool   `json:"paused"`
+	// CheckpointTS is 0 when no checkpoint has been reported yet.
+	CheckpointTS   uint64    `json:"checkpoint_ts"`
+	CheckpointTime time.Time `json:"checkpoint_time"`
+	// LagSeconds is how far the checkpoint falls behind the current time.
+	LagSeconds float64  `json:"lag_seconds"`
+	LastErrors []string `json:"last_errors,omitempty"`
+}
+
+// SQLTask is a task submitted by the BACKUP or RESTORE statement, from `SHOW BACKUPS` or `SHOW RESTORES`.
+type SQLTask struct {
+	Kind          string  `json:"kind" gorm:"-"` // `backup` or `restore`
+	ID            int64   `json:"id" gorm:"column:Id"`
+	Destination   string  `json:"destination" gorm:"column:Destination"`
+	State         string  `json:"state" gorm:"column:State"`
+	Progress      float64 `json:"progress" gorm:"column:Progress"`
+	QueueTime     string  `json:"queue_time" gorm:"column:Queue_time"`
+	ExecutionTime string  `json:"execution_time" gorm:"column:Execution_time"`
+	FinishTime    string  `json:"finish_time" gorm:"column:Finish_time"`
+	Connection    int64   `json:"connection" gorm:"column:Connection"`
+	Message       string  `json:"message" gorm:"column:Message"`
+}
+
+// tsoToTime returns the physical time of a TSO.
+func tsoToTime(tso uint64) time.Time {
+	return time.UnixMilli(int64(tso >> 18))
+}
+
+// streamTaskInfo is the subset of `backuppb.StreamBackupTaskInfo`. The kvproto version
+// in use does not contain log backup messages, so they are decoded by field numbers.
+type streamTaskInfo struct {
+	StartTS uint64 // field 2
+	EndTS   uint64 // field 3
+	Name    string // field 4
+}
+
+func decodeStreamTaskInfo(data []byte) (*streamTaskInfo, error) {
+	info := &streamTaskInfo{}
+	err := walkProtoFields(data, func(num protowire.Number, v uint64, b []byte) {
+		switch num {
+		case 2:
+			info.StartTS = v
+		case 3:
+			info.EndTS = v
+		case 4:
+			info.Name = string(b)
+		}
+	})
+	return info, err
+}
+
+// decodeStreamBackupErrorMessage returns the `error_message` (field 3) of `backuppb.StreamBackupError`.
+func decodeStreamBackupErrorMessage(data []byte) (string, error) {
+	var message string
+	err := walkProtoFields(data, func(num protowire.Number, _ uint64, b []byte) {
+		if num == 3 {
+			message = string(b)
+		}
+	})
+	return message, err
+}
+
+// walkProtoFields calls fn with the value of each varint or bytes field. Other fields are skipped.
+func walkProtoFields(data []byte, fn func(num protowire.Number, v uint64, b []byte)) error {
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		data = data[n:]
+		switch typ {
+		case protowire.VarintType:
+			v, n := protowire.ConsumeVarint(data)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			fn(num, v, nil)
+			data = data[n:]
+		case protowire.BytesType:
+			b, n := protowire.ConsumeBytes(data)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			fn(num, 0, b)
+			data = data[n:]
+		default:
+			n := protowire.ConsumeFieldValue(num, typ, data)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			data = data[n:]
+		}
+	}
+	return nil
+}
+
+// decodeCheckpoint decodes a checkpoint TS, which is stored in big endian.
+func decodeCheckpoint(data []byte) (uint64, bool) {
+	if len(data) != 8 {
+		return 0, false
+	}
+	return binary.BigEndian.Uint64(data), true
+}
@@ -0,0 +1,187 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package backup
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/joomcode/errorx"
+	"github.com/pingcap/log"
+	"go.etcd.io/etcd/clientv3"
+	"go.etcd.io/etcd/mvcc/mvccpb"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/cluster"
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/user"
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/utils"
+	"github.com/pingcap/tidb-dashboard/pkg/pd"
+	"github.com/pingcap/tidb-dashboard/pkg/tidb"
+	"github.com/pingcap/tidb-dashboard/util/rest"
+)
+
+const etcdFetchTimeout = 5 * time.Second
+
+var (
+	ErrNS          = errorx.NewNamespace("error.api.backup")
+	ErrEtcdRequest = ErrNS.NewType("etcd_request_failed")
+	ErrQueryFailed = ErrNS.NewType("query_failed")
+)
+
+// sqlTaskStatements lists tasks of each kind of statement.
+var sqlTaskStatements = []struct {
+	kind string
+	stmt string
+}{
+	{"backup", "SHOW BACKUPS"},
+	{"restore", "SHOW RESTORES"},
+}
+
+type ServiceParams struct {
+	fx.In
+	EtcdClients *pd.EtcdClientManager
+	TiDBClient  *tidb.Client
+	Registry    *cluster.Registry
+}
+
+type Service struct {
+	params       ServiceParams
+	lifecycleCtx context.Context
+}
+
+func newService(lc fx.Lifecycle, p ServiceParams) *Service {
+	s := &Service{params: p}
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			s.lifecycleCtx = ctx
+			return nil
+		},
+	})
+	return s
+}
+
+func registerRouter(r *gin.RouterGroup, auth *user.AuthService, s *Service) {
+	endpoint := r.Group("/backup")
+	endpoint.Use(auth.MWAuthRequired(), s.params.Registry.MWResolveCluster())
+	{
+		endpoint.GET("/log_tasks", s.getLogBackupTasks)
+		endpoint.GET("/sql_tasks", utils.MWConnectTiDB(s.params.TiDBClient), s.getSQLTasks)
+	}
+}
+
+// @ID backupGetLogTasks
+// @Summary List log backup tasks with their checkpoint and lag
+// @Success 200 {array} LogBackupTask
+// @Router /backup/log_tasks [get]
+// @Security JwtAuth
+// @Failure 401 {object} rest.ErrorResponse
+func (s *Service) getLogBackupTasks(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(s.lifecycleCtx, etcdFetchTimeout)
+	defer cancel()
+	resp, err := cluster.EtcdClientOf(c, s.params.EtcdClients).Get(ctx, streamKeyPrefix, clientv3.WithPrefix())
+	if err != nil {
+		rest.Error(c, ErrEtcdRequest.Wrap(err, "failed to get log backup tasks"))
+		return
+	}
+	c.JSON(http.StatusOK, buildLogBackupTasks(resp.Kvs, time.Now()))
+}
+
+// buildLogBackupTasks assembles log backup tasks from the keys under the log backup prefix.
+func buildLogBackupTasks(kvs []*mvccpb.KeyValue, now time.Time) []LogBackupTask {
+	tasks := make(map[string]*LogBackupTask)
+	globalCheckpoints := make(map[string]uint64)
+	storeCheckpoints := make(map[string][]uint64)
+	paused := make(map[string]bool)
+	lastErrors := make(map[string][]string)
+
+	for _, kv := range kvs {
+		key := string(kv.Key)
+		switch {
+		case strings.HasPrefix(key, streamTaskInfoPrefix):
+			info, err := decodeStreamTaskInfo(kv.Value)
+			if err != nil {
+				log.Warn("Ignored invalid log backup task info", zap.String("key", key), zap.Error(err))
+				continue
+			}
+			name := info.Name
+			if name == "" {
+				name = strings.TrimPrefix(key, streamTaskInfoPrefix)
+			}
+			tasks[name] = &LogBackupTask{Name: name, StartTS: info.StartTS, EndTS: info.EndTS}
+		case strings.HasPrefix(key, streamCheckpointPrefix):
+			// Keys are `<task>/central_global` or `<task>/store/<store_id>`.
+			parts := strings.SplitN(strings.TrimPrefix(key, streamCheckpointPrefix), "/", 2)
+			ts, ok := decodeCheckpoint(kv.Value)
+			if len(parts) != 2 || !ok {
+				continue
+			}
+			if parts[1] == globalCheckpointKey {
+				globalCheckpoints[parts[0]] = ts
+			} else if strings.HasPrefix(parts[1], "store/") {
+				storeCheckpoints[parts[0]] = append(storeCheckpoints[parts[0]], ts)
+			}
+		case strings.HasPrefix(key, streamPausePrefix):
+			paused[strings.TrimPrefix(key, streamPausePrefix)] = true
+		case strings.HasPrefix(key, streamLastErrorPrefix):
+			task := strings.SplitN(strings.TrimPrefix(key, streamLastErrorPrefix), "/", 2)[0]
+			message, err := decodeStreamBackupErrorMessage(kv.Value)
+			if err != nil || message == "" {
+				continue
+			}
+			lastErrors[task] = append(lastErrors[task], message)
+		}
+	}
+
+	result := make([]LogBackupTask, 0, len(tasks))
+	for name, task := range tasks {
+		checkpoint, ok := globalCheckpoints[name]
+		if !ok {
+			// Fallback to the slowest store for tasks without the global checkpoint.
+			for _, ts := range storeCheckpoints[name] {
+				if checkpoint == 0 || ts < checkpoint {
+					checkpoint = ts
+				}
+			}
+		}
+		if checkpoint > 0 {
+			task.CheckpointTS = checkpoint
+			task.CheckpointTime = tsoToTime(checkpoint)
+			task.LagSeconds = now.Sub(task.CheckpointTime).Seconds()
+		}
+		task.Paused = paused[name]
+		task.LastErrors = lastErrors[name]
+		result = append(result, *task)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// @ID backupGetSQLTasks
+// @Summary List tasks of BACKUP and RESTORE statements
+// @Success 200 {array} SQLTask
+// @Router /backup/sql_tasks [get]
+// @Security JwtAuth
+// @Failure 401 {object} rest.ErrorResponse
+func (s *Service) getSQLTasks(c *gin.Context) {
+	db := utils.GetTiDBConnection(c)
+	result := make([]SQLTask, 0)
+	for _, st := range sqlTaskStatements {
+		var tasks []SQLTask
+		if err := db.Raw(st.stmt).Scan(&tasks).Error; err != nil {
+			rest.Error(c, ErrQueryFailed.Wrap(err, "failed to list %s tasks", st.kind))
+			return
+		}
+		for _, t := range tasks {
+			t.Kind = st.kind
+			result = append(result, t)
+		}
+	}
+	c.JSON(http.StatusOK, result)
+}
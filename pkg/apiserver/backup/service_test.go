@@ -0,0 +1,67 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package backup
+
+import (
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/etcd/mvcc/mvccpb"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func encodeTaskInfo(name string, startTS uint64) []byte {
+	var b []byte
+	// storage, which should be skipped.
+	b = protowire.AppendTag(b, 1, protowire.BytesType)
+	b = protowire.AppendBytes(b, []byte{0x0a, 0x01, 0x78})
+	b = protowire.AppendTag(b, 2, protowire.VarintType)
+	b = protowire.AppendVarint(b, startTS)
+	b = protowire.AppendTag(b, 4, protowire.BytesType)
+	b = protowire.AppendString(b, name)
+	return b
+}
+
+func encodeTS(ts uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, ts)
+	return b
+}
+
+func TestBuildLogBackupTasks(t *testing.T) {
+	checkpoint := time.UnixMilli(1700000000000)
+	tso := func(t time.Time) uint64 { return uint64(t.UnixMilli()) << 18 }
+
+	var errMsg []byte
+	errMsg = protowire.AppendTag(errMsg, 3, protowire.BytesType)
+	errMsg = protowire.AppendString(errMsg, "failed to flush")
+
+	kvs := []*mvccpb.KeyValue{
+		{Key: []byte("/tidb/br-stream/info/task-b"), Value: encodeTaskInfo("task-b", 100)},
+		{Key: []byte("/tidb/br-stream/info/task-a"), Value: encodeTaskInfo("task-a", 200)},
+		{Key: []byte("/tidb/br-stream/info/broken"), Value: []byte{0xff}},
+		{Key: []byte("/tidb/br-stream/checkpoint/task-a/central_global"), Value: encodeTS(tso(checkpoint))},
+		{Key: []byte("/tidb/br-stream/checkpoint/task-a/store/1"), Value: encodeTS(1)},
+		{Key: []byte("/tidb/br-stream/checkpoint/task-b/store/1"), Value: encodeTS(tso(checkpoint))},
+		{Key: []byte("/tidb/br-stream/checkpoint/task-b/store/2"), Value: encodeTS(tso(checkpoint.Add(-time.Minute)))},
+		{Key: []byte("/tidb/br-stream/pause/task-b"), Value: []byte{}},
+		{Key: []byte("/tidb/br-stream/last-error/task-b/2"), Value: errMsg},
+	}
+	tasks := buildLogBackupTasks(kvs, checkpoint.Add(10*time.Second))
+	require.Len(t, tasks, 2)
+
+	require.Equal(t, "task-a", tasks[0].Name)
+	require.Equal(t, uint64(200), tasks[0].StartTS)
+	require.Equal(t, tso(checkpoint), tasks[0].CheckpointTS)
+	require.Equal(t, 10.0, tasks[0].LagSeconds)
+	require.False(t, tasks[0].Paused)
+	require.Empty(t, tasks[0].LastErrors)
+
+	// The slowest store is used without the global checkpoint.
+	require.Equal(t, "task-b", tasks[1].Name)
+	require.Equal(t, 70.0, tasks[1].LagSeconds)
+	require.True(t, tasks[1].Paused)
+	require.Equal(t, []string{"failed to flush"}, tasks[1].LastErrors)
+}
@@ -0,0 +1,287 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+// Package cluster manages the clusters registered to the dashboard, so that one dashboard can serve several
+// clusters. Services resolve the PD and etcd clients of the cluster selected in the session from the registry.
+package cluster
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/joomcode/errorx"
+	"github.com/pingcap/log"
+	"go.etcd.io/etcd/clientv3"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/utils"
+	"github.com/pingcap/tidb-dashboard/pkg/config"
+	"github.com/pingcap/tidb-dashboard/pkg/dbstore"
+	"github.com/pingcap/tidb-dashboard/pkg/pd"
+	"github.com/pingcap/tidb-dashboard/util/rest"
+)
+
+var (
+	ErrNS              = errorx.NewNamespace("error.api.cluster")
+	ErrClusterNotFound = ErrNS.NewType("not_found")
+)
+
+const (
+	// DefaultClusterID is the cluster specified by `--pd` when starting the dashboard.
+	DefaultClusterID = "default"
+
+	clientsKey = "cluster_clients"
+)
+
+// Model is a registered cluster.
+type Model struct {
+	ID         string    `json:"id" gorm:"primary_key;size:32"`
+	Name       string    `json:"name"`
+	PDEndpoint string    `json:"pd_endpoint"`
+	CreatedBy  string    `json:"created_by"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func (Model) TableName() string {
+	return "clusters"
+}
+
+// Clients are the clients to access a cluster.
+type Clients struct {
+	ClusterID  string
+	PDEndpoint string
+	PDClient   *pd.Client
+	EtcdClient *clientv3.Client
+}
+
+// IsDefault returns whether the clients are of the default cluster.
+func (c *Clients) IsDefault() bool {
+	return c.ClusterID == DefaultClusterID
+}
+
+type RegistryParams struct {
+	fx.In
+	DB          *dbstore.DB
+	Config      *config.Config
+	PDClient    *pd.Client
+	EtcdClients *pd.EtcdClientManager
+}
+
+type Registry struct {
+	params RegistryParams
+
+	newEtcdClient func(endpoint string) (*clientv3.Client, error)
+
+	mu sync.Mutex
+	// clients are the clients of registered clusters created so far, by cluster ID.
+	clients map[string]*Clients
+}
+
+func NewRegistry(lc fx.Lifecycle, p RegistryParams) *Registry {
+	if err := p.DB.AutoMigrate(&Model{}); err != nil {
+		log.Fatal("Failed to initialize database", zap.Error(err))
+	}
+	r := &Registry{
+		params: p,
+		newEtcdClient: func(endpoint string) (*clientv3.Client, error) {
+			return pd.NewEtcdClientForEndpoint(p.Config, endpoint)
+		},
+		clients: map[string]*Clients{},
+	}
+	lc.Append(fx.Hook{
+		OnStop: func(context.Context) error {
+			r.closeAll()
+			return nil
+		},
+	})
+	return r
+}
+
+var Module = fx.Options(
+	fx.Provide(NewRegistry),
+	fx.Invoke(registerRouter),
+)
+
+func newClusterID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func normalizePDEndpoint(endpoint string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(endpoint))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", rest.ErrBadRequest.New("invalid PD endpoint %s, expect http(s)://host:port", endpoint)
+	}
+	return u.Scheme + "://" + u.Host, nil
+}
+
+// List returns all registered clusters, excluding the default cluster.
+func (r *Registry) List() ([]Model, error) {
+	var clusters []Model
+	if err := r.params.DB.Order("created_at").Find(&clusters).Error; err != nil {
+		return nil, err
+	}
+	return clusters, nil
+}
+
+// Register registers a cluster by the endpoint of its PD.
+func (r *Registry) Register(name, pdEndpoint, createdBy string) (*Model, error) {
+	endpoint, err := normalizePDEndpoint(pdEndpoint)
+	if err != nil {
+		return nil, err
+	}
+	id, err := newClusterID()
+	if err != nil {
+		return nil, err
+	}
+	m := &Model{
+		ID:         id,
+		Name:       name,
+		PDEndpoint: endpoint,
+		CreatedBy:  createdBy,
+		CreatedAt:  time.Now(),
+	}
+	if err := r.params.DB.Create(m).Error; err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Unregister removes a registered cluster and closes its clients. It returns false if the cluster does not exist.
+func (r *Registry) Unregister(id string) (bool, error) {
+	result := r.params.DB.Where("id = ?", id).Delete(&Model{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	r.mu.Lock()
+	clients, ok := r.clients[id]
+	delete(r.clients, id)
+	r.mu.Unlock()
+	if ok && clients.EtcdClient != nil {
+		_ = clients.EtcdClient.Close()
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// Resolve returns the clients of the cluster. Clients of registered clusters are created on first use.
+func (r *Registry) Resolve(id string) (*Clients, error) {
+	if id == "" || id == DefaultClusterID {
+		return &Clients{
+			ClusterID:  DefaultClusterID,
+			PDEndpoint: r.params.Config.PDEndPoint,
+			PDClient:   r.params.PDClient,
+			EtcdClient: r.params.EtcdClients.Client(),
+		}, nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if clients, ok := r.clients[id]; ok {
+		return clients, nil
+	}
+
+	var m Model
+	if err := r.params.DB.Where("id = ?", id).First(&m).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrClusterNotFound.New("cluster %s is not registered", id)
+		}
+		return nil, err
+	}
+	etcdClient, err := r.newEtcdClient(m.PDEndpoint)
+	if err != nil {
+		return nil, err
+	}
+	clients := &Clients{
+		ClusterID:  m.ID,
+		PDEndpoint: m.PDEndpoint,
+		PDClient:   r.params.PDClient.WithBaseURL(m.PDEndpoint),
+		EtcdClient: etcdClient,
+	}
+	r.clients[id] = clients
+	return clients, nil
+}
+
+func (r *Registry) closeAll() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, clients := range r.clients {
+		if clients.EtcdClient != nil {
+			_ = clients.EtcdClient.Close()
+		}
+		delete(r.clients, id)
+	}
+}
+
+// MWResolveCluster resolves the clients of the cluster selected in the session, which can be retrieved by
+// GetClients. It must be used after the auth middleware.
+func (r *Registry) MWResolveCluster() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var id string
+		if u := utils.GetSession(c); u != nil {
+			id = u.ClusterID
+		}
+		clients, err := r.Resolve(id)
+		if err != nil {
+			if errorx.IsOfType(err, ErrClusterNotFound) {
+				err = rest.ErrNotFound.WrapWithNoMessage(err)
+			}
+			rest.Error(c, err)
+			c.Abort()
+			return
+		}
+		c.Set(clientsKey, clients)
+		c.Next()
+	}
+}
+
+// GetClients returns the clients resolved by MWResolveCluster, or nil if the middleware is not used.
+func GetClients(c *gin.Context) *Clients {
+	v, exists := c.Get(clientsKey)
+	if !exists {
+		return nil
+	}
+	return v.(*Clients)
+}
+
+// PDClientOf returns the PD client of the cluster selected in the session, or the default PD client if the
+// cluster is not resolved by MWResolveCluster.
+func PDClientOf(c *gin.Context, defaultClient *pd.Client) *pd.Client {
+	if clients := GetClients(c); clients != nil {
+		return clients.PDClient
+	}
+	return defaultClient
+}
+
+// EtcdClientOf returns the etcd client of the cluster selected in the session, or the current default etcd
+// client if the cluster is not resolved by MWResolveCluster.
+func EtcdClientOf(c *gin.Context, defaultClients *pd.EtcdClientManager) *clientv3.Client {
+	if clients := GetClients(c); clients != nil {
+		return clients.EtcdClient
+	}
+	return defaultClients.Client()
+}
+
+// MWRequireDefaultCluster rejects sessions selecting a non-default cluster, for APIs only serving the default
+// cluster, e.g. those backed by the history sampled from it. It must be used after the auth middleware.
+func MWRequireDefaultCluster() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if u := utils.GetSession(c); u != nil && u.ClusterID != "" {
+			rest.Error(c, rest.ErrBadRequest.New("this API is only available for the default cluster"))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package cluster
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/joomcode/errorx"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/etcd/clientv3"
+	"go.uber.org/fx/fxtest"
+
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/utils"
+	"github.com/pingcap/tidb-dashboard/pkg/config"
+	"github.com/pingcap/tidb-dashboard/pkg/dbstore/dbstoretest"
+	"github.com/pingcap/tidb-dashboard/pkg/httpc"
+	"github.com/pingcap/tidb-dashboard/pkg/pd"
+	"github.com/pingcap/tidb-dashboard/util/rest"
+)
+
+func newTestRegistry(t *testing.T) *Registry {
+	lc := fxtest.NewLifecycle(t)
+	cfg := &config.Config{PDEndPoint: "http://127.0.0.1:2379"}
+	r := NewRegistry(lc, RegistryParams{
+		DB:       dbstoretest.New(t),
+		Config:   cfg,
+		PDClient: pd.NewPDClient(lc, httpc.NewHTTPClient(lc, cfg), cfg),
+	})
+	// Etcd clients are not used in tests.
+	r.newEtcdClient = func(string) (*clientv3.Client, error) {
+		return nil, nil
+	}
+	require.NoError(t, lc.Start(context.Background()))
+	t.Cleanup(r.closeAll)
+	return r
+}
+
+func TestNormalizePDEndpoint(t *testing.T) {
+	endpoint, err := normalizePDEndpoint(" https://10.0.1.1:2379/pd/ ")
+	require.NoError(t, err)
+	require.Equal(t, "https://10.0.1.1:2379", endpoint)
+
+	for _, invalid := range []string{"10.0.1.1:2379", "tcp://10.0.1.1:2379", "http://", ""} {
+		_, err := normalizePDEndpoint(invalid)
+		require.Error(t, err, invalid)
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	pdServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("cluster-b"))
+	}))
+	defer pdServer.Close()
+
+	r := newTestRegistry(t)
+	m, err := r.Register("cluster-b", pdServer.URL, "root")
+	require.NoError(t, err)
+	require.Equal(t, pdServer.URL, m.PDEndpoint)
+
+	clusters, err := r.List()
+	require.NoError(t, err)
+	require.Len(t, clusters, 1)
+	require.Equal(t, m.ID, clusters[0].ID)
+
+	// Requests of the resolved PD client are sent to the registered cluster.
+	clients, err := r.Resolve(m.ID)
+	require.NoError(t, err)
+	require.Equal(t, m.ID, clients.ClusterID)
+	require.Equal(t, pdServer.URL, clients.PDEndpoint)
+	require.False(t, clients.IsDefault())
+	data, err := clients.PDClient.SendGetRequest("/health")
+	require.NoError(t, err)
+	require.Equal(t, "cluster-b", string(data))
+
+	// Clients are reused.
+	clients2, err := r.Resolve(m.ID)
+	require.NoError(t, err)
+	require.Same(t, clients, clients2)
+
+	found, err := r.Unregister(m.ID)
+	require.NoError(t, err)
+	require.True(t, found)
+	found, err = r.Unregister(m.ID)
+	require.NoError(t, err)
+	require.False(t, found)
+
+	_, err = r.Resolve(m.ID)
+	require.True(t, errorx.IsOfType(err, ErrClusterNotFound))
+}
+
+func TestMWRequireDefaultCluster(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(rest.ErrorHandlerFn())
+	engine.GET("/history", func(c *gin.Context) {
+		c.Set(utils.SessionUserKey, &utils.SessionUser{ClusterID: c.Query("cluster")})
+	}, MWRequireDefaultCluster(), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/history", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/history?cluster=c1", nil))
+	require.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,166 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package cluster
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/joomcode/errorx"
+
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/audit"
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/user"
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/utils"
+	"github.com/pingcap/tidb-dashboard/util/rest"
+)
+
+func registerRouter(r *gin.RouterGroup, auth *user.AuthService, a *audit.Service, reg *Registry) {
+	endpoint := r.Group("/clusters")
+	endpoint.Use(auth.MWAuthRequired())
+	endpoint.GET("", reg.listHandler)
+	endpoint.POST("", auth.MWRequireWritePriv(), a.MWRecord("cluster.register"), reg.registerHandler)
+	endpoint.DELETE("/:id", auth.MWRequireWritePriv(), a.MWRecord("cluster.unregister"), reg.unregisterHandler)
+	// The session keeps the privileges of the default cluster, so selecting another cluster is limited to users
+	// who can already modify the default cluster.
+	endpoint.POST("/select", auth.MWRequireWritePriv(), a.MWRecord("cluster.select"), reg.selectHandler(auth))
+}
+
+type ListResponse struct {
+	// Clusters are the registered clusters, the default cluster comes first.
+	Clusters []Model `json:"clusters"`
+	// Selected is the ID of the cluster selected in the current session.
+	Selected string `json:"selected"`
+}
+
+// @ID clusterList
+// @Summary List clusters managed by the dashboard
+// @Security JwtAuth
+// @Success 200 {object} ListResponse
+// @Failure 401 {object} rest.ErrorResponse
+// @Router /clusters [get]
+func (r *Registry) listHandler(c *gin.Context) {
+	clusters, err := r.List()
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	resp := ListResponse{
+		Clusters: append([]Model{{
+			ID:         DefaultClusterID,
+			Name:       DefaultClusterID,
+			PDEndpoint: r.params.Config.PDEndPoint,
+		}}, clusters...),
+		Selected: DefaultClusterID,
+	}
+	if id := utils.GetSession(c).ClusterID; id != "" {
+		resp.Selected = id
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+type RegisterRequest struct {
+	Name       string `json:"name" binding:"required"`
+	PDEndpoint string `json:"pd_endpoint" binding:"required"`
+}
+
+// @ID clusterRegister
+// @Summary Register a cluster by its PD endpoint
+// @Description The cluster shares the TLS config of the default cluster.
+// @Param request body RegisterRequest true "Request body"
+// @Security JwtAuth
+// @Success 200 {object} Model
+// @Failure 400 {object} rest.ErrorResponse
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 403 {object} rest.ErrorResponse
+// @Router /clusters [post]
+func (r *Registry) registerHandler(c *gin.Context) {
+	var req RegisterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		rest.Error(c, rest.ErrBadRequest.NewWithNoMessage())
+		return
+	}
+	m, err := r.Register(req.Name, req.PDEndpoint, utils.GetSession(c).DisplayName)
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, m)
+}
+
+// @ID clusterUnregister
+// @Summary Unregister a cluster
+// @Param id path string true "Cluster ID"
+// @Security JwtAuth
+// @Success 200 {string} string
+// @Failure 400 {object} rest.ErrorResponse
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 403 {object} rest.ErrorResponse
+// @Failure 404 {object} rest.ErrorResponse
+// @Router /clusters/{id} [delete]
+func (r *Registry) unregisterHandler(c *gin.Context) {
+	id := c.Param("id")
+	if id == DefaultClusterID {
+		rest.Error(c, rest.ErrBadRequest.New("the default cluster can not be unregistered"))
+		return
+	}
+	found, err := r.Unregister(id)
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	if !found {
+		rest.Error(c, rest.ErrNotFound.New("cluster %s is not registered", id))
+		return
+	}
+	c.JSON(http.StatusOK, nil)
+}
+
+type SelectRequest struct {
+	ClusterID string `json:"cluster_id" binding:"required"`
+}
+
+// @ID clusterSelect
+// @Summary Select the cluster of the session
+// @Description Only users with the write privilege can select clusters.
+// @Description A new token carrying the selected cluster is returned, which should replace the current token.
+// @Param request body SelectRequest true "Request body"
+// @Security JwtAuth
+// @Success 200 {object} user.TokenResponse
+// @Failure 400 {object} rest.ErrorResponse
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 403 {object} rest.ErrorResponse
+// @Failure 404 {object} rest.ErrorResponse
+// @Router /clusters/select [post]
+func (r *Registry) selectHandler(auth *user.AuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// API keys are not allowed to be exchanged for tokens.
+		if c.GetHeader(user.APIKeyHeader) != "" {
+			rest.Error(c, rest.ErrBadRequest.New("cluster can not be selected when using API keys"))
+			return
+		}
+		var req SelectRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			rest.Error(c, rest.ErrBadRequest.NewWithNoMessage())
+			return
+		}
+		if _, err := r.Resolve(req.ClusterID); err != nil {
+			if errorx.IsOfType(err, ErrClusterNotFound) {
+				err = rest.ErrNotFound.WrapWithNoMessage(err)
+			}
+			rest.Error(c, err)
+			return
+		}
+
+		u := *utils.GetSession(c)
+		u.ClusterID = req.ClusterID
+		if req.ClusterID == DefaultClusterID {
+			u.ClusterID = ""
+		}
+		token, err := auth.IssueToken(&u)
+		if err != nil {
+			rest.Error(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, token)
+	}
+}
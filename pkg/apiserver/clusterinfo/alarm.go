@@ -0,0 +1,72 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package clusterinfo
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/pingcap/tidb-dashboard/pkg/config"
+	"github.com/pingcap/tidb-dashboard/util/topo"
+)
+
+func expectedMinimumFromConfig(cfg *config.Config) map[topo.Kind]int {
+	expected := make(map[topo.Kind]int, len(cfg.TopologyExpectedMinimum))
+	for kind, minimum := range cfg.TopologyExpectedMinimum {
+		expected[topo.Kind(kind)] = minimum
+	}
+	return expected
+}
+
+// TopologyAlarm is a node that should be up but is not, or a component having fewer up nodes than expected.
+type TopologyAlarm struct {
+	Component topo.Kind `json:"component"`
+	// Address is empty for alarms of the whole component.
+	Address string    `json:"address,omitempty"`
+	Since   time.Time `json:"since"`
+	// DownSeconds is the duration since the alarm started.
+	DownSeconds int64  `json:"down_seconds"`
+	Reason      string `json:"reason"`
+}
+
+// alarms returns the alarms derived from the last recorded probe cycle, ordered by component and address.
+func (h *probeHistory) alarms() []TopologyAlarm {
+	now := h.now()
+	alarms := make([]TopologyAlarm, 0)
+	for _, state := range h.snapshot() {
+		// Vanished nodes are kept in the history, but are no longer expected to be up.
+		if !state.present || state.liveness != NodeLivenessDown {
+			continue
+		}
+		reason := state.lastError
+		if reason == "" {
+			reason = "probe failed"
+		}
+		alarms = append(alarms, TopologyAlarm{
+			Component:   state.component,
+			Address:     state.address,
+			Since:       state.failingSince,
+			DownSeconds: int64(now.Sub(state.failingSince).Seconds()),
+			Reason:      reason,
+		})
+	}
+	h.mu.Lock()
+	for kind, since := range h.belowMinimumSince {
+		alarms = append(alarms, TopologyAlarm{
+			Component:   kind,
+			Since:       since,
+			DownSeconds: int64(now.Sub(since).Seconds()),
+			Reason:      fmt.Sprintf("fewer than %d nodes are up", h.expectedMinimum[kind]),
+		})
+	}
+	h.mu.Unlock()
+
+	sort.Slice(alarms, func(i, j int) bool {
+		if alarms[i].Component != alarms[j].Component {
+			return alarms[i].Component < alarms[j].Component
+		}
+		return alarms[i].Address < alarms[j].Address
+	})
+	return alarms
+}
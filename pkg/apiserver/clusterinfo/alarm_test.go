@@ -0,0 +1,66 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package clusterinfo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pingcap/tidb-dashboard/util/topo"
+)
+
+func TestProbeHistoryAlarms(t *testing.T) {
+	h := newProbeHistory(2, 0, map[topo.Kind]int{topo.KindTiKV: 2})
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := start
+	h.now = func() time.Time { return now }
+
+	probe := func(aliveA, aliveB bool) {
+		h.record([]ProbeResult{
+			{Component: topo.KindTiKV, Address: "10.0.2.1:20160", Alive: aliveA},
+			{Component: topo.KindTiKV, Address: "10.0.2.2:20160", Alive: aliveB, Error: "connection refused"},
+		})
+		now = now.Add(time.Minute)
+	}
+
+	probe(true, true)
+	require.Empty(t, h.alarms())
+
+	// Degraded within the grace period, so that no alarm is raised yet.
+	failedAt := now
+	probe(true, false)
+	require.Empty(t, h.alarms())
+
+	probe(true, false)
+	require.Equal(t, []TopologyAlarm{
+		{
+			Component:   topo.KindTiKV,
+			Reason:      "fewer than 2 nodes are up",
+			Since:       now.Add(-time.Minute),
+			DownSeconds: 60,
+		},
+		{
+			Component: topo.KindTiKV,
+			Address:   "10.0.2.2:20160",
+			// Since is the first failed probe, not the probe flipping the node to down.
+			Since:       failedAt,
+			DownSeconds: 120,
+			Reason:      "connection refused",
+		},
+	}, h.alarms())
+
+	probe(true, true)
+	require.Empty(t, h.alarms())
+}
+
+func TestProbeHistoryAlarmsForMissingComponent(t *testing.T) {
+	h := newProbeHistory(2, 0, map[topo.Kind]int{topo.KindTiDB: 1})
+	h.record([]ProbeResult{{Component: topo.KindTiKV, Address: "10.0.2.1:20160", Alive: true}})
+
+	alarms := h.alarms()
+	require.Len(t, alarms, 1)
+	require.Equal(t, topo.KindTiDB, alarms[0].Component)
+	require.Empty(t, alarms[0].Address)
+}
@@ -0,0 +1,188 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package clusterinfo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/utils"
+	"github.com/pingcap/tidb-dashboard/pkg/httpc"
+	"github.com/pingcap/tidb-dashboard/pkg/utils/topology"
+	"github.com/pingcap/tidb-dashboard/util/rest"
+)
+
+var (
+	ErrAlertManagerNotFound = ErrNS.NewType("alertmanager_not_found")
+	ErrAlertManagerRequest  = ErrNS.NewType("alertmanager_request_failed")
+)
+
+const maxSilenceDuration = 7 * 24 * time.Hour
+
+// Alert is a subset of the AlertManager v2 API alert.
+type Alert struct {
+	Fingerprint string            `json:"fingerprint"`
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations"`
+	StartsAt    time.Time         `json:"startsAt"`
+	EndsAt      time.Time         `json:"endsAt"`
+	Status      struct {
+		State       string   `json:"state"`
+		SilencedBy  []string `json:"silencedBy"`
+		InhibitedBy []string `json:"inhibitedBy"`
+	} `json:"status"`
+}
+
+// alertInstanceLabel is the label of alerts holding the address of the node firing the alert.
+const alertInstanceLabel = "instance"
+
+type AlertsResponse struct {
+	Count  int     `json:"count"`
+	Alerts []Alert `json:"alerts"`
+}
+
+type SilenceMatcher struct {
+	Name    string `json:"name" binding:"required"`
+	Value   string `json:"value"`
+	IsRegex bool   `json:"isRegex"`
+	IsEqual bool   `json:"isEqual"`
+}
+
+type CreateSilenceRequest struct {
+	Matchers        []SilenceMatcher `json:"matchers" binding:"required"`
+	DurationSeconds int64            `json:"duration_seconds" binding:"required"`
+	Comment         string           `json:"comment"`
+}
+
+type CreateSilenceResponse struct {
+	SilenceID string `json:"silenceID"`
+}
+
+// discoverAlertManager returns the address of the AlertManager registered in the topology of the cluster selected
+// in the session. Only the discovered AlertManager is accessed, so that the dashboard can not be used to send
+// requests to arbitrary addresses.
+func (s *Service) discoverAlertManager(c *gin.Context) (string, error) {
+	info, err := topology.FetchAlertManagerTopology(c.Request.Context(), s.etcdClientOf(c))
+	if err != nil {
+		return "", err
+	}
+	if info == nil {
+		return "", ErrAlertManagerNotFound.New("AlertManager is not deployed").
+			WithProperty(rest.HTTPCodeProperty(http.StatusNotFound))
+	}
+	return net.JoinHostPort(info.IP, strconv.Itoa(int(info.Port))), nil
+}
+
+func (s *Service) sendAlertManagerRequest(c *gin.Context, method, path string, body interface{}) ([]byte, error) {
+	address, err := s.discoverAlertManager(c)
+	if err != nil {
+		return nil, err
+	}
+	return sendAlertManagerRequest(c.Request.Context(), s.params.HTTPClient, address, method, path, body)
+}
+
+func sendAlertManagerRequest(ctx context.Context, httpClient *httpc.Client, address, method, path string, body interface{}) ([]byte, error) {
+	var reader io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return nil, ErrAlertManagerRequest.WrapWithNoMessage(err)
+		}
+		reader = bytes.NewReader(b)
+	}
+	return httpClient.
+		CloneAndAddRequestHeader("Content-Type", "application/json").
+		SendRequest(ctx, fmt.Sprintf("http://%s/api/v2%s", address, path), method, reader, ErrAlertManagerRequest, "AlertManager")
+}
+
+// @ID getAlertManagerAlerts
+// @Summary Get firing alerts from the discovered AlertManager
+// @Success 200 {object} AlertsResponse
+// @Router /topology/alertmanager/alerts [get]
+// @Security JwtAuth
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 404 {object} rest.ErrorResponse
+func (s *Service) getAlertManagerAlerts(c *gin.Context) {
+	data, err := s.sendAlertManagerRequest(c, http.MethodGet, "/alerts?active=true&silenced=false&inhibited=false", nil)
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	alerts := make([]Alert, 0)
+	if err := json.Unmarshal(data, &alerts); err != nil {
+		rest.Error(c, ErrAlertManagerRequest.WrapWithNoMessage(err))
+		return
+	}
+	c.JSON(http.StatusOK, s.maskForUser(c, AlertsResponse{Count: len(alerts), Alerts: alerts}))
+}
+
+// @ID createAlertManagerSilence
+// @Summary Silence alerts in the discovered AlertManager, starting now
+// @Param request body CreateSilenceRequest true "Request body"
+// @Success 200 {object} CreateSilenceResponse
+// @Router /topology/alertmanager/silences [post]
+// @Security JwtAuth
+// @Failure 400 {object} rest.ErrorResponse
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 403 {object} rest.ErrorResponse
+// @Failure 404 {object} rest.ErrorResponse
+func (s *Service) createAlertManagerSilence(c *gin.Context) {
+	var req CreateSilenceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		rest.Error(c, rest.ErrBadRequest.WrapWithNoMessage(err))
+		return
+	}
+	duration := time.Duration(req.DurationSeconds) * time.Second
+	if len(req.Matchers) == 0 || duration <= 0 || duration > maxSilenceDuration {
+		rest.Error(c, rest.ErrBadRequest.New("invalid matchers or duration"))
+		return
+	}
+
+	now := time.Now()
+	body := map[string]interface{}{
+		"matchers":  req.Matchers,
+		"startsAt":  now,
+		"endsAt":    now.Add(duration),
+		"createdBy": utils.GetSession(c).DisplayName,
+		"comment":   req.Comment,
+	}
+	data, err := s.sendAlertManagerRequest(c, http.MethodPost, "/silences", body)
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	var resp CreateSilenceResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		rest.Error(c, ErrAlertManagerRequest.WrapWithNoMessage(err))
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// @ID expireAlertManagerSilence
+// @Summary Expire a silence in the discovered AlertManager
+// @Param id path string true "silence ID"
+// @Success 200 {string} string
+// @Router /topology/alertmanager/silences/{id} [delete]
+// @Security JwtAuth
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 403 {object} rest.ErrorResponse
+// @Failure 404 {object} rest.ErrorResponse
+func (s *Service) expireAlertManagerSilence(c *gin.Context) {
+	id := url.PathEscape(c.Param("id"))
+	if _, err := s.sendAlertManagerRequest(c, http.MethodDelete, "/silence/"+id, nil); err != nil {
+		rest.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, nil)
+}
@@ -0,0 +1,72 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package clusterinfo
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/joomcode/errorx"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/fx/fxtest"
+
+	"github.com/pingcap/tidb-dashboard/pkg/config"
+	"github.com/pingcap/tidb-dashboard/pkg/httpc"
+)
+
+func TestSendAlertManagerRequest(t *testing.T) {
+	var silenceBody map[string]interface{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v2/alerts":
+			_, _ = w.Write([]byte(`[{"fingerprint": "a1", "labels": {"alertname": "TiKV_down"}, "status": {"state": "active"}}]`))
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v2/silences":
+			b, _ := io.ReadAll(r.Body)
+			_ = json.Unmarshal(b, &silenceBody)
+			_, _ = w.Write([]byte(`{"silenceID": "s1"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+	address := strings.TrimPrefix(ts.URL, "http://")
+	lc := fxtest.NewLifecycle(t)
+	httpClient := httpc.NewHTTPClient(lc, &config.Config{})
+
+	data, err := sendAlertManagerRequest(context.Background(), httpClient, address, http.MethodGet, "/alerts", nil)
+	require.NoError(t, err)
+	var alerts []Alert
+	require.NoError(t, json.Unmarshal(data, &alerts))
+	require.Len(t, alerts, 1)
+	require.Equal(t, "TiKV_down", alerts[0].Labels["alertname"])
+	require.Equal(t, "active", alerts[0].Status.State)
+
+	data, err = sendAlertManagerRequest(context.Background(), httpClient, address, http.MethodPost, "/silences", map[string]interface{}{
+		"matchers":  []SilenceMatcher{{Name: "alertname", Value: "TiKV_down", IsEqual: true}},
+		"createdBy": "root",
+	})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"silenceID": "s1"}`, string(data))
+	require.Equal(t, "root", silenceBody["createdBy"])
+
+	_, err = sendAlertManagerRequest(context.Background(), httpClient, address, http.MethodDelete, "/silence/unknown", nil)
+	require.True(t, errorx.IsOfType(err, ErrAlertManagerRequest))
+}
+
+func TestAlertManagerRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := &Service{}
+	engine := gin.New()
+	// The static routes must not conflict with the address route.
+	require.NotPanics(t, func() {
+		engine.GET("/topology/alertmanager/:address/count", s.getAlertManagerCounts)
+		engine.GET("/topology/alertmanager/alerts", s.getAlertManagerAlerts)
+		engine.POST("/topology/alertmanager/silences", s.createAlertManagerSilence)
+	})
+}
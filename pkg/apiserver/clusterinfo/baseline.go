@@ -0,0 +1,142 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package clusterinfo
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+
+	"github.com/pingcap/tidb-dashboard/util/rest"
+	"github.com/pingcap/tidb-dashboard/util/topo"
+)
+
+// baselineTransientFields are node fields that change at runtime and are ignored
+// when comparing the topology against a baseline.
+var baselineTransientFields = map[string]struct{}{
+	"status":             {},
+	"start_timestamp":    {},
+	"pending_peer_count": {},
+	"down_peer_count":    {},
+	"warnings":           {},
+	"connection_count":   {},
+	"member_id":          {},
+	// The balance status and the flow of stores reported by PD.
+	"region_count":        {},
+	"leader_count":        {},
+	"region_score":        {},
+	"leader_score":        {},
+	"capacity":            {},
+	"available":           {},
+	"used_size":           {},
+	"read_bytes_per_sec":  {},
+	"write_bytes_per_sec": {},
+	// The state and the TSOs reported by Pump and Drainer nodes.
+	"state":         {},
+	"max_commit_ts": {},
+	"update_ts":     {},
+}
+
+type BaselineDiffType string
+
+const (
+	// BaselineDiffMissing means the node exists in the baseline but not in the cluster.
+	BaselineDiffMissing BaselineDiffType = "missing"
+	// BaselineDiffUnexpected means the node exists in the cluster but not in the baseline.
+	BaselineDiffUnexpected BaselineDiffType = "unexpected"
+	// BaselineDiffChanged means a field of the node is different from the baseline.
+	BaselineDiffChanged BaselineDiffType = "changed"
+)
+
+type BaselineDiff struct {
+	Type      BaselineDiffType `json:"type"`
+	Component topo.Kind        `json:"component"`
+	Address   string           `json:"address"`
+	Field     string           `json:"field,omitempty"`
+	Expected  interface{}      `json:"expected,omitempty"`
+	Actual    interface{}      `json:"actual,omitempty"`
+}
+
+type CompareBaselineResponse struct {
+	Match bool           `json:"match"`
+	Diffs []BaselineDiff `json:"diffs"`
+	// Errors contains components that could not be fetched, which are not compared.
+	Errors map[topo.Kind]rest.ErrorResponse `json:"errors,omitempty"`
+}
+
+// nodeFields flattens the node info into JSON fields, excluding transient fields.
+func nodeFields(n Node) map[string]interface{} {
+	fields := map[string]interface{}{}
+	data, err := json.Marshal(n.Info)
+	if err != nil {
+		return fields
+	}
+	_ = json.Unmarshal(data, &fields)
+	for k := range baselineTransientFields {
+		delete(fields, k)
+	}
+	return fields
+}
+
+// compareBaseline compares the actual topology with the baseline topology.
+// Nodes are matched by component and address. Components failed to be fetched are skipped.
+func compareBaseline(baseline, actual *ClusterInfo) CompareBaselineResponse {
+	diffs := make([]BaselineDiff, 0)
+
+	for _, kind := range clusterComponents {
+		if _, ok := actual.Errors[kind]; ok {
+			continue
+		}
+		actualNodes := map[string]Node{}
+		for _, n := range actual.nodesOf(kind) {
+			actualNodes[n.Address()] = n
+		}
+		baselineNodes := map[string]Node{}
+		for _, n := range baseline.nodesOf(kind) {
+			baselineNodes[n.Address()] = n
+		}
+
+		for addr, expected := range baselineNodes {
+			got, ok := actualNodes[addr]
+			if !ok {
+				diffs = append(diffs, BaselineDiff{Type: BaselineDiffMissing, Component: kind, Address: addr})
+				continue
+			}
+			expectedFields := nodeFields(expected)
+			gotFields := nodeFields(got)
+			for field, ev := range expectedFields {
+				if av := gotFields[field]; !reflect.DeepEqual(ev, av) {
+					diffs = append(diffs, BaselineDiff{
+						Type:      BaselineDiffChanged,
+						Component: kind,
+						Address:   addr,
+						Field:     field,
+						Expected:  ev,
+						Actual:    av,
+					})
+				}
+			}
+		}
+		for addr := range actualNodes {
+			if _, ok := baselineNodes[addr]; !ok {
+				diffs = append(diffs, BaselineDiff{Type: BaselineDiffUnexpected, Component: kind, Address: addr})
+			}
+		}
+	}
+
+	sort.SliceStable(diffs, func(i, j int) bool {
+		if diffs[i].Component != diffs[j].Component {
+			return diffs[i].Component < diffs[j].Component
+		}
+		if diffs[i].Address != diffs[j].Address {
+			return diffs[i].Address < diffs[j].Address
+		}
+		return diffs[i].Field < diffs[j].Field
+	})
+
+	return CompareBaselineResponse{
+		Match:  len(diffs) == 0 && len(actual.Errors) == 0,
+		Diffs:  diffs,
+		Errors: actual.Errors,
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package clusterinfo
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pingcap/tidb-dashboard/pkg/utils/topology"
+	"github.com/pingcap/tidb-dashboard/util/topo"
+)
+
+func newBaselineTestClusterInfo() *ClusterInfo {
+	return &ClusterInfo{
+		TiDB: []topology.TiDBInfo{
+			{IP: "10.0.1.1", Port: 4000, StatusPort: 10080, Version: "v7.5.0", Status: topology.ComponentStatusUp},
+		},
+		TiKV: []topology.StoreInfo{
+			{IP: "10.0.2.1", Port: 20160, StatusPort: 20180, Version: "v7.5.0", Labels: map[string]string{"zone": "z1"}},
+		},
+		PD: []topology.PDInfo{
+			{IP: "10.0.3.1", Port: 2379, Version: "v7.5.0", StartTimestamp: 1700000000},
+		},
+		Pump: []topology.BinlogInfo{
+			{NodeID: "pump-1:8250", IP: "10.0.4.1", Port: 8250, State: "online", MaxCommitTS: 429, UpdateTS: 430},
+		},
+	}
+}
+
+func TestCompareBaselineVersionChanged(t *testing.T) {
+	// The baseline is usually loaded from a golden file.
+	data, err := json.Marshal(newBaselineTestClusterInfo())
+	require.NoError(t, err)
+	var baseline ClusterInfo
+	require.NoError(t, json.Unmarshal(data, &baseline))
+
+	actual := newBaselineTestClusterInfo()
+	actual.TiKV[0].Version = "v7.5.1"
+	// Transient fields are ignored.
+	actual.TiDB[0].Status = topology.ComponentStatusUnreachable
+	actual.PD[0].StartTimestamp = 1800000000
+	actual.TiKV[0].DownPeerCount = 3
+	actual.TiKV[0].RegionCount = 100
+	actual.TiKV[0].LeaderScore = 42.5
+	actual.TiKV[0].Available = 1 << 30
+	actual.TiKV[0].WriteBytesPerSec = 1024
+	actual.Pump[0].State = "paused"
+	actual.Pump[0].MaxCommitTS = 500
+	actual.Pump[0].UpdateTS = 501
+
+	resp := compareBaseline(&baseline, actual)
+	require.False(t, resp.Match)
+	require.Equal(t, []BaselineDiff{{
+		Type:      BaselineDiffChanged,
+		Component: topo.KindTiKV,
+		Address:   "10.0.2.1:20160",
+		Field:     "version",
+		Expected:  "v7.5.0",
+		Actual:    "v7.5.1",
+	}}, resp.Diffs)
+}
+
+func TestCompareBaselineNodes(t *testing.T) {
+	baseline := newBaselineTestClusterInfo()
+	actual := newBaselineTestClusterInfo()
+	require.True(t, compareBaseline(baseline, actual).Match)
+
+	actual.TiDB[0].Port = 4001
+	resp := compareBaseline(baseline, actual)
+	require.False(t, resp.Match)
+	require.Len(t, resp.Diffs, 2)
+	require.Equal(t, BaselineDiffMissing, resp.Diffs[0].Type)
+	require.Equal(t, "10.0.1.1:4000", resp.Diffs[0].Address)
+	require.Equal(t, BaselineDiffUnexpected, resp.Diffs[1].Type)
+	require.Equal(t, "10.0.1.1:4001", resp.Diffs[1].Address)
+}
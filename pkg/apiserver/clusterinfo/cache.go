@@ -0,0 +1,185 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package clusterinfo
+
+import (
+	"container/list"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/cluster"
+	"github.com/pingcap/tidb-dashboard/util/rest"
+)
+
+const (
+	defaultTopologyCacheSize = 64
+)
+
+// topologyCache is a size-bounded LRU cache for topology responses.
+// Each entry expires after the TTL. This struct is concurrent-safe.
+type topologyCache struct {
+	mu       sync.Mutex
+	capacity int
+	ttl      time.Duration
+	ll       *list.List
+	items    map[string]*list.Element
+}
+
+type topologyCacheEntry struct {
+	key      string
+	value    interface{}
+	expireAt time.Time
+}
+
+func newTopologyCache(capacity int, ttl time.Duration) *topologyCache {
+	return &topologyCache{
+		capacity: capacity,
+		ttl:      ttl,
+		ll:       list.New(),
+		items:    make(map[string]*list.Element),
+	}
+}
+
+func (c *topologyCache) Get(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*topologyCacheEntry)
+	if time.Now().After(entry.expireAt) {
+		c.removeElement(elem)
+		return nil, false
+	}
+	c.ll.MoveToFront(elem)
+	return entry.value, true
+}
+
+// Set caches the value. Nothing is cached when the TTL is not positive.
+func (c *topologyCache) Set(key string, value interface{}) {
+	if c.ttl <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expireAt := time.Now().Add(c.ttl)
+	if elem, ok := c.items[key]; ok {
+		entry := elem.Value.(*topologyCacheEntry)
+		entry.value = value
+		entry.expireAt = expireAt
+		c.ll.MoveToFront(elem)
+		return
+	}
+
+	elem := c.ll.PushFront(&topologyCacheEntry{
+		key:      key,
+		value:    value,
+		expireAt: expireAt,
+	})
+	c.items[key] = elem
+	for c.capacity > 0 && c.ll.Len() > c.capacity {
+		c.removeElement(c.ll.Back())
+	}
+}
+
+// Purge drops all cached entries, e.g. after the topology is modified.
+func (c *topologyCache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.ll.Init()
+	c.items = make(map[string]*list.Element)
+}
+
+func (c *topologyCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.ll.Len()
+}
+
+func (c *topologyCache) removeElement(elem *list.Element) {
+	c.ll.Remove(elem)
+	delete(c.items, elem.Value.(*topologyCacheEntry).key)
+}
+
+// cacheIgnoredParams are query parameters that do not change the fetched content.
+var cacheIgnoredParams = map[string]struct{}{
+	"delta_from": {},
+	"refresh":    {},
+}
+
+// cacheKeyFromRequest builds the cache key from the request path and its query parameters.
+// Parameters are sorted so that `?a=1&b=2` and `?b=2&a=1` share the same entry, while
+// different components, filters or formats are cached independently.
+func cacheKeyFromRequest(r *http.Request) string {
+	query := r.URL.Query()
+	for k := range cacheIgnoredParams {
+		query.Del(k)
+	}
+	return r.URL.Path + "?" + canonicalQuery(query)
+}
+
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, k := range keys {
+		values := append([]string(nil), query[k]...)
+		sort.Strings(values)
+		for _, v := range values {
+			if b.Len() > 0 {
+				b.WriteByte('&')
+			}
+			b.WriteString(url.QueryEscape(k))
+			b.WriteByte('=')
+			b.WriteString(url.QueryEscape(v))
+		}
+	}
+	return b.String()
+}
+
+// cachedFetch returns the cached result for the current request if there is any,
+// otherwise it calls fetch and caches a successful result. Errors are never cached.
+// With `?refresh=true`, the cache is bypassed and refreshed by the fetched result.
+func (s *Service) cachedFetch(c *gin.Context, fetch func() (interface{}, error)) (interface{}, error) {
+	key := cacheKeyFromRequest(c.Request)
+	// Results of different clusters are cached separately.
+	if clients := cluster.GetClients(c); clients != nil && !clients.IsDefault() {
+		key = clients.ClusterID + ":" + key
+	}
+	if c.Query("refresh") != "true" {
+		if v, ok := s.cache.Get(key); ok {
+			return v, nil
+		}
+	}
+	v, err := fetch()
+	if err != nil {
+		return nil, err
+	}
+	s.cache.Set(key, v)
+	return v, nil
+}
+
+// serveCached responds the result of cachedFetch in JSON.
+func (s *Service) serveCached(c *gin.Context, fetch func() (interface{}, error)) {
+	v, err := s.cachedFetch(c, fetch)
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, s.maskForUser(c, v))
+}
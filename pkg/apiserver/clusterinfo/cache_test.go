@@ -0,0 +1,116 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package clusterinfo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCanonicalCacheKey(t *testing.T) {
+	r1 := httptest.NewRequest(http.MethodGet, "/topology/all?components=tidb&components=pd&format=json", nil)
+	r2 := httptest.NewRequest(http.MethodGet, "/topology/all?format=json&components=pd&components=tidb", nil)
+	r3 := httptest.NewRequest(http.MethodGet, "/topology/all?components=tidb", nil)
+	require.Equal(t, cacheKeyFromRequest(r1), cacheKeyFromRequest(r2))
+	require.NotEqual(t, cacheKeyFromRequest(r1), cacheKeyFromRequest(r3))
+
+	r4 := httptest.NewRequest(http.MethodGet, "/topology/all?components=tidb&delta_from=abc", nil)
+	require.Equal(t, cacheKeyFromRequest(r3), cacheKeyFromRequest(r4))
+}
+
+func TestServeCachedDistinctQueries(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := &Service{cache: newTopologyCache(defaultTopologyCacheSize, time.Minute)}
+
+	fetches := 0
+	engine := gin.New()
+	engine.GET("/topology/tidb", func(c *gin.Context) {
+		s.serveCached(c, func() (interface{}, error) {
+			fetches++
+			return c.Query("status"), nil
+		})
+	})
+
+	get := func(url string) string {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, url, nil))
+		require.Equal(t, http.StatusOK, w.Code)
+		return w.Body.String()
+	}
+
+	require.Equal(t, `"up"`, get("/topology/tidb?status=up"))
+	require.Equal(t, `"down"`, get("/topology/tidb?status=down"))
+	require.Equal(t, 2, fetches)
+
+	// Served from the cache without fetching again.
+	require.Equal(t, `"up"`, get("/topology/tidb?status=up"))
+	require.Equal(t, `"down"`, get("/topology/tidb?status=down"))
+	require.Equal(t, 2, fetches)
+}
+
+func TestServeCachedRefresh(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := &Service{cache: newTopologyCache(defaultTopologyCacheSize, time.Minute)}
+
+	fetches := 0
+	engine := gin.New()
+	engine.GET("/topology/pd", func(c *gin.Context) {
+		s.serveCached(c, func() (interface{}, error) {
+			fetches++
+			return fetches, nil
+		})
+	})
+	get := func(url string) string {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, url, nil))
+		require.Equal(t, http.StatusOK, w.Code)
+		return w.Body.String()
+	}
+
+	require.Equal(t, "1", get("/topology/pd"))
+	require.Equal(t, "1", get("/topology/pd"))
+	// Refreshing bypasses the cache, and the refreshed result is served afterwards.
+	require.Equal(t, "2", get("/topology/pd?refresh=true"))
+	require.Equal(t, "2", get("/topology/pd"))
+}
+
+func TestTopologyCacheDisabled(t *testing.T) {
+	c := newTopologyCache(2, 0)
+	c.Set("a", 1)
+	_, ok := c.Get("a")
+	require.False(t, ok)
+	require.Equal(t, 0, c.Len())
+}
+
+func TestTopologyCacheLRUEviction(t *testing.T) {
+	c := newTopologyCache(2, time.Minute)
+	c.Set("a", 1)
+	c.Set("b", 2)
+	_, ok := c.Get("a") // "b" becomes the least recently used entry
+	require.True(t, ok)
+	c.Set("c", 3)
+
+	require.Equal(t, 2, c.Len())
+	_, ok = c.Get("b")
+	require.False(t, ok)
+	v, ok := c.Get("a")
+	require.True(t, ok)
+	require.Equal(t, 1, v)
+	v, ok = c.Get("c")
+	require.True(t, ok)
+	require.Equal(t, 3, v)
+}
+
+func TestTopologyCacheExpire(t *testing.T) {
+	c := newTopologyCache(2, 10*time.Millisecond)
+	c.Set("a", 1)
+	time.Sleep(20 * time.Millisecond)
+	_, ok := c.Get("a")
+	require.False(t, ok)
+	require.Equal(t, 0, c.Len())
+}
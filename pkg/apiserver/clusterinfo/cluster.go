@@ -0,0 +1,421 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package clusterinfo
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pingcap/log"
+	"github.com/samber/lo"
+	"go.uber.org/zap"
+
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/cluster"
+	"github.com/pingcap/tidb-dashboard/pkg/utils/topology"
+	"github.com/pingcap/tidb-dashboard/util/rest"
+	"github.com/pingcap/tidb-dashboard/util/topo"
+)
+
+// ClusterInfo is the topology of all components in the cluster.
+type ClusterInfo struct {
+	TiDB         []topology.TiDBInfo        `json:"tidb"`
+	TiKV         []topology.StoreInfo       `json:"tikv"`
+	TiFlash      []topology.StoreInfo       `json:"tiflash"`
+	PD           []topology.PDInfo          `json:"pd"`
+	TiCDC        []topology.TiCDCInfo       `json:"ticdc"`
+	TiProxy      []topology.TiProxyInfo     `json:"tiproxy"`
+	Pump         []topology.BinlogInfo      `json:"pump"`
+	Drainer      []topology.BinlogInfo      `json:"drainer"`
+	Grafana      *topology.GrafanaInfo      `json:"grafana"`
+	AlertManager *topology.AlertManagerInfo `json:"alert_manager"`
+	Prometheus   *topology.PrometheusInfo   `json:"prometheus"`
+
+	// Region split settings of the cluster. Empty when unavailable.
+	RegionMaxSize string `json:"region_max_size"`
+	RegionMaxKeys int64  `json:"region_max_keys"`
+
+	// Errors contains the fetch error of each component that is not available.
+	// Topology of other components is still returned.
+	Errors map[topo.Kind]rest.ErrorResponse `json:"errors,omitempty"`
+	// LastSuccessAt is the time of the last successful fetch of each component, which is
+	// also reported for components failed in this fetch.
+	LastSuccessAt map[topo.Kind]time.Time `json:"last_success_at,omitempty"`
+	// StaleSince contains the components failed in this fetch but served from the last-known-good snapshot,
+	// with the time when the snapshot was taken.
+	StaleSince map[topo.Kind]time.Time `json:"stale_since,omitempty"`
+}
+
+// componentDependencies maps each component to the components it depends on.
+var componentDependencies = map[topo.Kind][]topo.Kind{
+	topo.KindTiDB:         {topo.KindPD, topo.KindTiKV, topo.KindTiFlash},
+	topo.KindTiKV:         {topo.KindPD},
+	topo.KindTiFlash:      {topo.KindPD},
+	topo.KindTiCDC:        {topo.KindPD, topo.KindTiKV},
+	topo.KindTiProxy:      {topo.KindTiDB},
+	topo.KindPump:         {topo.KindPD},
+	topo.KindDrainer:      {topo.KindPump},
+	topo.KindGrafana:      {topo.KindPrometheus},
+	topo.KindPrometheus:   {topo.KindAlertManager},
+	topo.KindAlertManager: {},
+	topo.KindPD:           {},
+}
+
+// clusterTopology keeps the topology sources of a cluster and the states across fetches and probes.
+type clusterTopology struct {
+	// clients are the clients the sources are built from, which is nil for the default cluster.
+	clients     *cluster.Clients
+	sources     []TopologySource
+	lastSuccess lastSuccessTracker
+	// snapshot is nil when there is no data dir to persist the snapshot.
+	snapshot *topologySnapshot
+	history  *probeHistory
+	// probedInBackground is true when the history is only recorded by the background probe loop, so that
+	// probes of requests do not shorten the grace period of nodes.
+	probedInBackground bool
+}
+
+// fetchClusterInfo fetches the topology of the cluster from all sources concurrently.
+// Failure of one source does not prevent others from being returned.
+// Each source is retried within its own retry budget.
+//
+// When dependency-aware fetching is enabled, sources depending on other components wait for them,
+// and are skipped with ErrDependencyUnavailable if any dependency fails. Other sources still run in parallel.
+func (s *Service) fetchClusterInfo(ctx context.Context, t *clusterTopology) *ClusterInfo {
+	info := &ClusterInfo{}
+	if s.fetchTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.fetchTimeout)
+		defer cancel()
+	}
+
+	dependencyAware := s.params.Config != nil && s.params.Config.TopologyDependencyAwareFetch
+	// done[kind] is finished when all sources of the component are finished.
+	done := make(map[topo.Kind]*sync.WaitGroup)
+	for _, src := range t.sources {
+		for _, kind := range src.Kinds() {
+			if _, ok := done[kind]; !ok {
+				done[kind] = &sync.WaitGroup{}
+			}
+			done[kind].Add(1)
+		}
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, src := range t.sources {
+		wg.Add(1)
+		go func(src TopologySource) {
+			defer wg.Done()
+			defer func() {
+				for _, kind := range src.Kinds() {
+					done[kind].Done()
+				}
+			}()
+
+			var err error
+			if ds, ok := src.(dependentSource); ok && dependencyAware {
+				err = waitDependencies(ds.DependsOn(), done, func(kind topo.Kind) bool {
+					mu.Lock()
+					defer mu.Unlock()
+					_, failed := info.Errors[kind]
+					return failed
+				})
+			}
+			if err == nil {
+				err = s.fetchSource(ctx, t, src, info, &mu)
+			}
+			if err != nil {
+				// Errors must be recorded before marking the components as done, for dependent sources.
+				mu.Lock()
+				if info.Errors == nil {
+					info.Errors = make(map[topo.Kind]rest.ErrorResponse)
+				}
+				for _, kind := range src.Kinds() {
+					info.Errors[kind] = rest.NewErrorResponse(err)
+				}
+				mu.Unlock()
+			}
+		}(src)
+	}
+	wg.Wait()
+
+	if t.snapshot != nil {
+		var fetched, failed []topo.Kind
+		for _, src := range t.sources {
+			for _, kind := range src.Kinds() {
+				if _, disabled := s.disabledKinds[kind]; disabled {
+					continue
+				}
+				if _, ok := info.Errors[kind]; ok {
+					failed = append(failed, kind)
+				} else {
+					fetched = append(fetched, kind)
+				}
+			}
+		}
+		t.snapshot.update(info, fetched, time.Now())
+		t.snapshot.fill(info, failed)
+	}
+	info.LastSuccessAt = t.lastSuccess.snapshot()
+	s.excludeSelf(info)
+	info.fillGrafanaURLs()
+	return info
+}
+
+// waitDependencies waits until all dependencies are fetched. Dependencies without any source are ignored.
+func waitDependencies(dependencies []topo.Kind, done map[topo.Kind]*sync.WaitGroup, failed func(topo.Kind) bool) error {
+	for _, kind := range dependencies {
+		wg, ok := done[kind]
+		if !ok {
+			continue
+		}
+		wg.Wait()
+		if failed(kind) {
+			return ErrDependencyUnavailable.New("dependency %s is unavailable", kind)
+		}
+	}
+	return nil
+}
+
+// fetchSource fetches nodes from the source and adds them into the topology.
+func (s *Service) fetchSource(ctx context.Context, t *clusterTopology, src TopologySource, info *ClusterInfo, mu *sync.Mutex) error {
+	kinds := src.Kinds()
+	nodes, err := s.fetchSourceNodes(ctx, src)
+	if err != nil {
+		return err
+	}
+	nodes = lo.Filter(nodes, func(n Node, _ int) bool {
+		_, disabled := s.disabledKinds[n.Kind]
+		return !disabled
+	})
+
+	var splitConfig *topology.RegionSplitConfig
+	if rs, ok := src.(regionSplitSource); ok {
+		splitConfig, err = rs.FetchRegionSplitConfig(ctx)
+		if err != nil {
+			// Region split settings are optional.
+			log.Warn("Failed to fetch region split config", zap.Error(err))
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	info.addNodes(nodes)
+	t.lastSuccess.record(kinds, time.Now())
+	if splitConfig != nil {
+		info.RegionMaxSize = splitConfig.RegionMaxSize
+		info.RegionMaxKeys = splitConfig.RegionMaxKeys
+	}
+	return nil
+}
+
+// fetchSourceNodes fetches nodes from the source within the source timeout. It gives up when the timeout
+// is reached, even if the source does not respect the context, e.g. the PD HTTP APIs.
+func (s *Service) fetchSourceNodes(ctx context.Context, src TopologySource) ([]Node, error) {
+	kind := src.Kinds()[0]
+	if s.sourceTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.sourceTimeout)
+		defer cancel()
+	}
+
+	type result struct {
+		nodes []Node
+		err   error
+	}
+	resultCh := make(chan result, 1)
+	go func() {
+		var r result
+		r.err = fetchWithRetry(ctx, kind, s.fetchRetryBudget, func(ctx context.Context) (err error) {
+			r.nodes, err = src.Fetch(ctx)
+			return
+		})
+		resultCh <- r
+	}()
+
+	select {
+	case r := <-resultCh:
+		return r.nodes, r.err
+	case <-ctx.Done():
+		return nil, ErrFetchTimeout.Wrap(ctx.Err(), "fetching %s timed out", kind)
+	}
+}
+
+// lastSuccessTracker records the time of the last successful fetch of each component.
+// This struct is concurrent-safe.
+type lastSuccessTracker struct {
+	mu    sync.Mutex
+	times map[topo.Kind]time.Time
+}
+
+func (t *lastSuccessTracker) record(kinds []topo.Kind, at time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.times == nil {
+		t.times = make(map[topo.Kind]time.Time)
+	}
+	for _, kind := range kinds {
+		t.times[kind] = at
+	}
+}
+
+func (t *lastSuccessTracker) snapshot() map[topo.Kind]time.Time {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(t.times) == 0 {
+		return nil
+	}
+	result := make(map[topo.Kind]time.Time, len(t.times))
+	for k, v := range t.times {
+		result[k] = v
+	}
+	return result
+}
+
+// clusterComponents is the display order of components.
+var clusterComponents = []topo.Kind{
+	topo.KindPD,
+	topo.KindTiDB,
+	topo.KindTiKV,
+	topo.KindTiFlash,
+	topo.KindTiCDC,
+	topo.KindTiProxy,
+	topo.KindPump,
+	topo.KindDrainer,
+	topo.KindPrometheus,
+	topo.KindGrafana,
+	topo.KindAlertManager,
+}
+
+// Node is an instance of a component in the cluster.
+type Node struct {
+	Kind       topo.Kind
+	IP         string
+	Port       uint
+	StatusPort uint // 0 if the component does not have a dedicated status port
+	// Info is the component specific node info, e.g. topology.TiDBInfo.
+	Info interface{}
+}
+
+func (n Node) Address() string {
+	return net.JoinHostPort(n.IP, strconv.Itoa(int(n.Port)))
+}
+
+// ProbeAddress is the address used to check the liveness of the node.
+func (n Node) ProbeAddress() string {
+	if n.StatusPort == 0 {
+		return n.Address()
+	}
+	return net.JoinHostPort(n.IP, strconv.Itoa(int(n.StatusPort)))
+}
+
+// ProbeAddresses returns all addresses that can be used to check the liveness of the node.
+func (n Node) ProbeAddresses() []string {
+	if n.StatusPort == 0 || n.StatusPort == n.Port {
+		return []string{n.Address()}
+	}
+	return []string{n.ProbeAddress(), n.Address()}
+}
+
+// nodesOf returns all nodes of the specified component.
+func (info *ClusterInfo) nodesOf(kind topo.Kind) []Node {
+	var nodes []Node
+	add := func(ip string, port, statusPort uint, i interface{}) {
+		nodes = append(nodes, Node{Kind: kind, IP: ip, Port: port, StatusPort: statusPort, Info: i})
+	}
+	switch kind {
+	case topo.KindTiDB:
+		for _, n := range info.TiDB {
+			add(n.IP, n.Port, n.StatusPort, n)
+		}
+	case topo.KindTiKV:
+		for _, n := range info.TiKV {
+			add(n.IP, n.Port, n.StatusPort, n)
+		}
+	case topo.KindTiFlash:
+		for _, n := range info.TiFlash {
+			add(n.IP, n.Port, n.StatusPort, n)
+		}
+	case topo.KindPD:
+		for _, n := range info.PD {
+			add(n.IP, n.Port, 0, n)
+		}
+	case topo.KindTiCDC:
+		for _, n := range info.TiCDC {
+			add(n.IP, n.Port, n.StatusPort, n)
+		}
+	case topo.KindTiProxy:
+		for _, n := range info.TiProxy {
+			add(n.IP, n.Port, n.StatusPort, n)
+		}
+	case topo.KindPump:
+		for _, n := range info.Pump {
+			add(n.IP, n.Port, 0, n)
+		}
+	case topo.KindDrainer:
+		for _, n := range info.Drainer {
+			add(n.IP, n.Port, 0, n)
+		}
+	case topo.KindGrafana:
+		if info.Grafana != nil {
+			add(info.Grafana.IP, info.Grafana.Port, 0, *info.Grafana)
+		}
+	case topo.KindAlertManager:
+		if info.AlertManager != nil {
+			add(info.AlertManager.IP, info.AlertManager.Port, 0, *info.AlertManager)
+		}
+	case topo.KindPrometheus:
+		if info.Prometheus != nil {
+			add(info.Prometheus.IP, info.Prometheus.Port, 0, *info.Prometheus)
+		}
+	}
+	return nodes
+}
+
+// nodes returns all nodes in the display order of components.
+func (info *ClusterInfo) nodes() []Node {
+	var nodes []Node
+	for _, kind := range clusterComponents {
+		nodes = append(nodes, info.nodesOf(kind)...)
+	}
+	return nodes
+}
+
+// addNodes adds nodes into the topology according to their component specific info.
+func (info *ClusterInfo) addNodes(nodes []Node) {
+	for _, n := range nodes {
+		switch i := n.Info.(type) {
+		case topology.TiDBInfo:
+			info.TiDB = append(info.TiDB, i)
+		case topology.StoreInfo:
+			if n.Kind == topo.KindTiFlash {
+				info.TiFlash = append(info.TiFlash, i)
+			} else {
+				info.TiKV = append(info.TiKV, i)
+			}
+		case topology.PDInfo:
+			info.PD = append(info.PD, i)
+		case topology.TiCDCInfo:
+			info.TiCDC = append(info.TiCDC, i)
+		case topology.TiProxyInfo:
+			info.TiProxy = append(info.TiProxy, i)
+		case topology.BinlogInfo:
+			if n.Kind == topo.KindDrainer {
+				info.Drainer = append(info.Drainer, i)
+			} else {
+				info.Pump = append(info.Pump, i)
+			}
+		case topology.GrafanaInfo:
+			info.Grafana = &i
+		case topology.AlertManagerInfo:
+			info.AlertManager = &i
+		case topology.PrometheusInfo:
+			info.Prometheus = &i
+		}
+	}
+}
@@ -0,0 +1,70 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package clusterinfo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pingcap/tidb-dashboard/util/rest"
+)
+
+func newMockPDForDeletion(t *testing.T, deleted *[]string) *httptest.Server {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			*deleted = append(*deleted, r.URL.Path)
+			return
+		}
+		switch r.URL.Path {
+		case "/pd/api/v1/health":
+			_, _ = w.Write([]byte(`[{"member_id": 1, "health": true}, {"member_id": 2, "health": false}]`))
+		case "/pd/api/v1/members":
+			_, _ = w.Write([]byte(`{"count": 2, "members": [
+  {"member_id": 1, "client_urls": ["http://10.0.1.1:2379"]},
+  {"member_id": 2, "client_urls": ["http://10.0.1.2:2379"]}
+]}`))
+		case "/pd/api/v1/status":
+			_, _ = w.Write([]byte(`{"start_timestamp": 1}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestDeleteStaleTopology(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var deleted []string
+	ts := newMockPDForDeletion(t, &deleted)
+	s := &Service{
+		params: ServiceParams{PDClient: newTestPDClient(t, ts.URL)},
+		cache:  newTopologyCache(defaultTopologyCacheSize, time.Minute),
+	}
+
+	engine := gin.New()
+	engine.Use(rest.ErrorHandlerFn())
+	engine.DELETE("/topology/store/tombstone", s.deleteTombstoneStores)
+	engine.DELETE("/topology/pd/:address", s.deletePDMember)
+	del := func(url string) int {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, url, nil))
+		return w.Code
+	}
+
+	require.Equal(t, http.StatusOK, del("/topology/store/tombstone"))
+	require.Equal(t, []string{"/pd/api/v1/stores/remove-tombstone"}, deleted)
+
+	// Healthy or unknown members are not removed.
+	require.Equal(t, http.StatusBadRequest, del("/topology/pd/10.0.1.1:2379"))
+	require.Equal(t, http.StatusNotFound, del("/topology/pd/10.0.1.3:2379"))
+	require.Len(t, deleted, 1)
+
+	require.Equal(t, http.StatusOK, del("/topology/pd/10.0.1.2:2379"))
+	require.Equal(t, "/pd/api/v1/members/id/2", deleted[1])
+}
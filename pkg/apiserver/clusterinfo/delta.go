@@ -0,0 +1,98 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package clusterinfo
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/pingcap/tidb-dashboard/util/rest"
+)
+
+const (
+	defaultTopologyVersionsSize = 16
+	defaultTopologyVersionsTTL  = 5 * time.Minute
+
+	mergePatchContentType = "application/merge-patch+json"
+)
+
+// topologyVersion returns the version identifying the document, which is sent as the ETag.
+func topologyVersion(doc []byte) string {
+	sum := sha256.Sum256(doc)
+	return hex.EncodeToString(sum[:16])
+}
+
+// createMergePatch creates a JSON merge patch (RFC 7386) that transforms the original
+// document into the modified document. Arrays are replaced as a whole. As a null in the
+// patch means removal, fields changed to null are removed, which is equivalent for the
+// topology document.
+func createMergePatch(original, modified []byte) ([]byte, error) {
+	var o, m interface{}
+	if err := json.Unmarshal(original, &o); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(modified, &m); err != nil {
+		return nil, err
+	}
+	return json.Marshal(diffMergePatch(o, m))
+}
+
+func diffMergePatch(original, modified interface{}) interface{} {
+	om, ok1 := original.(map[string]interface{})
+	mm, ok2 := modified.(map[string]interface{})
+	if !ok1 || !ok2 {
+		return modified
+	}
+	patch := map[string]interface{}{}
+	for k, ov := range om {
+		mv, ok := mm[k]
+		if !ok {
+			patch[k] = nil
+			continue
+		}
+		if reflect.DeepEqual(ov, mv) {
+			continue
+		}
+		patch[k] = diffMergePatch(ov, mv)
+	}
+	for k, mv := range mm {
+		if _, ok := om[k]; !ok {
+			patch[k] = mv
+		}
+	}
+	return patch
+}
+
+// serveWithDelta responds the JSON document with its ETag. If the client provides the ETag of
+// a previous version in `delta_from` and that version is still known, only a JSON merge patch
+// against it is sent. Otherwise the full document is sent.
+func (s *Service) serveWithDelta(c *gin.Context, v interface{}) {
+	doc, err := json.Marshal(v)
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	version := topologyVersion(doc)
+	s.versions.Set(version, doc)
+	c.Header("ETag", strconv.Quote(version))
+
+	if base := strings.Trim(c.Query("delta_from"), `"`); base != "" {
+		if baseDoc, ok := s.versions.Get(base); ok {
+			patch, err := createMergePatch(baseDoc.([]byte), doc)
+			if err == nil {
+				c.Header("X-Delta-From", strconv.Quote(base))
+				c.Data(http.StatusOK, mergePatchContentType, patch)
+				return
+			}
+		}
+	}
+	c.Data(http.StatusOK, "application/json; charset=utf-8", doc)
+}
@@ -0,0 +1,111 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package clusterinfo
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pingcap/tidb-dashboard/pkg/utils/topology"
+)
+
+// applyMergePatch applies a JSON merge patch (RFC 7386) to the document.
+func applyMergePatch(doc, patch interface{}) interface{} {
+	pm, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	dm, ok := doc.(map[string]interface{})
+	if !ok {
+		dm = map[string]interface{}{}
+	}
+	for k, v := range pm {
+		if v == nil {
+			delete(dm, k)
+			continue
+		}
+		dm[k] = applyMergePatch(dm[k], v)
+	}
+	return dm
+}
+
+func TestCreateMergePatch(t *testing.T) {
+	before := &ClusterInfo{
+		TiDB: []topology.TiDBInfo{{IP: "10.0.1.1", Port: 4000}},
+		PD:   []topology.PDInfo{{IP: "10.0.0.1", Port: 2379}},
+		Grafana: &topology.GrafanaInfo{
+			StandardComponentInfo: topology.StandardComponentInfo{IP: "10.0.3.1", Port: 3000},
+		},
+		RegionMaxKeys: 1440000,
+	}
+	after := &ClusterInfo{
+		TiDB:          []topology.TiDBInfo{{IP: "10.0.1.1", Port: 4000}, {IP: "10.0.1.2", Port: 4000}},
+		PD:            []topology.PDInfo{{IP: "10.0.0.1", Port: 2379}},
+		RegionMaxKeys: 1440000,
+	}
+	beforeDoc, err := json.Marshal(before)
+	require.NoError(t, err)
+	afterDoc, err := json.Marshal(after)
+	require.NoError(t, err)
+
+	patchDoc, err := createMergePatch(beforeDoc, afterDoc)
+	require.NoError(t, err)
+
+	var patch map[string]interface{}
+	require.NoError(t, json.Unmarshal(patchDoc, &patch))
+	require.Len(t, patch, 2)
+	require.Contains(t, patch, "tidb")
+	require.Contains(t, patch, "grafana")
+	require.Nil(t, patch["grafana"])
+
+	var beforeValue, afterValue interface{}
+	require.NoError(t, json.Unmarshal(beforeDoc, &beforeValue))
+	require.NoError(t, json.Unmarshal(afterDoc, &afterValue))
+	patched := applyMergePatch(beforeValue, patch)
+	afterMap := afterValue.(map[string]interface{})
+	delete(afterMap, "grafana") // null fields are removed by the patch
+	require.Equal(t, afterMap, patched)
+
+	patchDoc, err = createMergePatch(afterDoc, afterDoc)
+	require.NoError(t, err)
+	require.JSONEq(t, `{}`, string(patchDoc))
+}
+
+func TestServeWithDelta(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	s := &Service{versions: newTopologyCache(defaultTopologyVersionsSize, time.Minute)}
+	current := &ClusterInfo{RegionMaxSize: "96MiB"}
+	engine := gin.New()
+	engine.GET("/topology/all", func(c *gin.Context) {
+		s.serveWithDelta(c, current)
+	})
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/topology/all", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	etag := w.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+
+	current = &ClusterInfo{RegionMaxSize: "144MiB"}
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/topology/all?delta_from="+etag, nil))
+	require.Equal(t, mergePatchContentType, w.Header().Get("Content-Type"))
+	require.Equal(t, etag, w.Header().Get("X-Delta-From"))
+	require.NotEqual(t, etag, w.Header().Get("ETag"))
+	require.JSONEq(t, `{"region_max_size":"144MiB"}`, w.Body.String())
+
+	// Unknown base falls back to the full document.
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/topology/all?delta_from=unknown", nil))
+	require.Contains(t, w.Header().Get("Content-Type"), "application/json")
+	var info ClusterInfo
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &info))
+	require.Equal(t, "144MiB", info.RegionMaxSize)
+}
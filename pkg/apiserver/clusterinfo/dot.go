@@ -0,0 +1,80 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package clusterinfo
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pingcap/tidb-dashboard/util/distro"
+	"github.com/pingcap/tidb-dashboard/util/topo"
+)
+
+func componentDisplayName(kind topo.Kind) string {
+	switch kind {
+	case topo.KindTiDB:
+		return distro.R().TiDB
+	case topo.KindTiKV:
+		return distro.R().TiKV
+	case topo.KindPD:
+		return distro.R().PD
+	case topo.KindTiFlash:
+		return distro.R().TiFlash
+	case topo.KindTiCDC:
+		return distro.R().TiCDC
+	case topo.KindTiProxy:
+		return distro.R().TiProxy
+	case topo.KindPump:
+		return "Pump"
+	case topo.KindDrainer:
+		return "Drainer"
+	case topo.KindGrafana:
+		return "Grafana"
+	case topo.KindAlertManager:
+		return "AlertManager"
+	case topo.KindPrometheus:
+		return "Prometheus"
+	default:
+		return string(kind)
+	}
+}
+
+func dotNodeID(n Node) string {
+	return strconv.Quote(string(n.Kind) + "/" + n.Address())
+}
+
+// renderDOT renders the cluster topology in the GraphViz DOT language. Nodes are grouped
+// into one subgraph per component, and each node has an edge to every node it depends on.
+func renderDOT(info *ClusterInfo) string {
+	var b strings.Builder
+	b.WriteString("digraph topology {\n")
+	b.WriteString("\trankdir=TB;\n")
+	b.WriteString("\tnode [shape=box];\n")
+
+	for _, kind := range clusterComponents {
+		nodes := info.nodesOf(kind)
+		if len(nodes) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "\tsubgraph %s {\n", strconv.Quote("cluster_"+string(kind)))
+		fmt.Fprintf(&b, "\t\tlabel=%s;\n", strconv.Quote(componentDisplayName(kind)))
+		for _, n := range nodes {
+			fmt.Fprintf(&b, "\t\t%s [label=%s];\n", dotNodeID(n), strconv.Quote(n.Address()))
+		}
+		b.WriteString("\t}\n")
+	}
+
+	for _, kind := range clusterComponents {
+		for _, from := range info.nodesOf(kind) {
+			for _, depKind := range componentDependencies[kind] {
+				for _, to := range info.nodesOf(depKind) {
+					fmt.Fprintf(&b, "\t%s -> %s;\n", dotNodeID(from), dotNodeID(to))
+				}
+			}
+		}
+	}
+
+	b.WriteString("}\n")
+	return b.String()
+}
@@ -0,0 +1,41 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package clusterinfo
+
+import (
+	"testing"
+
+	"github.com/goccy/go-graphviz"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pingcap/tidb-dashboard/pkg/utils/topology"
+)
+
+func TestRenderDOT(t *testing.T) {
+	info := &ClusterInfo{
+		TiDB: []topology.TiDBInfo{
+			{IP: "10.0.1.1", Port: 4000},
+			{IP: "10.0.1.2", Port: 4000},
+		},
+		TiKV: []topology.StoreInfo{
+			{IP: "10.0.2.1", Port: 20160},
+			{IP: "10.0.2.2", Port: 20160},
+			{IP: "10.0.2.3", Port: 20160},
+		},
+		PD: []topology.PDInfo{
+			{IP: "10.0.3.1", Port: 2379},
+		},
+		Prometheus: &topology.PrometheusInfo{
+			StandardComponentInfo: topology.StandardComponentInfo{IP: "10.0.4.1", Port: 9090},
+		},
+	}
+
+	graph, err := graphviz.ParseBytes([]byte(renderDOT(info)))
+	require.NoError(t, err)
+	defer graph.Close()
+
+	require.Equal(t, 7, graph.NumberNodes())
+	require.Equal(t, 4, graph.NumberSubGraph())
+	// TiDB -> PD, TiDB -> TiKV, TiKV -> PD
+	require.Equal(t, 2*1+2*3+3*1, graph.NumberEdges())
+}
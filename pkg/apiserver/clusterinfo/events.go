@@ -0,0 +1,258 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package clusterinfo
+
+import (
+	"context"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pingcap/log"
+	"go.etcd.io/etcd/clientv3"
+	"go.uber.org/zap"
+
+	"github.com/pingcap/tidb-dashboard/pkg/utils/topology"
+	"github.com/pingcap/tidb-dashboard/util/topo"
+)
+
+const (
+	topologyKeyPrefix             = "/topology/"
+	defaultTopologyEventsCapacity = 256
+	topologyWatchRetryInterval    = 5 * time.Second
+	pdMembersPollInterval         = 10 * time.Second
+	// topologySubscriberBuffer is the number of events buffered for each subscriber.
+	// Subscribers falling behind are closed, so that they can resync the full topology.
+	topologySubscriberBuffer = 64
+)
+
+type TopologyEventType string
+
+const (
+	TopologyEventJoin  TopologyEventType = "join"
+	TopologyEventLeave TopologyEventType = "leave"
+)
+
+type TopologyEvent struct {
+	Time      time.Time         `json:"time"`
+	Type      TopologyEventType `json:"type"`
+	Component topo.Kind         `json:"component"`
+	Address   string            `json:"address"`
+}
+
+// topologyEventRing keeps the most recent topology events in a bounded ring buffer.
+// This struct is concurrent-safe.
+type topologyEventRing struct {
+	mu     sync.RWMutex
+	events []TopologyEvent
+	next   int
+	full   bool
+
+	subscribers map[chan TopologyEvent]struct{}
+}
+
+func newTopologyEventRing(capacity int) *topologyEventRing {
+	if capacity <= 0 {
+		capacity = defaultTopologyEventsCapacity
+	}
+	return &topologyEventRing{
+		events:      make([]TopologyEvent, capacity),
+		subscribers: make(map[chan TopologyEvent]struct{}),
+	}
+}
+
+func (r *topologyEventRing) Add(e TopologyEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.events[r.next] = e
+	r.next = (r.next + 1) % len(r.events)
+	if r.next == 0 {
+		r.full = true
+	}
+
+	for ch := range r.subscribers {
+		select {
+		case ch <- e:
+		default:
+			delete(r.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// Subscribe returns a channel receiving events added afterwards, and a function to unsubscribe.
+// The channel is closed when unsubscribed, or when the subscriber falls behind.
+func (r *topologyEventRing) Subscribe() (<-chan TopologyEvent, func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ch := make(chan TopologyEvent, topologySubscriberBuffer)
+	r.subscribers[ch] = struct{}{}
+	return ch, func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if _, ok := r.subscribers[ch]; ok {
+			delete(r.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// Recent returns at most `limit` most recent events, newest first.
+// All events are returned if limit is not positive.
+func (r *topologyEventRing) Recent(limit int) []TopologyEvent {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	size := r.next
+	if r.full {
+		size = len(r.events)
+	}
+	if limit <= 0 || limit > size {
+		limit = size
+	}
+	result := make([]TopologyEvent, 0, limit)
+	for i := 1; i <= limit; i++ {
+		idx := (r.next - i + len(r.events)) % len(r.events)
+		result = append(result, r.events[idx])
+	}
+	return result
+}
+
+var topologyComponentKinds = map[string]topo.Kind{
+	"tidb":         topo.KindTiDB,
+	"tiproxy":      topo.KindTiProxy,
+	"grafana":      topo.KindGrafana,
+	"alertmanager": topo.KindAlertManager,
+	"prometheus":   topo.KindPrometheus,
+}
+
+// parseTopologyEvent converts an etcd event under the topology prefix into a topology event.
+// A node joins when its TTL key is created, and leaves when the key is deleted, including when
+// its lease expires, while the info key may outlive a crashed node. Periodic TTL refreshes are
+// ignored as they do not change the topology.
+func parseTopologyEvent(ev *clientv3.Event, now time.Time) (TopologyEvent, bool) {
+	key := string(ev.Kv.Key)
+	if !strings.HasPrefix(key, topologyKeyPrefix) {
+		return TopologyEvent{}, false
+	}
+	// Key looks like `tidb/ip:port/info`, `tidb/ip:port/ttl` or `grafana`.
+	keyParts := strings.Split(key[len(topologyKeyPrefix):], "/")
+	kind, ok := topologyComponentKinds[keyParts[0]]
+	if !ok {
+		return TopologyEvent{}, false
+	}
+
+	address := ""
+	switch len(keyParts) {
+	case 1:
+	case 3:
+		if keyParts[2] != "ttl" {
+			return TopologyEvent{}, false
+		}
+		address = keyParts[1]
+	default:
+		return TopologyEvent{}, false
+	}
+
+	var typ TopologyEventType
+	switch {
+	case ev.IsCreate():
+		typ = TopologyEventJoin
+	case ev.Type == clientv3.EventTypeDelete:
+		typ = TopologyEventLeave
+	default:
+		return TopologyEvent{}, false
+	}
+
+	return TopologyEvent{
+		Time:      now,
+		Type:      typ,
+		Component: kind,
+		Address:   address,
+	}, true
+}
+
+func (s *Service) handleTopologyWatchResponse(resp clientv3.WatchResponse) {
+	now := time.Now()
+	for _, ev := range resp.Events {
+		if e, ok := parseTopologyEvent(ev, now); ok {
+			s.events.Add(e)
+		}
+	}
+}
+
+// watchTopologyEvents records topology changes until the context is done.
+func (s *Service) watchTopologyEvents(ctx context.Context) {
+	for {
+		watchCh := s.params.EtcdClients.Client().Watch(ctx, topologyKeyPrefix, clientv3.WithPrefix())
+		for resp := range watchCh {
+			if err := resp.Err(); err != nil {
+				log.Warn("Topology watch failed", zap.Error(err))
+				break
+			}
+			s.handleTopologyWatchResponse(resp)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(topologyWatchRetryInterval):
+		}
+	}
+}
+
+// diffPDMembers returns the join and leave events between two sets of PD member addresses.
+func diffPDMembers(prev, current map[string]struct{}, now time.Time) []TopologyEvent {
+	var events []TopologyEvent
+	for addr := range current {
+		if _, ok := prev[addr]; !ok {
+			events = append(events, TopologyEvent{Time: now, Type: TopologyEventJoin, Component: topo.KindPD, Address: addr})
+		}
+	}
+	for addr := range prev {
+		if _, ok := current[addr]; !ok {
+			events = append(events, TopologyEvent{Time: now, Type: TopologyEventLeave, Component: topo.KindPD, Address: addr})
+		}
+	}
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].Address < events[j].Address
+	})
+	return events
+}
+
+// watchPDMembers polls PD members to record membership changes until the context is done,
+// as PD members are not registered under the topology prefix.
+func (s *Service) watchPDMembers(ctx context.Context) {
+	var prev map[string]struct{}
+	ticker := time.NewTicker(pdMembersPollInterval)
+	defer ticker.Stop()
+	for {
+		members, err := topology.FetchPDTopology(s.params.PDClient)
+		if err != nil {
+			log.Warn("Failed to poll PD members", zap.Error(err))
+		} else {
+			current := make(map[string]struct{}, len(members))
+			for _, m := range members {
+				current[net.JoinHostPort(m.IP, strconv.Itoa(int(m.Port)))] = struct{}{}
+			}
+			// Members found in the first poll are not changes.
+			if prev != nil {
+				for _, e := range diffPDMembers(prev, current, time.Now()) {
+					s.events.Add(e)
+				}
+			}
+			prev = current
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
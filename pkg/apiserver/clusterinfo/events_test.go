@@ -0,0 +1,150 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package clusterinfo
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/etcd/clientv3"
+	"go.etcd.io/etcd/mvcc/mvccpb"
+
+	"github.com/pingcap/tidb-dashboard/util/rest"
+	"github.com/pingcap/tidb-dashboard/util/topo"
+)
+
+func newPutEvent(key string, created bool) *clientv3.Event {
+	kv := &mvccpb.KeyValue{Key: []byte(key), CreateRevision: 5, ModRevision: 7}
+	if created {
+		kv.ModRevision = kv.CreateRevision
+	}
+	return &clientv3.Event{Type: clientv3.EventTypePut, Kv: kv}
+}
+
+func newDeleteEvent(key string) *clientv3.Event {
+	return &clientv3.Event{Type: clientv3.EventTypeDelete, Kv: &mvccpb.KeyValue{Key: []byte(key)}}
+}
+
+func TestTopologyEventRing(t *testing.T) {
+	r := newTopologyEventRing(3)
+	require.Empty(t, r.Recent(0))
+
+	for _, addr := range []string{"a", "b", "c", "d"} {
+		r.Add(TopologyEvent{Address: addr})
+	}
+	addresses := func(events []TopologyEvent) []string {
+		result := make([]string, 0, len(events))
+		for _, e := range events {
+			result = append(result, e.Address)
+		}
+		return result
+	}
+	require.Equal(t, []string{"d", "c", "b"}, addresses(r.Recent(0)))
+	require.Equal(t, []string{"d", "c"}, addresses(r.Recent(2)))
+	require.Equal(t, []string{"d", "c", "b"}, addresses(r.Recent(10)))
+}
+
+func TestTopologyEventsFeed(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	s := &Service{events: newTopologyEventRing(16)}
+	s.handleTopologyWatchResponse(clientv3.WatchResponse{Events: []*clientv3.Event{
+		newPutEvent("/topology/tidb/10.0.1.1:4000/info", true),
+		newPutEvent("/topology/tidb/10.0.1.1:4000/ttl", true),
+		newPutEvent("/topology/tidb/10.0.1.1:4000/ttl", false),
+		newPutEvent("/topology/grafana", true),
+		newPutEvent("/topology/unknown/10.0.1.9:1234/info", true),
+	}})
+	// The info key of a crashed node is kept, while its TTL key is deleted when the lease expires.
+	s.handleTopologyWatchResponse(clientv3.WatchResponse{Events: []*clientv3.Event{
+		newDeleteEvent("/topology/tidb/10.0.1.1:4000/ttl"),
+	}})
+	s.handleTopologyWatchResponse(clientv3.WatchResponse{Events: []*clientv3.Event{
+		newDeleteEvent("/topology/tidb/10.0.1.1:4000/info"),
+	}})
+
+	engine := gin.New()
+	engine.Use(rest.ErrorHandlerFn())
+	engine.GET("/topology/events", s.getTopologyEvents)
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/topology/events", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var events []TopologyEvent
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &events))
+	require.Len(t, events, 3)
+	require.Equal(t, TopologyEventLeave, events[0].Type)
+	require.Equal(t, topo.KindTiDB, events[0].Component)
+	require.Equal(t, "10.0.1.1:4000", events[0].Address)
+	require.Equal(t, TopologyEventJoin, events[1].Type)
+	require.Equal(t, topo.KindGrafana, events[1].Component)
+	require.Equal(t, TopologyEventJoin, events[2].Type)
+	require.Equal(t, topo.KindTiDB, events[2].Component)
+	require.False(t, events[2].Time.IsZero())
+
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/topology/events?limit=1", nil))
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &events))
+	require.Len(t, events, 1)
+	require.Equal(t, TopologyEventLeave, events[0].Type)
+
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/topology/events?limit=abc", nil))
+	require.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestParseTopologyEventFromTTL(t *testing.T) {
+	now := time.Now()
+	// The info key is written before the TTL key, and is not removed when the node crashes.
+	_, ok := parseTopologyEvent(newPutEvent("/topology/tidb/10.0.1.1:4000/info", true), now)
+	require.False(t, ok)
+	_, ok = parseTopologyEvent(newDeleteEvent("/topology/tidb/10.0.1.1:4000/info"), now)
+	require.False(t, ok)
+
+	e, ok := parseTopologyEvent(newDeleteEvent("/topology/tiproxy/10.0.1.2:6000/ttl"), now)
+	require.True(t, ok)
+	require.Equal(t, TopologyEvent{Time: now, Type: TopologyEventLeave, Component: topo.KindTiProxy, Address: "10.0.1.2:6000"}, e)
+	// The node registers again after its lease expired.
+	e, ok = parseTopologyEvent(newPutEvent("/topology/tiproxy/10.0.1.2:6000/ttl", true), now)
+	require.True(t, ok)
+	require.Equal(t, TopologyEventJoin, e.Type)
+}
+
+func TestTopologyEventRingSubscribe(t *testing.T) {
+	r := newTopologyEventRing(4)
+	events, unsubscribe := r.Subscribe()
+	r.Add(TopologyEvent{Type: TopologyEventJoin, Address: "10.0.1.1:4000"})
+	require.Equal(t, "10.0.1.1:4000", (<-events).Address)
+
+	unsubscribe()
+	_, ok := <-events
+	require.False(t, ok)
+	unsubscribe()
+
+	// Subscribers falling behind are closed instead of blocking others.
+	events, _ = r.Subscribe()
+	for i := 0; i <= topologySubscriberBuffer; i++ {
+		r.Add(TopologyEvent{Type: TopologyEventJoin})
+	}
+	for range events {
+	}
+	require.Len(t, r.subscribers, 0)
+}
+
+func TestDiffPDMembers(t *testing.T) {
+	now := time.Now()
+	events := diffPDMembers(
+		map[string]struct{}{"10.0.1.1:2379": {}, "10.0.1.2:2379": {}},
+		map[string]struct{}{"10.0.1.2:2379": {}, "10.0.1.3:2379": {}},
+		now,
+	)
+	require.Equal(t, []TopologyEvent{
+		{Time: now, Type: TopologyEventLeave, Component: topo.KindPD, Address: "10.0.1.1:2379"},
+		{Time: now, Type: TopologyEventJoin, Component: topo.KindPD, Address: "10.0.1.3:2379"},
+	}, events)
+}
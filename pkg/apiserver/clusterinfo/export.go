@@ -0,0 +1,238 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package clusterinfo
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/clusterinfo/hostinfo"
+	"github.com/pingcap/tidb-dashboard/pkg/utils/topology"
+	"github.com/pingcap/tidb-dashboard/util/rest"
+)
+
+// Formats of responses, besides JSON. CSV and NDJSON flatten each node or host into a row, so that the inventory
+// can be imported into spreadsheets or CMDBs.
+const (
+	formatJSON   = "json"
+	formatDOT    = "dot"
+	formatCSV    = "csv"
+	formatNDJSON = "ndjson"
+)
+
+const (
+	// failedComponentsHeader lists the components failed to be fetched in flattened responses, which are not
+	// able to carry errors.
+	failedComponentsHeader = "X-Failed-Components"
+	// warningHeader carries the quoted warning of a partially fetched flattened response.
+	warningHeader = "X-Warning"
+)
+
+// responseFormat returns the format in the `format` query, or the format accepted by the `Accept` header. JSON
+// is the default format.
+func responseFormat(c *gin.Context, supported ...string) (string, error) {
+	format := c.Query("format")
+	if format == "" {
+		c.Header("Vary", "Accept")
+		accept := c.GetHeader("Accept")
+		switch {
+		case strings.Contains(accept, "text/csv"):
+			format = formatCSV
+		case strings.Contains(accept, "application/x-ndjson"):
+			format = formatNDJSON
+		default:
+			format = formatJSON
+		}
+	}
+	for _, f := range supported {
+		if f == format {
+			return format, nil
+		}
+	}
+	return "", rest.ErrBadRequest.New("unsupported format %s", format)
+}
+
+// flatTable is rows of fields in the order of the columns. A field is either a scalar, a list of strings or a map
+// of strings, which are joined in CSV.
+type flatTable struct {
+	columns []string
+	rows    [][]interface{}
+}
+
+func csvField(v interface{}) string {
+	switch f := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return f
+	case []string:
+		return strings.Join(f, ";")
+	case map[string]string:
+		pairs := make([]string, 0, len(f))
+		for k, v := range f {
+			pairs = append(pairs, k+"="+v)
+		}
+		sort.Strings(pairs)
+		return strings.Join(pairs, ";")
+	default:
+		return fmt.Sprint(f)
+	}
+}
+
+func (t *flatTable) writeCSV(w *csv.Writer) error {
+	if err := w.Write(t.columns); err != nil {
+		return err
+	}
+	record := make([]string, len(t.columns))
+	for _, row := range t.rows {
+		for i, v := range row {
+			record[i] = csvField(v)
+		}
+		if err := w.Write(record); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+func (t *flatTable) writeNDJSON(enc *json.Encoder) error {
+	for _, row := range t.rows {
+		obj := make(map[string]interface{}, len(t.columns))
+		for i, v := range row {
+			obj[t.columns[i]] = v
+		}
+		if err := enc.Encode(obj); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// serveFlatTable writes the table in CSV or NDJSON as a file named by the name.
+func serveFlatTable(c *gin.Context, format, name string, t *flatTable) {
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, name, format))
+	if format == formatCSV {
+		c.Header("Content-Type", "text/csv")
+		c.Status(http.StatusOK)
+		_ = t.writeCSV(csv.NewWriter(c.Writer))
+		return
+	}
+	c.Header("Content-Type", "application/x-ndjson")
+	c.Status(http.StatusOK)
+	_ = t.writeNDJSON(json.NewEncoder(c.Writer))
+}
+
+var componentStatusNames = map[topology.ComponentStatus]string{
+	topology.ComponentStatusUnreachable: "unreachable",
+	topology.ComponentStatusUp:          "up",
+	topology.ComponentStatusTombstone:   "tombstone",
+	topology.ComponentStatusOffline:     "offline",
+	topology.ComponentStatusDown:        "down",
+}
+
+// topologyTable flattens nodes of all components, with fields common to components.
+func topologyTable(info *ClusterInfo) *flatTable {
+	t := &flatTable{columns: []string{
+		"component", "address", "ip", "port", "status_port", "version", "git_hash", "deploy_path", "status",
+		"start_timestamp", "labels",
+	}}
+	for _, n := range info.nodes() {
+		var fields struct {
+			Version        string                    `json:"version"`
+			GitHash        string                    `json:"git_hash"`
+			DeployPath     string                    `json:"deploy_path"`
+			Status         *topology.ComponentStatus `json:"status"`
+			StartTimestamp int64                     `json:"start_timestamp"`
+			Labels         map[string]string         `json:"labels"`
+		}
+		if data, err := json.Marshal(n.Info); err == nil {
+			_ = json.Unmarshal(data, &fields)
+		}
+		var status interface{}
+		if fields.Status != nil {
+			status = componentStatusNames[*fields.Status]
+		}
+		var statusPort interface{}
+		if n.StatusPort != 0 {
+			statusPort = n.StatusPort
+		}
+		var labels interface{}
+		if len(fields.Labels) > 0 {
+			labels = fields.Labels
+		}
+		t.rows = append(t.rows, []interface{}{
+			string(n.Kind), n.Address(), n.IP, n.Port, statusPort, fields.Version, fields.GitHash, fields.DeployPath,
+			status, fields.StartTimestamp, labels,
+		})
+	}
+	return t
+}
+
+func serveTopologyTable(c *gin.Context, format string, info *ClusterInfo) {
+	if len(info.Errors) > 0 {
+		failed := make([]string, 0, len(info.Errors))
+		for kind := range info.Errors {
+			failed = append(failed, string(kind))
+		}
+		sort.Strings(failed)
+		c.Header(failedComponentsHeader, strings.Join(failed, ","))
+	}
+	serveFlatTable(c, format, "topology", topologyTable(info))
+}
+
+// hostsTable flattens the hardware and usage of each host. Disks are summed over partitions.
+func hostsTable(hosts []*hostinfo.Info) *flatTable {
+	t := &flatTable{columns: []string{
+		"host", "cpu_arch", "cpu_logical_cores", "cpu_physical_cores", "cpu_idle", "cpu_system", "memory_used",
+		"memory_total", "disk_free", "disk_total", "partitions", "instances",
+	}}
+	for _, h := range hosts {
+		row := make([]interface{}, len(t.columns))
+		row[0] = h.Host
+		if h.CPUInfo != nil {
+			row[1], row[2], row[3] = h.CPUInfo.Arch, h.CPUInfo.LogicalCores, h.CPUInfo.PhysicalCores
+		}
+		if h.CPUUsage != nil {
+			row[4], row[5] = h.CPUUsage.Idle, h.CPUUsage.System
+		}
+		if h.MemoryUsage != nil {
+			row[6], row[7] = h.MemoryUsage.Used, h.MemoryUsage.Total
+		}
+		if len(h.Partitions) > 0 {
+			free, total := 0, 0
+			partitions := make([]string, 0, len(h.Partitions))
+			for _, p := range h.Partitions {
+				free += p.Free
+				total += p.Total
+				partitions = append(partitions, p.Path+":"+p.FSType)
+			}
+			sort.Strings(partitions)
+			row[8], row[9], row[10] = free, total, partitions
+		}
+		if len(h.Instances) > 0 {
+			instances := make([]string, 0, len(h.Instances))
+			for addr, i := range h.Instances {
+				instances = append(instances, i.Type+"/"+addr)
+			}
+			sort.Strings(instances)
+			row[11] = instances
+		}
+		t.rows = append(t.rows, row)
+	}
+	return t
+}
+
+func serveHostsTable(c *gin.Context, format string, hosts []*hostinfo.Info, warning error) {
+	if warning != nil {
+		c.Header(warningHeader, strconv.Quote(rest.NewErrorResponse(warning).Message))
+	}
+	serveFlatTable(c, format, "hosts", hostsTable(hosts))
+}
@@ -0,0 +1,108 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package clusterinfo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/clusterinfo/hostinfo"
+	"github.com/pingcap/tidb-dashboard/pkg/utils/topology"
+	"github.com/pingcap/tidb-dashboard/util/rest"
+	"github.com/pingcap/tidb-dashboard/util/topo"
+)
+
+func TestResponseFormat(t *testing.T) {
+	format := func(target, accept string) (string, error) {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodGet, target, nil)
+		c.Request.Header.Set("Accept", accept)
+		return responseFormat(c, formatJSON, formatCSV, formatNDJSON)
+	}
+
+	f, err := format("/topology/all", "application/json, text/plain, */*")
+	require.NoError(t, err)
+	require.Equal(t, formatJSON, f)
+	f, err = format("/topology/all", "text/csv")
+	require.NoError(t, err)
+	require.Equal(t, formatCSV, f)
+	f, err = format("/topology/all", "application/x-ndjson")
+	require.NoError(t, err)
+	require.Equal(t, formatNDJSON, f)
+	// The query takes precedence over the Accept header.
+	f, err = format("/topology/all?format=ndjson", "text/csv")
+	require.NoError(t, err)
+	require.Equal(t, formatNDJSON, f)
+	_, err = format("/topology/all?format=dot", "")
+	require.Error(t, err)
+}
+
+func TestServeTopologyTable(t *testing.T) {
+	info := &ClusterInfo{
+		TiDB: []topology.TiDBInfo{
+			{IP: "10.0.1.1", Port: 4000, StatusPort: 10080, Version: "v7.5.0", Status: topology.ComponentStatusUp, StartTimestamp: 1700000000},
+		},
+		TiKV: []topology.StoreInfo{
+			{IP: "10.0.2.1", Port: 20160, StatusPort: 20180, Version: "v7.5.0", Status: topology.ComponentStatusOffline, Labels: map[string]string{"zone": "z1", "host": "h1"}},
+		},
+		PD: []topology.PDInfo{
+			{IP: "10.0.3.1", Port: 2379, Version: "v7.5.0", DeployPath: "/deploy/pd, 1", Status: topology.ComponentStatusUp},
+		},
+		Grafana: &topology.GrafanaInfo{StandardComponentInfo: topology.StandardComponentInfo{IP: "10.0.4.1", Port: 3000}},
+		Errors:  map[topo.Kind]rest.ErrorResponse{topo.KindTiFlash: {}, topo.KindTiCDC: {}},
+	}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	serveTopologyTable(c, formatCSV, info)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "text/csv", w.Header().Get("Content-Type"))
+	require.Equal(t, "ticdc,tiflash", w.Header().Get(failedComponentsHeader))
+	require.Equal(t, `component,address,ip,port,status_port,version,git_hash,deploy_path,status,start_timestamp,labels
+pd,10.0.3.1:2379,10.0.3.1,2379,,v7.5.0,,"/deploy/pd, 1",up,0,
+tidb,10.0.1.1:4000,10.0.1.1,4000,10080,v7.5.0,,,up,1700000000,
+tikv,10.0.2.1:20160,10.0.2.1,20160,20180,v7.5.0,,,offline,0,host=h1;zone=z1
+grafana,10.0.4.1:3000,10.0.4.1,3000,,,,,,0,
+`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(w)
+	serveTopologyTable(c, formatNDJSON, &ClusterInfo{TiKV: info.TiKV})
+	require.Equal(t, "application/x-ndjson", w.Header().Get("Content-Type"))
+	require.Empty(t, w.Header().Get(failedComponentsHeader))
+	require.JSONEq(t, `{"component":"tikv","address":"10.0.2.1:20160","ip":"10.0.2.1","port":20160,"status_port":20180,
+		"version":"v7.5.0","git_hash":"","deploy_path":"","status":"offline","start_timestamp":0,
+		"labels":{"host":"h1","zone":"z1"}}`, w.Body.String())
+}
+
+func TestServeHostsTable(t *testing.T) {
+	h := hostinfo.NewHostInfo("10.0.1.1")
+	h.CPUInfo = &hostinfo.CPUInfo{Arch: "x86_64", LogicalCores: 16, PhysicalCores: 8}
+	h.MemoryUsage = &hostinfo.MemoryUsageInfo{Used: 1024, Total: 4096}
+	h.Partitions["/data1"] = &hostinfo.PartitionInfo{Path: "/data1", FSType: "ext4", Free: 10, Total: 100}
+	h.Partitions["/data2"] = &hostinfo.PartitionInfo{Path: "/data2", FSType: "xfs", Free: 20, Total: 200}
+	h.Instances["10.0.1.1:4000"] = &hostinfo.InstanceInfo{Type: "tidb"}
+	h.Instances["10.0.1.1:20160"] = &hostinfo.InstanceInfo{Type: "tikv"}
+	hosts := []*hostinfo.Info{h, hostinfo.NewHostInfo("10.0.1.2")}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	serveHostsTable(c, formatCSV, hosts, nil)
+	require.Empty(t, w.Header().Get(warningHeader))
+	require.Equal(t, `host,cpu_arch,cpu_logical_cores,cpu_physical_cores,cpu_idle,cpu_system,memory_used,memory_total,disk_free,disk_total,partitions,instances
+10.0.1.1,x86_64,16,8,,,1024,4096,30,300,/data1:ext4;/data2:xfs,tidb/10.0.1.1:4000;tikv/10.0.1.1:20160
+10.0.1.2,,,,,,,,,,,
+`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(w)
+	serveHostsTable(c, formatNDJSON, hosts[1:], rest.ErrBadRequest.New("cluster_load is not available"))
+	require.Equal(t, `"cluster_load is not available"`, w.Header().Get(warningHeader))
+	require.JSONEq(t, `{"host":"10.0.1.2","cpu_arch":null,"cpu_logical_cores":null,"cpu_physical_cores":null,"cpu_idle":null,
+		"cpu_system":null,"memory_used":null,"memory_total":null,"disk_free":null,"disk_total":null,"partitions":null,
+		"instances":null}`, w.Body.String())
+}
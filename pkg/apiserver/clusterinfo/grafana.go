@@ -0,0 +1,67 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package clusterinfo
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+
+	"github.com/pingcap/tidb-dashboard/util/topo"
+)
+
+// grafanaDashboardUIDs are the UIDs of the Grafana dashboards of each component,
+// as deployed by tiup and TiDB Operator.
+var grafanaDashboardUIDs = map[topo.Kind]string{
+	topo.KindTiDB:    "000000011",
+	topo.KindTiKV:    "RDVQiEzZz",
+	topo.KindTiFlash: "SVbh2xUWk",
+	topo.KindPD:      "Q6RuHYIWk",
+	topo.KindTiCDC:   "YiGL8hBZ0",
+}
+
+// grafanaDashboardURL returns the URL of the component dashboard filtered to the instance,
+// whose metrics are labeled by the instance address.
+func grafanaDashboardURL(grafanaBase string, kind topo.Kind, instance string) string {
+	uid, ok := grafanaDashboardUIDs[kind]
+	if !ok {
+		return ""
+	}
+	query := url.Values{}
+	query.Set("orgId", "1")
+	query.Set("var-instance", instance)
+	return fmt.Sprintf("%s/d/%s?%s", grafanaBase, uid, query.Encode())
+}
+
+// fillGrafanaURLs fills the Grafana dashboard URL of each node when Grafana is discovered.
+func (info *ClusterInfo) fillGrafanaURLs() {
+	if info.Grafana == nil {
+		return
+	}
+	base := fmt.Sprintf("http://%s", net.JoinHostPort(info.Grafana.IP, strconv.Itoa(int(info.Grafana.Port))))
+	instance := func(ip string, port uint) string {
+		return net.JoinHostPort(ip, strconv.Itoa(int(port)))
+	}
+
+	for i := range info.TiDB {
+		n := &info.TiDB[i]
+		n.GrafanaURL = grafanaDashboardURL(base, topo.KindTiDB, instance(n.IP, n.StatusPort))
+	}
+	for i := range info.TiKV {
+		n := &info.TiKV[i]
+		n.GrafanaURL = grafanaDashboardURL(base, topo.KindTiKV, instance(n.IP, n.StatusPort))
+	}
+	for i := range info.TiFlash {
+		n := &info.TiFlash[i]
+		n.GrafanaURL = grafanaDashboardURL(base, topo.KindTiFlash, instance(n.IP, n.StatusPort))
+	}
+	for i := range info.PD {
+		n := &info.PD[i]
+		n.GrafanaURL = grafanaDashboardURL(base, topo.KindPD, instance(n.IP, n.Port))
+	}
+	for i := range info.TiCDC {
+		n := &info.TiCDC[i]
+		n.GrafanaURL = grafanaDashboardURL(base, topo.KindTiCDC, instance(n.IP, n.StatusPort))
+	}
+}
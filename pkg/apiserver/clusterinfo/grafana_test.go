@@ -0,0 +1,33 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package clusterinfo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pingcap/tidb-dashboard/pkg/utils/topology"
+)
+
+func TestFillGrafanaURLs(t *testing.T) {
+	info := &ClusterInfo{
+		TiKV:    []topology.StoreInfo{{IP: "10.0.2.1", Port: 20160, StatusPort: 20180}},
+		TiProxy: []topology.TiProxyInfo{{IP: "10.0.5.1", Port: 6000, StatusPort: 3080}},
+		Grafana: &topology.GrafanaInfo{
+			StandardComponentInfo: topology.StandardComponentInfo{IP: "10.0.3.1", Port: 3000},
+		},
+	}
+	info.fillGrafanaURLs()
+	require.Equal(t, "http://10.0.3.1:3000/d/RDVQiEzZz?orgId=1&var-instance=10.0.2.1%3A20180", info.TiKV[0].GrafanaURL)
+	// Components without a known dashboard have no link.
+	require.Empty(t, info.TiProxy[0].GrafanaURL)
+}
+
+func TestFillGrafanaURLsWithoutGrafana(t *testing.T) {
+	info := &ClusterInfo{
+		TiKV: []topology.StoreInfo{{IP: "10.0.2.1", Port: 20160, StatusPort: 20180}},
+	}
+	info.fillGrafanaURLs()
+	require.Empty(t, info.TiKV[0].GrafanaURL)
+}
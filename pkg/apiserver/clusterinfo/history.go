@@ -0,0 +1,328 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package clusterinfo
+
+import (
+	"hash/fnv"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/pingcap/tidb-dashboard/util/topo"
+)
+
+const (
+	defaultDownGraceProbes = 3
+	// Nodes vanished from the topology are kept for the retention, in case they come back soon.
+	defaultProbeHistoryRetention = 10 * time.Minute
+	probeHistoryShards           = 16
+	// maxProbeSamples is the max number of recent probes kept for each node.
+	maxProbeSamples = 32
+	// maxTransitionSeries limits the number of addresses labeled in the transition counter.
+	// Transitions of other addresses are counted under transitionOverflowAddress.
+	maxTransitionSeries       = 1000
+	transitionOverflowAddress = "other"
+
+	transitionUpToDown = "up_to_down"
+	transitionDownToUp = "down_to_up"
+)
+
+var nodeTransitionCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "tidb_dashboard",
+	Subsystem: "topology",
+	Name:      "node_transitions_total",
+	Help:      "Number of liveness transitions of each node.",
+}, []string{"component", "address", "transition"})
+
+type NodeLiveness string
+
+const (
+	NodeLivenessUp NodeLiveness = "up"
+	// NodeLivenessDegraded means the node failed recent probes but is still within the grace period.
+	NodeLivenessDegraded NodeLiveness = "degraded"
+	NodeLivenessDown     NodeLiveness = "down"
+	// NodeLivenessUnknown means the node was not probed, e.g. no probe worker slot was available in time.
+	NodeLivenessUnknown NodeLiveness = "unknown"
+)
+
+// probeSample is the result of a single probe of a node.
+type probeSample struct {
+	Time  time.Time
+	Alive bool
+}
+
+type nodeProbeState struct {
+	component topo.Kind
+	address   string
+
+	consecutiveFailures int
+	liveness            NodeLiveness
+	// failingSince is the time of the first failed probe in the current failure streak.
+	failingSince time.Time
+	lastError    string
+	// lastAliveAt and lastLatencyMs are of the last successful probe.
+	lastAliveAt   time.Time
+	lastLatencyMs float64
+
+	// samples are the most recent probes of the node, oldest first.
+	samples []probeSample
+	// present is false when the node is absent from the last probe cycle, after which
+	// the node is evicted when not seen within the retention.
+	present  bool
+	lastSeen time.Time
+}
+
+type probeHistoryShard struct {
+	mu    sync.Mutex
+	nodes map[string]*nodeProbeState
+}
+
+// probeHistory tracks the probe results of each node across probe cycles, so that a node is only
+// reported down after failing a number of consecutive probes. Nodes are sharded by key, so that
+// concurrent probe cycles do not contend on a single lock. This struct is concurrent-safe.
+type probeHistory struct {
+	graceProbes int
+	retention   time.Duration
+	shards      [probeHistoryShards]probeHistoryShard
+
+	// expectedMinimum is the minimum number of up nodes of each component.
+	expectedMinimum map[topo.Kind]int
+
+	// mu guards the states across nodes below.
+	mu sync.Mutex
+	// labeled are nodes having their own series in the transition counter.
+	labeled map[string]struct{}
+	// belowMinimumSince is the time since when each component has fewer up nodes than expected.
+	belowMinimumSince map[topo.Kind]time.Time
+
+	now func() time.Time
+}
+
+func newProbeHistory(graceProbes int, retention time.Duration, expectedMinimum map[topo.Kind]int) *probeHistory {
+	if graceProbes <= 0 {
+		graceProbes = defaultDownGraceProbes
+	}
+	if retention <= 0 {
+		retention = defaultProbeHistoryRetention
+	}
+	h := &probeHistory{
+		graceProbes:       graceProbes,
+		retention:         retention,
+		expectedMinimum:   expectedMinimum,
+		labeled:           make(map[string]struct{}),
+		belowMinimumSince: make(map[topo.Kind]time.Time),
+		now:               time.Now,
+	}
+	for i := range h.shards {
+		h.shards[i].nodes = make(map[string]*nodeProbeState)
+	}
+	return h
+}
+
+func probeHistoryKey(kind topo.Kind, address string) string {
+	return string(kind) + "/" + address
+}
+
+func (h *probeHistory) shardOf(key string) *probeHistoryShard {
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(key))
+	return &h.shards[hash.Sum32()%probeHistoryShards]
+}
+
+// record updates the history with the results of a probe cycle and fills the liveness of each result.
+// Nodes absent from the cycle are evicted after the retention. It returns the results of nodes that were
+// not down before but are down now.
+func (h *probeHistory) record(results []ProbeResult) []ProbeResult {
+	now := h.now()
+	upNodes := make(map[topo.Kind]int)
+	seen := make(map[string]struct{}, len(results))
+	var turnedDown []ProbeResult
+	for i := range results {
+		r := &results[i]
+		key := probeHistoryKey(r.Component, r.Address)
+		seen[key] = struct{}{}
+		shard := h.shardOf(key)
+		shard.mu.Lock()
+		if h.recordNode(shard, key, r, now) {
+			turnedDown = append(turnedDown, *r)
+		}
+		shard.mu.Unlock()
+		if r.Liveness != NodeLivenessDown {
+			upNodes[r.Component]++
+		}
+	}
+
+	for i := range h.shards {
+		shard := &h.shards[i]
+		shard.mu.Lock()
+		for key, state := range shard.nodes {
+			if _, ok := seen[key]; ok {
+				continue
+			}
+			state.present = false
+			if now.Sub(state.lastSeen) > h.retention {
+				delete(shard.nodes, key)
+			}
+		}
+		shard.mu.Unlock()
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for kind, minimum := range h.expectedMinimum {
+		if upNodes[kind] >= minimum {
+			delete(h.belowMinimumSince, kind)
+		} else if _, ok := h.belowMinimumSince[kind]; !ok {
+			h.belowMinimumSince[kind] = now
+		}
+	}
+	return turnedDown
+}
+
+// recordNode must be called with the shard locked. It returns true if the node turns down.
+func (h *probeHistory) recordNode(shard *probeHistoryShard, key string, r *ProbeResult, now time.Time) bool {
+	state, ok := shard.nodes[key]
+	if !ok {
+		state = &nodeProbeState{component: r.Component, address: r.Address}
+		shard.nodes[key] = state
+	}
+	state.present = true
+	state.lastSeen = now
+	r.ProbedAt = now
+	if r.Liveness == NodeLivenessUnknown {
+		// A node that was not probed keeps its history untouched.
+		r.LastAliveAt = state.lastAliveAt
+		return false
+	}
+
+	state.samples = append(state.samples, probeSample{Time: now, Alive: r.Alive})
+	if len(state.samples) > maxProbeSamples {
+		state.samples = state.samples[len(state.samples)-maxProbeSamples:]
+	}
+
+	if r.Alive {
+		state.consecutiveFailures = 0
+		state.failingSince = time.Time{}
+		state.lastAliveAt = now
+		state.lastLatencyMs = r.LatencyMs
+		r.Liveness = NodeLivenessUp
+	} else {
+		if state.consecutiveFailures == 0 {
+			state.failingSince = now
+		}
+		state.consecutiveFailures++
+		state.lastError = r.Error
+		if state.consecutiveFailures < h.graceProbes {
+			r.Liveness = NodeLivenessDegraded
+		} else {
+			r.Liveness = NodeLivenessDown
+		}
+	}
+
+	turnedDown := false
+	switch {
+	case state.liveness == "":
+	case r.Liveness == NodeLivenessDown && state.liveness != NodeLivenessDown:
+		h.countTransition(key, r.Component, r.Address, transitionUpToDown)
+		turnedDown = true
+	case r.Liveness == NodeLivenessUp && state.liveness == NodeLivenessDown:
+		h.countTransition(key, r.Component, r.Address, transitionDownToUp)
+	}
+	state.liveness = r.Liveness
+	r.LastAliveAt = state.lastAliveAt
+	return turnedDown
+}
+
+// annotate fills the liveness of the results from the history without recording them, for probes that do
+// not count towards the grace period. Nodes absent from the history are up or degraded by the probe itself.
+func (h *probeHistory) annotate(results []ProbeResult) {
+	now := h.now()
+	for i := range results {
+		r := &results[i]
+		r.ProbedAt = now
+		key := probeHistoryKey(r.Component, r.Address)
+		shard := h.shardOf(key)
+		shard.mu.Lock()
+		state, ok := shard.nodes[key]
+		switch {
+		case r.Liveness == NodeLivenessUnknown:
+		case ok && state.liveness != "":
+			r.Liveness = state.liveness
+		case r.Alive:
+			r.Liveness = NodeLivenessUp
+		default:
+			r.Liveness = NodeLivenessDegraded
+		}
+		if ok {
+			r.LastAliveAt = state.lastAliveAt
+		}
+		shard.mu.Unlock()
+		if r.Alive {
+			r.LastAliveAt = now
+		}
+	}
+}
+
+// snapshot returns a copy of the state of all nodes, which can be read without locking.
+func (h *probeHistory) snapshot() []nodeProbeState {
+	var states []nodeProbeState
+	for i := range h.shards {
+		shard := &h.shards[i]
+		shard.mu.Lock()
+		for _, state := range shard.nodes {
+			s := *state
+			s.samples = append([]probeSample(nil), state.samples...)
+			states = append(states, s)
+		}
+		shard.mu.Unlock()
+	}
+	return states
+}
+
+// latest returns the last probe result of each node present in the last probe cycle, ordered by component
+// and address.
+func (h *probeHistory) latest() []ProbeResult {
+	results := make([]ProbeResult, 0)
+	for _, state := range h.snapshot() {
+		if !state.present || len(state.samples) == 0 {
+			continue
+		}
+		last := state.samples[len(state.samples)-1]
+		r := ProbeResult{
+			Component:   state.component,
+			Address:     state.address,
+			Alive:       last.Alive,
+			Liveness:    state.liveness,
+			ProbedAt:    last.Time,
+			LastAliveAt: state.lastAliveAt,
+		}
+		if last.Alive {
+			r.LatencyMs = state.lastLatencyMs
+		} else {
+			r.Error = state.lastError
+		}
+		results = append(results, r)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Component != results[j].Component {
+			return results[i].Component < results[j].Component
+		}
+		return results[i].Address < results[j].Address
+	})
+	return results
+}
+
+func (h *probeHistory) countTransition(key string, kind topo.Kind, address, transition string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.labeled[key]; !ok {
+		if len(h.labeled) >= maxTransitionSeries {
+			address = transitionOverflowAddress
+		} else {
+			h.labeled[key] = struct{}{}
+		}
+	}
+	nodeTransitionCounter.WithLabelValues(string(kind), address, transition).Inc()
+}
@@ -0,0 +1,239 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package clusterinfo
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pingcap/tidb-dashboard/util/topo"
+)
+
+func recordProbe(h *probeHistory, address string, alive bool) NodeLiveness {
+	results := []ProbeResult{{Component: topo.KindTiKV, Address: address, Alive: alive}}
+	h.record(results)
+	return results[0].Liveness
+}
+
+func TestProbeHistoryGracePeriod(t *testing.T) {
+	h := newProbeHistory(3, 0, nil)
+	const addr = "10.0.2.1:20160"
+
+	require.Equal(t, NodeLivenessUp, recordProbe(h, addr, true))
+	// A single failed probe within the grace period does not flip the node to down.
+	require.Equal(t, NodeLivenessDegraded, recordProbe(h, addr, false))
+	require.Equal(t, NodeLivenessUp, recordProbe(h, addr, true))
+
+	require.Equal(t, NodeLivenessDegraded, recordProbe(h, addr, false))
+	require.Equal(t, NodeLivenessDegraded, recordProbe(h, addr, false))
+	require.Equal(t, NodeLivenessDown, recordProbe(h, addr, false))
+	require.Equal(t, NodeLivenessDown, recordProbe(h, addr, false))
+	require.Equal(t, NodeLivenessUp, recordProbe(h, addr, true))
+}
+
+func TestProbeHistoryReportsTurnedDown(t *testing.T) {
+	h := newProbeHistory(2, 0, nil)
+	probe := func(alive bool) []ProbeResult {
+		return h.record([]ProbeResult{{Component: topo.KindTiKV, Address: "10.0.2.1:20160", Alive: alive, Error: "refused"}})
+	}
+
+	require.Empty(t, probe(true))
+	require.Empty(t, probe(false))
+	turnedDown := probe(false)
+	require.Len(t, turnedDown, 1)
+	require.Equal(t, NodeLivenessDown, turnedDown[0].Liveness)
+	require.Equal(t, "refused", turnedDown[0].Error)
+	// A node staying down is only reported once.
+	require.Empty(t, probe(false))
+	require.Empty(t, probe(true))
+}
+
+func TestProbeHistoryNeverAlive(t *testing.T) {
+	h := newProbeHistory(3, 0, nil)
+	// A new node is also given the grace period, e.g. when it is still starting.
+	require.Equal(t, NodeLivenessDegraded, recordProbe(h, "10.0.2.1:20160", false))
+	require.Equal(t, NodeLivenessDegraded, recordProbe(h, "10.0.2.1:20160", false))
+	require.Equal(t, NodeLivenessDown, recordProbe(h, "10.0.2.1:20160", false))
+}
+
+func TestProbeHistoryAnnotate(t *testing.T) {
+	h := newProbeHistory(2, 0, nil)
+	require.Equal(t, NodeLivenessUp, recordProbe(h, "10.0.2.1:20160", true))
+	require.Equal(t, NodeLivenessDegraded, recordProbe(h, "10.0.2.1:20160", false))
+
+	results := []ProbeResult{
+		{Component: topo.KindTiKV, Address: "10.0.2.1:20160", Error: "refused"},
+		{Component: topo.KindTiKV, Address: "10.0.2.2:20160", Error: "refused"},
+		{Component: topo.KindTiKV, Address: "10.0.2.3:20160", Alive: true},
+	}
+	// Annotated probes do not count towards the grace period, however many times they fail.
+	for i := 0; i < 3; i++ {
+		h.annotate(results)
+	}
+	require.Equal(t, NodeLivenessDegraded, results[0].Liveness)
+	require.False(t, results[0].LastAliveAt.IsZero())
+	require.Equal(t, NodeLivenessDegraded, results[1].Liveness)
+	require.Equal(t, NodeLivenessUp, results[2].Liveness)
+	require.Len(t, h.snapshot(), 1)
+	require.Len(t, h.snapshot()[0].samples, 2)
+	require.Empty(t, h.alarms())
+}
+
+func TestProbeHistoryEvictsVanishedNodes(t *testing.T) {
+	h := newProbeHistory(3, time.Minute, nil)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	h.now = func() time.Time { return now }
+
+	require.Equal(t, NodeLivenessUp, recordProbe(h, "10.0.2.1:20160", true))
+	// 10.0.2.1 is absent from the cycle, but its history is kept within the retention.
+	now = now.Add(30 * time.Second)
+	require.Equal(t, NodeLivenessUp, recordProbe(h, "10.0.2.2:20160", true))
+	require.Len(t, h.snapshot(), 2)
+	require.Equal(t, NodeLivenessDegraded, recordProbe(h, "10.0.2.1:20160", false))
+
+	// 10.0.2.1 is evicted after being absent for longer than the retention.
+	now = now.Add(2 * time.Minute)
+	require.Equal(t, NodeLivenessUp, recordProbe(h, "10.0.2.2:20160", true))
+	snapshot := h.snapshot()
+	require.Len(t, snapshot, 1)
+	require.Equal(t, "10.0.2.2:20160", snapshot[0].address)
+	// The history of 10.0.2.1 starts over.
+	require.Equal(t, NodeLivenessDegraded, recordProbe(h, "10.0.2.1:20160", false))
+	for _, state := range h.snapshot() {
+		if state.address == "10.0.2.1:20160" {
+			require.Equal(t, 1, state.consecutiveFailures)
+			require.True(t, state.lastAliveAt.IsZero())
+		}
+	}
+}
+
+func TestProbeHistoryLatest(t *testing.T) {
+	h := newProbeHistory(3, time.Minute, nil)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	h.now = func() time.Time { return now }
+	aliveAt := now
+
+	h.record([]ProbeResult{
+		{Component: topo.KindTiKV, Address: "10.0.2.1:20160", Alive: true, LatencyMs: 1.5},
+		{Component: topo.KindPD, Address: "10.0.1.1:2379", Alive: true, LatencyMs: 0.5},
+	})
+	now = now.Add(30 * time.Second)
+	results := []ProbeResult{
+		{Component: topo.KindTiKV, Address: "10.0.2.1:20160", Error: "connection refused"},
+		{Component: topo.KindPD, Address: "10.0.1.1:2379", Alive: true, LatencyMs: 0.7},
+	}
+	h.record(results)
+	require.Equal(t, aliveAt, results[0].LastAliveAt)
+	require.Equal(t, now, results[0].ProbedAt)
+
+	require.Equal(t, []ProbeResult{
+		{Component: topo.KindPD, Address: "10.0.1.1:2379", Alive: true, LatencyMs: 0.7, Liveness: NodeLivenessUp, ProbedAt: now, LastAliveAt: now},
+		{Component: topo.KindTiKV, Address: "10.0.2.1:20160", Error: "connection refused", Liveness: NodeLivenessDegraded, ProbedAt: now, LastAliveAt: aliveAt},
+	}, h.latest())
+
+	// Nodes absent from the last cycle are not returned.
+	h.record([]ProbeResult{{Component: topo.KindPD, Address: "10.0.1.1:2379", Alive: true}})
+	require.Len(t, h.latest(), 1)
+}
+
+func TestProbeHistoryBoundedSamples(t *testing.T) {
+	h := newProbeHistory(3, 0, nil)
+	for i := 0; i < maxProbeSamples*2; i++ {
+		recordProbe(h, "10.0.2.1:20160", i%2 == 0)
+	}
+	snapshot := h.snapshot()
+	require.Len(t, snapshot, 1)
+	require.Len(t, snapshot[0].samples, maxProbeSamples)
+	require.False(t, snapshot[0].samples[maxProbeSamples-1].Alive)
+}
+
+func TestProbeHistoryConcurrentUpdates(t *testing.T) {
+	h := newProbeHistory(3, time.Minute, nil)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results := make([]ProbeResult, 0, 50)
+			for j := 0; j < 50; j++ {
+				results = append(results, ProbeResult{
+					Component: topo.KindTiKV,
+					Address:   "10.0.3." + strconv.Itoa(j) + ":20160",
+					Alive:     (i+j)%3 != 0,
+				})
+			}
+			for k := 0; k < 20; k++ {
+				h.record(results)
+				_ = h.snapshot()
+				_ = h.alarms()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	snapshot := h.snapshot()
+	require.Len(t, snapshot, 50)
+	for _, state := range snapshot {
+		require.Len(t, state.samples, maxProbeSamples)
+	}
+}
+
+func TestProbeHistoryTransitionCounters(t *testing.T) {
+	h := newProbeHistory(2, 0, nil)
+	const addr = "10.0.9.1:20160"
+	upToDown := nodeTransitionCounter.WithLabelValues(string(topo.KindTiKV), addr, transitionUpToDown)
+	downToUp := nodeTransitionCounter.WithLabelValues(string(topo.KindTiKV), addr, transitionDownToUp)
+
+	recordProbe(h, addr, true)
+	recordProbe(h, addr, false) // degraded
+	require.Equal(t, 0.0, testutil.ToFloat64(upToDown))
+	recordProbe(h, addr, false) // down
+	recordProbe(h, addr, false)
+	require.Equal(t, 1.0, testutil.ToFloat64(upToDown))
+	require.Equal(t, 0.0, testutil.ToFloat64(downToUp))
+	recordProbe(h, addr, true)
+	require.Equal(t, 1.0, testutil.ToFloat64(downToUp))
+}
+
+func TestProbeHistoryTransitionCardinality(t *testing.T) {
+	h := newProbeHistory(1, 0, nil)
+	for i := 0; i < maxTransitionSeries; i++ {
+		h.labeled[strconv.Itoa(i)] = struct{}{}
+	}
+	overflow := nodeTransitionCounter.WithLabelValues(string(topo.KindTiKV), transitionOverflowAddress, transitionUpToDown)
+	before := testutil.ToFloat64(overflow)
+
+	recordProbe(h, "10.0.9.2:20160", true)
+	recordProbe(h, "10.0.9.2:20160", false)
+	require.Equal(t, before+1, testutil.ToFloat64(overflow))
+}
+
+func TestProbeHistoryIgnoresUnknown(t *testing.T) {
+	h := newProbeHistory(2, 0, nil)
+	const addr = "10.0.2.1:20160"
+	unknown := func() ProbeResult {
+		results := []ProbeResult{{Component: topo.KindTiKV, Address: addr, Error: "slot timeout", Liveness: NodeLivenessUnknown}}
+		require.Empty(t, h.record(results))
+		return results[0]
+	}
+
+	require.Equal(t, NodeLivenessUp, recordProbe(h, addr, true))
+	// Slot timeouts neither count as failures nor reset the failure streak.
+	for i := 0; i < 5; i++ {
+		require.Equal(t, NodeLivenessUnknown, unknown().Liveness)
+	}
+	require.Equal(t, NodeLivenessDegraded, recordProbe(h, addr, false))
+	r := unknown()
+	require.Equal(t, NodeLivenessUnknown, r.Liveness)
+	require.False(t, r.LastAliveAt.IsZero())
+	require.Equal(t, NodeLivenessDown, recordProbe(h, addr, false))
+	require.Len(t, h.snapshot()[0].samples, 3)
+	alarms := h.alarms()
+	require.Len(t, alarms, 1)
+	require.Equal(t, addr, alarms[0].Address)
+}
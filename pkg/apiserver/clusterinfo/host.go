@@ -7,17 +7,19 @@ import (
 
 	"github.com/pingcap/log"
 	"github.com/samber/lo"
+	"go.etcd.io/etcd/clientv3"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 
 	"github.com/pingcap/tidb-dashboard/pkg/apiserver/clusterinfo/hostinfo"
+	"github.com/pingcap/tidb-dashboard/pkg/pd"
 	"github.com/pingcap/tidb-dashboard/pkg/utils/topology"
 )
 
 // fetchAllInstanceHosts fetches all hosts in the cluster and return in ascending order.
-func (s *Service) fetchAllInstanceHosts() ([]string, error) {
+func (s *Service) fetchAllInstanceHosts(pdClient *pd.Client, etcdClient *clientv3.Client) ([]string, error) {
 	allHostsMap := make(map[string]struct{})
-	pdInfo, err := topology.FetchPDTopology(s.params.PDClient)
+	pdInfo, err := topology.FetchPDTopology(pdClient)
 	if err != nil {
 		return nil, err
 	}
@@ -25,7 +27,7 @@ func (s *Service) fetchAllInstanceHosts() ([]string, error) {
 		allHostsMap[i.IP] = struct{}{}
 	}
 
-	tikvInfo, tiFlashInfo, err := topology.FetchStoreTopology(s.params.PDClient)
+	tikvInfo, tiFlashInfo, err := topology.FetchStoreTopology(pdClient)
 	if err != nil {
 		return nil, err
 	}
@@ -36,7 +38,7 @@ func (s *Service) fetchAllInstanceHosts() ([]string, error) {
 		allHostsMap[i.IP] = struct{}{}
 	}
 
-	tidbInfo, err := topology.FetchTiDBTopology(s.lifecycleCtx, s.params.EtcdClient)
+	tidbInfo, err := topology.FetchTiDBTopology(s.lifecycleCtx, etcdClient)
 	if err != nil {
 		return nil, err
 	}
@@ -44,7 +46,7 @@ func (s *Service) fetchAllInstanceHosts() ([]string, error) {
 		allHostsMap[i.IP] = struct{}{}
 	}
 
-	ticdcInfo, err := topology.FetchTiCDCTopology(s.lifecycleCtx, s.params.EtcdClient)
+	ticdcInfo, err := topology.FetchTiCDCTopology(s.lifecycleCtx, etcdClient)
 	if err != nil {
 		return nil, err
 	}
@@ -52,7 +54,7 @@ func (s *Service) fetchAllInstanceHosts() ([]string, error) {
 		allHostsMap[i.IP] = struct{}{}
 	}
 
-	tiproxyInfo, err := topology.FetchTiProxyTopology(s.lifecycleCtx, s.params.EtcdClient)
+	tiproxyInfo, err := topology.FetchTiProxyTopology(s.lifecycleCtx, etcdClient)
 	if err != nil {
 		return nil, err
 	}
@@ -68,8 +70,8 @@ func (s *Service) fetchAllInstanceHosts() ([]string, error) {
 
 // fetchAllHostsInfo fetches all hosts and their information.
 // Note: The returned data and error may both exist.
-func (s *Service) fetchAllHostsInfo(db *gorm.DB) ([]*hostinfo.Info, error) {
-	allHosts, err := s.fetchAllInstanceHosts()
+func (s *Service) fetchAllHostsInfo(db *gorm.DB, pdClient *pd.Client, etcdClient *clientv3.Client) ([]*hostinfo.Info, error) {
+	allHosts, err := s.fetchAllInstanceHosts(pdClient, etcdClient)
 	if err != nil {
 		return nil, err
 	}
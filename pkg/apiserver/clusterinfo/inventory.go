@@ -0,0 +1,88 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package clusterinfo
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+)
+
+// storeInventory is an authoritative store list maintained by operators, e.g. exported from a CMDB.
+type storeInventory struct {
+	Stores []string `json:"stores"`
+}
+
+// InventoryStore is a store merged from the PD store list and the inventory.
+type InventoryStore struct {
+	Address         string `json:"address"`
+	OnlyInPD        bool   `json:"only_in_pd"`
+	OnlyInInventory bool   `json:"only_in_inventory"`
+}
+
+type StoreInventoryResponse struct {
+	Stores []InventoryStore `json:"stores"`
+	// Match is true when PD and the inventory have the same stores.
+	Match bool `json:"match"`
+}
+
+// loadStoreInventory loads the inventory from a HTTP(S) URL or a local file.
+func (s *Service) loadStoreInventory(ctx context.Context, location string) ([]string, error) {
+	var data []byte
+	var err error
+	if strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://") {
+		data, err = s.params.HTTPClient.SendRequest(ctx, location, http.MethodGet, nil, ErrInvalidTopologySource, "store inventory")
+	} else {
+		data, err = os.ReadFile(location)
+		if err != nil {
+			err = ErrInvalidTopologySource.Wrap(err, "failed to read store inventory %s", location)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var inventory storeInventory
+	if err := json.Unmarshal(data, &inventory); err != nil {
+		return nil, ErrInvalidTopologySource.Wrap(err, "failed to parse store inventory %s", location)
+	}
+	return inventory.Stores, nil
+}
+
+// mergeStoreInventory merges the stores known by PD and the inventory, flagging stores present in only one of them.
+func mergeStoreInventory(pdStores, inventory []string) StoreInventoryResponse {
+	const (
+		inPD = 1 << iota
+		inInventory
+	)
+	presence := map[string]int{}
+	for _, addr := range pdStores {
+		presence[addr] |= inPD
+	}
+	for _, addr := range inventory {
+		presence[addr] |= inInventory
+	}
+
+	resp := StoreInventoryResponse{
+		Stores: make([]InventoryStore, 0, len(presence)),
+		Match:  true,
+	}
+	for addr, p := range presence {
+		store := InventoryStore{
+			Address:         addr,
+			OnlyInPD:        p == inPD,
+			OnlyInInventory: p == inInventory,
+		}
+		if store.OnlyInPD || store.OnlyInInventory {
+			resp.Match = false
+		}
+		resp.Stores = append(resp.Stores, store)
+	}
+	sort.Slice(resp.Stores, func(i, j int) bool {
+		return resp.Stores[i].Address < resp.Stores[j].Address
+	})
+	return resp
+}
@@ -0,0 +1,51 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package clusterinfo
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pingcap/tidb-dashboard/pkg/httpc"
+)
+
+func TestMergeStoreInventory(t *testing.T) {
+	resp := mergeStoreInventory(
+		[]string{"10.0.2.1:20160", "10.0.2.2:20160", "10.0.2.3:20160"},
+		[]string{"10.0.2.1:20160", "10.0.2.3:20160", "10.0.2.4:20160"},
+	)
+	require.False(t, resp.Match)
+	require.Equal(t, []InventoryStore{
+		{Address: "10.0.2.1:20160"},
+		{Address: "10.0.2.2:20160", OnlyInPD: true},
+		{Address: "10.0.2.3:20160"},
+		{Address: "10.0.2.4:20160", OnlyInInventory: true},
+	}, resp.Stores)
+
+	resp = mergeStoreInventory([]string{"10.0.2.1:20160"}, []string{"10.0.2.1:20160"})
+	require.True(t, resp.Match)
+}
+
+func TestLoadStoreInventory(t *testing.T) {
+	const inventory = `{"stores": ["10.0.2.1:20160", "10.0.2.4:20160"]}`
+	s := &Service{params: ServiceParams{HTTPClient: &httpc.Client{}}}
+
+	stores, err := s.loadStoreInventory(context.Background(), writeTestFile(t, "inventory.json", inventory))
+	require.NoError(t, err)
+	require.Equal(t, []string{"10.0.2.1:20160", "10.0.2.4:20160"}, stores)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(inventory))
+	}))
+	defer ts.Close()
+	stores, err = s.loadStoreInventory(context.Background(), ts.URL)
+	require.NoError(t, err)
+	require.Equal(t, []string{"10.0.2.1:20160", "10.0.2.4:20160"}, stores)
+
+	_, err = s.loadStoreInventory(context.Background(), writeTestFile(t, "invalid.json", "invalid"))
+	require.Error(t, err)
+}
@@ -0,0 +1,203 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package clusterinfo
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/joomcode/errorx"
+	"github.com/prometheus/client_golang/prometheus"
+
+	commonUtils "github.com/pingcap/tidb-dashboard/pkg/utils"
+	"github.com/pingcap/tidb-dashboard/util/topo"
+)
+
+const (
+	defaultProbeConcurrency = 16
+	defaultProbeSlotTimeout = 3 * time.Second
+	defaultProbeTimeout     = 2 * time.Second
+)
+
+var (
+	probeInflightGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "tidb_dashboard",
+		Subsystem: "topology",
+		Name:      "probe_inflight",
+		Help:      "Number of liveness probes being executed.",
+	})
+	probeQueueDepthGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "tidb_dashboard",
+		Subsystem: "topology",
+		Name:      "probe_queue_depth",
+		Help:      "Number of liveness probes waiting for a worker slot.",
+	})
+	probeSlotTimeoutCounter = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "tidb_dashboard",
+		Subsystem: "topology",
+		Name:      "probe_slot_timeout_total",
+		Help:      "Number of liveness probes that timed out waiting for a worker slot.",
+	})
+)
+
+func registerProbeMetrics() {
+	commonUtils.RegisterMetrics(probeInflightGauge, probeQueueDepthGauge, probeSlotTimeoutCounter, nodeTransitionCounter)
+}
+
+type ProbeResult struct {
+	Component topo.Kind `json:"component"`
+	Address   string    `json:"address"`
+	Alive     bool      `json:"alive"`
+	LatencyMs float64   `json:"latency_ms"`
+	Error     string    `json:"error,omitempty"`
+	// Liveness is the debounced status considering previous probes of the node.
+	Liveness NodeLiveness `json:"liveness"`
+	// ProbedAt is the time of the probe.
+	ProbedAt time.Time `json:"probed_at"`
+	// LastAliveAt is the time of the last successful probe, which is zero if the node has never been alive.
+	LastAliveAt time.Time `json:"last_alive_at"`
+}
+
+// prober checks the liveness of nodes by connecting to their (status) addresses.
+// The number of concurrent probes is limited by the worker slots.
+type prober struct {
+	slots        chan struct{}
+	slotTimeout  time.Duration
+	probeTimeout time.Duration
+	dialContext  func(ctx context.Context, network, address string) (net.Conn, error)
+}
+
+func newProber(concurrency int, slotTimeout, probeTimeout time.Duration) *prober {
+	if concurrency <= 0 {
+		concurrency = defaultProbeConcurrency
+	}
+	dialer := &net.Dialer{}
+	return &prober{
+		slots:        make(chan struct{}, concurrency),
+		slotTimeout:  slotTimeout,
+		probeTimeout: probeTimeout,
+		dialContext:  dialer.DialContext,
+	}
+}
+
+func (p *prober) acquireSlot(ctx context.Context) error {
+	probeQueueDepthGauge.Inc()
+	defer probeQueueDepthGauge.Dec()
+
+	timer := time.NewTimer(p.slotTimeout)
+	defer timer.Stop()
+	select {
+	case p.slots <- struct{}{}:
+		probeInflightGauge.Inc()
+		return nil
+	case <-timer.C:
+		probeSlotTimeoutCounter.Inc()
+		return ErrProbeSlotTimeout.New("timed out waiting for a probe worker slot")
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (p *prober) releaseSlot() {
+	probeInflightGauge.Dec()
+	<-p.slots
+}
+
+// probe checks whether the address accepts connections.
+func (p *prober) probe(ctx context.Context, address string) (time.Duration, error) {
+	if err := p.acquireSlot(ctx); err != nil {
+		return 0, err
+	}
+	defer p.releaseSlot()
+
+	ctx, cancel := context.WithTimeout(ctx, p.probeTimeout)
+	defer cancel()
+
+	start := time.Now()
+	conn, err := p.dialContext(ctx, "tcp", address)
+	if err != nil {
+		return 0, err
+	}
+	_ = conn.Close()
+	return time.Since(start), nil
+}
+
+// probeAny probes all addresses concurrently and returns as soon as any of them is alive,
+// cancelling the remaining probes. If none is alive, the last error is returned, preferring
+// errors of the addresses actually probed over slot timeouts.
+func (p *prober) probeAny(ctx context.Context, addresses []string) (time.Duration, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		latency time.Duration
+		err     error
+	}
+	ch := make(chan result, len(addresses))
+	for _, addr := range addresses {
+		go func(addr string) {
+			latency, err := p.probe(ctx, addr)
+			ch <- result{latency, err}
+		}(addr)
+	}
+
+	var lastErr error
+	for range addresses {
+		r := <-ch
+		if r.err == nil {
+			return r.latency, nil
+		}
+		if lastErr == nil || !errorx.IsOfType(r.err, ErrProbeSlotTimeout) {
+			lastErr = r.err
+		}
+	}
+	return 0, lastErr
+}
+
+type probeMode string
+
+const (
+	// probeModeStatus probes the status address of each node.
+	probeModeStatus probeMode = "status"
+	// probeModeAny probes both the service address and the status address of each node,
+	// and the node is considered alive as soon as any address responds.
+	probeModeAny probeMode = "any"
+)
+
+// probeNodes probes all nodes concurrently, limited by the worker slots.
+func (p *prober) probeNodes(ctx context.Context, nodes []Node, mode probeMode) []ProbeResult {
+	results := make([]ProbeResult, len(nodes))
+	var wg sync.WaitGroup
+	for i, n := range nodes {
+		wg.Add(1)
+		go func(i int, n Node) {
+			defer wg.Done()
+			r := ProbeResult{
+				Component: n.Kind,
+				Address:   n.Address(),
+			}
+			var latency time.Duration
+			var err error
+			if mode == probeModeAny {
+				latency, err = p.probeAny(ctx, n.ProbeAddresses())
+			} else {
+				latency, err = p.probe(ctx, n.ProbeAddress())
+			}
+			if errorx.IsOfType(err, ErrProbeSlotTimeout) {
+				// The node is not probed at all, which says nothing about its liveness.
+				r.Error = err.Error()
+				r.Liveness = NodeLivenessUnknown
+			} else if err != nil {
+				r.Error = err.Error()
+			} else {
+				r.Alive = true
+				r.LatencyMs = float64(latency.Microseconds()) / 1000
+			}
+			results[i] = r
+		}(i, n)
+	}
+	wg.Wait()
+	return results
+}
@@ -0,0 +1,158 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package clusterinfo
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pingcap/tidb-dashboard/util/topo"
+)
+
+func TestProberMetrics(t *testing.T) {
+	p := newProber(2, time.Minute, time.Minute)
+	release := make(chan struct{})
+	dialed := make(chan struct{}, 5)
+	p.dialContext = func(ctx context.Context, network, address string) (net.Conn, error) {
+		dialed <- struct{}{}
+		<-release
+		c1, c2 := net.Pipe()
+		_ = c2.Close()
+		return c1, nil
+	}
+
+	nodes := make([]Node, 5)
+	for i := range nodes {
+		nodes[i] = Node{Kind: topo.KindTiDB, IP: "10.0.1.1", Port: uint(4000 + i)}
+	}
+
+	done := make(chan []ProbeResult)
+	go func() {
+		done <- p.probeNodes(context.Background(), nodes, probeModeStatus)
+	}()
+
+	// Only 2 probes can be executed at the same time, others are waiting for slots.
+	<-dialed
+	<-dialed
+	require.Eventually(t, func() bool {
+		return testutil.ToFloat64(probeInflightGauge) == 2 && testutil.ToFloat64(probeQueueDepthGauge) == 3
+	}, time.Second, 10*time.Millisecond)
+
+	close(release)
+	results := <-done
+	require.Len(t, results, 5)
+	for _, r := range results {
+		require.True(t, r.Alive)
+	}
+	require.Equal(t, float64(0), testutil.ToFloat64(probeInflightGauge))
+	require.Equal(t, float64(0), testutil.ToFloat64(probeQueueDepthGauge))
+}
+
+func TestProberSlotTimeout(t *testing.T) {
+	p := newProber(1, 10*time.Millisecond, time.Minute)
+	release := make(chan struct{})
+	p.dialContext = func(ctx context.Context, network, address string) (net.Conn, error) {
+		<-release
+		c1, c2 := net.Pipe()
+		_ = c2.Close()
+		return c1, nil
+	}
+	defer close(release)
+
+	before := testutil.ToFloat64(probeSlotTimeoutCounter)
+	go func() {
+		_, _ = p.probe(context.Background(), "10.0.1.1:4000")
+	}()
+	require.Eventually(t, func() bool {
+		return testutil.ToFloat64(probeInflightGauge) == 1
+	}, time.Second, time.Millisecond)
+
+	_, err := p.probe(context.Background(), "10.0.1.1:4001")
+	require.Error(t, err)
+	require.Equal(t, before+1, testutil.ToFloat64(probeSlotTimeoutCounter))
+}
+
+func TestProbeAnyReturnsOnFirstAlive(t *testing.T) {
+	p := newProber(4, time.Minute, time.Minute)
+	slowStarted := make(chan struct{})
+	slowCancelled := make(chan struct{})
+	p.dialContext = func(ctx context.Context, network, address string) (net.Conn, error) {
+		if address == "10.0.1.1:10080" {
+			// The slow address only returns when the probe is cancelled.
+			close(slowStarted)
+			<-ctx.Done()
+			close(slowCancelled)
+			return nil, ctx.Err()
+		}
+		<-slowStarted
+		c1, c2 := net.Pipe()
+		_ = c2.Close()
+		return c1, nil
+	}
+
+	node := Node{Kind: topo.KindTiDB, IP: "10.0.1.1", Port: 4000, StatusPort: 10080}
+	start := time.Now()
+	_, err := p.probeAny(context.Background(), node.ProbeAddresses())
+	require.NoError(t, err)
+	require.Less(t, time.Since(start), time.Second)
+
+	select {
+	case <-slowCancelled:
+	case <-time.After(time.Second):
+		require.FailNow(t, "slow probe is not cancelled")
+	}
+}
+
+func TestProbeAnyAllFailed(t *testing.T) {
+	p := newProber(4, time.Minute, time.Minute)
+	p.dialContext = func(ctx context.Context, network, address string) (net.Conn, error) {
+		return nil, fmt.Errorf("connection refused")
+	}
+
+	node := Node{Kind: topo.KindTiDB, IP: "10.0.1.1", Port: 4000, StatusPort: 10080}
+	_, err := p.probeAny(context.Background(), node.ProbeAddresses())
+	require.EqualError(t, err, "connection refused")
+}
+
+func TestProbeNodesSlotTimeoutIsUnknown(t *testing.T) {
+	p := newProber(1, 10*time.Millisecond, time.Minute)
+	release := make(chan struct{})
+	p.dialContext = func(ctx context.Context, network, address string) (net.Conn, error) {
+		<-release
+		c1, c2 := net.Pipe()
+		_ = c2.Close()
+		return c1, nil
+	}
+	go func() {
+		_, _ = p.probe(context.Background(), "10.0.1.1:4000")
+	}()
+	require.Eventually(t, func() bool {
+		return len(p.slots) == 1
+	}, time.Second, time.Millisecond)
+
+	nodes := []Node{{Kind: topo.KindTiDB, IP: "10.0.1.2", Port: 4000, StatusPort: 10080}}
+	results := p.probeNodes(context.Background(), nodes, probeModeStatus)
+	close(release)
+	require.False(t, results[0].Alive)
+	require.NotEmpty(t, results[0].Error)
+	require.Equal(t, NodeLivenessUnknown, results[0].Liveness)
+}
+
+func TestProbeAnyPrefersProbeErrors(t *testing.T) {
+	p := newProber(1, 10*time.Millisecond, time.Minute)
+	p.dialContext = func(ctx context.Context, network, address string) (net.Conn, error) {
+		// Hold the only slot, so that the other address times out waiting for it.
+		time.Sleep(50 * time.Millisecond)
+		return nil, fmt.Errorf("connection refused")
+	}
+
+	node := Node{Kind: topo.KindTiDB, IP: "10.0.1.1", Port: 4000, StatusPort: 10080}
+	_, err := p.probeAny(context.Background(), node.ProbeAddresses())
+	require.EqualError(t, err, "connection refused")
+}
@@ -0,0 +1,72 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package clusterinfo
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pingcap/log"
+	"github.com/prometheus/common/expfmt"
+	"go.uber.org/zap"
+
+	"github.com/pingcap/tidb-dashboard/pkg/utils/topology"
+)
+
+const (
+	tidbConnectionsMetric = "tidb_server_connections"
+	defaultLoadTimeout    = 2 * time.Second
+)
+
+// fetchTiDBConnectionCount reads the number of current connections from the metrics of a TiDB instance.
+func (s *Service) fetchTiDBConnectionCount(ctx context.Context, statusAddr string) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.loadTimeout)
+	defer cancel()
+
+	uri := fmt.Sprintf("%s://%s/metrics", s.params.Config.GetClusterHTTPScheme(), statusAddr)
+	data, err := s.params.HTTPClient.SendRequest(ctx, uri, http.MethodGet, nil, ErrFetchLoadFailed, "TiDB")
+	if err != nil {
+		return 0, err
+	}
+
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(bytes.NewReader(data))
+	if err != nil {
+		return 0, ErrFetchLoadFailed.Wrap(err, "failed to parse TiDB metrics")
+	}
+	family, ok := families[tidbConnectionsMetric]
+	if !ok {
+		return 0, ErrFetchLoadFailed.New("metric %s not found", tidbConnectionsMetric)
+	}
+	count := 0.0
+	for _, m := range family.GetMetric() {
+		count += m.GetGauge().GetValue()
+	}
+	return int(count), nil
+}
+
+// fillTiDBConnectionCounts fetches the connection count of all TiDB instances concurrently.
+// The count is -1 for instances failed to be fetched.
+func (s *Service) fillTiDBConnectionCounts(ctx context.Context, instances []topology.TiDBInfo) {
+	var wg sync.WaitGroup
+	for i := range instances {
+		wg.Add(1)
+		go func(i *topology.TiDBInfo) {
+			defer wg.Done()
+			statusAddr := net.JoinHostPort(i.IP, strconv.Itoa(int(i.StatusPort)))
+			count, err := s.fetchTiDBConnectionCount(ctx, statusAddr)
+			if err != nil {
+				log.Warn("Failed to fetch TiDB connection count", zap.String("address", statusAddr), zap.Error(err))
+				count = -1
+			}
+			i.ConnectionCount = count
+		}(&instances[i])
+	}
+	wg.Wait()
+}
@@ -0,0 +1,74 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package clusterinfo
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pingcap/tidb-dashboard/pkg/config"
+	"github.com/pingcap/tidb-dashboard/pkg/httpc"
+	"github.com/pingcap/tidb-dashboard/pkg/utils/topology"
+)
+
+const testTiDBMetrics = `# HELP tidb_server_connections Number of connections.
+# TYPE tidb_server_connections gauge
+tidb_server_connections{resource_group="default"} 7
+tidb_server_connections{resource_group="rg1"} 3
+# HELP tidb_server_uptime TiDB uptime since last restart.
+# TYPE tidb_server_uptime gauge
+tidb_server_uptime 1234
+`
+
+func newTiDBInfoFromURL(t *testing.T, rawURL string) topology.TiDBInfo {
+	host, port, err := net.SplitHostPort(rawURL[len("http://"):])
+	require.NoError(t, err)
+	p, err := strconv.Atoi(port)
+	require.NoError(t, err)
+	return topology.TiDBInfo{IP: host, Port: 4000, StatusPort: uint(p)}
+}
+
+func TestFillTiDBConnectionCounts(t *testing.T) {
+	metricsServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/metrics", r.URL.Path)
+		_, _ = w.Write([]byte(testTiDBMetrics))
+	}))
+	defer metricsServer.Close()
+
+	brokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer brokenServer.Close()
+
+	slowServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer slowServer.Close()
+
+	s := &Service{
+		params: ServiceParams{
+			Config:     &config.Config{},
+			HTTPClient: &httpc.Client{},
+		},
+		loadTimeout: 200 * time.Millisecond,
+	}
+	instances := []topology.TiDBInfo{
+		newTiDBInfoFromURL(t, metricsServer.URL),
+		newTiDBInfoFromURL(t, brokenServer.URL),
+		newTiDBInfoFromURL(t, slowServer.URL),
+	}
+	s.fillTiDBConnectionCounts(context.Background(), instances)
+	require.Equal(t, 10, instances[0].ConnectionCount)
+	require.Equal(t, -1, instances[1].ConnectionCount)
+	require.Equal(t, -1, instances[2].ConnectionCount)
+}
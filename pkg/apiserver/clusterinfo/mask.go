@@ -0,0 +1,338 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package clusterinfo
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/clusterinfo/hostinfo"
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/utils"
+	"github.com/pingcap/tidb-dashboard/pkg/utils/topology"
+)
+
+// addressMasker masks node IPs in topology responses for users without the write privilege.
+// An IPv4 address keeps its last octet, e.g. `*.*.*.15#1a2b3c4d`, while other hosts are fully
+// masked. The hash is keyed by a random secret, so that it is stable for correlating nodes
+// within the process, but can not be reversed by enumerating addresses.
+type addressMasker struct {
+	key []byte
+}
+
+func newAddressMasker() *addressMasker {
+	key := make([]byte, 32)
+	_, _ = rand.Read(key)
+	return &addressMasker{key: key}
+}
+
+func (m *addressMasker) maskIP(ip string) string {
+	if ip == "" {
+		return ""
+	}
+	mac := hmac.New(sha256.New, m.key)
+	_, _ = mac.Write([]byte(ip))
+	hash := hex.EncodeToString(mac.Sum(nil))[:8]
+
+	if parsed := net.ParseIP(ip); parsed != nil && parsed.To4() != nil {
+		octets := strings.Split(parsed.To4().String(), ".")
+		return "*.*.*." + octets[3] + "#" + hash
+	}
+	return "*#" + hash
+}
+
+func (m *addressMasker) maskAddress(address string) string {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return m.maskIP(address)
+	}
+	return m.maskIP(host) + ":" + port
+}
+
+// maskMessage masks the host of the address in a message, e.g. a dial error containing the probed address.
+func (m *addressMasker) maskMessage(message, address string) string {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		host = address
+	}
+	if message == "" || host == "" {
+		return message
+	}
+	return strings.ReplaceAll(message, host, m.maskIP(host))
+}
+
+// shouldMask returns whether the current user is not allowed to see full addresses.
+func (s *Service) shouldMask(c *gin.Context) bool {
+	if s.masker == nil {
+		return false
+	}
+	session := utils.GetSession(c)
+	return session == nil || !session.IsWriteable
+}
+
+// maskForUser masks the topology response when the current user is not allowed to see full addresses.
+// Responses other than node lists are returned as it is.
+func (s *Service) maskForUser(c *gin.Context, v interface{}) interface{} {
+	if !s.shouldMask(c) {
+		return v
+	}
+
+	switch v := v.(type) {
+	case *ClusterInfo:
+		return s.masker.maskClusterInfo(v)
+	case []topology.TiDBInfo:
+		return s.masker.maskTiDB(v)
+	case []topology.TiCDCInfo:
+		return s.masker.maskTiCDC(v)
+	case []topology.TiProxyInfo:
+		return s.masker.maskTiProxy(v)
+	case []topology.PDInfo:
+		return s.masker.maskPD(v)
+	case []TopologyEvent:
+		return s.masker.maskEvents(v)
+	case []ProbeResult:
+		return s.masker.maskProbeResults(v)
+	case []TopologyAlarm:
+		return s.masker.maskAlarms(v)
+	case *topology.StoreInfo:
+		if v == nil {
+			return v
+		}
+		return &s.masker.maskStores([]topology.StoreInfo{*v})[0]
+	case StoreInventoryResponse:
+		return s.masker.maskStoreInventory(v)
+	case *topology.StoreLocation:
+		if v == nil {
+			return v
+		}
+		return s.masker.maskStoreLocation(v)
+	case AlertsResponse:
+		return s.masker.maskAlerts(v)
+	case []*hostinfo.Info:
+		return s.masker.maskHosts(v)
+	case StoreTopologyResponse:
+		return StoreTopologyResponse{
+			TiKV:    s.masker.maskStores(v.TiKV),
+			TiFlash: s.masker.maskStores(v.TiFlash),
+		}
+	default:
+		return v
+	}
+}
+
+// maskClusterInfo returns a masked copy, leaving the cached topology untouched.
+// Grafana links are dropped as they contain the addresses.
+func (m *addressMasker) maskClusterInfo(info *ClusterInfo) *ClusterInfo {
+	masked := *info
+	masked.TiDB = m.maskTiDB(info.TiDB)
+	masked.TiKV = m.maskStores(info.TiKV)
+	masked.TiFlash = m.maskStores(info.TiFlash)
+	masked.PD = m.maskPD(info.PD)
+	masked.TiCDC = m.maskTiCDC(info.TiCDC)
+	masked.TiProxy = m.maskTiProxy(info.TiProxy)
+	masked.Pump = m.maskBinlog(info.Pump)
+	masked.Drainer = m.maskBinlog(info.Drainer)
+	if info.Grafana != nil {
+		masked.Grafana = &topology.GrafanaInfo{StandardComponentInfo: m.maskStandard(info.Grafana.StandardComponentInfo)}
+	}
+	if info.AlertManager != nil {
+		masked.AlertManager = &topology.AlertManagerInfo{StandardComponentInfo: m.maskStandard(info.AlertManager.StandardComponentInfo)}
+	}
+	if info.Prometheus != nil {
+		masked.Prometheus = &topology.PrometheusInfo{StandardComponentInfo: m.maskStandard(info.Prometheus.StandardComponentInfo)}
+	}
+	return &masked
+}
+
+func (m *addressMasker) maskStandard(info topology.StandardComponentInfo) topology.StandardComponentInfo {
+	info.IP = m.maskIP(info.IP)
+	return info
+}
+
+func (m *addressMasker) maskTiDB(nodes []topology.TiDBInfo) []topology.TiDBInfo {
+	if nodes == nil {
+		return nil
+	}
+	masked := make([]topology.TiDBInfo, 0, len(nodes))
+	for _, n := range nodes {
+		n.IP = m.maskIP(n.IP)
+		n.GrafanaURL = ""
+		masked = append(masked, n)
+	}
+	return masked
+}
+
+func (m *addressMasker) maskStores(nodes []topology.StoreInfo) []topology.StoreInfo {
+	if nodes == nil {
+		return nil
+	}
+	masked := make([]topology.StoreInfo, 0, len(nodes))
+	for _, n := range nodes {
+		n.IP = m.maskIP(n.IP)
+		n.GrafanaURL = ""
+		masked = append(masked, n)
+	}
+	return masked
+}
+
+func (m *addressMasker) maskPD(nodes []topology.PDInfo) []topology.PDInfo {
+	if nodes == nil {
+		return nil
+	}
+	masked := make([]topology.PDInfo, 0, len(nodes))
+	for _, n := range nodes {
+		n.IP = m.maskIP(n.IP)
+		n.GrafanaURL = ""
+		masked = append(masked, n)
+	}
+	return masked
+}
+
+func (m *addressMasker) maskTiCDC(nodes []topology.TiCDCInfo) []topology.TiCDCInfo {
+	if nodes == nil {
+		return nil
+	}
+	masked := make([]topology.TiCDCInfo, 0, len(nodes))
+	for _, n := range nodes {
+		n.IP = m.maskIP(n.IP)
+		n.GrafanaURL = ""
+		masked = append(masked, n)
+	}
+	return masked
+}
+
+func (m *addressMasker) maskTiProxy(nodes []topology.TiProxyInfo) []topology.TiProxyInfo {
+	if nodes == nil {
+		return nil
+	}
+	masked := make([]topology.TiProxyInfo, 0, len(nodes))
+	for _, n := range nodes {
+		n.IP = m.maskIP(n.IP)
+		n.GrafanaURL = ""
+		masked = append(masked, n)
+	}
+	return masked
+}
+
+func (m *addressMasker) maskBinlog(nodes []topology.BinlogInfo) []topology.BinlogInfo {
+	if nodes == nil {
+		return nil
+	}
+	masked := make([]topology.BinlogInfo, 0, len(nodes))
+	for _, n := range nodes {
+		n.IP = m.maskIP(n.IP)
+		// Node IDs are the host names of the nodes by default.
+		n.NodeID = ""
+		masked = append(masked, n)
+	}
+	return masked
+}
+
+func (m *addressMasker) maskEvents(events []TopologyEvent) []TopologyEvent {
+	masked := make([]TopologyEvent, 0, len(events))
+	for _, e := range events {
+		e.Address = m.maskAddress(e.Address)
+		masked = append(masked, e)
+	}
+	return masked
+}
+
+func (m *addressMasker) maskProbeResults(results []ProbeResult) []ProbeResult {
+	masked := make([]ProbeResult, 0, len(results))
+	for _, r := range results {
+		r.Error = m.maskMessage(r.Error, r.Address)
+		r.Address = m.maskAddress(r.Address)
+		masked = append(masked, r)
+	}
+	return masked
+}
+
+func (m *addressMasker) maskAlarms(alarms []TopologyAlarm) []TopologyAlarm {
+	masked := make([]TopologyAlarm, 0, len(alarms))
+	for _, a := range alarms {
+		if a.Address != "" {
+			a.Reason = m.maskMessage(a.Reason, a.Address)
+			a.Address = m.maskAddress(a.Address)
+		}
+		masked = append(masked, a)
+	}
+	return masked
+}
+
+func (m *addressMasker) maskStoreInventory(resp StoreInventoryResponse) StoreInventoryResponse {
+	stores := make([]InventoryStore, 0, len(resp.Stores))
+	for _, store := range resp.Stores {
+		store.Address = m.maskAddress(store.Address)
+		stores = append(stores, store)
+	}
+	resp.Stores = stores
+	return resp
+}
+
+func (m *addressMasker) maskStoreLocation(location *topology.StoreLocation) *topology.StoreLocation {
+	masked := *location
+	masked.Stores = make([]topology.StoreLabels, 0, len(location.Stores))
+	for _, store := range location.Stores {
+		store.Address = m.maskAddress(store.Address)
+		masked.Stores = append(masked.Stores, store)
+	}
+	return &masked
+}
+
+// maskAlerts masks the `instance` label of alerts, which is the address of the node firing the alert. The
+// address is masked in annotations as well, as they are usually rendered from the labels.
+func (m *addressMasker) maskAlerts(resp AlertsResponse) AlertsResponse {
+	alerts := make([]Alert, 0, len(resp.Alerts))
+	for _, alert := range resp.Alerts {
+		instance, ok := alert.Labels[alertInstanceLabel]
+		if !ok || instance == "" {
+			alerts = append(alerts, alert)
+			continue
+		}
+		labels := make(map[string]string, len(alert.Labels))
+		for k, v := range alert.Labels {
+			labels[k] = v
+		}
+		labels[alertInstanceLabel] = m.maskAddress(instance)
+		alert.Labels = labels
+		if alert.Annotations != nil {
+			annotations := make(map[string]string, len(alert.Annotations))
+			for k, v := range alert.Annotations {
+				annotations[k] = m.maskMessage(v, instance)
+			}
+			alert.Annotations = annotations
+		}
+		alerts = append(alerts, alert)
+	}
+	resp.Alerts = alerts
+	return resp
+}
+
+// maskHosts returns masked copies of the hosts, whose instances are keyed by the masked addresses.
+func (m *addressMasker) maskHosts(hosts []*hostinfo.Info) []*hostinfo.Info {
+	if hosts == nil {
+		return nil
+	}
+	masked := make([]*hostinfo.Info, 0, len(hosts))
+	for _, h := range hosts {
+		if h == nil {
+			masked = append(masked, h)
+			continue
+		}
+		host := *h
+		host.Host = m.maskIP(h.Host)
+		if h.Instances != nil {
+			host.Instances = make(map[string]*hostinfo.InstanceInfo, len(h.Instances))
+			for addr, instance := range h.Instances {
+				host.Instances[m.maskAddress(addr)] = instance
+			}
+		}
+		masked = append(masked, &host)
+	}
+	return masked
+}
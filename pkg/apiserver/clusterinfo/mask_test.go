@@ -0,0 +1,171 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package clusterinfo
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/clusterinfo/hostinfo"
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/utils"
+	"github.com/pingcap/tidb-dashboard/pkg/utils/topology"
+)
+
+func TestAddressMasker(t *testing.T) {
+	m := newAddressMasker()
+	masked := m.maskIP("10.0.2.15")
+	require.Regexp(t, regexp.MustCompile(`^\*\.\*\.\*\.15#[0-9a-f]{8}$`), masked)
+	// The hash is stable, so that nodes can be correlated across responses.
+	require.Equal(t, masked, m.maskIP("10.0.2.15"))
+	require.NotEqual(t, masked, m.maskIP("10.0.3.15"))
+	require.Regexp(t, regexp.MustCompile(`^\*#[0-9a-f]{8}$`), m.maskIP("tikv-0.tikv-peer"))
+	require.Equal(t, "", m.maskIP(""))
+}
+
+func TestServeCachedMasksAddressesForViewers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := &Service{
+		cache:  newTopologyCache(defaultTopologyCacheSize, time.Minute),
+		masker: newAddressMasker(),
+	}
+
+	engine := gin.New()
+	engine.GET("/topology/pd", func(c *gin.Context) {
+		c.Set(utils.SessionUserKey, &utils.SessionUser{IsWriteable: c.Query("role") == "admin"})
+		s.serveCached(c, func() (interface{}, error) {
+			return []topology.PDInfo{{IP: "10.0.2.15", Port: 2379, GrafanaURL: "http://10.0.2.1:3000/d/Q6RuHYIWk"}}, nil
+		})
+	})
+	get := func(url string) topology.PDInfo {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, url, nil))
+		require.Equal(t, http.StatusOK, w.Code)
+		var nodes []topology.PDInfo
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &nodes))
+		require.Len(t, nodes, 1)
+		return nodes[0]
+	}
+
+	viewer := get("/topology/pd?role=viewer")
+	require.Equal(t, s.masker.maskIP("10.0.2.15"), viewer.IP)
+	require.Equal(t, uint(2379), viewer.Port)
+	require.Empty(t, viewer.GrafanaURL)
+
+	admin := get("/topology/pd?role=admin")
+	require.Equal(t, "10.0.2.15", admin.IP)
+	require.NotEmpty(t, admin.GrafanaURL)
+
+	// The cached topology is not modified by masking.
+	viewer = get("/topology/pd?role=viewer")
+	require.Equal(t, s.masker.maskIP("10.0.2.15"), viewer.IP)
+}
+
+func TestMaskClusterInfo(t *testing.T) {
+	m := newAddressMasker()
+	info := &ClusterInfo{
+		TiDB:    []topology.TiDBInfo{{IP: "10.0.2.1", Port: 4000}},
+		TiKV:    []topology.StoreInfo{{IP: "10.0.2.2", Port: 20160}},
+		Grafana: &topology.GrafanaInfo{StandardComponentInfo: topology.StandardComponentInfo{IP: "10.0.2.3", Port: 3000}},
+	}
+	masked := m.maskClusterInfo(info)
+	require.Equal(t, m.maskIP("10.0.2.1"), masked.TiDB[0].IP)
+	require.Equal(t, m.maskIP("10.0.2.2"), masked.TiKV[0].IP)
+	require.Equal(t, m.maskIP("10.0.2.3"), masked.Grafana.IP)
+	require.Nil(t, masked.TiFlash)
+	require.Equal(t, "10.0.2.1", info.TiDB[0].IP)
+	require.Equal(t, "10.0.2.3", info.Grafana.IP)
+}
+
+func TestMaskProbeResultsAndAlarms(t *testing.T) {
+	m := newAddressMasker()
+	results := []ProbeResult{{
+		Address: "10.0.2.1:20160",
+		Error:   "dial tcp 10.0.2.1:20180: connect: connection refused",
+	}}
+	masked := m.maskProbeResults(results)
+	require.Equal(t, m.maskIP("10.0.2.1")+":20160", masked[0].Address)
+	require.Equal(t, "dial tcp "+m.maskIP("10.0.2.1")+":20180: connect: connection refused", masked[0].Error)
+	require.Equal(t, "10.0.2.1:20160", results[0].Address)
+
+	alarms := m.maskAlarms([]TopologyAlarm{
+		{Address: "10.0.2.1:20160", Reason: "dial tcp 10.0.2.1:20180: i/o timeout"},
+		{Reason: "fewer than 3 nodes are up"},
+	})
+	require.Equal(t, m.maskIP("10.0.2.1")+":20160", alarms[0].Address)
+	require.NotContains(t, alarms[0].Reason, "10.0.2.1")
+	require.Empty(t, alarms[1].Address)
+	require.Equal(t, "fewer than 3 nodes are up", alarms[1].Reason)
+}
+
+func TestMaskHosts(t *testing.T) {
+	m := newAddressMasker()
+	hosts := []*hostinfo.Info{{
+		Host:      "10.0.2.1",
+		Instances: map[string]*hostinfo.InstanceInfo{"10.0.2.1:20160": {Type: "tikv"}},
+	}}
+	masked := m.maskHosts(hosts)
+	require.Equal(t, m.maskIP("10.0.2.1"), masked[0].Host)
+	require.Contains(t, masked[0].Instances, m.maskIP("10.0.2.1")+":20160")
+	require.Equal(t, "10.0.2.1", hosts[0].Host)
+	require.Contains(t, hosts[0].Instances, "10.0.2.1:20160")
+
+	inventory := m.maskStoreInventory(StoreInventoryResponse{Stores: []InventoryStore{{Address: "10.0.2.1:20160", OnlyInPD: true}}})
+	require.Equal(t, m.maskIP("10.0.2.1")+":20160", inventory.Stores[0].Address)
+	require.True(t, inventory.Stores[0].OnlyInPD)
+}
+
+func TestMaskStoreLocationAndAlerts(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := &Service{
+		cache:  newTopologyCache(defaultTopologyCacheSize, time.Minute),
+		masker: newAddressMasker(),
+	}
+	engine := gin.New()
+	engine.Use(func(c *gin.Context) {
+		c.Set(utils.SessionUserKey, &utils.SessionUser{IsWriteable: c.Query("role") == "admin"})
+	})
+	engine.GET("/topology/store_location", func(c *gin.Context) {
+		s.serveCached(c, func() (interface{}, error) {
+			return &topology.StoreLocation{
+				LocationLabels: []string{"zone"},
+				Stores:         []topology.StoreLabels{{Address: "10.0.2.1:20160", Labels: map[string]string{"zone": "z1"}}},
+			}, nil
+		})
+	})
+	alert := Alert{
+		Labels:      map[string]string{"alertname": "TiKV_server_is_down", alertInstanceLabel: "10.0.2.1:20180"},
+		Annotations: map[string]string{"summary": "TiKV 10.0.2.1:20180 is down"},
+	}
+	engine.GET("/topology/alertmanager/alerts", func(c *gin.Context) {
+		c.JSON(http.StatusOK, s.maskForUser(c, AlertsResponse{Count: 1, Alerts: []Alert{alert}}))
+	})
+	get := func(url string, v interface{}) {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, url, nil))
+		require.Equal(t, http.StatusOK, w.Code)
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), v))
+	}
+
+	var location topology.StoreLocation
+	get("/topology/store_location?role=viewer", &location)
+	require.Equal(t, s.masker.maskIP("10.0.2.1")+":20160", location.Stores[0].Address)
+	require.Equal(t, "z1", location.Stores[0].Labels["zone"])
+	get("/topology/store_location?role=admin", &location)
+	require.Equal(t, "10.0.2.1:20160", location.Stores[0].Address)
+
+	var alerts AlertsResponse
+	get("/topology/alertmanager/alerts?role=viewer", &alerts)
+	require.Equal(t, s.masker.maskIP("10.0.2.1")+":20180", alerts.Alerts[0].Labels[alertInstanceLabel])
+	require.Equal(t, "TiKV_server_is_down", alerts.Alerts[0].Labels["alertname"])
+	require.NotContains(t, alerts.Alerts[0].Annotations["summary"], "10.0.2.1")
+	require.Equal(t, "10.0.2.1:20180", alert.Labels[alertInstanceLabel])
+	get("/topology/alertmanager/alerts?role=admin", &alerts)
+	require.Equal(t, "10.0.2.1:20180", alerts.Alerts[0].Labels[alertInstanceLabel])
+}
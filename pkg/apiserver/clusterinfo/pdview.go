@@ -0,0 +1,100 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package clusterinfo
+
+import (
+	"net"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/pingcap/tidb-dashboard/pkg/pd"
+	"github.com/pingcap/tidb-dashboard/pkg/utils/topology"
+)
+
+// PDMemberView is the store list seen by a single PD member.
+type PDMemberView struct {
+	Member string   `json:"member"`
+	Stores []string `json:"stores"`
+	Error  string   `json:"error,omitempty"`
+}
+
+// StoreDiscrepancy is a store that is not seen by all PD members.
+type StoreDiscrepancy struct {
+	Store       string   `json:"store"`
+	SeenBy      []string `json:"seen_by"`
+	MissingFrom []string `json:"missing_from"`
+}
+
+type PDMemberViewsResponse struct {
+	Members       []PDMemberView     `json:"members"`
+	Discrepancies []StoreDiscrepancy `json:"discrepancies"`
+}
+
+// fetchPDMemberViews queries the store list from each PD member independently.
+func fetchPDMemberViews(pdClient *pd.Client, members []topology.PDInfo) []PDMemberView {
+	views := make([]PDMemberView, len(members))
+	var wg sync.WaitGroup
+	for i, m := range members {
+		wg.Add(1)
+		go func(i int, m topology.PDInfo) {
+			defer wg.Done()
+			view := PDMemberView{
+				Member: net.JoinHostPort(m.IP, strconv.Itoa(int(m.Port))),
+				Stores: []string{},
+			}
+			stores, err := topology.FetchStoreAddresses(pdClient.WithAddress(m.IP, int(m.Port)))
+			if err != nil {
+				view.Error = err.Error()
+			} else {
+				view.Stores = stores
+			}
+			views[i] = view
+		}(i, m)
+	}
+	wg.Wait()
+	return views
+}
+
+// findStoreDiscrepancies reports stores that some members see while others do not.
+// Members failed to respond are not considered.
+func findStoreDiscrepancies(views []PDMemberView) []StoreDiscrepancy {
+	seenBy := map[string][]string{}
+	var members []string
+	for _, v := range views {
+		if v.Error != "" {
+			continue
+		}
+		members = append(members, v.Member)
+		for _, store := range v.Stores {
+			seenBy[store] = append(seenBy[store], v.Member)
+		}
+	}
+
+	discrepancies := make([]StoreDiscrepancy, 0)
+	for store, seen := range seenBy {
+		if len(seen) == len(members) {
+			continue
+		}
+		seenSet := make(map[string]struct{}, len(seen))
+		for _, m := range seen {
+			seenSet[m] = struct{}{}
+		}
+		missing := make([]string, 0)
+		for _, m := range members {
+			if _, ok := seenSet[m]; !ok {
+				missing = append(missing, m)
+			}
+		}
+		sort.Strings(seen)
+		discrepancies = append(discrepancies, StoreDiscrepancy{
+			Store:       store,
+			SeenBy:      seen,
+			MissingFrom: missing,
+		})
+	}
+	sort.Slice(discrepancies, func(i, j int) bool {
+		return discrepancies[i].Store < discrepancies[j].Store
+	})
+	return discrepancies
+}
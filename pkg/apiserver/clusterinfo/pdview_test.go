@@ -0,0 +1,80 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package clusterinfo
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/fx/fxtest"
+
+	"github.com/pingcap/tidb-dashboard/pkg/config"
+	"github.com/pingcap/tidb-dashboard/pkg/httpc"
+	"github.com/pingcap/tidb-dashboard/pkg/pd"
+	"github.com/pingcap/tidb-dashboard/pkg/utils/topology"
+)
+
+func newTestPDClient(t *testing.T, endpoint string) *pd.Client {
+	lc := fxtest.NewLifecycle(t)
+	cfg := &config.Config{PDEndPoint: endpoint}
+	c := pd.NewPDClient(lc, httpc.NewHTTPClient(lc, cfg), cfg)
+	require.NoError(t, lc.Start(context.Background()))
+	return c
+}
+
+// newMockPDMember starts a mocked PD member responding the stores API.
+func newMockPDMember(t *testing.T, storesResponse string) topology.PDInfo {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/pd/api/v1/stores" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(storesResponse))
+	}))
+	t.Cleanup(ts.Close)
+
+	host, port, err := net.SplitHostPort(ts.Listener.Addr().String())
+	require.NoError(t, err)
+	p, err := strconv.Atoi(port)
+	require.NoError(t, err)
+	return topology.PDInfo{IP: host, Port: uint(p)}
+}
+
+func TestPDMemberViewsDiscrepancy(t *testing.T) {
+	member1 := newMockPDMember(t, `{"count": 2, "stores": [
+		{"store": {"id": 1, "address": "10.0.2.1:20160"}},
+		{"store": {"id": 2, "address": "10.0.2.2:20160"}}
+	]}`)
+	member2 := newMockPDMember(t, `{"count": 2, "stores": [
+		{"store": {"id": 1, "address": "10.0.2.1:20160"}},
+		{"store": {"id": 3, "address": "10.0.2.3:20160"}}
+	]}`)
+	member3 := newMockPDMember(t, `invalid`)
+	pdClient := newTestPDClient(t, "http://127.0.0.1:2379")
+
+	views := fetchPDMemberViews(pdClient, []topology.PDInfo{member1, member2, member3})
+	require.Len(t, views, 3)
+	require.Equal(t, []string{"10.0.2.1:20160", "10.0.2.2:20160"}, views[0].Stores)
+	require.Equal(t, []string{"10.0.2.1:20160", "10.0.2.3:20160"}, views[1].Stores)
+	require.NotEmpty(t, views[2].Error)
+
+	addr1 := views[0].Member
+	addr2 := views[1].Member
+	require.Equal(t, []StoreDiscrepancy{
+		{Store: "10.0.2.2:20160", SeenBy: []string{addr1}, MissingFrom: []string{addr2}},
+		{Store: "10.0.2.3:20160", SeenBy: []string{addr2}, MissingFrom: []string{addr1}},
+	}, findStoreDiscrepancies(views))
+}
+
+func TestPDMemberViewsConsistent(t *testing.T) {
+	views := []PDMemberView{
+		{Member: "10.0.0.1:2379", Stores: []string{"10.0.2.1:20160"}},
+		{Member: "10.0.0.2:2379", Stores: []string{"10.0.2.1:20160"}},
+	}
+	require.Empty(t, findStoreDiscrepancies(views))
+}
@@ -0,0 +1,60 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package clusterinfo
+
+import (
+	"context"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+
+	"github.com/pingcap/tidb-dashboard/util/topo"
+)
+
+const (
+	defaultFetchMaxRetries      = 2
+	defaultFetchRetryBudget     = 2 * time.Second
+	defaultFetchInitialInterval = 100 * time.Millisecond
+)
+
+// retryBudget limits the retries of a single component fetcher. Each fetcher owns its
+// budget, so that a flaky component cannot consume the retry time of other components.
+type retryBudget struct {
+	maxRetries int
+	maxElapsed time.Duration
+}
+
+func newRetryBudget(maxRetries int, maxElapsed time.Duration) retryBudget {
+	if maxRetries < 0 {
+		maxRetries = defaultFetchMaxRetries
+	}
+	if maxElapsed <= 0 {
+		maxElapsed = defaultFetchRetryBudget
+	}
+	return retryBudget{
+		maxRetries: maxRetries,
+		maxElapsed: maxElapsed,
+	}
+}
+
+// fetchWithRetry calls fetch until it succeeds or the budget is exhausted. When the budget is
+// exhausted, the last error is wrapped in ErrRetryBudgetExhausted.
+func fetchWithRetry(ctx context.Context, kind topo.Kind, budget retryBudget, fetch func(ctx context.Context) error) error {
+	ebo := backoff.NewExponentialBackOff()
+	ebo.InitialInterval = defaultFetchInitialInterval
+	ebo.MaxElapsedTime = budget.maxElapsed
+	bo := backoff.WithContext(backoff.WithMaxRetries(ebo, uint64(budget.maxRetries)), ctx)
+
+	attempts := 0
+	err := backoff.Retry(func() error {
+		attempts++
+		return fetch(ctx)
+	}, bo)
+	if err == nil {
+		return nil
+	}
+	if ctx.Err() != nil {
+		return err
+	}
+	return ErrRetryBudgetExhausted.Wrap(err, "retry budget of %s exhausted after %d attempts", kind, attempts)
+}
@@ -0,0 +1,74 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package clusterinfo
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/joomcode/errorx"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pingcap/tidb-dashboard/util/topo"
+)
+
+func TestFetchWithRetrySucceeds(t *testing.T) {
+	var calls int32
+	err := fetchWithRetry(context.Background(), topo.KindPD, newRetryBudget(3, time.Second), func(ctx context.Context) error {
+		if atomic.AddInt32(&calls, 1) < 2 {
+			return errors.New("flaky")
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestFetchWithRetryStopsAfterMaxRetries(t *testing.T) {
+	var calls int32
+	err := fetchWithRetry(context.Background(), topo.KindPD, newRetryBudget(2, 10*time.Second), func(ctx context.Context) error {
+		atomic.AddInt32(&calls, 1)
+		return errors.New("always fails")
+	})
+	require.Error(t, err)
+	require.True(t, errorx.IsOfType(err, ErrRetryBudgetExhausted))
+	require.Contains(t, err.Error(), "always fails")
+	require.Equal(t, int32(3), atomic.LoadInt32(&calls))
+}
+
+func TestFetchWithRetryStopsAfterMaxElapsed(t *testing.T) {
+	var calls int32
+	start := time.Now()
+	err := fetchWithRetry(context.Background(), topo.KindPD, newRetryBudget(1000, 300*time.Millisecond), func(ctx context.Context) error {
+		atomic.AddInt32(&calls, 1)
+		return errors.New("always fails")
+	})
+	require.True(t, errorx.IsOfType(err, ErrRetryBudgetExhausted))
+	require.Less(t, time.Since(start), 2*time.Second)
+	require.Less(t, atomic.LoadInt32(&calls), int32(1000))
+}
+
+func TestFetchWithRetryBudgetsAreIndependent(t *testing.T) {
+	s := &Service{fetchRetryBudget: newRetryBudget(2, 10*time.Second)}
+	var pdCalls, tikvCalls int32
+	pdErr := make(chan error, 1)
+	go func() {
+		pdErr <- fetchWithRetry(context.Background(), topo.KindPD, s.fetchRetryBudget, func(ctx context.Context) error {
+			atomic.AddInt32(&pdCalls, 1)
+			return errors.New("pd is down")
+		})
+	}()
+	err := fetchWithRetry(context.Background(), topo.KindTiKV, s.fetchRetryBudget, func(ctx context.Context) error {
+		if atomic.AddInt32(&tikvCalls, 1) < 3 {
+			return errors.New("flaky")
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.Error(t, <-pdErr)
+	require.Equal(t, int32(3), atomic.LoadInt32(&pdCalls))
+	require.Equal(t, int32(3), atomic.LoadInt32(&tikvCalls))
+}
@@ -0,0 +1,62 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package clusterinfo
+
+import (
+	"net"
+	"strconv"
+
+	"github.com/samber/lo"
+
+	"github.com/pingcap/tidb-dashboard/pkg/utils/topology"
+)
+
+// excludeSelf removes the Dashboard Server itself from the topology when it is
+// co-located with other components and is configured to be excluded.
+func (s *Service) excludeSelf(info *ClusterInfo) {
+	cfg := s.params.Config
+	if cfg == nil || !cfg.ExcludeSelfFromTopology || cfg.AdvertiseAddress == "" {
+		return
+	}
+	info.excludeAddress(cfg.AdvertiseAddress)
+}
+
+// excludeAddress removes all nodes listening on the specified `host:port` address.
+func (info *ClusterInfo) excludeAddress(address string) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return
+	}
+	address = net.JoinHostPort(host, port)
+	match := func(ip string, port uint) bool {
+		return net.JoinHostPort(ip, strconv.Itoa(int(port))) == address
+	}
+
+	info.TiDB = lo.Filter(info.TiDB, func(n topology.TiDBInfo, _ int) bool {
+		return !match(n.IP, n.Port)
+	})
+	info.TiKV = lo.Filter(info.TiKV, func(n topology.StoreInfo, _ int) bool {
+		return !match(n.IP, n.Port)
+	})
+	info.TiFlash = lo.Filter(info.TiFlash, func(n topology.StoreInfo, _ int) bool {
+		return !match(n.IP, n.Port)
+	})
+	info.PD = lo.Filter(info.PD, func(n topology.PDInfo, _ int) bool {
+		return !match(n.IP, n.Port)
+	})
+	info.TiCDC = lo.Filter(info.TiCDC, func(n topology.TiCDCInfo, _ int) bool {
+		return !match(n.IP, n.Port)
+	})
+	info.TiProxy = lo.Filter(info.TiProxy, func(n topology.TiProxyInfo, _ int) bool {
+		return !match(n.IP, n.Port)
+	})
+	if info.Grafana != nil && match(info.Grafana.IP, info.Grafana.Port) {
+		info.Grafana = nil
+	}
+	if info.AlertManager != nil && match(info.AlertManager.IP, info.AlertManager.Port) {
+		info.AlertManager = nil
+	}
+	if info.Prometheus != nil && match(info.Prometheus.IP, info.Prometheus.Port) {
+		info.Prometheus = nil
+	}
+}
@@ -0,0 +1,45 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package clusterinfo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pingcap/tidb-dashboard/pkg/config"
+	"github.com/pingcap/tidb-dashboard/pkg/utils/topology"
+)
+
+func newSelfTestClusterInfo() *ClusterInfo {
+	return &ClusterInfo{
+		TiDB: []topology.TiDBInfo{
+			{IP: "10.0.1.1", Port: 4000},
+			{IP: "10.0.1.1", Port: 12333},
+		},
+		Grafana: &topology.GrafanaInfo{
+			StandardComponentInfo: topology.StandardComponentInfo{IP: "10.0.1.1", Port: 3000},
+		},
+	}
+}
+
+func TestExcludeSelf(t *testing.T) {
+	s := &Service{params: ServiceParams{Config: &config.Config{
+		AdvertiseAddress:        "10.0.1.1:12333",
+		ExcludeSelfFromTopology: true,
+	}}}
+	info := newSelfTestClusterInfo()
+	s.excludeSelf(info)
+	require.Len(t, info.TiDB, 1)
+	require.Equal(t, uint(4000), info.TiDB[0].Port)
+	require.NotNil(t, info.Grafana)
+}
+
+func TestExcludeSelfDisabled(t *testing.T) {
+	s := &Service{params: ServiceParams{Config: &config.Config{
+		AdvertiseAddress: "10.0.1.1:12333",
+	}}}
+	info := newSelfTestClusterInfo()
+	s.excludeSelf(info)
+	require.Len(t, info.TiDB, 2)
+}
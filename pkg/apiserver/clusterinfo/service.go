@@ -8,70 +8,242 @@ package clusterinfo
 import (
 	"context"
 	"fmt"
+	"net"
 	"net/http"
+	"strconv"
 	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/joomcode/errorx"
+	"github.com/samber/lo"
 	"go.etcd.io/etcd/clientv3"
 	"go.uber.org/fx"
 
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/audit"
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/cluster"
 	"github.com/pingcap/tidb-dashboard/pkg/apiserver/clusterinfo/hostinfo"
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/notification"
 	"github.com/pingcap/tidb-dashboard/pkg/apiserver/user"
 	"github.com/pingcap/tidb-dashboard/pkg/apiserver/utils"
+	"github.com/pingcap/tidb-dashboard/pkg/config"
 	"github.com/pingcap/tidb-dashboard/pkg/httpc"
 	"github.com/pingcap/tidb-dashboard/pkg/pd"
 	"github.com/pingcap/tidb-dashboard/pkg/tidb"
 	"github.com/pingcap/tidb-dashboard/pkg/utils/topology"
 	"github.com/pingcap/tidb-dashboard/util/rest"
+	"github.com/pingcap/tidb-dashboard/util/topo"
+)
+
+var (
+	ErrNS                    = errorx.NewNamespace("error.api.clusterinfo")
+	ErrProbeSlotTimeout      = ErrNS.NewType("probe_slot_timeout")
+	ErrRetryBudgetExhausted  = ErrNS.NewType("retry_budget_exhausted")
+	ErrFetchLoadFailed       = ErrNS.NewType("fetch_load_failed")
+	ErrInvalidTopologySource = ErrNS.NewType("invalid_topology_source")
+	ErrDependencyUnavailable = ErrNS.NewType("dependency_unavailable")
+	ErrFetchTimeout          = ErrNS.NewType("fetch_timeout")
 )
 
 type ServiceParams struct {
 	fx.In
-	PDClient   *pd.Client
-	EtcdClient *clientv3.Client
-	HTTPClient *httpc.Client
-	TiDBClient *tidb.Client
+	Config      *config.Config
+	PDClient    *pd.Client
+	EtcdClients *pd.EtcdClientManager
+	HTTPClient  *httpc.Client
+	TiDBClient  *tidb.Client
+	Registry    *cluster.Registry
+	Notifier    *notification.Service
 }
 
 type Service struct {
 	params       ServiceParams
 	lifecycleCtx context.Context
+
+	// topology is of the default cluster. Topologies of other clusters are built on demand by topologyOf.
+	topology     *clusterTopology
+	topologiesMu sync.Mutex
+	topologies   map[string]*clusterTopology
+
+	cache *topologyCache
+	// versions keeps recently served topology documents by ETag, as the base of deltas.
+	versions *topologyCache
+	prober   *prober
+	events   *topologyEventRing
+
+	fetchRetryBudget retryBudget
+	fetchTimeout     time.Duration
+	sourceTimeout    time.Duration
+	// disabledKinds are components not to be fetched.
+	disabledKinds map[topo.Kind]struct{}
+	loadTimeout   time.Duration
+	// masker is nil when addresses are not masked.
+	masker *addressMasker
 }
 
 func NewService(lc fx.Lifecycle, p ServiceParams) *Service {
-	s := &Service{params: p}
+	registerProbeMetrics()
+	s := &Service{
+		params:   p,
+		cache:    newTopologyCache(defaultTopologyCacheSize, p.Config.TopologyCacheTTL),
+		versions: newTopologyCache(defaultTopologyVersionsSize, defaultTopologyVersionsTTL),
+		prober:   newProber(p.Config.TopologyProbeConcurrency, defaultProbeSlotTimeout, defaultProbeTimeout),
+		events:   newTopologyEventRing(p.Config.TopologyEventsCapacity),
+
+		fetchRetryBudget: newRetryBudget(p.Config.TopologyFetchMaxRetries, p.Config.TopologyFetchRetryBudget),
+		fetchTimeout:     p.Config.TopologyFetchTimeout,
+		sourceTimeout:    p.Config.TopologyFetchSourceTimeout,
+		disabledKinds:    make(map[topo.Kind]struct{}),
+		loadTimeout:      defaultLoadTimeout,
+		topologies:       make(map[string]*clusterTopology),
+	}
+	for _, kind := range p.Config.TopologyDisabledComponents {
+		s.disabledKinds[topo.Kind(kind)] = struct{}{}
+	}
+	s.topology = s.newClusterTopology(nil, newTopologySources(p))
+	s.topology.probedInBackground = p.Config.TopologyProbeInterval > 0
+	if p.Config.TopologyMaskAddresses {
+		s.masker = newAddressMasker()
+	}
 	lc.Append(fx.Hook{
 		OnStart: func(ctx context.Context) error {
 			s.lifecycleCtx = ctx
+			go s.watchTopologyEvents(ctx)
+			go s.watchPDMembers(ctx)
+			if p.Config.TopologyProbeInterval > 0 {
+				go s.probeLoop(ctx, p.Config.TopologyProbeInterval)
+			}
+			return nil
+		},
+		OnStop: func(context.Context) error {
+			s.flushSnapshots()
 			return nil
 		},
 	})
 	return s
 }
 
-func RegisterRouter(r *gin.RouterGroup, auth *user.AuthService, s *Service) {
+func RegisterRouter(r *gin.RouterGroup, auth *user.AuthService, a *audit.Service, s *Service) {
 	endpoint := r.Group("/topology")
-	endpoint.Use(auth.MWAuthRequired())
+	// The WebSocket is authenticated by a token in the query, as browsers can not set headers for it.
+	endpoint.GET("/ws", s.serveTopologyWS)
+	endpoint.Use(auth.MWAuthRequired(), s.params.Registry.MWResolveCluster(), utils.MWConditionalGet())
+	endpoint.GET("/ws/acquire_token", s.getTopologyWSToken)
+	endpoint.GET("/all", s.getClusterInfo)
+	endpoint.POST("/compare_baseline", s.compareBaseline)
+	endpoint.GET("/liveness", s.getLiveness)
+	endpoint.GET("/alarms", s.getAlarms)
+	endpoint.GET("/events", s.getTopologyEvents)
 	endpoint.GET("/tidb", s.getTiDBTopology)
 	endpoint.GET("/ticdc", s.getTiCDCTopology)
 	endpoint.GET("/tiproxy", s.getTiProxyTopology)
-	endpoint.DELETE("/tidb/:address", s.deleteTiDBTopology)
+	endpoint.DELETE("/tidb/:address", auth.MWRequireCapability(utils.CapabilityManageTopology), a.MWRecord("topology.delete_tidb"), s.deleteTiDBTopology)
 	endpoint.GET("/store", s.getStoreTopology)
+	endpoint.DELETE("/store/tombstone", auth.MWRequireCapability(utils.CapabilityManageTopology), a.MWRecord("topology.delete_tombstone_stores"), s.deleteTombstoneStores)
 	endpoint.GET("/pd", s.getPDTopology)
+	endpoint.DELETE("/pd/:address", auth.MWRequireCapability(utils.CapabilityManageTopology), a.MWRecord("topology.delete_pd_member"), s.deletePDMember)
+	endpoint.GET("/pd/member_views", auth.MWRequireWritePriv(), s.getPDMemberViews)
 	endpoint.GET("/alertmanager", s.getAlertManagerTopology)
 	endpoint.GET("/alertmanager/:address/count", s.getAlertManagerCounts)
+	endpoint.GET("/alertmanager/alerts", s.getAlertManagerAlerts)
+	endpoint.POST("/alertmanager/silences", auth.MWRequireWritePriv(), a.MWRecord("alertmanager.create_silence"), s.createAlertManagerSilence)
+	endpoint.DELETE("/alertmanager/silences/:id", auth.MWRequireWritePriv(), a.MWRecord("alertmanager.expire_silence"), s.expireAlertManagerSilence)
 	endpoint.GET("/grafana", s.getGrafanaTopology)
 
 	endpoint.GET("/store_location", s.getStoreLocationTopology)
+	endpoint.GET("/store_inventory", s.getStoreInventory)
+	endpoint.GET("/region/:id/leader", s.getRegionLeaderTopology)
 
 	endpoint = r.Group("/host")
-	endpoint.Use(auth.MWAuthRequired())
-	endpoint.Use(utils.MWConnectTiDB(s.params.TiDBClient))
-	endpoint.GET("/all", s.getHostsInfo)
-	endpoint.GET("/statistics", s.getStatistics)
+	endpoint.Use(auth.MWAuthRequired(), s.params.Registry.MWResolveCluster())
+	endpoint.GET("/all", utils.MWConnectTiDB(s.params.TiDBClient), s.getHostsInfo)
+	endpoint.GET("/statistics", s.mwServeStaleStatistics(), utils.MWConnectTiDB(s.params.TiDBClient), s.getStatistics)
+}
+
+// pdClientOf returns the PD client of the cluster selected in the session.
+func (s *Service) pdClientOf(c *gin.Context) *pd.Client {
+	if clients := cluster.GetClients(c); clients != nil {
+		return clients.PDClient
+	}
+	return s.params.PDClient
+}
+
+// etcdClientOf returns the etcd client of the cluster selected in the session.
+func (s *Service) etcdClientOf(c *gin.Context) *clientv3.Client {
+	if clients := cluster.GetClients(c); clients != nil {
+		return clients.EtcdClient
+	}
+	return s.params.EtcdClients.Client()
+}
+
+func (s *Service) newClusterTopology(clients *cluster.Clients, sources []TopologySource) *clusterTopology {
+	t := &clusterTopology{
+		clients: clients,
+		sources: s.enabledSources(sources),
+		history: newProbeHistory(s.params.Config.TopologyDownGraceProbes, s.params.Config.TopologyProbeHistoryRetention, expectedMinimumFromConfig(s.params.Config)),
+	}
+	if s.params.Config.DataDir != "" {
+		clusterID, pdEndpoint := cluster.DefaultClusterID, s.params.Config.PDEndPoint
+		if clients != nil {
+			clusterID, pdEndpoint = clients.ClusterID, clients.PDEndpoint
+		}
+		t.snapshot = newTopologySnapshot(s.params.Config.DataDir, clusterID, pdEndpoint)
+	}
+	return t
+}
+
+// flushSnapshots persists the snapshots of all clusters.
+func (s *Service) flushSnapshots() {
+	if s.topology.snapshot != nil {
+		s.topology.snapshot.flush()
+	}
+	s.topologiesMu.Lock()
+	defer s.topologiesMu.Unlock()
+	for _, t := range s.topologies {
+		if t.snapshot != nil {
+			t.snapshot.flush()
+		}
+	}
+}
+
+// topologyOf returns the topology of the cluster selected in the session.
+func (s *Service) topologyOf(c *gin.Context) *clusterTopology {
+	return s.topologyOfClients(cluster.GetClients(c))
+}
+
+// topologyOfClients returns the topology of the cluster. Sources of a non-default cluster are built from its
+// clients, and are rebuilt when the clients change, e.g. when the cluster is registered again.
+func (s *Service) topologyOfClients(clients *cluster.Clients) *clusterTopology {
+	if clients == nil || clients.IsDefault() {
+		return s.topology
+	}
+
+	s.topologiesMu.Lock()
+	defer s.topologiesMu.Unlock()
+	t, ok := s.topologies[clients.ClusterID]
+	if !ok || t.clients != clients {
+		if ok && t.snapshot != nil {
+			// The snapshot of the rebuilt topology is loaded from the file.
+			t.snapshot.flush()
+		}
+		etcdClient := clients.EtcdClient
+		t = s.newClusterTopology(clients, newClusterSources(clients.PDClient, func() *clientv3.Client { return etcdClient }))
+		s.topologies[clients.ClusterID] = t
+	}
+	return t
+}
+
+// requireDefaultCluster responds an error and returns false if a non-default cluster is selected in the session.
+// It is used by APIs only available for the default cluster, e.g. topology events, which are only watched for it.
+func requireDefaultCluster(c *gin.Context) bool {
+	if clients := cluster.GetClients(c); clients != nil && !clients.IsDefault() {
+		rest.Error(c, rest.ErrBadRequest.New("this API is only available for the default cluster"))
+		return false
+	}
+	return true
 }
 
+// @ID topologyTidbAddressDelete
 // @Summary Hide a TiDB instance
 // @Param address path string true "ip:port"
 // @Success 200 "delete ok"
@@ -86,12 +258,13 @@ func (s *Service) deleteTiDBTopology(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(s.lifecycleCtx, time.Second*5)
 	defer cancel()
 
+	etcdClient := s.etcdClientOf(c)
 	var wg sync.WaitGroup
 	for _, key := range []string{ttlKey, nonTTLKey} {
 		wg.Add(1)
 		go func(toDel string) {
 			defer wg.Done()
-			if _, err := s.params.EtcdClient.Delete(ctx, toDel); err != nil {
+			if _, err := etcdClient.Delete(ctx, toDel); err != nil {
 				errorChannel <- err
 			}
 		}(key)
@@ -108,22 +281,229 @@ func (s *Service) deleteTiDBTopology(c *gin.Context) {
 		rest.Error(c, err)
 		return
 	}
+	s.cache.Purge()
 	c.JSON(http.StatusOK, nil)
 }
 
+// @ID getClusterInfo
+// @Summary Get topology of all components
+// @Description Components that fail to be fetched are reported in `errors`.
+// @Description With `delta_from`, a JSON merge patch is returned if the base version is still known.
+// @Description When address masking is enabled, users without the write privilege see masked IPs.
+// @Description CSV and NDJSON flatten each node into a row, with components failed to be fetched listed in the X-Failed-Components header.
+// @Param format query string false "Response format, which is also negotiated by the Accept header" Enums(json, dot, csv, ndjson)
+// @Param with_load query boolean false "Whether to fetch the connection count of TiDB instances"
+// @Param delta_from query string false "ETag of a previous response to receive a JSON merge patch against"
+// @Param refresh query boolean false "Whether to bypass the cached topology"
+// @Success 200 {object} ClusterInfo
+// @Router /topology/all [get]
+// @Security JwtAuth
+// @Failure 401 {object} rest.ErrorResponse
+func (s *Service) getClusterInfo(c *gin.Context) {
+	format, err := responseFormat(c, formatJSON, formatDOT, formatCSV, formatNDJSON)
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+
+	withLoad := c.Query("with_load") == "true"
+	v, err := s.cachedFetch(c, func() (interface{}, error) {
+		info := s.fetchClusterInfo(s.lifecycleCtx, s.topologyOf(c))
+		if withLoad {
+			s.fillTiDBConnectionCounts(s.lifecycleCtx, info.TiDB)
+		}
+		return info, nil
+	})
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	info := s.maskForUser(c, v).(*ClusterInfo)
+
+	switch format {
+	case formatDOT:
+		c.Data(http.StatusOK, "text/vnd.graphviz; charset=utf-8", []byte(renderDOT(info)))
+	case formatCSV, formatNDJSON:
+		serveTopologyTable(c, format, info)
+	default:
+		s.serveWithDelta(c, info)
+	}
+}
+
+// @ID compareTopologyBaseline
+// @Summary Compare the topology with a baseline
+// @Description Runtime fields like status and start timestamp are ignored.
+// @Param request body ClusterInfo true "Baseline topology"
+// @Success 200 {object} CompareBaselineResponse
+// @Failure 400 {object} rest.ErrorResponse
+// @Failure 401 {object} rest.ErrorResponse
+// @Security JwtAuth
+// @Router /topology/compare_baseline [post]
+func (s *Service) compareBaseline(c *gin.Context) {
+	var baseline ClusterInfo
+	if err := c.ShouldBindJSON(&baseline); err != nil {
+		rest.Error(c, rest.ErrBadRequest.NewWithNoMessage())
+		return
+	}
+	actual := s.fetchClusterInfo(s.lifecycleCtx, s.topologyOf(c))
+	c.JSON(http.StatusOK, compareBaseline(&baseline, actual))
+}
+
+// @ID getTopologyLiveness
+// @Summary Probe the liveness of all instances
+// @Description With mode=any, both the service and status addresses are probed and the first alive one wins.
+// @Description A node is only reported down after failing a number of consecutive probes, before which it is degraded.
+// @Description A node is unknown when no probe worker is available in time, which does not count as a failed probe.
+// @Description With cached=true, the results of the last background probe are returned without probing.
+// @Description When probing in background, the liveness of probes by this API follows the background probes and is not recorded.
+// @Param mode query string false "Probe mode" Enums(status, any)
+// @Param cached query bool false "Return the results of the last background probe"
+// @Success 200 {array} ProbeResult
+// @Router /topology/liveness [get]
+// @Security JwtAuth
+// @Failure 401 {object} rest.ErrorResponse
+func (s *Service) getLiveness(c *gin.Context) {
+	mode := probeMode(c.DefaultQuery("mode", string(probeModeStatus)))
+	if mode != probeModeStatus && mode != probeModeAny {
+		rest.Error(c, rest.ErrBadRequest.New("unsupported probe mode %s", mode))
+		return
+	}
+	t := s.topologyOf(c)
+	if c.Query("cached") == "true" {
+		c.JSON(http.StatusOK, s.maskForUser(c, t.history.latest()))
+		return
+	}
+
+	results := s.probeNodes(c.Request.Context(), t, mode)
+	if t.probedInBackground {
+		t.history.annotate(results)
+	} else {
+		s.recordProbes(t, results)
+	}
+	c.JSON(http.StatusOK, s.maskForUser(c, results))
+}
+
+// probeLoop probes the status addresses of all nodes in the default cluster periodically, so that the liveness history is kept up to
+// date without requests.
+func (s *Service) probeLoop(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.recordProbes(s.topology, s.probeNodes(ctx, s.topology, probeModeStatus))
+		}
+	}
+}
+
+func (s *Service) probeNodes(ctx context.Context, t *clusterTopology, mode probeMode) []ProbeResult {
+	info := s.fetchClusterInfo(s.lifecycleCtx, t)
+	return s.prober.probeNodes(ctx, info.nodes(), mode)
+}
+
+// recordProbes records the results of a probe cycle in the history, and notifies nodes turned down.
+func (s *Service) recordProbes(t *clusterTopology, results []ProbeResult) {
+	for _, r := range t.history.record(results) {
+		s.params.Notifier.Publish(notification.Event{
+			Type:    notification.EventNodeDown,
+			Title:   "Node detected down",
+			Message: fmt.Sprintf("The %s node %s is down: %s", r.Component, r.Address, r.Error),
+			Details: map[string]interface{}{
+				"component":     r.Component,
+				"address":       r.Address,
+				"error":         r.Error,
+				"last_alive_at": r.LastAliveAt,
+			},
+		})
+	}
+}
+
+// @ID getTopologyAlarms
+// @Summary Get nodes that should be up but are not
+// @Description Alarms are derived from the background probes, or from probing all nodes when probing in background is disabled.
+// @Description A node is reported after being down, with the time of its first failed probe.
+// @Description Components having fewer up nodes than the expected minimum are also reported, without an address.
+// @Success 200 {array} TopologyAlarm
+// @Router /topology/alarms [get]
+// @Security JwtAuth
+// @Failure 401 {object} rest.ErrorResponse
+func (s *Service) getAlarms(c *gin.Context) {
+	t := s.topologyOf(c)
+	if !t.probedInBackground {
+		s.recordProbes(t, s.probeNodes(c.Request.Context(), t, probeModeStatus))
+	}
+	c.JSON(http.StatusOK, s.maskForUser(c, t.history.alarms()))
+}
+
+// @ID getTopologyEvents
+// @Summary Get recent topology change events
+// @Description Events are kept in memory and are newest first. Events are only available for the default cluster.
+// @Param limit query int false "Max number of events to return"
+// @Success 200 {array} TopologyEvent
+// @Failure 400 {object} rest.ErrorResponse
+// @Failure 401 {object} rest.ErrorResponse
+// @Security JwtAuth
+// @Router /topology/events [get]
+func (s *Service) getTopologyEvents(c *gin.Context) {
+	if !requireDefaultCluster(c) {
+		return
+	}
+	limit := 0
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			rest.Error(c, rest.ErrBadRequest.New("invalid limit %s", v))
+			return
+		}
+		limit = n
+	}
+	c.JSON(http.StatusOK, s.maskForUser(c, s.events.Recent(limit)))
+}
+
+// TopologyEventsForUser returns all recent topology events, latest first. Addresses are masked when
+// the current user is not allowed to see them.
+func (s *Service) TopologyEventsForUser(c *gin.Context) []TopologyEvent {
+	return s.maskForUser(c, s.events.Recent(0)).([]TopologyEvent)
+}
+
+// HasNode returns whether the topology of the cluster has a node of the component at the `host:port` address.
+// The default cluster is used when clients are nil. It fails when the topology of the component can not be fetched.
+func (s *Service) HasNode(ctx context.Context, clients *cluster.Clients, kind topo.Kind, address string) (bool, error) {
+	info := s.fetchClusterInfo(ctx, s.topologyOfClients(clients))
+	if resp, ok := info.Errors[kind]; ok {
+		return false, ErrDependencyUnavailable.New("failed to fetch the topology of %s: %s", kind, resp.Message)
+	}
+	for _, n := range info.nodesOf(kind) {
+		if n.Address() == address {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
 // @ID getTiDBTopology
 // @Summary Get all TiDB instances
+// @Param with_load query boolean false "Whether to fetch the connection count of each instance"
 // @Success 200 {array} topology.TiDBInfo
 // @Router /topology/tidb [get]
 // @Security JwtAuth
 // @Failure 401 {object} rest.ErrorResponse
 func (s *Service) getTiDBTopology(c *gin.Context) {
-	instances, err := topology.FetchTiDBTopology(s.lifecycleCtx, s.params.EtcdClient)
-	if err != nil {
-		rest.Error(c, err)
-		return
-	}
-	c.JSON(http.StatusOK, instances)
+	withLoad := c.Query("with_load") == "true"
+	s.serveComponent(c, []topo.Kind{topo.KindTiDB}, func() (*ClusterInfo, error) {
+		instances, err := topology.FetchTiDBTopology(s.lifecycleCtx, s.etcdClientOf(c))
+		if err != nil {
+			return nil, err
+		}
+		if withLoad {
+			s.fillTiDBConnectionCounts(s.lifecycleCtx, instances)
+		}
+		return &ClusterInfo{TiDB: instances}, nil
+	}, func(info *ClusterInfo) interface{} {
+		return info.TiDB
+	})
 }
 
 // @ID getTiCDCTopology
@@ -133,12 +513,15 @@ func (s *Service) getTiDBTopology(c *gin.Context) {
 // @Security JwtAuth
 // @Failure 401 {object} rest.ErrorResponse
 func (s *Service) getTiCDCTopology(c *gin.Context) {
-	instances, err := topology.FetchTiCDCTopology(s.lifecycleCtx, s.params.EtcdClient)
-	if err != nil {
-		rest.Error(c, err)
-		return
-	}
-	c.JSON(http.StatusOK, instances)
+	s.serveComponent(c, []topo.Kind{topo.KindTiCDC}, func() (*ClusterInfo, error) {
+		instances, err := topology.FetchTiCDCTopology(s.lifecycleCtx, s.etcdClientOf(c))
+		if err != nil {
+			return nil, err
+		}
+		return &ClusterInfo{TiCDC: instances}, nil
+	}, func(info *ClusterInfo) interface{} {
+		return info.TiCDC
+	})
 }
 
 // @ID getTiProxyTopology
@@ -148,12 +531,15 @@ func (s *Service) getTiCDCTopology(c *gin.Context) {
 // @Security JwtAuth
 // @Failure 401 {object} rest.ErrorResponse
 func (s *Service) getTiProxyTopology(c *gin.Context) {
-	instances, err := topology.FetchTiProxyTopology(s.lifecycleCtx, s.params.EtcdClient)
-	if err != nil {
-		rest.Error(c, err)
-		return
-	}
-	c.JSON(http.StatusOK, instances)
+	s.serveComponent(c, []topo.Kind{topo.KindTiProxy}, func() (*ClusterInfo, error) {
+		instances, err := topology.FetchTiProxyTopology(s.lifecycleCtx, s.etcdClientOf(c))
+		if err != nil {
+			return nil, err
+		}
+		return &ClusterInfo{TiProxy: instances}, nil
+	}, func(info *ClusterInfo) interface{} {
+		return info.TiProxy
+	})
 }
 
 type StoreTopologyResponse struct {
@@ -168,14 +554,17 @@ type StoreTopologyResponse struct {
 // @Security JwtAuth
 // @Failure 401 {object} rest.ErrorResponse
 func (s *Service) getStoreTopology(c *gin.Context) {
-	tikvInstances, tiFlashInstances, err := topology.FetchStoreTopology(s.params.PDClient)
-	if err != nil {
-		rest.Error(c, err)
-		return
-	}
-	c.JSON(http.StatusOK, StoreTopologyResponse{
-		TiKV:    tikvInstances,
-		TiFlash: tiFlashInstances,
+	s.serveComponent(c, []topo.Kind{topo.KindTiKV, topo.KindTiFlash}, func() (*ClusterInfo, error) {
+		tikvInstances, tiFlashInstances, err := topology.FetchStoreTopology(s.pdClientOf(c))
+		if err != nil {
+			return nil, err
+		}
+		return &ClusterInfo{TiKV: tikvInstances, TiFlash: tiFlashInstances}, nil
+	}, func(info *ClusterInfo) interface{} {
+		return StoreTopologyResponse{
+			TiKV:    info.TiKV,
+			TiFlash: info.TiFlash,
+		}
 	})
 }
 
@@ -186,12 +575,61 @@ func (s *Service) getStoreTopology(c *gin.Context) {
 // @Security JwtAuth
 // @Failure 401 {object} rest.ErrorResponse
 func (s *Service) getStoreLocationTopology(c *gin.Context) {
-	storeLocation, err := topology.FetchStoreLocation(s.params.PDClient)
+	s.serveCached(c, func() (interface{}, error) {
+		return topology.FetchStoreLocation(s.pdClientOf(c))
+	})
+}
+
+// @ID getStoreInventory
+// @Summary Compare the stores known by PD with the authoritative store inventory
+// @Success 200 {object} StoreInventoryResponse
+// @Router /topology/store_inventory [get]
+// @Security JwtAuth
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 404 {object} rest.ErrorResponse
+func (s *Service) getStoreInventory(c *gin.Context) {
+	location := s.params.Config.TopologyStoreInventory
+	if location == "" {
+		rest.Error(c, rest.ErrNotFound.New("store inventory is not configured"))
+		return
+	}
+	inventory, err := s.loadStoreInventory(s.lifecycleCtx, location)
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	pdStores, err := topology.FetchStoreAddresses(s.pdClientOf(c))
 	if err != nil {
 		rest.Error(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, storeLocation)
+	c.JSON(http.StatusOK, s.maskForUser(c, mergeStoreInventory(pdStores, inventory)))
+}
+
+// @ID getRegionLeaderTopology
+// @Summary Get the TiKV instance hosting the leader of a region
+// @Param id path integer true "region ID"
+// @Success 200 {object} topology.StoreInfo
+// @Router /topology/region/{id}/leader [get]
+// @Security JwtAuth
+// @Failure 400 {object} rest.ErrorResponse
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 404 {object} rest.ErrorResponse
+func (s *Service) getRegionLeaderTopology(c *gin.Context) {
+	regionID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		rest.Error(c, rest.ErrBadRequest.New("invalid region id %s", c.Param("id")))
+		return
+	}
+	store, err := topology.FetchRegionLeaderStore(s.pdClientOf(c), regionID)
+	if err != nil {
+		if errorx.IsOfType(err, topology.ErrRegionNotFound) || errorx.IsOfType(err, topology.ErrStoreNotFound) {
+			err = rest.ErrNotFound.WrapWithNoMessage(err)
+		}
+		rest.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, s.maskForUser(c, store))
 }
 
 // @ID getPDTopology
@@ -201,12 +639,91 @@ func (s *Service) getStoreLocationTopology(c *gin.Context) {
 // @Security JwtAuth
 // @Failure 401 {object} rest.ErrorResponse
 func (s *Service) getPDTopology(c *gin.Context) {
-	instances, err := topology.FetchPDTopology(s.params.PDClient)
+	s.serveComponent(c, []topo.Kind{topo.KindPD}, func() (*ClusterInfo, error) {
+		instances, err := topology.FetchPDTopology(s.pdClientOf(c))
+		if err != nil {
+			return nil, err
+		}
+		return &ClusterInfo{PD: instances}, nil
+	}, func(info *ClusterInfo) interface{} {
+		return info.PD
+	})
+}
+
+// @ID deleteTombstoneStores
+// @Summary Remove all tombstone TiKV / TiFlash stores
+// @Success 200 "delete ok"
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 403 {object} rest.ErrorResponse
+// @Failure 500 {object} rest.ErrorResponse
+// @Security JwtAuth
+// @Router /topology/store/tombstone [delete]
+func (s *Service) deleteTombstoneStores(c *gin.Context) {
+	if _, err := s.pdClientOf(c).SendDeleteRequest("/stores/remove-tombstone"); err != nil {
+		rest.Error(c, err)
+		return
+	}
+	s.cache.Purge()
+	c.JSON(http.StatusOK, nil)
+}
+
+// @ID deletePDMember
+// @Summary Remove a stale PD member
+// @Description Only members that are not healthy can be removed.
+// @Param address path string true "ip:port"
+// @Success 200 "delete ok"
+// @Failure 400 {object} rest.ErrorResponse
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 403 {object} rest.ErrorResponse
+// @Failure 404 {object} rest.ErrorResponse
+// @Security JwtAuth
+// @Router /topology/pd/{address} [delete]
+func (s *Service) deletePDMember(c *gin.Context) {
+	address := c.Param("address")
+	members, err := topology.FetchPDTopology(s.pdClientOf(c))
 	if err != nil {
 		rest.Error(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, instances)
+	member, ok := lo.Find(members, func(m topology.PDInfo) bool {
+		return net.JoinHostPort(m.IP, strconv.Itoa(int(m.Port))) == address
+	})
+	if !ok {
+		rest.Error(c, rest.ErrNotFound.New("PD member %s is not found", address))
+		return
+	}
+	if member.Status == topology.ComponentStatusUp {
+		rest.Error(c, rest.ErrBadRequest.New("PD member %s is healthy", address))
+		return
+	}
+
+	if _, err := s.pdClientOf(c).SendDeleteRequest(fmt.Sprintf("/members/id/%d", member.MemberID)); err != nil {
+		rest.Error(c, err)
+		return
+	}
+	s.cache.Purge()
+	c.JSON(http.StatusOK, nil)
+}
+
+// @ID getPDMemberViews
+// @Summary Get the stores seen by each PD member
+// @Description Each PD member is queried independently. Stores not seen by all members are reported as discrepancies.
+// @Success 200 {object} PDMemberViewsResponse
+// @Router /topology/pd/member_views [get]
+// @Security JwtAuth
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 403 {object} rest.ErrorResponse
+func (s *Service) getPDMemberViews(c *gin.Context) {
+	members, err := topology.FetchPDTopology(s.pdClientOf(c))
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	views := fetchPDMemberViews(s.pdClientOf(c), members)
+	c.JSON(http.StatusOK, PDMemberViewsResponse{
+		Members:       views,
+		Discrepancies: findStoreDiscrepancies(views),
+	})
 }
 
 // @ID getAlertManagerTopology
@@ -216,12 +733,15 @@ func (s *Service) getPDTopology(c *gin.Context) {
 // @Security JwtAuth
 // @Failure 401 {object} rest.ErrorResponse
 func (s *Service) getAlertManagerTopology(c *gin.Context) {
-	instance, err := topology.FetchAlertManagerTopology(s.lifecycleCtx, s.params.EtcdClient)
-	if err != nil {
-		rest.Error(c, err)
-		return
-	}
-	c.JSON(http.StatusOK, instance)
+	s.serveComponent(c, []topo.Kind{topo.KindAlertManager}, func() (*ClusterInfo, error) {
+		instances, err := topology.FetchAlertManagerTopology(s.lifecycleCtx, s.etcdClientOf(c))
+		if err != nil {
+			return nil, err
+		}
+		return &ClusterInfo{AlertManager: instances}, nil
+	}, func(info *ClusterInfo) interface{} {
+		return info.AlertManager
+	})
 }
 
 // @ID getGrafanaTopology
@@ -231,12 +751,15 @@ func (s *Service) getAlertManagerTopology(c *gin.Context) {
 // @Security JwtAuth
 // @Failure 401 {object} rest.ErrorResponse
 func (s *Service) getGrafanaTopology(c *gin.Context) {
-	instance, err := topology.FetchGrafanaTopology(s.lifecycleCtx, s.params.EtcdClient)
-	if err != nil {
-		rest.Error(c, err)
-		return
-	}
-	c.JSON(http.StatusOK, instance)
+	s.serveComponent(c, []topo.Kind{topo.KindGrafana}, func() (*ClusterInfo, error) {
+		instances, err := topology.FetchGrafanaTopology(s.lifecycleCtx, s.etcdClientOf(c))
+		if err != nil {
+			return nil, err
+		}
+		return &ClusterInfo{Grafana: instances}, nil
+	}, func(info *ClusterInfo) interface{} {
+		return info.Grafana
+	})
 }
 
 // @ID getAlertManagerCounts
@@ -263,18 +786,30 @@ type GetHostsInfoResponse struct {
 
 // @ID clusterInfoGetHostsInfo
 // @Summary Get information of all hosts
+// @Description CSV and NDJSON flatten each host into a row, with the warning in the X-Warning header.
+// @Param format query string false "Response format, which is also negotiated by the Accept header" Enums(json, csv, ndjson)
 // @Router /host/all [get]
 // @Security JwtAuth
 // @Success 200 {object} GetHostsInfoResponse
 // @Failure 401 {object} rest.ErrorResponse
 func (s *Service) getHostsInfo(c *gin.Context) {
+	format, err := responseFormat(c, formatJSON, formatCSV, formatNDJSON)
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
 	db := utils.GetTiDBConnection(c)
 
-	info, err := s.fetchAllHostsInfo(db)
+	info, err := s.fetchAllHostsInfo(db, s.pdClientOf(c), s.etcdClientOf(c))
 	if err != nil && info == nil {
 		rest.Error(c, err)
 		return
 	}
+	info = s.maskForUser(c, info).([]*hostinfo.Info)
+	if format != formatJSON {
+		serveHostsTable(c, format, info, err)
+		return
+	}
 
 	var warning rest.ErrorResponse
 	if err != nil {
@@ -295,10 +830,13 @@ func (s *Service) getHostsInfo(c *gin.Context) {
 // @Failure 401 {object} rest.ErrorResponse
 func (s *Service) getStatistics(c *gin.Context) {
 	db := utils.GetTiDBConnection(c)
-	stats, err := s.calculateStatistics(db)
+	stats, err := s.calculateStatistics(db, s.pdClientOf(c), s.etcdClientOf(c))
 	if err != nil {
 		rest.Error(c, err)
 		return
 	}
+	if s.topology.snapshot != nil {
+		s.topology.snapshot.updateStatistics(stats, time.Now())
+	}
 	c.JSON(http.StatusOK, stats)
 }
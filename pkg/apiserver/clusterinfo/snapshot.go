@@ -0,0 +1,266 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package clusterinfo
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pingcap/log"
+	"go.uber.org/zap"
+
+	"github.com/pingcap/tidb-dashboard/util/rest"
+	"github.com/pingcap/tidb-dashboard/util/topo"
+)
+
+const (
+	// topologySnapshotPersistInterval limits how often the snapshot is written to the file, as it is updated by
+	// every successful fetch. Updates in between are written by the next persist or by flush.
+	topologySnapshotPersistInterval = time.Minute
+
+	// staleSinceHeader is set when the response is served from the last-known-good snapshot, with the time
+	// when the snapshot was taken.
+	staleSinceHeader = "X-Stale-Since"
+)
+
+// topologySnapshotFileName returns the file name of the snapshot of the cluster. Snapshots are keyed by both the
+// cluster ID and the PD endpoint, so that a different cluster behind the same ID never serves the snapshot.
+func topologySnapshotFileName(clusterID, pdEndpoint string) string {
+	sum := sha256.Sum256([]byte(clusterID + "\x00" + pdEndpoint))
+	return fmt.Sprintf("topology_snapshot_%s.json", hex.EncodeToString(sum[:8]))
+}
+
+// topologySnapshotFile is the persisted format of topologySnapshot.
+type topologySnapshotFile struct {
+	ClusterID  string                  `json:"cluster_id"`
+	PDEndpoint string                  `json:"pd_endpoint"`
+	Info       ClusterInfo             `json:"info"`
+	TakenAt    map[topo.Kind]time.Time `json:"taken_at"`
+	// Statistics is nil if the statistics were never calculated successfully.
+	Statistics        *ClusterStatistics `json:"statistics,omitempty"`
+	StatisticsTakenAt time.Time          `json:"statistics_taken_at,omitempty"`
+}
+
+// topologySnapshot keeps the last-known-good topology of each component, which is served when fetching the
+// component fails, e.g. when etcd is unavailable. It is persisted into the file under the data dir, if not empty,
+// so that it survives restarts. This struct is concurrent-safe.
+type topologySnapshot struct {
+	mu   sync.Mutex
+	path string
+	data topologySnapshotFile
+	// dirty is true when the data is changed since persistedAt.
+	dirty       bool
+	persistedAt time.Time
+}
+
+func newTopologySnapshot(dataDir, clusterID, pdEndpoint string) *topologySnapshot {
+	s := &topologySnapshot{data: topologySnapshotFile{
+		ClusterID:  clusterID,
+		PDEndpoint: pdEndpoint,
+		TakenAt:    map[topo.Kind]time.Time{},
+	}}
+	if dataDir == "" {
+		return s
+	}
+	s.path = filepath.Join(dataDir, topologySnapshotFileName(clusterID, pdEndpoint))
+	content, err := os.ReadFile(s.path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Warn("Failed to read topology snapshot", zap.String("path", s.path), zap.Error(err))
+		}
+		return s
+	}
+	var data topologySnapshotFile
+	if err := json.Unmarshal(content, &data); err != nil {
+		log.Warn("Failed to parse topology snapshot, ignored", zap.String("path", s.path), zap.Error(err))
+		return s
+	}
+	if data.ClusterID != clusterID || data.PDEndpoint != pdEndpoint {
+		log.Warn("Topology snapshot is of another cluster, ignored",
+			zap.String("path", s.path),
+			zap.String("clusterID", data.ClusterID),
+			zap.String("pdEndpoint", data.PDEndpoint))
+		return s
+	}
+	if data.TakenAt == nil {
+		data.TakenAt = map[topo.Kind]time.Time{}
+	}
+	s.data = data
+	return s
+}
+
+// update replaces the snapshot of the fetched components by their topology in info.
+func (s *topologySnapshot) update(info *ClusterInfo, fetched []topo.Kind, at time.Time) {
+	if len(fetched) == 0 {
+		return
+	}
+	isFetched := make(map[topo.Kind]bool, len(fetched))
+	for _, kind := range fetched {
+		isFetched[kind] = true
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	next := ClusterInfo{}
+	for _, kind := range clusterComponents {
+		if isFetched[kind] {
+			next.addNodes(info.nodesOf(kind))
+			s.data.TakenAt[kind] = at
+		} else {
+			next.addNodes(s.data.Info.nodesOf(kind))
+		}
+	}
+	s.data.Info = next
+	s.markDirty(at)
+}
+
+// updateStatistics replaces the snapshot of the cluster statistics.
+func (s *topologySnapshot) updateStatistics(stats *ClusterStatistics, at time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.Statistics = stats
+	s.data.StatisticsTakenAt = at
+	s.markDirty(at)
+}
+
+// statistics returns the last-known-good cluster statistics and the time when they were taken.
+func (s *topologySnapshot) statistics() (*ClusterStatistics, time.Time, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.data.Statistics == nil {
+		return nil, time.Time{}, false
+	}
+	return s.data.Statistics, s.data.StatisticsTakenAt, true
+}
+
+// markDirty must be called with the lock held. The snapshot is persisted at most once per
+// topologySnapshotPersistInterval.
+func (s *topologySnapshot) markDirty(at time.Time) {
+	s.dirty = true
+	if at.Sub(s.persistedAt) >= topologySnapshotPersistInterval {
+		s.persist(at)
+	}
+}
+
+// flush persists the changes not persisted yet, e.g. when the service stops.
+func (s *topologySnapshot) flush() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.dirty {
+		s.persist(time.Now())
+	}
+}
+
+// persist must be called with the lock held.
+func (s *topologySnapshot) persist(at time.Time) {
+	if s.path == "" {
+		return
+	}
+	s.dirty = false
+	s.persistedAt = at
+	content, err := json.Marshal(s.data)
+	if err != nil {
+		log.Warn("Failed to encode topology snapshot", zap.Error(err))
+		return
+	}
+	// Write to a temporary file first, so that the snapshot is never partially written.
+	tmpPath := s.path + ".tmp"
+	if err := os.WriteFile(tmpPath, content, 0o600); err != nil {
+		log.Warn("Failed to write topology snapshot", zap.String("path", s.path), zap.Error(err))
+		return
+	}
+	if err := os.Rename(tmpPath, s.path); err != nil {
+		log.Warn("Failed to write topology snapshot", zap.String("path", s.path), zap.Error(err))
+	}
+}
+
+// fill adds the last-known-good topology of the failed components into info, and marks them as stale.
+// Components never fetched successfully are left unchanged.
+func (s *topologySnapshot) fill(info *ClusterInfo, failed []topo.Kind) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, kind := range failed {
+		takenAt, ok := s.data.TakenAt[kind]
+		if !ok {
+			continue
+		}
+		info.addNodes(s.data.Info.nodesOf(kind))
+		if info.StaleSince == nil {
+			info.StaleSince = make(map[topo.Kind]time.Time)
+		}
+		info.StaleSince[kind] = takenAt
+	}
+}
+
+// serveComponent is serveCached for APIs of a single component, e.g. /topology/tidb. The fetched topology of the
+// component is kept in the snapshot, and when the fetch fails, the response is built from the snapshot instead,
+// with the time when the snapshot was taken in staleSinceHeader. The stale response is never cached.
+func (s *Service) serveComponent(
+	c *gin.Context,
+	kinds []topo.Kind,
+	fetch func() (*ClusterInfo, error),
+	response func(info *ClusterInfo) interface{},
+) {
+	t := s.topologyOf(c)
+	v, err := s.cachedFetch(c, func() (interface{}, error) {
+		info, err := fetch()
+		if err != nil {
+			return nil, err
+		}
+		if t.snapshot != nil {
+			t.snapshot.update(info, kinds, time.Now())
+		}
+		return response(info), nil
+	})
+	if err != nil {
+		if t.snapshot == nil {
+			rest.Error(c, err)
+			return
+		}
+		info := &ClusterInfo{}
+		t.snapshot.fill(info, kinds)
+		if len(info.StaleSince) < len(kinds) {
+			rest.Error(c, err)
+			return
+		}
+		var staleSince time.Time
+		for _, takenAt := range info.StaleSince {
+			if staleSince.IsZero() || takenAt.Before(staleSince) {
+				staleSince = takenAt
+			}
+		}
+		log.Warn("Failed to fetch topology, served from the snapshot",
+			zap.String("uri", c.Request.RequestURI),
+			zap.Time("staleSince", staleSince),
+			zap.Error(err))
+		c.Header(staleSinceHeader, staleSince.UTC().Format(time.RFC3339))
+		v = response(info)
+	}
+	c.JSON(http.StatusOK, s.maskForUser(c, v))
+}
+
+// mwServeStaleStatistics serves the last-known-good cluster statistics when they can not be calculated, e.g. when
+// TiDB is unavailable. Errors caused by the request itself, e.g. lacking the SQL privilege, are still responded.
+func (s *Service) mwServeStaleStatistics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if s.topology.snapshot == nil || c.Writer.Written() || rest.ResponseStatus(c) < http.StatusInternalServerError {
+			return
+		}
+		stats, takenAt, ok := s.topology.snapshot.statistics()
+		if !ok {
+			return
+		}
+		c.Header(staleSinceHeader, takenAt.UTC().Format(time.RFC3339))
+		c.JSON(http.StatusOK, stats)
+	}
+}
@@ -0,0 +1,224 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package clusterinfo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/cluster"
+	"github.com/pingcap/tidb-dashboard/pkg/utils/topology"
+	"github.com/pingcap/tidb-dashboard/util/rest"
+	"github.com/pingcap/tidb-dashboard/util/topo"
+)
+
+const testPDEndpoint = "http://10.0.1.2:2379"
+
+// etcdDownSource returns a TiDB instance until etcd is down.
+type etcdDownSource struct {
+	down bool
+}
+
+func (s *etcdDownSource) Kinds() []topo.Kind {
+	return []topo.Kind{topo.KindTiDB}
+}
+
+func (s *etcdDownSource) Fetch(ctx context.Context) ([]Node, error) {
+	if s.down {
+		return nil, errors.New("etcd is unavailable")
+	}
+	info := &ClusterInfo{TiDB: []topology.TiDBInfo{{IP: "10.0.1.1", Port: 4000, Version: "v7.5.0"}}}
+	return info.nodesOf(topo.KindTiDB), nil
+}
+
+func TestFetchClusterInfoServesSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	src := &etcdDownSource{}
+	s := &Service{}
+	ct := &clusterTopology{sources: []TopologySource{src}, snapshot: newTopologySnapshot(dir, cluster.DefaultClusterID, testPDEndpoint)}
+
+	info := s.fetchClusterInfo(context.Background(), ct)
+	require.Empty(t, info.Errors)
+	require.Empty(t, info.StaleSince)
+	require.Len(t, info.TiDB, 1)
+
+	src.down = true
+	info = s.fetchClusterInfo(context.Background(), ct)
+	require.Contains(t, info.Errors, topo.KindTiDB)
+	require.Len(t, info.TiDB, 1)
+	require.Equal(t, "v7.5.0", info.TiDB[0].Version)
+	takenAt, ok := info.StaleSince[topo.KindTiDB]
+	require.True(t, ok)
+	require.False(t, takenAt.IsZero())
+
+	// The snapshot survives restarts.
+	ct = &clusterTopology{sources: []TopologySource{src}, snapshot: newTopologySnapshot(dir, cluster.DefaultClusterID, testPDEndpoint)}
+	info = s.fetchClusterInfo(context.Background(), ct)
+	require.Len(t, info.TiDB, 1)
+	require.True(t, takenAt.Equal(info.StaleSince[topo.KindTiDB]))
+}
+
+func TestTopologySnapshotKeepsOtherComponents(t *testing.T) {
+	snapshot := newTopologySnapshot("", cluster.DefaultClusterID, testPDEndpoint)
+	info := &ClusterInfo{
+		TiDB: []topology.TiDBInfo{{IP: "10.0.1.1", Port: 4000}},
+		PD:   []topology.PDInfo{{IP: "10.0.1.2", Port: 2379}},
+	}
+	at := time.Now()
+	snapshot.update(info, []topo.Kind{topo.KindTiDB, topo.KindPD}, at)
+
+	// Only fetched components are replaced.
+	snapshot.update(&ClusterInfo{}, []topo.Kind{topo.KindPD}, at)
+	filled := &ClusterInfo{}
+	snapshot.fill(filled, []topo.Kind{topo.KindTiDB, topo.KindPD, topo.KindTiCDC})
+	require.Len(t, filled.TiDB, 1)
+	require.Empty(t, filled.PD)
+	require.Contains(t, filled.StaleSince, topo.KindPD)
+	require.NotContains(t, filled.StaleSince, topo.KindTiCDC)
+}
+
+func TestTopologySnapshotKeyedByCluster(t *testing.T) {
+	dir := t.TempDir()
+	info := &ClusterInfo{TiDB: []topology.TiDBInfo{{IP: "10.0.1.1", Port: 4000}}}
+	newTopologySnapshot(dir, "c1", testPDEndpoint).update(info, []topo.Kind{topo.KindTiDB}, time.Now())
+
+	filled := &ClusterInfo{}
+	newTopologySnapshot(dir, "c1", testPDEndpoint).fill(filled, []topo.Kind{topo.KindTiDB})
+	require.Len(t, filled.TiDB, 1)
+
+	// Neither another cluster nor the same cluster ID behind another PD serves the snapshot.
+	for _, key := range [][2]string{{"c2", testPDEndpoint}, {"c1", "http://10.0.2.2:2379"}} {
+		filled = &ClusterInfo{}
+		newTopologySnapshot(dir, key[0], key[1]).fill(filled, []topo.Kind{topo.KindTiDB})
+		require.Empty(t, filled.TiDB)
+		require.Empty(t, filled.StaleSince)
+	}
+
+	// The snapshot of another cluster is ignored even if it is placed under the file name of this cluster.
+	content, err := os.ReadFile(filepath.Join(dir, topologySnapshotFileName("c1", testPDEndpoint)))
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, topologySnapshotFileName("c2", testPDEndpoint)), content, 0o600))
+	filled = &ClusterInfo{}
+	newTopologySnapshot(dir, "c2", testPDEndpoint).fill(filled, []topo.Kind{topo.KindTiDB})
+	require.Empty(t, filled.TiDB)
+}
+
+func TestTopologySnapshotPersistThrottled(t *testing.T) {
+	dir := t.TempDir()
+	snapshot := newTopologySnapshot(dir, cluster.DefaultClusterID, testPDEndpoint)
+	persisted := func() []topology.TiDBInfo {
+		filled := &ClusterInfo{}
+		newTopologySnapshot(dir, cluster.DefaultClusterID, testPDEndpoint).fill(filled, []topo.Kind{topo.KindTiDB})
+		return filled.TiDB
+	}
+
+	at := time.Now()
+	snapshot.update(&ClusterInfo{TiDB: []topology.TiDBInfo{{IP: "10.0.1.1", Port: 4000}}}, []topo.Kind{topo.KindTiDB}, at)
+	require.Len(t, persisted(), 1)
+
+	// Updates within the interval are not written until flushed.
+	snapshot.update(&ClusterInfo{}, []topo.Kind{topo.KindTiDB}, at.Add(time.Second))
+	require.Len(t, persisted(), 1)
+	snapshot.flush()
+	require.Empty(t, persisted())
+
+	snapshot.update(&ClusterInfo{TiDB: []topology.TiDBInfo{{IP: "10.0.1.1", Port: 4000}}}, []topo.Kind{topo.KindTiDB}, at.Add(topologySnapshotPersistInterval+time.Second))
+	require.Len(t, persisted(), 1)
+}
+
+func TestServeComponentFromSnapshot(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := &Service{
+		cache:    newTopologyCache(defaultTopologyCacheSize, 0),
+		topology: &clusterTopology{snapshot: newTopologySnapshot("", cluster.DefaultClusterID, testPDEndpoint)},
+	}
+	etcdDown := false
+	engine := gin.New()
+	engine.Use(rest.ErrorHandlerFn())
+	engine.GET("/topology/tidb", func(c *gin.Context) {
+		s.serveComponent(c, []topo.Kind{topo.KindTiDB}, func() (*ClusterInfo, error) {
+			if etcdDown {
+				return nil, errors.New("etcd is unavailable")
+			}
+			return &ClusterInfo{TiDB: []topology.TiDBInfo{{IP: "10.0.1.1", Port: 4000}}}, nil
+		}, func(info *ClusterInfo) interface{} {
+			return info.TiDB
+		})
+	})
+	get := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/topology/tidb", nil))
+		return w
+	}
+
+	// Nothing to serve before the first successful fetch.
+	etcdDown = true
+	w := get()
+	require.Equal(t, http.StatusInternalServerError, w.Code)
+
+	etcdDown = false
+	w = get()
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Empty(t, w.Header().Get(staleSinceHeader))
+
+	etcdDown = true
+	w = get()
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NotEmpty(t, w.Header().Get(staleSinceHeader))
+	var instances []topology.TiDBInfo
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &instances))
+	require.Len(t, instances, 1)
+	require.Equal(t, "10.0.1.1", instances[0].IP)
+}
+
+func TestServeStaleStatistics(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := &Service{
+		topology: &clusterTopology{snapshot: newTopologySnapshot("", cluster.DefaultClusterID, testPDEndpoint)},
+	}
+	var handlerErr error
+	engine := gin.New()
+	engine.Use(rest.ErrorHandlerFn())
+	engine.GET("/host/statistics", s.mwServeStaleStatistics(), func(c *gin.Context) {
+		if handlerErr != nil {
+			rest.Error(c, handlerErr)
+			return
+		}
+		c.JSON(http.StatusOK, &ClusterStatistics{ProbeFailureHosts: 1})
+	})
+	get := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/host/statistics", nil))
+		return w
+	}
+
+	handlerErr = errors.New("TiDB is unavailable")
+	require.Equal(t, http.StatusInternalServerError, get().Code)
+
+	s.topology.snapshot.updateStatistics(&ClusterStatistics{ProbeFailureHosts: 2}, time.Now())
+	w := get()
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NotEmpty(t, w.Header().Get(staleSinceHeader))
+	var stats ClusterStatistics
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &stats))
+	require.Equal(t, 2, stats.ProbeFailureHosts)
+
+	// Errors of the request are not hidden by the snapshot.
+	handlerErr = rest.ErrForbidden.NewWithNoMessage()
+	require.Equal(t, http.StatusForbidden, get().Code)
+
+	handlerErr = nil
+	w = get()
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Empty(t, w.Header().Get(staleSinceHeader))
+}
@@ -0,0 +1,213 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package clusterinfo
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+
+	"github.com/samber/lo"
+	"go.etcd.io/etcd/clientv3"
+
+	"github.com/pingcap/tidb-dashboard/pkg/pd"
+	"github.com/pingcap/tidb-dashboard/pkg/utils/topology"
+	"github.com/pingcap/tidb-dashboard/util/topo"
+)
+
+// TopologySource discovers nodes of some components in the cluster.
+type TopologySource interface {
+	// Kinds returns the components discovered by the source. When Fetch fails,
+	// the error is reported for all these components.
+	Kinds() []topo.Kind
+	Fetch(ctx context.Context) ([]Node, error)
+}
+
+// regionSplitSource is implemented by sources that also provide the region split settings.
+type regionSplitSource interface {
+	FetchRegionSplitConfig(ctx context.Context) (*topology.RegionSplitConfig, error)
+}
+
+// dependentSource is implemented by sources that can only be fetched when other components are available.
+type dependentSource interface {
+	DependsOn() []topo.Kind
+}
+
+// newTopologySources assembles the sources of the default cluster according to the config.
+func newTopologySources(p ServiceParams) []TopologySource {
+	if p.Config != nil && p.Config.TopologyStaticFile != "" {
+		return []TopologySource{newStaticFileSource(p.Config.TopologyStaticFile)}
+	}
+	if p.Config != nil && p.Config.TopologyTiUPMetaFile != "" {
+		return []TopologySource{newTiUPMetaSource(p.Config.TopologyTiUPMetaFile)}
+	}
+	return newClusterSources(p.PDClient, p.EtcdClients.Client)
+}
+
+// newClusterSources assembles the sources discovering the cluster from PD and etcd.
+func newClusterSources(pdClient *pd.Client, etcdClient func() *clientv3.Client) []TopologySource {
+	return []TopologySource{
+		newEtcdSource(topo.KindTiDB, etcdClient),
+		newPDStoreSource(pdClient),
+		newPDMemberSource(pdClient),
+		newEtcdSource(topo.KindTiCDC, etcdClient),
+		newEtcdSource(topo.KindTiProxy, etcdClient),
+		newEtcdSource(topo.KindPump, etcdClient),
+		newEtcdSource(topo.KindDrainer, etcdClient),
+		newEtcdSource(topo.KindGrafana, etcdClient),
+		newEtcdSource(topo.KindAlertManager, etcdClient),
+		newEtcdSource(topo.KindPrometheus, etcdClient),
+	}
+}
+
+// enabledSources filters out sources whose components are all disabled.
+func (s *Service) enabledSources(sources []TopologySource) []TopologySource {
+	return lo.Filter(sources, func(src TopologySource, _ int) bool {
+		return lo.SomeBy(src.Kinds(), func(kind topo.Kind) bool {
+			_, disabled := s.disabledKinds[kind]
+			return !disabled
+		})
+	})
+}
+
+// etcdSource discovers a component registered in etcd.
+type etcdSource struct {
+	kind topo.Kind
+	// client returns the current etcd client, which may be rebuilt.
+	client func() *clientv3.Client
+}
+
+func newEtcdSource(kind topo.Kind, client func() *clientv3.Client) *etcdSource {
+	return &etcdSource{kind: kind, client: client}
+}
+
+func (s *etcdSource) Kinds() []topo.Kind {
+	return []topo.Kind{s.kind}
+}
+
+func (s *etcdSource) Fetch(ctx context.Context) ([]Node, error) {
+	info := &ClusterInfo{}
+	client := s.client()
+	var err error
+	switch s.kind {
+	case topo.KindTiDB:
+		info.TiDB, err = topology.FetchTiDBTopology(ctx, client)
+	case topo.KindTiCDC:
+		info.TiCDC, err = topology.FetchTiCDCTopology(ctx, client)
+	case topo.KindTiProxy:
+		info.TiProxy, err = topology.FetchTiProxyTopology(ctx, client)
+	case topo.KindPump:
+		info.Pump, err = topology.FetchPumpTopology(ctx, client)
+	case topo.KindDrainer:
+		info.Drainer, err = topology.FetchDrainerTopology(ctx, client)
+	case topo.KindGrafana:
+		info.Grafana, err = topology.FetchGrafanaTopology(ctx, client)
+	case topo.KindAlertManager:
+		info.AlertManager, err = topology.FetchAlertManagerTopology(ctx, client)
+	case topo.KindPrometheus:
+		info.Prometheus, err = topology.FetchPrometheusTopology(ctx, client)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return info.nodesOf(s.kind), nil
+}
+
+// pdStoreSource discovers TiKV and TiFlash stores from PD.
+type pdStoreSource struct {
+	client *pd.Client
+}
+
+func newPDStoreSource(client *pd.Client) *pdStoreSource {
+	return &pdStoreSource{client: client}
+}
+
+func (s *pdStoreSource) Kinds() []topo.Kind {
+	return []topo.Kind{topo.KindTiKV, topo.KindTiFlash}
+}
+
+func (s *pdStoreSource) DependsOn() []topo.Kind {
+	return []topo.Kind{topo.KindPD}
+}
+
+func (s *pdStoreSource) Fetch(ctx context.Context) ([]Node, error) {
+	info := &ClusterInfo{}
+	var err error
+	info.TiKV, info.TiFlash, err = topology.FetchStoreTopology(s.client)
+	if err != nil {
+		return nil, err
+	}
+	return info.nodes(), nil
+}
+
+// pdMemberSource discovers PD members.
+type pdMemberSource struct {
+	client *pd.Client
+}
+
+func newPDMemberSource(client *pd.Client) *pdMemberSource {
+	return &pdMemberSource{client: client}
+}
+
+func (s *pdMemberSource) Kinds() []topo.Kind {
+	return []topo.Kind{topo.KindPD}
+}
+
+func (s *pdMemberSource) Fetch(ctx context.Context) ([]Node, error) {
+	info := &ClusterInfo{}
+	var err error
+	info.PD, err = topology.FetchPDTopology(s.client)
+	if err != nil {
+		return nil, err
+	}
+	return info.nodes(), nil
+}
+
+func (s *pdMemberSource) FetchRegionSplitConfig(ctx context.Context) (*topology.RegionSplitConfig, error) {
+	return topology.FetchRegionSplitConfig(s.client)
+}
+
+// staticFileSource loads the topology from a JSON file in the format of ClusterInfo,
+// e.g. saved from `/topology/all`. It is useful for demos without a running cluster.
+type staticFileSource struct {
+	path string
+}
+
+func newStaticFileSource(path string) *staticFileSource {
+	return &staticFileSource{path: path}
+}
+
+func (s *staticFileSource) Kinds() []topo.Kind {
+	return clusterComponents
+}
+
+func (s *staticFileSource) load() (*ClusterInfo, error) {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return nil, ErrInvalidTopologySource.Wrap(err, "failed to read topology file %s", s.path)
+	}
+	var info ClusterInfo
+	if err := json.Unmarshal(data, &info); err != nil {
+		return nil, ErrInvalidTopologySource.Wrap(err, "failed to parse topology file %s", s.path)
+	}
+	return &info, nil
+}
+
+func (s *staticFileSource) Fetch(ctx context.Context) ([]Node, error) {
+	info, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	return info.nodes(), nil
+}
+
+func (s *staticFileSource) FetchRegionSplitConfig(ctx context.Context) (*topology.RegionSplitConfig, error) {
+	info, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	return &topology.RegionSplitConfig{
+		RegionMaxSize: info.RegionMaxSize,
+		RegionMaxKeys: info.RegionMaxKeys,
+	}, nil
+}
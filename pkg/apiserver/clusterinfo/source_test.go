@@ -0,0 +1,236 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package clusterinfo
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pingcap/tidb-dashboard/pkg/config"
+	"github.com/pingcap/tidb-dashboard/util/topo"
+)
+
+const testStaticTopology = `{
+	"tidb": [{"ip": "10.0.1.1", "port": 4000, "status_port": 10080, "version": "v7.5.0"}],
+	"tikv": [{"store_id": 1, "ip": "10.0.2.1", "port": 20160, "status_port": 20180}],
+	"tiflash": [{"store_id": 100, "ip": "10.0.2.9", "port": 3930, "status_port": 20292}],
+	"pd": [{"ip": "10.0.0.1", "port": 2379}],
+	"grafana": {"ip": "10.0.3.1", "port": 3000},
+	"region_max_size": "144MiB",
+	"region_max_keys": 1440000
+}`
+
+func writeTestFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestStaticFileSource(t *testing.T) {
+	path := writeTestFile(t, "topology.json", testStaticTopology)
+	src := newStaticFileSource(path)
+
+	nodes, err := src.Fetch(context.Background())
+	require.NoError(t, err)
+	addresses := make([]string, 0, len(nodes))
+	for _, n := range nodes {
+		addresses = append(addresses, string(n.Kind)+"/"+n.Address())
+	}
+	require.Equal(t, []string{
+		"pd/10.0.0.1:2379",
+		"tidb/10.0.1.1:4000",
+		"tikv/10.0.2.1:20160",
+		"tiflash/10.0.2.9:3930",
+		"grafana/10.0.3.1:3000",
+	}, addresses)
+
+	p := ServiceParams{Config: &config.Config{TopologyStaticFile: path}}
+	s := &Service{params: p}
+	ct := &clusterTopology{sources: newTopologySources(p)}
+	info := s.fetchClusterInfo(context.Background(), ct)
+	require.Empty(t, info.Errors)
+	require.Len(t, info.TiDB, 1)
+	require.Equal(t, "v7.5.0", info.TiDB[0].Version)
+	require.Len(t, info.TiKV, 1)
+	require.Len(t, info.TiFlash, 1)
+	require.Equal(t, 100, info.TiFlash[0].StoreID)
+	require.NotNil(t, info.Grafana)
+	require.Nil(t, info.Prometheus)
+	require.Equal(t, "144MiB", info.RegionMaxSize)
+	require.Equal(t, int64(1440000), info.RegionMaxKeys)
+}
+
+func TestStaticFileSourceMissing(t *testing.T) {
+	s := &Service{}
+	ct := &clusterTopology{sources: []TopologySource{newStaticFileSource(filepath.Join(t.TempDir(), "missing.json"))}}
+	info := s.fetchClusterInfo(context.Background(), ct)
+	require.Len(t, info.Errors, len(clusterComponents))
+	require.Contains(t, info.Errors, topo.KindTiDB)
+}
+
+type failingSource struct{}
+
+func (failingSource) Kinds() []topo.Kind {
+	return []topo.Kind{topo.KindTiCDC}
+}
+
+func (failingSource) Fetch(ctx context.Context) ([]Node, error) {
+	return nil, errors.New("source is down")
+}
+
+func TestFetchClusterInfoFromSources(t *testing.T) {
+	path := writeTestFile(t, "topology.json", `{"tidb": [{"ip": "10.0.1.1", "port": 4000}]}`)
+	s := &Service{}
+	ct := &clusterTopology{sources: []TopologySource{newStaticFileSource(path), failingSource{}}}
+	info := s.fetchClusterInfo(context.Background(), ct)
+	require.Len(t, info.TiDB, 1)
+	require.Len(t, info.Errors, 1)
+	require.Contains(t, info.Errors[topo.KindTiCDC].Message, "source is down")
+}
+
+func TestHasNode(t *testing.T) {
+	path := writeTestFile(t, "topology.json", `{"tidb": [{"ip": "10.0.1.1", "port": 4000}]}`)
+	s := &Service{topology: &clusterTopology{sources: []TopologySource{newStaticFileSource(path), failingSource{}}}}
+	found, err := s.HasNode(context.Background(), nil, topo.KindTiDB, "10.0.1.1:4000")
+	require.NoError(t, err)
+	require.True(t, found)
+	found, err = s.HasNode(context.Background(), nil, topo.KindTiDB, "10.0.1.1:4001")
+	require.NoError(t, err)
+	require.False(t, found)
+	_, err = s.HasNode(context.Background(), nil, topo.KindTiCDC, "10.0.1.1:8300")
+	require.Error(t, err)
+}
+
+type toggleSource struct {
+	fail bool
+}
+
+func (s *toggleSource) Kinds() []topo.Kind {
+	return []topo.Kind{topo.KindTiCDC}
+}
+
+func (s *toggleSource) Fetch(ctx context.Context) ([]Node, error) {
+	if s.fail {
+		return nil, errors.New("source is down")
+	}
+	return nil, nil
+}
+
+func TestFetchClusterInfoLastSuccessAt(t *testing.T) {
+	src := &toggleSource{}
+	s := &Service{}
+	ct := &clusterTopology{sources: []TopologySource{src}}
+
+	info := s.fetchClusterInfo(context.Background(), ct)
+	require.Empty(t, info.Errors)
+	lastSuccess, ok := info.LastSuccessAt[topo.KindTiCDC]
+	require.True(t, ok)
+	require.False(t, lastSuccess.IsZero())
+
+	src.fail = true
+	info = s.fetchClusterInfo(context.Background(), ct)
+	require.Contains(t, info.Errors, topo.KindTiCDC)
+	require.Equal(t, lastSuccess, info.LastSuccessAt[topo.KindTiCDC])
+	require.NotContains(t, info.LastSuccessAt, topo.KindTiDB)
+}
+
+type fakeSource struct {
+	kinds     []topo.Kind
+	dependsOn []topo.Kind
+	err       error
+	calls     int32
+}
+
+func (s *fakeSource) Kinds() []topo.Kind {
+	return s.kinds
+}
+
+func (s *fakeSource) DependsOn() []topo.Kind {
+	return s.dependsOn
+}
+
+func (s *fakeSource) Fetch(ctx context.Context) ([]Node, error) {
+	atomic.AddInt32(&s.calls, 1)
+	return nil, s.err
+}
+
+func TestFetchClusterInfoDependencyAware(t *testing.T) {
+	pdSrc := &fakeSource{kinds: []topo.Kind{topo.KindPD}, err: errors.New("pd is down")}
+	storeSrc := &fakeSource{kinds: []topo.Kind{topo.KindTiKV, topo.KindTiFlash}, dependsOn: []topo.Kind{topo.KindPD}}
+	tidbSrc := &fakeSource{kinds: []topo.Kind{topo.KindTiDB}}
+	s := &Service{params: ServiceParams{Config: &config.Config{TopologyDependencyAwareFetch: true}}}
+	ct := &clusterTopology{sources: []TopologySource{storeSrc, pdSrc, tidbSrc}}
+
+	info := s.fetchClusterInfo(context.Background(), ct)
+	require.Equal(t, int32(0), atomic.LoadInt32(&storeSrc.calls))
+	require.Equal(t, int32(1), atomic.LoadInt32(&tidbSrc.calls))
+	require.Contains(t, info.Errors, topo.KindPD)
+	require.Equal(t, "api.clusterinfo.dependency_unavailable", info.Errors[topo.KindTiKV].Code)
+	require.Equal(t, "api.clusterinfo.dependency_unavailable", info.Errors[topo.KindTiFlash].Code)
+	require.NotContains(t, info.Errors, topo.KindTiDB)
+
+	// Dependencies are fetched in parallel when the ordering is disabled.
+	s.params.Config.TopologyDependencyAwareFetch = false
+	info = s.fetchClusterInfo(context.Background(), ct)
+	require.Equal(t, int32(1), atomic.LoadInt32(&storeSrc.calls))
+	require.NotContains(t, info.Errors, topo.KindTiKV)
+
+	// Dependent sources proceed when the dependency succeeds.
+	s.params.Config.TopologyDependencyAwareFetch = true
+	pdSrc.err = nil
+	info = s.fetchClusterInfo(context.Background(), ct)
+	require.Equal(t, int32(2), atomic.LoadInt32(&storeSrc.calls))
+	require.Empty(t, info.Errors)
+}
+
+// blockingSource blocks until released, ignoring the context.
+type blockingSource struct {
+	release chan struct{}
+}
+
+func (s *blockingSource) Kinds() []topo.Kind {
+	return []topo.Kind{topo.KindAlertManager}
+}
+
+func (s *blockingSource) Fetch(ctx context.Context) ([]Node, error) {
+	<-s.release
+	return nil, nil
+}
+
+func TestFetchClusterInfoSourceTimeout(t *testing.T) {
+	path := writeTestFile(t, "topology.json", `{"tidb": [{"ip": "10.0.1.1", "port": 4000}]}`)
+	blocking := &blockingSource{release: make(chan struct{})}
+	defer close(blocking.release)
+	s := &Service{sourceTimeout: 50 * time.Millisecond}
+	ct := &clusterTopology{sources: []TopologySource{newStaticFileSource(path), blocking}}
+
+	info := s.fetchClusterInfo(context.Background(), ct)
+	require.Len(t, info.TiDB, 1)
+	require.Len(t, info.Errors, 1)
+	require.Equal(t, "api.clusterinfo.fetch_timeout", info.Errors[topo.KindAlertManager].Code)
+}
+
+func TestFetchClusterInfoDisabledComponents(t *testing.T) {
+	path := writeTestFile(t, "topology.json", `{
+  "tidb": [{"ip": "10.0.1.1", "port": 4000}],
+  "alert_manager": {"ip": "10.0.1.2", "port": 9093}
+}`)
+	s := &Service{disabledKinds: map[topo.Kind]struct{}{
+		topo.KindAlertManager: {},
+		topo.KindTiCDC:        {},
+	}}
+	ct := &clusterTopology{sources: s.enabledSources([]TopologySource{newStaticFileSource(path), failingSource{}})}
+	require.Len(t, ct.sources, 1)
+
+	info := s.fetchClusterInfo(context.Background(), ct)
+	require.Len(t, info.TiDB, 1)
+	require.Nil(t, info.AlertManager)
+	require.Empty(t, info.Errors)
+}
@@ -3,23 +3,31 @@
 package clusterinfo
 
 import (
+	"fmt"
 	"net"
 	"sort"
 	"strconv"
+	"strings"
 
 	"github.com/samber/lo"
+	"go.etcd.io/etcd/clientv3"
 	"gorm.io/gorm"
 
 	"github.com/pingcap/tidb-dashboard/pkg/apiserver/clusterinfo/hostinfo"
+	"github.com/pingcap/tidb-dashboard/pkg/pd"
 	"github.com/pingcap/tidb-dashboard/pkg/utils/topology"
 )
 
 type ClusterStatisticsPartial struct {
-	NumberOfHosts            int `json:"number_of_hosts"`
-	NumberOfInstances        int `json:"number_of_instances"`
-	TotalMemoryCapacityBytes int `json:"total_memory_capacity_bytes"`
-	TotalPhysicalCores       int `json:"total_physical_cores"`
-	TotalLogicalCores        int `json:"total_logical_cores"`
+	NumberOfHosts            int      `json:"number_of_hosts"`
+	NumberOfInstances        int      `json:"number_of_instances"`
+	Versions                 []string `json:"versions"`
+	TotalMemoryCapacityBytes int      `json:"total_memory_capacity_bytes"`
+	TotalMemoryUsedBytes     int      `json:"total_memory_used_bytes"`
+	TotalDiskCapacityBytes   int      `json:"total_disk_capacity_bytes"`
+	TotalDiskUsedBytes       int      `json:"total_disk_used_bytes"`
+	TotalPhysicalCores       int      `json:"total_physical_cores"`
+	TotalLogicalCores        int      `json:"total_logical_cores"`
 }
 
 type ClusterStatistics struct {
@@ -27,26 +35,39 @@ type ClusterStatistics struct {
 	Versions            []string                             `json:"versions"`
 	TotalStats          *ClusterStatisticsPartial            `json:"total_stats"`
 	StatsByInstanceKind map[string]*ClusterStatisticsPartial `json:"stats_by_instance_kind"`
+	// Warnings contains human readable issues found, e.g. instances of the same kind running different versions.
+	Warnings []string `json:"warnings"`
 }
 
 type instanceKindHostImmediateInfo struct {
 	memoryCapacity int
+	memoryUsed     int
+	diskCapacity   int
+	diskUsed       int
 	physicalCores  int
 	logicalCores   int
 }
 
 type instanceKindImmediateInfo struct {
 	instances map[string]struct{}
+	versions  map[string]struct{}
 	hosts     map[string]*instanceKindHostImmediateInfo
 }
 
 func newInstanceKindImmediateInfo() *instanceKindImmediateInfo {
 	return &instanceKindImmediateInfo{
 		instances: make(map[string]struct{}),
+		versions:  make(map[string]struct{}),
 		hosts:     make(map[string]*instanceKindHostImmediateInfo),
 	}
 }
 
+func (info *instanceKindImmediateInfo) addVersion(version string) {
+	if version != "" {
+		info.versions[version] = struct{}{}
+	}
+}
+
 func sumInt(array []int) int {
 	result := 0
 	for _, v := range array {
@@ -56,16 +77,22 @@ func sumInt(array []int) int {
 }
 
 func (info *instanceKindImmediateInfo) ToResult() *ClusterStatisticsPartial {
+	versions := lo.Keys(info.versions)
+	sort.Strings(versions)
 	return &ClusterStatisticsPartial{
 		NumberOfHosts:            len(lo.Keys(info.hosts)),
 		NumberOfInstances:        len(lo.Keys(info.instances)),
+		Versions:                 versions,
 		TotalMemoryCapacityBytes: sumInt(lo.Map(lo.Values(info.hosts), func(x *instanceKindHostImmediateInfo, _ int) int { return x.memoryCapacity })),
+		TotalMemoryUsedBytes:     sumInt(lo.Map(lo.Values(info.hosts), func(x *instanceKindHostImmediateInfo, _ int) int { return x.memoryUsed })),
+		TotalDiskCapacityBytes:   sumInt(lo.Map(lo.Values(info.hosts), func(x *instanceKindHostImmediateInfo, _ int) int { return x.diskCapacity })),
+		TotalDiskUsedBytes:       sumInt(lo.Map(lo.Values(info.hosts), func(x *instanceKindHostImmediateInfo, _ int) int { return x.diskUsed })),
 		TotalPhysicalCores:       sumInt(lo.Map(lo.Values(info.hosts), func(x *instanceKindHostImmediateInfo, _ int) int { return x.physicalCores })),
 		TotalLogicalCores:        sumInt(lo.Map(lo.Values(info.hosts), func(x *instanceKindHostImmediateInfo, _ int) int { return x.logicalCores })),
 	}
 }
 
-func (s *Service) calculateStatistics(db *gorm.DB) (*ClusterStatistics, error) {
+func (s *Service) calculateStatistics(db *gorm.DB, pdClient *pd.Client, etcdClient *clientv3.Client) (*ClusterStatistics, error) {
 	globalHostsSet := make(map[string]struct{})
 	globalFailureHostsSet := make(map[string]struct{})
 	globalVersionsSet := make(map[string]struct{})
@@ -79,7 +106,7 @@ func (s *Service) calculateStatistics(db *gorm.DB) (*ClusterStatistics, error) {
 	infoByIk["tiproxy"] = newInstanceKindImmediateInfo()
 
 	// Fill from topology info
-	pdInfo, err := topology.FetchPDTopology(s.params.PDClient)
+	pdInfo, err := topology.FetchPDTopology(pdClient)
 	if err != nil {
 		return nil, err
 	}
@@ -88,8 +115,9 @@ func (s *Service) calculateStatistics(db *gorm.DB) (*ClusterStatistics, error) {
 		globalVersionsSet[i.Version] = struct{}{}
 		globalInfo.instances[net.JoinHostPort(i.IP, strconv.Itoa(int(i.Port)))] = struct{}{}
 		infoByIk["pd"].instances[net.JoinHostPort(i.IP, strconv.Itoa(int(i.Port)))] = struct{}{}
+		infoByIk["pd"].addVersion(i.Version)
 	}
-	tikvInfo, tiFlashInfo, err := topology.FetchStoreTopology(s.params.PDClient)
+	tikvInfo, tiFlashInfo, err := topology.FetchStoreTopology(pdClient)
 	if err != nil {
 		return nil, err
 	}
@@ -98,14 +126,16 @@ func (s *Service) calculateStatistics(db *gorm.DB) (*ClusterStatistics, error) {
 		globalVersionsSet[i.Version] = struct{}{}
 		globalInfo.instances[net.JoinHostPort(i.IP, strconv.Itoa(int(i.Port)))] = struct{}{}
 		infoByIk["tikv"].instances[net.JoinHostPort(i.IP, strconv.Itoa(int(i.Port)))] = struct{}{}
+		infoByIk["tikv"].addVersion(i.Version)
 	}
 	for _, i := range tiFlashInfo {
 		globalHostsSet[i.IP] = struct{}{}
 		globalVersionsSet[i.Version] = struct{}{}
 		globalInfo.instances[net.JoinHostPort(i.IP, strconv.Itoa(int(i.Port)))] = struct{}{}
 		infoByIk["tiflash"].instances[net.JoinHostPort(i.IP, strconv.Itoa(int(i.Port)))] = struct{}{}
+		infoByIk["tiflash"].addVersion(i.Version)
 	}
-	tidbInfo, err := topology.FetchTiDBTopology(s.lifecycleCtx, s.params.EtcdClient)
+	tidbInfo, err := topology.FetchTiDBTopology(s.lifecycleCtx, etcdClient)
 	if err != nil {
 		return nil, err
 	}
@@ -114,8 +144,9 @@ func (s *Service) calculateStatistics(db *gorm.DB) (*ClusterStatistics, error) {
 		globalVersionsSet[i.Version] = struct{}{}
 		globalInfo.instances[net.JoinHostPort(i.IP, strconv.Itoa(int(i.Port)))] = struct{}{}
 		infoByIk["tidb"].instances[net.JoinHostPort(i.IP, strconv.Itoa(int(i.Port)))] = struct{}{}
+		infoByIk["tidb"].addVersion(i.Version)
 	}
-	ticdcInfo, err := topology.FetchTiCDCTopology(s.lifecycleCtx, s.params.EtcdClient)
+	ticdcInfo, err := topology.FetchTiCDCTopology(s.lifecycleCtx, etcdClient)
 	if err != nil {
 		return nil, err
 	}
@@ -124,8 +155,9 @@ func (s *Service) calculateStatistics(db *gorm.DB) (*ClusterStatistics, error) {
 		globalVersionsSet[i.Version] = struct{}{}
 		globalInfo.instances[net.JoinHostPort(i.IP, strconv.Itoa(int(i.Port)))] = struct{}{}
 		infoByIk["ticdc"].instances[net.JoinHostPort(i.IP, strconv.Itoa(int(i.Port)))] = struct{}{}
+		infoByIk["ticdc"].addVersion(i.Version)
 	}
-	tiproxyInfo, err := topology.FetchTiProxyTopology(s.lifecycleCtx, s.params.EtcdClient)
+	tiproxyInfo, err := topology.FetchTiProxyTopology(s.lifecycleCtx, etcdClient)
 	if err != nil {
 		return nil, err
 	}
@@ -134,24 +166,31 @@ func (s *Service) calculateStatistics(db *gorm.DB) (*ClusterStatistics, error) {
 		globalVersionsSet[i.Version] = struct{}{}
 		globalInfo.instances[net.JoinHostPort(i.IP, strconv.Itoa(int(i.Port)))] = struct{}{}
 		infoByIk["tiproxy"].instances[net.JoinHostPort(i.IP, strconv.Itoa(int(i.Port)))] = struct{}{}
+		infoByIk["tiproxy"].addVersion(i.Version)
 	}
 
 	// Fill from hardware info
 	allHostsInfoMap := make(map[string]*hostinfo.Info)
-	if e := hostinfo.FillFromClusterLoadTable(db, allHostsInfoMap); e != nil {
+	if err := hostinfo.FillFromClusterLoadTable(db, allHostsInfoMap); err != nil {
 		return nil, err
 	}
-	if e := hostinfo.FillFromClusterHardwareTable(db, allHostsInfoMap); e != nil {
+	if err := hostinfo.FillFromClusterHardwareTable(db, allHostsInfoMap); err != nil {
 		return nil, err
 	}
 	for host, hi := range allHostsInfoMap {
 		if hi.MemoryUsage.Total > 0 && hi.CPUInfo.PhysicalCores > 0 && hi.CPUInfo.LogicalCores > 0 {
 			// Put success host info into `globalInfo.hosts`.
-			globalInfo.hosts[host] = &instanceKindHostImmediateInfo{
+			hostInfo := &instanceKindHostImmediateInfo{
 				memoryCapacity: hi.MemoryUsage.Total,
+				memoryUsed:     hi.MemoryUsage.Used,
 				physicalCores:  hi.CPUInfo.PhysicalCores,
 				logicalCores:   hi.CPUInfo.LogicalCores,
 			}
+			for _, p := range hi.Partitions {
+				hostInfo.diskCapacity += p.Total
+				hostInfo.diskUsed += p.Total - p.Free
+			}
+			globalInfo.hosts[host] = hostInfo
 		}
 	}
 
@@ -213,5 +252,19 @@ func (s *Service) calculateStatistics(db *gorm.DB) (*ClusterStatistics, error) {
 		Versions:            versions,
 		TotalStats:          globalInfo.ToResult(),
 		StatsByInstanceKind: statsByIk,
+		Warnings:            versionMismatchWarnings(statsByIk),
 	}, nil
 }
+
+// versionMismatchWarnings warns instance kinds running different versions. Versions are not compared across
+// instance kinds, as they are released with different version schemes.
+func versionMismatchWarnings(statsByIk map[string]*ClusterStatisticsPartial) []string {
+	warnings := make([]string, 0)
+	for _, ik := range lo.Keys(statsByIk) {
+		if versions := statsByIk[ik].Versions; len(versions) > 1 {
+			warnings = append(warnings, fmt.Sprintf("%s instances are running different versions: %s", ik, strings.Join(versions, ", ")))
+		}
+	}
+	sort.Strings(warnings)
+	return warnings
+}
@@ -0,0 +1,41 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package clusterinfo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInstanceKindImmediateInfoToResult(t *testing.T) {
+	info := newInstanceKindImmediateInfo()
+	info.instances["10.0.1.1:20160"] = struct{}{}
+	info.instances["10.0.1.2:20160"] = struct{}{}
+	info.addVersion("v7.5.0")
+	info.addVersion("v7.1.0")
+	info.addVersion("")
+	info.hosts["10.0.1.1"] = &instanceKindHostImmediateInfo{memoryCapacity: 100, memoryUsed: 40, diskCapacity: 1000, diskUsed: 300}
+	info.hosts["10.0.1.2"] = &instanceKindHostImmediateInfo{memoryCapacity: 200, memoryUsed: 10, diskCapacity: 500, diskUsed: 500}
+
+	r := info.ToResult()
+	require.Equal(t, 2, r.NumberOfInstances)
+	require.Equal(t, []string{"v7.1.0", "v7.5.0"}, r.Versions)
+	require.Equal(t, 300, r.TotalMemoryCapacityBytes)
+	require.Equal(t, 50, r.TotalMemoryUsedBytes)
+	require.Equal(t, 1500, r.TotalDiskCapacityBytes)
+	require.Equal(t, 800, r.TotalDiskUsedBytes)
+}
+
+func TestVersionMismatchWarnings(t *testing.T) {
+	warnings := versionMismatchWarnings(map[string]*ClusterStatisticsPartial{
+		"tikv":    {Versions: []string{"v7.1.0", "v7.5.0"}},
+		"tidb":    {Versions: []string{"v7.5.0"}},
+		"tiflash": {Versions: nil},
+		"pd":      {Versions: []string{"v7.1.0", "v7.5.0"}},
+	})
+	require.Equal(t, []string{
+		"pd instances are running different versions: v7.1.0, v7.5.0",
+		"tikv instances are running different versions: v7.1.0, v7.5.0",
+	}, warnings)
+}
@@ -0,0 +1,240 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package clusterinfo
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/pingcap/tidb-dashboard/pkg/utils/topology"
+	"github.com/pingcap/tidb-dashboard/util/topo"
+)
+
+// tiupMeta is the cluster metadata file (`meta.yaml`) managed by tiup cluster.
+// Only fields concerned by the topology are included.
+type tiupMeta struct {
+	Version  string `yaml:"tidb_version"`
+	Topology struct {
+		Global struct {
+			DeployDir string `yaml:"deploy_dir"`
+		} `yaml:"global"`
+		PDServers []struct {
+			Host       string `yaml:"host"`
+			ClientPort uint   `yaml:"client_port"`
+			DeployDir  string `yaml:"deploy_dir"`
+		} `yaml:"pd_servers"`
+		TiDBServers []struct {
+			Host       string `yaml:"host"`
+			Port       uint   `yaml:"port"`
+			StatusPort uint   `yaml:"status_port"`
+			DeployDir  string `yaml:"deploy_dir"`
+		} `yaml:"tidb_servers"`
+		TiKVServers []struct {
+			Host       string `yaml:"host"`
+			Port       uint   `yaml:"port"`
+			StatusPort uint   `yaml:"status_port"`
+			DeployDir  string `yaml:"deploy_dir"`
+		} `yaml:"tikv_servers"`
+		TiFlashServers []struct {
+			Host                 string `yaml:"host"`
+			FlashServicePort     uint   `yaml:"flash_service_port"`
+			FlashProxyStatusPort uint   `yaml:"flash_proxy_status_port"`
+			DeployDir            string `yaml:"deploy_dir"`
+		} `yaml:"tiflash_servers"`
+		CDCServers []struct {
+			Host      string `yaml:"host"`
+			Port      uint   `yaml:"port"`
+			DeployDir string `yaml:"deploy_dir"`
+		} `yaml:"cdc_servers"`
+		TiProxyServers []struct {
+			Host       string `yaml:"host"`
+			Port       uint   `yaml:"port"`
+			StatusPort uint   `yaml:"status_port"`
+			DeployDir  string `yaml:"deploy_dir"`
+		} `yaml:"tiproxy_servers"`
+		PumpServers []struct {
+			Host string `yaml:"host"`
+			Port uint   `yaml:"port"`
+		} `yaml:"pump_servers"`
+		DrainerServers []struct {
+			Host string `yaml:"host"`
+			Port uint   `yaml:"port"`
+		} `yaml:"drainer_servers"`
+		MonitoringServers []struct {
+			Host string `yaml:"host"`
+			Port uint   `yaml:"port"`
+		} `yaml:"monitoring_servers"`
+		GrafanaServers []struct {
+			Host string `yaml:"host"`
+			Port uint   `yaml:"port"`
+		} `yaml:"grafana_servers"`
+		AlertManagerServers []struct {
+			Host    string `yaml:"host"`
+			WebPort uint   `yaml:"web_port"`
+		} `yaml:"alertmanager_servers"`
+	} `yaml:"topology"`
+}
+
+// orDefault returns the default port when the port is not specified in the metadata,
+// in which case tiup deploys the component with its default port.
+func orDefault(port, defaultPort uint) uint {
+	if port == 0 {
+		return defaultPort
+	}
+	return port
+}
+
+// parseTiUPMeta converts the tiup cluster metadata into the cluster topology. As the metadata
+// does not contain runtime states, all nodes are considered up.
+func parseTiUPMeta(data []byte) (*ClusterInfo, error) {
+	var meta tiupMeta
+	if err := yaml.Unmarshal(data, &meta); err != nil {
+		return nil, ErrInvalidTopologySource.Wrap(err, "failed to parse tiup cluster metadata")
+	}
+	t := meta.Topology
+	version := meta.Version
+	if version != "" && !strings.HasPrefix(version, "v") {
+		version = "v" + version
+	}
+	deployDir := func(dir string) string {
+		if dir == "" {
+			return t.Global.DeployDir
+		}
+		return dir
+	}
+
+	info := &ClusterInfo{
+		TiDB:    make([]topology.TiDBInfo, 0, len(t.TiDBServers)),
+		TiKV:    make([]topology.StoreInfo, 0, len(t.TiKVServers)),
+		TiFlash: make([]topology.StoreInfo, 0, len(t.TiFlashServers)),
+		PD:      make([]topology.PDInfo, 0, len(t.PDServers)),
+		TiCDC:   make([]topology.TiCDCInfo, 0, len(t.CDCServers)),
+		TiProxy: make([]topology.TiProxyInfo, 0, len(t.TiProxyServers)),
+		Pump:    make([]topology.BinlogInfo, 0, len(t.PumpServers)),
+		Drainer: make([]topology.BinlogInfo, 0, len(t.DrainerServers)),
+	}
+	for _, s := range t.PDServers {
+		info.PD = append(info.PD, topology.PDInfo{
+			Version:    version,
+			IP:         s.Host,
+			Port:       orDefault(s.ClientPort, 2379),
+			DeployPath: deployDir(s.DeployDir),
+			Status:     topology.ComponentStatusUp,
+		})
+	}
+	for _, s := range t.TiDBServers {
+		info.TiDB = append(info.TiDB, topology.TiDBInfo{
+			Version:    version,
+			IP:         s.Host,
+			Port:       orDefault(s.Port, 4000),
+			StatusPort: orDefault(s.StatusPort, 10080),
+			DeployPath: deployDir(s.DeployDir),
+			Status:     topology.ComponentStatusUp,
+		})
+	}
+	for _, s := range t.TiKVServers {
+		info.TiKV = append(info.TiKV, topology.StoreInfo{
+			Version:    version,
+			IP:         s.Host,
+			Port:       orDefault(s.Port, 20160),
+			StatusPort: orDefault(s.StatusPort, 20180),
+			DeployPath: deployDir(s.DeployDir),
+			Status:     topology.ComponentStatusUp,
+			Labels:     map[string]string{},
+		})
+	}
+	for _, s := range t.TiFlashServers {
+		info.TiFlash = append(info.TiFlash, topology.StoreInfo{
+			Version:    version,
+			IP:         s.Host,
+			Port:       orDefault(s.FlashServicePort, 3930),
+			StatusPort: orDefault(s.FlashProxyStatusPort, 20292),
+			DeployPath: deployDir(s.DeployDir),
+			Status:     topology.ComponentStatusUp,
+			Labels:     map[string]string{"engine": "tiflash"},
+		})
+	}
+	for _, s := range t.CDCServers {
+		port := orDefault(s.Port, 8300)
+		info.TiCDC = append(info.TiCDC, topology.TiCDCInfo{
+			Version:    version,
+			IP:         s.Host,
+			Port:       port,
+			StatusPort: port,
+			DeployPath: deployDir(s.DeployDir),
+			Status:     topology.ComponentStatusUp,
+		})
+	}
+	for _, s := range t.TiProxyServers {
+		info.TiProxy = append(info.TiProxy, topology.TiProxyInfo{
+			IP:         s.Host,
+			Port:       orDefault(s.Port, 6000),
+			StatusPort: orDefault(s.StatusPort, 3080),
+			DeployPath: deployDir(s.DeployDir),
+			Status:     topology.ComponentStatusUp,
+		})
+	}
+	for _, s := range t.PumpServers {
+		info.Pump = append(info.Pump, topology.BinlogInfo{
+			IP:     s.Host,
+			Port:   orDefault(s.Port, 8250),
+			Status: topology.ComponentStatusUp,
+		})
+	}
+	for _, s := range t.DrainerServers {
+		info.Drainer = append(info.Drainer, topology.BinlogInfo{
+			IP:     s.Host,
+			Port:   orDefault(s.Port, 8249),
+			Status: topology.ComponentStatusUp,
+		})
+	}
+	// Only the first instance of singleton components is used, the same as registered in etcd.
+	if len(t.MonitoringServers) > 0 {
+		s := t.MonitoringServers[0]
+		info.Prometheus = &topology.PrometheusInfo{
+			StandardComponentInfo: topology.StandardComponentInfo{IP: s.Host, Port: orDefault(s.Port, 9090)},
+		}
+	}
+	if len(t.GrafanaServers) > 0 {
+		s := t.GrafanaServers[0]
+		info.Grafana = &topology.GrafanaInfo{
+			StandardComponentInfo: topology.StandardComponentInfo{IP: s.Host, Port: orDefault(s.Port, 3000)},
+		}
+	}
+	if len(t.AlertManagerServers) > 0 {
+		s := t.AlertManagerServers[0]
+		info.AlertManager = &topology.AlertManagerInfo{
+			StandardComponentInfo: topology.StandardComponentInfo{IP: s.Host, Port: orDefault(s.WebPort, 9093)},
+		}
+	}
+	return info, nil
+}
+
+// tiupMetaSource loads the topology from a tiup cluster metadata file, without contacting
+// etcd or PD. It is useful for reproducing issues offline.
+type tiupMetaSource struct {
+	path string
+}
+
+func newTiUPMetaSource(path string) *tiupMetaSource {
+	return &tiupMetaSource{path: path}
+}
+
+func (s *tiupMetaSource) Kinds() []topo.Kind {
+	return clusterComponents
+}
+
+func (s *tiupMetaSource) Fetch(ctx context.Context) ([]Node, error) {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return nil, ErrInvalidTopologySource.Wrap(err, "failed to read tiup cluster metadata %s", s.path)
+	}
+	info, err := parseTiUPMeta(data)
+	if err != nil {
+		return nil, err
+	}
+	return info.nodes(), nil
+}
@@ -0,0 +1,117 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package clusterinfo
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pingcap/tidb-dashboard/pkg/config"
+	"github.com/pingcap/tidb-dashboard/pkg/utils/topology"
+)
+
+const testTiUPMeta = `user: tidb
+tidb_version: v7.5.0
+topology:
+  global:
+    user: tidb
+    ssh_port: 22
+    deploy_dir: /tidb-deploy
+    data_dir: /tidb-data
+  pd_servers:
+  - host: 10.0.0.1
+    ssh_port: 22
+    name: pd-10.0.0.1-2379
+    client_port: 2379
+    peer_port: 2380
+    deploy_dir: /tidb-deploy/pd-2379
+  tidb_servers:
+  - host: 10.0.1.1
+    port: 4000
+    status_port: 10080
+    deploy_dir: /tidb-deploy/tidb-4000
+  - host: 10.0.1.2
+  tikv_servers:
+  - host: 10.0.2.1
+    port: 20160
+    status_port: 20180
+  tiflash_servers:
+  - host: 10.0.2.9
+    tcp_port: 9000
+    flash_service_port: 3930
+    flash_proxy_port: 20170
+    flash_proxy_status_port: 20292
+  cdc_servers:
+  - host: 10.0.4.1
+    port: 8300
+  pump_servers:
+  - host: 10.0.4.2
+  monitoring_servers:
+  - host: 10.0.3.1
+    port: 9090
+  grafana_servers:
+  - host: 10.0.3.1
+    port: 3000
+  alertmanager_servers:
+  - host: 10.0.3.1
+    web_port: 9093
+    cluster_port: 9094
+`
+
+func TestParseTiUPMeta(t *testing.T) {
+	info, err := parseTiUPMeta([]byte(testTiUPMeta))
+	require.NoError(t, err)
+
+	require.Len(t, info.PD, 1)
+	require.Equal(t, topology.PDInfo{
+		Version:    "v7.5.0",
+		IP:         "10.0.0.1",
+		Port:       2379,
+		DeployPath: "/tidb-deploy/pd-2379",
+		Status:     topology.ComponentStatusUp,
+	}, info.PD[0])
+
+	require.Len(t, info.TiDB, 2)
+	require.Equal(t, uint(10080), info.TiDB[0].StatusPort)
+	require.Equal(t, "/tidb-deploy/tidb-4000", info.TiDB[0].DeployPath)
+	// Ports and deploy dir fall back to defaults.
+	require.Equal(t, uint(4000), info.TiDB[1].Port)
+	require.Equal(t, uint(10080), info.TiDB[1].StatusPort)
+	require.Equal(t, "/tidb-deploy", info.TiDB[1].DeployPath)
+
+	require.Len(t, info.TiKV, 1)
+	require.Equal(t, uint(20180), info.TiKV[0].StatusPort)
+	require.Len(t, info.TiFlash, 1)
+	require.Equal(t, uint(3930), info.TiFlash[0].Port)
+	require.Equal(t, uint(20292), info.TiFlash[0].StatusPort)
+	require.Len(t, info.TiCDC, 1)
+	require.Empty(t, info.TiProxy)
+	require.Len(t, info.Pump, 1)
+	require.Equal(t, uint(8250), info.Pump[0].Port)
+	require.Empty(t, info.Drainer)
+
+	require.Equal(t, uint(9090), info.Prometheus.Port)
+	require.Equal(t, uint(3000), info.Grafana.Port)
+	require.Equal(t, uint(9093), info.AlertManager.Port)
+}
+
+func TestTiUPMetaSource(t *testing.T) {
+	path := writeTestFile(t, "meta.yaml", testTiUPMeta)
+	p := ServiceParams{Config: &config.Config{TopologyTiUPMetaFile: path}}
+	s := &Service{params: p}
+	ct := &clusterTopology{sources: newTopologySources(p)}
+
+	info := s.fetchClusterInfo(context.Background(), ct)
+	require.Empty(t, info.Errors)
+	require.Len(t, info.TiDB, 2)
+	require.Len(t, info.TiKV, 1)
+	require.Len(t, info.TiFlash, 1)
+	require.NotNil(t, info.Grafana)
+}
+
+func TestParseTiUPMetaInvalid(t *testing.T) {
+	_, err := parseTiUPMeta([]byte("topology: [invalid"))
+	require.Error(t, err)
+}
@@ -0,0 +1,122 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package clusterinfo
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/utils"
+	"github.com/pingcap/tidb-dashboard/util/rest"
+)
+
+const (
+	topologyWSTokenIssuer = "topology/ws"
+	topologyWSTokenExpire = time.Minute
+	// topologyWSTokenMasked is the token data when addresses are masked for the user.
+	topologyWSTokenMasked = "masked"
+
+	topologyWSPingInterval = 30 * time.Second
+	topologyWSWriteTimeout = 10 * time.Second
+)
+
+var topologyWSUpgrader = websocket.Upgrader{
+	// Cross-origin requests are allowed, as the token can only be acquired by authenticated users.
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// @ID getTopologyWSToken
+// @Summary Generate a token for subscribing topology changes
+// @Description The token expires in 1 minute, and is only used to establish the WebSocket.
+// @Description Topology changes are only available for the default cluster.
+// @Produce plain
+// @Success 200 {string} string "xxx"
+// @Failure 400 {object} rest.ErrorResponse
+// @Failure 401 {object} rest.ErrorResponse
+// @Security JwtAuth
+// @Router /topology/ws/acquire_token [get]
+func (s *Service) getTopologyWSToken(c *gin.Context) {
+	if !requireDefaultCluster(c) {
+		return
+	}
+	data := ""
+	if s.shouldMask(c) {
+		data = topologyWSTokenMasked
+	}
+	token, err := utils.NewJWTStringWithExpire(topologyWSTokenIssuer, data, topologyWSTokenExpire)
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	c.String(http.StatusOK, token)
+}
+
+// @ID subscribeTopology
+// @Summary Subscribe topology changes via WebSocket
+// @Description Each message is a TopologyEvent, including TiDB and other components registered in etcd, as well as
+// @Description PD members. The connection is closed when the subscriber falls behind, after which the full topology
+// @Description should be fetched again.
+// @Param token query string true "token acquired from /topology/ws/acquire_token"
+// @Success 101 {object} TopologyEvent
+// @Failure 401 {object} rest.ErrorResponse
+// @Router /topology/ws [get]
+func (s *Service) serveTopologyWS(c *gin.Context) {
+	data, err := utils.ParseJWTString(topologyWSTokenIssuer, c.Query("token"))
+	if err != nil {
+		rest.Error(c, rest.ErrUnauthenticated.NewWithNoMessage())
+		return
+	}
+	conn, err := topologyWSUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// The error response is already written by the upgrader.
+		return
+	}
+	defer conn.Close()
+
+	events, unsubscribe := s.events.Subscribe()
+	defer unsubscribe()
+
+	// Messages from the client are discarded, but reading is required to handle the close message.
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	ping := time.NewTicker(topologyWSPingInterval)
+	defer ping.Stop()
+	for {
+		select {
+		case <-closed:
+			return
+		case <-s.lifecycleCtx.Done():
+			_ = conn.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseGoingAway, ""), time.Now().Add(topologyWSWriteTimeout))
+			return
+		case <-ping.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(topologyWSWriteTimeout)); err != nil {
+				return
+			}
+		case e, ok := <-events:
+			if !ok {
+				_ = conn.WriteControl(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "subscriber falls behind"), time.Now().Add(topologyWSWriteTimeout))
+				return
+			}
+			if data == topologyWSTokenMasked && s.masker != nil {
+				e.Address = s.masker.maskAddress(e.Address)
+			}
+			_ = conn.SetWriteDeadline(time.Now().Add(topologyWSWriteTimeout))
+			if err := conn.WriteJSON(e); err != nil {
+				return
+			}
+		}
+	}
+}
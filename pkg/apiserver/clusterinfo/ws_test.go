@@ -0,0 +1,76 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package clusterinfo
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/utils"
+	"github.com/pingcap/tidb-dashboard/util/rest"
+	"github.com/pingcap/tidb-dashboard/util/topo"
+)
+
+func TestServeTopologyWS(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := &Service{
+		lifecycleCtx: context.Background(),
+		events:       newTopologyEventRing(8),
+		masker:       newAddressMasker(),
+	}
+
+	engine := gin.New()
+	engine.Use(rest.ErrorHandlerFn())
+	engine.GET("/topology/ws", s.serveTopologyWS)
+	engine.GET("/topology/ws/acquire_token", func(c *gin.Context) {
+		c.Set(utils.SessionUserKey, &utils.SessionUser{IsWriteable: c.Query("role") == "admin"})
+		s.getTopologyWSToken(c)
+	})
+	ts := httptest.NewServer(engine)
+	defer ts.Close()
+
+	acquireToken := func(role string) string {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/topology/ws/acquire_token?role="+role, nil))
+		require.Equal(t, http.StatusOK, w.Code)
+		return w.Body.String()
+	}
+	dial := func(token string) (*websocket.Conn, *http.Response, error) {
+		url := "ws" + strings.TrimPrefix(ts.URL, "http") + "/topology/ws?token=" + token
+		return websocket.DefaultDialer.Dial(url, nil)
+	}
+
+	_, resp, err := dial("invalid")
+	require.Error(t, err)
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	admin, _, err := dial(acquireToken("admin"))
+	require.NoError(t, err)
+	defer admin.Close()
+	viewer, _, err := dial(acquireToken("viewer"))
+	require.NoError(t, err)
+	defer viewer.Close()
+
+	// Wait for both connections to subscribe.
+	require.Eventually(t, func() bool {
+		s.events.mu.RLock()
+		defer s.events.mu.RUnlock()
+		return len(s.events.subscribers) == 2
+	}, time.Second, 10*time.Millisecond)
+	s.events.Add(TopologyEvent{Type: TopologyEventJoin, Component: topo.KindTiDB, Address: "10.0.1.1:4000"})
+
+	var e TopologyEvent
+	require.NoError(t, admin.ReadJSON(&e))
+	require.Equal(t, "10.0.1.1:4000", e.Address)
+	require.Equal(t, TopologyEventJoin, e.Type)
+	require.NoError(t, viewer.ReadJSON(&e))
+	require.Equal(t, s.masker.maskAddress("10.0.1.1:4000"), e.Address)
+}
@@ -0,0 +1,226 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package configuration
+
+import (
+	"bytes"
+	"encoding/json"
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+
+	"go.etcd.io/etcd/clientv3"
+
+	"github.com/pingcap/tidb-dashboard/pkg/pd"
+	"github.com/pingcap/tidb-dashboard/pkg/utils/topology"
+	"github.com/pingcap/tidb-dashboard/util/distro"
+	"github.com/pingcap/tidb-dashboard/util/rest"
+	"github.com/pingcap/tidb-dashboard/util/topo"
+)
+
+var (
+	ErrGetLogLevelFailed = ErrNS.NewType("get_log_level_failed")
+	ErrSetLogLevelFailed = ErrNS.NewType("set_log_level_failed")
+)
+
+// logLevels are the log levels supported by all components.
+var logLevels = []string{"debug", "info", "warn", "error"}
+
+func isValidLogLevel(level string) bool {
+	for _, l := range logLevels {
+		if l == level {
+			return true
+		}
+	}
+	return false
+}
+
+type LogLevelInstance struct {
+	Component topo.Kind `json:"component"`
+	// Instance is the address of the instance, e.g. `10.0.1.1:4000` for TiDB.
+	Instance string `json:"instance"`
+}
+
+type InstanceLogLevel struct {
+	LogLevelInstance
+	// Level is the current log level when read, or the new log level when set successfully.
+	Level string              `json:"level,omitempty"`
+	Error *rest.ErrorResponse `json:"error,omitempty"`
+}
+
+// logLevelTarget is an instance whose log level can be adjusted through its HTTP API.
+type logLevelTarget struct {
+	LogLevelInstance
+	host       string
+	statusPort int
+}
+
+func (s *Service) listLogLevelTargets(pdClient *pd.Client, etcdClient *clientv3.Client) ([]logLevelTarget, error) {
+	pdInfo, err := topology.FetchPDTopology(pdClient)
+	if err != nil {
+		return nil, ErrListTopologyFailed.Wrap(err, "Failed to list %s members", distro.R().PD)
+	}
+	tikvInfo, _, err := topology.FetchStoreTopology(pdClient)
+	if err != nil {
+		return nil, ErrListTopologyFailed.Wrap(err, "Failed to list TiKV stores")
+	}
+	tidbInfo, err := topology.FetchTiDBTopology(s.lifecycleCtx, etcdClient)
+	if err != nil {
+		return nil, ErrListTopologyFailed.Wrap(err, "Failed to list %s instances", distro.R().TiDB)
+	}
+
+	targets := make([]logLevelTarget, 0, len(pdInfo)+len(tikvInfo)+len(tidbInfo))
+	add := func(kind topo.Kind, ip string, port, statusPort uint) {
+		targets = append(targets, logLevelTarget{
+			LogLevelInstance: LogLevelInstance{Component: kind, Instance: net.JoinHostPort(ip, strconv.Itoa(int(port)))},
+			host:             ip,
+			statusPort:       int(statusPort),
+		})
+	}
+	for _, i := range pdInfo {
+		add(topo.KindPD, i.IP, i.Port, i.Port)
+	}
+	for _, i := range tikvInfo {
+		if i.Status == topology.ComponentStatusTombstone {
+			continue
+		}
+		add(topo.KindTiKV, i.IP, i.Port, i.StatusPort)
+	}
+	for _, i := range tidbInfo {
+		add(topo.KindTiDB, i.IP, i.Port, i.StatusPort)
+	}
+	sort.Slice(targets, func(i, j int) bool {
+		if targets[i].Component != targets[j].Component {
+			return targets[i].Component < targets[j].Component
+		}
+		return targets[i].Instance < targets[j].Instance
+	})
+	return targets, nil
+}
+
+// selectLogLevelTargets returns the targets of the selected instances. Selected instances not found are
+// returned as failures.
+func selectLogLevelTargets(targets []logLevelTarget, selected []LogLevelInstance) ([]logLevelTarget, []InstanceLogLevel) {
+	byInstance := make(map[LogLevelInstance]logLevelTarget, len(targets))
+	for _, t := range targets {
+		byInstance[t.LogLevelInstance] = t
+	}
+	result := make([]logLevelTarget, 0, len(selected))
+	notFound := make([]InstanceLogLevel, 0)
+	seen := make(map[LogLevelInstance]struct{}, len(selected))
+	for _, i := range selected {
+		if _, ok := seen[i]; ok {
+			continue
+		}
+		seen[i] = struct{}{}
+		t, ok := byInstance[i]
+		if !ok {
+			errResp := rest.NewErrorResponse(ErrSetLogLevelFailed.New("%s instance `%s` not found", i.Component, i.Instance))
+			notFound = append(notFound, InstanceLogLevel{LogLevelInstance: i, Error: &errResp})
+			continue
+		}
+		result = append(result, t)
+	}
+	return result, notFound
+}
+
+// logLevelFromConfig returns the log level in the config returned by the config API of components.
+func logLevelFromConfig(data []byte) (string, error) {
+	cfg, err := processNestedConfigAPIResponse(data)
+	if err != nil {
+		return "", err
+	}
+	// Earlier TiKV versions use `log-level` instead of `log.level`.
+	for _, key := range []string{"log.level", "log-level"} {
+		if level, ok := cfg[key].(string); ok && level != "" {
+			return level, nil
+		}
+	}
+	return "", ErrGetLogLevelFailed.New("log level is not found in the config")
+}
+
+func (s *Service) getInstanceLogLevel(pdClient *pd.Client, t logLevelTarget) (string, error) {
+	var data []byte
+	var err error
+	switch t.Component {
+	case topo.KindPD:
+		data, err = pdClient.WithAddress(t.host, t.statusPort).SendGetRequest("/config")
+	case topo.KindTiKV:
+		data, err = s.params.TiKVClient.SendGetRequest(t.host, t.statusPort, "/config")
+	case topo.KindTiDB:
+		data, err = s.params.TiDBClient.WithStatusAPIAddress(t.host, t.statusPort).SendGetRequest("/settings")
+	}
+	if err != nil {
+		return "", ErrGetLogLevelFailed.WrapWithNoMessage(err)
+	}
+	return logLevelFromConfig(data)
+}
+
+func (s *Service) setInstanceLogLevel(pdClient *pd.Client, t logLevelTarget, level string) error {
+	var err error
+	switch t.Component {
+	case topo.KindPD:
+		// The log level is only changed for the PD member receiving the request.
+		body, _ := json.Marshal(level)
+		_, err = pdClient.WithAddress(t.host, t.statusPort).SendPostRequest("/admin/log", bytes.NewBuffer(body))
+	case topo.KindTiKV:
+		body, _ := json.Marshal(map[string]string{"log.level": level})
+		_, err = s.params.TiKVClient.SendPostRequest(t.host, t.statusPort, "/config", bytes.NewBuffer(body))
+	case topo.KindTiDB:
+		_, err = s.params.TiDBClient.WithStatusAPIAddress(t.host, t.statusPort).
+			SendPostRequest("/settings?log_level="+url.QueryEscape(level), nil)
+	}
+	if err != nil {
+		return ErrSetLogLevelFailed.WrapWithNoMessage(err)
+	}
+	return nil
+}
+
+// forEachLogLevelTarget calls fn for each target concurrently and collects the results in the order of targets.
+func forEachLogLevelTarget(targets []logLevelTarget, fn func(t logLevelTarget) (string, error)) []InstanceLogLevel {
+	results := make([]InstanceLogLevel, len(targets))
+	var wg sync.WaitGroup
+	for i := range targets {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i].LogLevelInstance = targets[i].LogLevelInstance
+			level, err := fn(targets[i])
+			if err != nil {
+				errResp := rest.NewErrorResponse(err)
+				results[i].Error = &errResp
+				return
+			}
+			results[i].Level = level
+		}(i)
+	}
+	wg.Wait()
+	return results
+}
+
+func (s *Service) getLogLevels(pdClient *pd.Client, etcdClient *clientv3.Client) ([]InstanceLogLevel, error) {
+	targets, err := s.listLogLevelTargets(pdClient, etcdClient)
+	if err != nil {
+		return nil, err
+	}
+	return forEachLogLevelTarget(targets, func(t logLevelTarget) (string, error) {
+		return s.getInstanceLogLevel(pdClient, t)
+	}), nil
+}
+
+func (s *Service) setLogLevels(pdClient *pd.Client, etcdClient *clientv3.Client, level string, selected []LogLevelInstance) ([]InstanceLogLevel, error) {
+	targets, err := s.listLogLevelTargets(pdClient, etcdClient)
+	if err != nil {
+		return nil, err
+	}
+	targets, notFound := selectLogLevelTargets(targets, selected)
+	results := forEachLogLevelTarget(targets, func(t logLevelTarget) (string, error) {
+		if err := s.setInstanceLogLevel(pdClient, t, level); err != nil {
+			return "", err
+		}
+		return level, nil
+	})
+	return append(results, notFound...), nil
+}
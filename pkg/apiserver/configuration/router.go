@@ -7,18 +7,22 @@ import (
 
 	"github.com/gin-gonic/gin"
 
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/audit"
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/cluster"
 	"github.com/pingcap/tidb-dashboard/pkg/apiserver/user"
 	"github.com/pingcap/tidb-dashboard/pkg/apiserver/utils"
 	"github.com/pingcap/tidb-dashboard/util/rest"
 )
 
-func RegisterRouter(r *gin.RouterGroup, auth *user.AuthService, s *Service) {
+func RegisterRouter(r *gin.RouterGroup, auth *user.AuthService, a *audit.Service, s *Service) {
 	endpoint := r.Group("/configuration")
-	endpoint.Use(auth.MWAuthRequired())
-	endpoint.Use(utils.MWConnectTiDB(s.params.TiDBClient))
+	endpoint.Use(auth.MWAuthRequired(), s.params.Registry.MWResolveCluster())
 	endpoint.Use(utils.MWForbidByExperimentalFlag(s.params.Config.EnableExperimental))
-	endpoint.GET("/all", s.getHandler)
-	endpoint.POST("/edit", auth.MWRequireWritePriv(), s.editHandler)
+	// Config items include TiDB variables, which are only available for the default cluster.
+	endpoint.GET("/all", utils.MWConnectTiDB(s.params.TiDBClient), utils.MWConditionalGet(), s.getHandler)
+	endpoint.POST("/edit", utils.MWConnectTiDB(s.params.TiDBClient), auth.MWRequireWritePriv(), a.MWRecord("configuration.edit"), s.editHandler)
+	endpoint.GET("/log_levels", s.getLogLevelsHandler)
+	endpoint.POST("/log_levels", auth.MWRequireWritePriv(), a.MWRecord("configuration.set_log_level"), s.setLogLevelsHandler)
 }
 
 // @ID configurationGetAll
@@ -31,7 +35,7 @@ func RegisterRouter(r *gin.RouterGroup, auth *user.AuthService, s *Service) {
 // @Failure 500 {object} rest.ErrorResponse
 func (s *Service) getHandler(c *gin.Context) {
 	db := utils.GetTiDBConnection(c)
-	r, err := s.getAllConfigItems(db)
+	r, err := s.getAllConfigItems(db, cluster.PDClientOf(c, s.params.PDClient), cluster.EtcdClientOf(c, s.params.EtcdClients))
 	if err != nil {
 		rest.Error(c, err)
 		return
@@ -67,7 +71,7 @@ func (s *Service) editHandler(c *gin.Context) {
 	}
 
 	db := utils.GetTiDBConnection(c)
-	warnings, err := s.editConfig(db, req.Kind, req.ID, req.NewValue)
+	warnings, err := s.editConfig(db, cluster.PDClientOf(c, s.params.PDClient), req.Kind, req.ID, req.NewValue)
 	if err != nil {
 		rest.Error(c, err)
 		return
@@ -78,3 +82,60 @@ func (s *Service) editHandler(c *gin.Context) {
 
 	c.JSON(http.StatusOK, resp)
 }
+
+// @ID configurationGetLogLevels
+// @Summary Get the runtime log level of each PD, TiKV and TiDB instance
+// @Success 200 {array} InstanceLogLevel
+// @Router /configuration/log_levels [get]
+// @Security JwtAuth
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 403 {object} rest.ErrorResponse
+// @Failure 500 {object} rest.ErrorResponse
+func (s *Service) getLogLevelsHandler(c *gin.Context) {
+	r, err := s.getLogLevels(cluster.PDClientOf(c, s.params.PDClient), cluster.EtcdClientOf(c, s.params.EtcdClients))
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, r)
+}
+
+type SetLogLevelsRequest struct {
+	// Level is one of debug, info, warn and error.
+	Level     string             `json:"level"`
+	Instances []LogLevelInstance `json:"instances"`
+}
+
+// @ID configurationSetLogLevels
+// @Summary Set the runtime log level of selected PD, TiKV and TiDB instances
+// @Description The log level is not persisted, so that it is reset when instances restart. The result of each instance is returned.
+// @Param request body SetLogLevelsRequest true "Request body"
+// @Success 200 {array} InstanceLogLevel
+// @Router /configuration/log_levels [post]
+// @Security JwtAuth
+// @Failure 400 {object} rest.ErrorResponse
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 403 {object} rest.ErrorResponse
+// @Failure 500 {object} rest.ErrorResponse
+func (s *Service) setLogLevelsHandler(c *gin.Context) {
+	var req SetLogLevelsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		rest.Error(c, rest.ErrBadRequest.NewWithNoMessage())
+		return
+	}
+	if !isValidLogLevel(req.Level) {
+		rest.Error(c, rest.ErrBadRequest.New("invalid log level %q", req.Level))
+		return
+	}
+	if len(req.Instances) == 0 {
+		rest.Error(c, rest.ErrBadRequest.New("no instance is selected"))
+		return
+	}
+
+	r, err := s.setLogLevels(cluster.PDClientOf(c, s.params.PDClient), cluster.EtcdClientOf(c, s.params.EtcdClients), req.Level, req.Instances)
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, r)
+}
@@ -16,6 +16,7 @@ import (
 	"go.uber.org/fx"
 	"gorm.io/gorm"
 
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/cluster"
 	"github.com/pingcap/tidb-dashboard/pkg/config"
 	"github.com/pingcap/tidb-dashboard/pkg/pd"
 	"github.com/pingcap/tidb-dashboard/pkg/tidb"
@@ -35,11 +36,12 @@ var (
 
 type ServiceParams struct {
 	fx.In
-	Config     *config.Config
-	PDClient   *pd.Client
-	EtcdClient *clientv3.Client
-	TiDBClient *tidb.Client
-	TiKVClient *tikv.Client
+	Config      *config.Config
+	PDClient    *pd.Client
+	EtcdClients *pd.EtcdClientManager
+	TiDBClient  *tidb.Client
+	TiKVClient  *tikv.Client
+	Registry    *cluster.Registry
 }
 
 type Service struct {
@@ -85,8 +87,8 @@ func processNestedConfigAPIResponse(data []byte) (map[string]interface{}, error)
 	return plainConfig, nil
 }
 
-func (s *Service) getConfigItemsFromPDToChannel(ch chan<- channelItem) {
-	r, err := s.getConfigItemsFromPD()
+func (s *Service) getConfigItemsFromPDToChannel(pdClient *pd.Client, ch chan<- channelItem) {
+	r, err := s.getConfigItemsFromPD(pdClient)
 	if err != nil {
 		ch <- channelItem{Err: ErrListConfigItemsFailed.Wrap(err, "Failed to list PD config items")}
 		return
@@ -98,8 +100,8 @@ func (s *Service) getConfigItemsFromPDToChannel(ch chan<- channelItem) {
 	}
 }
 
-func (s *Service) getConfigItemsFromPD() (map[string]interface{}, error) {
-	data, err := s.params.PDClient.SendGetRequest("/config")
+func (s *Service) getConfigItemsFromPD(pdClient *pd.Client) (map[string]interface{}, error) {
+	data, err := pdClient.SendGetRequest("/config")
 	if err != nil {
 		return nil, err
 	}
@@ -187,8 +189,17 @@ func (s *Service) getGlobalVariablesFromTiDB(db *gorm.DB) (map[string]interface{
 type Item struct {
 	ID           string      `json:"id"`
 	IsEditable   bool        `json:"is_editable"`
-	IsMultiValue bool        `json:"is_multi_value"` // TODO: Support per-instance config
-	Value        interface{} `json:"value"`          // When multi value present, this contains one of the value
+	IsMultiValue bool        `json:"is_multi_value"`
+	Value        interface{} `json:"value"` // When multi value present, this contains one of the value
+	// Instances contains the value of each instance when multi value present.
+	Instances []InstanceValue `json:"instances,omitempty"`
+}
+
+type InstanceValue struct {
+	Instance string `json:"instance"`
+	// Missing is true when the instance does not have this config item.
+	Missing bool        `json:"missing,omitempty"`
+	Value   interface{} `json:"value"`
 }
 
 type AllConfigItems struct {
@@ -196,13 +207,13 @@ type AllConfigItems struct {
 	Items  map[ItemKind][]Item  `json:"items"`
 }
 
-func (s *Service) getAllConfigItems(db *gorm.DB) (*AllConfigItems, error) {
-	tikvInfo, _, err := topology.FetchStoreTopology(s.params.PDClient)
+func (s *Service) getAllConfigItems(db *gorm.DB, pdClient *pd.Client, etcdClient *clientv3.Client) (*AllConfigItems, error) {
+	tikvInfo, _, err := topology.FetchStoreTopology(pdClient)
 	if err != nil {
 		return nil, ErrListTopologyFailed.Wrap(err, "Failed to list TiKV stores")
 	}
 
-	tidbInfo, err := topology.FetchTiDBTopology(s.lifecycleCtx, s.params.EtcdClient)
+	tidbInfo, err := topology.FetchTiDBTopology(s.lifecycleCtx, etcdClient)
 	if err != nil {
 		return nil, ErrListTopologyFailed.Wrap(err, "Failed to list %s instances", distro.R().TiDB)
 	}
@@ -212,7 +223,7 @@ func (s *Service) getAllConfigItems(db *gorm.DB) (*AllConfigItems, error) {
 
 	{
 		waitItems++
-		go s.getConfigItemsFromPDToChannel(ch)
+		go s.getConfigItemsFromPDToChannel(pdClient, ch)
 	}
 	{
 		waitItems++
@@ -236,66 +247,67 @@ func (s *Service) getAllConfigItems(db *gorm.DB) (*AllConfigItems, error) {
 	for i := 0; i < waitItems; i++ {
 		item := <-ch
 		if item.Err != nil {
-			errors = append(errors, rest.NewErrorResponse(err))
+			errors = append(errors, rest.NewErrorResponse(item.Err))
 			continue
 		}
 		successItems = append(successItems, item)
 	}
 	close(ch)
 
-	// The first occurred value of each config item
-	valuesMap := make(map[ItemKind]map[string]interface{})
-	// Number of config item key occurred to detect missing config items
-	occurTimesMap := make(map[ItemKind]map[string]int)
-	// Whether each config item has different values
-	identicalMap := make(map[ItemKind]map[string]bool)
-	// The expected number of occur times
-	expectedOccurTimes := make(map[ItemKind]int)
-
-	for _, item := range successItems {
-		if _, ok := expectedOccurTimes[item.SourceKind]; !ok {
-			expectedOccurTimes[item.SourceKind] = 1
-		} else {
-			expectedOccurTimes[item.SourceKind]++
-		}
-		if _, ok := valuesMap[item.SourceKind]; !ok {
-			valuesMap[item.SourceKind] = make(map[string]interface{})
-			occurTimesMap[item.SourceKind] = make(map[string]int)
-			identicalMap[item.SourceKind] = make(map[string]bool)
-		}
-		for key, value := range item.Values {
-			if _, ok := valuesMap[item.SourceKind][key]; !ok {
-				valuesMap[item.SourceKind][key] = value
-				occurTimesMap[item.SourceKind][key] = 1
-				identicalMap[item.SourceKind][key] = true
-			} else {
-				occurTimesMap[item.SourceKind][key]++
-				if value != valuesMap[item.SourceKind][key] {
-					identicalMap[item.SourceKind][key] = false
-				}
-			}
-		}
+	result := mergeConfigItems(successItems)
+	return &AllConfigItems{
+		Errors: errors,
+		Items:  result,
+	}, nil
+}
+
+// mergeConfigItems groups config items of the same kind. An item is multi value when instances have
+// different values, or when some instances do not have it.
+func mergeConfigItems(items []channelItem) map[ItemKind][]Item {
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].SourceDisplayAddress < items[j].SourceDisplayAddress
+	})
+	sources := make(map[ItemKind][]channelItem)
+	for _, item := range items {
+		sources[item.SourceKind] = append(sources[item.SourceKind], item)
 	}
 
 	result := make(map[ItemKind][]Item)
-	for kind, v := range valuesMap {
-		result[kind] = make([]Item, 0)
-		for configKey, configValue := range v {
-			// There are two cases when a config item has multiple values:
-			// 1. Values are not equal
-			// 2. Value is missing
-			isMultiValue := !identicalMap[kind][configKey]
-			value := configValue
-			if !isMultiValue && occurTimesMap[kind][configKey] < expectedOccurTimes[kind] {
-				isMultiValue = false
+	for kind, kindSources := range sources {
+		keys := make(map[string]struct{})
+		for _, source := range kindSources {
+			for key := range source.Values {
+				keys[key] = struct{}{}
 			}
+		}
 
-			result[kind] = append(result[kind], Item{
-				ID:           configKey,
-				IsEditable:   isConfigItemEditable(kind, configKey),
-				IsMultiValue: isMultiValue,
-				Value:        value,
-			})
+		result[kind] = make([]Item, 0, len(keys))
+		for key := range keys {
+			item := Item{
+				ID:         key,
+				IsEditable: isConfigItemEditable(kind, key),
+			}
+			instances := make([]InstanceValue, 0, len(kindSources))
+			hasValue := false
+			for _, source := range kindSources {
+				value, ok := source.Values[key]
+				if !ok {
+					item.IsMultiValue = true
+					instances = append(instances, InstanceValue{Instance: source.SourceDisplayAddress, Missing: true})
+					continue
+				}
+				if !hasValue {
+					item.Value = value
+					hasValue = true
+				} else if value != item.Value {
+					item.IsMultiValue = true
+				}
+				instances = append(instances, InstanceValue{Instance: source.SourceDisplayAddress, Value: value})
+			}
+			if item.IsMultiValue {
+				item.Instances = instances
+			}
+			result[kind] = append(result[kind], item)
 		}
 
 		s := result[kind]
@@ -303,14 +315,10 @@ func (s *Service) getAllConfigItems(db *gorm.DB) (*AllConfigItems, error) {
 			return s[i].ID < s[j].ID
 		})
 	}
-
-	return &AllConfigItems{
-		Errors: errors,
-		Items:  result,
-	}, nil
+	return result
 }
 
-func (s *Service) editConfig(db *gorm.DB, kind ItemKind, id string, newValue interface{}) ([]rest.ErrorResponse, error) {
+func (s *Service) editConfig(db *gorm.DB, pdClient *pd.Client, kind ItemKind, id string, newValue interface{}) ([]rest.ErrorResponse, error) {
 	if !isConfigItemEditable(kind, id) {
 		return nil, ErrNotEditable.New("Configuration `%s` is not editable", id)
 	}
@@ -323,12 +331,12 @@ func (s *Service) editConfig(db *gorm.DB, kind ItemKind, id string, newValue int
 
 	switch kind {
 	case ItemKindPDConfig:
-		_, err := s.params.PDClient.SendPostRequest("/config", bytes.NewBuffer(bodyJSON))
+		_, err := pdClient.SendPostRequest("/config", bytes.NewBuffer(bodyJSON))
 		if err != nil {
 			return nil, ErrEditFailed.WrapWithNoMessage(err)
 		}
 	case ItemKindTiKVConfig:
-		tikvInfo, _, err := topology.FetchStoreTopology(s.params.PDClient)
+		tikvInfo, _, err := topology.FetchStoreTopology(pdClient)
 		if err != nil {
 			return nil, ErrEditFailed.WrapWithNoMessage(ErrListTopologyFailed.WrapWithNoMessage(err))
 		}
@@ -0,0 +1,82 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package configuration
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pingcap/tidb-dashboard/util/topo"
+)
+
+func TestMergeConfigItems(t *testing.T) {
+	result := mergeConfigItems([]channelItem{
+		{
+			SourceDisplayAddress: "10.0.2.2:20160",
+			SourceKind:           ItemKindTiKVConfig,
+			Values:               map[string]interface{}{"a": 1.0, "b": "x"},
+		},
+		{
+			SourceDisplayAddress: "10.0.2.1:20160",
+			SourceKind:           ItemKindTiKVConfig,
+			Values:               map[string]interface{}{"a": 1.0, "b": "y", "c": true},
+		},
+		{
+			SourceKind: ItemKindPDConfig,
+			Values:     map[string]interface{}{"a": 1.0},
+		},
+	})
+
+	require.Equal(t, []Item{{ID: "a", Value: 1.0}}, result[ItemKindPDConfig])
+	require.Equal(t, []Item{
+		{ID: "a", Value: 1.0},
+		{
+			ID:           "b",
+			IsMultiValue: true,
+			Value:        "y",
+			Instances: []InstanceValue{
+				{Instance: "10.0.2.1:20160", Value: "y"},
+				{Instance: "10.0.2.2:20160", Value: "x"},
+			},
+		},
+		{
+			ID:           "c",
+			IsMultiValue: true,
+			Value:        true,
+			Instances: []InstanceValue{
+				{Instance: "10.0.2.1:20160", Value: true},
+				{Instance: "10.0.2.2:20160", Missing: true},
+			},
+		},
+	}, result[ItemKindTiKVConfig])
+}
+
+func TestLogLevelFromConfig(t *testing.T) {
+	level, err := logLevelFromConfig([]byte(`{"log": {"level": "info", "file": {"filename": ""}}}`))
+	require.NoError(t, err)
+	require.Equal(t, "info", level)
+
+	level, err = logLevelFromConfig([]byte(`{"log-level": "warn"}`))
+	require.NoError(t, err)
+	require.Equal(t, "warn", level)
+
+	_, err = logLevelFromConfig([]byte(`{"log": {}}`))
+	require.Error(t, err)
+}
+
+func TestSelectLogLevelTargets(t *testing.T) {
+	pd := LogLevelInstance{Component: topo.KindPD, Instance: "10.0.1.1:2379"}
+	tidb := LogLevelInstance{Component: topo.KindTiDB, Instance: "10.0.1.1:4000"}
+	targets := []logLevelTarget{
+		{LogLevelInstance: pd, host: "10.0.1.1", statusPort: 2379},
+		{LogLevelInstance: tidb, host: "10.0.1.1", statusPort: 10080},
+	}
+
+	missing := LogLevelInstance{Component: topo.KindTiKV, Instance: "10.0.1.1:4000"}
+	selected, notFound := selectLogLevelTargets(targets, []LogLevelInstance{tidb, missing, tidb})
+	require.Equal(t, []logLevelTarget{targets[1]}, selected)
+	require.Len(t, notFound, 1)
+	require.Equal(t, missing, notFound[0].LogLevelInstance)
+	require.NotNil(t, notFound[0].Error)
+}
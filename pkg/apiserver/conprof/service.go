@@ -8,12 +8,13 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
-	"go.etcd.io/etcd/clientv3"
 	"go.uber.org/fx"
 
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/audit"
 	"github.com/pingcap/tidb-dashboard/pkg/apiserver/user"
 	"github.com/pingcap/tidb-dashboard/pkg/apiserver/utils"
 	"github.com/pingcap/tidb-dashboard/pkg/config"
+	"github.com/pingcap/tidb-dashboard/pkg/pd"
 	"github.com/pingcap/tidb-dashboard/util/featureflag"
 	"github.com/pingcap/tidb-dashboard/util/rest"
 )
@@ -21,7 +22,7 @@ import (
 type ServiceParams struct {
 	fx.In
 
-	EtcdClient   *clientv3.Client
+	EtcdClients  *pd.EtcdClientManager
 	Config       *config.Config
 	NgmProxy     *utils.NgmProxy
 	FeatureFlags *featureflag.Registry
@@ -51,13 +52,13 @@ func newService(lc fx.Lifecycle, p ServiceParams) *Service {
 }
 
 // Register register the handlers to the service.
-func registerRouter(r *gin.RouterGroup, auth *user.AuthService, s *Service) {
+func registerRouter(r *gin.RouterGroup, auth *user.AuthService, a *audit.Service, s *Service) {
 	endpoint := r.Group("/continuous_profiling")
 
 	endpoint.Use(s.FeatureFlagConprof.VersionGuard())
 	{
 		endpoint.GET("/config", auth.MWAuthRequired(), s.params.NgmProxy.Route("/config"))
-		endpoint.POST("/config", auth.MWAuthRequired(), auth.MWRequireWritePriv(), s.params.NgmProxy.Route("/config"))
+		endpoint.POST("/config", auth.MWAuthRequired(), auth.MWRequireWritePriv(), a.MWRecord("conprof.set_config"), s.params.NgmProxy.Route("/config"))
 		endpoint.GET("/components", auth.MWAuthRequired(), s.params.NgmProxy.Route("/continuous_profiling/components"))
 		endpoint.GET("/estimate_size", auth.MWAuthRequired(), s.params.NgmProxy.Route("/continuous_profiling/estimate_size"))
 		endpoint.GET("/group_profiles", auth.MWAuthRequired(), s.params.NgmProxy.Route("/continuous_profiling/group_profiles"))
@@ -0,0 +1,23 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package ddl
+
+import "time"
+
+type Job struct {
+	JobID       int64      `gorm:"column:JOB_ID" json:"job_id"`
+	DBName      string     `gorm:"column:DB_NAME" json:"db_name"`
+	TableName   string     `gorm:"column:TABLE_NAME" json:"table_name"`
+	JobType     string     `gorm:"column:JOB_TYPE" json:"job_type"`
+	SchemaState string     `gorm:"column:SCHEMA_STATE" json:"schema_state"`
+	RowCount    int64      `gorm:"column:ROW_COUNT" json:"row_count"`
+	CreateTime  *time.Time `gorm:"column:CREATE_TIME" json:"create_time"`
+	StartTime   *time.Time `gorm:"column:START_TIME" json:"start_time"`
+	EndTime     *time.Time `gorm:"column:END_TIME" json:"end_time"`
+	State       string     `gorm:"column:STATE" json:"state"`
+	Query       string     `gorm:"column:QUERY" json:"query"`
+
+	// EstimatedTotalRows is the estimated row count of the table, only present for running jobs backfilling
+	// rows, e.g. adding indexes. It can be compared with RowCount to show the progress.
+	EstimatedTotalRows *int64 `gorm:"-" json:"estimated_total_rows,omitempty"`
+}
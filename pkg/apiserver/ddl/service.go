@@ -0,0 +1,197 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package ddl
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/joomcode/errorx"
+	"go.uber.org/fx"
+	"gorm.io/gorm"
+
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/audit"
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/user"
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/utils"
+	"github.com/pingcap/tidb-dashboard/pkg/tidb"
+	"github.com/pingcap/tidb-dashboard/util/rest"
+)
+
+const (
+	DDLJobsTable = "INFORMATION_SCHEMA.DDL_JOBS"
+
+	defaultListLimit = 100
+)
+
+var (
+	ErrNS           = errorx.NewNamespace("error.api.ddl")
+	ErrQueryFailed  = ErrNS.NewType("query_failed")
+	ErrCancelFailed = ErrNS.NewType("cancel_failed")
+)
+
+// finishedStates are the states of jobs no longer running.
+var finishedStates = []string{"synced", "cancelled", "rollback done"}
+
+type ServiceParams struct {
+	fx.In
+	TiDBClient *tidb.Client
+}
+
+type Service struct {
+	params ServiceParams
+}
+
+func newService(p ServiceParams) *Service {
+	return &Service{params: p}
+}
+
+func registerRouter(r *gin.RouterGroup, auth *user.AuthService, a *audit.Service, s *Service) {
+	endpoint := r.Group("/ddl")
+	endpoint.Use(
+		auth.MWAuthRequired(),
+		utils.MWConnectTiDB(s.params.TiDBClient),
+	)
+	{
+		endpoint.GET("/jobs", s.getJobs)
+		endpoint.GET("/jobs/:id", s.getJob)
+		endpoint.POST("/jobs/:id/cancel", auth.MWRequireWritePriv(), a.MWRecord("ddl.cancel"), s.cancelJob)
+	}
+}
+
+type GetJobsRequest struct {
+	// Running lists running jobs only when true, or finished jobs only when false. All jobs are listed when absent.
+	Running *bool `json:"running" form:"running"`
+	Limit   int   `json:"limit" form:"limit"`
+}
+
+// isBackfillJob returns whether the job backfills rows of the whole table.
+func isBackfillJob(jobType string) bool {
+	t := strings.ToLower(jobType)
+	return strings.Contains(t, "add index") || strings.Contains(t, "add primary key") || strings.Contains(t, "modify column")
+}
+
+func isRunning(state string) bool {
+	for _, s := range finishedStates {
+		if state == s {
+			return false
+		}
+	}
+	return true
+}
+
+func fillEstimatedTotalRows(db *gorm.DB, jobs []Job) {
+	for i := range jobs {
+		j := &jobs[i]
+		if !isRunning(j.State) || !isBackfillJob(j.JobType) {
+			continue
+		}
+		var rows []int64
+		err := db.
+			Table("INFORMATION_SCHEMA.TABLES").
+			Where("TABLE_SCHEMA = ? AND TABLE_NAME = ?", j.DBName, j.TableName).
+			Pluck("TABLE_ROWS", &rows).Error
+		// The progress is optional, so that failures are ignored.
+		if err == nil && len(rows) == 1 {
+			j.EstimatedTotalRows = &rows[0]
+		}
+	}
+}
+
+// @ID ddlGetJobs
+// @Summary List DDL jobs, latest first
+// @Param q query GetJobsRequest true "Query"
+// @Success 200 {array} Job
+// @Router /ddl/jobs [get]
+// @Security JwtAuth
+// @Failure 400 {object} rest.ErrorResponse
+// @Failure 401 {object} rest.ErrorResponse
+func (s *Service) getJobs(c *gin.Context) {
+	var req GetJobsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		rest.Error(c, rest.ErrBadRequest.NewWithNoMessage())
+		return
+	}
+	if req.Limit <= 0 {
+		req.Limit = defaultListLimit
+	}
+
+	db := utils.GetTiDBConnection(c)
+	tx := db.Table(DDLJobsTable).Order("JOB_ID DESC").Limit(req.Limit)
+	if req.Running != nil {
+		if *req.Running {
+			tx = tx.Where("STATE NOT IN (?)", finishedStates)
+		} else {
+			tx = tx.Where("STATE IN (?)", finishedStates)
+		}
+	}
+	jobs := make([]Job, 0)
+	if err := tx.Find(&jobs).Error; err != nil {
+		rest.Error(c, ErrQueryFailed.WrapWithNoMessage(err))
+		return
+	}
+	fillEstimatedTotalRows(db, jobs)
+	c.JSON(http.StatusOK, jobs)
+}
+
+func parseJobID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		rest.Error(c, rest.ErrBadRequest.New("invalid job id"))
+		return 0, false
+	}
+	return id, true
+}
+
+// @ID ddlGetJob
+// @Summary Get a DDL job
+// @Param id path int true "job ID"
+// @Success 200 {object} Job
+// @Router /ddl/jobs/{id} [get]
+// @Security JwtAuth
+// @Failure 400 {object} rest.ErrorResponse
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 404 {object} rest.ErrorResponse
+func (s *Service) getJob(c *gin.Context) {
+	id, ok := parseJobID(c)
+	if !ok {
+		return
+	}
+	db := utils.GetTiDBConnection(c)
+	jobs := make([]Job, 0)
+	if err := db.Table(DDLJobsTable).Where("JOB_ID = ?", id).Find(&jobs).Error; err != nil {
+		rest.Error(c, ErrQueryFailed.WrapWithNoMessage(err))
+		return
+	}
+	if len(jobs) == 0 {
+		rest.Error(c, rest.ErrNotFound.New("DDL job %d not found", id))
+		return
+	}
+	fillEstimatedTotalRows(db, jobs)
+	c.JSON(http.StatusOK, jobs[0])
+}
+
+// @ID ddlCancelJob
+// @Summary Cancel a running DDL job
+// @Param id path int true "job ID"
+// @Success 200 {string} string
+// @Router /ddl/jobs/{id}/cancel [post]
+// @Security JwtAuth
+// @Failure 400 {object} rest.ErrorResponse
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 403 {object} rest.ErrorResponse
+func (s *Service) cancelJob(c *gin.Context) {
+	id, ok := parseJobID(c)
+	if !ok {
+		return
+	}
+	db := utils.GetTiDBConnection(c)
+	// ADMIN statements do not support placeholders. The id is an integer, so that it is safe to interpolate.
+	if err := db.Exec(fmt.Sprintf("ADMIN CANCEL DDL JOBS %d", id)).Error; err != nil {
+		rest.Error(c, ErrCancelFailed.WrapWithNoMessage(err))
+		return
+	}
+	c.JSON(http.StatusOK, nil)
+}
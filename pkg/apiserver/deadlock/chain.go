@@ -0,0 +1,125 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package deadlock
+
+import (
+	"sort"
+)
+
+// buildChains groups deadlock records by instance and deadlock ID, latest first.
+func buildChains(records []Model) []Chain {
+	type chainKey struct {
+		instance string
+		id       uint64
+	}
+	recordsByKey := make(map[chainKey][]Model)
+	var keys []chainKey
+	for _, r := range records {
+		k := chainKey{instance: r.Instance, id: r.DeadlockID}
+		if _, ok := recordsByKey[k]; !ok {
+			keys = append(keys, k)
+		}
+		recordsByKey[k] = append(recordsByKey[k], r)
+	}
+
+	chains := make([]Chain, 0, len(keys))
+	for _, k := range keys {
+		chains = append(chains, buildChain(recordsByKey[k]))
+	}
+	sort.SliceStable(chains, func(i, j int) bool {
+		if !chains[i].OccurTime.Equal(chains[j].OccurTime) {
+			return chains[i].OccurTime.After(chains[j].OccurTime)
+		}
+		if chains[i].Instance != chains[j].Instance {
+			return chains[i].Instance < chains[j].Instance
+		}
+		return chains[i].DeadlockID < chains[j].DeadlockID
+	})
+	return chains
+}
+
+// buildChain orders the records of a deadlock by following the transactions holding the locks, starting from the
+// transaction with the smallest ID. Records not reached are appended in the end.
+func buildChain(records []Model) Chain {
+	sorted := append([]Model(nil), records...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].TryLockTrxID < sorted[j].TryLockTrxID
+	})
+	chain := Chain{
+		Instance:   sorted[0].Instance,
+		DeadlockID: sorted[0].DeadlockID,
+		OccurTime:  sorted[0].OccurTime,
+		Retryable:  sorted[0].Retryable,
+		Waits:      make([]ChainWait, 0, len(sorted)),
+	}
+
+	byTrx := make(map[uint64]int, len(sorted))
+	for i, r := range sorted {
+		if _, ok := byTrx[r.TryLockTrxID]; !ok {
+			byTrx[r.TryLockTrxID] = i
+		}
+	}
+	visited := make([]bool, len(sorted))
+	appendWait := func(i int) {
+		r := sorted[i]
+		visited[i] = true
+		chain.Waits = append(chain.Waits, ChainWait{
+			TrxID:        r.TryLockTrxID,
+			HoldingTrxID: r.TryHoldingLock,
+			SQLDigest:    r.CurrentDigest,
+			SQL:          r.CurrentSQL,
+			Key:          r.Key,
+			KeyInfo:      r.KeyInfo,
+		})
+	}
+
+	next := 0
+	for {
+		appendWait(next)
+		i, ok := byTrx[sorted[next].TryHoldingLock]
+		if !ok || visited[i] {
+			chain.Complete = ok && i == 0 && len(chain.Waits) == len(sorted)
+			break
+		}
+		next = i
+	}
+	for i := range sorted {
+		if !visited[i] {
+			appendWait(i)
+		}
+	}
+	return chain
+}
+
+// buildBlockers counts the transactions waiting for each transaction holding locks.
+func buildBlockers(waits []LockWait) []LockBlocker {
+	indexes := make(map[uint64]int)
+	blockers := make([]LockBlocker, 0)
+	for _, w := range waits {
+		i, ok := indexes[w.HoldingTrxID]
+		if !ok {
+			i = len(blockers)
+			indexes[w.HoldingTrxID] = i
+			blockers = append(blockers, LockBlocker{HoldingTrxID: w.HoldingTrxID, Keys: []string{}})
+		}
+		b := &blockers[i]
+		b.WaitingCount++
+		found := false
+		for _, key := range b.Keys {
+			if key == w.Key {
+				found = true
+				break
+			}
+		}
+		if !found {
+			b.Keys = append(b.Keys, w.Key)
+		}
+	}
+	sort.SliceStable(blockers, func(i, j int) bool {
+		if blockers[i].WaitingCount != blockers[j].WaitingCount {
+			return blockers[i].WaitingCount > blockers[j].WaitingCount
+		}
+		return blockers[i].HoldingTrxID < blockers[j].HoldingTrxID
+	})
+	return blockers
+}
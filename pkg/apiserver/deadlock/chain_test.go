@@ -0,0 +1,62 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package deadlock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildChains(t *testing.T) {
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	records := []Model{
+		// A cycle of 3 transactions recorded out of order.
+		{Instance: "tidb-0", DeadlockID: 1, OccurTime: t0, TryLockTrxID: 30, TryHoldingLock: 10, Key: "k3", CurrentDigest: "d3"},
+		{Instance: "tidb-0", DeadlockID: 1, OccurTime: t0, TryLockTrxID: 10, TryHoldingLock: 20, Key: "k1", CurrentDigest: "d1"},
+		{Instance: "tidb-0", DeadlockID: 1, OccurTime: t0, TryLockTrxID: 20, TryHoldingLock: 30, Key: "k2", CurrentDigest: "d2"},
+		// The same deadlock ID on another instance is another deadlock, with a record missing.
+		{Instance: "tidb-1", DeadlockID: 1, OccurTime: t0.Add(time.Minute), TryLockTrxID: 40, TryHoldingLock: 50, Key: "k4"},
+	}
+
+	chains := buildChains(records)
+	require.Len(t, chains, 2)
+
+	require.Equal(t, "tidb-1", chains[0].Instance)
+	require.False(t, chains[0].Complete)
+	require.Len(t, chains[0].Waits, 1)
+
+	require.Equal(t, "tidb-0", chains[1].Instance)
+	require.True(t, chains[1].Complete)
+	require.Equal(t, []ChainWait{
+		{TrxID: 10, HoldingTrxID: 20, SQLDigest: "d1", Key: "k1"},
+		{TrxID: 20, HoldingTrxID: 30, SQLDigest: "d2", Key: "k2"},
+		{TrxID: 30, HoldingTrxID: 10, SQLDigest: "d3", Key: "k3"},
+	}, chains[1].Waits)
+}
+
+func TestBuildChainWithUnreachedRecords(t *testing.T) {
+	chain := buildChain([]Model{
+		{TryLockTrxID: 10, TryHoldingLock: 20},
+		{TryLockTrxID: 20, TryHoldingLock: 10},
+		{TryLockTrxID: 30, TryHoldingLock: 10},
+	})
+	require.False(t, chain.Complete)
+	require.Len(t, chain.Waits, 3)
+	require.Equal(t, uint64(30), chain.Waits[2].TrxID)
+}
+
+func TestBuildBlockers(t *testing.T) {
+	blockers := buildBlockers([]LockWait{
+		{Key: "k1", TrxID: 2, HoldingTrxID: 1},
+		{Key: "k2", TrxID: 4, HoldingTrxID: 3},
+		{Key: "k2", TrxID: 5, HoldingTrxID: 3},
+		{Key: "k3", TrxID: 6, HoldingTrxID: 3},
+	})
+	require.Equal(t, []LockBlocker{
+		{HoldingTrxID: 3, WaitingCount: 3, Keys: []string{"k2", "k3"}},
+		{HoldingTrxID: 1, WaitingCount: 1, Keys: []string{"k1"}},
+	}, blockers)
+	require.Empty(t, buildBlockers(nil))
+}
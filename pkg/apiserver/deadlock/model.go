@@ -11,7 +11,53 @@ type Model struct {
 	Retryable      bool      `gorm:"column:RETRYABLE" json:"retryable"`
 	TryLockTrxID   uint64    `gorm:"column:TRY_LOCK_TRX_ID" json:"try_lock_trx_id"`
 	TryHoldingLock uint64    `gorm:"column:TRX_HOLDING_LOCK" json:"trx_holding_lock"`
+	CurrentDigest  string    `gorm:"column:CURRENT_SQL_DIGEST" json:"current_sql_digest"`
 	CurrentSQL     string    `gorm:"column:CURRENT_SQL_DIGEST_TEXT" json:"current_sql"`
 	Key            string    `gorm:"column:KEY" json:"key"`
 	KeyInfo        string    `gorm:"column:KEY_INFO" json:"key_info"`
 }
+
+// ChainWait is a transaction waiting for a lock held by another transaction in a deadlock.
+type ChainWait struct {
+	TrxID        uint64 `json:"trx_id"`
+	HoldingTrxID uint64 `json:"holding_trx_id"`
+	// SQLDigest links to the statement of the waiting transaction, which is empty if unknown.
+	SQLDigest string `json:"sql_digest"`
+	SQL       string `json:"sql"`
+	Key       string `json:"key"`
+	KeyInfo   string `json:"key_info"`
+}
+
+// Chain is a deadlock, whose waits are ordered to form the cycle, i.e. each transaction is waiting for the next one
+// and the last one is waiting for the first one.
+type Chain struct {
+	Instance   string      `json:"instance"`
+	DeadlockID uint64      `json:"id"`
+	OccurTime  time.Time   `json:"occur_time"`
+	Retryable  bool        `json:"retryable"`
+	Waits      []ChainWait `json:"waits"`
+	// Complete is false if the waits do not form a cycle, e.g. when some records are missing.
+	Complete bool `json:"complete"`
+}
+
+type LockWait struct {
+	Key          string `gorm:"column:KEY" json:"key"`
+	KeyInfo      string `gorm:"column:KEY_INFO" json:"key_info"`
+	TrxID        uint64 `gorm:"column:TRX_ID" json:"trx_id"`
+	HoldingTrxID uint64 `gorm:"column:CURRENT_HOLDING_TRX_ID" json:"holding_trx_id"`
+	SQLDigest    string `gorm:"column:SQL_DIGEST" json:"sql_digest"`
+	SQL          string `gorm:"column:SQL_DIGEST_TEXT" json:"sql"`
+}
+
+// LockBlocker is a transaction holding locks that other transactions are waiting for.
+type LockBlocker struct {
+	HoldingTrxID uint64   `json:"holding_trx_id"`
+	WaitingCount int      `json:"waiting_count"`
+	Keys         []string `json:"keys"`
+}
+
+type LockWaitsResponse struct {
+	Waits []LockWait `json:"waits"`
+	// Blockers are ordered by the number of waiting transactions, most first.
+	Blockers []LockBlocker `json:"blockers"`
+}
@@ -16,7 +16,8 @@ import (
 )
 
 const (
-	DeadlockTable = "INFORMATION_SCHEMA.CLUSTER_DEADLOCKS"
+	DeadlockTable     = "INFORMATION_SCHEMA.CLUSTER_DEADLOCKS"
+	DataLockWaitTable = "INFORMATION_SCHEMA.DATA_LOCK_WAITS"
 )
 
 type ServiceParams struct {
@@ -41,6 +42,8 @@ func registerRouter(r *gin.RouterGroup, auth *user.AuthService, s *Service) {
 	)
 	{
 		endpoint.GET("/list", s.getList)
+		endpoint.GET("/chains", s.getChains)
+		endpoint.GET("/lock_waits", s.getLockWaits)
 	}
 }
 
@@ -61,3 +64,45 @@ func (s *Service) getList(c *gin.Context) {
 
 	c.JSON(http.StatusOK, results)
 }
+
+// @Summary List deadlocks of all instances as wait chains, latest first
+// @Description Each chain lists the transactions in the deadlock cycle, with the digest of the statement waiting
+// @Description for the lock, which can be used to look up the statement and its plans.
+// @Success 200 {array} Chain
+// @Router /deadlock/chains [get]
+// @Security JwtAuth
+// @Failure 400 {object} rest.ErrorResponse
+// @Failure 401 {object} rest.ErrorResponse
+func (s *Service) getChains(c *gin.Context) {
+	db := utils.GetTiDBConnection(c)
+	var results []Model
+	err := db.Table(DeadlockTable).Find(&results).Error
+	if err != nil {
+		rest.Error(c, rest.ErrBadRequest.WrapWithNoMessage(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, buildChains(results))
+}
+
+// @Summary List pessimistic lock waits of the cluster
+// @Description Blockers are transactions holding locks that other transactions are waiting for.
+// @Success 200 {object} LockWaitsResponse
+// @Router /deadlock/lock_waits [get]
+// @Security JwtAuth
+// @Failure 400 {object} rest.ErrorResponse
+// @Failure 401 {object} rest.ErrorResponse
+func (s *Service) getLockWaits(c *gin.Context) {
+	db := utils.GetTiDBConnection(c)
+	waits := make([]LockWait, 0)
+	err := db.Table(DataLockWaitTable).Find(&waits).Error
+	if err != nil {
+		rest.Error(c, rest.ErrBadRequest.WrapWithNoMessage(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, LockWaitsResponse{
+		Waits:    waits,
+		Blockers: buildBlockers(waits),
+	})
+}
@@ -9,9 +9,10 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"go.etcd.io/etcd/clientv3"
 	"go.uber.org/fx"
 
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/audit"
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/cluster"
 	"github.com/pingcap/tidb-dashboard/pkg/apiserver/debugapi/endpoint"
 	"github.com/pingcap/tidb-dashboard/pkg/apiserver/user"
 	"github.com/pingcap/tidb-dashboard/pkg/pd"
@@ -25,13 +26,13 @@ import (
 	"github.com/pingcap/tidb-dashboard/util/rest/fileswap"
 )
 
-func registerRouter(r *gin.RouterGroup, auth *user.AuthService, s *Service) {
+func registerRouter(r *gin.RouterGroup, auth *user.AuthService, a *audit.Service, s *Service) {
 	ep := r.Group("/debug_api")
 	ep.GET("/download", s.Download)
 	{
-		ep.Use(auth.MWAuthRequired())
+		ep.Use(auth.MWAuthRequired(), s.registry.MWResolveCluster())
 		ep.GET("/endpoints", s.GetEndpoints)
-		ep.POST("/endpoint", s.RequestEndpoint)
+		ep.POST("/endpoint", a.MWRecord("debug_api.request"), s.RequestEndpoint)
 	}
 }
 
@@ -43,14 +44,16 @@ type ServiceParams struct {
 	TiFlashStatusClient *tiflashclient.StatusClient
 	TiCDCStatusClient   *ticdcclient.StatusClient
 	TiProxyStatusClient *tiproxyclient.StatusClient
-	EtcdClient          *clientv3.Client
+	EtcdClients         *pd.EtcdClientManager
 	PDClient            *pd.Client
+	Registry            *cluster.Registry
 }
 
 type Service struct {
 	httpClients endpoint.HTTPClients
-	etcdClient  *clientv3.Client
+	etcdClients *pd.EtcdClientManager
 	pdClient    *pd.Client
+	registry    *cluster.Registry
 	resolver    *endpoint.RequestPayloadResolver
 	fSwap       *fileswap.Handler
 }
@@ -66,8 +69,9 @@ func newService(p ServiceParams) *Service {
 	}
 	return &Service{
 		httpClients: httpClients,
-		etcdClient:  p.EtcdClient,
+		etcdClients: p.EtcdClients,
 		pdClient:    p.PDClient,
+		registry:    p.Registry,
 		resolver:    endpoint.NewRequestPayloadResolver(apiEndpoints, httpClients),
 		fSwap:       fileswap.New(),
 	}
@@ -124,7 +128,10 @@ func (s *Service) RequestEndpoint(c *gin.Context) {
 		_ = writer.Close()
 	}()
 
-	resp, err := resolved.SendRequestAndPipe(c.Request.Context(), s.httpClients, s.etcdClient, s.pdClient, writer)
+	// The endpoint is verified against the topology of the cluster selected in the session.
+	etcdClient := cluster.EtcdClientOf(c, s.etcdClients)
+	pdClient := cluster.PDClientOf(c, s.pdClient)
+	resp, err := resolved.SendRequestAndPipe(c.Request.Context(), s.httpClients, etcdClient, pdClient, writer)
 	if err != nil {
 		rest.Error(c, err)
 		return
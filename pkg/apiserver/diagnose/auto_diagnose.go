@@ -0,0 +1,348 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package diagnose
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/utils"
+	"github.com/pingcap/tidb-dashboard/util/rest"
+)
+
+const (
+	SeverityCritical = "critical"
+	SeverityWarning  = "warning"
+	SeverityInfo     = "info"
+)
+
+// Issue is an anomaly found by a rule of the auto diagnosis.
+type Issue struct {
+	Rule string `json:"rule"`
+	// Score is the severity of the issue from 0 to 100, issues are sorted by the score in descending order.
+	Score    float64 `json:"score"`
+	Severity string  `json:"severity"`
+	Instance string  `json:"instance"`
+	Message  string  `json:"message"`
+	// Link is the route of the dashboard page showing the details, e.g. `/keyviz`.
+	Link string `json:"link"`
+}
+
+type RuleError struct {
+	Rule  string `json:"rule"`
+	Error string `json:"error"`
+}
+
+type AutoDiagnoseResponse struct {
+	Issues []Issue `json:"issues"`
+	// RuleErrors are the rules failed to run, e.g. when the metrics are not available.
+	RuleErrors []RuleError `json:"rule_errors"`
+}
+
+// autoDiagnoseRule queries a metrics table in the time range and evaluates the rows into issues.
+type autoDiagnoseRule struct {
+	name string
+	// sql is formatted with the start time and end time.
+	sql      string
+	link     string
+	evaluate func(rows [][]string) []Issue
+}
+
+var autoDiagnoseRules = []autoDiagnoseRule{
+	{
+		name: "latency_jump",
+		sql: "select time, instance, value from metrics_schema.tidb_query_duration " +
+			"where time >= '%s' and time < '%s' and quantile = 0.99 and value is not null order by time",
+		link:     "/slow_query",
+		evaluate: evaluateLatencyJump,
+	},
+	{
+		name: "leader_drop",
+		sql: "select time, address, value from metrics_schema.pd_scheduler_store_status " +
+			"where time >= '%s' and time < '%s' and type = 'leader_count' and value is not null order by time",
+		link:     "/cluster_info",
+		evaluate: evaluateLeaderDrop,
+	},
+	{
+		name: "disk_saturation",
+		sql: "select instance, device, avg(value), max(value) from metrics_schema.node_disk_io_util " +
+			"where time >= '%s' and time < '%s' and value is not null group by instance, device",
+		link:     "/cluster_info",
+		evaluate: evaluateDiskSaturation,
+	},
+	{
+		name: "hot_write_store_imbalance",
+		sql: "select address, avg(value) from metrics_schema.pd_scheduler_store_status " +
+			"where time >= '%s' and time < '%s' and type = 'store_write_rate_bytes' and value is not null group by address",
+		link:     "/keyviz",
+		evaluate: evaluateStoreImbalance("write"),
+	},
+	{
+		name: "hot_read_store_imbalance",
+		sql: "select address, avg(value) from metrics_schema.pd_scheduler_store_status " +
+			"where time >= '%s' and time < '%s' and type = 'store_read_rate_bytes' and value is not null group by address",
+		link:     "/keyviz",
+		evaluate: evaluateStoreImbalance("read"),
+	},
+}
+
+const (
+	// latencyJumpMinPeak is the min p99 latency in seconds to be considered as a latency jump.
+	latencyJumpMinPeak = 0.1
+	// latencyJumpMinRatio is the min ratio of the peak latency to the median latency.
+	latencyJumpMinRatio = 3
+	// leaderDropMinLeaders is the min number of leaders before the drop to be considered.
+	leaderDropMinLeaders = 10
+	// leaderDropMinRatio is the min ratio of dropped leaders.
+	leaderDropMinRatio = 0.3
+	// diskSaturationMinUtil is the min average IO utilization to be considered as saturated.
+	diskSaturationMinUtil = 0.8
+	// storeImbalanceMinFlow is the min flow in bytes per second of the hottest store.
+	storeImbalanceMinFlow = 1 << 20
+	// storeImbalanceMinRatio is the min ratio of the flow of the hottest store to the average flow of all stores.
+	storeImbalanceMinRatio = 2
+)
+
+func clampScore(score float64) float64 {
+	score = math.Max(0, math.Min(100, score))
+	return math.Round(score*10) / 10
+}
+
+func severityOfScore(score float64) string {
+	switch {
+	case score >= 80:
+		return SeverityCritical
+	case score >= 50:
+		return SeverityWarning
+	default:
+		return SeverityInfo
+	}
+}
+
+func newIssue(score float64, instance, message string) Issue {
+	score = clampScore(score)
+	return Issue{
+		Score:    score,
+		Severity: severityOfScore(score),
+		Instance: instance,
+		Message:  message,
+	}
+}
+
+// groupSeries groups rows of `time, label, value` by the label, keeping values in the order of rows.
+// Rows with invalid values are skipped.
+func groupSeries(rows [][]string) (labels []string, series map[string][]float64) {
+	series = make(map[string][]float64)
+	for _, row := range rows {
+		if len(row) < 3 {
+			continue
+		}
+		v, err := strconv.ParseFloat(row[2], 64)
+		if err != nil {
+			continue
+		}
+		if _, ok := series[row[1]]; !ok {
+			labels = append(labels, row[1])
+		}
+		series[row[1]] = append(series[row[1]], v)
+	}
+	return labels, series
+}
+
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+// evaluateLatencyJump finds instances whose peak p99 query latency is far above the median of the time range.
+func evaluateLatencyJump(rows [][]string) []Issue {
+	var issues []Issue
+	labels, series := groupSeries(rows)
+	for _, instance := range labels {
+		values := series[instance]
+		peak := 0.0
+		for _, v := range values {
+			peak = math.Max(peak, v)
+		}
+		base := median(values)
+		if peak < latencyJumpMinPeak || base <= 0 {
+			continue
+		}
+		ratio := peak / base
+		if ratio < latencyJumpMinRatio {
+			continue
+		}
+		issues = append(issues, newIssue(25*math.Log2(ratio), instance,
+			fmt.Sprintf("p99 query latency jumped to %s, %.1fx of the median %s", formatSeconds(peak), ratio, formatSeconds(base))))
+	}
+	return issues
+}
+
+// maxDrop returns the largest decrease from a previous peak in the values, and the peak.
+func maxDrop(values []float64) (drop, peak float64) {
+	runningPeak := math.Inf(-1)
+	for _, v := range values {
+		runningPeak = math.Max(runningPeak, v)
+		if d := runningPeak - v; d > drop {
+			drop = d
+			peak = runningPeak
+		}
+	}
+	return drop, peak
+}
+
+// evaluateLeaderDrop finds stores losing a large portion of leaders in the time range.
+func evaluateLeaderDrop(rows [][]string) []Issue {
+	var issues []Issue
+	labels, series := groupSeries(rows)
+	for _, store := range labels {
+		drop, peak := maxDrop(series[store])
+		if peak < leaderDropMinLeaders {
+			continue
+		}
+		ratio := drop / peak
+		if ratio < leaderDropMinRatio {
+			continue
+		}
+		issues = append(issues, newIssue(ratio*100, store,
+			fmt.Sprintf("leader count dropped from %.0f to %.0f", peak, peak-drop)))
+	}
+	return issues
+}
+
+// evaluateDiskSaturation finds disks with a high average IO utilization. Rows are `instance, device, avg, max`.
+func evaluateDiskSaturation(rows [][]string) []Issue {
+	var issues []Issue
+	for _, row := range rows {
+		if len(row) < 4 {
+			continue
+		}
+		values, err := batchAtof(row[2:4])
+		if err != nil {
+			continue
+		}
+		avg, max := values[0], values[1]
+		if avg < diskSaturationMinUtil {
+			continue
+		}
+		issues = append(issues, newIssue(avg*100, row[0],
+			fmt.Sprintf("disk %s is saturated, average IO utilization %.1f%%, max %.1f%%", row[1], avg*100, max*100)))
+	}
+	return issues
+}
+
+// evaluateStoreImbalance finds the store whose flow is far above the average flow of all stores.
+// Rows are `address, avg`.
+func evaluateStoreImbalance(flow string) func(rows [][]string) []Issue {
+	return func(rows [][]string) []Issue {
+		var hottest string
+		var total, peak float64
+		n := 0
+		for _, row := range rows {
+			if len(row) < 2 {
+				continue
+			}
+			v, err := strconv.ParseFloat(row[1], 64)
+			if err != nil {
+				continue
+			}
+			n++
+			total += v
+			if v > peak {
+				peak = v
+				hottest = row[0]
+			}
+		}
+		if n < 2 || peak < storeImbalanceMinFlow {
+			return nil
+		}
+		ratio := peak / (total / float64(n))
+		if ratio < storeImbalanceMinRatio {
+			return nil
+		}
+		return []Issue{newIssue(50*(ratio-1), hottest,
+			fmt.Sprintf("%s flow %s/s is %.1fx of the average of %d stores", flow, formatBytes(peak), ratio, n))}
+	}
+}
+
+func formatSeconds(v float64) string {
+	return (time.Duration(v * float64(time.Second))).Round(time.Millisecond).String()
+}
+
+func formatBytes(v float64) string {
+	units := []string{"B", "KiB", "MiB", "GiB"}
+	i := 0
+	for v >= 1024 && i < len(units)-1 {
+		v /= 1024
+		i++
+	}
+	return fmt.Sprintf("%.1f %s", v, units[i])
+}
+
+func runAutoDiagnose(db *gorm.DB, rules []autoDiagnoseRule, startTime, endTime string) AutoDiagnoseResponse {
+	resp := AutoDiagnoseResponse{
+		Issues:     []Issue{},
+		RuleErrors: []RuleError{},
+	}
+	for _, rule := range rules {
+		rows, err := querySQL(db, fmt.Sprintf(rule.sql, startTime, endTime))
+		if err != nil {
+			resp.RuleErrors = append(resp.RuleErrors, RuleError{Rule: rule.name, Error: err.Error()})
+			continue
+		}
+		for _, issue := range rule.evaluate(rows) {
+			issue.Rule = rule.name
+			issue.Link = rule.link
+			resp.Issues = append(resp.Issues, issue)
+		}
+	}
+	sort.SliceStable(resp.Issues, func(i, j int) bool {
+		return resp.Issues[i].Score > resp.Issues[j].Score
+	})
+	return resp
+}
+
+type AutoDiagnoseRequest struct {
+	StartTime int64 `json:"start_time"`
+	EndTime   int64 `json:"end_time"`
+}
+
+// @Summary Automatic anomaly detection
+// @Description Scan the metrics in the time range for latency jumps, leader drops, disk saturation and hot store imbalance
+// @Produce json
+// @Param request body AutoDiagnoseRequest true "Request body"
+// @Success 200 {object} AutoDiagnoseResponse
+// @Router /diagnose/auto [post]
+// @Security JwtAuth
+// @Failure 400 {object} rest.ErrorResponse
+// @Failure 401 {object} rest.ErrorResponse
+func (s *Service) autoDiagnoseHandler(c *gin.Context) {
+	var req AutoDiagnoseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		rest.Error(c, rest.ErrBadRequest.WrapWithNoMessage(err))
+		return
+	}
+	if req.StartTime <= 0 || req.EndTime <= req.StartTime {
+		rest.Error(c, rest.ErrBadRequest.New("invalid time range"))
+		return
+	}
+
+	startTime := time.Unix(req.StartTime, 0).Format(timeLayout)
+	endTime := time.Unix(req.EndTime, 0).Format(timeLayout)
+	c.JSON(http.StatusOK, runAutoDiagnose(utils.GetTiDBConnection(c), autoDiagnoseRules, startTime, endTime))
+}
@@ -0,0 +1,78 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package diagnose
+
+import (
+	. "github.com/pingcap/check"
+)
+
+var _ = Suite(&testAutoDiagnoseSuite{})
+
+type testAutoDiagnoseSuite struct{}
+
+func (t *testAutoDiagnoseSuite) TestLatencyJump(c *C) {
+	rows := [][]string{
+		{"t1", "tidb-1", "0.01"},
+		{"t1", "tidb-2", "0.01"},
+		{"t2", "tidb-1", "0.01"},
+		{"t2", "tidb-2", "0.02"},
+		{"t3", "tidb-1", "0.8"},
+		{"t3", "tidb-2", "0.03"},
+		{"t4", "tidb-1", "invalid"},
+	}
+	issues := evaluateLatencyJump(rows)
+	c.Assert(issues, HasLen, 1)
+	c.Assert(issues[0].Instance, Equals, "tidb-1")
+	c.Assert(issues[0].Score, Equals, 100.0)
+	c.Assert(issues[0].Severity, Equals, SeverityCritical)
+
+	// Latency below the min peak is ignored.
+	c.Assert(evaluateLatencyJump([][]string{{"t1", "tidb-1", "0.001"}, {"t2", "tidb-1", "0.05"}}), HasLen, 0)
+}
+
+func (t *testAutoDiagnoseSuite) TestLeaderDrop(c *C) {
+	drop, peak := maxDrop([]float64{10, 100, 80, 120, 40, 90})
+	c.Assert(drop, Equals, 80.0)
+	c.Assert(peak, Equals, 120.0)
+
+	rows := [][]string{
+		{"t1", "store-1", "100"},
+		{"t1", "store-2", "100"},
+		{"t1", "store-3", "5"},
+		{"t2", "store-1", "40"},
+		{"t2", "store-2", "90"},
+		{"t2", "store-3", "0"},
+	}
+	issues := evaluateLeaderDrop(rows)
+	c.Assert(issues, HasLen, 1)
+	c.Assert(issues[0].Instance, Equals, "store-1")
+	c.Assert(issues[0].Score, Equals, 60.0)
+	c.Assert(issues[0].Severity, Equals, SeverityWarning)
+}
+
+func (t *testAutoDiagnoseSuite) TestDiskSaturation(c *C) {
+	issues := evaluateDiskSaturation([][]string{
+		{"node-1", "sda", "0.95", "1"},
+		{"node-2", "sda", "0.5", "1"},
+	})
+	c.Assert(issues, HasLen, 1)
+	c.Assert(issues[0].Instance, Equals, "node-1")
+	c.Assert(issues[0].Score, Equals, 95.0)
+}
+
+func (t *testAutoDiagnoseSuite) TestStoreImbalance(c *C) {
+	evaluate := evaluateStoreImbalance("write")
+	issues := evaluate([][]string{
+		{"store-1", "10485760"},
+		{"store-2", "1048576"},
+		{"store-3", "1048576"},
+	})
+	c.Assert(issues, HasLen, 1)
+	c.Assert(issues[0].Instance, Equals, "store-1")
+	c.Assert(issues[0].Score, Equals, 75.0)
+
+	// Balanced, low or single store flows are ignored.
+	c.Assert(evaluate([][]string{{"store-1", "10485760"}, {"store-2", "10485760"}}), HasLen, 0)
+	c.Assert(evaluate([][]string{{"store-1", "1000"}, {"store-2", "10"}}), HasLen, 0)
+	c.Assert(evaluate([][]string{{"store-1", "10485760"}}), HasLen, 0)
+}
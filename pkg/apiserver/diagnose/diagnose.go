@@ -3,11 +3,13 @@
 package diagnose
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/url"
 	"os"
+	"path"
 	"path/filepath"
 	"sync"
 	"time"
@@ -15,8 +17,12 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/goccy/go-graphviz"
 	"github.com/pingcap/log"
+	"go.uber.org/fx"
 	"go.uber.org/zap"
+	"gorm.io/gorm"
 
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/audit"
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/notification"
 	"github.com/pingcap/tidb-dashboard/pkg/apiserver/user"
 	"github.com/pingcap/tidb-dashboard/pkg/apiserver/utils"
 	"github.com/pingcap/tidb-dashboard/pkg/config"
@@ -38,23 +44,48 @@ type Service struct {
 	db         *dbstore.DB
 	tidbClient *tidb.Client
 	fileServer http.Handler
+	notifier   *notification.Service
+
+	encKey *utils.EncKeyFile
+	// now and openSQLConn are replaced in tests.
+	now         func() time.Time
+	openSQLConn func(c credential) (*gorm.DB, error)
 }
 
-func NewService(config *config.Config, tidbClient *tidb.Client, db *dbstore.DB, uiAssetFS http.FileSystem) *Service {
+func NewService(lc fx.Lifecycle, config *config.Config, tidbClient *tidb.Client, db *dbstore.DB, uiAssetFS http.FileSystem, notifier *notification.Service) *Service {
 	err := autoMigrate(db)
 	if err != nil {
 		log.Fatal("Failed to initialize database", zap.Error(err))
 	}
 
-	return &Service{
+	s := &Service{
 		config:     config,
 		db:         db,
 		tidbClient: tidbClient,
 		fileServer: uiserver.Handler(uiAssetFS),
+		notifier:   notifier,
+		encKey:     utils.NewEncKeyFile(path.Join(config.DataDir, "diagnose_schedule_ek.bin")),
+		now:        time.Now,
+	}
+	s.openSQLConn = func(c credential) (*gorm.DB, error) {
+		return tidbClient.OpenSQLConn(c.Username, c.Password)
 	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go s.scheduleLoop(ctx)
+			return nil
+		},
+		OnStop: func(context.Context) error {
+			cancel()
+			return nil
+		},
+	})
+	return s
 }
 
-func RegisterRouter(r *gin.RouterGroup, auth *user.AuthService, s *Service) {
+func RegisterRouter(r *gin.RouterGroup, auth *user.AuthService, a *audit.Service, s *Service) {
 	endpoint := r.Group("/diagnose")
 	endpoint.GET("/reports",
 		auth.MWAuthRequired(),
@@ -69,6 +100,23 @@ func RegisterRouter(r *gin.RouterGroup, auth *user.AuthService, s *Service) {
 		auth.MWAuthRequired(),
 		s.reportStatusHandler)
 
+	endpoint.GET("/schedules",
+		auth.MWAuthRequired(),
+		s.listSchedulesHandler)
+	endpoint.POST("/schedules",
+		auth.MWAuthRequired(),
+		auth.MWRequireWritePriv(),
+		a.MWRecord("diagnose.schedule.create"),
+		s.createScheduleHandler)
+	endpoint.DELETE("/schedules/:id",
+		auth.MWAuthRequired(),
+		auth.MWRequireWritePriv(),
+		a.MWRecord("diagnose.schedule.delete"),
+		s.deleteScheduleHandler)
+	endpoint.GET("/schedules/reports",
+		auth.MWAuthRequired(),
+		s.scheduleReportsHandler)
+
 	endpoint.POST("/metrics_relation/generate", auth.MWAuthRequired(), s.metricsRelationHandler)
 	endpoint.GET("/metrics_relation/view", s.metricsRelationViewHandler)
 
@@ -76,6 +124,11 @@ func RegisterRouter(r *gin.RouterGroup, auth *user.AuthService, s *Service) {
 		auth.MWAuthRequired(),
 		utils.MWConnectTiDB((s.tidbClient)),
 		s.genDiagnosisHandler)
+
+	endpoint.POST("/auto",
+		auth.MWAuthRequired(),
+		utils.MWConnectTiDB(s.tidbClient),
+		s.autoDiagnoseHandler)
 }
 
 func (s *Service) generateMetricsRelation(startTime, endTime time.Time, graphType string) (string, error) {
@@ -226,36 +279,47 @@ func (s *Service) genReportHandler(c *gin.Context) {
 		*compareEndTime = time.Unix(req.CompareEndTime, 0)
 	}
 
-	reportID, err := NewReport(s.db, startTime, endTime, compareStartTime, compareEndTime)
+	reportID, err := NewReport(s.db, 0, startTime, endTime, compareStartTime, compareEndTime)
 	if err != nil {
 		rest.Error(c, err)
 		return
 	}
 
-	db := utils.TakeTiDBConnection(c)
-
-	go func() {
-		defer utils.CloseTiDBConnection(db) //nolint:errcheck
-
-		var tables []*TableDef
-		if compareStartTime == nil || compareEndTime == nil {
-			tables = GetReportTablesForDisplay(startTime.Format(timeLayout), endTime.Format(timeLayout), db, s.db, reportID)
-		} else {
-			tables = GetCompareReportTablesForDisplay(
-				compareStartTime.Format(timeLayout), compareEndTime.Format(timeLayout),
-				startTime.Format(timeLayout), endTime.Format(timeLayout),
-				db, s.db, reportID)
-		}
-		_ = UpdateReportProgress(s.db, reportID, 100)
-		content, err := json.Marshal(tables)
-		if err == nil {
-			_ = SaveReportContent(s.db, reportID, string(content))
-		}
-	}()
+	go s.generateReport(utils.TakeTiDBConnection(c), reportID, startTime, endTime, compareStartTime, compareEndTime)
 
 	c.JSON(http.StatusOK, reportID)
 }
 
+// generateReport fills the content of the report, which takes a while. The connection is closed when done.
+func (s *Service) generateReport(db *gorm.DB, reportID string, startTime, endTime time.Time, compareStartTime, compareEndTime *time.Time) {
+	defer utils.CloseTiDBConnection(db) //nolint:errcheck
+
+	var tables []*TableDef
+	if compareStartTime == nil || compareEndTime == nil {
+		tables = GetReportTablesForDisplay(startTime.Format(timeLayout), endTime.Format(timeLayout), db, s.db, reportID)
+	} else {
+		tables = GetCompareReportTablesForDisplay(
+			compareStartTime.Format(timeLayout), compareEndTime.Format(timeLayout),
+			startTime.Format(timeLayout), endTime.Format(timeLayout),
+			db, s.db, reportID)
+	}
+	_ = UpdateReportProgress(s.db, reportID, 100)
+	content, err := json.Marshal(tables)
+	if err == nil {
+		_ = SaveReportContent(s.db, reportID, string(content))
+	}
+	s.notifier.Publish(notification.Event{
+		Type:    notification.EventReportFinished,
+		Title:   "Diagnosis report generated",
+		Message: fmt.Sprintf("Diagnosis report %s from %s to %s is generated.", reportID, startTime.Format(timeLayout), endTime.Format(timeLayout)),
+		Details: map[string]interface{}{
+			"report_id":  reportID,
+			"start_time": startTime.Unix(),
+			"end_time":   endTime.Unix(),
+		},
+	})
+}
+
 // @Summary Diagnosis report status
 // @Description Get diagnosis report status
 // @Param id path string true "report id"
@@ -19,6 +19,8 @@ type Report struct {
 	EndTime          time.Time  `json:"end_time"`
 	CompareStartTime *time.Time `json:"compare_start_time"`
 	CompareEndTime   *time.Time `json:"compare_end_time"`
+	// ScheduleID is the schedule generating the report, or 0 for reports generated manually.
+	ScheduleID uint `gorm:"index" json:"schedule_id,omitempty"`
 }
 
 func (Report) TableName() string {
@@ -26,13 +28,14 @@ func (Report) TableName() string {
 }
 
 func autoMigrate(db *dbstore.DB) error {
-	return db.AutoMigrate(&Report{})
+	return db.AutoMigrate(&Report{}, &Schedule{})
 }
 
-func NewReport(db *dbstore.DB, startTime, endTime time.Time, compareStartTime, compareEndTime *time.Time) (string, error) {
+func NewReport(db *dbstore.DB, scheduleID uint, startTime, endTime time.Time, compareStartTime, compareEndTime *time.Time) (string, error) {
 	report := Report{
 		ID:               uuid.New().String(),
 		CreatedAt:        time.Now(),
+		ScheduleID:       scheduleID,
 		StartTime:        startTime,
 		EndTime:          endTime,
 		CompareStartTime: compareStartTime,
@@ -48,7 +51,7 @@ func NewReport(db *dbstore.DB, startTime, endTime time.Time, compareStartTime, c
 func GetReports(db *dbstore.DB) ([]Report, error) {
 	var reports []Report
 	err := db.
-		Select("id, created_at, progress, start_time, end_time, compare_start_time, compare_end_time").
+		Select("id, created_at, progress, start_time, end_time, compare_start_time, compare_end_time, schedule_id").
 		Order("created_at desc").
 		Find(&reports).Error
 	return reports, err
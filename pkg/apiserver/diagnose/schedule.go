@@ -0,0 +1,386 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package diagnose
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gtank/cryptopasta"
+	"github.com/pingcap/log"
+	"go.uber.org/zap"
+
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/utils"
+	"github.com/pingcap/tidb-dashboard/util/rest"
+)
+
+const (
+	scheduleCheckInterval = time.Minute
+	// maxScheduleWindow is the max time range of a scheduled report, since a report of a long range is slow.
+	maxScheduleWindow = 7 * 24 * time.Hour
+)
+
+// Schedule generates reports of a rolling window periodically, e.g. the last day at 8:00 every day.
+type Schedule struct {
+	ID   uint   `gorm:"primary_key" json:"id"`
+	Name string `json:"name"`
+	// Cron is in the standard 5 fields cron format in the time zone of the dashboard, e.g. `0 8 * * 1` or `@daily`.
+	Cron string `json:"cron"`
+	// WindowSecs is the length of the time range of each report, which ends at the scheduled time.
+	WindowSecs int64 `json:"window_secs"`
+	// CompareWithPrevious compares the time range with the previous one of the same length in each report.
+	CompareWithPrevious bool `json:"compare_with_previous"`
+	// MaxReports and MaxAgeDays are the retention policy of reports of the schedule. Zero means unlimited.
+	MaxReports int       `json:"max_reports"`
+	MaxAgeDays int       `json:"max_age_days"`
+	CreatedBy  string    `json:"created_by"`
+	CreatedAt  time.Time `json:"created_at"`
+	NextRunAt  time.Time `json:"next_run_at"`
+	// LastRunAt and LastError are about the last scheduled time, whose error is empty when the report is generated.
+	LastRunAt *time.Time `json:"last_run_at"`
+	LastError string     `json:"last_error"`
+
+	// EncryptedCredential is the SQL user of the creator, which is used to generate reports in background.
+	EncryptedCredential []byte `json:"-"`
+}
+
+func (Schedule) TableName() string {
+	return "diagnose_schedules"
+}
+
+// ScheduleReports are the reports generated by a schedule, latest first.
+type ScheduleReports struct {
+	// Schedule is empty for reports generated manually or by deleted schedules.
+	Schedule *Schedule `json:"schedule"`
+	Reports  []Report  `json:"reports"`
+}
+
+type credential struct {
+	Username string
+	Password string
+}
+
+// CreateSchedule creates a schedule generating reports with the SQL user of the session.
+func (s *Service) CreateSchedule(u *utils.SessionUser, sc *Schedule) error {
+	if !u.HasTiDBAuth {
+		return rest.ErrBadRequest.New("schedules can only be created by sessions signed in with a SQL user")
+	}
+	if sc.Name == "" {
+		return rest.ErrBadRequest.New("name is required")
+	}
+	cron, err := parseCron(sc.Cron)
+	if err != nil {
+		return rest.ErrBadRequest.Wrap(err, "invalid cron")
+	}
+	if sc.WindowSecs <= 0 || time.Duration(sc.WindowSecs)*time.Second > maxScheduleWindow {
+		return rest.ErrBadRequest.New("window must be positive and at most %s", maxScheduleWindow)
+	}
+	if sc.MaxReports < 0 || sc.MaxAgeDays < 0 {
+		return rest.ErrBadRequest.New("retention must not be negative")
+	}
+	now := s.now()
+	next := cron.next(now)
+	if next.IsZero() {
+		return rest.ErrBadRequest.New("cron %s is never matched", sc.Cron)
+	}
+
+	key, err := s.encKey.GetOrCreate()
+	if err != nil {
+		return err
+	}
+	plain, err := json.Marshal(credential{Username: u.TiDBUsername, Password: u.TiDBPassword})
+	if err != nil {
+		return err
+	}
+	encrypted, err := cryptopasta.Encrypt(plain, key)
+	if err != nil {
+		return err
+	}
+
+	sc.ID = 0
+	sc.CreatedBy = u.DisplayName
+	sc.CreatedAt = now
+	sc.NextRunAt = next
+	sc.LastRunAt = nil
+	sc.LastError = ""
+	sc.EncryptedCredential = encrypted
+	return s.db.Create(sc).Error
+}
+
+func (s *Service) ListSchedules() ([]Schedule, error) {
+	var schedules []Schedule
+	if err := s.db.Order("id").Find(&schedules).Error; err != nil {
+		return nil, err
+	}
+	return schedules, nil
+}
+
+// DeleteSchedule deletes a schedule. Its reports are kept until removed by the storage manager. It returns false if
+// the schedule does not exist.
+func (s *Service) DeleteSchedule(id uint) (bool, error) {
+	result := s.db.Where("id = ?", id).Delete(&Schedule{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// GroupReportsBySchedule returns reports of each schedule ordered by the schedule ID, followed by other reports.
+func (s *Service) GroupReportsBySchedule() ([]ScheduleReports, error) {
+	schedules, err := s.ListSchedules()
+	if err != nil {
+		return nil, err
+	}
+	reports, err := GetReports(s.db)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make([]ScheduleReports, 0, len(schedules)+1)
+	index := make(map[uint]int, len(schedules))
+	for i := range schedules {
+		index[schedules[i].ID] = len(groups)
+		groups = append(groups, ScheduleReports{Schedule: &schedules[i], Reports: []Report{}})
+	}
+	others := ScheduleReports{Reports: []Report{}}
+	for _, r := range reports {
+		if i, ok := index[r.ScheduleID]; ok && r.ScheduleID != 0 {
+			groups[i].Reports = append(groups[i].Reports, r)
+		} else {
+			others.Reports = append(others.Reports, r)
+		}
+	}
+	return append(groups, others), nil
+}
+
+func (s *Service) scheduleLoop(ctx context.Context) {
+	ticker := time.NewTicker(scheduleCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.runDueSchedules()
+		}
+	}
+}
+
+// runDueSchedules generates reports of schedules whose time has come. Reports are generated one by one, so that the
+// cluster is not overloaded by schedules of the same time.
+func (s *Service) runDueSchedules() {
+	var schedules []Schedule
+	if err := s.db.Where("next_run_at <= ?", s.now()).Order("next_run_at").Find(&schedules).Error; err != nil {
+		log.Warn("Failed to list diagnosis report schedules", zap.Error(err))
+		return
+	}
+	for i := range schedules {
+		sc := &schedules[i]
+		runAt := sc.NextRunAt
+		runErr := s.runSchedule(sc, runAt)
+		if runErr != nil {
+			log.Warn("Failed to generate scheduled diagnosis report", zap.Uint("id", sc.ID), zap.Error(runErr))
+		}
+		if err := s.updateScheduleRun(sc, runAt, runErr); err != nil {
+			log.Warn("Failed to update diagnosis report schedule", zap.Uint("id", sc.ID), zap.Error(err))
+		}
+		if err := s.applyRetention(sc); err != nil {
+			log.Warn("Failed to remove expired scheduled diagnosis reports", zap.Uint("id", sc.ID), zap.Error(err))
+		}
+	}
+}
+
+// runSchedule generates the report of the window ending at the scheduled time.
+func (s *Service) runSchedule(sc *Schedule, runAt time.Time) error {
+	key, err := s.encKey.GetOrCreate()
+	if err != nil {
+		return err
+	}
+	plain, err := cryptopasta.Decrypt(sc.EncryptedCredential, key)
+	if err != nil {
+		return err
+	}
+	var cred credential
+	if err := json.Unmarshal(plain, &cred); err != nil {
+		return err
+	}
+	db, err := s.openSQLConn(cred)
+	if err != nil {
+		return err
+	}
+
+	window := time.Duration(sc.WindowSecs) * time.Second
+	endTime := runAt
+	startTime := endTime.Add(-window)
+	var compareStartTime, compareEndTime *time.Time
+	if sc.CompareWithPrevious {
+		compareStartTime = new(time.Time)
+		compareEndTime = new(time.Time)
+		*compareStartTime = startTime.Add(-window)
+		*compareEndTime = startTime
+	}
+	reportID, err := NewReport(s.db, sc.ID, startTime, endTime, compareStartTime, compareEndTime)
+	if err != nil {
+		_ = utils.CloseTiDBConnection(db)
+		return err
+	}
+	s.generateReport(db, reportID, startTime, endTime, compareStartTime, compareEndTime)
+	return nil
+}
+
+// updateScheduleRun records the run and schedules the next run. Runs missed, e.g. when the dashboard is stopped, are
+// skipped except the last one.
+func (s *Service) updateScheduleRun(sc *Schedule, runAt time.Time, runErr error) error {
+	lastError := ""
+	if runErr != nil {
+		lastError = runErr.Error()
+	}
+	updates := map[string]interface{}{
+		"last_run_at": runAt,
+		"last_error":  lastError,
+	}
+	if cron, err := parseCron(sc.Cron); err == nil {
+		updates["next_run_at"] = cron.next(s.now())
+	}
+	return s.db.Model(&Schedule{}).Where("id = ?", sc.ID).Updates(updates).Error
+}
+
+// applyRetention removes reports of the schedule exceeding its retention policy.
+func (s *Service) applyRetention(sc *Schedule) error {
+	if sc.MaxAgeDays > 0 {
+		deadline := s.now().Add(-time.Duration(sc.MaxAgeDays) * 24 * time.Hour)
+		err := s.db.Where("schedule_id = ? AND created_at < ?", sc.ID, deadline).Delete(&Report{}).Error
+		if err != nil {
+			return err
+		}
+	}
+	if sc.MaxReports > 0 {
+		var expired []string
+		err := s.db.Model(&Report{}).
+			Where("schedule_id = ?", sc.ID).
+			Order("created_at desc").
+			Offset(sc.MaxReports).
+			Limit(-1).
+			Pluck("id", &expired).Error
+		if err != nil {
+			return err
+		}
+		if len(expired) > 0 {
+			return s.db.Where("id IN ?", expired).Delete(&Report{}).Error
+		}
+	}
+	return nil
+}
+
+func parseScheduleID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		rest.Error(c, rest.ErrBadRequest.New("invalid id %s", c.Param("id")))
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// @ID diagnoseSchedulesGet
+// @Summary List schedules of diagnosis reports
+// @Success 200 {array} Schedule
+// @Router /diagnose/schedules [get]
+// @Security JwtAuth
+// @Failure 401 {object} rest.ErrorResponse
+func (s *Service) listSchedulesHandler(c *gin.Context) {
+	schedules, err := s.ListSchedules()
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, schedules)
+}
+
+type CreateScheduleRequest struct {
+	Name                string `json:"name" binding:"required"`
+	Cron                string `json:"cron" binding:"required"`
+	WindowSecs          int64  `json:"window_secs" binding:"required"`
+	CompareWithPrevious bool   `json:"compare_with_previous"`
+	MaxReports          int    `json:"max_reports"`
+	MaxAgeDays          int    `json:"max_age_days"`
+}
+
+// @ID diagnoseSchedulesPost
+// @Summary Create a schedule of diagnosis reports
+// @Description Reports are generated with the SQL user of the current session. Each report covers the window
+// @Description ending at the scheduled time.
+// @Param request body CreateScheduleRequest true "Request body"
+// @Success 200 {object} Schedule
+// @Router /diagnose/schedules [post]
+// @Security JwtAuth
+// @Failure 400 {object} rest.ErrorResponse
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 403 {object} rest.ErrorResponse
+func (s *Service) createScheduleHandler(c *gin.Context) {
+	var req CreateScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		rest.Error(c, rest.ErrBadRequest.NewWithNoMessage())
+		return
+	}
+	sc := &Schedule{
+		Name:                req.Name,
+		Cron:                req.Cron,
+		WindowSecs:          req.WindowSecs,
+		CompareWithPrevious: req.CompareWithPrevious,
+		MaxReports:          req.MaxReports,
+		MaxAgeDays:          req.MaxAgeDays,
+	}
+	if err := s.CreateSchedule(utils.GetSession(c), sc); err != nil {
+		rest.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, sc)
+}
+
+// @ID diagnoseSchedulesIdDelete
+// @Summary Delete a schedule of diagnosis reports
+// @Description Reports generated by the schedule are kept.
+// @Param id path integer true "Schedule ID"
+// @Success 200 {string} string
+// @Router /diagnose/schedules/{id} [delete]
+// @Security JwtAuth
+// @Failure 400 {object} rest.ErrorResponse
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 403 {object} rest.ErrorResponse
+// @Failure 404 {object} rest.ErrorResponse
+func (s *Service) deleteScheduleHandler(c *gin.Context) {
+	id, ok := parseScheduleID(c)
+	if !ok {
+		return
+	}
+	found, err := s.DeleteSchedule(id)
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	if !found {
+		rest.Error(c, rest.ErrNotFound.New("schedule %d not found", id))
+		return
+	}
+	c.JSON(http.StatusOK, nil)
+}
+
+// @ID diagnoseSchedulesReportsGet
+// @Summary List diagnosis reports grouped by schedules
+// @Description Reports generated manually or by deleted schedules are in the last group without a schedule.
+// @Success 200 {array} ScheduleReports
+// @Router /diagnose/schedules/reports [get]
+// @Security JwtAuth
+// @Failure 401 {object} rest.ErrorResponse
+func (s *Service) scheduleReportsHandler(c *gin.Context) {
+	groups, err := s.GroupReportsBySchedule()
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, groups)
+}
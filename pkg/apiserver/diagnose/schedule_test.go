@@ -0,0 +1,151 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package diagnose
+
+import (
+	"errors"
+	"path"
+	"time"
+
+	. "github.com/pingcap/check"
+	"gorm.io/gorm"
+
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/utils"
+	"github.com/pingcap/tidb-dashboard/pkg/dbstore/dbstoretest"
+)
+
+var _ = Suite(&testScheduleSuite{})
+
+type testScheduleSuite struct{}
+
+func (t *testScheduleSuite) TestParseCron(c *C) {
+	for _, spec := range []string{"* * * * *", "*/15 8-18 * * 1-5", "0 0 1,15 * *", "5/10 * * * 7", "@weekly"} {
+		_, err := parseCron(spec)
+		c.Assert(err, IsNil, Commentf("%s", spec))
+	}
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "*/0 * * * *", "5-1 * * * *", "a * * * *"} {
+		_, err := parseCron(spec)
+		c.Assert(err, NotNil, Commentf("%s", spec))
+	}
+}
+
+func (t *testScheduleSuite) TestCronNext(c *C) {
+	// 2024-03-15 is a Friday.
+	now := time.Date(2024, 3, 15, 10, 30, 20, 0, time.UTC)
+	cases := []struct {
+		spec string
+		next time.Time
+	}{
+		{"* * * * *", time.Date(2024, 3, 15, 10, 31, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2024, 3, 15, 10, 45, 0, 0, time.UTC)},
+		{"@daily", time.Date(2024, 3, 16, 0, 0, 0, 0, time.UTC)},
+		{"0 8 * * 1", time.Date(2024, 3, 18, 8, 0, 0, 0, time.UTC)},
+		{"0 8 * * 7", time.Date(2024, 3, 17, 8, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		// Either day of month or day of week is matched when both are restricted.
+		{"0 0 20 * 6", time.Date(2024, 3, 16, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, cs := range cases {
+		s, err := parseCron(cs.spec)
+		c.Assert(err, IsNil)
+		c.Assert(s.next(now), Equals, cs.next, Commentf("%s", cs.spec))
+	}
+
+	s, err := parseCron("0 0 30 2 *")
+	c.Assert(err, IsNil)
+	c.Assert(s.next(now).IsZero(), IsTrue)
+}
+
+func newTestScheduleService(c *C, now time.Time) *Service {
+	dir := c.MkDir()
+	db, err := dbstoretest.Open(dir)
+	c.Assert(err, IsNil)
+	c.Assert(autoMigrate(db), IsNil)
+	return &Service{
+		db:     db,
+		encKey: utils.NewEncKeyFile(path.Join(dir, "ek.bin")),
+		now:    func() time.Time { return now },
+		openSQLConn: func(credential) (*gorm.DB, error) {
+			return nil, errors.New("tidb is unavailable")
+		},
+	}
+}
+
+func (t *testScheduleSuite) TestCreateAndRunSchedule(c *C) {
+	now := time.Date(2024, 3, 15, 10, 30, 0, 0, time.Local)
+	s := newTestScheduleService(c, now)
+	u := &utils.SessionUser{HasTiDBAuth: true, TiDBUsername: "root", DisplayName: "root"}
+
+	c.Assert(s.CreateSchedule(&utils.SessionUser{}, &Schedule{Name: "daily", Cron: "@daily", WindowSecs: 3600}), NotNil)
+	c.Assert(s.CreateSchedule(u, &Schedule{Name: "daily", Cron: "@every", WindowSecs: 3600}), NotNil)
+	c.Assert(s.CreateSchedule(u, &Schedule{Name: "daily", Cron: "@daily", WindowSecs: 30 * 24 * 3600}), NotNil)
+
+	sc := &Schedule{Name: "daily", Cron: "@daily", WindowSecs: 24 * 3600}
+	c.Assert(s.CreateSchedule(u, sc), IsNil)
+	c.Assert(sc.NextRunAt.Equal(time.Date(2024, 3, 16, 0, 0, 0, 0, time.Local)), IsTrue)
+	c.Assert(sc.EncryptedCredential, Not(HasLen), 0)
+
+	// Not due yet.
+	s.runDueSchedules()
+	schedules, err := s.ListSchedules()
+	c.Assert(err, IsNil)
+	c.Assert(schedules[0].LastRunAt, IsNil)
+
+	// Errors are recorded, and the next run is scheduled.
+	s.now = func() time.Time { return time.Date(2024, 3, 16, 0, 0, 30, 0, time.Local) }
+	s.runDueSchedules()
+	schedules, err = s.ListSchedules()
+	c.Assert(err, IsNil)
+	c.Assert(schedules[0].LastRunAt.Equal(sc.NextRunAt), IsTrue)
+	c.Assert(schedules[0].LastError, Equals, "tidb is unavailable")
+	c.Assert(schedules[0].NextRunAt.Equal(time.Date(2024, 3, 17, 0, 0, 0, 0, time.Local)), IsTrue)
+
+	found, err := s.DeleteSchedule(sc.ID)
+	c.Assert(err, IsNil)
+	c.Assert(found, IsTrue)
+	found, err = s.DeleteSchedule(sc.ID)
+	c.Assert(err, IsNil)
+	c.Assert(found, IsFalse)
+}
+
+func (t *testScheduleSuite) TestRetentionAndGroups(c *C) {
+	now := time.Date(2024, 3, 15, 10, 30, 0, 0, time.Local)
+	s := newTestScheduleService(c, now)
+	c.Assert(s.db.Create(&Schedule{ID: 1, Name: "a", Cron: "@daily", MaxReports: 2, MaxAgeDays: 3}).Error, IsNil)
+	c.Assert(s.db.Create(&Schedule{ID: 2, Name: "b", Cron: "@daily"}).Error, IsNil)
+	for i, r := range []Report{
+		{ID: "a1", ScheduleID: 1, CreatedAt: now.AddDate(0, 0, -5)},
+		{ID: "a2", ScheduleID: 1, CreatedAt: now.AddDate(0, 0, -2)},
+		{ID: "a3", ScheduleID: 1, CreatedAt: now.AddDate(0, 0, -1)},
+		{ID: "a4", ScheduleID: 1, CreatedAt: now},
+		{ID: "b1", ScheduleID: 2, CreatedAt: now.AddDate(0, 0, -5)},
+		{ID: "deleted", ScheduleID: 3, CreatedAt: now},
+		{ID: "manual", CreatedAt: now.Add(-time.Hour)},
+	} {
+		r := r
+		c.Assert(s.db.Create(&r).Error, IsNil, Commentf("%d", i))
+	}
+
+	schedules, err := s.ListSchedules()
+	c.Assert(err, IsNil)
+	for i := range schedules {
+		c.Assert(s.applyRetention(&schedules[i]), IsNil)
+	}
+
+	groups, err := s.GroupReportsBySchedule()
+	c.Assert(err, IsNil)
+	c.Assert(groups, HasLen, 3)
+	ids := func(g ScheduleReports) []string {
+		var result []string
+		for _, r := range g.Reports {
+			result = append(result, r.ID)
+		}
+		return result
+	}
+	c.Assert(groups[0].Schedule.ID, Equals, uint(1))
+	c.Assert(ids(groups[0]), DeepEquals, []string{"a4", "a3"})
+	c.Assert(ids(groups[1]), DeepEquals, []string{"b1"})
+	c.Assert(groups[2].Schedule, IsNil)
+	c.Assert(ids(groups[2]), DeepEquals, []string{"deleted", "manual"})
+}
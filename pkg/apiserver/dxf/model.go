@@ -0,0 +1,46 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package dxf
+
+import "time"
+
+const (
+	GlobalTaskTable        = "mysql.tidb_global_task"
+	GlobalTaskHistoryTable = "mysql.tidb_global_task_history"
+	SubtaskTable           = "mysql.tidb_background_subtask"
+	SubtaskHistoryTable    = "mysql.tidb_background_subtask_history"
+)
+
+// Task is a task of the distributed execution framework, e.g. IMPORT INTO or adding indexes with acceleration.
+type Task struct {
+	ID              int64      `gorm:"column:id" json:"id"`
+	TaskKey         string     `gorm:"column:task_key" json:"task_key"`
+	Type            string     `gorm:"column:type" json:"type"`
+	State           string     `gorm:"column:state" json:"state"`
+	Step            int64      `gorm:"column:step" json:"step"`
+	Concurrency     int64      `gorm:"column:concurrency" json:"concurrency"`
+	StartTime       *time.Time `gorm:"column:start_time" json:"start_time"`
+	StateUpdateTime *time.Time `gorm:"column:state_update_time" json:"state_update_time"`
+	Error           string     `gorm:"column:error" json:"error"`
+}
+
+type Subtask struct {
+	ID     int64  `gorm:"column:id" json:"id"`
+	Step   int64  `gorm:"column:step" json:"step"`
+	ExecID string `gorm:"column:exec_id" json:"exec_id"` // The TiDB node executing the subtask
+	State  string `gorm:"column:state" json:"state"`
+	// Subtask times are stored as unix seconds.
+	StartTime       int64  `gorm:"column:start_time" json:"start_time"`
+	StateUpdateTime int64  `gorm:"column:state_update_time" json:"state_update_time"`
+	Summary         string `gorm:"column:summary" json:"summary"` // Progress reported by the executor in JSON, e.g. row count
+	Error           string `gorm:"column:error" json:"error"`
+}
+
+type TaskDetail struct {
+	Task
+	Subtasks []Subtask `json:"subtasks"`
+	// SubtaskStates is the number of subtasks in each state.
+	SubtaskStates map[string]int `json:"subtask_states"`
+	// Nodes are the TiDB nodes executing the subtasks.
+	Nodes []string `json:"nodes"`
+}
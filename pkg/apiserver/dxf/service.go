@@ -0,0 +1,239 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package dxf
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/joomcode/errorx"
+	"go.uber.org/fx"
+	"gorm.io/gorm"
+
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/audit"
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/user"
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/utils"
+	"github.com/pingcap/tidb-dashboard/pkg/tidb"
+	"github.com/pingcap/tidb-dashboard/util/rest"
+)
+
+const (
+	defaultListLimit = 100
+
+	taskColumns    = "id, task_key, type, state, step, concurrency, start_time, state_update_time, error"
+	subtaskColumns = "id, step, exec_id, state, start_time, state_update_time, summary, error"
+)
+
+var (
+	ErrNS           = errorx.NewNamespace("error.api.dxf")
+	ErrQueryFailed  = ErrNS.NewType("query_failed")
+	ErrCancelFailed = ErrNS.NewType("cancel_failed")
+)
+
+// finishedStates are the states of tasks no longer running.
+var finishedStates = []string{"succeed", "failed", "reverted", "cancelled"}
+
+type ServiceParams struct {
+	fx.In
+	TiDBClient *tidb.Client
+}
+
+type Service struct {
+	params ServiceParams
+}
+
+func newService(p ServiceParams) *Service {
+	return &Service{params: p}
+}
+
+func registerRouter(r *gin.RouterGroup, auth *user.AuthService, a *audit.Service, s *Service) {
+	endpoint := r.Group("/dxf")
+	endpoint.Use(
+		auth.MWAuthRequired(),
+		utils.MWConnectTiDB(s.params.TiDBClient),
+	)
+	{
+		endpoint.GET("/tasks", s.getTasks)
+		endpoint.GET("/tasks/:id", s.getTask)
+		endpoint.POST("/tasks/:id/cancel", auth.MWRequireWritePriv(), a.MWRecord("dxf.cancel"), s.cancelTask)
+	}
+}
+
+type GetTasksRequest struct {
+	// History lists finished tasks kept in the history table when true.
+	History bool `json:"history" form:"history"`
+	Limit   int  `json:"limit" form:"limit"`
+}
+
+// @ID dxfGetTasks
+// @Summary List distributed execution tasks, latest first
+// @Param q query GetTasksRequest true "Query"
+// @Success 200 {array} Task
+// @Router /dxf/tasks [get]
+// @Security JwtAuth
+// @Failure 400 {object} rest.ErrorResponse
+// @Failure 401 {object} rest.ErrorResponse
+func (s *Service) getTasks(c *gin.Context) {
+	var req GetTasksRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		rest.Error(c, rest.ErrBadRequest.NewWithNoMessage())
+		return
+	}
+	if req.Limit <= 0 {
+		req.Limit = defaultListLimit
+	}
+	table := GlobalTaskTable
+	if req.History {
+		table = GlobalTaskHistoryTable
+	}
+
+	tasks := make([]Task, 0)
+	err := utils.GetTiDBConnection(c).
+		Table(table).
+		Select(taskColumns).
+		Order("id DESC").
+		Limit(req.Limit).
+		Find(&tasks).Error
+	if err != nil {
+		rest.Error(c, ErrQueryFailed.WrapWithNoMessage(err))
+		return
+	}
+	c.JSON(http.StatusOK, tasks)
+}
+
+func parseTaskID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		rest.Error(c, rest.ErrBadRequest.New("invalid task id"))
+		return 0, false
+	}
+	return id, true
+}
+
+// findTask finds the task in the task table, then the history table. Subtasks are also read from the
+// corresponding table.
+func findTask(db *gorm.DB, id int64, withSubtasks bool) (*TaskDetail, error) {
+	for _, tables := range [][2]string{{GlobalTaskTable, SubtaskTable}, {GlobalTaskHistoryTable, SubtaskHistoryTable}} {
+		tasks := make([]Task, 0)
+		if err := db.Table(tables[0]).Select(taskColumns).Where("id = ?", id).Find(&tasks).Error; err != nil {
+			return nil, ErrQueryFailed.WrapWithNoMessage(err)
+		}
+		if len(tasks) == 0 {
+			continue
+		}
+		detail := &TaskDetail{Task: tasks[0]}
+		if !withSubtasks {
+			return detail, nil
+		}
+		subtasks := make([]Subtask, 0)
+		// `task_key` of subtasks is the ID of the task.
+		err := db.Table(tables[1]).Select(subtaskColumns).Where("task_key = ?", strconv.FormatInt(id, 10)).Order("id").Find(&subtasks).Error
+		if err != nil {
+			return nil, ErrQueryFailed.WrapWithNoMessage(err)
+		}
+		detail.fillSubtasks(subtasks)
+		return detail, nil
+	}
+	return nil, rest.ErrNotFound.New("task %d not found", id)
+}
+
+func (d *TaskDetail) fillSubtasks(subtasks []Subtask) {
+	d.Subtasks = subtasks
+	d.SubtaskStates = make(map[string]int)
+	nodes := make(map[string]struct{})
+	for _, st := range subtasks {
+		d.SubtaskStates[st.State]++
+		if st.ExecID != "" {
+			nodes[st.ExecID] = struct{}{}
+		}
+	}
+	d.Nodes = make([]string, 0, len(nodes))
+	for node := range nodes {
+		d.Nodes = append(d.Nodes, node)
+	}
+	sort.Strings(d.Nodes)
+}
+
+// @ID dxfGetTask
+// @Summary Get a distributed execution task with its subtasks
+// @Param id path int true "task ID"
+// @Success 200 {object} TaskDetail
+// @Router /dxf/tasks/{id} [get]
+// @Security JwtAuth
+// @Failure 400 {object} rest.ErrorResponse
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 404 {object} rest.ErrorResponse
+func (s *Service) getTask(c *gin.Context) {
+	id, ok := parseTaskID(c)
+	if !ok {
+		return
+	}
+	detail, err := findTask(utils.GetTiDBConnection(c), id, true)
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, detail)
+}
+
+// cancelStatement returns the statement to cancel the task. Tasks are cancelled through the job which
+// submits the task, whose ID is the suffix of the task key.
+func cancelStatement(task Task) (string, error) {
+	idx := strings.LastIndex(task.TaskKey, "/")
+	jobID, err := strconv.ParseInt(task.TaskKey[idx+1:], 10, 64)
+	if err != nil {
+		return "", rest.ErrBadRequest.New("unrecognized task key %s", task.TaskKey)
+	}
+	// Statements below do not support placeholders. The id is an integer, so that it is safe to interpolate.
+	switch {
+	case task.Type == "ImportInto":
+		return fmt.Sprintf("CANCEL IMPORT JOB %d", jobID), nil
+	case strings.HasPrefix(task.TaskKey, "ddl/"):
+		return fmt.Sprintf("ADMIN CANCEL DDL JOBS %d", jobID), nil
+	default:
+		return "", rest.ErrBadRequest.New("task of type %s cannot be cancelled", task.Type)
+	}
+}
+
+// @ID dxfCancelTask
+// @Summary Cancel a running distributed execution task
+// @Param id path int true "task ID"
+// @Success 200 {string} string
+// @Router /dxf/tasks/{id}/cancel [post]
+// @Security JwtAuth
+// @Failure 400 {object} rest.ErrorResponse
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 403 {object} rest.ErrorResponse
+// @Failure 404 {object} rest.ErrorResponse
+func (s *Service) cancelTask(c *gin.Context) {
+	id, ok := parseTaskID(c)
+	if !ok {
+		return
+	}
+	db := utils.GetTiDBConnection(c)
+	detail, err := findTask(db, id, false)
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	for _, state := range finishedStates {
+		if detail.State == state {
+			rest.Error(c, rest.ErrBadRequest.New("task %d is already %s", id, state))
+			return
+		}
+	}
+	stmt, err := cancelStatement(detail.Task)
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	if err := db.Exec(stmt).Error; err != nil {
+		rest.Error(c, ErrCancelFailed.WrapWithNoMessage(err))
+		return
+	}
+	c.JSON(http.StatusOK, nil)
+}
@@ -0,0 +1,37 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package dxf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCancelStatement(t *testing.T) {
+	stmt, err := cancelStatement(Task{Type: "ImportInto", TaskKey: "ImportInto/12"})
+	require.NoError(t, err)
+	require.Equal(t, "CANCEL IMPORT JOB 12", stmt)
+
+	stmt, err = cancelStatement(Task{Type: "backfill", TaskKey: "ddl/backfill/108"})
+	require.NoError(t, err)
+	require.Equal(t, "ADMIN CANCEL DDL JOBS 108", stmt)
+
+	_, err = cancelStatement(Task{Type: "example", TaskKey: "example/1"})
+	require.Error(t, err)
+	_, err = cancelStatement(Task{Type: "ImportInto", TaskKey: "ImportInto/1; DROP TABLE t"})
+	require.Error(t, err)
+}
+
+func TestFillSubtasks(t *testing.T) {
+	d := &TaskDetail{}
+	d.fillSubtasks([]Subtask{
+		{ID: 1, ExecID: "10.0.1.2:4000", State: "succeed"},
+		{ID: 2, ExecID: "10.0.1.1:4000", State: "running"},
+		{ID: 3, ExecID: "10.0.1.2:4000", State: "running"},
+		{ID: 4, State: "pending"},
+	})
+	require.Equal(t, []string{"10.0.1.1:4000", "10.0.1.2:4000"}, d.Nodes)
+	require.Equal(t, map[string]int{"succeed": 1, "running": 2, "pending": 1}, d.SubtaskStates)
+	require.Len(t, d.Subtasks, 4)
+}
@@ -0,0 +1,98 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package hotregion
+
+import (
+	"time"
+
+	"github.com/pingcap/tidb-dashboard/pkg/dbstore"
+)
+
+const (
+	TypeRead  = "read"
+	TypeWrite = "write"
+)
+
+// HotRegionModel is a hot peer of a region reported by PD at a sample time.
+type HotRegionModel struct {
+	ID       uint      `json:"-" gorm:"primary_key"`
+	Time     time.Time `json:"time" gorm:"index"`
+	Type     string    `json:"type"`
+	RegionID uint64    `json:"region_id"`
+	StoreID  uint64    `json:"store_id" gorm:"index"`
+	IsLeader bool      `json:"is_leader"`
+	// TableID is the physical table ID decoded from the start key of the region, or 0 if the region
+	// does not start inside a table.
+	TableID   int64   `json:"table_id" gorm:"index"`
+	HotDegree int     `json:"hot_degree"`
+	FlowBytes float64 `json:"flow_bytes"`
+	FlowKeys  float64 `json:"flow_keys"`
+	FlowQuery float64 `json:"flow_query"`
+}
+
+func (HotRegionModel) TableName() string {
+	return "hot_region_history"
+}
+
+func autoMigrate(db *dbstore.DB) error {
+	return db.AutoMigrate(&HotRegionModel{})
+}
+
+type historyFilter struct {
+	BeginTime time.Time
+	EndTime   time.Time
+	Type      string
+	StoreID   uint64
+	// TableIDs filters by any of the physical tables when not empty.
+	TableIDs []int64
+	Limit    int
+}
+
+// queryHistory returns the recorded hot regions matching the filter, latest first.
+func queryHistory(db *dbstore.DB, f historyFilter) ([]HotRegionModel, error) {
+	tx := db.
+		Where("time >= ? AND time <= ?", f.BeginTime, f.EndTime).
+		Order("time DESC, flow_bytes DESC")
+	if f.Type != "" {
+		tx = tx.Where("type = ?", f.Type)
+	}
+	if f.StoreID != 0 {
+		tx = tx.Where("store_id = ?", f.StoreID)
+	}
+	if len(f.TableIDs) > 0 {
+		tx = tx.Where("table_id IN ?", f.TableIDs)
+	}
+	if f.Limit > 0 {
+		tx = tx.Limit(f.Limit)
+	}
+	records := make([]HotRegionModel, 0)
+	err := tx.Find(&records).Error
+	return records, err
+}
+
+func deleteHistoryBefore(db *dbstore.DB, t time.Time) error {
+	return db.Where("time < ?", t).Delete(&HotRegionModel{}).Error
+}
+
+type pdHotRegions struct {
+	AsPeer   map[string]pdHotStoreStat `json:"as_peer"`
+	AsLeader map[string]pdHotStoreStat `json:"as_leader"`
+}
+
+type pdHotStoreStat struct {
+	Stats []pdHotPeerStat `json:"statistics"`
+}
+
+type pdHotPeerStat struct {
+	StoreID   uint64  `json:"store_id"`
+	RegionID  uint64  `json:"region_id"`
+	IsLeader  bool    `json:"is_leader"`
+	HotDegree int     `json:"hot_degree"`
+	FlowBytes float64 `json:"flow_bytes"`
+	FlowKeys  float64 `json:"flow_keys"`
+	FlowQuery float64 `json:"flow_query"`
+}
+
+type pdRegion struct {
+	StartKey string `json:"start_key"`
+}
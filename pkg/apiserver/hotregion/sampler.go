@@ -0,0 +1,115 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package hotregion
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/pingcap/tidb-dashboard/pkg/tidb/model"
+)
+
+// pdGetFunc sends a GET request to the PD API, e.g. pd.Client.SendGetRequest.
+type pdGetFunc func(relativeURI string) ([]byte, error)
+
+// sampleHotRegions fetches current hot read and write regions from PD, as records at the time.
+// Regions are looked up once per sample for their tables.
+func sampleHotRegions(get pdGetFunc, now time.Time) ([]HotRegionModel, error) {
+	tableIDs := make(map[uint64]int64)
+	records := make([]HotRegionModel, 0)
+	for _, typ := range []string{TypeRead, TypeWrite} {
+		data, err := get("/hotspot/regions/" + typ)
+		if err != nil {
+			return nil, err
+		}
+		var resp pdHotRegions
+		if err := json.Unmarshal(data, &resp); err != nil {
+			return nil, ErrPDRequest.Wrap(err, "PD hot regions API unmarshal failed")
+		}
+		for _, stat := range resp.peerStats() {
+			tableID, ok := tableIDs[stat.RegionID]
+			if !ok {
+				// Regions may be merged or split since reported as hot, in which case the table is unknown.
+				tableID, _ = fetchRegionTableID(get, stat.RegionID)
+				tableIDs[stat.RegionID] = tableID
+			}
+			records = append(records, HotRegionModel{
+				Time:      now,
+				Type:      typ,
+				RegionID:  stat.RegionID,
+				StoreID:   stat.StoreID,
+				IsLeader:  stat.IsLeader,
+				TableID:   tableID,
+				HotDegree: stat.HotDegree,
+				FlowBytes: stat.FlowBytes,
+				FlowKeys:  stat.FlowKeys,
+				FlowQuery: stat.FlowQuery,
+			})
+		}
+	}
+	return records, nil
+}
+
+// peerStats returns the hot peers reported either as peers or leaders, each peer only once, ordered by
+// store and region.
+func (h *pdHotRegions) peerStats() []pdHotPeerStat {
+	type peerKey struct{ storeID, regionID uint64 }
+	peers := make(map[peerKey]pdHotPeerStat)
+	for _, stats := range []map[string]pdHotStoreStat{h.AsPeer, h.AsLeader} {
+		for storeKey, s := range stats {
+			storeID, _ := strconv.ParseUint(storeKey, 10, 64)
+			for _, stat := range s.Stats {
+				if stat.StoreID == 0 {
+					stat.StoreID = storeID
+				}
+				k := peerKey{storeID: stat.StoreID, regionID: stat.RegionID}
+				if prev, ok := peers[k]; ok {
+					stat.IsLeader = stat.IsLeader || prev.IsLeader
+				}
+				peers[k] = stat
+			}
+		}
+	}
+	result := make([]pdHotPeerStat, 0, len(peers))
+	for _, stat := range peers {
+		result = append(result, stat)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].StoreID != result[j].StoreID {
+			return result[i].StoreID < result[j].StoreID
+		}
+		return result[i].RegionID < result[j].RegionID
+	})
+	return result
+}
+
+func fetchRegionTableID(get pdGetFunc, regionID uint64) (int64, error) {
+	data, err := get(fmt.Sprintf("/region/id/%d", regionID))
+	if err != nil {
+		return 0, err
+	}
+	var region pdRegion
+	if err := json.Unmarshal(data, &region); err != nil {
+		return 0, ErrPDRequest.Wrap(err, "PD region API unmarshal failed")
+	}
+	return tableIDOfKey(region.StartKey), nil
+}
+
+// tableIDOfKey returns the table ID of an encoded key in hex, or 0 if the key is not inside a table.
+func tableIDOfKey(hexKey string) int64 {
+	key, err := hex.DecodeString(hexKey)
+	if err != nil || len(key) == 0 {
+		return 0
+	}
+	var buf model.KeyInfoBuffer
+	info, err := buf.DecodeKey(key)
+	if err != nil {
+		return 0
+	}
+	_, tableID := info.MetaOrTable()
+	return tableID
+}
@@ -0,0 +1,179 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package hotregion
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/joomcode/errorx"
+	"github.com/pingcap/log"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/cluster"
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/region"
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/user"
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/utils"
+	"github.com/pingcap/tidb-dashboard/pkg/dbstore"
+	"github.com/pingcap/tidb-dashboard/pkg/pd"
+	"github.com/pingcap/tidb-dashboard/pkg/tidb"
+	"github.com/pingcap/tidb-dashboard/util/rest"
+)
+
+const (
+	sampleInterval = time.Minute
+	// Samples older than this are removed after each sample.
+	historyRetention = 7 * 24 * time.Hour
+	insertBatchSize  = 100
+
+	defaultHistoryLimit = 1000
+	maxHistoryLimit     = 10000
+)
+
+var (
+	ErrNS          = errorx.NewNamespace("error.api.hot_region")
+	ErrPDRequest   = ErrNS.NewType("pd_request_failed")
+	ErrQueryFailed = ErrNS.NewType("query_failed")
+)
+
+type ServiceParams struct {
+	fx.In
+	PDClient   *pd.Client
+	TiDBClient *tidb.Client
+	LocalStore *dbstore.DB
+}
+
+type Service struct {
+	params ServiceParams
+	now    func() time.Time
+}
+
+func newService(lc fx.Lifecycle, p ServiceParams) (*Service, error) {
+	if err := autoMigrate(p.LocalStore); err != nil {
+		return nil, err
+	}
+	s := &Service{params: p, now: time.Now}
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			go s.sampleLoop(ctx)
+			return nil
+		},
+	})
+	return s, nil
+}
+
+func registerRouter(r *gin.RouterGroup, auth *user.AuthService, s *Service) {
+	endpoint := r.Group("/hot_regions")
+	endpoint.Use(
+		auth.MWAuthRequired(),
+		// The history is only sampled from the default cluster.
+		cluster.MWRequireDefaultCluster(),
+		utils.MWConnectTiDB(s.params.TiDBClient),
+	)
+	endpoint.GET("/history", s.getHistory)
+}
+
+func (s *Service) sampleLoop(ctx context.Context) {
+	ticker := time.NewTicker(sampleInterval)
+	defer ticker.Stop()
+	for {
+		if err := s.sample(); err != nil {
+			log.Warn("Failed to sample hot regions", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Service) sample() error {
+	now := s.now()
+	records, err := sampleHotRegions(s.params.PDClient.SendGetRequest, now)
+	if err != nil {
+		return err
+	}
+	if len(records) > 0 {
+		if err := s.params.LocalStore.CreateInBatches(records, insertBatchSize).Error; err != nil {
+			return err
+		}
+	}
+	return deleteHistoryBefore(s.params.LocalStore, now.Add(-historyRetention))
+}
+
+type GetHistoryRequest struct {
+	// BeginTime and EndTime are unix seconds.
+	BeginTime int64 `json:"begin_time" form:"begin_time" binding:"required"`
+	EndTime   int64 `json:"end_time" form:"end_time" binding:"required"`
+	// Type is either "read" or "write". Both are returned when empty.
+	Type    string `json:"type" form:"type"`
+	StoreID uint64 `json:"store_id" form:"store_id"`
+	// DB and Table filter by a table, which must be specified together.
+	DB    string `json:"db" form:"db"`
+	Table string `json:"table" form:"table"`
+	Limit int    `json:"limit" form:"limit"`
+}
+
+// @ID hotRegionGetHistory
+// @Summary Get hot regions sampled from PD in a time range, latest first
+// @Description Hot regions are sampled every minute and kept for 7 days.
+// @Param q query GetHistoryRequest true "Query"
+// @Success 200 {array} HotRegionModel
+// @Router /hot_regions/history [get]
+// @Security JwtAuth
+// @Failure 400 {object} rest.ErrorResponse
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 404 {object} rest.ErrorResponse
+func (s *Service) getHistory(c *gin.Context) {
+	var req GetHistoryRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		rest.Error(c, rest.ErrBadRequest.NewWithNoMessage())
+		return
+	}
+	if req.EndTime < req.BeginTime {
+		rest.Error(c, rest.ErrBadRequest.New("end_time must not be earlier than begin_time"))
+		return
+	}
+	if req.Type != "" && req.Type != TypeRead && req.Type != TypeWrite {
+		rest.Error(c, rest.ErrBadRequest.New("type must be either read or write"))
+		return
+	}
+	if req.Limit <= 0 {
+		req.Limit = defaultHistoryLimit
+	}
+	if req.Limit > maxHistoryLimit {
+		req.Limit = maxHistoryLimit
+	}
+
+	filter := historyFilter{
+		BeginTime: time.Unix(req.BeginTime, 0),
+		EndTime:   time.Unix(req.EndTime, 0),
+		Type:      req.Type,
+		StoreID:   req.StoreID,
+		Limit:     req.Limit,
+	}
+	switch {
+	case req.DB != "" && req.Table != "":
+		ids, err := region.ResolveTableIDs(utils.GetTiDBConnection(c), req.DB, req.Table)
+		if err != nil {
+			rest.Error(c, err)
+			return
+		}
+		filter.TableIDs = ids
+	case req.DB != "" || req.Table != "":
+		rest.Error(c, rest.ErrBadRequest.New("both db and table are required"))
+		return
+	}
+
+	records, err := queryHistory(s.params.LocalStore, filter)
+	if err != nil {
+		rest.Error(c, ErrQueryFailed.WrapWithNoMessage(err))
+		return
+	}
+	c.JSON(http.StatusOK, records)
+}
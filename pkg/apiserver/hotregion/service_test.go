@@ -0,0 +1,112 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package hotregion
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pingcap/tidb-dashboard/pkg/dbstore"
+	"github.com/pingcap/tidb-dashboard/pkg/dbstore/dbstoretest"
+	"github.com/pingcap/tidb-dashboard/pkg/tidb/model"
+)
+
+func newTestDB(t *testing.T) *dbstore.DB {
+	db := dbstoretest.New(t)
+	require.NoError(t, autoMigrate(db))
+	return db
+}
+
+func tableKeyHex(tableID int64) string {
+	var buf model.KeyInfoBuffer
+	return strings.ToUpper(hex.EncodeToString(buf.GenerateKey(tableID, 0)))
+}
+
+func TestTableIDOfKey(t *testing.T) {
+	require.Equal(t, int64(45), tableIDOfKey(tableKeyHex(45)))
+	require.Equal(t, int64(0), tableIDOfKey(""))
+	require.Equal(t, int64(0), tableIDOfKey("not hex"))
+}
+
+func TestSampleHotRegions(t *testing.T) {
+	responses := map[string]string{
+		"/hotspot/regions/read": `{
+			"as_peer": {"1": {"statistics": [{"store_id": 1, "region_id": 10, "flow_bytes": 100}]}},
+			"as_leader": {"1": {"statistics": [{"store_id": 1, "region_id": 10, "is_leader": true, "flow_bytes": 100}]}}
+		}`,
+		"/hotspot/regions/write": `{
+			"as_peer": {
+				"2": {"statistics": [{"region_id": 11, "flow_bytes": 200, "flow_keys": 20}]},
+				"1": {"statistics": [{"store_id": 1, "region_id": 10, "flow_bytes": 50}]}
+			}
+		}`,
+		"/region/id/10": fmt.Sprintf(`{"start_key": %q}`, tableKeyHex(45)),
+	}
+	requested := make(map[string]int)
+	get := func(uri string) ([]byte, error) {
+		requested[uri]++
+		if r, ok := responses[uri]; ok {
+			return []byte(r), nil
+		}
+		return nil, fmt.Errorf("region not found")
+	}
+
+	now := time.Unix(1700000000, 0)
+	records, err := sampleHotRegions(get, now)
+	require.NoError(t, err)
+	require.Equal(t, []HotRegionModel{
+		{Time: now, Type: TypeRead, RegionID: 10, StoreID: 1, IsLeader: true, TableID: 45, FlowBytes: 100},
+		{Time: now, Type: TypeWrite, RegionID: 10, StoreID: 1, TableID: 45, FlowBytes: 50},
+		{Time: now, Type: TypeWrite, RegionID: 11, StoreID: 2, FlowBytes: 200, FlowKeys: 20},
+	}, records)
+	// Each region is only looked up once.
+	require.Equal(t, 1, requested["/region/id/10"])
+}
+
+func TestQueryHistory(t *testing.T) {
+	db := newTestDB(t)
+	base := time.Unix(1700000000, 0)
+	records := []HotRegionModel{
+		{Time: base, Type: TypeRead, RegionID: 1, StoreID: 1, TableID: 45, FlowBytes: 10},
+		{Time: base, Type: TypeWrite, RegionID: 2, StoreID: 2, TableID: 46, FlowBytes: 30},
+		{Time: base.Add(time.Minute), Type: TypeWrite, RegionID: 1, StoreID: 1, TableID: 45, FlowBytes: 20},
+		{Time: base.Add(2 * time.Hour), Type: TypeRead, RegionID: 3, StoreID: 1, TableID: 47},
+	}
+	require.NoError(t, db.Create(&records).Error)
+
+	regionIDs := func(f historyFilter) []uint64 {
+		result, err := queryHistory(db, f)
+		require.NoError(t, err)
+		ids := make([]uint64, 0, len(result))
+		for _, r := range result {
+			ids = append(ids, r.RegionID)
+		}
+		return ids
+	}
+	inHour := historyFilter{BeginTime: base, EndTime: base.Add(time.Hour)}
+	require.Equal(t, []uint64{1, 2, 1}, regionIDs(inHour))
+
+	f := inHour
+	f.Type = TypeWrite
+	require.Equal(t, []uint64{1, 2}, regionIDs(f))
+
+	f = inHour
+	f.StoreID = 2
+	require.Equal(t, []uint64{2}, regionIDs(f))
+
+	f = inHour
+	f.TableIDs = []int64{45, 47}
+	require.Equal(t, []uint64{1, 1}, regionIDs(f))
+
+	f = inHour
+	f.Limit = 1
+	require.Equal(t, []uint64{1}, regionIDs(f))
+
+	require.NoError(t, deleteHistoryBefore(db, base.Add(time.Hour)))
+	require.Equal(t, []uint64{3}, regionIDs(historyFilter{BeginTime: base, EndTime: base.Add(3 * time.Hour)}))
+}
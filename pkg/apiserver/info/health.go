@@ -0,0 +1,154 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package info
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/joomcode/errorx"
+	"go.etcd.io/etcd/clientv3"
+
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/utils"
+	"github.com/pingcap/tidb-dashboard/pkg/pd"
+)
+
+const healthCheckTimeout = 5 * time.Second
+
+var (
+	ErrNS                = errorx.NewNamespace("error.api.info")
+	ErrHealthCheckFailed = ErrNS.NewType("health_check_failed")
+)
+
+type DependencyHealth struct {
+	Name      string  `json:"name"`
+	Healthy   bool    `json:"healthy"`
+	Skipped   bool    `json:"skipped"`
+	LatencyMs float64 `json:"latency_ms"`
+	// Detail is the checked target, or why the check is skipped.
+	Detail string `json:"detail,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+type HealthResponse struct {
+	// Healthy is true when all dependencies not skipped are healthy.
+	Healthy      bool               `json:"healthy"`
+	Dependencies []DependencyHealth `json:"dependencies"`
+	// EtcdConnection is the etcd connection state observed by background health checks.
+	EtcdConnection *pd.EtcdConnectionState `json:"etcd_connection,omitempty"`
+}
+
+// healthCheck checks a dependency and returns the checked target. Returning skippedError skips the check.
+type healthCheck struct {
+	name  string
+	check func(ctx context.Context) (detail string, err error)
+}
+
+type skippedError struct {
+	reason string
+}
+
+func (e skippedError) Error() string {
+	return e.reason
+}
+
+func runHealthChecks(ctx context.Context, checks []healthCheck) HealthResponse {
+	resp := HealthResponse{Healthy: true, Dependencies: make([]DependencyHealth, len(checks))}
+	var wg sync.WaitGroup
+	for i, hc := range checks {
+		wg.Add(1)
+		go func(i int, hc healthCheck) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+			defer cancel()
+
+			start := time.Now()
+			detail, err := hc.check(ctx)
+			h := DependencyHealth{
+				Name:      hc.name,
+				LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+				Detail:    detail,
+			}
+			if se, ok := err.(skippedError); ok {
+				h.Skipped = true
+				h.Detail = se.reason
+				h.LatencyMs = 0
+			} else if err != nil {
+				h.Error = err.Error()
+			} else {
+				h.Healthy = true
+			}
+			resp.Dependencies[i] = h
+		}(i, hc)
+	}
+	wg.Wait()
+	for _, h := range resp.Dependencies {
+		if !h.Healthy && !h.Skipped {
+			resp.Healthy = false
+		}
+	}
+	return resp
+}
+
+// @ID infoHealth
+// @Summary Check whether the dashboard can reach the cluster components it depends on
+// @Success 200 {object} HealthResponse
+// @Router /info/health [get]
+// @Security JwtAuth
+// @Failure 401 {object} rest.ErrorResponse
+func (s *Service) healthHandler(c *gin.Context) {
+	session := utils.GetSession(c)
+	checks := []healthCheck{
+		{name: "pd", check: func(ctx context.Context) (string, error) {
+			_, err := s.params.PDClient.WithTimeout(healthCheckTimeout).SendGetRequest("/health")
+			return "", err
+		}},
+		{name: "etcd", check: func(ctx context.Context) (string, error) {
+			_, err := s.params.EtcdClients.Client().Get(ctx, "/topology", clientv3.WithPrefix(), clientv3.WithCountOnly())
+			return "", err
+		}},
+		{name: "tidb", check: func(ctx context.Context) (string, error) {
+			if !session.HasTiDBAuth {
+				return "", skippedError{"current session does not sign in with a SQL user"}
+			}
+			db, err := s.params.TiDBClient.OpenSQLConn(session.TiDBUsername, session.TiDBPassword)
+			if err != nil {
+				return "", err
+			}
+			defer func() { _ = utils.CloseTiDBConnection(db) }()
+			return "", db.WithContext(ctx).Exec("SELECT 1").Error
+		}},
+		{name: "prometheus", check: func(ctx context.Context) (string, error) {
+			addr, err := s.params.Metrics.ResolvePromAddress()
+			if err != nil {
+				return "", err
+			}
+			if addr == "" {
+				return "", skippedError{"Prometheus is not deployed"}
+			}
+			_, err = s.params.HTTPClient.SendRequest(ctx, addr+"/-/ready", http.MethodGet, nil, ErrHealthCheckFailed, "Prometheus")
+			return addr, err
+		}},
+		{name: "local_storage", check: func(ctx context.Context) (string, error) {
+			return s.params.Config.DataDir, checkDirWritable(s.params.Config.DataDir)
+		}},
+	}
+	resp := runHealthChecks(s.lifecycleCtx, checks)
+	etcdState := s.params.EtcdClients.State()
+	resp.EtcdConnection = &etcdState
+	c.JSON(http.StatusOK, resp)
+}
+
+// checkDirWritable checks whether files can be created in the directory.
+func checkDirWritable(dir string) error {
+	f, err := os.CreateTemp(dir, ".health-check-*")
+	if err != nil {
+		return err
+	}
+	_ = f.Close()
+	return os.Remove(f.Name())
+}
@@ -0,0 +1,35 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package info
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunHealthChecks(t *testing.T) {
+	ok := func(ctx context.Context) (string, error) { return "target", nil }
+	skipped := func(ctx context.Context) (string, error) { return "", skippedError{"not deployed"} }
+	failed := func(ctx context.Context) (string, error) { return "", errors.New("connection refused") }
+
+	resp := runHealthChecks(context.Background(), []healthCheck{{"a", ok}, {"b", skipped}})
+	require.True(t, resp.Healthy)
+	require.Equal(t, "target", resp.Dependencies[0].Detail)
+	require.True(t, resp.Dependencies[0].Healthy)
+	require.True(t, resp.Dependencies[1].Skipped)
+	require.Equal(t, "not deployed", resp.Dependencies[1].Detail)
+
+	resp = runHealthChecks(context.Background(), []healthCheck{{"a", ok}, {"c", failed}})
+	require.False(t, resp.Healthy)
+	require.Equal(t, "c", resp.Dependencies[1].Name)
+	require.Equal(t, "connection refused", resp.Dependencies[1].Error)
+}
+
+func TestCheckDirWritable(t *testing.T) {
+	require.NoError(t, checkDirWritable(t.TempDir()))
+	require.Error(t, checkDirWritable(filepath.Join(t.TempDir(), "missing")))
+}
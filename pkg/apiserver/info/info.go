@@ -11,13 +11,17 @@ import (
 	"github.com/Masterminds/semver"
 	"github.com/gin-gonic/gin"
 	"github.com/samber/lo"
-	"go.etcd.io/etcd/clientv3"
 	"go.uber.org/fx"
 
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/cluster"
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/metrics"
 	"github.com/pingcap/tidb-dashboard/pkg/apiserver/user"
 	"github.com/pingcap/tidb-dashboard/pkg/apiserver/utils"
 	"github.com/pingcap/tidb-dashboard/pkg/config"
 	"github.com/pingcap/tidb-dashboard/pkg/dbstore"
+	"github.com/pingcap/tidb-dashboard/pkg/httpc"
+	"github.com/pingcap/tidb-dashboard/pkg/pd"
+	"github.com/pingcap/tidb-dashboard/pkg/ticdc"
 	"github.com/pingcap/tidb-dashboard/pkg/tidb"
 	"github.com/pingcap/tidb-dashboard/pkg/utils/topology"
 	"github.com/pingcap/tidb-dashboard/pkg/utils/version"
@@ -27,11 +31,16 @@ import (
 
 type ServiceParams struct {
 	fx.In
-	EtcdClient   *clientv3.Client
+	EtcdClients  *pd.EtcdClientManager
 	Config       *config.Config
 	LocalStore   *dbstore.DB
 	TiDBClient   *tidb.Client
+	PDClient     *pd.Client
+	TiCDCClient  *ticdc.Client
+	HTTPClient   *httpc.Client
+	Metrics      *metrics.Service
 	FeatureFlags *featureflag.Registry
+	Registry     *cluster.Registry
 }
 
 type Service struct {
@@ -55,9 +64,13 @@ func NewService(lc fx.Lifecycle, p ServiceParams) *Service {
 func RegisterRouter(r *gin.RouterGroup, auth *user.AuthService, s *Service) {
 	endpoint := r.Group("/info")
 	endpoint.GET("/info", s.infoHandler)
-	endpoint.Use(auth.MWAuthRequired())
-	endpoint.GET("/whoami", s.WhoamiHandler)
+	endpoint.GET("/features", utils.MWConditionalGet(), s.featuresHandler)
+	endpoint.GET("/whoami", auth.MWAuthRequiredFor(""), s.WhoamiHandler)
+	endpoint.GET("/versions", auth.MWAuthRequired(), s.params.Registry.MWResolveCluster(), s.versionsHandler)
+	endpoint.GET("/health", auth.MWAuthRequired(), s.healthHandler)
 
+	// Databases and tables are listed by TiDB according to the SQL privileges of the user.
+	endpoint.Use(auth.MWAuthRequiredFor(utils.CapabilityViewStatements))
 	endpoint.Use(utils.MWConnectTiDB(s.params.TiDBClient))
 	endpoint.GET("/databases", s.databasesHandler)
 	endpoint.GET("/tables", s.tablesHandler)
@@ -69,6 +82,8 @@ type InfoResponse struct { // nolint
 	EnableExperimental bool           `json:"enable_experimental"`
 	SupportedFeatures  []string       `json:"supported_features"`
 	NgmState           utils.NgmState `json:"ngm_state"`
+	// PublicPathPrefix is the path prefix of the dashboard seen by browsers, used to build links to the dashboard.
+	PublicPathPrefix string `json:"public_path_prefix"`
 }
 
 // @ID infoGet
@@ -95,7 +110,7 @@ func (s *Service) infoHandler(c *gin.Context) {
 	ngmState := utils.NgmStateNotSupported
 	if constraint.Check(v) {
 		ngmState = utils.NgmStateNotStarted
-		addr, err := topology.FetchNgMonitoringTopology(s.lifecycleCtx, s.params.EtcdClient)
+		addr, err := topology.FetchNgMonitoringTopology(s.lifecycleCtx, s.params.EtcdClients.Client())
 		if err == nil && addr != "" {
 			ngmState = utils.NgmStateStarted
 		}
@@ -107,6 +122,7 @@ func (s *Service) infoHandler(c *gin.Context) {
 		EnableExperimental: s.params.Config.EnableExperimental,
 		SupportedFeatures:  s.params.FeatureFlags.SupportedFeatures(),
 		NgmState:           ngmState,
+		PublicPathPrefix:   s.params.Config.PublicPathPrefix,
 	}
 	c.JSON(http.StatusOK, resp)
 }
@@ -115,6 +131,17 @@ type WhoAmIResponse struct {
 	DisplayName string `json:"display_name"`
 	IsShareable bool   `json:"is_shareable"`
 	IsWriteable bool   `json:"is_writeable"`
+
+	Capabilities []utils.Capability `json:"capabilities"`
+}
+
+// @ID infoGetFeatures
+// @Summary Get the status of all features of this TiDB Dashboard
+// @Description Features are unavailable when not supported in the cluster version, or disabled by the deployment.
+// @Success 200 {array} featureflag.Status
+// @Router /info/features [get]
+func (s *Service) featuresHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, s.params.FeatureFlags.Features())
 }
 
 // @ID infoWhoami
@@ -129,6 +156,8 @@ func (s *Service) WhoamiHandler(c *gin.Context) {
 		DisplayName: sessionUser.DisplayName,
 		IsShareable: sessionUser.IsShareable,
 		IsWriteable: sessionUser.IsWriteable,
+
+		Capabilities: sessionUser.Capabilities,
 	}
 	c.JSON(http.StatusOK, resp)
 }
@@ -0,0 +1,241 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package info
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/cluster"
+	"github.com/pingcap/tidb-dashboard/pkg/pd"
+	"github.com/pingcap/tidb-dashboard/pkg/utils/topology"
+	"github.com/pingcap/tidb-dashboard/util/rest"
+	"github.com/pingcap/tidb-dashboard/util/topo"
+)
+
+const (
+	VersionSourceStatusAPI = "status_api"
+	VersionSourceTopology  = "topology"
+)
+
+type NodeVersion struct {
+	Kind    topo.Kind `json:"kind"`
+	Address string    `json:"address"`
+	// Version is normalized as `vX.Y.Z`, e.g. the MySQL compatible prefix of TiDB is removed.
+	Version   string `json:"version"`
+	GitHash   string `json:"git_hash"`
+	BuildTime string `json:"build_time,omitempty"`
+	Edition   string `json:"edition,omitempty"`
+	// Source is where the build info comes from. Nodes without a build info API, or failed to respond,
+	// fallback to the info registered in the topology.
+	Source string `json:"source"`
+	Error  string `json:"error,omitempty"`
+	// Mismatch is true when the version differs from the majority version of the cluster.
+	Mismatch bool `json:"mismatch"`
+}
+
+type VersionsResponse struct {
+	MajorityVersion string        `json:"majority_version"`
+	Nodes           []NodeVersion `json:"nodes"`
+}
+
+// standaloneVersionKinds are components released with their own versions, which are not
+// compared with the majority version.
+var standaloneVersionKinds = map[topo.Kind]struct{}{
+	topo.KindTiProxy: {},
+}
+
+// normalizeVersion converts versions like `8.0.11-TiDB-v7.5.0` or `7.5.0` into `v7.5.0`.
+func normalizeVersion(version string) string {
+	if idx := strings.LastIndex(version, "-TiDB-"); idx >= 0 {
+		version = version[idx+len("-TiDB-"):]
+	}
+	if version != "" && !strings.HasPrefix(version, "v") {
+		version = "v" + version
+	}
+	return version
+}
+
+// editionOf guesses the edition from the version, as enterprise builds are suffixed in versions.
+func editionOf(version string) string {
+	if strings.Contains(strings.ToLower(version), "enterprise") {
+		return "Enterprise"
+	}
+	return "Community"
+}
+
+// markVersionMismatches marks nodes whose versions differ from the majority version and returns
+// the majority version. Ties are broken by choosing the greater version in lexical order.
+func markVersionMismatches(nodes []NodeVersion) string {
+	counts := make(map[string]int)
+	for _, n := range nodes {
+		if _, ok := standaloneVersionKinds[n.Kind]; ok || n.Version == "" {
+			continue
+		}
+		counts[n.Version]++
+	}
+	majority := ""
+	for v, count := range counts {
+		if count > counts[majority] || (count == counts[majority] && v > majority) {
+			majority = v
+		}
+	}
+	for i := range nodes {
+		if _, ok := standaloneVersionKinds[nodes[i].Kind]; ok || nodes[i].Version == "" {
+			continue
+		}
+		nodes[i].Mismatch = nodes[i].Version != majority
+	}
+	return majority
+}
+
+func address(ip string, port uint) string {
+	return net.JoinHostPort(ip, strconv.Itoa(int(port)))
+}
+
+// @ID infoGetVersions
+// @Summary Get the version and build info of all nodes in the selected cluster
+// @Description Nodes whose versions differ from the majority are marked, which indicates a partially-upgraded cluster.
+// @Success 200 {object} VersionsResponse
+// @Router /info/versions [get]
+// @Security JwtAuth
+// @Failure 401 {object} rest.ErrorResponse
+func (s *Service) versionsHandler(c *gin.Context) {
+	nodes, err := s.fetchNodeVersions(cluster.GetClients(c))
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	majority := markVersionMismatches(nodes)
+	c.JSON(http.StatusOK, VersionsResponse{MajorityVersion: majority, Nodes: nodes})
+}
+
+// fetchNodeVersions discovers all nodes and queries their status API for the build info concurrently.
+func (s *Service) fetchNodeVersions(clients *cluster.Clients) ([]NodeVersion, error) {
+	pdNodes, err := topology.FetchPDTopology(clients.PDClient)
+	if err != nil {
+		return nil, err
+	}
+	tidbNodes, err := topology.FetchTiDBTopology(s.lifecycleCtx, clients.EtcdClient)
+	if err != nil {
+		return nil, err
+	}
+	tikvNodes, tiflashNodes, err := topology.FetchStoreTopology(clients.PDClient)
+	if err != nil {
+		return nil, err
+	}
+	ticdcNodes, err := topology.FetchTiCDCTopology(s.lifecycleCtx, clients.EtcdClient)
+	if err != nil {
+		return nil, err
+	}
+	tiproxyNodes, err := topology.FetchTiProxyTopology(s.lifecycleCtx, clients.EtcdClient)
+	if err != nil {
+		return nil, err
+	}
+
+	var nodes []NodeVersion
+	var fetchers []func(n *NodeVersion)
+	add := func(n NodeVersion, fetch func(n *NodeVersion)) {
+		n.Version = normalizeVersion(n.Version)
+		n.Source = VersionSourceTopology
+		nodes = append(nodes, n)
+		fetchers = append(fetchers, fetch)
+	}
+	for _, n := range pdNodes {
+		n := n
+		add(NodeVersion{Kind: topo.KindPD, Address: address(n.IP, n.Port), Version: n.Version, GitHash: n.GitHash}, func(v *NodeVersion) {
+			s.fetchPDBuildInfo(clients.PDClient, v, n.IP, n.Port)
+		})
+	}
+	for _, n := range tidbNodes {
+		n := n
+		add(NodeVersion{Kind: topo.KindTiDB, Address: address(n.IP, n.Port), Version: n.Version, GitHash: n.GitHash}, func(v *NodeVersion) {
+			s.fetchTiDBBuildInfo(v, n.IP, n.StatusPort)
+		})
+	}
+	for _, n := range tikvNodes {
+		add(NodeVersion{Kind: topo.KindTiKV, Address: address(n.IP, n.Port), Version: n.Version, GitHash: n.GitHash}, nil)
+	}
+	for _, n := range tiflashNodes {
+		add(NodeVersion{Kind: topo.KindTiFlash, Address: address(n.IP, n.Port), Version: n.Version, GitHash: n.GitHash}, nil)
+	}
+	for _, n := range ticdcNodes {
+		n := n
+		add(NodeVersion{Kind: topo.KindTiCDC, Address: address(n.IP, n.Port), Version: n.Version, GitHash: n.GitHash}, func(v *NodeVersion) {
+			s.fetchTiCDCBuildInfo(v, n.IP, n.StatusPort)
+		})
+	}
+	for _, n := range tiproxyNodes {
+		add(NodeVersion{Kind: topo.KindTiProxy, Address: address(n.IP, n.Port), Version: n.Version, GitHash: n.GitHash}, nil)
+	}
+
+	var wg sync.WaitGroup
+	for i := range nodes {
+		if fetchers[i] == nil {
+			continue
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			fetchers[i](&nodes[i])
+		}(i)
+	}
+	wg.Wait()
+
+	for i := range nodes {
+		if nodes[i].Version != "" {
+			nodes[i].Edition = editionOf(nodes[i].Version)
+		}
+	}
+	sort.SliceStable(nodes, func(i, j int) bool {
+		return nodes[i].Kind < nodes[j].Kind
+	})
+	return nodes, nil
+}
+
+// applyBuildInfo overrides the node with the build info responded from its status API.
+func applyBuildInfo(n *NodeVersion, data []byte, err error) {
+	if err != nil {
+		n.Error = err.Error()
+		return
+	}
+	var resp struct {
+		Version string `json:"version"`
+		GitHash string `json:"git_hash"`
+		BuildTS string `json:"build_ts"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		n.Error = err.Error()
+		return
+	}
+	n.Source = VersionSourceStatusAPI
+	if resp.Version != "" {
+		n.Version = normalizeVersion(resp.Version)
+	}
+	if resp.GitHash != "" {
+		n.GitHash = resp.GitHash
+	}
+	n.BuildTime = resp.BuildTS
+}
+
+func (s *Service) fetchPDBuildInfo(pdClient *pd.Client, n *NodeVersion, ip string, port uint) {
+	data, err := pdClient.WithAddress(ip, int(port)).SendGetRequest("/status")
+	applyBuildInfo(n, data, err)
+}
+
+func (s *Service) fetchTiDBBuildInfo(n *NodeVersion, ip string, statusPort uint) {
+	data, err := s.params.TiDBClient.WithEnforcedStatusAPIAddress(ip, int(statusPort)).SendGetRequest("/status")
+	applyBuildInfo(n, data, err)
+}
+
+func (s *Service) fetchTiCDCBuildInfo(n *NodeVersion, ip string, statusPort uint) {
+	data, err := s.params.TiCDCClient.SendGetRequest(ip, int(statusPort), "/status")
+	applyBuildInfo(n, data, err)
+}
@@ -0,0 +1,56 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package info
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pingcap/tidb-dashboard/util/topo"
+)
+
+func TestNormalizeVersion(t *testing.T) {
+	require.Equal(t, "v7.5.0", normalizeVersion("8.0.11-TiDB-v7.5.0"))
+	require.Equal(t, "v7.5.0", normalizeVersion("7.5.0"))
+	require.Equal(t, "v7.5.0", normalizeVersion("v7.5.0"))
+	require.Equal(t, "", normalizeVersion(""))
+}
+
+func TestMarkVersionMismatches(t *testing.T) {
+	nodes := []NodeVersion{
+		{Kind: topo.KindPD, Version: "v7.5.0"},
+		{Kind: topo.KindTiDB, Version: "v7.5.0"},
+		{Kind: topo.KindTiKV, Version: "v7.1.0"},
+		{Kind: topo.KindTiKV, Version: "v7.5.0"},
+		{Kind: topo.KindTiProxy, Version: "v1.0.0"},
+		{Kind: topo.KindTiFlash, Version: ""},
+	}
+	require.Equal(t, "v7.5.0", markVersionMismatches(nodes))
+	require.Equal(t, []bool{false, false, true, false, false, false}, []bool{
+		nodes[0].Mismatch, nodes[1].Mismatch, nodes[2].Mismatch, nodes[3].Mismatch, nodes[4].Mismatch, nodes[5].Mismatch,
+	})
+
+	// Ties are broken by the greater version.
+	nodes = []NodeVersion{{Kind: topo.KindPD, Version: "v7.1.0"}, {Kind: topo.KindTiDB, Version: "v7.5.0"}}
+	require.Equal(t, "v7.5.0", markVersionMismatches(nodes))
+	require.True(t, nodes[0].Mismatch)
+}
+
+func TestApplyBuildInfo(t *testing.T) {
+	n := NodeVersion{Version: "v7.1.0", GitHash: "old", Source: VersionSourceTopology}
+	applyBuildInfo(&n, []byte(`{"version":"8.0.11-TiDB-v7.5.0","git_hash":"abc","build_ts":"2023-12-01 07:00:00"}`), nil)
+	require.Equal(t, NodeVersion{
+		Version:   "v7.5.0",
+		GitHash:   "abc",
+		BuildTime: "2023-12-01 07:00:00",
+		Source:    VersionSourceStatusAPI,
+	}, n)
+
+	n = NodeVersion{Version: "v7.1.0", Source: VersionSourceTopology}
+	applyBuildInfo(&n, nil, errors.New("connection refused"))
+	require.Equal(t, "v7.1.0", n.Version)
+	require.Equal(t, VersionSourceTopology, n.Source)
+	require.Equal(t, "connection refused", n.Error)
+}
@@ -14,11 +14,13 @@ import (
 	"go.uber.org/fx"
 	"go.uber.org/zap"
 
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/audit"
 	"github.com/pingcap/tidb-dashboard/pkg/apiserver/model"
 	"github.com/pingcap/tidb-dashboard/pkg/apiserver/user"
 	"github.com/pingcap/tidb-dashboard/pkg/apiserver/utils"
 	"github.com/pingcap/tidb-dashboard/pkg/config"
 	"github.com/pingcap/tidb-dashboard/pkg/dbstore"
+	"github.com/pingcap/tidb-dashboard/util/featureflag"
 	"github.com/pingcap/tidb-dashboard/util/rest"
 )
 
@@ -30,9 +32,11 @@ type Service struct {
 	logStoreDirectory string
 	db                *dbstore.DB
 	scheduler         *Scheduler
+
+	FeatureFlagLogSearch *featureflag.FeatureFlag
 }
 
-func NewService(lc fx.Lifecycle, config *config.Config, db *dbstore.DB) *Service {
+func NewService(lc fx.Lifecycle, config *config.Config, db *dbstore.DB, ff *featureflag.Registry) *Service {
 	dir := config.TempDir
 	if dir == "" {
 		var err error
@@ -52,6 +56,8 @@ func NewService(lc fx.Lifecycle, config *config.Config, db *dbstore.DB) *Service
 		logStoreDirectory: dir,
 		db:                db,
 		scheduler:         nil, // will be filled after scheduler is created
+
+		FeatureFlagLogSearch: ff.Register("log_search"),
 	}
 	scheduler := NewScheduler(service)
 	service.scheduler = scheduler
@@ -66,20 +72,21 @@ func NewService(lc fx.Lifecycle, config *config.Config, db *dbstore.DB) *Service
 	return service
 }
 
-func RegisterRouter(r *gin.RouterGroup, auth *user.AuthService, s *Service) {
+func RegisterRouter(r *gin.RouterGroup, auth *user.AuthService, a *audit.Service, s *Service) {
 	endpoint := r.Group("/logs")
+	endpoint.Use(s.FeatureFlagLogSearch.VersionGuard())
 	{
 		endpoint.GET("/download", s.DownloadLogs)
 		endpoint.Use(auth.MWAuthRequired())
 		{
 			endpoint.GET("/download/acquire_token", s.GetDownloadToken)
-			endpoint.PUT("/taskgroup", s.CreateTaskGroup)
+			endpoint.PUT("/taskgroup", a.MWRecord("logsearch.create"), s.CreateTaskGroup)
 			endpoint.GET("/taskgroups", s.GetAllTaskGroups)
 			endpoint.GET("/taskgroups/:id", s.GetTaskGroup)
 			endpoint.GET("/taskgroups/:id/preview", s.GetTaskGroupPreview)
-			endpoint.POST("/taskgroups/:id/retry", s.RetryTask)
-			endpoint.POST("/taskgroups/:id/cancel", s.CancelTask)
-			endpoint.DELETE("/taskgroups/:id", s.DeleteTaskGroup)
+			endpoint.POST("/taskgroups/:id/retry", a.MWRecord("logsearch.retry"), s.RetryTask)
+			endpoint.POST("/taskgroups/:id/cancel", a.MWRecord("logsearch.cancel"), s.CancelTask)
+			endpoint.DELETE("/taskgroups/:id", a.MWRecord("logsearch.delete"), s.DeleteTaskGroup)
 		}
 	}
 }
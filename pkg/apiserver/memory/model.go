@@ -0,0 +1,139 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package memory
+
+import (
+	"github.com/pingcap/tidb-dashboard/util/rest"
+)
+
+const (
+	ClusterMemoryUsageTable           = "INFORMATION_SCHEMA.CLUSTER_MEMORY_USAGE"
+	ClusterMemoryUsageOpsHistoryTable = "INFORMATION_SCHEMA.CLUSTER_MEMORY_USAGE_OPS_HISTORY"
+	ClusterProcessListTable           = "INFORMATION_SCHEMA.CLUSTER_PROCESSLIST"
+	ClusterSlowQueryTable             = "INFORMATION_SCHEMA.CLUSTER_SLOW_QUERY"
+)
+
+// InstanceMemory is the memory usage of a TiDB instance.
+type InstanceMemory struct {
+	// Instance is the status address of the TiDB instance.
+	Instance    string `gorm:"column:INSTANCE" json:"instance"`
+	MemoryTotal int64  `gorm:"column:MEMORY_TOTAL" json:"memory_total"`
+	// MemoryLimit is the limit set by tidb_server_memory_limit, which is 0 when not limited.
+	MemoryLimit   int64 `gorm:"column:MEMORY_LIMIT" json:"memory_limit"`
+	MemoryCurrent int64 `gorm:"column:MEMORY_CURRENT" json:"memory_current"`
+	MemoryMaxUsed int64 `gorm:"column:MEMORY_MAX_USED" json:"memory_max_used"`
+	// CurrentOps is the operation in progress to reduce the memory usage, e.g. killing a session.
+	CurrentOps string `gorm:"column:CURRENT_OPS" json:"current_ops"`
+	// SessionKillLast and GCLast are unix timestamps, which are 0 if never happened.
+	SessionKillLast  float64 `gorm:"column:SESSION_KILL_LAST" json:"session_kill_last"`
+	SessionKillTotal int64   `gorm:"column:SESSION_KILL_TOTAL" json:"session_kill_total"`
+	GCLast           float64 `gorm:"column:GC_LAST" json:"gc_last"`
+	GCTotal          int64   `gorm:"column:GC_TOTAL" json:"gc_total"`
+	// DiskUsage is the size of the temporary storage, and QueryForceDisk is the number of statements spilled to it.
+	DiskUsage      int64 `gorm:"column:DISK_USAGE" json:"disk_usage"`
+	QueryForceDisk int64 `gorm:"column:QUERY_FORCE_DISK" json:"query_force_disk"`
+
+	// RunningStatements, StatementMemory and StatementDisk are the number and usage of statements running in the
+	// instance. The rest of MemoryCurrent is used by other components, e.g. caches and background jobs.
+	RunningStatements int64 `gorm:"-" json:"running_statements"`
+	StatementMemory   int64 `gorm:"-" json:"statement_memory"`
+	StatementDisk     int64 `gorm:"-" json:"statement_disk"`
+}
+
+// statementUsage is the usage of statements running in an instance.
+type statementUsage struct {
+	Instance string `gorm:"column:INSTANCE"`
+	Count    int64  `gorm:"column:COUNT"`
+	Mem      int64  `gorm:"column:MEM"`
+	Disk     int64  `gorm:"column:DISK"`
+}
+
+// RunningStatement is a statement running in a session.
+type RunningStatement struct {
+	Instance  string `gorm:"column:INSTANCE" json:"instance"`
+	SessionID uint64 `gorm:"column:ID" json:"session_id"`
+	User      string `gorm:"column:USER" json:"user"`
+	DB        string `gorm:"column:DB" json:"db"`
+	// DurationSecs is how long the session has been in the current state.
+	DurationSecs int64  `gorm:"column:TIME" json:"duration_secs"`
+	Digest       string `gorm:"column:DIGEST" json:"digest"`
+	SQL          string `gorm:"column:INFO" json:"sql"`
+	Memory       int64  `gorm:"column:MEM" json:"memory"`
+	Disk         int64  `gorm:"column:DISK" json:"disk"`
+}
+
+type EventType string
+
+const (
+	// EventSessionKill is a session killed as the instance exceeds tidb_server_memory_limit.
+	EventSessionKill EventType = "session_kill"
+	// EventGC is a GC triggered as the instance approaches tidb_server_memory_limit.
+	EventGC EventType = "gc"
+	// EventQuotaExceeded is a failed statement whose memory reached tidb_mem_quota_query, which is likely cancelled
+	// by the memory quota.
+	EventQuotaExceeded EventType = "quota_exceeded"
+	// EventSpill is a statement spilled to the temporary storage, i.e. oom-use-tmp-storage.
+	EventSpill EventType = "spill"
+)
+
+// Event is a memory event of a TiDB instance. The statement fields are empty for GC events.
+type Event struct {
+	Type      EventType `json:"type"`
+	Timestamp float64   `json:"timestamp"`
+	Instance  string    `json:"instance"`
+	// MemoryLimit and MemoryCurrent are the memory of the instance when the session is killed or GC is triggered.
+	MemoryLimit   int64 `json:"memory_limit,omitempty"`
+	MemoryCurrent int64 `json:"memory_current,omitempty"`
+
+	ConnectionID string `json:"connection_id"`
+	User         string `json:"user"`
+	DB           string `json:"db"`
+	Digest       string `json:"digest"`
+	SQL          string `json:"sql"`
+	Memory       int64  `json:"memory"`
+	Disk         int64  `json:"disk"`
+}
+
+// opsHistoryRow is an operation of the memory limit, which is either killing a session or triggering GC.
+type opsHistoryRow struct {
+	Timestamp     float64 `gorm:"column:timestamp"`
+	Instance      string  `gorm:"column:INSTANCE"`
+	Ops           string  `gorm:"column:OPS"`
+	MemoryLimit   int64   `gorm:"column:MEMORY_LIMIT"`
+	MemoryCurrent int64   `gorm:"column:MEMORY_CURRENT"`
+	ProcessID     string  `gorm:"column:PROCESSID"`
+	Mem           int64   `gorm:"column:MEM"`
+	Disk          int64   `gorm:"column:DISK"`
+	User          string  `gorm:"column:USER"`
+	DB            string  `gorm:"column:DB"`
+	Digest        string  `gorm:"column:SQL_DIGEST"`
+	SQL           string  `gorm:"column:SQL_TEXT"`
+}
+
+// Statement is an execution in the slow log. Timestamp, Digest and ConnectionID identify the slow query.
+type Statement struct {
+	Timestamp    float64 `gorm:"column:timestamp" json:"timestamp"`
+	Instance     string  `gorm:"column:INSTANCE" json:"instance"`
+	ConnectionID string  `gorm:"column:Conn_ID" json:"connection_id"`
+	User         string  `gorm:"column:User" json:"user"`
+	DB           string  `gorm:"column:DB" json:"db"`
+	Digest       string  `gorm:"column:Digest" json:"digest"`
+	SQL          string  `gorm:"column:Query" json:"sql"`
+	QueryTime    float64 `gorm:"column:Query_time" json:"query_time"`
+	MemoryMax    int64   `gorm:"column:Mem_max" json:"memory_max"`
+	DiskMax      int64   `gorm:"column:Disk_max" json:"disk_max"`
+	Success      bool    `gorm:"column:Succ" json:"success"`
+}
+
+// DiagnosticsResponse helps finding statements running out of memory. Each section is queried independently.
+type DiagnosticsResponse struct {
+	Instances []InstanceMemory `json:"instances"`
+	// RunningStatements are the statements using the most memory now.
+	RunningStatements []RunningStatement `json:"running_statements"`
+	// Events are in the time range, the latest first.
+	Events []Event `json:"events"`
+	// TopStatements are the executions using the most memory in the time range, from the slow log.
+	TopStatements []Statement `json:"top_statements"`
+	// Errors are failures of sections keyed by the JSON name of the section, while other sections are returned.
+	Errors map[string]rest.ErrorResponse `json:"errors,omitempty"`
+}
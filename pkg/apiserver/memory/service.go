@@ -0,0 +1,320 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+// Package memory diagnoses the memory usage of TiDB instances, e.g. finding statements running out of memory.
+package memory
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/joomcode/errorx"
+	"go.uber.org/fx"
+	"gorm.io/gorm"
+
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/user"
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/utils"
+	"github.com/pingcap/tidb-dashboard/pkg/tidb"
+	"github.com/pingcap/tidb-dashboard/util/featureflag"
+	"github.com/pingcap/tidb-dashboard/util/rest"
+)
+
+const (
+	defaultLimit     = 10
+	maxLimit         = 100
+	defaultTimeRange = time.Hour
+	// maxEvents bounds events of each source, as well as the merged events.
+	maxEvents = 100
+
+	// SQL texts are truncated to 4096 characters, as they can be huge, e.g. batch inserts.
+	instanceColumns = "INSTANCE, MEMORY_TOTAL, MEMORY_LIMIT, MEMORY_CURRENT, MEMORY_MAX_USED, " +
+		"IFNULL(CURRENT_OPS, '') AS CURRENT_OPS, " +
+		"IFNULL(UNIX_TIMESTAMP(SESSION_KILL_LAST), 0) + 0E0 AS SESSION_KILL_LAST, SESSION_KILL_TOTAL, " +
+		"IFNULL(UNIX_TIMESTAMP(GC_LAST), 0) + 0E0 AS GC_LAST, GC_TOTAL, DISK_USAGE, QUERY_FORCE_DISK"
+	runningColumns = "INSTANCE, ID, USER, IFNULL(DB, '') AS DB, TIME, IFNULL(DIGEST, '') AS DIGEST, " +
+		"IFNULL(LEFT(INFO, 4096), '') AS INFO, MEM, IFNULL(DISK, 0) AS DISK"
+	opsHistoryColumns = "(UNIX_TIMESTAMP(TIME) + 0E0) AS timestamp, INSTANCE, OPS, MEMORY_LIMIT, MEMORY_CURRENT, " +
+		"IFNULL(PROCESSID, '') AS PROCESSID, IFNULL(MEM, 0) AS MEM, IFNULL(DISK, 0) AS DISK, " +
+		"IFNULL(USER, '') AS USER, IFNULL(DB, '') AS DB, IFNULL(SQL_DIGEST, '') AS SQL_DIGEST, " +
+		"IFNULL(LEFT(SQL_TEXT, 4096), '') AS SQL_TEXT"
+	statementColumns = "(UNIX_TIMESTAMP(Time) + 0E0) AS timestamp, INSTANCE, Conn_ID, User, DB, Digest, " +
+		"LEFT(Query, 4096) AS Query, Query_time, Mem_max, Disk_max, Succ"
+)
+
+var (
+	ErrNS          = errorx.NewNamespace("error.api.memory")
+	ErrQueryFailed = ErrNS.NewType("query_failed")
+)
+
+type ServiceParams struct {
+	fx.In
+	TiDBClient *tidb.Client
+}
+
+type Service struct {
+	params            ServiceParams
+	FeatureDiagnostic *featureflag.FeatureFlag
+	now               func() time.Time
+}
+
+func newService(p ServiceParams, ff *featureflag.Registry) *Service {
+	// The memory usage tables are introduced in TiDB 6.4.
+	return &Service{params: p, FeatureDiagnostic: ff.Register("memory_diagnostics", ">= 6.4.0"), now: time.Now}
+}
+
+func registerRouter(r *gin.RouterGroup, auth *user.AuthService, s *Service) {
+	endpoint := r.Group("/memory")
+	endpoint.Use(
+		auth.MWAuthRequired(),
+		s.FeatureDiagnostic.VersionGuard(),
+		utils.MWConnectTiDB(s.params.TiDBClient))
+	{
+		endpoint.GET("/diagnostics", s.getDiagnostics)
+	}
+}
+
+type DiagnosticsRequest struct {
+	// BeginTime and EndTime are the unix time range of events and top statements, which is the last hour by default.
+	BeginTime int64 `json:"begin_time" form:"begin_time"`
+	EndTime   int64 `json:"end_time" form:"end_time"`
+	// Limit is the number of running statements and top statements.
+	Limit int `json:"limit" form:"limit"`
+}
+
+// normalize checks the request and fills the defaults.
+func (r *DiagnosticsRequest) normalize(now time.Time) error {
+	if r.EndTime == 0 {
+		r.EndTime = now.Unix()
+	}
+	if r.BeginTime == 0 {
+		r.BeginTime = r.EndTime - int64(defaultTimeRange/time.Second)
+	}
+	if r.BeginTime < 0 || r.BeginTime > r.EndTime {
+		return rest.ErrBadRequest.New("invalid time range")
+	}
+	if r.Limit < 0 || r.Limit > maxLimit {
+		return rest.ErrBadRequest.New("limit must be within %d", maxLimit)
+	}
+	if r.Limit == 0 {
+		r.Limit = defaultLimit
+	}
+	return nil
+}
+
+// attachStatementUsage fills the usage of running statements in instances.
+func attachStatementUsage(instances []InstanceMemory, usages []statementUsage) {
+	byInstance := make(map[string]statementUsage, len(usages))
+	for _, u := range usages {
+		byInstance[u.Instance] = u
+	}
+	for i := range instances {
+		u := byInstance[instances[i].Instance]
+		instances[i].RunningStatements = u.Count
+		instances[i].StatementMemory = u.Mem
+		instances[i].StatementDisk = u.Disk
+	}
+}
+
+var opsEventTypes = map[string]EventType{
+	"SessionKill": EventSessionKill,
+	"GC":          EventGC,
+}
+
+func eventFromOps(row *opsHistoryRow) Event {
+	t, ok := opsEventTypes[row.Ops]
+	if !ok {
+		t = EventType(row.Ops)
+	}
+	return Event{
+		Type:          t,
+		Timestamp:     row.Timestamp,
+		Instance:      row.Instance,
+		MemoryLimit:   row.MemoryLimit,
+		MemoryCurrent: row.MemoryCurrent,
+		ConnectionID:  row.ProcessID,
+		User:          row.User,
+		DB:            row.DB,
+		Digest:        row.Digest,
+		SQL:           row.SQL,
+		Memory:        row.Mem,
+		Disk:          row.Disk,
+	}
+}
+
+// eventFromStatement classifies the statement as exceeding the memory quota or spilled. The quota is not checked
+// when it is not positive.
+func eventFromStatement(stmt *Statement, memQuota int64) Event {
+	t := EventSpill
+	if !stmt.Success && memQuota > 0 && stmt.MemoryMax >= memQuota {
+		t = EventQuotaExceeded
+	}
+	return Event{
+		Type:         t,
+		Timestamp:    stmt.Timestamp,
+		Instance:     stmt.Instance,
+		ConnectionID: stmt.ConnectionID,
+		User:         stmt.User,
+		DB:           stmt.DB,
+		Digest:       stmt.Digest,
+		SQL:          stmt.SQL,
+		Memory:       stmt.MemoryMax,
+		Disk:         stmt.DiskMax,
+	}
+}
+
+// sortEvents sorts events the latest first, and keeps maxEvents of them.
+func sortEvents(events []Event) []Event {
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Timestamp > events[j].Timestamp
+	})
+	if len(events) > maxEvents {
+		events = events[:maxEvents]
+	}
+	return events
+}
+
+func queryInstances(db *gorm.DB) ([]InstanceMemory, error) {
+	instances := make([]InstanceMemory, 0)
+	if err := db.Table(ClusterMemoryUsageTable).Select(instanceColumns).Order("INSTANCE").Find(&instances).Error; err != nil {
+		return nil, err
+	}
+	var usages []statementUsage
+	err := db.Table(ClusterProcessListTable).
+		Select("INSTANCE, COUNT(*) AS COUNT, IFNULL(SUM(MEM), 0) AS MEM, IFNULL(SUM(DISK), 0) AS DISK").
+		Where("COMMAND <> 'Sleep'").
+		Group("INSTANCE").
+		Find(&usages).Error
+	if err != nil {
+		return nil, err
+	}
+	attachStatementUsage(instances, usages)
+	return instances, nil
+}
+
+func queryRunningStatements(db *gorm.DB, limit int) ([]RunningStatement, error) {
+	statements := make([]RunningStatement, 0)
+	err := db.Table(ClusterProcessListTable).
+		Select(runningColumns).
+		Where("COMMAND <> 'Sleep' AND MEM > 0").
+		Order("MEM DESC").
+		Limit(limit).
+		Find(&statements).Error
+	return statements, err
+}
+
+// readMemQuota reads tidb_mem_quota_query, which is the memory quota of each statement.
+func readMemQuota(db *gorm.DB) (int64, error) {
+	var quota int64
+	err := db.Raw("SELECT @@GLOBAL.tidb_mem_quota_query").Row().Scan(&quota)
+	return quota, err
+}
+
+func queryEvents(db *gorm.DB, req *DiagnosticsRequest) ([]Event, error) {
+	var rows []opsHistoryRow
+	err := db.Table(ClusterMemoryUsageOpsHistoryTable).
+		Select(opsHistoryColumns).
+		Where("TIME BETWEEN FROM_UNIXTIME(?) AND FROM_UNIXTIME(?)", req.BeginTime, req.EndTime).
+		Order("TIME DESC").
+		Limit(maxEvents).
+		Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	events := make([]Event, 0, len(rows))
+	for i := range rows {
+		events = append(events, eventFromOps(&rows[i]))
+	}
+
+	memQuota, err := readMemQuota(db)
+	if err != nil {
+		return nil, err
+	}
+	var statements []Statement
+	query := db.Table(ClusterSlowQueryTable).
+		Select(statementColumns).
+		Where("Time BETWEEN FROM_UNIXTIME(?) AND FROM_UNIXTIME(?)", req.BeginTime, req.EndTime)
+	if memQuota > 0 {
+		query = query.Where("Disk_max > 0 OR (Succ = 0 AND Mem_max >= ?)", memQuota)
+	} else {
+		query = query.Where("Disk_max > 0")
+	}
+	if err := query.Order("Time DESC").Limit(maxEvents).Find(&statements).Error; err != nil {
+		return nil, err
+	}
+	for i := range statements {
+		events = append(events, eventFromStatement(&statements[i], memQuota))
+	}
+	return sortEvents(events), nil
+}
+
+func queryTopStatements(db *gorm.DB, req *DiagnosticsRequest) ([]Statement, error) {
+	statements := make([]Statement, 0)
+	err := db.Table(ClusterSlowQueryTable).
+		Select(statementColumns).
+		Where("Time BETWEEN FROM_UNIXTIME(?) AND FROM_UNIXTIME(?)", req.BeginTime, req.EndTime).
+		Where("Mem_max > 0").
+		Order("Mem_max DESC").
+		Limit(req.Limit).
+		Find(&statements).Error
+	return statements, err
+}
+
+// @ID memoryGetDiagnostics
+// @Summary Diagnose the memory usage of TiDB instances
+// @Description Returns the memory usage of each instance, statements using the most memory now and in the time
+// @Description range, and memory events, e.g. sessions killed by the memory limit, statements exceeding the memory
+// @Description quota or spilled to the temporary storage. Sections failed to be queried are reported in `errors`.
+// @Param q query DiagnosticsRequest true "Query"
+// @Success 200 {object} DiagnosticsResponse
+// @Router /memory/diagnostics [get]
+// @Security JwtAuth
+// @Failure 400 {object} rest.ErrorResponse
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 403 {object} rest.ErrorResponse
+func (s *Service) getDiagnostics(c *gin.Context) {
+	var req DiagnosticsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		rest.Error(c, rest.ErrBadRequest.WrapWithNoMessage(err))
+		return
+	}
+	if err := req.normalize(s.now()); err != nil {
+		rest.Error(c, err)
+		return
+	}
+	db := utils.GetTiDBConnection(c)
+
+	resp := DiagnosticsResponse{
+		Instances:         make([]InstanceMemory, 0),
+		RunningStatements: make([]RunningStatement, 0),
+		Events:            make([]Event, 0),
+		TopStatements:     make([]Statement, 0),
+	}
+	reportError := func(section string, err error) {
+		if resp.Errors == nil {
+			resp.Errors = map[string]rest.ErrorResponse{}
+		}
+		resp.Errors[section] = rest.NewErrorResponse(ErrQueryFailed.WrapWithNoMessage(err))
+	}
+	if instances, err := queryInstances(db); err != nil {
+		reportError("instances", err)
+	} else {
+		resp.Instances = instances
+	}
+	if statements, err := queryRunningStatements(db, req.Limit); err != nil {
+		reportError("running_statements", err)
+	} else {
+		resp.RunningStatements = statements
+	}
+	if events, err := queryEvents(db, &req); err != nil {
+		reportError("events", err)
+	} else {
+		resp.Events = events
+	}
+	if statements, err := queryTopStatements(db, &req); err != nil {
+		reportError("top_statements", err)
+	} else {
+		resp.TopStatements = statements
+	}
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,62 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package memory
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeRequest(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+
+	req := DiagnosticsRequest{}
+	require.NoError(t, req.normalize(now))
+	require.Equal(t, DiagnosticsRequest{BeginTime: 1700000000 - 3600, EndTime: 1700000000, Limit: defaultLimit}, req)
+
+	req = DiagnosticsRequest{BeginTime: 100, EndTime: 200, Limit: 5}
+	require.NoError(t, req.normalize(now))
+	require.Equal(t, DiagnosticsRequest{BeginTime: 100, EndTime: 200, Limit: 5}, req)
+
+	req = DiagnosticsRequest{BeginTime: 200, EndTime: 100}
+	require.Error(t, req.normalize(now))
+	req = DiagnosticsRequest{Limit: maxLimit + 1}
+	require.Error(t, req.normalize(now))
+	req = DiagnosticsRequest{Limit: -1}
+	require.Error(t, req.normalize(now))
+}
+
+func TestAttachStatementUsage(t *testing.T) {
+	instances := []InstanceMemory{{Instance: "a:10080"}, {Instance: "b:10080"}}
+	attachStatementUsage(instances, []statementUsage{{Instance: "b:10080", Count: 2, Mem: 300, Disk: 40}})
+	require.Equal(t, int64(0), instances[0].RunningStatements)
+	require.Equal(t, int64(2), instances[1].RunningStatements)
+	require.Equal(t, int64(300), instances[1].StatementMemory)
+	require.Equal(t, int64(40), instances[1].StatementDisk)
+}
+
+func TestEvents(t *testing.T) {
+	kill := eventFromOps(&opsHistoryRow{Timestamp: 3, Instance: "a:10080", Ops: "SessionKill", ProcessID: "7", Mem: 100})
+	require.Equal(t, EventSessionKill, kill.Type)
+	require.Equal(t, "7", kill.ConnectionID)
+	require.Equal(t, int64(100), kill.Memory)
+	gc := eventFromOps(&opsHistoryRow{Timestamp: 1, Ops: "GC"})
+	require.Equal(t, EventGC, gc.Type)
+
+	// Failed statements reaching the quota are likely cancelled by the quota, while the others are spilled.
+	exceeded := eventFromStatement(&Statement{Timestamp: 2, MemoryMax: 1024}, 1024)
+	require.Equal(t, EventQuotaExceeded, exceeded.Type)
+	require.Equal(t, int64(1024), exceeded.Memory)
+	require.Equal(t, EventSpill, eventFromStatement(&Statement{MemoryMax: 1024, Success: true, DiskMax: 1}, 1024).Type)
+	require.Equal(t, EventSpill, eventFromStatement(&Statement{MemoryMax: 512, DiskMax: 1}, 1024).Type)
+	require.Equal(t, EventSpill, eventFromStatement(&Statement{MemoryMax: 1024, DiskMax: 1}, 0).Type)
+
+	events := sortEvents([]Event{gc, kill, exceeded})
+	require.Equal(t, []EventType{EventSessionKill, EventQuotaExceeded, EventGC},
+		[]EventType{events[0].Type, events[1].Type, events[2].Type})
+
+	many := make([]Event, maxEvents+10)
+	require.Len(t, sortEvents(many), maxEvents)
+}
@@ -0,0 +1,300 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package metrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/cluster"
+	"github.com/pingcap/tidb-dashboard/util/rest"
+)
+
+const (
+	// maxComparePoints bounds the points of each series, which decides the default step.
+	maxComparePoints = 1000
+	minCompareStep   = 60
+	maxCompareRange  = 31 * 24 * 60 * 60
+)
+
+type CompareMetric string
+
+const (
+	CompareMetricQPS             CompareMetric = "qps"
+	CompareMetricP99Latency      CompareMetric = "p99_latency"
+	CompareMetricSlowQueryCount  CompareMetric = "slow_query_count"
+	CompareMetricHotStoreMaxFlow CompareMetric = "hot_store_max_flow"
+)
+
+type compareMetricDef struct {
+	// query is aggregated into a single series. `%[1]s` is replaced by the rate window, which is the step, so that
+	// no sample is skipped in a long range.
+	query string
+	unit  string
+}
+
+var compareMetrics = []CompareMetric{
+	CompareMetricQPS,
+	CompareMetricP99Latency,
+	CompareMetricSlowQueryCount,
+	CompareMetricHotStoreMaxFlow,
+}
+
+var compareMetricDefs = map[CompareMetric]compareMetricDef{
+	CompareMetricQPS: {
+		query: `sum(rate(tidb_executor_statement_total[%[1]s]))`,
+		unit:  "ops",
+	},
+	CompareMetricP99Latency: {
+		query: `histogram_quantile(0.99, sum(rate(tidb_server_handle_query_duration_seconds_bucket[%[1]s])) by (le))`,
+		unit:  "s",
+	},
+	// The count of slow queries in each step.
+	CompareMetricSlowQueryCount: {
+		query: `sum(increase(tidb_server_slow_query_process_duration_seconds_count[%[1]s]))`,
+		unit:  "count",
+	},
+	// The read and written bytes per second of hot leaders in the busiest store.
+	CompareMetricHotStoreMaxFlow: {
+		query: `max(sum(pd_hotspot_status{type=~"total_(read|written)_bytes_as_leader"}) by (address, store))`,
+		unit:  "bytes/s",
+	},
+}
+
+type CompareRequest struct {
+	// Metrics to compare. All metrics are compared when empty.
+	Metrics            []CompareMetric `json:"metrics" form:"metrics"`
+	BaseStartTimeSec   int64           `json:"base_start_time_sec" form:"base_start_time_sec"`
+	BaseEndTimeSec     int64           `json:"base_end_time_sec" form:"base_end_time_sec"`
+	TargetStartTimeSec int64           `json:"target_start_time_sec" form:"target_start_time_sec"`
+	TargetEndTimeSec   int64           `json:"target_end_time_sec" form:"target_end_time_sec"`
+	// StepSec is derived from the range when empty.
+	StepSec int64 `json:"step_sec" form:"step_sec"`
+}
+
+// ComparePoint is the values of both ranges at the same offset. Values are null when there is no sample.
+type ComparePoint struct {
+	// Offset is the seconds since the start of the ranges.
+	Offset int64    `json:"offset"`
+	Base   *float64 `json:"base"`
+	Target *float64 `json:"target"`
+}
+
+type SeriesStats struct {
+	// Count is the number of samples.
+	Count int     `json:"count"`
+	Avg   float64 `json:"avg"`
+	Min   float64 `json:"min"`
+	Max   float64 `json:"max"`
+}
+
+// CompareDelta is the change from the base range to the target range. Ratios are relative to the base, which are
+// omitted when the base is 0.
+type CompareDelta struct {
+	Avg      float64  `json:"avg"`
+	AvgRatio *float64 `json:"avg_ratio,omitempty"`
+	Max      float64  `json:"max"`
+	MaxRatio *float64 `json:"max_ratio,omitempty"`
+}
+
+type MetricComparison struct {
+	Metric CompareMetric  `json:"metric"`
+	Unit   string         `json:"unit"`
+	Points []ComparePoint `json:"points"`
+	Base   SeriesStats    `json:"base"`
+	Target SeriesStats    `json:"target"`
+	// Delta is omitted when either range has no sample.
+	Delta *CompareDelta `json:"delta,omitempty"`
+	// Error is the failure of querying the metric, while other metrics are still compared.
+	Error *rest.ErrorResponse `json:"error,omitempty"`
+}
+
+type CompareResponse struct {
+	StepSec int64              `json:"step_sec"`
+	Metrics []MetricComparison `json:"metrics"`
+}
+
+// validate checks the request and fills the defaults.
+func (r *CompareRequest) validate() error {
+	if len(r.Metrics) == 0 {
+		r.Metrics = compareMetrics
+	}
+	for _, m := range r.Metrics {
+		if _, ok := compareMetricDefs[m]; !ok {
+			return rest.ErrBadRequest.New("unknown metric %q", m)
+		}
+	}
+	duration := r.BaseEndTimeSec - r.BaseStartTimeSec
+	if r.BaseStartTimeSec <= 0 || duration <= 0 || r.TargetStartTimeSec <= 0 {
+		return rest.ErrBadRequest.New("invalid time range")
+	}
+	if r.TargetEndTimeSec-r.TargetStartTimeSec != duration {
+		return rest.ErrBadRequest.New("the base range and the target range must have the same length")
+	}
+	if duration > maxCompareRange {
+		return rest.ErrBadRequest.New("time range must be within %d days", maxCompareRange/(24*60*60))
+	}
+	if r.StepSec == 0 {
+		r.StepSec = (duration + maxComparePoints - 1) / maxComparePoints
+		if r.StepSec < minCompareStep {
+			r.StepSec = minCompareStep
+		}
+	}
+	if r.StepSec < 0 || duration/r.StepSec > maxComparePoints {
+		return rest.ErrBadRequest.New("step must be at least %ds for the time range", (duration+maxComparePoints-1)/maxComparePoints)
+	}
+	return nil
+}
+
+type promMatrixResponse struct {
+	Data struct {
+		Result []struct {
+			Values [][2]interface{} `json:"values"`
+		} `json:"result"`
+	} `json:"data"`
+}
+
+// queryPromRange returns the samples of the single series of the query by their unix time. NaN and infinite
+// samples, e.g. quantiles of no request, are skipped.
+func (s *Service) queryPromRange(addr, query string, start, end, step int64) (map[int64]float64, error) {
+	params := url.Values{}
+	params.Add("query", query)
+	params.Add("start", strconv.FormatInt(start, 10))
+	params.Add("end", strconv.FormatInt(end, 10))
+	params.Add("step", strconv.FormatInt(step, 10))
+	data, err := s.params.HTTPClient.
+		WithTimeout(defaultPromQueryTimeout).
+		SendRequest(s.lifecycleCtx, addr+"/api/v1/query_range?"+params.Encode(), http.MethodGet, nil, ErrPrometheusQueryFailed, "Prometheus")
+	if err != nil {
+		return nil, err
+	}
+	var resp promMatrixResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, ErrPrometheusQueryFailed.Wrap(err, "Prometheus query API unmarshal failed")
+	}
+	samples := make(map[int64]float64)
+	if len(resp.Data.Result) == 0 {
+		return samples, nil
+	}
+	for _, v := range resp.Data.Result[0].Values {
+		ts, ok := v[0].(float64)
+		if !ok {
+			continue
+		}
+		str, ok := v[1].(string)
+		if !ok {
+			continue
+		}
+		value, err := strconv.ParseFloat(str, 64)
+		if err != nil || math.IsNaN(value) || math.IsInf(value, 0) {
+			continue
+		}
+		samples[int64(math.Round(ts))] = value
+	}
+	return samples, nil
+}
+
+func seriesStats(values []float64) SeriesStats {
+	stats := SeriesStats{Count: len(values)}
+	if len(values) == 0 {
+		return stats
+	}
+	stats.Min, stats.Max = values[0], values[0]
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+		stats.Min = math.Min(stats.Min, v)
+		stats.Max = math.Max(stats.Max, v)
+	}
+	stats.Avg = sum / float64(len(values))
+	return stats
+}
+
+func changeRatio(base, target float64) *float64 {
+	if base == 0 {
+		return nil
+	}
+	r := (target - base) / math.Abs(base)
+	return &r
+}
+
+// alignSeries aligns the samples of both ranges by their offsets since the start of the ranges.
+func alignSeries(r *CompareRequest, base, target map[int64]float64) MetricComparison {
+	var c MetricComparison
+	var baseValues, targetValues []float64
+	for offset := int64(0); offset <= r.BaseEndTimeSec-r.BaseStartTimeSec; offset += r.StepSec {
+		p := ComparePoint{Offset: offset}
+		if v, ok := base[r.BaseStartTimeSec+offset]; ok {
+			p.Base = &v
+			baseValues = append(baseValues, v)
+		}
+		if v, ok := target[r.TargetStartTimeSec+offset]; ok {
+			p.Target = &v
+			targetValues = append(targetValues, v)
+		}
+		c.Points = append(c.Points, p)
+	}
+	c.Base = seriesStats(baseValues)
+	c.Target = seriesStats(targetValues)
+	if c.Base.Count > 0 && c.Target.Count > 0 {
+		c.Delta = &CompareDelta{
+			Avg:      c.Target.Avg - c.Base.Avg,
+			AvgRatio: changeRatio(c.Base.Avg, c.Target.Avg),
+			Max:      c.Target.Max - c.Base.Max,
+			MaxRatio: changeRatio(c.Base.Max, c.Target.Max),
+		}
+	}
+	return c
+}
+
+func (s *Service) compareMetric(addr string, r *CompareRequest, metric CompareMetric) MetricComparison {
+	def := compareMetricDefs[metric]
+	query := fmt.Sprintf(def.query, strconv.FormatInt(r.StepSec, 10)+"s")
+	var c MetricComparison
+	base, err := s.queryPromRange(addr, query, r.BaseStartTimeSec, r.BaseEndTimeSec, r.StepSec)
+	if err == nil {
+		var target map[int64]float64
+		target, err = s.queryPromRange(addr, query, r.TargetStartTimeSec, r.TargetEndTimeSec, r.StepSec)
+		if err == nil {
+			c = alignSeries(r, base, target)
+		}
+	}
+	if err != nil {
+		e := rest.NewErrorResponse(err)
+		c.Error = &e
+	}
+	c.Metric = metric
+	c.Unit = def.unit
+	return c
+}
+
+// compareRanges queries the metrics of both ranges concurrently.
+func (s *Service) compareRanges(clients *cluster.Clients, r *CompareRequest) (*CompareResponse, error) {
+	if err := r.validate(); err != nil {
+		return nil, err
+	}
+	addr, err := s.getPromAddressFromCache(clients)
+	if err != nil {
+		return nil, ErrLoadPrometheusAddressFailed.Wrap(err, "Load prometheus address failed")
+	}
+	if addr == "" {
+		return nil, ErrPrometheusNotFound.New("Prometheus is not deployed in the cluster")
+	}
+
+	resp := &CompareResponse{StepSec: r.StepSec, Metrics: make([]MetricComparison, len(r.Metrics))}
+	var wg sync.WaitGroup
+	for i, metric := range r.Metrics {
+		wg.Add(1)
+		go func(i int, metric CompareMetric) {
+			defer wg.Done()
+			resp.Metrics[i] = s.compareMetric(addr, r, metric)
+		}(i, metric)
+	}
+	wg.Wait()
+	return resp, nil
+}
@@ -0,0 +1,116 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/joomcode/errorx"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/fx/fxtest"
+
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/cluster"
+	"github.com/pingcap/tidb-dashboard/pkg/config"
+	"github.com/pingcap/tidb-dashboard/pkg/httpc"
+	"github.com/pingcap/tidb-dashboard/util/rest"
+)
+
+func TestCompareRequestValidate(t *testing.T) {
+	r := CompareRequest{BaseStartTimeSec: 1000, BaseEndTimeSec: 1000 + 7*86400, TargetStartTimeSec: 1000 + 7*86400, TargetEndTimeSec: 1000 + 14*86400}
+	require.NoError(t, r.validate())
+	require.Equal(t, compareMetrics, r.Metrics)
+	require.Equal(t, int64(605), r.StepSec)
+
+	r = CompareRequest{BaseStartTimeSec: 1000, BaseEndTimeSec: 4600, TargetStartTimeSec: 5000, TargetEndTimeSec: 8600}
+	require.NoError(t, r.validate())
+	require.Equal(t, int64(minCompareStep), r.StepSec)
+
+	for _, r := range []CompareRequest{
+		{Metrics: []CompareMetric{"foo"}, BaseStartTimeSec: 1000, BaseEndTimeSec: 4600, TargetStartTimeSec: 5000, TargetEndTimeSec: 8600},
+		{BaseStartTimeSec: 4600, BaseEndTimeSec: 1000, TargetStartTimeSec: 5000, TargetEndTimeSec: 1400},
+		{BaseStartTimeSec: 1000, BaseEndTimeSec: 4600, TargetStartTimeSec: 5000, TargetEndTimeSec: 5600},
+		{BaseStartTimeSec: 1000, BaseEndTimeSec: 1000 + 40*86400, TargetStartTimeSec: 1000, TargetEndTimeSec: 1000 + 40*86400},
+		{BaseStartTimeSec: 1000, BaseEndTimeSec: 4600, TargetStartTimeSec: 5000, TargetEndTimeSec: 8600, StepSec: 1},
+	} {
+		require.True(t, errorx.IsOfType(r.validate(), rest.ErrBadRequest))
+	}
+}
+
+func TestCompareRanges(t *testing.T) {
+	// Values of the base range are 1, 2, 3, and the target range doubles them, except for a missing and a NaN sample.
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("query") == compareQuery(CompareMetricP99Latency, "60s") {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		require.Equal(t, compareQuery(CompareMetricQPS, "60s"), q.Get("query"))
+		require.Equal(t, "60", q.Get("step"))
+		if q.Get("start") == "1000" {
+			_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"matrix","result":[{"metric":{},"values":[[1000,"1"],[1060,"2"],[1120,"3"]]}]}}`))
+		} else {
+			_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"matrix","result":[{"metric":{},"values":[[5000,"2"],[5060,"NaN"]]}]}}`))
+		}
+	}))
+	defer ts.Close()
+	s := &Service{
+		params:       ServiceParams{HTTPClient: httpc.NewHTTPClient(fxtest.NewLifecycle(t), &config.Config{})},
+		lifecycleCtx: context.Background(),
+	}
+	clients := &cluster.Clients{ClusterID: cluster.DefaultClusterID}
+	s.promAddressCache.Store(clients.ClusterID, &promAddressCacheEntity{address: ts.URL, cacheAt: time.Now()})
+
+	resp, err := s.compareRanges(clients, &CompareRequest{
+		Metrics:            []CompareMetric{CompareMetricQPS, CompareMetricP99Latency},
+		BaseStartTimeSec:   1000,
+		BaseEndTimeSec:     1120,
+		TargetStartTimeSec: 5000,
+		TargetEndTimeSec:   5120,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(60), resp.StepSec)
+	require.Len(t, resp.Metrics, 2)
+
+	qps := resp.Metrics[0]
+	require.Nil(t, qps.Error)
+	require.Equal(t, "ops", qps.Unit)
+	require.Len(t, qps.Points, 3)
+	require.Equal(t, 1.0, *qps.Points[0].Base)
+	require.Equal(t, 2.0, *qps.Points[0].Target)
+	require.Equal(t, 2.0, *qps.Points[1].Base)
+	require.Nil(t, qps.Points[1].Target)
+	require.Nil(t, qps.Points[2].Target)
+	require.Equal(t, SeriesStats{Count: 3, Avg: 2, Min: 1, Max: 3}, qps.Base)
+	require.Equal(t, SeriesStats{Count: 1, Avg: 2, Min: 2, Max: 2}, qps.Target)
+	require.Equal(t, 0.0, qps.Delta.Avg)
+	require.Equal(t, 0.0, *qps.Delta.AvgRatio)
+	require.Equal(t, -1.0, qps.Delta.Max)
+	require.InDelta(t, -1.0/3, *qps.Delta.MaxRatio, 1e-9)
+
+	p99 := resp.Metrics[1]
+	require.Equal(t, CompareMetricP99Latency, p99.Metric)
+	require.NotNil(t, p99.Error)
+	require.Nil(t, p99.Delta)
+}
+
+func compareQuery(metric CompareMetric, window string) string {
+	return fmt.Sprintf(compareMetricDefs[metric].query, window)
+}
+
+func TestAlignSeriesWithoutSamples(t *testing.T) {
+	r := &CompareRequest{BaseStartTimeSec: 0, BaseEndTimeSec: 120, TargetStartTimeSec: 600, TargetEndTimeSec: 720, StepSec: 60}
+	c := alignSeries(r, map[int64]float64{0: 0, 60: 0}, map[int64]float64{660: 5})
+	require.Len(t, c.Points, 3)
+	require.Equal(t, int64(120), c.Points[2].Offset)
+	require.Equal(t, 5.0, c.Delta.Max)
+	require.Nil(t, c.Delta.MaxRatio)
+
+	c = alignSeries(r, map[int64]float64{}, map[int64]float64{660: 5})
+	require.Nil(t, c.Delta)
+	require.Zero(t, c.Base.Count)
+}
@@ -12,6 +12,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/cluster"
 	"github.com/pingcap/tidb-dashboard/pkg/utils/topology"
 )
 
@@ -51,9 +52,9 @@ func normalizeCustomizedPromAddress(addr string) (string, error) {
 
 // Resolve the customized Prometheus address in PD config. If it is not configured, empty address will be returned.
 // The returned address must be valid. If an invalid Prometheus address is configured, errors will be returned.
-func (s *Service) resolveCustomizedPromAddress(acceptInvalidAddr bool) (string, error) {
+func (s *Service) resolveCustomizedPromAddress(clients *cluster.Clients, acceptInvalidAddr bool) (string, error) {
 	// Lookup "metric-storage" cluster config in PD.
-	data, err := s.params.PDClient.SendGetRequest("/config")
+	data, err := clients.PDClient.SendGetRequest("/config")
 	if err != nil {
 		return "", err
 	}
@@ -78,8 +79,8 @@ func (s *Service) resolveCustomizedPromAddress(acceptInvalidAddr bool) (string,
 
 // Resolve the Prometheus address recorded by deployment tools in the `/topology` etcd namespace.
 // If the address is not recorded (for example, when Prometheus is not deployed), empty address will be returned.
-func (s *Service) resolveDeployedPromAddress() (string, error) {
-	pi, err := topology.FetchPrometheusTopology(s.lifecycleCtx, s.params.EtcdClient)
+func (s *Service) resolveDeployedPromAddress(clients *cluster.Clients) (string, error) {
+	pi, err := topology.FetchPrometheusTopology(s.lifecycleCtx, clients.EtcdClient)
 	if err != nil {
 		return "", err
 	}
@@ -92,15 +93,15 @@ func (s *Service) resolveDeployedPromAddress() (string, error) {
 // Resolve the final Prometheus address. When user has customized an address, this address is returned. Otherwise,
 // address recorded by deployment tools will be returned.
 // If neither custom address nor deployed address is available, empty address will be returned.
-func (s *Service) resolveFinalPromAddress() (string, error) {
-	addr, err := s.resolveCustomizedPromAddress(false)
+func (s *Service) resolveFinalPromAddress(clients *cluster.Clients) (string, error) {
+	addr, err := s.resolveCustomizedPromAddress(clients, false)
 	if err != nil {
 		return "", err
 	}
 	if addr != "" {
 		return addr, nil
 	}
-	addr, err = s.resolveDeployedPromAddress()
+	addr, err = s.resolveDeployedPromAddress(clients)
 	if err != nil {
 		return "", err
 	}
@@ -110,12 +111,12 @@ func (s *Service) resolveFinalPromAddress() (string, error) {
 	return "", nil
 }
 
-// Get the final Prometheus address from cache. If cache item is not valid, the address will be resolved from PD
-// or etcd and then the cache will be updated.
-func (s *Service) getPromAddressFromCache() (string, error) {
+// Get the final Prometheus address of the cluster from cache. If cache item is not valid, the address will be
+// resolved from PD or etcd and then the cache will be updated.
+func (s *Service) getPromAddressFromCache(clients *cluster.Clients) (string, error) {
 	fn := func() (string, error) {
 		// Check whether cache is valid, and use the cache if possible.
-		if v := s.promAddressCache.Load(); v != nil {
+		if v, ok := s.promAddressCache.Load(clients.ClusterID); ok {
 			entity := v.(*promAddressCacheEntity)
 			if entity.cacheAt.Add(promCacheTTL).After(time.Now()) {
 				return entity.address, nil
@@ -123,12 +124,12 @@ func (s *Service) getPromAddressFromCache() (string, error) {
 		}
 
 		// Cache is not valid, read from PD and etcd.
-		addr, err := s.resolveFinalPromAddress()
+		addr, err := s.resolveFinalPromAddress(clients)
 		if err != nil {
 			return "", err
 		}
 
-		s.promAddressCache.Store(&promAddressCacheEntity{
+		s.promAddressCache.Store(clients.ClusterID, &promAddressCacheEntity{
 			address: addr,
 			cacheAt: time.Now(),
 		})
@@ -136,7 +137,7 @@ func (s *Service) getPromAddressFromCache() (string, error) {
 		return addr, nil
 	}
 
-	resolveResult, err, _ := s.promRequestGroup.Do("any_key", func() (interface{}, error) {
+	resolveResult, err, _ := s.promRequestGroup.Do(clients.ClusterID, func() (interface{}, error) {
 		return fn()
 	})
 	if err != nil {
@@ -147,7 +148,7 @@ func (s *Service) getPromAddressFromCache() (string, error) {
 
 // Set the customized Prometheus address. Address can be empty or a valid address like `http://host:port`.
 // If address is set to empty, address from deployment tools will be used later.
-func (s *Service) setCustomPromAddress(addr string) (string, error) {
+func (s *Service) setCustomPromAddress(clients *cluster.Clients, addr string) (string, error) {
 	var err error
 	if len(addr) > 0 {
 		addr, err = normalizeCustomizedPromAddress(addr)
@@ -163,16 +164,26 @@ func (s *Service) setCustomPromAddress(addr string) (string, error) {
 		return "", err
 	}
 
-	_, err = s.params.PDClient.SendPostRequest("/config", bytes.NewBuffer(bodyJSON))
+	_, err = clients.PDClient.SendPostRequest("/config", bytes.NewBuffer(bodyJSON))
 	if err != nil {
 		return "", err
 	}
 
 	// Invalidate cache immediately.
-	s.promAddressCache.Value.Store(&promAddressCacheEntity{
+	s.promAddressCache.Store(clients.ClusterID, &promAddressCacheEntity{
 		address: addr,
 		cacheAt: time.Time{},
 	})
 
 	return addr, nil
 }
+
+// ResolvePromAddress returns the Prometheus address in use of the default cluster. Empty address is returned when
+// Prometheus is neither customized nor deployed.
+func (s *Service) ResolvePromAddress() (string, error) {
+	clients, err := s.params.Registry.Resolve(cluster.DefaultClusterID)
+	if err != nil {
+		return "", err
+	}
+	return s.getPromAddressFromCache(clients)
+}
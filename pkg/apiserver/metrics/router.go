@@ -11,6 +11,8 @@ import (
 
 	"github.com/gin-gonic/gin"
 
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/audit"
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/cluster"
 	"github.com/pingcap/tidb-dashboard/pkg/apiserver/user"
 	"github.com/pingcap/tidb-dashboard/util/rest"
 )
@@ -27,12 +29,14 @@ type QueryResponse struct {
 	Data   map[string]interface{} `json:"data"`
 }
 
-func RegisterRouter(r *gin.RouterGroup, auth *user.AuthService, s *Service) {
+func RegisterRouter(r *gin.RouterGroup, auth *user.AuthService, a *audit.Service, s *Service) {
 	endpoint := r.Group("/metrics")
-	endpoint.Use(auth.MWAuthRequired())
+	endpoint.Use(auth.MWAuthRequired(), s.params.Registry.MWResolveCluster())
 	endpoint.GET("/query", s.queryMetrics)
 	endpoint.GET("/prom_address", s.getPromAddressConfig)
-	endpoint.PUT("/prom_address", auth.MWRequireWritePriv(), s.putCustomPromAddress)
+	endpoint.GET("/targets", s.getTargets)
+	endpoint.GET("/compare", s.compareMetrics)
+	endpoint.PUT("/prom_address", auth.MWRequireWritePriv(), a.MWRecord("metrics.set_prom_address"), s.putCustomPromAddress)
 }
 
 // @Summary Query metrics
@@ -49,7 +53,7 @@ func (s *Service) queryMetrics(c *gin.Context) {
 		return
 	}
 
-	addr, err := s.getPromAddressFromCache()
+	addr, err := s.getPromAddressFromCache(cluster.GetClients(c))
 	if err != nil {
 		rest.Error(c, ErrLoadPrometheusAddressFailed.Wrap(err, "Load prometheus address failed"))
 		return
@@ -105,12 +109,13 @@ type GetPromAddressConfigResponse struct {
 // @Security JwtAuth
 // @Router /metrics/prom_address [get]
 func (s *Service) getPromAddressConfig(c *gin.Context) {
-	cAddr, err := s.resolveCustomizedPromAddress(true)
+	clients := cluster.GetClients(c)
+	cAddr, err := s.resolveCustomizedPromAddress(clients, true)
 	if err != nil {
 		rest.Error(c, err)
 		return
 	}
-	dAddr, err := s.resolveDeployedPromAddress()
+	dAddr, err := s.resolveDeployedPromAddress(clients)
 	if err != nil {
 		rest.Error(c, err)
 		return
@@ -142,7 +147,7 @@ func (s *Service) putCustomPromAddress(c *gin.Context) {
 		rest.Error(c, rest.ErrBadRequest.NewWithNoMessage())
 		return
 	}
-	addr, err := s.setCustomPromAddress(req.Addr)
+	addr, err := s.setCustomPromAddress(cluster.GetClients(c), req.Addr)
 	if err != nil {
 		rest.Error(c, err)
 		return
@@ -151,3 +156,44 @@ func (s *Service) putCustomPromAddress(c *gin.Context) {
 		NormalizedAddr: addr,
 	})
 }
+
+// @ID metricsGetTargets
+// @Summary Get the scrape status of cluster components in Prometheus
+// @Description Components are matched with Prometheus targets by their status addresses. Components not scraped are reported as missing.
+// @Success 200 {object} TargetsResponse
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 500 {object} rest.ErrorResponse
+// @Security JwtAuth
+// @Router /metrics/targets [get]
+func (s *Service) getTargets(c *gin.Context) {
+	r, err := s.getScrapeTargets(cluster.GetClients(c))
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, r)
+}
+
+// @ID metricsCompare
+// @Summary Compare key metrics of two time ranges
+// @Description Series of both ranges are aligned by their offsets since the start of the ranges, e.g. this week and last week. Failures of some metrics are reported in their comparisons.
+// @Param q query CompareRequest true "Query"
+// @Success 200 {object} CompareResponse
+// @Failure 400 {object} rest.ErrorResponse
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 500 {object} rest.ErrorResponse
+// @Security JwtAuth
+// @Router /metrics/compare [get]
+func (s *Service) compareMetrics(c *gin.Context) {
+	var req CompareRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		rest.Error(c, rest.ErrBadRequest.NewWithNoMessage())
+		return
+	}
+	r, err := s.compareRanges(cluster.GetClients(c), &req)
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, r)
+}
@@ -4,14 +4,14 @@ package metrics
 
 import (
 	"context"
+	"sync"
 	"time"
 
 	"github.com/joomcode/errorx"
-	"go.etcd.io/etcd/clientv3"
-	"go.uber.org/atomic"
 	"go.uber.org/fx"
 	"golang.org/x/sync/singleflight"
 
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/cluster"
 	"github.com/pingcap/tidb-dashboard/pkg/httpc"
 	"github.com/pingcap/tidb-dashboard/pkg/pd"
 )
@@ -29,9 +29,10 @@ const (
 
 type ServiceParams struct {
 	fx.In
-	HTTPClient *httpc.Client
-	EtcdClient *clientv3.Client
-	PDClient   *pd.Client
+	HTTPClient  *httpc.Client
+	EtcdClients *pd.EtcdClientManager
+	PDClient    *pd.Client
+	Registry    *cluster.Registry
 }
 
 type Service struct {
@@ -39,7 +40,8 @@ type Service struct {
 	lifecycleCtx context.Context
 
 	promRequestGroup singleflight.Group
-	promAddressCache atomic.Value
+	// promAddressCache caches the *promAddressCacheEntity of each cluster by the cluster ID.
+	promAddressCache sync.Map
 }
 
 func NewService(lc fx.Lifecycle, p ServiceParams) *Service {
@@ -0,0 +1,239 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package metrics
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/cluster"
+	"github.com/pingcap/tidb-dashboard/pkg/utils/topology"
+	"github.com/pingcap/tidb-dashboard/util/rest"
+	"github.com/pingcap/tidb-dashboard/util/topo"
+)
+
+const defaultPromTargetsTimeout = time.Second * 10
+
+type ScrapeStatus string
+
+const (
+	// ScrapeStatusUp means the instance is scraped successfully.
+	ScrapeStatusUp ScrapeStatus = "up"
+	// ScrapeStatusFailing means all scrapes of the instance are failing.
+	ScrapeStatusFailing ScrapeStatus = "failing"
+	// ScrapeStatusUnknown means the instance is not scraped yet.
+	ScrapeStatusUnknown ScrapeStatus = "unknown"
+	// ScrapeStatusMissing means the instance is not a target of Prometheus.
+	ScrapeStatusMissing ScrapeStatus = "missing"
+)
+
+type ScrapeTarget struct {
+	Job        string    `json:"job"`
+	Instance   string    `json:"instance"`
+	ScrapeURL  string    `json:"scrape_url"`
+	Health     string    `json:"health"`
+	LastError  string    `json:"last_error,omitempty"`
+	LastScrape time.Time `json:"last_scrape"`
+}
+
+type ComponentScrapeStatus struct {
+	Component topo.Kind `json:"component"`
+	// Instance is the address scraped for metrics, i.e. the status address of the instance.
+	Instance  string       `json:"instance"`
+	Status    ScrapeStatus `json:"status"`
+	Jobs      []string     `json:"jobs,omitempty"`
+	LastError string       `json:"last_error,omitempty"`
+}
+
+type TargetsResponse struct {
+	PromAddress string                  `json:"prom_address"`
+	Components  []ComponentScrapeStatus `json:"components"`
+	Targets     []ScrapeTarget          `json:"targets"`
+	// Errors are failures of listing some components, whose scrape status is not reported.
+	Errors []rest.ErrorResponse `json:"errors"`
+}
+
+type promTargetsResponse struct {
+	Data struct {
+		ActiveTargets []struct {
+			Labels     map[string]string `json:"labels"`
+			ScrapePool string            `json:"scrapePool"`
+			ScrapeURL  string            `json:"scrapeUrl"`
+			Health     string            `json:"health"`
+			LastError  string            `json:"lastError"`
+			LastScrape time.Time         `json:"lastScrape"`
+		} `json:"activeTargets"`
+	} `json:"data"`
+}
+
+// metricsInstance is a cluster component instance expected to be scraped.
+type metricsInstance struct {
+	component topo.Kind
+	address   string
+}
+
+func (s *Service) fetchPromTargets(addr string) ([]ScrapeTarget, error) {
+	data, err := s.params.HTTPClient.
+		WithTimeout(defaultPromTargetsTimeout).
+		SendRequest(s.lifecycleCtx, addr+"/api/v1/targets?state=active", http.MethodGet, nil, ErrPrometheusQueryFailed, "Prometheus")
+	if err != nil {
+		return nil, err
+	}
+	var resp promTargetsResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, ErrPrometheusQueryFailed.Wrap(err, "Prometheus targets API unmarshal failed")
+	}
+	targets := make([]ScrapeTarget, 0, len(resp.Data.ActiveTargets))
+	for _, t := range resp.Data.ActiveTargets {
+		job := t.Labels["job"]
+		if job == "" {
+			job = t.ScrapePool
+		}
+		targets = append(targets, ScrapeTarget{
+			Job:        job,
+			Instance:   t.Labels["instance"],
+			ScrapeURL:  t.ScrapeURL,
+			Health:     t.Health,
+			LastError:  t.LastError,
+			LastScrape: t.LastScrape,
+		})
+	}
+	sort.Slice(targets, func(i, j int) bool {
+		if targets[i].Job != targets[j].Job {
+			return targets[i].Job < targets[j].Job
+		}
+		return targets[i].Instance < targets[j].Instance
+	})
+	return targets, nil
+}
+
+// listMetricsInstances lists the instances of cluster components exposing metrics. Components failed to list
+// are returned as errors, while other components are still listed.
+func (s *Service) listMetricsInstances(clients *cluster.Clients) ([]metricsInstance, []rest.ErrorResponse) {
+	instances := make([]metricsInstance, 0)
+	errors := make([]rest.ErrorResponse, 0)
+	add := func(kind topo.Kind, ip string, port uint) {
+		instances = append(instances, metricsInstance{component: kind, address: net.JoinHostPort(ip, strconv.Itoa(int(port)))})
+	}
+
+	if pdInfo, err := topology.FetchPDTopology(clients.PDClient); err != nil {
+		errors = append(errors, rest.NewErrorResponse(err))
+	} else {
+		for _, i := range pdInfo {
+			add(topo.KindPD, i.IP, i.Port)
+		}
+	}
+	if tikvInfo, tiflashInfo, err := topology.FetchStoreTopology(clients.PDClient); err != nil {
+		errors = append(errors, rest.NewErrorResponse(err))
+	} else {
+		for _, i := range tikvInfo {
+			if i.Status != topology.ComponentStatusTombstone {
+				add(topo.KindTiKV, i.IP, i.StatusPort)
+			}
+		}
+		for _, i := range tiflashInfo {
+			if i.Status != topology.ComponentStatusTombstone {
+				add(topo.KindTiFlash, i.IP, i.StatusPort)
+			}
+		}
+	}
+	if tidbInfo, err := topology.FetchTiDBTopology(s.lifecycleCtx, clients.EtcdClient); err != nil {
+		errors = append(errors, rest.NewErrorResponse(err))
+	} else {
+		for _, i := range tidbInfo {
+			add(topo.KindTiDB, i.IP, i.StatusPort)
+		}
+	}
+	if cdcInfo, err := topology.FetchTiCDCTopology(s.lifecycleCtx, clients.EtcdClient); err != nil {
+		errors = append(errors, rest.NewErrorResponse(err))
+	} else {
+		for _, i := range cdcInfo {
+			// TiCDC serves metrics on the same port as its API.
+			add(topo.KindTiCDC, i.IP, i.Port)
+		}
+	}
+	if proxyInfo, err := topology.FetchTiProxyTopology(s.lifecycleCtx, clients.EtcdClient); err != nil {
+		errors = append(errors, rest.NewErrorResponse(err))
+	} else {
+		for _, i := range proxyInfo {
+			add(topo.KindTiProxy, i.IP, i.StatusPort)
+		}
+	}
+	return instances, errors
+}
+
+// targetAddress returns the host and port scraped by the target.
+func targetAddress(t ScrapeTarget) string {
+	if u, err := url.Parse(t.ScrapeURL); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return t.Instance
+}
+
+// matchScrapeTargets reports the scrape status of each instance according to the targets scraping its address.
+// An instance is up when any of its targets is up, e.g. TiFlash is scraped by multiple jobs.
+func matchScrapeTargets(instances []metricsInstance, targets []ScrapeTarget) []ComponentScrapeStatus {
+	byAddress := make(map[string][]ScrapeTarget)
+	for _, t := range targets {
+		addr := targetAddress(t)
+		byAddress[addr] = append(byAddress[addr], t)
+	}
+
+	result := make([]ComponentScrapeStatus, 0, len(instances))
+	for _, i := range instances {
+		status := ComponentScrapeStatus{Component: i.component, Instance: i.address, Status: ScrapeStatusMissing}
+		for _, t := range byAddress[i.address] {
+			status.Jobs = append(status.Jobs, t.Job)
+			switch t.Health {
+			case "up":
+				status.Status = ScrapeStatusUp
+			case "down":
+				if status.Status != ScrapeStatusUp {
+					status.Status = ScrapeStatusFailing
+					status.LastError = t.LastError
+				}
+			default:
+				if status.Status == ScrapeStatusMissing {
+					status.Status = ScrapeStatusUnknown
+				}
+			}
+		}
+		if status.Status == ScrapeStatusUp {
+			status.LastError = ""
+		}
+		result = append(result, status)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Component != result[j].Component {
+			return result[i].Component < result[j].Component
+		}
+		return result[i].Instance < result[j].Instance
+	})
+	return result
+}
+
+func (s *Service) getScrapeTargets(clients *cluster.Clients) (*TargetsResponse, error) {
+	addr, err := s.getPromAddressFromCache(clients)
+	if err != nil {
+		return nil, ErrLoadPrometheusAddressFailed.Wrap(err, "Load prometheus address failed")
+	}
+	if addr == "" {
+		return nil, ErrPrometheusNotFound.New("Prometheus is not deployed in the cluster")
+	}
+	targets, err := s.fetchPromTargets(addr)
+	if err != nil {
+		return nil, err
+	}
+	instances, errors := s.listMetricsInstances(clients)
+	return &TargetsResponse{
+		PromAddress: addr,
+		Components:  matchScrapeTargets(instances, targets),
+		Targets:     targets,
+		Errors:      errors,
+	}, nil
+}
@@ -0,0 +1,37 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package metrics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pingcap/tidb-dashboard/util/topo"
+)
+
+func TestMatchScrapeTargets(t *testing.T) {
+	instances := []metricsInstance{
+		{component: topo.KindTiDB, address: "10.0.1.1:10080"},
+		{component: topo.KindTiKV, address: "10.0.1.2:20180"},
+		{component: topo.KindTiFlash, address: "10.0.1.3:20292"},
+		{component: topo.KindPD, address: "10.0.1.4:2379"},
+		{component: topo.KindTiCDC, address: "10.0.1.5:8300"},
+	}
+	targets := []ScrapeTarget{
+		{Job: "tidb", Instance: "10.0.1.1:10080", ScrapeURL: "http://10.0.1.1:10080/metrics", Health: "up"},
+		{Job: "tikv", Instance: "tikv-1", ScrapeURL: "http://10.0.1.2:20180/metrics", Health: "down", LastError: "connection refused"},
+		{Job: "tiflash", Instance: "10.0.1.3:20292", ScrapeURL: "http://10.0.1.3:20292/metrics", Health: "down", LastError: "timeout"},
+		{Job: "tiflash-learner", Instance: "10.0.1.3:20292", Health: "up"},
+		{Job: "pd", Instance: "10.0.1.4:2379", ScrapeURL: "http://10.0.1.4:2379/metrics", Health: "unknown"},
+		{Job: "node_exporter", Instance: "10.0.1.1:9100", ScrapeURL: "http://10.0.1.1:9100/metrics", Health: "up"},
+	}
+
+	require.Equal(t, []ComponentScrapeStatus{
+		{Component: topo.KindPD, Instance: "10.0.1.4:2379", Status: ScrapeStatusUnknown, Jobs: []string{"pd"}},
+		{Component: topo.KindTiCDC, Instance: "10.0.1.5:8300", Status: ScrapeStatusMissing},
+		{Component: topo.KindTiDB, Instance: "10.0.1.1:10080", Status: ScrapeStatusUp, Jobs: []string{"tidb"}},
+		{Component: topo.KindTiFlash, Instance: "10.0.1.3:20292", Status: ScrapeStatusUp, Jobs: []string{"tiflash", "tiflash-learner"}},
+		{Component: topo.KindTiKV, Instance: "10.0.1.2:20180", Status: ScrapeStatusFailing, Jobs: []string{"tikv"}, LastError: "connection refused"},
+	}, matchScrapeTargets(instances, targets))
+}
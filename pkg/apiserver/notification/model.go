@@ -0,0 +1,146 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package notification
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// maskedValue replaces secrets in responses to users without the write privilege.
+const maskedValue = "******"
+
+type EventType string
+
+const (
+	EventReportFinished          EventType = "report_finished"
+	EventProfilingFinished       EventType = "profiling_finished"
+	EventStatementWatchTriggered EventType = "statement_watch_triggered"
+	EventNodeDown                EventType = "node_down"
+	// EventTest is only sent by testing a channel, which is not subscribable.
+	EventTest EventType = "test"
+)
+
+var subscribableEvents = map[EventType]struct{}{
+	EventReportFinished:          {},
+	EventProfilingFinished:       {},
+	EventStatementWatchTriggered: {},
+	EventNodeDown:                {},
+}
+
+// Event is a dashboard-originated event delivered to the subscribed channels.
+type Event struct {
+	Type    EventType `json:"type"`
+	Title   string    `json:"title"`
+	Message string    `json:"message"`
+	Time    time.Time `json:"time"`
+	// Details are the event specific fields, e.g. the ID of the finished report.
+	Details map[string]interface{} `json:"details,omitempty"`
+}
+
+type ChannelType string
+
+const (
+	// ChannelTypeGeneric receives the event as is in JSON.
+	ChannelTypeGeneric ChannelType = "generic"
+	ChannelTypeSlack   ChannelType = "slack"
+	ChannelTypeLark    ChannelType = "lark"
+)
+
+type EventTypeList []EventType
+
+func (l *EventTypeList) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case string:
+		return json.Unmarshal([]byte(v), l)
+	case []byte:
+		return json.Unmarshal(v, l)
+	default:
+		return fmt.Errorf("unsupported type %T of event types", src)
+	}
+}
+
+func (l EventTypeList) Value() (driver.Value, error) {
+	val, err := json.Marshal(l)
+	return string(val), err
+}
+
+// ChannelModel is a webhook receiving events.
+type ChannelModel struct {
+	ID         uint        `json:"id" gorm:"primary_key"`
+	Name       string      `json:"name"`
+	Type       ChannelType `json:"type"`
+	WebhookURL string      `json:"webhook_url" gorm:"type:text"`
+	// Events are the subscribed event types. All events are subscribed when empty.
+	Events    EventTypeList `json:"events" gorm:"type:text"`
+	CreatedBy string        `json:"created_by"`
+	CreatedAt time.Time     `json:"created_at"`
+
+	// LastDeliveryAt and LastDeliveryError are of the last delivery after retries.
+	LastDeliveryAt    time.Time `json:"last_delivery_at"`
+	LastDeliveryError string    `json:"last_delivery_error" gorm:"type:text"`
+}
+
+func (ChannelModel) TableName() string {
+	return "notification_channels"
+}
+
+// maskWebhookURL keeps the scheme and the host of the webhook URL, as the path and the query usually contain the
+// token to post to the channel.
+func maskWebhookURL(webhookURL string) string {
+	u, err := url.Parse(webhookURL)
+	if err != nil || u.Host == "" {
+		return maskedValue
+	}
+	return u.Scheme + "://" + u.Host + "/" + maskedValue
+}
+
+// mask hides the webhook URL of the channel, including in the delivery error.
+func (m *ChannelModel) mask() {
+	masked := maskWebhookURL(m.WebhookURL)
+	if m.WebhookURL != "" {
+		m.LastDeliveryError = strings.ReplaceAll(m.LastDeliveryError, m.WebhookURL, masked)
+	}
+	m.WebhookURL = masked
+}
+
+func (m *ChannelModel) subscribes(t EventType) bool {
+	if len(m.Events) == 0 {
+		return true
+	}
+	for _, e := range m.Events {
+		if e == t {
+			return true
+		}
+	}
+	return false
+}
+
+type slackPayload struct {
+	Text string `json:"text"`
+}
+
+type larkPayload struct {
+	MsgType string `json:"msg_type"`
+	Content struct {
+		Text string `json:"text"`
+	} `json:"content"`
+}
+
+// buildPayload encodes the event in the format accepted by the channel type.
+func buildPayload(t ChannelType, e *Event) ([]byte, error) {
+	switch t {
+	case ChannelTypeSlack:
+		return json.Marshal(slackPayload{Text: fmt.Sprintf("*%s*\n%s", e.Title, e.Message)})
+	case ChannelTypeLark:
+		p := larkPayload{MsgType: "text"}
+		p.Content.Text = fmt.Sprintf("%s\n%s", e.Title, e.Message)
+		return json.Marshal(p)
+	default:
+		return json.Marshal(e)
+	}
+}
@@ -0,0 +1,183 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package notification
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/audit"
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/user"
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/utils"
+	"github.com/pingcap/tidb-dashboard/util/rest"
+)
+
+func registerRouter(r *gin.RouterGroup, auth *user.AuthService, a *audit.Service, s *Service) {
+	endpoint := r.Group("/notifications/channels")
+	endpoint.Use(auth.MWAuthRequired())
+	endpoint.GET("", s.listChannelsHandler)
+	endpoint.POST("", auth.MWRequireWritePriv(), a.MWRecord("notification.channel.create", audit.RedactJSONFields("webhook_url")), s.createChannelHandler)
+	endpoint.PUT("/:id", auth.MWRequireWritePriv(), a.MWRecord("notification.channel.update", audit.RedactJSONFields("webhook_url")), s.updateChannelHandler)
+	endpoint.DELETE("/:id", auth.MWRequireWritePriv(), a.MWRecord("notification.channel.delete"), s.deleteChannelHandler)
+	endpoint.POST("/:id/test", auth.MWRequireWritePriv(), s.testChannelHandler)
+}
+
+func parseID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		rest.Error(c, rest.ErrBadRequest.New("invalid id %s", c.Param("id")))
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// @ID notificationListChannels
+// @Summary List notification channels
+// @Description Webhook URLs are masked for users without the write privilege.
+// @Security JwtAuth
+// @Success 200 {array} ChannelModel
+// @Failure 401 {object} rest.ErrorResponse
+// @Router /notifications/channels [get]
+func (s *Service) listChannelsHandler(c *gin.Context) {
+	channels, err := s.ListChannels()
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	if !utils.GetSession(c).IsWriteable {
+		for i := range channels {
+			channels[i].mask()
+		}
+	}
+	c.JSON(http.StatusOK, channels)
+}
+
+type ChannelRequest struct {
+	Name       string        `json:"name" binding:"required"`
+	Type       ChannelType   `json:"type" binding:"required"`
+	WebhookURL string        `json:"webhook_url" binding:"required"`
+	Events     EventTypeList `json:"events"`
+}
+
+func (r *ChannelRequest) toModel() *ChannelModel {
+	return &ChannelModel{
+		Name:       r.Name,
+		Type:       r.Type,
+		WebhookURL: r.WebhookURL,
+		Events:     r.Events,
+	}
+}
+
+// @ID notificationCreateChannel
+// @Summary Create a notification channel
+// @Description Events are posted to the webhook in JSON, formatted by the channel type (generic, slack or lark).
+// @Description All events are subscribed when events is empty. Failed deliveries are retried with a backoff.
+// @Param request body ChannelRequest true "Request body"
+// @Security JwtAuth
+// @Success 200 {object} ChannelModel
+// @Failure 400 {object} rest.ErrorResponse
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 403 {object} rest.ErrorResponse
+// @Router /notifications/channels [post]
+func (s *Service) createChannelHandler(c *gin.Context) {
+	var req ChannelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		rest.Error(c, rest.ErrBadRequest.NewWithNoMessage())
+		return
+	}
+	m := req.toModel()
+	m.CreatedBy = utils.GetSession(c).DisplayName
+	if err := s.CreateChannel(m); err != nil {
+		rest.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, m)
+}
+
+// @ID notificationUpdateChannel
+// @Summary Update a notification channel
+// @Param id path integer true "Channel ID"
+// @Param request body ChannelRequest true "Request body"
+// @Security JwtAuth
+// @Success 200 {object} ChannelModel
+// @Failure 400 {object} rest.ErrorResponse
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 403 {object} rest.ErrorResponse
+// @Failure 404 {object} rest.ErrorResponse
+// @Router /notifications/channels/{id} [put]
+func (s *Service) updateChannelHandler(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	var req ChannelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		rest.Error(c, rest.ErrBadRequest.NewWithNoMessage())
+		return
+	}
+	m := req.toModel()
+	m.ID = id
+	if err := s.UpdateChannel(m); err != nil {
+		rest.Error(c, err)
+		return
+	}
+	updated, err := s.GetChannel(id)
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, updated)
+}
+
+// @ID notificationDeleteChannel
+// @Summary Delete a notification channel
+// @Param id path integer true "Channel ID"
+// @Security JwtAuth
+// @Success 200 {string} string
+// @Failure 400 {object} rest.ErrorResponse
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 403 {object} rest.ErrorResponse
+// @Failure 404 {object} rest.ErrorResponse
+// @Router /notifications/channels/{id} [delete]
+func (s *Service) deleteChannelHandler(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	found, err := s.DeleteChannel(id)
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	if !found {
+		rest.Error(c, rest.ErrNotFound.New("channel %d not found", id))
+		return
+	}
+	c.JSON(http.StatusOK, nil)
+}
+
+// @ID notificationTestChannel
+// @Summary Send a test notification to a channel
+// @Description The test notification is sent once without retries, and the delivery error is returned.
+// @Param id path integer true "Channel ID"
+// @Security JwtAuth
+// @Success 200 {string} string
+// @Failure 400 {object} rest.ErrorResponse
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 403 {object} rest.ErrorResponse
+// @Failure 404 {object} rest.ErrorResponse
+// @Failure 500 {object} rest.ErrorResponse
+// @Router /notifications/channels/{id}/test [post]
+func (s *Service) testChannelHandler(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	if err := s.TestChannel(c.Request.Context(), id); err != nil {
+		rest.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, nil)
+}
@@ -0,0 +1,349 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+// Package notification delivers dashboard-originated events, e.g. a finished report, to webhooks of generic,
+// Slack or Lark channels.
+package notification
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/joomcode/errorx"
+	"github.com/pingcap/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+
+	"github.com/pingcap/tidb-dashboard/pkg/dbstore"
+	"github.com/pingcap/tidb-dashboard/pkg/httpc"
+	"github.com/pingcap/tidb-dashboard/pkg/utils"
+	"github.com/pingcap/tidb-dashboard/util/rest"
+)
+
+var (
+	ErrNS             = errorx.NewNamespace("error.api.notification")
+	ErrDeliveryFailed = ErrNS.NewType("delivery_failed")
+	ErrWebhookRequest = ErrNS.NewType("webhook_request")
+)
+
+var (
+	queueLengthGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "tidb_dashboard",
+		Subsystem: "notification",
+		Name:      "queue_length",
+		Help:      "Number of events waiting for delivery.",
+	})
+	droppedEventCounter = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "tidb_dashboard",
+		Subsystem: "notification",
+		Name:      "dropped_events_total",
+		Help:      "Number of events dropped as the queue is full.",
+	})
+)
+
+const (
+	// queueSize is the number of events waiting for delivery, both in the service and in each channel. Events are
+	// dropped when the queue is full.
+	queueSize          = 256
+	deliveryTimeout    = 10 * time.Second
+	maxDeliveryRetries = 3
+	retryMinBackoff    = time.Second
+)
+
+type ServiceParams struct {
+	fx.In
+	LocalStore *dbstore.DB
+	HTTPClient *httpc.Client
+}
+
+type Service struct {
+	params ServiceParams
+	queue  chan Event
+
+	// channelQueues are queues of channels with pending deliveries, so that a failing channel does not delay others.
+	channelQueuesMu sync.Mutex
+	channelQueues   map[uint]chan channelDelivery
+	// pendingDeliveries counts deliveries in channel queues.
+	pendingDeliveries sync.WaitGroup
+
+	retryMinBackoff time.Duration
+	// send is replaced in tests.
+	send func(ctx context.Context, webhookURL string, body []byte) error
+}
+
+func newService(lc fx.Lifecycle, p ServiceParams) (*Service, error) {
+	if err := p.LocalStore.AutoMigrate(&ChannelModel{}); err != nil {
+		return nil, err
+	}
+	utils.RegisterMetrics(queueLengthGauge, droppedEventCounter)
+	s := &Service{
+		params:          p,
+		queue:           make(chan Event, queueSize),
+		channelQueues:   make(map[uint]chan channelDelivery),
+		retryMinBackoff: retryMinBackoff,
+	}
+	s.send = s.postWebhook
+
+	ctx, cancel := context.WithCancel(context.Background())
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go s.deliverLoop(ctx)
+			return nil
+		},
+		OnStop: func(context.Context) error {
+			cancel()
+			return nil
+		},
+	})
+	return s, nil
+}
+
+var Module = fx.Options(
+	fx.Provide(newService),
+	fx.Invoke(registerRouter),
+)
+
+// Publish queues the event for delivery to the subscribed channels without blocking.
+// It is a no-op on a nil service, so that publishers do not need to check whether notification is available.
+func (s *Service) Publish(e Event) {
+	if s == nil {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	select {
+	case s.queue <- e:
+		queueLengthGauge.Set(float64(len(s.queue)))
+	default:
+		droppedEventCounter.Inc()
+		log.Warn("Notification queue is full, event dropped", zap.String("type", string(e.Type)))
+	}
+}
+
+func validateChannel(m *ChannelModel) error {
+	if m.Name == "" {
+		return rest.ErrBadRequest.New("name is required")
+	}
+	switch m.Type {
+	case ChannelTypeGeneric, ChannelTypeSlack, ChannelTypeLark:
+	default:
+		return rest.ErrBadRequest.New("unsupported channel type %s", m.Type)
+	}
+	u, err := url.Parse(m.WebhookURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return rest.ErrBadRequest.New("invalid webhook url %s", m.WebhookURL)
+	}
+	for _, e := range m.Events {
+		if _, ok := subscribableEvents[e]; !ok {
+			return rest.ErrBadRequest.New("unsupported event type %s", e)
+		}
+	}
+	return nil
+}
+
+func (s *Service) CreateChannel(m *ChannelModel) error {
+	if err := validateChannel(m); err != nil {
+		return err
+	}
+	m.ID = 0
+	m.CreatedAt = time.Now()
+	m.LastDeliveryAt = time.Time{}
+	m.LastDeliveryError = ""
+	return s.params.LocalStore.Create(m).Error
+}
+
+// UpdateChannel replaces the name, type, webhook URL and events of an existing channel.
+func (s *Service) UpdateChannel(m *ChannelModel) error {
+	if err := validateChannel(m); err != nil {
+		return err
+	}
+	result := s.params.LocalStore.Model(&ChannelModel{}).Where("id = ?", m.ID).Updates(map[string]interface{}{
+		"name":        m.Name,
+		"type":        m.Type,
+		"webhook_url": m.WebhookURL,
+		"events":      m.Events,
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return rest.ErrNotFound.New("channel %d not found", m.ID)
+	}
+	return nil
+}
+
+func (s *Service) GetChannel(id uint) (*ChannelModel, error) {
+	var channels []ChannelModel
+	if err := s.params.LocalStore.Where("id = ?", id).Find(&channels).Error; err != nil {
+		return nil, err
+	}
+	if len(channels) == 0 {
+		return nil, rest.ErrNotFound.New("channel %d not found", id)
+	}
+	return &channels[0], nil
+}
+
+func (s *Service) ListChannels() ([]ChannelModel, error) {
+	var channels []ChannelModel
+	if err := s.params.LocalStore.Order("id").Find(&channels).Error; err != nil {
+		return nil, err
+	}
+	return channels, nil
+}
+
+// DeleteChannel returns false if the channel does not exist.
+func (s *Service) DeleteChannel(id uint) (bool, error) {
+	result := s.params.LocalStore.Where("id = ?", id).Delete(&ChannelModel{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// TestChannel sends a test event to the channel once, without retries.
+func (s *Service) TestChannel(ctx context.Context, id uint) error {
+	ch, err := s.GetChannel(id)
+	if err != nil {
+		return err
+	}
+	body, err := buildPayload(ch.Type, &Event{
+		Type:    EventTest,
+		Title:   "Test notification from TiDB Dashboard",
+		Message: "The channel " + ch.Name + " is configured successfully.",
+		Time:    time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	return s.send(ctx, ch.WebhookURL, body)
+}
+
+func (s *Service) deliverLoop(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case e := <-s.queue:
+			queueLengthGauge.Set(float64(len(s.queue)))
+			s.deliver(ctx, &e)
+		}
+	}
+}
+
+type channelDelivery struct {
+	channel ChannelModel
+	event   *Event
+}
+
+// deliver queues the event to every subscribed channel. Events of a channel are delivered in order, while channels
+// are delivered concurrently.
+func (s *Service) deliver(ctx context.Context, e *Event) {
+	channels, err := s.ListChannels()
+	if err != nil {
+		log.Warn("Failed to list notification channels", zap.Error(err))
+		return
+	}
+	for i := range channels {
+		if channels[i].subscribes(e.Type) {
+			s.enqueueDelivery(ctx, channelDelivery{channel: channels[i], event: e})
+		}
+	}
+}
+
+// enqueueDelivery queues the delivery to the queue of the channel, which is created on demand along with its
+// delivering goroutine.
+func (s *Service) enqueueDelivery(ctx context.Context, d channelDelivery) {
+	s.channelQueuesMu.Lock()
+	defer s.channelQueuesMu.Unlock()
+	q, ok := s.channelQueues[d.channel.ID]
+	if !ok {
+		q = make(chan channelDelivery, queueSize)
+		s.channelQueues[d.channel.ID] = q
+		go s.channelDeliverLoop(ctx, d.channel.ID, q)
+	}
+	s.pendingDeliveries.Add(1)
+	select {
+	case q <- d:
+	default:
+		s.pendingDeliveries.Done()
+		droppedEventCounter.Inc()
+		log.Warn("Notification channel queue is full, event dropped",
+			zap.Uint("channel", d.channel.ID),
+			zap.String("type", string(d.event.Type)))
+	}
+}
+
+// channelDeliverLoop delivers the queued events of the channel, and exits once the queue is drained.
+func (s *Service) channelDeliverLoop(ctx context.Context, channelID uint, q chan channelDelivery) {
+	for {
+		s.channelQueuesMu.Lock()
+		select {
+		case d := <-q:
+			s.channelQueuesMu.Unlock()
+			s.deliverToChannel(ctx, &d.channel, d.event)
+			s.pendingDeliveries.Done()
+		default:
+			delete(s.channelQueues, channelID)
+			s.channelQueuesMu.Unlock()
+			return
+		}
+	}
+}
+
+// deliverToChannel sends the event to the channel, and records the result in the channel.
+func (s *Service) deliverToChannel(ctx context.Context, ch *ChannelModel, e *Event) {
+	err := s.deliverWithRetry(ctx, ch, e)
+	lastError := ""
+	if err != nil {
+		lastError = err.Error()
+		log.Warn("Failed to deliver notification",
+			zap.Uint("channel", ch.ID),
+			zap.String("type", string(e.Type)),
+			zap.Error(err))
+	}
+	err = s.params.LocalStore.Model(&ChannelModel{}).Where("id = ?", ch.ID).Updates(map[string]interface{}{
+		"last_delivery_at":    time.Now(),
+		"last_delivery_error": lastError,
+	}).Error
+	if err != nil {
+		log.Warn("Failed to update notification channel", zap.Uint("channel", ch.ID), zap.Error(err))
+	}
+}
+
+// deliverWithRetry retries failed deliveries with an exponential backoff.
+func (s *Service) deliverWithRetry(ctx context.Context, ch *ChannelModel, e *Event) error {
+	body, err := buildPayload(ch.Type, e)
+	if err != nil {
+		return err
+	}
+	backoff := s.retryMinBackoff
+	for attempt := 0; ; attempt++ {
+		err = s.send(ctx, ch.WebhookURL, body)
+		if err == nil {
+			return nil
+		}
+		if attempt >= maxDeliveryRetries {
+			return ErrDeliveryFailed.Wrap(err, "delivery failed after %d attempts", attempt+1)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+func (s *Service) postWebhook(ctx context.Context, webhookURL string, body []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, deliveryTimeout)
+	defer cancel()
+	_, err := s.params.HTTPClient.
+		CloneAndAddRequestHeader("Content-Type", "application/json").
+		SendRequest(ctx, webhookURL, http.MethodPost, bytes.NewReader(body), ErrWebhookRequest, "webhook")
+	return err
+}
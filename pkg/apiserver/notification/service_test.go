@@ -0,0 +1,199 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/joomcode/errorx"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pingcap/tidb-dashboard/pkg/dbstore/dbstoretest"
+	"github.com/pingcap/tidb-dashboard/util/rest"
+)
+
+type sentRequest struct {
+	url  string
+	body []byte
+}
+
+func newTestService(t *testing.T) (*Service, *[]sentRequest) {
+	db := dbstoretest.New(t)
+	require.NoError(t, db.AutoMigrate(&ChannelModel{}))
+	s := &Service{
+		params:        ServiceParams{LocalStore: db},
+		queue:         make(chan Event, queueSize),
+		channelQueues: make(map[uint]chan channelDelivery),
+	}
+	var mu sync.Mutex
+	var sent []sentRequest
+	s.send = func(ctx context.Context, webhookURL string, body []byte) error {
+		mu.Lock()
+		defer mu.Unlock()
+		sent = append(sent, sentRequest{url: webhookURL, body: body})
+		return nil
+	}
+	return s, &sent
+}
+
+// deliverAndWait delivers the event, and waits until it is delivered to all channels. Requests sent to channels are
+// sorted by the URL, as channels are delivered concurrently.
+func deliverAndWait(s *Service, e *Event, sent *[]sentRequest) {
+	s.deliver(context.Background(), e)
+	s.pendingDeliveries.Wait()
+	if sent != nil {
+		sort.Slice(*sent, func(i, j int) bool { return (*sent)[i].url < (*sent)[j].url })
+	}
+}
+
+func TestChannelValidation(t *testing.T) {
+	s, _ := newTestService(t)
+
+	require.Error(t, s.CreateChannel(&ChannelModel{Type: ChannelTypeSlack, WebhookURL: "https://example.com/hook"}))
+	require.Error(t, s.CreateChannel(&ChannelModel{Name: "a", Type: "email", WebhookURL: "https://example.com/hook"}))
+	require.Error(t, s.CreateChannel(&ChannelModel{Name: "a", Type: ChannelTypeSlack, WebhookURL: "example.com/hook"}))
+	require.Error(t, s.CreateChannel(&ChannelModel{Name: "a", Type: ChannelTypeSlack, WebhookURL: "https://example.com/hook", Events: EventTypeList{EventTest}}))
+
+	m := &ChannelModel{Name: "a", Type: ChannelTypeSlack, WebhookURL: "https://example.com/hook", Events: EventTypeList{EventNodeDown}}
+	require.NoError(t, s.CreateChannel(m))
+	channels, err := s.ListChannels()
+	require.NoError(t, err)
+	require.Len(t, channels, 1)
+	require.Equal(t, EventTypeList{EventNodeDown}, channels[0].Events)
+
+	m.Events = nil
+	m.Type = ChannelTypeLark
+	require.NoError(t, s.UpdateChannel(m))
+	updated, err := s.GetChannel(m.ID)
+	require.NoError(t, err)
+	require.Equal(t, ChannelTypeLark, updated.Type)
+	require.Empty(t, updated.Events)
+
+	m.ID = 100
+	require.True(t, errorx.IsOfType(s.UpdateChannel(m), rest.ErrNotFound))
+
+	found, err := s.DeleteChannel(updated.ID)
+	require.NoError(t, err)
+	require.True(t, found)
+	found, err = s.DeleteChannel(updated.ID)
+	require.NoError(t, err)
+	require.False(t, found)
+}
+
+func TestDeliverToSubscribedChannels(t *testing.T) {
+	s, sent := newTestService(t)
+	require.NoError(t, s.CreateChannel(&ChannelModel{Name: "generic", Type: ChannelTypeGeneric, WebhookURL: "http://generic"}))
+	require.NoError(t, s.CreateChannel(&ChannelModel{Name: "slack", Type: ChannelTypeSlack, WebhookURL: "http://slack", Events: EventTypeList{EventReportFinished}}))
+	require.NoError(t, s.CreateChannel(&ChannelModel{Name: "lark", Type: ChannelTypeLark, WebhookURL: "http://lark", Events: EventTypeList{EventNodeDown}}))
+
+	deliverAndWait(s, &Event{Type: EventNodeDown, Title: "Node detected down", Message: "tikv is down"}, sent)
+	require.Len(t, *sent, 2)
+	require.Equal(t, "http://generic", (*sent)[0].url)
+	var e Event
+	require.NoError(t, json.Unmarshal((*sent)[0].body, &e))
+	require.Equal(t, EventNodeDown, e.Type)
+	require.Equal(t, "http://lark", (*sent)[1].url)
+	require.JSONEq(t, `{"msg_type": "text", "content": {"text": "Node detected down\ntikv is down"}}`, string((*sent)[1].body))
+
+	*sent = nil
+	deliverAndWait(s, &Event{Type: EventReportFinished, Title: "Report", Message: "done"}, sent)
+	require.Len(t, *sent, 2)
+	require.Equal(t, "http://slack", (*sent)[1].url)
+	require.JSONEq(t, `{"text": "*Report*\ndone"}`, string((*sent)[1].body))
+}
+
+func TestDeliverRetries(t *testing.T) {
+	s, _ := newTestService(t)
+	require.NoError(t, s.CreateChannel(&ChannelModel{Name: "a", Type: ChannelTypeGeneric, WebhookURL: "http://a"}))
+
+	attempts := 0
+	failures := 2
+	s.send = func(ctx context.Context, webhookURL string, body []byte) error {
+		attempts++
+		if attempts <= failures {
+			return errors.New("unavailable")
+		}
+		return nil
+	}
+	deliverAndWait(s, &Event{Type: EventProfilingFinished}, nil)
+	require.Equal(t, 3, attempts)
+	ch, err := s.GetChannel(1)
+	require.NoError(t, err)
+	require.False(t, ch.LastDeliveryAt.IsZero())
+	require.Empty(t, ch.LastDeliveryError)
+
+	// Gives up after the max retries and records the error.
+	attempts = 0
+	failures = 100
+	deliverAndWait(s, &Event{Type: EventProfilingFinished}, nil)
+	require.Equal(t, maxDeliveryRetries+1, attempts)
+	ch, err = s.GetChannel(1)
+	require.NoError(t, err)
+	require.Contains(t, ch.LastDeliveryError, "unavailable")
+}
+
+func TestDeliverFailingChannelDoesNotDelayOthers(t *testing.T) {
+	s, _ := newTestService(t)
+	s.retryMinBackoff = time.Hour
+	require.NoError(t, s.CreateChannel(&ChannelModel{Name: "down", Type: ChannelTypeGeneric, WebhookURL: "http://down"}))
+	require.NoError(t, s.CreateChannel(&ChannelModel{Name: "up", Type: ChannelTypeGeneric, WebhookURL: "http://up"}))
+
+	delivered := make(chan struct{}, 2)
+	s.send = func(ctx context.Context, webhookURL string, body []byte) error {
+		if webhookURL == "http://down" {
+			return errors.New("unavailable")
+		}
+		delivered <- struct{}{}
+		return nil
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s.deliver(ctx, &Event{Type: EventNodeDown})
+	s.deliver(ctx, &Event{Type: EventNodeDown})
+	// The down channel is waiting for the retry, while both events are delivered to the up channel.
+	for i := 0; i < 2; i++ {
+		select {
+		case <-delivered:
+		case <-time.After(10 * time.Second):
+			require.FailNow(t, "events are not delivered to the up channel")
+		}
+	}
+	// The retry is given up once cancelled.
+	cancel()
+	s.pendingDeliveries.Wait()
+	ch, err := s.GetChannel(1)
+	require.NoError(t, err)
+	require.NotEmpty(t, ch.LastDeliveryError)
+}
+
+func TestPublishDoesNotBlock(t *testing.T) {
+	s, _ := newTestService(t)
+	for i := 0; i < queueSize+10; i++ {
+		s.Publish(Event{Type: EventNodeDown})
+	}
+	require.Len(t, s.queue, queueSize)
+	e := <-s.queue
+	require.False(t, e.Time.IsZero())
+
+	var nilService *Service
+	nilService.Publish(Event{Type: EventNodeDown})
+}
+
+func TestMaskChannel(t *testing.T) {
+	ch := ChannelModel{
+		WebhookURL:        "https://hooks.slack.com/services/T0/B0/token?x=1",
+		LastDeliveryError: `Post "https://hooks.slack.com/services/T0/B0/token?x=1": EOF`,
+	}
+	ch.mask()
+	require.Equal(t, "https://hooks.slack.com/******", ch.WebhookURL)
+	require.Equal(t, `Post "https://hooks.slack.com/******": EOF`, ch.LastDeliveryError)
+
+	ch = ChannelModel{WebhookURL: "://invalid"}
+	ch.mask()
+	require.Equal(t, "******", ch.WebhookURL)
+}
@@ -0,0 +1,35 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package pdmanage
+
+// evictLeaderScheduler is the name of the PD scheduler evicting leaders from stores. Each store it evicts
+// from is in its config, and the scheduler is removed when no store is left.
+const evictLeaderScheduler = "evict-leader-scheduler"
+
+type Scheduler struct {
+	Name   string `json:"name"`
+	Paused bool   `json:"paused"`
+}
+
+// Operator is a running operator of PD. Only the fields shared by all PD versions are kept.
+type Operator struct {
+	RegionID uint64 `json:"region_id"`
+	Desc     string `json:"desc"`
+	Brief    string `json:"brief"`
+	Kind     string `json:"kind"`
+	Status   string `json:"status"`
+}
+
+type PauseSchedulerRequest struct {
+	// DelaySecs is how long the scheduler is paused, after which it resumes automatically.
+	DelaySecs int64 `json:"delay_secs" binding:"required"`
+}
+
+type TransferLeaderRequest struct {
+	RegionID  uint64 `json:"region_id" binding:"required"`
+	ToStoreID uint64 `json:"to_store_id" binding:"required"`
+}
+
+type EvictLeaderRequest struct {
+	StoreID uint64 `json:"store_id" binding:"required"`
+}
@@ -0,0 +1,314 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+// Package pdmanage manages the schedulers and operators of PD, so that routine scheduling operations can be done
+// without pd-ctl.
+package pdmanage
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/joomcode/errorx"
+	"go.uber.org/fx"
+
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/audit"
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/cluster"
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/user"
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/utils"
+	"github.com/pingcap/tidb-dashboard/pkg/pd"
+	"github.com/pingcap/tidb-dashboard/util/rest"
+)
+
+var (
+	ErrNS        = errorx.NewNamespace("error.api.pd_manage")
+	ErrPDRequest = ErrNS.NewType("pd_request_failed")
+)
+
+type ServiceParams struct {
+	fx.In
+	PDClient *pd.Client
+	Registry *cluster.Registry
+}
+
+type Service struct {
+	params ServiceParams
+}
+
+func newService(p ServiceParams) *Service {
+	return &Service{params: p}
+}
+
+func registerRouter(r *gin.RouterGroup, auth *user.AuthService, a *audit.Service, s *Service) {
+	endpoint := r.Group("/pd_manage")
+	endpoint.Use(auth.MWAuthRequired(), s.params.Registry.MWResolveCluster())
+	{
+		endpoint.GET("/schedulers", s.getSchedulers)
+		endpoint.POST("/schedulers/:name/pause",
+			auth.MWRequireCapability(utils.CapabilityManageScheduling),
+			a.MWRecord("pd_manage.pause_scheduler"),
+			s.pauseScheduler)
+		endpoint.POST("/schedulers/:name/resume",
+			auth.MWRequireCapability(utils.CapabilityManageScheduling),
+			a.MWRecord("pd_manage.resume_scheduler"),
+			s.resumeScheduler)
+		endpoint.GET("/operators", s.getOperators)
+		endpoint.POST("/operators/transfer_leader",
+			auth.MWRequireCapability(utils.CapabilityManageScheduling),
+			a.MWRecord("pd_manage.transfer_leader"),
+			s.transferLeader)
+		endpoint.POST("/evict_leader",
+			auth.MWRequireCapability(utils.CapabilityManageScheduling),
+			a.MWRecord("pd_manage.evict_leader"),
+			s.evictLeader)
+		endpoint.DELETE("/evict_leader/:store_id",
+			auth.MWRequireCapability(utils.CapabilityManageScheduling),
+			a.MWRecord("pd_manage.cancel_evict_leader"),
+			s.cancelEvictLeader)
+	}
+}
+
+// pdClientOf returns the PD client of the cluster selected in the session.
+func (s *Service) pdClientOf(c *gin.Context) *pd.Client {
+	if clients := cluster.GetClients(c); clients != nil {
+		return clients.PDClient
+	}
+	return s.params.PDClient
+}
+
+func fetchSchedulerNames(client *pd.Client, status string) ([]string, error) {
+	uri := "/schedulers"
+	if status != "" {
+		uri += "?status=" + url.QueryEscape(status)
+	}
+	data, err := client.SendGetRequest(uri)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	if err := json.Unmarshal(data, &names); err != nil {
+		return nil, ErrPDRequest.Wrap(err, "failed to decode schedulers")
+	}
+	return names, nil
+}
+
+// FetchSchedulers returns the active schedulers of PD, ordered by name.
+func FetchSchedulers(client *pd.Client) ([]Scheduler, error) {
+	names, err := fetchSchedulerNames(client, "")
+	if err != nil {
+		return nil, err
+	}
+	pausedNames, err := fetchSchedulerNames(client, "paused")
+	if err != nil {
+		return nil, err
+	}
+	paused := make(map[string]struct{}, len(pausedNames))
+	for _, name := range pausedNames {
+		paused[name] = struct{}{}
+	}
+	schedulers := make([]Scheduler, 0, len(names))
+	for _, name := range names {
+		_, isPaused := paused[name]
+		schedulers = append(schedulers, Scheduler{Name: name, Paused: isPaused})
+	}
+	sort.Slice(schedulers, func(i, j int) bool {
+		return schedulers[i].Name < schedulers[j].Name
+	})
+	return schedulers, nil
+}
+
+// SetSchedulerDelay pauses the scheduler for the delay, or resumes it when the delay is 0.
+func SetSchedulerDelay(client *pd.Client, name string, delaySecs int64) error {
+	body, err := json.Marshal(map[string]int64{"delay": delaySecs})
+	if err != nil {
+		return err
+	}
+	_, err = client.SendPostRequest("/schedulers/"+url.PathEscape(name), bytes.NewReader(body))
+	return err
+}
+
+func FetchOperators(client *pd.Client) ([]Operator, error) {
+	data, err := client.SendGetRequest("/operators")
+	if err != nil {
+		return nil, err
+	}
+	operators := make([]Operator, 0)
+	if err := json.Unmarshal(data, &operators); err != nil {
+		return nil, ErrPDRequest.Wrap(err, "failed to decode operators")
+	}
+	return operators, nil
+}
+
+func TransferLeader(client *pd.Client, regionID, toStoreID uint64) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"name":        "transfer-leader",
+		"region_id":   regionID,
+		"to_store_id": toStoreID,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = client.SendPostRequest("/operators", bytes.NewReader(body))
+	return err
+}
+
+// EvictLeader adds the store into the evict-leader scheduler, which is created if not exists.
+func EvictLeader(client *pd.Client, storeID uint64) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"name":     evictLeaderScheduler,
+		"store_id": storeID,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = client.SendPostRequest("/schedulers", bytes.NewReader(body))
+	return err
+}
+
+// CancelEvictLeader removes the store from the evict-leader scheduler.
+func CancelEvictLeader(client *pd.Client, storeID uint64) error {
+	_, err := client.SendDeleteRequest(fmt.Sprintf("/schedulers/%s-%d", evictLeaderScheduler, storeID))
+	return err
+}
+
+// @ID pdManageGetSchedulers
+// @Summary List active PD schedulers
+// @Success 200 {array} Scheduler
+// @Router /pd_manage/schedulers [get]
+// @Security JwtAuth
+// @Failure 401 {object} rest.ErrorResponse
+func (s *Service) getSchedulers(c *gin.Context) {
+	schedulers, err := FetchSchedulers(s.pdClientOf(c))
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, schedulers)
+}
+
+// @ID pdManagePauseScheduler
+// @Summary Pause a PD scheduler
+// @Description The scheduler resumes automatically after the delay.
+// @Param name path string true "Scheduler name"
+// @Param request body PauseSchedulerRequest true "Request body"
+// @Success 200 {string} string
+// @Router /pd_manage/schedulers/{name}/pause [post]
+// @Security JwtAuth
+// @Failure 400 {object} rest.ErrorResponse
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 403 {object} rest.ErrorResponse
+func (s *Service) pauseScheduler(c *gin.Context) {
+	var req PauseSchedulerRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.DelaySecs <= 0 {
+		rest.Error(c, rest.ErrBadRequest.New("delay_secs must be positive"))
+		return
+	}
+	if err := SetSchedulerDelay(s.pdClientOf(c), c.Param("name"), req.DelaySecs); err != nil {
+		rest.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, nil)
+}
+
+// @ID pdManageResumeScheduler
+// @Summary Resume a paused PD scheduler
+// @Param name path string true "Scheduler name"
+// @Success 200 {string} string
+// @Router /pd_manage/schedulers/{name}/resume [post]
+// @Security JwtAuth
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 403 {object} rest.ErrorResponse
+func (s *Service) resumeScheduler(c *gin.Context) {
+	if err := SetSchedulerDelay(s.pdClientOf(c), c.Param("name"), 0); err != nil {
+		rest.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, nil)
+}
+
+// @ID pdManageGetOperators
+// @Summary List running PD operators
+// @Success 200 {array} Operator
+// @Router /pd_manage/operators [get]
+// @Security JwtAuth
+// @Failure 401 {object} rest.ErrorResponse
+func (s *Service) getOperators(c *gin.Context) {
+	operators, err := FetchOperators(s.pdClientOf(c))
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, operators)
+}
+
+// @ID pdManageTransferLeader
+// @Summary Add an operator transferring the leader of a region to a store
+// @Param request body TransferLeaderRequest true "Request body"
+// @Success 200 {string} string
+// @Router /pd_manage/operators/transfer_leader [post]
+// @Security JwtAuth
+// @Failure 400 {object} rest.ErrorResponse
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 403 {object} rest.ErrorResponse
+func (s *Service) transferLeader(c *gin.Context) {
+	var req TransferLeaderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		rest.Error(c, rest.ErrBadRequest.NewWithNoMessage())
+		return
+	}
+	if err := TransferLeader(s.pdClientOf(c), req.RegionID, req.ToStoreID); err != nil {
+		rest.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, nil)
+}
+
+// @ID pdManageEvictLeader
+// @Summary Evict all leaders from a store
+// @Description Leaders are kept evicted until cancelled.
+// @Param request body EvictLeaderRequest true "Request body"
+// @Success 200 {string} string
+// @Router /pd_manage/evict_leader [post]
+// @Security JwtAuth
+// @Failure 400 {object} rest.ErrorResponse
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 403 {object} rest.ErrorResponse
+func (s *Service) evictLeader(c *gin.Context) {
+	var req EvictLeaderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		rest.Error(c, rest.ErrBadRequest.NewWithNoMessage())
+		return
+	}
+	if err := EvictLeader(s.pdClientOf(c), req.StoreID); err != nil {
+		rest.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, nil)
+}
+
+// @ID pdManageCancelEvictLeader
+// @Summary Stop evicting leaders from a store
+// @Param store_id path integer true "Store ID"
+// @Success 200 {string} string
+// @Router /pd_manage/evict_leader/{store_id} [delete]
+// @Security JwtAuth
+// @Failure 400 {object} rest.ErrorResponse
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 403 {object} rest.ErrorResponse
+func (s *Service) cancelEvictLeader(c *gin.Context) {
+	storeID, err := strconv.ParseUint(c.Param("store_id"), 10, 64)
+	if err != nil {
+		rest.Error(c, rest.ErrBadRequest.New("invalid store id %s", c.Param("store_id")))
+		return
+	}
+	if err := CancelEvictLeader(s.pdClientOf(c), storeID); err != nil {
+		rest.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, nil)
+}
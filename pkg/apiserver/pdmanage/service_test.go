@@ -0,0 +1,96 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package pdmanage
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/fx/fxtest"
+
+	"github.com/pingcap/tidb-dashboard/pkg/config"
+	"github.com/pingcap/tidb-dashboard/pkg/httpc"
+	"github.com/pingcap/tidb-dashboard/pkg/pd"
+)
+
+type pdRequest struct {
+	method string
+	path   string
+	body   map[string]interface{}
+}
+
+// newMockPD returns a PD client of a mock server recording mutating requests.
+func newMockPD(t *testing.T) (*pd.Client, *[]pdRequest) {
+	var requests []pdRequest
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/pd/api/v1/schedulers":
+			if r.URL.Query().Get("status") == "paused" {
+				_, _ = w.Write([]byte(`["balance-leader-scheduler"]`))
+			} else {
+				_, _ = w.Write([]byte(`["balance-region-scheduler", "balance-leader-scheduler"]`))
+			}
+		case r.Method == http.MethodGet && r.URL.Path == "/pd/api/v1/operators":
+			_, _ = w.Write([]byte(`[{"region_id": 2, "desc": "transfer-leader", "brief": "transfer leader: store 1 to 3", "kind": "leader", "status": "RUNNING", "timeout": "10m0s"}]`))
+		default:
+			req := pdRequest{method: r.Method, path: r.URL.Path}
+			if b, _ := io.ReadAll(r.Body); len(b) > 0 {
+				require.NoError(t, json.Unmarshal(b, &req.body))
+			}
+			requests = append(requests, req)
+			_, _ = w.Write([]byte(`"ok"`))
+		}
+	}))
+	t.Cleanup(ts.Close)
+
+	lc := fxtest.NewLifecycle(t)
+	cfg := &config.Config{}
+	client := pd.NewPDClient(lc, httpc.NewHTTPClient(lc, cfg), cfg)
+	require.NoError(t, lc.Start(context.Background()))
+	// The lifecycle context is copied into the client with the base URL, thus the hooks must be started first.
+	return client.WithBaseURL(ts.URL), &requests
+}
+
+func TestFetchSchedulersAndOperators(t *testing.T) {
+	client, _ := newMockPD(t)
+
+	schedulers, err := FetchSchedulers(client)
+	require.NoError(t, err)
+	require.Equal(t, []Scheduler{
+		{Name: "balance-leader-scheduler", Paused: true},
+		{Name: "balance-region-scheduler", Paused: false},
+	}, schedulers)
+
+	operators, err := FetchOperators(client)
+	require.NoError(t, err)
+	require.Len(t, operators, 1)
+	require.Equal(t, uint64(2), operators[0].RegionID)
+	require.Equal(t, "leader", operators[0].Kind)
+}
+
+func TestSchedulingMutations(t *testing.T) {
+	client, requests := newMockPD(t)
+
+	require.NoError(t, SetSchedulerDelay(client, "balance-leader-scheduler", 60))
+	require.NoError(t, SetSchedulerDelay(client, "balance-leader-scheduler", 0))
+	require.NoError(t, TransferLeader(client, 2, 3))
+	require.NoError(t, EvictLeader(client, 1))
+	require.NoError(t, CancelEvictLeader(client, 1))
+
+	require.Equal(t, []pdRequest{
+		{method: http.MethodPost, path: "/pd/api/v1/schedulers/balance-leader-scheduler", body: map[string]interface{}{"delay": float64(60)}},
+		{method: http.MethodPost, path: "/pd/api/v1/schedulers/balance-leader-scheduler", body: map[string]interface{}{"delay": float64(0)}},
+		{method: http.MethodPost, path: "/pd/api/v1/operators", body: map[string]interface{}{
+			"name": "transfer-leader", "region_id": float64(2), "to_store_id": float64(3),
+		}},
+		{method: http.MethodPost, path: "/pd/api/v1/schedulers", body: map[string]interface{}{
+			"name": "evict-leader-scheduler", "store_id": float64(1),
+		}},
+		{method: http.MethodDelete, path: "/pd/api/v1/schedulers/evict-leader-scheduler-1"},
+	}, *requests)
+}
@@ -0,0 +1,64 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package placement
+
+import "github.com/pingcap/tidb-dashboard/pkg/apiserver/region"
+
+// tidbGroupPrefix prefixes the rule groups managed by TiDB for placement policies, i.e. Placement-in-SQL.
+const tidbGroupPrefix = "TiDB_DDL_"
+
+type LabelConstraint struct {
+	Key string `json:"key"`
+	// Op is one of `in`, `notIn`, `exists` and `notExists`.
+	Op     string   `json:"op"`
+	Values []string `json:"values,omitempty"`
+}
+
+// Rule is a placement rule of PD. Keys are encoded keys in hex, the same as PD.
+type Rule struct {
+	GroupID  string `json:"group_id"`
+	ID       string `json:"id"`
+	Index    int    `json:"index,omitempty"`
+	Override bool   `json:"override,omitempty"`
+	StartKey string `json:"start_key"`
+	EndKey   string `json:"end_key"`
+	// Role is one of `voter`, `leader`, `follower` and `learner`.
+	Role             string            `json:"role"`
+	IsWitness        bool              `json:"is_witness,omitempty"`
+	Count            int               `json:"count"`
+	LabelConstraints []LabelConstraint `json:"label_constraints,omitempty"`
+	LocationLabels   []string          `json:"location_labels,omitempty"`
+	IsolationLevel   string            `json:"isolation_level,omitempty"`
+	Version          uint64            `json:"version,omitempty"`
+	CreateTimestamp  uint64            `json:"create_timestamp,omitempty"`
+}
+
+// GroupBundle is a rule group of PD with its rules.
+type GroupBundle struct {
+	ID       string `json:"group_id"`
+	Index    int    `json:"group_index"`
+	Override bool   `json:"group_override"`
+	Rules    []Rule `json:"rules"`
+}
+
+// BoundRule is a rule with the objects owning its start key and end key. The key range of a rule created by
+// Placement-in-SQL starts at its table or partition, and ends at the next one.
+type BoundRule struct {
+	Rule
+	StartKeyInfo region.KeyInfo `json:"start_key_info"`
+	EndKeyInfo   region.KeyInfo `json:"end_key_info"`
+}
+
+type BoundGroupBundle struct {
+	ID       string `json:"group_id"`
+	Index    int    `json:"group_index"`
+	Override bool   `json:"group_override"`
+	// ManagedByTiDB is true for groups of placement policies, which are overwritten by TiDB and thus not editable.
+	ManagedByTiDB bool        `json:"managed_by_tidb"`
+	Rules         []BoundRule `json:"rules"`
+}
+
+type ValidateResponse struct {
+	// Problems are empty when the bundle is valid.
+	Problems []string `json:"problems"`
+}
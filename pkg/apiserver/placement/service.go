@@ -0,0 +1,289 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+// Package placement inspects and edits placement rules of PD, including the rules of placement policies created by
+// Placement-in-SQL, whose key ranges are bound to tables and partitions.
+package placement
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/joomcode/errorx"
+	"go.uber.org/fx"
+	"gorm.io/gorm"
+
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/audit"
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/cluster"
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/region"
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/user"
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/utils"
+	"github.com/pingcap/tidb-dashboard/pkg/pd"
+	"github.com/pingcap/tidb-dashboard/pkg/tidb"
+	"github.com/pingcap/tidb-dashboard/util/rest"
+)
+
+var (
+	ErrNS           = errorx.NewNamespace("error.api.placement")
+	ErrPDRequest    = ErrNS.NewType("pd_request_failed")
+	ErrInvalidRules = ErrNS.NewType("invalid_rules")
+)
+
+type ServiceParams struct {
+	fx.In
+	PDClient   *pd.Client
+	TiDBClient *tidb.Client
+	Registry   *cluster.Registry
+}
+
+type Service struct {
+	params ServiceParams
+}
+
+func newService(p ServiceParams) *Service {
+	return &Service{params: p}
+}
+
+func registerRouter(r *gin.RouterGroup, auth *user.AuthService, a *audit.Service, s *Service) {
+	endpoint := r.Group("/placement")
+	endpoint.Use(auth.MWAuthRequired(), s.params.Registry.MWResolveCluster())
+	{
+		endpoint.GET("/bundles", s.mwConnectTiDB(), s.getBundles)
+		endpoint.GET("/bundles/:group", s.mwConnectTiDB(), s.getBundle)
+		endpoint.POST("/bundles/:group/validate", s.validateBundle)
+		endpoint.PUT("/bundles/:group",
+			auth.MWRequireCapability(utils.CapabilityManageScheduling),
+			a.MWRecord("placement.apply_bundle"),
+			s.applyBundle)
+		endpoint.DELETE("/bundles/:group",
+			auth.MWRequireCapability(utils.CapabilityManageScheduling),
+			a.MWRecord("placement.delete_bundle"),
+			s.deleteBundle)
+	}
+}
+
+// pdClientOf returns the PD client of the cluster selected in the session.
+func (s *Service) pdClientOf(c *gin.Context) *pd.Client {
+	if clients := cluster.GetClients(c); clients != nil {
+		return clients.PDClient
+	}
+	return s.params.PDClient
+}
+
+// FetchBundles returns all rule groups of PD with their rules, ordered by the group index and the group ID.
+func FetchBundles(client *pd.Client) ([]GroupBundle, error) {
+	data, err := client.SendGetRequest("/config/placement-rule")
+	if err != nil {
+		return nil, err
+	}
+	var bundles []GroupBundle
+	if err := json.Unmarshal(data, &bundles); err != nil {
+		return nil, ErrPDRequest.Wrap(err, "failed to decode placement rules")
+	}
+	sort.Slice(bundles, func(i, j int) bool {
+		if bundles[i].Index != bundles[j].Index {
+			return bundles[i].Index < bundles[j].Index
+		}
+		return bundles[i].ID < bundles[j].ID
+	})
+	return bundles, nil
+}
+
+// FetchBundle returns a rule group of PD, whose rules are empty when the group does not exist.
+func FetchBundle(client *pd.Client, groupID string) (*GroupBundle, error) {
+	data, err := client.SendGetRequest("/config/placement-rule/" + url.PathEscape(groupID))
+	if err != nil {
+		return nil, err
+	}
+	var bundle GroupBundle
+	if err := json.Unmarshal(data, &bundle); err != nil {
+		return nil, ErrPDRequest.Wrap(err, "failed to decode placement rules")
+	}
+	return &bundle, nil
+}
+
+// ApplyBundle replaces the rules of the group by the rules in the bundle.
+func ApplyBundle(client *pd.Client, bundle *GroupBundle) error {
+	body, err := json.Marshal(bundle)
+	if err != nil {
+		return err
+	}
+	_, err = client.SendPostRequest("/config/placement-rule/"+url.PathEscape(bundle.ID), bytes.NewReader(body))
+	return err
+}
+
+// DeleteBundle deletes the rule group and its rules.
+func DeleteBundle(client *pd.Client, groupID string) error {
+	_, err := client.SendDeleteRequest("/config/placement-rule/" + url.PathEscape(groupID))
+	return err
+}
+
+// bindBundles decodes the key ranges of the rules. Tables are resolved by the TiDB of the default cluster only,
+// thus names are empty for other clusters.
+func bindBundles(db *gorm.DB, bundles []GroupBundle) ([]BoundGroupBundle, error) {
+	var infos []region.KeyInfo
+	for _, b := range bundles {
+		for _, r := range b.Rules {
+			start, _ := hex.DecodeString(r.StartKey)
+			end, _ := hex.DecodeString(r.EndKey)
+			infos = append(infos, region.DecodeKey(start), region.DecodeKey(end))
+		}
+	}
+	if db != nil {
+		if err := region.ResolveKeyInfos(db, infos); err != nil {
+			return nil, err
+		}
+	}
+
+	result := make([]BoundGroupBundle, 0, len(bundles))
+	for _, b := range bundles {
+		bound := BoundGroupBundle{
+			ID:            b.ID,
+			Index:         b.Index,
+			Override:      b.Override,
+			ManagedByTiDB: strings.HasPrefix(b.ID, tidbGroupPrefix),
+			Rules:         make([]BoundRule, 0, len(b.Rules)),
+		}
+		for _, r := range b.Rules {
+			bound.Rules = append(bound.Rules, BoundRule{Rule: r, StartKeyInfo: infos[0], EndKeyInfo: infos[1]})
+			infos = infos[2:]
+		}
+		result = append(result, bound)
+	}
+	return result, nil
+}
+
+// mwConnectTiDB connects TiDB only when the default cluster is selected, as TiDB is optional to bind the bundles.
+func (s *Service) mwConnectTiDB() gin.HandlerFunc {
+	connect := utils.MWConnectTiDB(s.params.TiDBClient)
+	return func(c *gin.Context) {
+		if clients := cluster.GetClients(c); clients != nil && !clients.IsDefault() {
+			c.Next()
+			return
+		}
+		connect(c)
+	}
+}
+
+// tidbConnectionOf returns the TiDB connection if the default cluster is selected, or nil otherwise.
+func tidbConnectionOf(c *gin.Context) *gorm.DB {
+	if clients := cluster.GetClients(c); clients != nil && !clients.IsDefault() {
+		return nil
+	}
+	return utils.GetTiDBConnection(c)
+}
+
+// @ID placementBundlesGet
+// @Summary List placement rule groups and rules of PD, with the tables and partitions bound to rules
+// @Success 200 {array} BoundGroupBundle
+// @Router /placement/bundles [get]
+// @Security JwtAuth
+// @Failure 401 {object} rest.ErrorResponse
+func (s *Service) getBundles(c *gin.Context) {
+	bundles, err := FetchBundles(s.pdClientOf(c))
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	result, err := bindBundles(tidbConnectionOf(c), bundles)
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// @ID placementBundlesGroupGet
+// @Summary Get a placement rule group of PD, with the tables and partitions bound to rules
+// @Param group path string true "Group ID"
+// @Success 200 {object} BoundGroupBundle
+// @Router /placement/bundles/{group} [get]
+// @Security JwtAuth
+// @Failure 401 {object} rest.ErrorResponse
+func (s *Service) getBundle(c *gin.Context) {
+	bundle, err := FetchBundle(s.pdClientOf(c), c.Param("group"))
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	result, err := bindBundles(tidbConnectionOf(c), []GroupBundle{*bundle})
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result[0])
+}
+
+// @ID placementBundlesGroupValidatePost
+// @Summary Validate the rules of a placement rule group without applying them
+// @Param group path string true "Group ID"
+// @Param request body GroupBundle true "Request body"
+// @Success 200 {object} ValidateResponse
+// @Router /placement/bundles/{group}/validate [post]
+// @Security JwtAuth
+// @Failure 400 {object} rest.ErrorResponse
+// @Failure 401 {object} rest.ErrorResponse
+func (s *Service) validateBundle(c *gin.Context) {
+	var bundle GroupBundle
+	if err := c.ShouldBindJSON(&bundle); err != nil {
+		rest.Error(c, rest.ErrBadRequest.NewWithNoMessage())
+		return
+	}
+	c.JSON(http.StatusOK, ValidateResponse{Problems: validateBundle(c.Param("group"), &bundle)})
+}
+
+// @ID placementBundlesGroupPut
+// @Summary Replace the rules of a placement rule group
+// @Description Groups of placement policies are managed by TiDB and cannot be changed.
+// @Param group path string true "Group ID"
+// @Param request body GroupBundle true "Request body"
+// @Success 200 {string} string
+// @Router /placement/bundles/{group} [put]
+// @Security JwtAuth
+// @Failure 400 {object} rest.ErrorResponse
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 403 {object} rest.ErrorResponse
+func (s *Service) applyBundle(c *gin.Context) {
+	var bundle GroupBundle
+	if err := c.ShouldBindJSON(&bundle); err != nil {
+		rest.Error(c, rest.ErrBadRequest.NewWithNoMessage())
+		return
+	}
+	if problems := validateBundle(c.Param("group"), &bundle); len(problems) > 0 {
+		rest.Error(c, ErrInvalidRules.New("%s", strings.Join(problems, "; ")).
+			WithProperty(rest.HTTPCodeProperty(http.StatusBadRequest)))
+		return
+	}
+	if err := ApplyBundle(s.pdClientOf(c), &bundle); err != nil {
+		rest.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, nil)
+}
+
+// @ID placementBundlesGroupDelete
+// @Summary Delete a placement rule group and its rules
+// @Param group path string true "Group ID"
+// @Success 200 {string} string
+// @Router /placement/bundles/{group} [delete]
+// @Security JwtAuth
+// @Failure 400 {object} rest.ErrorResponse
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 403 {object} rest.ErrorResponse
+func (s *Service) deleteBundle(c *gin.Context) {
+	groupID := c.Param("group")
+	if strings.HasPrefix(groupID, tidbGroupPrefix) {
+		rest.Error(c, rest.ErrBadRequest.New("group %s is managed by placement policies of TiDB", groupID))
+		return
+	}
+	if err := DeleteBundle(s.pdClientOf(c), groupID); err != nil {
+		rest.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, nil)
+}
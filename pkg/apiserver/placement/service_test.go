@@ -0,0 +1,138 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package placement
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/fx/fxtest"
+
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/region"
+	"github.com/pingcap/tidb-dashboard/pkg/config"
+	"github.com/pingcap/tidb-dashboard/pkg/httpc"
+	"github.com/pingcap/tidb-dashboard/pkg/pd"
+)
+
+type pdRequest struct {
+	method string
+	path   string
+	body   map[string]interface{}
+}
+
+// tableRuleKeys are the encoded keys of the range of table 45 in hex, i.e. `t{45}` and `t{46}`.
+var tableRuleKeys = [2]string{"7480000000000000ff2d00000000000000f8", "7480000000000000ff2e00000000000000f8"}
+
+// newMockPD returns a PD client of a mock server recording mutating requests.
+func newMockPD(t *testing.T) (*pd.Client, *[]pdRequest) {
+	var requests []pdRequest
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/pd/api/v1/config/placement-rule":
+			_, _ = w.Write([]byte(`[
+				{"group_id": "TiDB_DDL_45", "group_index": 40, "group_override": true, "rules": [
+					{"group_id": "TiDB_DDL_45", "id": "table_rule_45_0", "start_key": "` + tableRuleKeys[0] + `", "end_key": "` + tableRuleKeys[1] + `", "role": "voter", "count": 3}
+				]},
+				{"group_id": "pd", "group_index": 0, "rules": [
+					{"group_id": "pd", "id": "default", "start_key": "", "end_key": "", "role": "voter", "count": 3, "location_labels": ["zone"]}
+				]}
+			]`))
+		case r.Method == http.MethodGet && r.URL.Path == "/pd/api/v1/config/placement-rule/pd":
+			_, _ = w.Write([]byte(`{"group_id": "pd", "group_index": 0, "rules": []}`))
+		default:
+			req := pdRequest{method: r.Method, path: r.URL.Path}
+			if b, _ := io.ReadAll(r.Body); len(b) > 0 {
+				require.NoError(t, json.Unmarshal(b, &req.body))
+			}
+			requests = append(requests, req)
+			_, _ = w.Write([]byte(`"ok"`))
+		}
+	}))
+	t.Cleanup(ts.Close)
+
+	lc := fxtest.NewLifecycle(t)
+	cfg := &config.Config{}
+	client := pd.NewPDClient(lc, httpc.NewHTTPClient(lc, cfg), cfg)
+	require.NoError(t, lc.Start(context.Background()))
+	return client.WithBaseURL(ts.URL), &requests
+}
+
+func TestFetchAndBindBundles(t *testing.T) {
+	client, _ := newMockPD(t)
+
+	bundles, err := FetchBundles(client)
+	require.NoError(t, err)
+	require.Len(t, bundles, 2)
+	require.Equal(t, "pd", bundles[0].ID)
+	require.Equal(t, []string{"zone"}, bundles[0].Rules[0].LocationLabels)
+
+	bound, err := bindBundles(nil, bundles)
+	require.NoError(t, err)
+	require.False(t, bound[0].ManagedByTiDB)
+	require.Equal(t, region.KeyTypeUnbounded, bound[0].Rules[0].StartKeyInfo.Type)
+	require.True(t, bound[1].ManagedByTiDB)
+	require.Equal(t, "table_rule_45_0", bound[1].Rules[0].ID)
+	require.Equal(t, region.KeyTypeTable, bound[1].Rules[0].StartKeyInfo.Type)
+	require.Equal(t, int64(45), bound[1].Rules[0].StartKeyInfo.TableID)
+	require.Equal(t, int64(46), bound[1].Rules[0].EndKeyInfo.TableID)
+
+	bundle, err := FetchBundle(client, "pd")
+	require.NoError(t, err)
+	require.Equal(t, "pd", bundle.ID)
+	require.Empty(t, bundle.Rules)
+}
+
+func TestBundleMutations(t *testing.T) {
+	client, requests := newMockPD(t)
+
+	require.NoError(t, ApplyBundle(client, &GroupBundle{ID: "custom", Index: 10, Rules: []Rule{
+		{GroupID: "custom", ID: "learner", Role: "learner", Count: 1},
+	}}))
+	require.NoError(t, DeleteBundle(client, "custom"))
+
+	require.Equal(t, []pdRequest{
+		{method: http.MethodPost, path: "/pd/api/v1/config/placement-rule/custom", body: map[string]interface{}{
+			"group_id": "custom", "group_index": float64(10), "group_override": false, "rules": []interface{}{
+				map[string]interface{}{
+					"group_id": "custom", "id": "learner", "start_key": "", "end_key": "", "role": "learner", "count": float64(1),
+				},
+			},
+		}},
+		{method: http.MethodDelete, path: "/pd/api/v1/config/placement-rule/custom"},
+	}, *requests)
+}
+
+func TestValidateBundle(t *testing.T) {
+	bundle := GroupBundle{Rules: []Rule{
+		{ID: "voters", Role: "voter", Count: 3, LabelConstraints: []LabelConstraint{{Key: "zone", Op: "in", Values: []string{"z1"}}}},
+		{ID: "learners", Role: "learner", Count: 1, StartKey: tableRuleKeys[0], EndKey: tableRuleKeys[1]},
+	}}
+	require.Empty(t, validateBundle("custom", &bundle))
+	require.Equal(t, "custom", bundle.ID)
+	require.Equal(t, "custom", bundle.Rules[0].GroupID)
+
+	bundle = GroupBundle{ID: "other", Rules: []Rule{
+		{ID: "leader", Role: "leader", Count: 2},
+		{ID: "leader", GroupID: "other", Role: "witness", Count: 1, StartKey: tableRuleKeys[1], EndKey: tableRuleKeys[0]},
+		{ID: "labels", Role: "voter", Count: 1, StartKey: "zz", LabelConstraints: []LabelConstraint{{Key: "zone", Op: "notIn"}, {Key: "disk", Op: "eq"}}},
+	}}
+	require.Equal(t, []string{
+		"group id other of the bundle mismatches custom",
+		"rule leader: count of leaders must be 1",
+		"rule leader: invalid role \"witness\"",
+		"rule leader: start key must be less than end key",
+		"rule leader: group id other mismatches custom",
+		"rule leader: duplicated id",
+		"rule labels: start key is not in hex",
+		"rule labels: values of label zone are required by op notIn",
+		"rule labels: invalid op \"eq\" of label disk",
+	}, validateBundle("custom", &bundle))
+
+	require.Len(t, validateBundle("TiDB_DDL_45", &GroupBundle{}), 1)
+	require.Equal(t, []string{"group id is required"}, validateBundle("", &GroupBundle{}))
+}
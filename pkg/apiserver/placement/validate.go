@@ -0,0 +1,90 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package placement
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+var (
+	validRoles = map[string]struct{}{"voter": {}, "leader": {}, "follower": {}, "learner": {}}
+	validOps   = map[string]struct{}{"in": {}, "notIn": {}, "exists": {}, "notExists": {}}
+)
+
+// validateBundle checks the bundle the same as PD does, so that problems are reported all at once before applying.
+// Empty group IDs of rules are filled by the group ID of the bundle.
+func validateBundle(groupID string, bundle *GroupBundle) []string {
+	problems := make([]string, 0)
+	if groupID == "" {
+		return append(problems, "group id is required")
+	}
+	if strings.HasPrefix(groupID, tidbGroupPrefix) {
+		problems = append(problems, fmt.Sprintf("group %s is managed by placement policies of TiDB, "+
+			"which should be changed by ALTER TABLE ... PLACEMENT POLICY instead", groupID))
+	}
+	if bundle.ID == "" {
+		bundle.ID = groupID
+	} else if bundle.ID != groupID {
+		problems = append(problems, fmt.Sprintf("group id %s of the bundle mismatches %s", bundle.ID, groupID))
+	}
+
+	ids := make(map[string]struct{}, len(bundle.Rules))
+	for i := range bundle.Rules {
+		rule := &bundle.Rules[i]
+		if rule.GroupID == "" {
+			rule.GroupID = groupID
+		}
+		for _, p := range validateRule(rule) {
+			problems = append(problems, fmt.Sprintf("rule %s: %s", rule.ID, p))
+		}
+		if rule.GroupID != groupID {
+			problems = append(problems, fmt.Sprintf("rule %s: group id %s mismatches %s", rule.ID, rule.GroupID, groupID))
+		}
+		if _, ok := ids[rule.ID]; ok {
+			problems = append(problems, fmt.Sprintf("rule %s: duplicated id", rule.ID))
+		}
+		ids[rule.ID] = struct{}{}
+	}
+	return problems
+}
+
+func validateRule(rule *Rule) []string {
+	var problems []string
+	if rule.ID == "" {
+		problems = append(problems, "id is required")
+	}
+	if _, ok := validRoles[rule.Role]; !ok {
+		problems = append(problems, fmt.Sprintf("invalid role %q", rule.Role))
+	}
+	if rule.Count <= 0 {
+		problems = append(problems, "count must be positive")
+	} else if rule.Role == "leader" && rule.Count > 1 {
+		problems = append(problems, "count of leaders must be 1")
+	}
+	if rule.IsWitness && rule.Role == "leader" {
+		problems = append(problems, "leaders cannot be witnesses")
+	}
+
+	start, err1 := hex.DecodeString(rule.StartKey)
+	end, err2 := hex.DecodeString(rule.EndKey)
+	switch {
+	case err1 != nil:
+		problems = append(problems, "start key is not in hex")
+	case err2 != nil:
+		problems = append(problems, "end key is not in hex")
+	case len(end) > 0 && bytes.Compare(start, end) >= 0:
+		problems = append(problems, "start key must be less than end key")
+	}
+
+	for _, lc := range rule.LabelConstraints {
+		if _, ok := validOps[lc.Op]; !ok {
+			problems = append(problems, fmt.Sprintf("invalid op %q of label %s", lc.Op, lc.Key))
+		} else if (lc.Op == "in" || lc.Op == "notIn") && len(lc.Values) == 0 {
+			problems = append(problems, fmt.Sprintf("values of label %s are required by op %s", lc.Key, lc.Op))
+		}
+	}
+	return problems
+}
@@ -0,0 +1,297 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package profiling
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"hash/fnv"
+	"html"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/google/pprof/profile"
+)
+
+const (
+	flameGraphWidth       = 1200
+	flameGraphFrameHeight = 16
+	flameGraphMargin      = 10
+	// flameGraphMinWidth is the min width in pixels of frames to be rendered.
+	flameGraphMinWidth = 0.1
+	// topTableMaxRows is the max number of functions in the top table.
+	topTableMaxRows = 100
+)
+
+// collapsedStack is a stack with its value, frames are ordered from the root to the leaf.
+type collapsedStack struct {
+	frames []string
+	value  int64
+}
+
+// stacksFromProtobuf collapses the samples of a pprof protobuf profile using the default sample type.
+func stacksFromProtobuf(content []byte) ([]collapsedStack, string, error) {
+	p, err := profile.ParseData(content)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(p.SampleType) == 0 {
+		return nil, "", fmt.Errorf("no sample type in the profile")
+	}
+	index := len(p.SampleType) - 1
+	for i, st := range p.SampleType {
+		if st.Type == p.DefaultSampleType {
+			index = i
+		}
+	}
+
+	stacks := make([]collapsedStack, 0, len(p.Sample))
+	for _, sample := range p.Sample {
+		var frames []string
+		// Locations and lines are ordered from the leaf to the root.
+		for i := len(sample.Location) - 1; i >= 0; i-- {
+			loc := sample.Location[i]
+			for j := len(loc.Line) - 1; j >= 0; j-- {
+				if fn := loc.Line[j].Function; fn != nil {
+					frames = append(frames, fn.Name)
+				}
+			}
+			if len(loc.Line) == 0 {
+				frames = append(frames, fmt.Sprintf("0x%x", loc.Address))
+			}
+		}
+		stacks = append(stacks, collapsedStack{frames: frames, value: sample.Value[index]})
+	}
+	return stacks, p.SampleType[index].Unit, nil
+}
+
+// stacksFromCollapsed parses Brendan Gregg's collapsed stack format, i.e. `root;child;leaf value` in each line.
+func stacksFromCollapsed(content []byte) []collapsedStack {
+	var stacks []collapsedStack
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		sep := strings.LastIndexByte(line, ' ')
+		if sep <= 0 {
+			continue
+		}
+		value, err := strconv.ParseInt(line[sep+1:], 10, 64)
+		if err != nil {
+			continue
+		}
+		stacks = append(stacks, collapsedStack{frames: strings.Split(line[:sep], ";"), value: value})
+	}
+	return stacks
+}
+
+// stacksFromDebugText parses the text format of Go profiles with `debug=1`, e.g. goroutine and mutex profiles.
+// Each record starts with a line like `<value> [<count>] @ <addresses>`, followed by frames like
+// `#	0x1234	pkg.func+0x12	file.go:34` from the leaf to the root.
+func stacksFromDebugText(content []byte) []collapsedStack {
+	var stacks []collapsedStack
+	var cur *collapsedStack
+	flush := func() {
+		if cur != nil && len(cur.frames) > 0 {
+			// Reverse the frames to be from the root to the leaf.
+			for i, j := 0, len(cur.frames)-1; i < j; i, j = i+1, j-1 {
+				cur.frames[i], cur.frames[j] = cur.frames[j], cur.frames[i]
+			}
+			stacks = append(stacks, *cur)
+		}
+		cur = nil
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "":
+			flush()
+		case strings.HasPrefix(line, "#"):
+			if cur == nil {
+				continue
+			}
+			fields := strings.Fields(line)
+			if len(fields) >= 3 {
+				name := fields[2]
+				if i := strings.LastIndex(name, "+0x"); i > 0 {
+					name = name[:i]
+				}
+				cur.frames = append(cur.frames, name)
+			}
+		case strings.Contains(line, " @"):
+			flush()
+			value, err := strconv.ParseInt(strings.Fields(line)[0], 10, 64)
+			if err != nil {
+				continue
+			}
+			cur = &collapsedStack{value: value}
+		}
+	}
+	flush()
+	return stacks
+}
+
+type flameNode struct {
+	name     string
+	value    int64
+	children map[string]*flameNode
+}
+
+func buildFlameTree(stacks []collapsedStack) *flameNode {
+	root := &flameNode{name: "root", children: map[string]*flameNode{}}
+	for _, s := range stacks {
+		if s.value <= 0 {
+			continue
+		}
+		node := root
+		node.value += s.value
+		for _, frame := range s.frames {
+			child, ok := node.children[frame]
+			if !ok {
+				child = &flameNode{name: frame, children: map[string]*flameNode{}}
+				node.children[frame] = child
+			}
+			child.value += s.value
+			node = child
+		}
+	}
+	return root
+}
+
+func (n *flameNode) sortedChildren() []*flameNode {
+	children := make([]*flameNode, 0, len(n.children))
+	for _, c := range n.children {
+		children = append(children, c)
+	}
+	sort.Slice(children, func(i, j int) bool {
+		return children[i].name < children[j].name
+	})
+	return children
+}
+
+func (n *flameNode) depth() int {
+	d := 0
+	for _, c := range n.children {
+		if cd := c.depth(); cd > d {
+			d = cd
+		}
+	}
+	return d + 1
+}
+
+// flameColor returns a warm color stable for the function name.
+func flameColor(name string) string {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(name))
+	v := h.Sum32()
+	return fmt.Sprintf("rgb(%d,%d,%d)", 205+v%50, 80+(v>>8)%150, 30+(v>>16)%40)
+}
+
+// renderFlameGraph renders the stacks as a flame graph in SVG, with the root at the bottom.
+func renderFlameGraph(stacks []collapsedStack, title, unit string) []byte {
+	root := buildFlameTree(stacks)
+	depth := root.depth()
+	height := depth*flameGraphFrameHeight + 3*flameGraphMargin + flameGraphFrameHeight
+	scale := 0.0
+	if root.value > 0 {
+		scale = float64(flameGraphWidth-2*flameGraphMargin) / float64(root.value)
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<?xml version="1.0" standalone="no"?>`+"\n")
+	fmt.Fprintf(&buf, `<svg version="1.1" width="%d" height="%d" xmlns="http://www.w3.org/2000/svg" font-family="Verdana" font-size="11">`+"\n", flameGraphWidth, height)
+	fmt.Fprintf(&buf, `<rect x="0" y="0" width="%d" height="%d" fill="#f8f8f8"/>`+"\n", flameGraphWidth, height)
+	fmt.Fprintf(&buf, `<text x="%d" y="%d" text-anchor="middle" font-size="14">%s</text>`+"\n",
+		flameGraphWidth/2, flameGraphMargin+flameGraphFrameHeight/2+4, html.EscapeString(title))
+
+	var render func(n *flameNode, x float64, level int)
+	render = func(n *flameNode, x float64, level int) {
+		w := float64(n.value) * scale
+		if w < flameGraphMinWidth {
+			return
+		}
+		y := height - flameGraphMargin - (level+1)*flameGraphFrameHeight
+		percent := float64(n.value) * 100 / float64(root.value)
+		name := html.EscapeString(n.name)
+		fmt.Fprintf(&buf, `<g><title>%s (%d %s, %.2f%%)</title>`, name, n.value, html.EscapeString(unit), percent)
+		fmt.Fprintf(&buf, `<rect x="%.1f" y="%d" width="%.1f" height="%d" fill="%s" rx="2" ry="2"/>`,
+			x, y, w, flameGraphFrameHeight-1, flameColor(n.name))
+		// Each character takes about 7 pixels.
+		if chars := int(w/7) - 1; chars >= 3 {
+			label := n.name
+			if len(label) > chars {
+				label = label[:chars-2] + ".."
+			}
+			fmt.Fprintf(&buf, `<text x="%.1f" y="%d">%s</text>`, x+3, y+flameGraphFrameHeight-4, html.EscapeString(label))
+		}
+		buf.WriteString("</g>\n")
+		for _, c := range n.sortedChildren() {
+			render(c, x, level+1)
+			x += float64(c.value) * scale
+		}
+	}
+	if root.value > 0 {
+		render(root, flameGraphMargin, 0)
+	}
+	buf.WriteString("</svg>\n")
+	return buf.Bytes()
+}
+
+// renderTopTable renders the functions with the most flat values, like `pprof -top`.
+func renderTopTable(stacks []collapsedStack, unit string) []byte {
+	flat := map[string]int64{}
+	cum := map[string]int64{}
+	var total int64
+	for _, s := range stacks {
+		if s.value <= 0 || len(s.frames) == 0 {
+			continue
+		}
+		total += s.value
+		flat[s.frames[len(s.frames)-1]] += s.value
+		// Recursive functions are only counted once in each stack.
+		seen := map[string]struct{}{}
+		for _, f := range s.frames {
+			if _, ok := seen[f]; ok {
+				continue
+			}
+			seen[f] = struct{}{}
+			cum[f] += s.value
+		}
+	}
+
+	names := make([]string, 0, len(cum))
+	for name := range cum {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if flat[names[i]] != flat[names[j]] {
+			return flat[names[i]] > flat[names[j]]
+		}
+		if cum[names[i]] != cum[names[j]] {
+			return cum[names[i]] > cum[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	if len(names) > topTableMaxRows {
+		names = names[:topTableMaxRows]
+	}
+
+	percent := func(v int64) float64 {
+		if total == 0 {
+			return 0
+		}
+		return float64(v) * 100 / float64(total)
+	}
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "Total: %d %s\n", total, unit)
+	fmt.Fprintf(&buf, "%14s %7s %14s %7s  %s\n", "flat", "flat%", "cum", "cum%", "function")
+	for _, name := range names {
+		fmt.Fprintf(&buf, "%14d %6.2f%% %14d %6.2f%%  %s\n", flat[name], percent(flat[name]), cum[name], percent(cum[name]), name)
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,91 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package profiling
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/google/pprof/profile"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStacksFromDebugText(t *testing.T) {
+	goroutines := `goroutine profile: total 5
+3 @ 0x1 0x2 0x3
+#	0x1	runtime.gopark+0xce	/go/src/runtime/proc.go:398
+#	0x2	main.worker+0x10	/app/main.go:10
+#	0x3	runtime.goexit+0x1	/go/src/runtime/asm_amd64.s:1650
+
+2 @ 0x4 0x3
+#	0x4	main.idle+0x20	/app/main.go:20
+#	0x3	runtime.goexit+0x1	/go/src/runtime/asm_amd64.s:1650
+`
+	stacks := stacksFromDebugText([]byte(goroutines))
+	require.Equal(t, []collapsedStack{
+		{frames: []string{"runtime.goexit", "main.worker", "runtime.gopark"}, value: 3},
+		{frames: []string{"runtime.goexit", "main.idle"}, value: 2},
+	}, stacks)
+
+	mutex := `--- mutex:
+cycles/second=1000000000
+sampling period=5
+100 2 @ 0x1 0x2
+#	0x1	sync.(*Mutex).Unlock+0x10	/go/src/sync/mutex.go:1
+#	0x2	main.lock+0x20	/app/main.go:30
+`
+	require.Equal(t, []collapsedStack{
+		{frames: []string{"main.lock", "sync.(*Mutex).Unlock"}, value: 100},
+	}, stacksFromDebugText([]byte(mutex)))
+}
+
+func TestStacksFromCollapsed(t *testing.T) {
+	stacks := stacksFromCollapsed([]byte("main;a;b 10\nmain;c 5\ninvalid\n"))
+	require.Equal(t, []collapsedStack{
+		{frames: []string{"main", "a", "b"}, value: 10},
+		{frames: []string{"main", "c"}, value: 5},
+	}, stacks)
+}
+
+func TestStacksFromProtobuf(t *testing.T) {
+	fnMain := &profile.Function{ID: 1, Name: "main.main"}
+	fnWork := &profile.Function{ID: 2, Name: "main.work"}
+	locMain := &profile.Location{ID: 1, Line: []profile.Line{{Function: fnMain}}}
+	locWork := &profile.Location{ID: 2, Line: []profile.Line{{Function: fnWork}}}
+	p := &profile.Profile{
+		SampleType: []*profile.ValueType{{Type: "samples", Unit: "count"}, {Type: "cpu", Unit: "nanoseconds"}},
+		Sample: []*profile.Sample{
+			{Location: []*profile.Location{locWork, locMain}, Value: []int64{1, 100}},
+		},
+		Location: []*profile.Location{locMain, locWork},
+		Function: []*profile.Function{fnMain, fnWork},
+	}
+	var buf bytes.Buffer
+	require.NoError(t, p.Write(&buf))
+
+	stacks, unit, err := stacksFromProtobuf(buf.Bytes())
+	require.NoError(t, err)
+	require.Equal(t, "nanoseconds", unit)
+	require.Equal(t, []collapsedStack{{frames: []string{"main.main", "main.work"}, value: 100}}, stacks)
+}
+
+func TestRenderFlameGraphAndTopTable(t *testing.T) {
+	stacks := []collapsedStack{
+		{frames: []string{"main", "a", "b"}, value: 30},
+		{frames: []string{"main", "a"}, value: 10},
+		{frames: []string{"main", "<c>"}, value: 60},
+	}
+	svg := string(renderFlameGraph(stacks, "cpu profile", "samples"))
+	require.True(t, strings.HasPrefix(svg, "<?xml"))
+	require.Contains(t, svg, "<title>main (100 samples, 100.00%)</title>")
+	require.Contains(t, svg, "<title>&lt;c&gt; (60 samples, 60.00%)</title>")
+
+	top := strings.Split(strings.TrimSpace(string(renderTopTable(stacks, "samples"))), "\n")
+	require.Len(t, top, 6)
+	require.Equal(t, "Total: 100 samples", top[0])
+	require.True(t, strings.HasSuffix(top[2], "<c>"))
+	require.True(t, strings.HasSuffix(top[3], "b"))
+	require.True(t, strings.HasSuffix(top[4], "a"))
+	require.True(t, strings.HasSuffix(top[5], "main"))
+}
@@ -16,6 +16,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/audit"
 	"github.com/pingcap/tidb-dashboard/pkg/apiserver/user"
 	"github.com/pingcap/tidb-dashboard/pkg/apiserver/utils"
 	"github.com/pingcap/tidb-dashboard/pkg/config"
@@ -23,13 +24,14 @@ import (
 )
 
 // Register register the handlers to the service.
-func RegisterRouter(r *gin.RouterGroup, auth *user.AuthService, s *Service) {
+func RegisterRouter(r *gin.RouterGroup, auth *user.AuthService, a *audit.Service, s *Service) {
 	endpoint := r.Group("/profiling")
+	endpoint.Use(s.FeatureFlagProfiling.VersionGuard())
 	endpoint.GET("/group/list", auth.MWAuthRequired(), s.getGroupList)
-	endpoint.POST("/group/start", auth.MWAuthRequired(), s.handleStartGroup)
+	endpoint.POST("/group/start", auth.MWAuthRequired(), a.MWRecord("profiling.start"), s.handleStartGroup)
 	endpoint.GET("/group/detail/:groupId", auth.MWAuthRequired(), s.getGroupDetail)
-	endpoint.POST("/group/cancel/:groupId", auth.MWAuthRequired(), s.handleCancelGroup)
-	endpoint.DELETE("/group/delete/:groupId", auth.MWAuthRequired(), s.deleteGroup)
+	endpoint.POST("/group/cancel/:groupId", auth.MWAuthRequired(), a.MWRecord("profiling.cancel"), s.handleCancelGroup)
+	endpoint.DELETE("/group/delete/:groupId", auth.MWAuthRequired(), a.MWRecord("profiling.delete"), s.deleteGroup)
 
 	endpoint.GET("/action_token", auth.MWAuthRequired(), s.getActionToken)
 	endpoint.GET("/group/download", s.downloadGroup)
@@ -37,7 +39,7 @@ func RegisterRouter(r *gin.RouterGroup, auth *user.AuthService, s *Service) {
 	endpoint.GET("/single/view", s.viewSingle)
 
 	endpoint.GET("/config", auth.MWAuthRequired(), s.getDynamicConfig)
-	endpoint.PUT("/config", auth.MWAuthRequired(), auth.MWRequireWritePriv(), s.setDynamicConfig)
+	endpoint.PUT("/config", auth.MWAuthRequired(), auth.MWRequireWritePriv(), a.MWRecord("profiling.set_config"), s.setDynamicConfig)
 }
 
 // @ID startProfiling
@@ -194,6 +196,7 @@ func (s *Service) getActionToken(c *gin.Context) {
 // @Description Download all finished profiling results of a task group
 // @Produce application/x-gzip
 // @Param token query string true "download token"
+// @Success 200 {string} string "Archive of the profiling results"
 // @Security JwtAuth
 // @Failure 400 {object} rest.ErrorResponse
 // @Failure 401 {object} rest.ErrorResponse
@@ -249,6 +252,7 @@ func (s *Service) downloadGroup(c *gin.Context) {
 // @Description Download the finished profiling result of a task
 // @Produce application/x-gzip
 // @Param token query string true "download token"
+// @Success 200 {string} string "Archive of the profiling result"
 // @Security JwtAuth
 // @Failure 400 {object} rest.ErrorResponse
 // @Failure 401 {object} rest.ErrorResponse
@@ -370,13 +374,51 @@ const (
 	ViewOutputTypeProtobuf ViewOutputType = "protobuf"
 	ViewOutputTypeGraph    ViewOutputType = "graph"
 	ViewOutputTypeText     ViewOutputType = "text"
+	// ViewOutputTypeFlameGraph and ViewOutputTypeTop are rendered on the server for all raw data types.
+	ViewOutputTypeFlameGraph ViewOutputType = "flamegraph"
+	ViewOutputTypeTop        ViewOutputType = "top"
 )
 
+// jeprofCollapsed converts the jeprof raw data file to Brendan Gregg's collapsed stack format.
+func jeprofCollapsed(filePath string) ([]byte, error) {
+	cmd := exec.Command("perl", "/dev/stdin", "--collapsed", filePath) //nolint:gosec
+	cmd.Stdin = strings.NewReader(jeprof)
+	return cmd.Output()
+}
+
+// stacksOfTask collapses the stacks in the raw data of the task, returning the unit of the values.
+func stacksOfTask(task TaskModel, content []byte) ([]collapsedStack, string, error) {
+	switch task.RawDataType {
+	case RawDataTypeProtobuf:
+		return stacksFromProtobuf(content)
+	case RawDataTypeJeprof:
+		collapsed, err := jeprofCollapsed(task.FilePath)
+		if err != nil {
+			return nil, "", err
+		}
+		return stacksFromCollapsed(collapsed), "bytes", nil
+	case RawDataTypeText:
+		unit := ""
+		switch task.ProfilingType {
+		case ProfilingTypeGoroutine:
+			unit = "goroutines"
+		case ProfilingTypeMutex:
+			unit = "cycles"
+		}
+		return stacksFromDebugText(content), unit, nil
+	default:
+		return nil, "", rest.ErrBadRequest.New("Cannot render %s raw data", task.RawDataType)
+	}
+}
+
 // @ID viewProfilingSingle
 // @Summary View the result of a task
-// @Description View the finished profiling result of a task
+// @Description View the finished profiling result of a task. With output_type=flamegraph or output_type=top,
+// @Description the result is rendered on the server as a flame graph in SVG or a table of top functions in text.
 // @Produce html
 // @Param token query string true "download token"
+// @Param output_type query string false "output type" Enums(protobuf, graph, text, flamegraph, top)
+// @Success 200 {string} string "Profiling result in the output type"
 // @Security JwtAuth
 // @Failure 400 {object} rest.ErrorResponse
 // @Failure 401 {object} rest.ErrorResponse
@@ -408,6 +450,22 @@ func (s *Service) viewSingle(c *gin.Context) {
 		return
 	}
 
+	switch outputType {
+	case string(ViewOutputTypeFlameGraph), string(ViewOutputTypeTop):
+		stacks, unit, err := stacksOfTask(task, content)
+		if err != nil {
+			rest.Error(c, err)
+			return
+		}
+		if outputType == string(ViewOutputTypeTop) {
+			c.Data(http.StatusOK, "text/plain", renderTopTable(stacks, unit))
+			return
+		}
+		title := fmt.Sprintf("%s profile of %s", task.ProfilingType, task.Target.String())
+		c.Data(http.StatusOK, "image/svg+xml", renderFlameGraph(stacks, title, unit))
+		return
+	}
+
 	// set default content-type for legacy profiling content.
 	contentType := "image/svg+xml"
 
@@ -448,9 +506,7 @@ func (s *Service) viewSingle(c *gin.Context) {
 			contentType = "image/svg+xml"
 		case string(ViewOutputTypeText):
 			// Brendan Gregg's collapsed stack format
-			cmd := exec.Command("perl", "/dev/stdin", "--collapsed", task.FilePath) //nolint:gosec
-			cmd.Stdin = strings.NewReader(jeprof)
-			textContent, err := cmd.Output()
+			textContent, err := jeprofCollapsed(task.FilePath)
 			if err != nil {
 				rest.Error(c, err)
 				return
@@ -507,6 +563,7 @@ func (s *Service) deleteGroup(c *gin.Context) {
 	c.JSON(http.StatusOK, rest.EmptyResponse{})
 }
 
+// @ID profilingConfigGet
 // @Summary Get Profiling Dynamic Config
 // @Success 200 {object} config.ProfilingConfig
 // @Router /profiling/config [get]
@@ -522,6 +579,7 @@ func (s *Service) getDynamicConfig(c *gin.Context) {
 	c.JSON(http.StatusOK, dc.Profiling)
 }
 
+// @ID profilingConfigPut
 // @Summary Set Profiling Dynamic Config
 // @Param request body config.ProfilingConfig true "Request body"
 // @Success 200 {object} config.ProfilingConfig
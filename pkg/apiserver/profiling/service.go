@@ -4,20 +4,22 @@ package profiling
 
 import (
 	"context"
+	"fmt"
 	"sync"
 	"time"
 
 	"github.com/joomcode/errorx"
 	"github.com/pingcap/log"
-	"go.etcd.io/etcd/clientv3"
 	"go.uber.org/fx"
 	"go.uber.org/zap"
 
 	"github.com/pingcap/tidb-dashboard/pkg/apiserver/model"
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/notification"
 	"github.com/pingcap/tidb-dashboard/pkg/config"
 	"github.com/pingcap/tidb-dashboard/pkg/dbstore"
 	"github.com/pingcap/tidb-dashboard/pkg/httpc"
 	"github.com/pingcap/tidb-dashboard/pkg/pd"
+	"github.com/pingcap/tidb-dashboard/util/featureflag"
 )
 
 const (
@@ -50,15 +52,20 @@ type ServiceParams struct {
 	ConfigManager *config.DynamicConfigManager
 	LocalStore    *dbstore.DB
 
-	HTTPClient *httpc.Client
-	EtcdClient *clientv3.Client
-	PDClient   *pd.Client
+	HTTPClient  *httpc.Client
+	EtcdClients *pd.EtcdClientManager
+	PDClient    *pd.Client
+
+	FeatureFlags *featureflag.Registry
+	Notifier     *notification.Service
 }
 
 type Service struct {
 	params       ServiceParams
 	lifecycleCtx context.Context
 
+	FeatureFlagProfiling *featureflag.FeatureFlag
+
 	wg            sync.WaitGroup
 	sessionCh     chan *StartRequestSession
 	lastTaskGroup *TaskGroup
@@ -70,7 +77,11 @@ var newService = fx.Provide(func(lc fx.Lifecycle, p ServiceParams, fts *fetchers
 	if err := autoMigrate(p.LocalStore); err != nil {
 		return nil, err
 	}
-	s := &Service{params: p, fetchers: fts}
+	s := &Service{
+		params:               p,
+		fetchers:             fts,
+		FeatureFlagProfiling: p.FeatureFlags.Register("profiling"),
+	}
 	lc.Append(fx.Hook{
 		OnStart: func(ctx context.Context) error {
 			s.lifecycleCtx = ctx
@@ -207,6 +218,17 @@ func (s *Service) startGroup(ctx context.Context, req *StartRequest) (*TaskGroup
 			taskGroup.State = TaskStateFinish
 		}
 		s.params.LocalStore.Save(taskGroup.TaskGroupModel)
+		s.params.Notifier.Publish(notification.Event{
+			Type:    notification.EventProfilingFinished,
+			Title:   "Profiling completed",
+			Message: fmt.Sprintf("Profiling task group %d completed, %d of %d tasks finished.", taskGroup.ID, finishedTasks, len(tasks)),
+			Details: map[string]interface{}{
+				"task_group_id":  taskGroup.ID,
+				"state":          taskGroup.State,
+				"finished_tasks": finishedTasks,
+				"error_tasks":    errorTasks,
+			},
+		})
 	}()
 
 	return taskGroup, nil
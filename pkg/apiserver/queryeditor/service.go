@@ -3,57 +3,80 @@
 package queryeditor
 
 import (
+	"bufio"
 	"context"
 	"database/sql"
+	"encoding/json"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/joomcode/errorx"
 	"github.com/pingcap/log"
 	"go.uber.org/fx"
 	"go.uber.org/zap"
 
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/audit"
 	"github.com/pingcap/tidb-dashboard/pkg/apiserver/user"
 	"github.com/pingcap/tidb-dashboard/pkg/apiserver/utils"
 	"github.com/pingcap/tidb-dashboard/pkg/config"
 	"github.com/pingcap/tidb-dashboard/pkg/tidb"
+	"github.com/pingcap/tidb-dashboard/util/featureflag"
 	"github.com/pingcap/tidb-dashboard/util/rest"
 )
 
+const (
+	defaultMaxRows = 1000
+	maxMaxRows     = 10000
+
+	defaultTimeout = time.Minute
+	maxTimeout     = time.Minute * 5
+
+	// streamFlushInterval is the number of rows between flushes of the streamed response.
+	streamFlushInterval = 100
+)
+
+var (
+	ErrNS          = errorx.NewNamespace("error.api.query_editor")
+	ErrNotReadOnly = ErrNS.NewType("not_read_only")
+)
+
 type ServiceParams struct {
 	fx.In
 	Config     *config.Config
 	TiDBClient *tidb.Client
+
+	FeatureFlags *featureflag.Registry
 }
 
 type Service struct {
-	params       ServiceParams
-	lifecycleCtx context.Context
-}
+	params ServiceParams
 
-func NewService(lc fx.Lifecycle, p ServiceParams) *Service {
-	service := &Service{params: p}
-	lc.Append(fx.Hook{
-		OnStart: func(ctx context.Context) error {
-			service.lifecycleCtx = ctx
-			return nil
-		},
-	})
+	FeatureFlagQueryEditor *featureflag.FeatureFlag
+}
 
-	return service
+func NewService(p ServiceParams) *Service {
+	return &Service{params: p, FeatureFlagQueryEditor: p.FeatureFlags.Register("query_editor")}
 }
 
-func RegisterRouter(r *gin.RouterGroup, auth *user.AuthService, s *Service) {
+func RegisterRouter(r *gin.RouterGroup, auth *user.AuthService, a *audit.Service, s *Service) {
 	endpoint := r.Group("/query_editor")
+	endpoint.Use(s.FeatureFlagQueryEditor.VersionGuard())
 	endpoint.Use(auth.MWAuthRequired())
 	endpoint.Use(utils.MWConnectTiDB(s.params.TiDBClient))
 	endpoint.Use(utils.MWForbidByExperimentalFlag(s.params.Config.EnableExperimental))
-	endpoint.POST("/run", auth.MWRequireWritePriv(), s.runHandler)
+	endpoint.POST("/run", auth.MWRequireWritePriv(), a.MWRecord("query_editor.run"), s.runHandler)
+	endpoint.POST("/run_stream", auth.MWRequireWritePriv(), a.MWRecord("query_editor.run"), s.runStreamHandler)
 }
 
 type RunRequest struct {
 	Statements string `json:"statements" example:"show databases;"`
-	MaxRows    int    `json:"max_rows" example:"1000"`
+	// MaxRows is the max number of rows returned, 1000 by default and at most 10000.
+	MaxRows int `json:"max_rows" example:"1000"`
+	// ReadOnly rejects statements modifying data, and runs statements in a read-only transaction.
+	ReadOnly bool `json:"read_only"`
+	// TimeoutSecs is the timeout of running statements, 60 seconds by default and at most 300 seconds.
+	TimeoutSecs int `json:"timeout_secs"`
 }
 
 type RunResponse struct {
@@ -62,22 +85,29 @@ type RunResponse struct {
 	Rows        [][]interface{} `json:"rows"`
 	ExecutionMs int64           `json:"execution_ms"`
 	ActualRows  int             `json:"actual_rows"`
+	// Truncated is true when there are more rows than the max rows, which are not read.
+	Truncated bool `json:"truncated"`
 }
 
-func executeStatements(context context.Context, db *sql.DB, statements string) ([]string, [][]interface{}, error) {
-	rows, err := db.QueryContext(context, statements)
+// queryer runs queries, which can be either a connection pool or a dedicated connection.
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// executeStatements runs the statements and calls onRow for each row of the result, until onRow returns false.
+func executeStatements(ctx context.Context, q queryer, statements string, onColumns func([]string), onRow func([]interface{}) bool) error {
+	rows, err := q.QueryContext(ctx, statements)
 	if err != nil {
-		return nil, nil, err
+		return err
 	}
 
 	defer rows.Close()
 
 	colNames, err := rows.Columns()
 	if err != nil {
-		return nil, nil, err
+		return err
 	}
-
-	retRows := make([][]interface{}, 0)
+	onColumns(colNames)
 
 	values := make([]sql.RawBytes, len(colNames))
 	scanArgs := make([]interface{}, len(values))
@@ -88,7 +118,7 @@ func executeStatements(context context.Context, db *sql.DB, statements string) (
 	for rows.Next() {
 		err = rows.Scan(scanArgs...)
 		if err != nil {
-			return nil, nil, err
+			return err
 		}
 
 		retRow := make([]interface{}, 0, len(values))
@@ -101,14 +131,73 @@ func executeStatements(context context.Context, db *sql.DB, statements string) (
 			}
 			retRow = append(retRow, value)
 		}
-		retRows = append(retRows, retRow)
+		if !onRow(retRow) {
+			return nil
+		}
+	}
+
+	return rows.Err()
+}
+
+func normalizeRunRequest(req *RunRequest) {
+	if req.MaxRows <= 0 {
+		req.MaxRows = defaultMaxRows
+	}
+	if req.MaxRows > maxMaxRows {
+		req.MaxRows = maxMaxRows
+	}
+	if req.TimeoutSecs <= 0 {
+		req.TimeoutSecs = int(defaultTimeout / time.Second)
+	}
+	if req.TimeoutSecs > int(maxTimeout/time.Second) {
+		req.TimeoutSecs = int(maxTimeout / time.Second)
+	}
+}
+
+// bindRunRequest parses the request and rejects statements modifying data in read-only mode. Errors are responded.
+func bindRunRequest(c *gin.Context) (*RunRequest, bool) {
+	var req RunRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		rest.Error(c, rest.ErrBadRequest.NewWithNoMessage())
+		return nil, false
+	}
+	normalizeRunRequest(&req)
+	if req.ReadOnly {
+		if stmt := firstModifyingStatement(req.Statements); stmt != "" {
+			rest.Error(c, ErrNotReadOnly.New("Statement is not allowed in read-only mode: %s", stmt).
+				WithProperty(rest.HTTPCodeProperty(http.StatusForbidden)))
+			return nil, false
+		}
 	}
+	return &req, true
+}
 
-	if err = rows.Err(); err != nil {
-		return nil, nil, err
+// run runs the statements of the request, in a read-only transaction of a dedicated connection in read-only mode.
+func (s *Service) run(c *gin.Context, req *RunRequest, onColumns func([]string), onRow func([]interface{}) bool) error {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), time.Duration(req.TimeoutSecs)*time.Second)
+	defer cancel()
+
+	sqlDB, err := utils.GetTiDBConnection(c).DB()
+	if err != nil {
+		return err
+	}
+	if !req.ReadOnly {
+		return executeStatements(ctx, sqlDB, req.Statements, onColumns, onRow)
 	}
 
-	return colNames, retRows, nil
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if _, err := conn.ExecContext(ctx, "START TRANSACTION READ ONLY"); err != nil {
+		return err
+	}
+	// The transaction is rolled back even when timed out.
+	defer func() {
+		_, _ = conn.ExecContext(context.Background(), "ROLLBACK")
+	}()
+	return executeStatements(ctx, conn, req.Statements, onColumns, onRow)
 }
 
 // @ID queryEditorRun
@@ -121,44 +210,108 @@ func executeStatements(context context.Context, db *sql.DB, statements string) (
 // @Failure 401 {object} rest.ErrorResponse
 // @Failure 403 {object} rest.ErrorResponse
 func (s *Service) runHandler(c *gin.Context) {
-	var req RunRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		rest.Error(c, rest.ErrBadRequest.NewWithNoMessage())
+	req, ok := bindRunRequest(c)
+	if !ok {
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(s.lifecycleCtx, time.Minute*5)
-	defer cancel()
-
+	var resp RunResponse
 	startTime := time.Now()
-	sqlDB, err := utils.GetTiDBConnection(c).DB()
-	if err != nil {
-		panic(err)
-	}
-	colNames, rows, err := executeStatements(ctx, sqlDB, req.Statements)
-	elapsedTime := time.Since(startTime)
+	err := s.run(c, req, func(colNames []string) {
+		resp.ColumnNames = colNames
+		resp.Rows = make([][]interface{}, 0)
+	}, func(row []interface{}) bool {
+		// Rows beyond the limit are not read.
+		if len(resp.Rows) >= req.MaxRows {
+			resp.Truncated = true
+			return false
+		}
+		resp.Rows = append(resp.Rows, row)
+		resp.ActualRows++
+		return true
+	})
+	resp.ExecutionMs = time.Since(startTime).Milliseconds()
 
 	if err != nil {
 		log.Warn("Failed to execute user input statements", zap.String("statements", req.Statements), zap.Error(err))
 		c.JSON(http.StatusOK, RunResponse{
 			ErrorMsg:    err.Error(),
-			ColumnNames: nil,
-			Rows:        nil,
-			ExecutionMs: elapsedTime.Milliseconds(),
-			ActualRows:  0,
+			ExecutionMs: resp.ExecutionMs,
 		})
 		return
 	}
 
-	truncatedRows := rows
-	if len(truncatedRows) > req.MaxRows {
-		truncatedRows = truncatedRows[:req.MaxRows]
+	c.JSON(http.StatusOK, resp)
+}
+
+type RunStreamHeader struct {
+	ColumnNames []string `json:"column_names"`
+}
+
+type RunStreamSummary struct {
+	ErrorMsg    string `json:"error_msg"`
+	ExecutionMs int64  `json:"execution_ms"`
+	ActualRows  int    `json:"actual_rows"`
+	// Truncated is true when there are more rows than the max rows, which are not read.
+	Truncated bool `json:"truncated"`
+}
+
+// @ID queryEditorRunStream
+// @Summary Run statements and stream the result
+// @Description The result is streamed in newline delimited JSON: a RunStreamHeader, followed by each row as an array, and finally a RunStreamSummary.
+// @Produce application/x-ndjson
+// @Param request body RunRequest true "Request body"
+// @Success 200 {object} RunStreamSummary
+// @Router /query_editor/run_stream [post]
+// @Security JwtAuth
+// @Failure 400 {object} rest.ErrorResponse
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 403 {object} rest.ErrorResponse
+func (s *Service) runStreamHandler(c *gin.Context) {
+	req, ok := bindRunRequest(c)
+	if !ok {
+		return
+	}
+
+	c.Header("Content-Type", "application/x-ndjson")
+	c.Status(http.StatusOK)
+	w := bufio.NewWriter(c.Writer)
+	enc := json.NewEncoder(w)
+	flush := func() {
+		_ = w.Flush()
+		c.Writer.Flush()
 	}
 
-	c.JSON(http.StatusOK, RunResponse{
-		ColumnNames: colNames,
-		Rows:        truncatedRows,
-		ExecutionMs: elapsedTime.Milliseconds(),
-		ActualRows:  len(rows),
+	var summary RunStreamSummary
+	headerWritten := false
+	startTime := time.Now()
+	err := s.run(c, req, func(colNames []string) {
+		_ = enc.Encode(RunStreamHeader{ColumnNames: colNames})
+		headerWritten = true
+		flush()
+	}, func(row []interface{}) bool {
+		if summary.ActualRows >= req.MaxRows {
+			summary.Truncated = true
+			return false
+		}
+		if err := enc.Encode(row); err != nil {
+			// The client is gone.
+			return false
+		}
+		summary.ActualRows++
+		if summary.ActualRows%streamFlushInterval == 0 {
+			flush()
+		}
+		return true
 	})
+	summary.ExecutionMs = time.Since(startTime).Milliseconds()
+	if err != nil {
+		log.Warn("Failed to execute user input statements", zap.String("statements", req.Statements), zap.Error(err))
+		summary.ErrorMsg = err.Error()
+		if !headerWritten {
+			_ = enc.Encode(RunStreamHeader{ColumnNames: []string{}})
+		}
+	}
+	_ = enc.Encode(summary)
+	flush()
 }
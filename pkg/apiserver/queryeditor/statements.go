@@ -0,0 +1,137 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package queryeditor
+
+import (
+	"strings"
+)
+
+// executableComment replaces executable comments, i.e. `/*! ... */` and `/*T! ... */`, which are run by TiDB.
+const executableComment = "/*!...*/"
+
+// splitStatements splits the statements by semicolons. Comments are removed, and contents of quoted strings,
+// identifiers and executable comments are replaced by placeholders, so that the returned statements are only used to
+// classify statements.
+func splitStatements(statements string) []string {
+	var result []string
+	var cur strings.Builder
+	flush := func() {
+		if s := strings.TrimSpace(cur.String()); s != "" {
+			result = append(result, s)
+		}
+		cur.Reset()
+	}
+
+	n := len(statements)
+	for i := 0; i < n; i++ {
+		ch := statements[i]
+		switch {
+		case ch == '\'' || ch == '"' || ch == '`':
+			// Skip to the closing quote. Quotes are escaped by backslashes or by doubling them.
+			j := i + 1
+			for ; j < n; j++ {
+				if statements[j] == '\\' && ch != '`' {
+					j++
+					continue
+				}
+				if statements[j] == ch {
+					if j+1 < n && statements[j+1] == ch {
+						j++
+						continue
+					}
+					break
+				}
+			}
+			if ch == '`' {
+				cur.WriteString("`x`")
+			} else {
+				cur.WriteString("'?'")
+			}
+			i = j
+		case ch == '#' || (ch == '-' && strings.HasPrefix(statements[i:], "-- ")):
+			for i < n && statements[i] != '\n' {
+				i++
+			}
+			cur.WriteByte(' ')
+		case ch == '/' && strings.HasPrefix(statements[i:], "/*"):
+			executable := strings.HasPrefix(statements[i:], "/*!") || strings.HasPrefix(statements[i:], "/*T!")
+			end := strings.Index(statements[i+2:], "*/")
+			if end < 0 {
+				i = n
+			} else {
+				i += end + 3
+			}
+			if executable {
+				cur.WriteString(" " + executableComment + " ")
+			} else {
+				cur.WriteByte(' ')
+			}
+		case ch == ';':
+			flush()
+		default:
+			cur.WriteByte(ch)
+		}
+	}
+	flush()
+	return result
+}
+
+// readOnlyKeywords are the leading keywords of statements not modifying data.
+var readOnlyKeywords = map[string]struct{}{
+	"SELECT":   {},
+	"SHOW":     {},
+	"DESC":     {},
+	"DESCRIBE": {},
+	"EXPLAIN":  {},
+	"WITH":     {},
+	"TABLE":    {},
+	"USE":      {},
+}
+
+// modifyingKeywords modify data even when appearing in a read-only statement, e.g. `WITH ... DELETE`.
+var modifyingKeywords = map[string]struct{}{
+	"INSERT":   {},
+	"UPDATE":   {},
+	"DELETE":   {},
+	"REPLACE":  {},
+	"OUTFILE":  {},
+	"DUMPFILE": {},
+}
+
+func statementTokens(statement string) []string {
+	return strings.FieldsFunc(strings.ToUpper(statement), func(r rune) bool {
+		return !(r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_')
+	})
+}
+
+// isReadOnlyStatement returns whether the statement split by splitStatements does not modify data. Statements with
+// executable comments are never read-only, as the comments can contain any statement.
+func isReadOnlyStatement(statement string) bool {
+	if strings.Contains(statement, executableComment) {
+		return false
+	}
+	tokens := statementTokens(statement)
+	if len(tokens) == 0 {
+		return true
+	}
+	if _, ok := readOnlyKeywords[tokens[0]]; !ok {
+		return false
+	}
+	for _, t := range tokens[1:] {
+		if _, ok := modifyingKeywords[t]; ok {
+			return false
+		}
+	}
+	return true
+}
+
+// firstModifyingStatement returns the first statement modifying data, or an empty string if all statements are
+// read-only.
+func firstModifyingStatement(statements string) string {
+	for _, s := range splitStatements(statements) {
+		if !isReadOnlyStatement(s) {
+			return s
+		}
+	}
+	return ""
+}
@@ -0,0 +1,42 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package queryeditor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitStatements(t *testing.T) {
+	require.Equal(t, []string{
+		"select '?' from `x`",
+		"show   databases",
+	}, splitStatements("select ';' from `a;b`; -- comment;\n show /* ; */ databases;;"))
+	require.Equal(t, []string{"select '?'"}, splitStatements(`select 'it''s \' ;'`))
+	require.Empty(t, splitStatements(" ; # only comments"))
+}
+
+func TestFirstModifyingStatement(t *testing.T) {
+	require.Equal(t, "", firstModifyingStatement("SELECT * FROM t; show tables; explain analyze select 1; use test"))
+	require.Equal(t, "", firstModifyingStatement("select 'delete from t', update_time from t"))
+	require.Equal(t, "delete from t", firstModifyingStatement("select 1; delete from t"))
+	require.Equal(t, "explain analyze insert into t values (1)", firstModifyingStatement("explain analyze insert into t values (1)"))
+	require.Equal(t, "with c as (select 1) update t set a = 1", firstModifyingStatement("with c as (select 1) update t set a = 1"))
+	require.Equal(t, "select * from t into outfile '?'", firstModifyingStatement("select * from t into outfile '/tmp/a'"))
+	require.Equal(t, "drop table t", firstModifyingStatement("/* hint */ drop table t"))
+	require.Equal(t, "/*!...*/", firstModifyingStatement("/*!40000 DROP TABLE t */"))
+	require.Equal(t, "/*!...*/", firstModifyingStatement("SELECT 1; /*T! DELETE FROM t */"))
+	require.Equal(t, "select  /*!...*/  1", firstModifyingStatement("select /*T![clustered_index] 1 */ 1"))
+	require.Equal(t, "", firstModifyingStatement("select /*+ read_from_storage(tiflash[t]) */ * from t"))
+}
+
+func TestNormalizeRunRequest(t *testing.T) {
+	req := RunRequest{}
+	normalizeRunRequest(&req)
+	require.Equal(t, RunRequest{MaxRows: defaultMaxRows, TimeoutSecs: 60}, req)
+
+	req = RunRequest{MaxRows: 100000, TimeoutSecs: 3600, ReadOnly: true}
+	normalizeRunRequest(&req)
+	require.Equal(t, RunRequest{MaxRows: maxMaxRows, TimeoutSecs: 300, ReadOnly: true}, req)
+}
@@ -0,0 +1,246 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package region
+
+import (
+	"encoding/hex"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"github.com/pingcap/tidb-dashboard/pkg/tidb/model"
+	"github.com/pingcap/tidb-dashboard/util/rest"
+)
+
+const (
+	// dataKeyPrefix prefixes keys in the storage of TiKV, which appear in TiKV logs.
+	dataKeyPrefix = 'z'
+	// keyspacePrefix prefixes keys of a keyspace in API v2, followed by the 3 bytes keyspace ID.
+	keyspacePrefix    = 'x'
+	keyspacePrefixLen = 4
+)
+
+type KeyType string
+
+const (
+	// KeyTypeUnbounded is the empty key, i.e. the start or the end of all keys.
+	KeyTypeUnbounded KeyType = "unbounded"
+	KeyTypeMeta      KeyType = "meta"
+	// KeyTypeTable is the prefix of a table, not a row or an index.
+	KeyTypeTable   KeyType = "table"
+	KeyTypeRow     KeyType = "row"
+	KeyTypeIndex   KeyType = "index"
+	KeyTypeUnknown KeyType = "unknown"
+)
+
+// KeyInfo is the object owning a key.
+type KeyInfo struct {
+	// Key is the key in upper hex, as pasted by the user.
+	Key string `json:"key"`
+	// Encoded is true when the key is memcomparable encoded, e.g. region keys.
+	Encoded    bool    `json:"encoded"`
+	KeyspaceID *uint32 `json:"keyspace_id,omitempty"`
+	Type       KeyType `json:"type"`
+	// TableID is the physical table ID, i.e. the partition ID of a partitioned table.
+	TableID int64 `json:"table_id,omitempty"`
+	// RowID is the integer handle of a row key, which is empty for rows of clustered tables with common handles.
+	RowID   *int64 `json:"row_id,omitempty"`
+	IndexID int64  `json:"index_id,omitempty"`
+
+	// The fields below are resolved from the schema, which are empty when the table is dropped or not visible to
+	// the user.
+	DB        string `json:"db,omitempty"`
+	Table     string `json:"table,omitempty"`
+	Partition string `json:"partition,omitempty"`
+	Index     string `json:"index,omitempty"`
+}
+
+// parseKey parses a key in hex, or escaped like `t\200\000\000\000\000\000\000\377` in TiKV logs.
+func parseKey(s string) ([]byte, error) {
+	s = strings.TrimSpace(s)
+	if len(s) >= 2 && (s[0] == '"' && s[len(s)-1] == '"' || s[0] == '\'' && s[len(s)-1] == '\'') {
+		s = s[1 : len(s)-1]
+	}
+	if strings.ContainsRune(s, '\\') {
+		return unescapeKey(s)
+	}
+	if b, err := hex.DecodeString(s); err == nil {
+		return b, nil
+	}
+	return []byte(s), nil
+}
+
+func unescapeKey(s string) ([]byte, error) {
+	invalid := rest.ErrBadRequest.New("invalid escaped key %q", s)
+	b := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' {
+			b = append(b, s[i])
+			continue
+		}
+		i++
+		if i >= len(s) {
+			return nil, invalid
+		}
+		switch c := s[i]; {
+		case c >= '0' && c <= '7':
+			v := 0
+			for n := 0; n < 3 && i < len(s) && s[i] >= '0' && s[i] <= '7'; n++ {
+				v = v*8 + int(s[i]-'0')
+				i++
+			}
+			i--
+			if v > 0xff {
+				return nil, invalid
+			}
+			b = append(b, byte(v))
+		case c == 'x':
+			if i+2 >= len(s) {
+				return nil, invalid
+			}
+			v, err := hex.DecodeString(s[i+1 : i+3])
+			if err != nil {
+				return nil, invalid
+			}
+			b = append(b, v[0])
+			i += 2
+		case c == 'n':
+			b = append(b, '\n')
+		case c == 'r':
+			b = append(b, '\r')
+		case c == 't':
+			b = append(b, '\t')
+		case c == '\\' || c == '"' || c == '\'':
+			b = append(b, c)
+		default:
+			return nil, invalid
+		}
+	}
+	return b, nil
+}
+
+// DecodeKey decodes the IDs in the key, which is either memcomparable encoded or not.
+func DecodeKey(key []byte) KeyInfo {
+	info := KeyInfo{Key: strings.ToUpper(hex.EncodeToString(key)), Type: KeyTypeUnknown}
+	if len(key) == 0 {
+		info.Type = KeyTypeUnbounded
+		return info
+	}
+	if key[0] == dataKeyPrefix {
+		key = key[1:]
+	}
+
+	var buf model.KeyInfoBuffer
+	decoded, err := buf.DecodeKey(key)
+	if err == nil {
+		info.Encoded = true
+	} else {
+		decoded = model.KeyInfoBuffer(key)
+	}
+	if len(decoded) >= keyspacePrefixLen && decoded[0] == keyspacePrefix {
+		id := uint32(decoded[1])<<16 | uint32(decoded[2])<<8 | uint32(decoded[3])
+		info.KeyspaceID = &id
+		decoded = decoded[keyspacePrefixLen:]
+	}
+
+	isMeta, tableID := decoded.MetaOrTable()
+	switch {
+	case isMeta:
+		info.Type = KeyTypeMeta
+		return info
+	case tableID == 0:
+		return info
+	}
+	info.TableID = tableID
+	info.Type = KeyTypeTable
+	if isCommonHandle, rowID := decoded.RowInfo(); isCommonHandle || rowID != 0 {
+		info.Type = KeyTypeRow
+		if !isCommonHandle {
+			info.RowID = &rowID
+		}
+	} else if indexID := decoded.IndexInfo(); indexID != 0 {
+		info.Type = KeyTypeIndex
+		info.IndexID = indexID
+	}
+	return info
+}
+
+type tableName struct {
+	DB        string `gorm:"column:TABLE_SCHEMA"`
+	Table     string `gorm:"column:TABLE_NAME"`
+	Partition string `gorm:"column:PARTITION_NAME"`
+}
+
+// resolveTableName returns the names of the table or partition of the physical table ID.
+func resolveTableName(db *gorm.DB, tableID int64) (*tableName, error) {
+	var names []tableName
+	err := db.
+		Table("INFORMATION_SCHEMA.TABLES").
+		Select("TABLE_SCHEMA, TABLE_NAME").
+		Where("TIDB_TABLE_ID = ?", tableID).
+		Find(&names).Error
+	if err != nil {
+		return nil, ErrResolveFailed.WrapWithNoMessage(err)
+	}
+	if len(names) == 0 {
+		err = db.
+			Table("INFORMATION_SCHEMA.PARTITIONS").
+			Select("TABLE_SCHEMA, TABLE_NAME, PARTITION_NAME").
+			Where("TIDB_PARTITION_ID = ?", tableID).
+			Find(&names).Error
+		if err != nil {
+			return nil, ErrResolveFailed.WrapWithNoMessage(err)
+		}
+	}
+	if len(names) == 0 {
+		return nil, nil
+	}
+	return &names[0], nil
+}
+
+func resolveIndexName(db *gorm.DB, name *tableName, indexID int64) (string, error) {
+	var indexNames []string
+	err := db.
+		Table("INFORMATION_SCHEMA.TIDB_INDEXES").
+		Where("TABLE_SCHEMA = ? AND TABLE_NAME = ? AND INDEX_ID = ?", name.DB, name.Table, indexID).
+		Limit(1).
+		Pluck("KEY_NAME", &indexNames).Error
+	if err != nil {
+		return "", ErrResolveFailed.WrapWithNoMessage(err)
+	}
+	if len(indexNames) == 0 {
+		return "", nil
+	}
+	return indexNames[0], nil
+}
+
+// ResolveKeyInfos fills the names of the objects owning the keys. Tables are resolved once.
+func ResolveKeyInfos(db *gorm.DB, infos []KeyInfo) error {
+	names := make(map[int64]*tableName)
+	for i := range infos {
+		info := &infos[i]
+		if info.TableID == 0 {
+			continue
+		}
+		name, ok := names[info.TableID]
+		if !ok {
+			var err error
+			if name, err = resolveTableName(db, info.TableID); err != nil {
+				return err
+			}
+			names[info.TableID] = name
+		}
+		if name == nil {
+			continue
+		}
+		info.DB, info.Table, info.Partition = name.DB, name.Table, name.Partition
+		if info.IndexID != 0 {
+			index, err := resolveIndexName(db, name, info.IndexID)
+			if err != nil {
+				return err
+			}
+			info.Index = index
+		}
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package region
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pingcap/tidb-dashboard/pkg/tidb/model"
+)
+
+// rawKey builds a key that is not memcomparable encoded, e.g. `t{tableID}_i{indexID}` or `t{tableID}_r{rowID}`.
+func rawKey(tableID int64, sep string, id int64) []byte {
+	key := make([]byte, 19)
+	key[0] = 't'
+	binary.BigEndian.PutUint64(key[1:], uint64(tableID)^0x8000000000000000)
+	copy(key[9:], sep)
+	binary.BigEndian.PutUint64(key[11:], uint64(id)^0x8000000000000000)
+	return key
+}
+
+// encodeKey encodes the key in memcomparable format, the same as keys of regions.
+func encodeKey(key []byte) []byte {
+	result := make([]byte, 0, (len(key)/8+1)*9)
+	for i := 0; i <= len(key); i += 8 {
+		group := make([]byte, 8)
+		n := copy(group, key[i:])
+		result = append(result, group...)
+		result = append(result, byte(0xff-(8-n)))
+	}
+	return result
+}
+
+func TestParseKey(t *testing.T) {
+	key, err := parseKey(" 7480000000000000FF ")
+	require.NoError(t, err)
+	require.Equal(t, []byte{0x74, 0x80, 0, 0, 0, 0, 0, 0, 0xff}, key)
+
+	key, err = parseKey(`"t\200\000\000\000\000\000\000\377_r\x01\\"`)
+	require.NoError(t, err)
+	require.Equal(t, []byte{'t', 0x80, 0, 0, 0, 0, 0, 0, 0xff, '_', 'r', 0x01, '\\'}, key)
+
+	key, err = parseKey("m_ddl")
+	require.NoError(t, err)
+	require.Equal(t, []byte("m_ddl"), key)
+
+	for _, s := range []string{`t\400`, `t\x0`, `t\q`, `t\`} {
+		_, err = parseKey(s)
+		require.Error(t, err, s)
+	}
+}
+
+func TestDecodeKey(t *testing.T) {
+	require.Equal(t, KeyTypeUnbounded, DecodeKey(nil).Type)
+
+	rowKey := encodeKey(rawKey(45, "_r", 1000))
+	info := DecodeKey(rowKey)
+	require.True(t, info.Encoded)
+	require.Equal(t, KeyTypeRow, info.Type)
+	require.Equal(t, int64(45), info.TableID)
+	require.Equal(t, int64(1000), *info.RowID)
+	require.Equal(t, strings.ToUpper(hex.EncodeToString(rowKey)), info.Key)
+
+	// Keys in TiKV logs are prefixed by `z`.
+	info = DecodeKey(append([]byte{'z'}, rowKey...))
+	require.Equal(t, KeyTypeRow, info.Type)
+	require.Equal(t, int64(45), info.TableID)
+
+	info = DecodeKey(rawKey(45, "_i", 2))
+	require.False(t, info.Encoded)
+	require.Equal(t, KeyTypeIndex, info.Type)
+	require.Equal(t, int64(45), info.TableID)
+	require.Equal(t, int64(2), info.IndexID)
+	require.Nil(t, info.KeyspaceID)
+
+	info = DecodeKey(append([]byte{'x', 0, 1, 2}, rawKey(45, "_r", 7)...))
+	require.Equal(t, KeyTypeRow, info.Type)
+	require.Equal(t, uint32(0x102), *info.KeyspaceID)
+	require.Equal(t, int64(7), *info.RowID)
+
+	// The clustered index of common handles.
+	info = DecodeKey(append(rawKey(45, "_r", 7), 0x01))
+	require.Equal(t, KeyTypeRow, info.Type)
+	require.Nil(t, info.RowID)
+
+	var buf model.KeyInfoBuffer
+	info = DecodeKey(buf.GenerateKey(45, 0))
+	require.True(t, info.Encoded)
+	require.Equal(t, KeyTypeTable, info.Type)
+	require.Equal(t, int64(45), info.TableID)
+
+	require.Equal(t, KeyTypeMeta, DecodeKey([]byte("mDDLJobList")).Type)
+	require.Equal(t, KeyTypeUnknown, DecodeKey([]byte("abc")).Type)
+}
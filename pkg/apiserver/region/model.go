@@ -0,0 +1,55 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package region
+
+type Peer struct {
+	ID        uint64 `json:"id"`
+	StoreID   uint64 `json:"store_id"`
+	IsLearner bool   `json:"is_learner,omitempty"`
+}
+
+// Region is a subset of the PD region info. Keys are encoded keys in upper hex, the same as PD.
+type Region struct {
+	ID              uint64 `json:"id"`
+	StartKey        string `json:"start_key"`
+	EndKey          string `json:"end_key"`
+	Peers           []Peer `json:"peers"`
+	Leader          *Peer  `json:"leader"`
+	ApproximateSize int64  `json:"approximate_size"`
+	ApproximateKeys int64  `json:"approximate_keys"`
+	WrittenBytes    uint64 `json:"written_bytes"`
+	ReadBytes       uint64 `json:"read_bytes"`
+
+	// HotRead and HotWrite are filled from PD hot region statistics.
+	HotRead  bool `json:"hot_read"`
+	HotWrite bool `json:"hot_write"`
+}
+
+type pdRegions struct {
+	Count   int      `json:"count"`
+	Regions []Region `json:"regions"`
+}
+
+type pdHotRegions struct {
+	AsPeer   map[string]pdHotStoreStat `json:"as_peer"`
+	AsLeader map[string]pdHotStoreStat `json:"as_leader"`
+}
+
+type pdHotStoreStat struct {
+	Stats []pdHotRegionStat `json:"statistics"`
+}
+
+type pdHotRegionStat struct {
+	RegionID uint64 `json:"region_id"`
+}
+
+type GetRegionsResponse struct {
+	Regions []Region `json:"regions"`
+	// NextCursor is the cursor for the next page, or empty when there are no more regions.
+	NextCursor string `json:"next_cursor"`
+}
+
+type GetKeyInfoResponse struct {
+	// Keys are in the order of the requested keys, or the start key and the end key of the requested region.
+	Keys []KeyInfo `json:"keys"`
+}
@@ -0,0 +1,93 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package region
+
+import (
+	"bytes"
+	"encoding/hex"
+	"sort"
+
+	"github.com/pingcap/tidb-dashboard/pkg/tidb/model"
+)
+
+// keyRange is a range of encoded keys. An empty end means unbounded.
+type keyRange struct {
+	start []byte
+	end   []byte
+}
+
+// tableRanges returns the key ranges of the tables or partitions, ordered by keys.
+func tableRanges(ids []int64) []keyRange {
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	ranges := make([]keyRange, 0, len(ids))
+	var buf model.KeyInfoBuffer
+	for _, id := range ids {
+		start := buf.GenerateKey(id, 0)
+		end := buf.GenerateKey(id+1, 0)
+		ranges = append(ranges, keyRange{start: start, end: end})
+	}
+	return ranges
+}
+
+type scanFunc func(start, end []byte, limit int) ([]Region, error)
+
+// scanRanges returns at most limit regions in the ranges, starting from the cursor. The returned cursor is
+// the end key of the last region, or nil when all ranges are scanned.
+func scanRanges(ranges []keyRange, cursor []byte, limit int, scan scanFunc) ([]Region, []byte, error) {
+	result := make([]Region, 0, limit)
+	seen := make(map[uint64]struct{})
+	for i, r := range ranges {
+		if len(r.end) > 0 && bytes.Compare(cursor, r.end) >= 0 {
+			continue
+		}
+		start := r.start
+		if bytes.Compare(cursor, start) > 0 {
+			start = cursor
+		}
+		regions, err := scan(start, r.end, limit-len(result))
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, region := range regions {
+			// A region can cover several ranges.
+			if _, ok := seen[region.ID]; ok {
+				continue
+			}
+			seen[region.ID] = struct{}{}
+			result = append(result, region)
+		}
+		if len(result) < limit {
+			continue
+		}
+
+		next, err := hex.DecodeString(result[len(result)-1].EndKey)
+		if err != nil || len(next) == 0 {
+			return result, nil, nil
+		}
+		lastRange := i == len(ranges)-1
+		if lastRange && len(r.end) > 0 && bytes.Compare(next, r.end) >= 0 {
+			return result, nil, nil
+		}
+		return result, next, nil
+	}
+	return result, nil, nil
+}
+
+func markHotRegions(regions []Region, hotRead, hotWrite map[uint64]struct{}) {
+	for i := range regions {
+		_, regions[i].HotRead = hotRead[regions[i].ID]
+		_, regions[i].HotWrite = hotWrite[regions[i].ID]
+	}
+}
+
+func (h *pdHotRegions) regionIDs() map[uint64]struct{} {
+	ids := make(map[uint64]struct{})
+	for _, stats := range []map[string]pdHotStoreStat{h.AsPeer, h.AsLeader} {
+		for _, s := range stats {
+			for _, r := range s.Stats {
+				ids[r.RegionID] = struct{}{}
+			}
+		}
+	}
+	return ids
+}
@@ -0,0 +1,89 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package region
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newMockScan mocks PD regions split at the keys.
+func newMockScan(splitKeys ...[]byte) scanFunc {
+	regions := make([]Region, 0, len(splitKeys)+1)
+	var start []byte
+	for i := 0; i <= len(splitKeys); i++ {
+		var end []byte
+		if i < len(splitKeys) {
+			end = splitKeys[i]
+		}
+		regions = append(regions, Region{
+			ID:       uint64(i + 1),
+			StartKey: hex.EncodeToString(start),
+			EndKey:   hex.EncodeToString(end),
+		})
+		start = end
+	}
+	return func(start, end []byte, limit int) ([]Region, error) {
+		result := make([]Region, 0)
+		for _, r := range regions {
+			rStart, _ := hex.DecodeString(r.StartKey)
+			rEnd, _ := hex.DecodeString(r.EndKey)
+			if len(rEnd) > 0 && bytes.Compare(rEnd, start) <= 0 {
+				continue
+			}
+			if len(end) > 0 && bytes.Compare(rStart, end) >= 0 {
+				break
+			}
+			result = append(result, r)
+			if len(result) >= limit {
+				break
+			}
+		}
+		return result, nil
+	}
+}
+
+func regionIDs(regions []Region) []uint64 {
+	ids := make([]uint64, 0, len(regions))
+	for _, r := range regions {
+		ids = append(ids, r.ID)
+	}
+	return ids
+}
+
+func TestScanRanges(t *testing.T) {
+	ranges := tableRanges([]int64{3, 1})
+	require.Len(t, ranges, 2)
+	require.Negative(t, bytes.Compare(ranges[0].end, ranges[1].start))
+
+	// Regions: [-inf, t1), [t1, t2), [t2, t3), [t3, t3_mid), [t3_mid, t4), [t4, +inf)
+	mid := append(append([]byte{}, ranges[1].start...), 1)
+	scan := newMockScan(ranges[0].start, ranges[0].end, ranges[1].start, mid, ranges[1].end)
+
+	regions, next, err := scanRanges(ranges, nil, 100, scan)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{2, 4, 5}, regionIDs(regions))
+	require.Nil(t, next)
+
+	regions, next, err = scanRanges(ranges, nil, 2, scan)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{2, 4}, regionIDs(regions))
+	require.Equal(t, mid, next)
+
+	regions, next, err = scanRanges(ranges, next, 2, scan)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{5}, regionIDs(regions))
+	require.Nil(t, next)
+}
+
+func TestMarkHotRegions(t *testing.T) {
+	regions := []Region{{ID: 1}, {ID: 2}}
+	hot := pdHotRegions{AsLeader: map[string]pdHotStoreStat{"1": {Stats: []pdHotRegionStat{{RegionID: 2}}}}}
+	markHotRegions(regions, map[uint64]struct{}{}, hot.regionIDs())
+	require.False(t, regions[0].HotWrite)
+	require.True(t, regions[1].HotWrite)
+	require.False(t, regions[1].HotRead)
+}
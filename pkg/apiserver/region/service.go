@@ -0,0 +1,282 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package region
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+	"github.com/joomcode/errorx"
+	"go.uber.org/fx"
+	"gorm.io/gorm"
+
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/cluster"
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/user"
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/utils"
+	"github.com/pingcap/tidb-dashboard/pkg/pd"
+	"github.com/pingcap/tidb-dashboard/pkg/tidb"
+	"github.com/pingcap/tidb-dashboard/util/pagination"
+	"github.com/pingcap/tidb-dashboard/util/rest"
+)
+
+const (
+	defaultPageLimit = 100
+	maxPageLimit     = 1000
+
+	maxKeyInfoKeys = 64
+)
+
+var (
+	ErrNS             = errorx.NewNamespace("error.api.region")
+	ErrTableNotFound  = ErrNS.NewType("table_not_found")
+	ErrPDRequest      = ErrNS.NewType("pd_request_failed")
+	ErrResolveFailed  = ErrNS.NewType("resolve_table_failed")
+	ErrRegionNotFound = ErrNS.NewType("region_not_found")
+)
+
+type ServiceParams struct {
+	fx.In
+	PDClient   *pd.Client
+	TiDBClient *tidb.Client
+	Registry   *cluster.Registry
+}
+
+type Service struct {
+	params ServiceParams
+}
+
+func newService(p ServiceParams) *Service {
+	return &Service{params: p}
+}
+
+var Module = fx.Options(
+	fx.Provide(newService),
+	fx.Invoke(registerRouter),
+)
+
+func registerRouter(r *gin.RouterGroup, auth *user.AuthService, s *Service) {
+	endpoint := r.Group("/regions")
+	endpoint.Use(
+		auth.MWAuthRequired(),
+		s.params.Registry.MWResolveCluster(),
+		utils.MWConnectTiDB(s.params.TiDBClient),
+	)
+	endpoint.GET("", s.getRegions)
+	endpoint.GET("/key_info", s.getKeyInfo)
+}
+
+type GetRegionsRequest struct {
+	// Either DB and Table, or StartKey and EndKey should be specified. Keys are encoded keys in hex.
+	DB       string `json:"db" form:"db"`
+	Table    string `json:"table" form:"table"`
+	StartKey string `json:"start_key" form:"start_key"`
+	EndKey   string `json:"end_key" form:"end_key"`
+	pagination.Request
+}
+
+// @ID regionsGet
+// @Summary Get regions of a table or a key range from PD, ordered by keys
+// @Param q query GetRegionsRequest true "Query"
+// @Success 200 {object} GetRegionsResponse
+// @Router /regions [get]
+// @Security JwtAuth
+// @Failure 400 {object} rest.ErrorResponse
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 404 {object} rest.ErrorResponse
+func (s *Service) getRegions(c *gin.Context) {
+	var req GetRegionsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		rest.Error(c, rest.ErrBadRequest.NewWithNoMessage())
+		return
+	}
+	req.Normalize(defaultPageLimit, maxPageLimit)
+	cursor, err := hex.DecodeString(req.Cursor)
+	if err != nil {
+		rest.Error(c, rest.ErrBadRequest.New("invalid cursor"))
+		return
+	}
+
+	var ranges []keyRange
+	switch {
+	case req.DB != "" && req.Table != "":
+		ids, err := ResolveTableIDs(utils.GetTiDBConnection(c), req.DB, req.Table)
+		if err != nil {
+			rest.Error(c, err)
+			return
+		}
+		ranges = tableRanges(ids)
+	case req.DB == "" && req.Table == "":
+		start, err1 := hex.DecodeString(req.StartKey)
+		end, err2 := hex.DecodeString(req.EndKey)
+		if err1 != nil || err2 != nil {
+			rest.Error(c, rest.ErrBadRequest.New("invalid key range"))
+			return
+		}
+		ranges = []keyRange{{start: start, end: end}}
+	default:
+		rest.Error(c, rest.ErrBadRequest.New("both db and table are required"))
+		return
+	}
+
+	pdClient := cluster.PDClientOf(c, s.params.PDClient)
+	regions, next, err := scanRanges(ranges, cursor, req.Limit, func(start, end []byte, limit int) ([]Region, error) {
+		return scanRegions(pdClient, start, end, limit)
+	})
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	hotRead, err := fetchHotRegionIDs(pdClient, "read")
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	hotWrite, err := fetchHotRegionIDs(pdClient, "write")
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	markHotRegions(regions, hotRead, hotWrite)
+
+	c.JSON(http.StatusOK, GetRegionsResponse{
+		Regions:    regions,
+		NextCursor: hex.EncodeToString(next),
+	})
+}
+
+type GetKeyInfoRequest struct {
+	// Keys are in hex or escaped as in TiKV logs, either encoded or not. Alternatively, RegionID looks up the start
+	// key and the end key of the region.
+	Keys     []string `json:"keys" form:"keys"`
+	RegionID uint64   `json:"region_id" form:"region_id"`
+}
+
+// @ID regionsKeyInfoGet
+// @Summary Get the database, table, partition and index owning the keys
+// @Param q query GetKeyInfoRequest true "Query"
+// @Success 200 {object} GetKeyInfoResponse
+// @Router /regions/key_info [get]
+// @Security JwtAuth
+// @Failure 400 {object} rest.ErrorResponse
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 404 {object} rest.ErrorResponse
+func (s *Service) getKeyInfo(c *gin.Context) {
+	var req GetKeyInfoRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		rest.Error(c, rest.ErrBadRequest.NewWithNoMessage())
+		return
+	}
+
+	var keys [][]byte
+	switch {
+	case req.RegionID != 0 && len(req.Keys) == 0:
+		region, err := fetchRegion(cluster.PDClientOf(c, s.params.PDClient), req.RegionID)
+		if err != nil {
+			rest.Error(c, err)
+			return
+		}
+		start, err1 := hex.DecodeString(region.StartKey)
+		end, err2 := hex.DecodeString(region.EndKey)
+		if err1 != nil || err2 != nil {
+			rest.Error(c, ErrPDRequest.New("invalid keys of region %d", req.RegionID))
+			return
+		}
+		keys = [][]byte{start, end}
+	case req.RegionID == 0 && len(req.Keys) > 0 && len(req.Keys) <= maxKeyInfoKeys:
+		for _, k := range req.Keys {
+			key, err := parseKey(k)
+			if err != nil {
+				rest.Error(c, err)
+				return
+			}
+			keys = append(keys, key)
+		}
+	default:
+		rest.Error(c, rest.ErrBadRequest.New("either 1~%d keys or a region ID is required", maxKeyInfoKeys))
+		return
+	}
+
+	infos := make([]KeyInfo, 0, len(keys))
+	for _, key := range keys {
+		infos = append(infos, DecodeKey(key))
+	}
+	if err := ResolveKeyInfos(utils.GetTiDBConnection(c), infos); err != nil {
+		rest.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, GetKeyInfoResponse{Keys: infos})
+}
+
+// ResolveTableIDs returns the physical table IDs of a table, i.e. the partition IDs of a partitioned table, or the table ID otherwise.
+func ResolveTableIDs(db *gorm.DB, dbName, tableName string) ([]int64, error) {
+	var tableIDs []int64
+	err := db.
+		Table("INFORMATION_SCHEMA.TABLES").
+		Where("TABLE_SCHEMA = ? AND TABLE_NAME = ?", dbName, tableName).
+		Pluck("TIDB_TABLE_ID", &tableIDs).Error
+	if err != nil {
+		return nil, ErrResolveFailed.WrapWithNoMessage(err)
+	}
+	if len(tableIDs) == 0 {
+		return nil, ErrTableNotFound.New("table %s.%s not found", dbName, tableName).
+			WithProperty(rest.HTTPCodeProperty(http.StatusNotFound))
+	}
+
+	var partitionIDs []int64
+	err = db.
+		Table("INFORMATION_SCHEMA.PARTITIONS").
+		Where("TABLE_SCHEMA = ? AND TABLE_NAME = ? AND TIDB_PARTITION_ID IS NOT NULL", dbName, tableName).
+		Pluck("TIDB_PARTITION_ID", &partitionIDs).Error
+	if err != nil {
+		return nil, ErrResolveFailed.WrapWithNoMessage(err)
+	}
+	if len(partitionIDs) > 0 {
+		return partitionIDs, nil
+	}
+	return tableIDs, nil
+}
+
+func scanRegions(pdClient *pd.Client, start, end []byte, limit int) ([]Region, error) {
+	data, err := pdClient.SendGetRequest(fmt.Sprintf("/regions/key?key=%s&end_key=%s&limit=%d",
+		url.QueryEscape(string(start)), url.QueryEscape(string(end)), limit))
+	if err != nil {
+		return nil, err
+	}
+	var resp pdRegions
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, ErrPDRequest.Wrap(err, "PD regions API unmarshal failed")
+	}
+	return resp.Regions, nil
+}
+
+func fetchRegion(pdClient *pd.Client, id uint64) (*Region, error) {
+	data, err := pdClient.SendGetRequest(fmt.Sprintf("/region/id/%d", id))
+	if err != nil {
+		return nil, err
+	}
+	var region Region
+	if err := json.Unmarshal(data, &region); err != nil {
+		return nil, ErrPDRequest.Wrap(err, "PD region API unmarshal failed")
+	}
+	if region.ID == 0 {
+		return nil, ErrRegionNotFound.New("region %d not found", id).
+			WithProperty(rest.HTTPCodeProperty(http.StatusNotFound))
+	}
+	return &region, nil
+}
+
+func fetchHotRegionIDs(pdClient *pd.Client, kind string) (map[uint64]struct{}, error) {
+	data, err := pdClient.SendGetRequest("/hotspot/regions/" + kind)
+	if err != nil {
+		return nil, err
+	}
+	var resp pdHotRegions
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, ErrPDRequest.Wrap(err, "PD hot regions API unmarshal failed")
+	}
+	return resp.regionIDs(), nil
+}
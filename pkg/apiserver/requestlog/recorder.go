@@ -0,0 +1,204 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package requestlog
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/joomcode/errorx"
+	"github.com/pingcap/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/utils"
+	"github.com/pingcap/tidb-dashboard/pkg/config"
+	commonUtils "github.com/pingcap/tidb-dashboard/pkg/utils"
+)
+
+const (
+	RequestIDHeader   = "X-Request-Id"
+	traceParentHeader = "traceparent"
+
+	// RequestIDKey is the key of the request ID in the gin context.
+	RequestIDKey = "request_id"
+
+	maxRequestIDLength = 64
+)
+
+var (
+	// traceParentRegex matches the W3C trace context, i.e. `version-trace_id-parent_id-flags`.
+	traceParentRegex = regexp.MustCompile(`^[0-9a-f]{2}-([0-9a-f]{32})-[0-9a-f]{16}-[0-9a-f]{2}$`)
+	requestIDRegex   = regexp.MustCompile(`^[0-9A-Za-z._:-]+$`)
+
+	requestDurationHistogram = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "tidb_dashboard",
+		Subsystem: "api",
+		Name:      "request_duration_seconds",
+		Help:      "Latency of API requests handled by each route.",
+		Buckets:   prometheus.ExponentialBuckets(0.005, 2, 14), // 5ms ~ 41s
+	}, []string{"method", "route", "status"})
+)
+
+// Request is an API request handled by the dashboard.
+type Request struct {
+	RequestID string `json:"request_id"`
+	// TraceID is the trace ID in the W3C trace context of the request, which is empty when not traced.
+	TraceID string `json:"trace_id,omitempty"`
+	Method  string `json:"method"`
+	// Route is the route of the handler, e.g. `/dashboard/api/topology/pd/:address`.
+	Route string `json:"route"`
+	// Path is the path without the query, which may contain tokens.
+	Path       string    `json:"path"`
+	Status     int       `json:"status"`
+	ErrorClass string    `json:"error_class,omitempty"`
+	User       string    `json:"user,omitempty"`
+	StartedAt  time.Time `json:"started_at"`
+	LatencyMs  float64   `json:"latency_ms"`
+}
+
+// Recorder assigns request IDs, logs requests and keeps recent slow requests in memory.
+type Recorder struct {
+	threshold time.Duration
+	capacity  int
+	now       func() time.Time
+
+	mu sync.Mutex
+	// slow is a ring buffer of slow requests, whose next slot is next.
+	slow []Request
+	next int
+}
+
+func NewRecorder(cfg *config.Config) *Recorder {
+	commonUtils.RegisterMetrics(requestDurationHistogram)
+	return newRecorder(cfg.SlowRequestThreshold, cfg.SlowRequestCapacity)
+}
+
+func newRecorder(threshold time.Duration, capacity int) *Recorder {
+	if capacity < 0 {
+		capacity = 0
+	}
+	return &Recorder{
+		threshold: threshold,
+		capacity:  capacity,
+		now:       time.Now,
+		slow:      make([]Request, 0, capacity),
+	}
+}
+
+// MWRecord creates a middleware assigning a request ID to each request, which is returned in the response header,
+// and recording the latency and the error class of the handler.
+func (r *Recorder) MWRecord() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		startedAt := r.now()
+		requestID := c.GetHeader(RequestIDHeader)
+		if !isValidRequestID(requestID) {
+			requestID = newRequestID()
+		}
+		c.Set(RequestIDKey, requestID)
+		c.Header(RequestIDHeader, requestID)
+
+		c.Next()
+
+		req := Request{
+			RequestID:  requestID,
+			TraceID:    parseTraceID(c.GetHeader(traceParentHeader)),
+			Method:     c.Request.Method,
+			Route:      c.FullPath(),
+			Path:       c.Request.URL.Path,
+			Status:     c.Writer.Status(),
+			ErrorClass: errorClass(c),
+			StartedAt:  startedAt,
+			LatencyMs:  float64(r.now().Sub(startedAt)) / float64(time.Millisecond),
+		}
+		if u := utils.GetSession(c); u != nil {
+			req.User = u.DisplayName
+		}
+		r.record(req)
+	}
+}
+
+func (r *Recorder) record(req Request) {
+	requestDurationHistogram.
+		WithLabelValues(req.Method, req.Route, strconv.Itoa(req.Status)).
+		Observe(req.LatencyMs / 1000)
+
+	fields := []zap.Field{
+		zap.String("requestID", req.RequestID),
+		zap.String("traceID", req.TraceID),
+		zap.String("method", req.Method),
+		zap.String("route", req.Route),
+		zap.Int("status", req.Status),
+		zap.String("errorClass", req.ErrorClass),
+		zap.Float64("latencyMs", req.LatencyMs),
+	}
+	if r.threshold <= 0 || req.LatencyMs < float64(r.threshold)/float64(time.Millisecond) {
+		log.Debug("Handled API request", fields...)
+		return
+	}
+	log.Warn("Handled slow API request", fields...)
+
+	if r.capacity == 0 {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.slow) < r.capacity {
+		r.slow = append(r.slow, req)
+	} else {
+		r.slow[r.next] = req
+	}
+	r.next = (r.next + 1) % r.capacity
+}
+
+// SlowRequests returns the recent slow requests, latest first.
+func (r *Recorder) SlowRequests() []Request {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	result := make([]Request, 0, len(r.slow))
+	for i := 1; i <= len(r.slow); i++ {
+		result = append(result, r.slow[(r.next-i+len(r.slow))%len(r.slow)])
+	}
+	return result
+}
+
+// GetRequestID returns the ID of the request, which is empty when the request is not recorded.
+func GetRequestID(c *gin.Context) string {
+	return c.GetString(RequestIDKey)
+}
+
+func isValidRequestID(id string) bool {
+	return id != "" && len(id) <= maxRequestIDLength && requestIDRegex.MatchString(id)
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// parseTraceID returns the trace ID in the W3C trace context, which is empty when absent or invalid.
+func parseTraceID(traceParent string) string {
+	m := traceParentRegex.FindStringSubmatch(traceParent)
+	if m == nil || m[1] == "00000000000000000000000000000000" {
+		return ""
+	}
+	return m[1]
+}
+
+// errorClass returns the errorx type of the error attached to the context, e.g. `common.bad_request`.
+func errorClass(c *gin.Context) string {
+	err := c.Errors.Last()
+	if err == nil {
+		return ""
+	}
+	if e := errorx.Cast(err.Err); e != nil {
+		return e.Type().FullName()
+	}
+	return "unknown"
+}
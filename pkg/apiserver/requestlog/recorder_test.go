@@ -0,0 +1,117 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package requestlog
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pingcap/tidb-dashboard/util/rest"
+)
+
+// newTestEngine returns an engine whose handlers take the latency in the query.
+func newTestEngine(r *Recorder) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var latency time.Duration
+	r.now = func() time.Time {
+		return now
+	}
+
+	engine := gin.New()
+	engine.Use(func(c *gin.Context) {
+		latency, _ = time.ParseDuration(c.Query("latency"))
+		c.Next()
+	})
+	engine.Use(r.MWRecord())
+	engine.Use(rest.ErrorHandlerFn())
+	engine.GET("/items/:id", func(c *gin.Context) {
+		now = now.Add(latency)
+		c.JSON(http.StatusOK, nil)
+	})
+	engine.GET("/error", func(c *gin.Context) {
+		now = now.Add(latency)
+		rest.Error(c, rest.ErrBadRequest.New("bad"))
+	})
+	return engine
+}
+
+func serve(engine *gin.Engine, target string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	for k, v := range header {
+		req.Header.Set(k, v[0])
+	}
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	return w
+}
+
+func TestMWRecordRequestID(t *testing.T) {
+	engine := newTestEngine(newRecorder(time.Second, 10))
+
+	w := serve(engine, "/items/1", nil)
+	require.Regexp(t, `^[0-9a-f]{32}$`, w.Header().Get(RequestIDHeader))
+
+	w = serve(engine, "/items/1", http.Header{RequestIDHeader: {"from-proxy.1"}})
+	require.Equal(t, "from-proxy.1", w.Header().Get(RequestIDHeader))
+
+	// Invalid request IDs are replaced, so that they are safe to be logged.
+	w = serve(engine, "/items/1", http.Header{RequestIDHeader: {"bad id\n"}})
+	require.NotEqual(t, "bad id\n", w.Header().Get(RequestIDHeader))
+}
+
+func TestMWRecordSlowRequests(t *testing.T) {
+	r := newRecorder(time.Second, 2)
+	engine := newTestEngine(r)
+
+	serve(engine, "/items/1?latency=10ms", nil)
+	require.Empty(t, r.SlowRequests())
+
+	serve(engine, "/items/1?latency=2s", nil)
+	serve(engine, "/error?latency=3s", nil)
+	serve(engine, "/items/2?latency=4s", nil)
+
+	requests := r.SlowRequests()
+	require.Len(t, requests, 2)
+	require.Equal(t, "/items/2", requests[0].Path)
+	require.Equal(t, "/items/:id", requests[0].Route)
+	require.Equal(t, float64(4000), requests[0].LatencyMs)
+	require.Equal(t, "/error", requests[1].Path)
+	require.Equal(t, http.StatusBadRequest, requests[1].Status)
+	require.Equal(t, "common.bad_request", requests[1].ErrorClass)
+	require.Empty(t, requests[0].ErrorClass)
+}
+
+func TestMWRecordTraceID(t *testing.T) {
+	r := newRecorder(time.Second, 10)
+	engine := newTestEngine(r)
+
+	serve(engine, "/items/1?latency=2s&token=secret", http.Header{
+		traceParentHeader: {"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+	})
+	requests := r.SlowRequests()
+	require.Len(t, requests, 1)
+	require.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", requests[0].TraceID)
+	// The query is not kept.
+	require.Equal(t, "/items/1", requests[0].Path)
+}
+
+func TestMWRecordDisabled(t *testing.T) {
+	r := newRecorder(0, 10)
+	engine := newTestEngine(r)
+	serve(engine, "/items/1?latency=10s", nil)
+	require.Empty(t, r.SlowRequests())
+}
+
+func TestParseTraceID(t *testing.T) {
+	require.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736",
+		parseTraceID("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"))
+	require.Empty(t, parseTraceID(""))
+	require.Empty(t, parseTraceID("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7"))
+	require.Empty(t, parseTraceID("00-00000000000000000000000000000000-00f067aa0ba902b7-01"))
+}
@@ -0,0 +1,37 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package requestlog
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/fx"
+
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/user"
+)
+
+var Module = fx.Options(
+	fx.Provide(NewRecorder),
+	fx.Invoke(registerRouter),
+)
+
+func registerRouter(r *gin.RouterGroup, auth *user.AuthService, rec *Recorder) {
+	endpoint := r.Group("/request_log")
+	endpoint.Use(auth.MWAuthRequired())
+	// Requests of all users are listed.
+	endpoint.Use(auth.MWRequireWritePriv())
+	endpoint.GET("/slow_requests", rec.slowRequestsHandler)
+}
+
+// @ID requestLogListSlowRequests
+// @Summary List recent slow requests of the dashboard API, latest first
+// @Description Only requests slower than the slow request threshold are kept, up to the capacity.
+// @Success 200 {array} Request
+// @Router /request_log/slow_requests [get]
+// @Security JwtAuth
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 403 {object} rest.ErrorResponse
+func (r *Recorder) slowRequestsHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, r.SlowRequests())
+}
@@ -19,15 +19,21 @@ type GetListRequest struct {
 	EndTime   int      `json:"end_time" form:"end_time"`
 	DB        []string `json:"db" form:"db"`
 	Limit     int      `json:"limit" form:"limit"`
-	Text      string   `json:"text" form:"text"`
-	OrderBy   string   `json:"orderBy" form:"orderBy"`
-	IsDesc    bool     `json:"desc" form:"desc"`
+	// Offset skips slow queries of previous pages. It can not be specified along with Cursor.
+	Offset  int    `json:"offset" form:"offset"`
+	Text    string `json:"text" form:"text"`
+	OrderBy string `json:"orderBy" form:"orderBy"`
+	IsDesc  bool   `json:"desc" form:"desc"`
 
 	// for showing slow queries in the statement detail page
 	Plans  []string `json:"plans" form:"plans"`
 	Digest string   `json:"digest" form:"digest"`
 
 	Fields string `json:"fields" form:"fields"` // example: "Query,Digest"
+
+	// Cursor is returned by the previous page, which encodes the offset of the next page. It can not be specified
+	// along with Offset.
+	Cursor string `json:"cursor" form:"cursor"`
 }
 
 type GetDetailRequest struct {
@@ -60,6 +66,9 @@ func QuerySlowLogList(req *GetListRequest, sysSchema *utils.SysSchema, db *gorm.
 		req.Limit = 100
 	}
 	tx = tx.Limit(req.Limit)
+	if req.Offset > 0 {
+		tx = tx.Offset(req.Offset)
+	}
 
 	if req.Text != "" {
 		lowerStr := strings.ToLower(req.Text)
@@ -0,0 +1,37 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package slowquery
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pingcap/tidb-dashboard/pkg/utils"
+	"github.com/pingcap/tidb-dashboard/util/testutil"
+)
+
+func TestQuerySlowLogListPagination(t *testing.T) {
+	db := testutil.OpenMockDB(t)
+	defer db.MustClose()
+	sysSchema := utils.NewSysSchema()
+	defer sysSchema.Close()
+
+	db.Mocker().
+		ExpectQuery("DESC " + SlowQueryTable).
+		WillReturnRows(sqlmock.NewRows([]string{"Field"}).AddRow("Digest").AddRow("Conn_ID").AddRow("Time"))
+	db.Mocker().
+		ExpectQuery("SELECT Digest, Conn_ID, (UNIX_TIMESTAMP(Time) + 0E0) AS timestamp FROM `INFORMATION_SCHEMA`.`CLUSTER_SLOW_QUERY` ORDER BY Time ASC LIMIT 20 OFFSET 40").
+		WillReturnRows(sqlmock.NewRows([]string{"Digest"}))
+	_, err := QuerySlowLogList(&GetListRequest{Fields: "digest", Limit: 20, Offset: 40}, sysSchema, db.Gorm().Table(SlowQueryTable))
+	require.NoError(t, err)
+
+	// The first page has no offset, and the limit is 100 by default. Columns are cached in the sys schema.
+	db.Mocker().
+		ExpectQuery("SELECT Digest, Conn_ID, (UNIX_TIMESTAMP(Time) + 0E0) AS timestamp FROM `INFORMATION_SCHEMA`.`CLUSTER_SLOW_QUERY` ORDER BY Time DESC LIMIT 100").
+		WillReturnRows(sqlmock.NewRows([]string{"Digest"}))
+	_, err = QuerySlowLogList(&GetListRequest{Fields: "digest", IsDesc: true}, sysSchema, db.Gorm().Table(SlowQueryTable))
+	require.NoError(t, err)
+	db.MustMeetMockExpectation()
+}
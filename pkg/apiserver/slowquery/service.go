@@ -12,13 +12,23 @@ import (
 	"github.com/joomcode/errorx"
 	"go.uber.org/fx"
 
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/audit"
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/cluster"
 	"github.com/pingcap/tidb-dashboard/pkg/apiserver/user"
 	"github.com/pingcap/tidb-dashboard/pkg/apiserver/utils"
+	"github.com/pingcap/tidb-dashboard/pkg/pd"
 	"github.com/pingcap/tidb-dashboard/pkg/tidb"
 	commonUtils "github.com/pingcap/tidb-dashboard/pkg/utils"
+	"github.com/pingcap/tidb-dashboard/util/pagination"
 	"github.com/pingcap/tidb-dashboard/util/rest"
 )
 
+const (
+	defaultListLimit = 100
+	// maxListLimit caps the slow queries returned in one request to protect the memory of both sides.
+	maxListLimit = 10000
+)
+
 var (
 	ErrNS     = errorx.NewNamespace("error.api.slow_query")
 	ErrNoData = ErrNS.NewType("export_no_data")
@@ -26,8 +36,10 @@ var (
 
 type ServiceParams struct {
 	fx.In
-	TiDBClient *tidb.Client
-	SysSchema  *commonUtils.SysSchema
+	TiDBClient  *tidb.Client
+	EtcdClients *pd.EtcdClientManager
+	SysSchema   *commonUtils.SysSchema
+	Registry    *cluster.Registry
 }
 
 type Service struct {
@@ -38,12 +50,12 @@ func newService(p ServiceParams) *Service {
 	return &Service{params: p}
 }
 
-func registerRouter(r *gin.RouterGroup, auth *user.AuthService, s *Service) {
+func registerRouter(r *gin.RouterGroup, auth *user.AuthService, a *audit.Service, s *Service) {
 	endpoint := r.Group("/slow_query")
 	{
 		endpoint.GET("/download", s.downloadHandler)
 
-		endpoint.Use(auth.MWAuthRequired())
+		endpoint.Use(auth.MWAuthRequiredFor(utils.CapabilityViewStatements), s.params.Registry.MWResolveCluster())
 		endpoint.Use(utils.MWConnectTiDB(s.params.TiDBClient))
 		{
 			endpoint.GET("/list", s.getList)
@@ -52,12 +64,17 @@ func registerRouter(r *gin.RouterGroup, auth *user.AuthService, s *Service) {
 			endpoint.POST("/download/token", s.downloadTokenHandler)
 
 			endpoint.GET("/available_fields", s.getAvailableFields)
+
+			endpoint.GET("/settings", s.getSettings)
+			endpoint.POST("/settings", auth.MWRequireWritePriv(), a.MWRecord("slow_query.set_settings"), s.setSettings)
 		}
 	}
 }
 
+// @ID slowQueryListGet
 // @Summary List all slow queries
 // @Param q query GetListRequest true "Query"
+// @Description The response is streamed. The cursor of the next page is returned in the `X-Next-Cursor` header when there may be more slow queries.
 // @Success 200 {array} Model
 // @Router /slow_query/list [get]
 // @Security JwtAuth
@@ -70,6 +87,23 @@ func (s *Service) getList(c *gin.Context) {
 		return
 	}
 
+	if req.Cursor != "" && req.Offset != 0 {
+		rest.Error(c, rest.ErrBadRequest.New("offset can not be specified along with cursor"))
+		return
+	}
+
+	page := pagination.Request{Cursor: req.Cursor, Limit: req.Limit}
+	page.Normalize(defaultListLimit, maxListLimit)
+	req.Limit = page.Limit
+	if req.Cursor != "" {
+		offset, err := pagination.DecodeOffsetCursor(req.Cursor)
+		if err != nil {
+			rest.Error(c, err)
+			return
+		}
+		req.Offset = offset
+	}
+
 	db := utils.GetTiDBConnection(c)
 	results, err := QuerySlowLogList(&req, s.params.SysSchema, db.Table(SlowQueryTable))
 	if err != nil {
@@ -77,9 +111,11 @@ func (s *Service) getList(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, results)
+	nextCursor := pagination.NextOffsetCursor(req.Offset, req.Limit, len(results))
+	pagination.WriteArray(c, nextCursor, len(results), func(i int) interface{} { return results[i] })
 }
 
+// @ID slowQueryDetailGet
 // @Summary Get details of a slow query
 // @Param q query GetDetailRequest true "Query"
 // @Success 200 {object} Model
@@ -121,6 +157,7 @@ func (s *Service) getDetails(c *gin.Context) {
 	c.JSON(http.StatusOK, *result)
 }
 
+// @ID slowQueryDownloadTokenPost
 // @Router /slow_query/download/token [post]
 // @Summary Generate a download token for exported slow query statements
 // @Produce plain
@@ -139,6 +176,9 @@ func (s *Service) downloadTokenHandler(c *gin.Context) {
 	if strings.TrimSpace(req.Fields) != "" {
 		fields = strings.Split(req.Fields, ",")
 	}
+	if req.Limit > utils.MaxExportRows {
+		req.Limit = utils.MaxExportRows
+	}
 	db := utils.GetTiDBConnection(c)
 	list, err := QuerySlowLogList(&req, s.params.SysSchema, db.Table(SlowQueryTable))
 	if err != nil {
@@ -173,10 +213,12 @@ func (s *Service) downloadTokenHandler(c *gin.Context) {
 	c.String(http.StatusOK, token)
 }
 
+// @ID slowQueryDownloadGet
 // @Router /slow_query/download [get]
 // @Summary Download slow query statements
 // @Produce text/csv
 // @Param token query string true "download token"
+// @Success 200 {string} string "CSV file"
 // @Failure 400 {object} rest.ErrorResponse
 // @Failure 401 {object} rest.ErrorResponse
 func (s *Service) downloadHandler(c *gin.Context) {
@@ -184,6 +226,7 @@ func (s *Service) downloadHandler(c *gin.Context) {
 	utils.DownloadByToken(token, "slowquery/download", c)
 }
 
+// @ID slowQueryAvailableFieldsGet
 // @Summary Get available field names
 // @Description Get available field names by slowquery table columns
 // @Success 200 {array} string
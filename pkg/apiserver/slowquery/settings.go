@@ -0,0 +1,191 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package slowquery
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/cluster"
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/utils"
+	"github.com/pingcap/tidb-dashboard/pkg/utils/topology"
+	"github.com/pingcap/tidb-dashboard/util/distro"
+	"github.com/pingcap/tidb-dashboard/util/rest"
+)
+
+const (
+	// maxSlowLogThresholdMs is the max slow log threshold accepted, i.e. 1 hour.
+	maxSlowLogThresholdMs = 3600 * 1000
+	// minExpensiveQueryTimeThresholdSecs is the min value of tidb_expensive_query_time_threshold accepted by TiDB.
+	minExpensiveQueryTimeThresholdSecs = 10
+	maxExpensiveQueryTimeThresholdSecs = 24 * 3600
+)
+
+// Settings are the slow log settings of a TiDB instance. These variables are instance scoped, so that they are
+// read and set on each instance.
+type Settings struct {
+	EnableSlowLog                   bool `json:"enable_slow_log" gorm:"column:tidb_enable_slow_log"`
+	SlowLogThresholdMs              int  `json:"slow_log_threshold_ms" gorm:"column:tidb_slow_log_threshold"`
+	ExpensiveQueryTimeThresholdSecs int  `json:"expensive_query_time_threshold_secs" gorm:"column:tidb_expensive_query_time_threshold"`
+}
+
+type InstanceSettings struct {
+	Instance string `json:"instance"`
+	// Settings is nil when failed to read or set the settings of the instance.
+	Settings *Settings           `json:"settings,omitempty"`
+	Error    *rest.ErrorResponse `json:"error,omitempty"`
+}
+
+// SetSettingsRequest sets the provided settings on all TiDB instances.
+type SetSettingsRequest struct {
+	EnableSlowLog                   *bool `json:"enable_slow_log"`
+	SlowLogThresholdMs              *int  `json:"slow_log_threshold_ms"`
+	ExpensiveQueryTimeThresholdSecs *int  `json:"expensive_query_time_threshold_secs"`
+}
+
+const selectSettingsSQL = "SELECT @@GLOBAL.tidb_enable_slow_log AS tidb_enable_slow_log, " +
+	"@@GLOBAL.tidb_slow_log_threshold AS tidb_slow_log_threshold, " +
+	"@@GLOBAL.tidb_expensive_query_time_threshold AS tidb_expensive_query_time_threshold"
+
+// buildSetSettingsSQL validates the request and builds the statement setting the provided settings.
+func buildSetSettingsSQL(req SetSettingsRequest) (string, error) {
+	var assignments []string
+	if req.EnableSlowLog != nil {
+		v := 0
+		if *req.EnableSlowLog {
+			v = 1
+		}
+		assignments = append(assignments, fmt.Sprintf("@@GLOBAL.tidb_enable_slow_log = %d", v))
+	}
+	if req.SlowLogThresholdMs != nil {
+		if *req.SlowLogThresholdMs < 0 || *req.SlowLogThresholdMs > maxSlowLogThresholdMs {
+			return "", rest.ErrBadRequest.New("slow_log_threshold_ms must be between 0 and %d", maxSlowLogThresholdMs)
+		}
+		assignments = append(assignments, fmt.Sprintf("@@GLOBAL.tidb_slow_log_threshold = %d", *req.SlowLogThresholdMs))
+	}
+	if req.ExpensiveQueryTimeThresholdSecs != nil {
+		v := *req.ExpensiveQueryTimeThresholdSecs
+		if v < minExpensiveQueryTimeThresholdSecs || v > maxExpensiveQueryTimeThresholdSecs {
+			return "", rest.ErrBadRequest.New("expensive_query_time_threshold_secs must be between %d and %d",
+				minExpensiveQueryTimeThresholdSecs, maxExpensiveQueryTimeThresholdSecs)
+		}
+		assignments = append(assignments, fmt.Sprintf("@@GLOBAL.tidb_expensive_query_time_threshold = %d", v))
+	}
+	if len(assignments) == 0 {
+		return "", rest.ErrBadRequest.New("no settings to set")
+	}
+	return "SET " + strings.Join(assignments, ", "), nil
+}
+
+// forEachTiDB runs fn on a connection to each TiDB instance concurrently, using the credential of the session.
+// fn returns the settings of the instance after running.
+func (s *Service) forEachTiDB(c *gin.Context, fn func(db *gorm.DB) (*Settings, error)) ([]InstanceSettings, error) {
+	u := utils.GetSession(c)
+	instances, err := topology.FetchTiDBTopology(c.Request.Context(), cluster.EtcdClientOf(c, s.params.EtcdClients))
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]InstanceSettings, len(instances))
+	var wg sync.WaitGroup
+	for i, instance := range instances {
+		wg.Add(1)
+		go func(i int, instance topology.TiDBInfo) {
+			defer wg.Done()
+			r := InstanceSettings{Instance: net.JoinHostPort(instance.IP, strconv.Itoa(int(instance.Port)))}
+			defer func() { results[i] = r }()
+
+			db, err := s.params.TiDBClient.WithSQLAPIAddress(instance.IP, int(instance.Port)).OpenSQLConn(u.TiDBUsername, u.TiDBPassword)
+			if err != nil {
+				errResp := rest.NewErrorResponse(err)
+				r.Error = &errResp
+				return
+			}
+			defer utils.CloseTiDBConnection(db) //nolint:errcheck
+
+			settings, err := fn(db)
+			if err != nil {
+				errResp := rest.NewErrorResponse(err)
+				r.Error = &errResp
+				return
+			}
+			r.Settings = settings
+		}(i, instance)
+	}
+	wg.Wait()
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Instance < results[j].Instance
+	})
+	return results, nil
+}
+
+func readSettings(db *gorm.DB) (*Settings, error) {
+	var settings Settings
+	if err := db.Raw(selectSettingsSQL).Scan(&settings).Error; err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+// @ID slowQuerySettingsGet
+// @Summary Get slow log settings of all TiDB instances
+// @Success 200 {array} InstanceSettings
+// @Router /slow_query/settings [get]
+// @Security JwtAuth
+// @Failure 401 {object} rest.ErrorResponse
+func (s *Service) getSettings(c *gin.Context) {
+	results, err := s.forEachTiDB(c, readSettings)
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, results)
+}
+
+// @ID slowQuerySettingsPost
+// @Summary Set slow log settings of all TiDB instances
+// @Description Only provided settings are set. The settings after setting are returned for each instance.
+// @Param request body SetSettingsRequest true "Request body"
+// @Success 200 {array} InstanceSettings
+// @Router /slow_query/settings [post]
+// @Security JwtAuth
+// @Failure 400 {object} rest.ErrorResponse
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 403 {object} rest.ErrorResponse
+func (s *Service) setSettings(c *gin.Context) {
+	var req SetSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		rest.Error(c, rest.ErrBadRequest.NewWithNoMessage())
+		return
+	}
+	sql, err := buildSetSettingsSQL(req)
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+
+	results, err := s.forEachTiDB(c, func(db *gorm.DB) (*Settings, error) {
+		if err := db.Exec(sql).Error; err != nil {
+			return nil, err
+		}
+		return readSettings(db)
+	})
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	if len(results) == 0 {
+		rest.Error(c, rest.ErrNotFound.New("no %s instance found", distro.R().TiDB))
+		return
+	}
+	c.JSON(http.StatusOK, results)
+}
@@ -0,0 +1,36 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package slowquery
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildSetSettingsSQL(t *testing.T) {
+	enable := true
+	threshold := 100
+	expensive := 60
+	sql, err := buildSetSettingsSQL(SetSettingsRequest{
+		EnableSlowLog:                   &enable,
+		SlowLogThresholdMs:              &threshold,
+		ExpensiveQueryTimeThresholdSecs: &expensive,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "SET @@GLOBAL.tidb_enable_slow_log = 1, @@GLOBAL.tidb_slow_log_threshold = 100, "+
+		"@@GLOBAL.tidb_expensive_query_time_threshold = 60", sql)
+
+	sql, err = buildSetSettingsSQL(SetSettingsRequest{SlowLogThresholdMs: &threshold})
+	require.NoError(t, err)
+	require.Equal(t, "SET @@GLOBAL.tidb_slow_log_threshold = 100", sql)
+
+	_, err = buildSetSettingsSQL(SetSettingsRequest{})
+	require.Error(t, err)
+	negative := -1
+	_, err = buildSetSettingsSQL(SetSettingsRequest{SlowLogThresholdMs: &negative})
+	require.Error(t, err)
+	tooSmall := 5
+	_, err = buildSetSettingsSQL(SetSettingsRequest{ExpensiveQueryTimeThresholdSecs: &tooSmall})
+	require.Error(t, err)
+}
@@ -61,3 +61,15 @@ func (t *testConfigSuite) Test_buildGlobalConfigNamedArgsUpdateSQL_extract_field
 	testConfigStmt := "SET @@GLOBAL.tidb_enable_stmt_summary = @Enable"
 	c.Assert(buildGlobalConfigNamedArgsUpdateSQL(&testConfig{Enable: true, RefreshInterval: 1800}, "Enable"), Equals, testConfigStmt)
 }
+
+func (t *testConfigSuite) Test_EditableConfig_fieldsToUpdate(c *C) {
+	cfg := &EditableConfig{Enable: true, RefreshInterval: 1800, MaxSQLLength: 4096}
+	fields, err := cfg.fieldsToUpdate()
+	c.Assert(err, IsNil)
+	c.Assert(buildGlobalConfigNamedArgsUpdateSQL(cfg, fields...), Equals,
+		"SET @@GLOBAL.tidb_enable_stmt_summary = @Enable, @@GLOBAL.tidb_stmt_summary_refresh_interval = @RefreshInterval, "+
+			"@@GLOBAL.tidb_stmt_summary_internal_query = @InternalQuery, @@GLOBAL.tidb_stmt_summary_max_sql_length = @MaxSQLLength")
+
+	_, err = (&EditableConfig{Enable: true, HistorySize: -1}).fieldsToUpdate()
+	c.Assert(err, NotNil)
+}
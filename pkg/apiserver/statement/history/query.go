@@ -0,0 +1,133 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package history
+
+import (
+	"gorm.io/gorm"
+
+	"github.com/pingcap/tidb-dashboard/util/rest"
+)
+
+const (
+	defaultTopLimit = 100
+	maxTopLimit     = 1000
+	// maxTrendPoints bounds the points of a trend, buckets are enlarged when exceeded.
+	maxTrendPoints = 500
+)
+
+// topOrders are the expressions of the orders of top digests.
+var topOrders = map[string]string{
+	"sum_latency": "sum_latency",
+	"exec_count":  "exec_count",
+	"max_latency": "max_latency",
+	"sum_errors":  "sum_errors",
+	"avg_latency": "sum_latency * 1.0 / exec_count",
+}
+
+// DigestStats is the statistics of a digest summed over snapshots in a time range. Times are unix seconds.
+type DigestStats struct {
+	SchemaName string `json:"schema_name"`
+	Digest     string `json:"digest"`
+	DigestText string `json:"digest_text"`
+	StmtType   string `json:"stmt_type"`
+	ExecCount  int64  `json:"exec_count"`
+	// Latencies are in nanoseconds.
+	SumLatency int64 `json:"sum_latency"`
+	AvgLatency int64 `json:"avg_latency" gorm:"-"`
+	MaxLatency int64 `json:"max_latency"`
+	SumErrors  int64 `json:"sum_errors"`
+	SumMem     int64 `json:"-"`
+	AvgMem     int64 `json:"avg_mem" gorm:"-"`
+	MaxMem     int64 `json:"max_mem"`
+	FirstSeen  int64 `json:"first_seen"`
+	LastSeen   int64 `json:"last_seen"`
+}
+
+// TrendPoint is the statistics of a digest summed over snapshots in a bucket starting at Time.
+type TrendPoint struct {
+	Time       int64 `json:"time"`
+	ExecCount  int64 `json:"exec_count"`
+	AvgLatency int64 `json:"avg_latency"`
+	MaxLatency int64 `json:"max_latency"`
+	SumErrors  int64 `json:"sum_errors"`
+	AvgMem     int64 `json:"avg_mem"`
+}
+
+// queryTopDigests returns the digests executed in the time range, ordered by the order in descending order.
+func queryTopDigests(db *gorm.DB, beginTime, endTime int64, schemaName, order string, limit int) ([]DigestStats, error) {
+	orderExpr, ok := topOrders[order]
+	if !ok {
+		return nil, rest.ErrBadRequest.New("unsupported order %s", order)
+	}
+	query := db.
+		Model(&SnapshotModel{}).
+		Select("schema_name, digest, MAX(digest_text) AS digest_text, MAX(stmt_type) AS stmt_type, "+
+			"SUM(exec_count) AS exec_count, SUM(sum_latency) AS sum_latency, MAX(max_latency) AS max_latency, "+
+			"SUM(sum_errors) AS sum_errors, SUM(sum_mem) AS sum_mem, MAX(max_mem) AS max_mem, "+
+			"MIN(begin_time) AS first_seen, MAX(end_time) AS last_seen").
+		Where("begin_time < ? AND end_time > ?", endTime, beginTime).
+		Group("schema_name, digest").
+		Having("SUM(exec_count) > 0").
+		Order(orderExpr + " DESC").
+		Limit(limit)
+	if schemaName != "" {
+		query = query.Where("schema_name = ?", schemaName)
+	}
+	var result []DigestStats
+	if err := query.Scan(&result).Error; err != nil {
+		return nil, err
+	}
+	for i := range result {
+		result[i].AvgLatency = result[i].SumLatency / result[i].ExecCount
+		result[i].AvgMem = result[i].SumMem / result[i].ExecCount
+	}
+	return result, nil
+}
+
+// queryTrend returns the trend of a digest in the time range. Windows are summed into buckets of bucketSecs, which
+// is enlarged when there are too many points.
+func queryTrend(db *gorm.DB, beginTime, endTime int64, schemaName, digest string, bucketSecs int64) ([]TrendPoint, error) {
+	if minBucketSecs := (endTime - beginTime + maxTrendPoints - 1) / maxTrendPoints; bucketSecs < minBucketSecs {
+		bucketSecs = minBucketSecs
+	}
+	var snapshots []SnapshotModel
+	err := db.
+		Where("schema_name = ? AND digest = ? AND begin_time < ? AND end_time > ?", schemaName, digest, endTime, beginTime).
+		Order("begin_time").
+		Find(&snapshots).Error
+	if err != nil {
+		return nil, err
+	}
+
+	points := make([]TrendPoint, 0)
+	var sumLatency, sumMem int64
+	flush := func() {
+		p := &points[len(points)-1]
+		if p.ExecCount > 0 {
+			p.AvgLatency = sumLatency / p.ExecCount
+			p.AvgMem = sumMem / p.ExecCount
+		}
+		sumLatency, sumMem = 0, 0
+	}
+	for _, ss := range snapshots {
+		t := ss.BeginTime - ss.BeginTime%bucketSecs
+		if len(points) == 0 || points[len(points)-1].Time != t {
+			if len(points) > 0 {
+				flush()
+			}
+			points = append(points, TrendPoint{Time: t})
+		}
+		p := &points[len(points)-1]
+		p.ExecCount += ss.ExecCount
+		p.SumErrors += ss.SumErrors
+		if ss.MaxLatency > p.MaxLatency {
+			p.MaxLatency = ss.MaxLatency
+		}
+		sumLatency += ss.SumLatency
+		sumMem += ss.SumMem
+	}
+	if len(points) > 0 {
+		flush()
+	}
+	return points, nil
+}
@@ -0,0 +1,158 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package history
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/audit"
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/user"
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/utils"
+	"github.com/pingcap/tidb-dashboard/util/rest"
+)
+
+// maxQueryRange bounds the time range of queries, which is longer than snapshots are kept.
+const maxQueryRange = 90 * 24 * 60 * 60
+
+func registerRouter(r *gin.RouterGroup, auth *user.AuthService, a *audit.Service, s *Service) {
+	endpoint := r.Group("/statements/history")
+	endpoint.Use(auth.MWAuthRequired())
+	endpoint.GET("/collector", s.getCollectorHandler)
+	endpoint.PUT("/collector", auth.MWRequireWritePriv(), a.MWRecord("statement.history.set_collector"), s.setCollectorHandler)
+	endpoint.GET("/top", s.topHandler)
+	endpoint.GET("/trend", s.trendHandler)
+}
+
+// @ID statementsHistoryCollectorGet
+// @Summary Get the state of the collector persisting the statement summary history
+// @Security JwtAuth
+// @Success 200 {object} CollectorModel
+// @Failure 401 {object} rest.ErrorResponse
+// @Router /statements/history/collector [get]
+func (s *Service) getCollectorHandler(c *gin.Context) {
+	collector, err := s.GetCollector()
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, collector)
+}
+
+type SetCollectorRequest struct {
+	Enabled bool `json:"enabled"`
+}
+
+// @ID statementsHistoryCollectorPut
+// @Summary Enable or disable the collector persisting the statement summary history
+// @Description The statement summary history is collected every 5 minutes with the SQL user of the current
+// @Description session. Collected snapshots are kept when disabled.
+// @Param request body SetCollectorRequest true "Request body"
+// @Security JwtAuth
+// @Success 200 {object} CollectorModel
+// @Failure 400 {object} rest.ErrorResponse
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 403 {object} rest.ErrorResponse
+// @Router /statements/history/collector [put]
+func (s *Service) setCollectorHandler(c *gin.Context) {
+	var req SetCollectorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		rest.Error(c, rest.ErrBadRequest.NewWithNoMessage())
+		return
+	}
+	collector, err := s.SetEnabled(utils.GetSession(c), req.Enabled)
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, collector)
+}
+
+type TimeRangeRequest struct {
+	BeginTime int64 `json:"begin_time" form:"begin_time" binding:"required"`
+	EndTime   int64 `json:"end_time" form:"end_time" binding:"required"`
+}
+
+func (r *TimeRangeRequest) validate() error {
+	if r.EndTime <= r.BeginTime || r.EndTime-r.BeginTime > maxQueryRange {
+		return rest.ErrBadRequest.New("invalid time range")
+	}
+	return nil
+}
+
+type GetTopRequest struct {
+	TimeRangeRequest
+	SchemaName string `json:"schema_name" form:"schema_name"`
+	// OrderBy is one of `sum_latency`, `exec_count`, `avg_latency`, `max_latency` and `sum_errors`.
+	OrderBy string `json:"order_by" form:"order_by"`
+	Limit   int    `json:"limit" form:"limit"`
+}
+
+// @ID statementsHistoryTopGet
+// @Summary Get the top statements in a time range from the persisted statement summary history
+// @Param q query GetTopRequest true "Query"
+// @Security JwtAuth
+// @Success 200 {array} DigestStats
+// @Failure 400 {object} rest.ErrorResponse
+// @Failure 401 {object} rest.ErrorResponse
+// @Router /statements/history/top [get]
+func (s *Service) topHandler(c *gin.Context) {
+	var req GetTopRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		rest.Error(c, rest.ErrBadRequest.NewWithNoMessage())
+		return
+	}
+	if err := req.validate(); err != nil {
+		rest.Error(c, err)
+		return
+	}
+	if req.OrderBy == "" {
+		req.OrderBy = "sum_latency"
+	}
+	if req.Limit <= 0 {
+		req.Limit = defaultTopLimit
+	} else if req.Limit > maxTopLimit {
+		req.Limit = maxTopLimit
+	}
+	result, err := queryTopDigests(s.params.LocalStore.DB, req.BeginTime, req.EndTime, req.SchemaName, req.OrderBy, req.Limit)
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+type GetTrendRequest struct {
+	TimeRangeRequest
+	SchemaName string `json:"schema_name" form:"schema_name"`
+	Digest     string `json:"digest" form:"digest" binding:"required"`
+	// BucketSecs is the length of each point, which is enlarged when there are too many points.
+	BucketSecs int64 `json:"bucket_secs" form:"bucket_secs"`
+}
+
+// @ID statementsHistoryTrendGet
+// @Summary Get the trend of a statement in a time range from the persisted statement summary history
+// @Param q query GetTrendRequest true "Query"
+// @Security JwtAuth
+// @Success 200 {array} TrendPoint
+// @Failure 400 {object} rest.ErrorResponse
+// @Failure 401 {object} rest.ErrorResponse
+// @Router /statements/history/trend [get]
+func (s *Service) trendHandler(c *gin.Context) {
+	var req GetTrendRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		rest.Error(c, rest.ErrBadRequest.NewWithNoMessage())
+		return
+	}
+	if err := req.validate(); err != nil {
+		rest.Error(c, err)
+		return
+	}
+	result, err := queryTrend(s.params.LocalStore.DB, req.BeginTime, req.EndTime, req.SchemaName, req.Digest, req.BucketSecs)
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,346 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+// Package history persists the statement summary history of TiDB into the local store. TiDB keeps only a few
+// summary windows in memory, which are lost when TiDB restarts, while snapshots in the local store are kept for
+// weeks for analyzing long-range trends of statements.
+package history
+
+import (
+	"context"
+	"encoding/json"
+	"path"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gtank/cryptopasta"
+	"github.com/pingcap/log"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/utils"
+	"github.com/pingcap/tidb-dashboard/pkg/config"
+	"github.com/pingcap/tidb-dashboard/pkg/dbstore"
+	"github.com/pingcap/tidb-dashboard/pkg/tidb"
+	"github.com/pingcap/tidb-dashboard/util/rest"
+)
+
+const (
+	collectInterval = 5 * time.Minute
+	// maxDigestTextLength truncates the normalized SQL, which is repeated in every window.
+	maxDigestTextLength = 4096
+	insertBatchSize     = 500
+)
+
+// SnapshotModel is the statistics of a digest in a summary window, summed over all TiDB instances and plans.
+// Times are unix seconds.
+type SnapshotModel struct {
+	ID         uint   `json:"-" gorm:"primary_key"`
+	BeginTime  int64  `json:"begin_time" gorm:"index"`
+	EndTime    int64  `json:"end_time"`
+	SchemaName string `json:"schema_name"`
+	Digest     string `json:"digest" gorm:"index"`
+	DigestText string `json:"digest_text"`
+	StmtType   string `json:"stmt_type"`
+	PlanCount  int    `json:"plan_count"`
+	ExecCount  int64  `json:"exec_count"`
+	// SumLatency and MaxLatency are in nanoseconds.
+	SumLatency int64 `json:"sum_latency"`
+	MaxLatency int64 `json:"max_latency"`
+	SumErrors  int64 `json:"sum_errors"`
+	// SumMem is the sum of the memory used by executions in bytes.
+	SumMem int64 `json:"sum_mem"`
+	MaxMem int64 `json:"max_mem"`
+}
+
+func (SnapshotModel) TableName() string {
+	return "statement_history_snapshots"
+}
+
+// CollectorModel is the state of the collector, which has only one row.
+type CollectorModel struct {
+	ID        uint      `json:"-" gorm:"primary_key"`
+	Enabled   bool      `json:"enabled"`
+	EnabledBy string    `json:"enabled_by"`
+	EnabledAt time.Time `json:"enabled_at"`
+	// CollectedUntil is the end time of the last collected window in unix seconds.
+	CollectedUntil int64 `json:"collected_until"`
+	// LastError is the error of the last collection, which is empty when succeeded.
+	LastError string `json:"last_error"`
+
+	// EncryptedCredential is the SQL user enabling the collector, which is used to read the statement summary in
+	// background.
+	EncryptedCredential []byte `json:"-"`
+}
+
+func (CollectorModel) TableName() string {
+	return "statement_history_collector"
+}
+
+type credential struct {
+	Username string
+	Password string
+}
+
+// summaryRow is a row of the statement summary history of a TiDB instance and a plan.
+type summaryRow struct {
+	BeginTime  int64  `gorm:"column:begin_time"`
+	EndTime    int64  `gorm:"column:end_time"`
+	SchemaName string `gorm:"column:schema_name"`
+	Digest     string `gorm:"column:digest"`
+	DigestText string `gorm:"column:digest_text"`
+	StmtType   string `gorm:"column:stmt_type"`
+	PlanDigest string `gorm:"column:plan_digest"`
+	ExecCount  int64  `gorm:"column:exec_count"`
+	SumLatency int64  `gorm:"column:sum_latency"`
+	MaxLatency int64  `gorm:"column:max_latency"`
+	SumErrors  int64  `gorm:"column:sum_errors"`
+	AvgMem     int64  `gorm:"column:avg_mem"`
+	MaxMem     int64  `gorm:"column:max_mem"`
+}
+
+// Windows ending after the current time of TiDB are still being summarized, thus not collected.
+const selectSummaryRowsSQL = "SELECT FLOOR(UNIX_TIMESTAMP(summary_begin_time)) AS begin_time, " +
+	"FLOOR(UNIX_TIMESTAMP(summary_end_time)) AS end_time, schema_name, digest, digest_text, " +
+	"stmt_type, plan_digest, exec_count, sum_latency, max_latency, sum_errors, avg_mem, max_mem " +
+	"FROM INFORMATION_SCHEMA.CLUSTER_STATEMENTS_SUMMARY_HISTORY " +
+	"WHERE summary_end_time > FROM_UNIXTIME(?) AND summary_end_time <= NOW()"
+
+type ServiceParams struct {
+	fx.In
+	Config     *config.Config
+	LocalStore *dbstore.DB
+	TiDBClient *tidb.Client
+}
+
+type Service struct {
+	params ServiceParams
+
+	encKey *utils.EncKeyFile
+	// collectMu prevents collections from running at the same time.
+	collectMu sync.Mutex
+	// now and openSQLConn are replaced in tests.
+	now         func() time.Time
+	openSQLConn func(c credential) (*gorm.DB, error)
+}
+
+func newService(lc fx.Lifecycle, p ServiceParams) (*Service, error) {
+	if err := p.LocalStore.AutoMigrate(&SnapshotModel{}, &CollectorModel{}); err != nil {
+		return nil, err
+	}
+	s := &Service{
+		params: p,
+		encKey: utils.NewEncKeyFile(path.Join(p.Config.DataDir, "stmt_history_ek.bin")),
+		now:    time.Now,
+	}
+	s.openSQLConn = func(c credential) (*gorm.DB, error) {
+		return p.TiDBClient.OpenSQLConn(c.Username, c.Password)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go s.collectLoop(ctx)
+			return nil
+		},
+		OnStop: func(context.Context) error {
+			cancel()
+			return nil
+		},
+	})
+	return s, nil
+}
+
+var Module = fx.Options(
+	fx.Provide(newService),
+	fx.Invoke(registerRouter),
+)
+
+// GetCollector returns the state of the collector, which is disabled when never enabled.
+func (s *Service) GetCollector() (*CollectorModel, error) {
+	var collector CollectorModel
+	err := s.params.LocalStore.Where("id = ?", 1).Limit(1).Find(&collector).Error
+	if err != nil {
+		return nil, err
+	}
+	collector.ID = 1
+	return &collector, nil
+}
+
+// SetEnabled enables the collector with the SQL user of the session, or disables it. Collected snapshots are kept
+// when disabled.
+func (s *Service) SetEnabled(u *utils.SessionUser, enabled bool) (*CollectorModel, error) {
+	collector, err := s.GetCollector()
+	if err != nil {
+		return nil, err
+	}
+	collector.Enabled = enabled
+	if enabled {
+		if !u.HasTiDBAuth {
+			return nil, rest.ErrBadRequest.New("the collector can only be enabled by sessions signed in with a SQL user")
+		}
+		key, err := s.encKey.GetOrCreate()
+		if err != nil {
+			return nil, err
+		}
+		plain, err := json.Marshal(credential{Username: u.TiDBUsername, Password: u.TiDBPassword})
+		if err != nil {
+			return nil, err
+		}
+		encrypted, err := cryptopasta.Encrypt(plain, key)
+		if err != nil {
+			return nil, err
+		}
+		collector.EnabledBy = u.DisplayName
+		collector.EnabledAt = s.now()
+		collector.LastError = ""
+		collector.EncryptedCredential = encrypted
+	} else {
+		collector.EncryptedCredential = nil
+	}
+	if err := s.params.LocalStore.Save(collector).Error; err != nil {
+		return nil, err
+	}
+	return collector, nil
+}
+
+func (s *Service) collectLoop(ctx context.Context) {
+	ticker := time.NewTicker(collectInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.collect(ctx); err != nil {
+				log.Warn("Failed to collect statement history", zap.Error(err))
+			}
+		}
+	}
+}
+
+// collect persists the summary windows finished since the last collection. Windows already evicted from TiDB are
+// lost, thus the interval of collections should be shorter than the history kept by TiDB.
+func (s *Service) collect(ctx context.Context) error {
+	s.collectMu.Lock()
+	defer s.collectMu.Unlock()
+
+	collector, err := s.GetCollector()
+	if err != nil || !collector.Enabled {
+		return err
+	}
+	collectErr := s.collectWindows(ctx, collector)
+	lastError := ""
+	if collectErr != nil {
+		lastError = collectErr.Error()
+	}
+	err = s.params.LocalStore.Model(&CollectorModel{}).Where("id = ?", collector.ID).Update("last_error", lastError).Error
+	if collectErr != nil {
+		return collectErr
+	}
+	return err
+}
+
+func (s *Service) collectWindows(ctx context.Context, collector *CollectorModel) error {
+	key, err := s.encKey.GetOrCreate()
+	if err != nil {
+		return err
+	}
+	plain, err := cryptopasta.Decrypt(collector.EncryptedCredential, key)
+	if err != nil {
+		return err
+	}
+	var cred credential
+	if err := json.Unmarshal(plain, &cred); err != nil {
+		return err
+	}
+	db, err := s.openSQLConn(cred)
+	if err != nil {
+		return err
+	}
+	defer utils.CloseTiDBConnection(db) //nolint:errcheck
+
+	var rows []summaryRow
+	err = db.WithContext(ctx).
+		Raw(selectSummaryRowsSQL, collector.CollectedUntil).
+		Scan(&rows).Error
+	if err != nil {
+		return err
+	}
+	snapshots := aggregateRows(rows)
+	if len(snapshots) == 0 {
+		return nil
+	}
+	collectedUntil := collector.CollectedUntil
+	for _, ss := range snapshots {
+		if ss.EndTime > collectedUntil {
+			collectedUntil = ss.EndTime
+		}
+	}
+	return s.params.LocalStore.Transaction(func(tx *gorm.DB) error {
+		if err := tx.CreateInBatches(snapshots, insertBatchSize).Error; err != nil {
+			return err
+		}
+		return tx.Model(&CollectorModel{}).Where("id = ?", collector.ID).Update("collected_until", collectedUntil).Error
+	})
+}
+
+// aggregateRows sums rows of the same window and digest, ordered by the window and the digest.
+func aggregateRows(rows []summaryRow) []SnapshotModel {
+	type key struct {
+		beginTime  int64
+		schemaName string
+		digest     string
+	}
+	snapshots := make(map[key]*SnapshotModel)
+	plans := make(map[key]map[string]struct{})
+	for _, r := range rows {
+		k := key{beginTime: r.BeginTime, schemaName: r.SchemaName, digest: r.Digest}
+		ss, ok := snapshots[k]
+		if !ok {
+			text := r.DigestText
+			if len(text) > maxDigestTextLength {
+				text = text[:maxDigestTextLength]
+			}
+			ss = &SnapshotModel{
+				BeginTime:  k.beginTime,
+				EndTime:    r.EndTime,
+				SchemaName: r.SchemaName,
+				Digest:     r.Digest,
+				DigestText: text,
+				StmtType:   r.StmtType,
+			}
+			snapshots[k] = ss
+			plans[k] = make(map[string]struct{})
+		}
+		plans[k][r.PlanDigest] = struct{}{}
+		ss.PlanCount = len(plans[k])
+		ss.ExecCount += r.ExecCount
+		ss.SumLatency += r.SumLatency
+		ss.SumErrors += r.SumErrors
+		ss.SumMem += r.AvgMem * r.ExecCount
+		if r.MaxLatency > ss.MaxLatency {
+			ss.MaxLatency = r.MaxLatency
+		}
+		if r.MaxMem > ss.MaxMem {
+			ss.MaxMem = r.MaxMem
+		}
+	}
+
+	result := make([]SnapshotModel, 0, len(snapshots))
+	for _, ss := range snapshots {
+		result = append(result, *ss)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if a.BeginTime != b.BeginTime {
+			return a.BeginTime < b.BeginTime
+		}
+		if a.SchemaName != b.SchemaName {
+			return a.SchemaName < b.SchemaName
+		}
+		return a.Digest < b.Digest
+	})
+	return result
+}
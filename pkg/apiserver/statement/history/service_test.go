@@ -0,0 +1,194 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package history
+
+import (
+	"context"
+	"database/sql"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/utils"
+	"github.com/pingcap/tidb-dashboard/pkg/config"
+	"github.com/pingcap/tidb-dashboard/pkg/dbstore/dbstoretest"
+)
+
+// testNow is the current time of the mocked TiDB in unix seconds.
+const testNow = 1704070800 // 2024-01-01 01:00:00 UTC
+
+// The mocked TiDB keeps times in unix seconds, with the time functions of TiDB used by the collector.
+func init() {
+	sql.Register("sqlite3_tidb", &sqlite3.SQLiteDriver{
+		ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+			identity := func(v int64) int64 { return v }
+			if err := conn.RegisterFunc("UNIX_TIMESTAMP", identity, true); err != nil {
+				return err
+			}
+			if err := conn.RegisterFunc("FROM_UNIXTIME", identity, true); err != nil {
+				return err
+			}
+			if err := conn.RegisterFunc("FLOOR", identity, true); err != nil {
+				return err
+			}
+			return conn.RegisterFunc("NOW", func() int64 { return testNow }, false)
+		},
+	})
+}
+
+func newTestService(t *testing.T) *Service {
+	dir := t.TempDir()
+	db, err := dbstoretest.Open(dir)
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&SnapshotModel{}, &CollectorModel{}))
+	s := &Service{
+		params: ServiceParams{Config: &config.Config{DataDir: dir}, LocalStore: db},
+		encKey: utils.NewEncKeyFile(path.Join(dir, "stmt_history_ek.bin")),
+		now:    time.Now,
+	}
+	s.openSQLConn = func(credential) (*gorm.DB, error) {
+		return openTestSummaryDB(dir)
+	}
+	return s
+}
+
+// openTestSummaryDB opens a SQLite database in dir mocking the statement summary history of TiDB.
+func openTestSummaryDB(dir string) (*gorm.DB, error) {
+	db, err := gorm.Open(sqlite.Dialector{DriverName: "sqlite3_tidb", DSN: path.Join(dir, "tidb.sqlite.db")})
+	if err != nil {
+		return nil, err
+	}
+	// INFORMATION_SCHEMA is attached to the connection, thus only one connection is used.
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	sqlDB.SetMaxOpenConns(1)
+	if err := db.Exec("ATTACH DATABASE ? AS INFORMATION_SCHEMA", path.Join(dir, "is.sqlite.db")).Error; err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+// insertSummaryRows inserts rows of two windows of 30 minutes ending at testNow, and a window not finished yet.
+func insertSummaryRows(t *testing.T, s *Service) {
+	db, err := s.openSQLConn(credential{})
+	require.NoError(t, err)
+	defer utils.CloseTiDBConnection(db) //nolint:errcheck
+	require.NoError(t, db.Exec("CREATE TABLE IF NOT EXISTS INFORMATION_SCHEMA.CLUSTER_STATEMENTS_SUMMARY_HISTORY "+
+		"(instance TEXT, summary_begin_time INTEGER, summary_end_time INTEGER, schema_name TEXT, digest TEXT, "+
+		"digest_text TEXT, stmt_type TEXT, plan_digest TEXT, exec_count INTEGER, sum_latency INTEGER, "+
+		"max_latency INTEGER, sum_errors INTEGER, avg_mem INTEGER, max_mem INTEGER)").Error)
+	rows := [][]interface{}{
+		{"tidb-0", testNow - 3600, testNow - 1800, "db", "digest-a", "select ?", "Select", "plan-1", 10, 1000, 200, 0, 100, 200},
+		{"tidb-1", testNow - 3600, testNow - 1800, "db", "digest-a", "select ?", "Select", "plan-2", 30, 6000, 500, 1, 200, 300},
+		{"tidb-0", testNow - 1800, testNow, "db", "digest-a", "select ?", "Select", "plan-1", 20, 2000, 100, 0, 100, 100},
+		{"tidb-0", testNow - 1800, testNow, "db", "digest-b", "update t", "Update", "plan-3", 1, 9500, 9500, 2, 100, 100},
+		{"tidb-0", testNow, testNow + 1800, "db", "digest-a", "select ?", "Select", "plan-1", 5, 500, 100, 0, 100, 100},
+	}
+	for _, r := range rows {
+		require.NoError(t, db.Exec("INSERT INTO INFORMATION_SCHEMA.CLUSTER_STATEMENTS_SUMMARY_HISTORY "+
+			"VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)", r...).Error)
+	}
+}
+
+func TestSetEnabled(t *testing.T) {
+	s := newTestService(t)
+
+	collector, err := s.GetCollector()
+	require.NoError(t, err)
+	require.False(t, collector.Enabled)
+
+	_, err = s.SetEnabled(&utils.SessionUser{}, true)
+	require.Error(t, err)
+
+	collector, err = s.SetEnabled(&utils.SessionUser{HasTiDBAuth: true, TiDBUsername: "root", DisplayName: "root"}, true)
+	require.NoError(t, err)
+	require.True(t, collector.Enabled)
+	require.Equal(t, "root", collector.EnabledBy)
+	require.NotEmpty(t, collector.EncryptedCredential)
+
+	collector, err = s.SetEnabled(nil, false)
+	require.NoError(t, err)
+	require.False(t, collector.Enabled)
+	require.Empty(t, collector.EncryptedCredential)
+}
+
+func TestCollectAndQuery(t *testing.T) {
+	s := newTestService(t)
+	insertSummaryRows(t, s)
+
+	// Nothing is collected when disabled.
+	require.NoError(t, s.collect(context.Background()))
+	var count int64
+	require.NoError(t, s.params.LocalStore.Model(&SnapshotModel{}).Count(&count).Error)
+	require.Zero(t, count)
+
+	_, err := s.SetEnabled(&utils.SessionUser{HasTiDBAuth: true, TiDBUsername: "root"}, true)
+	require.NoError(t, err)
+	require.NoError(t, s.collect(context.Background()))
+	// Windows are collected only once.
+	require.NoError(t, s.collect(context.Background()))
+
+	var snapshots []SnapshotModel
+	require.NoError(t, s.params.LocalStore.Order("begin_time, digest").Find(&snapshots).Error)
+	require.Len(t, snapshots, 3)
+	require.Equal(t, SnapshotModel{
+		ID:         snapshots[0].ID,
+		BeginTime:  testNow - 3600,
+		EndTime:    testNow - 1800,
+		SchemaName: "db",
+		Digest:     "digest-a",
+		DigestText: "select ?",
+		StmtType:   "Select",
+		PlanCount:  2,
+		ExecCount:  40,
+		SumLatency: 7000,
+		MaxLatency: 500,
+		SumErrors:  1,
+		SumMem:     10*100 + 30*200,
+		MaxMem:     300,
+	}, snapshots[0])
+	require.Equal(t, "digest-b", snapshots[2].Digest)
+
+	collector, err := s.GetCollector()
+	require.NoError(t, err)
+	require.Equal(t, int64(testNow), collector.CollectedUntil)
+	require.Empty(t, collector.LastError)
+
+	db := s.params.LocalStore.DB
+	top, err := queryTopDigests(db, testNow-7200, testNow, "", "sum_latency", 10)
+	require.NoError(t, err)
+	require.Len(t, top, 2)
+	require.Equal(t, "digest-b", top[0].Digest)
+	require.Equal(t, "digest-a", top[1].Digest)
+	require.Equal(t, int64(60), top[1].ExecCount)
+	require.Equal(t, int64(150), top[1].AvgLatency)
+	require.Equal(t, int64(testNow-3600), top[1].FirstSeen)
+	require.Equal(t, int64(testNow), top[1].LastSeen)
+
+	top, err = queryTopDigests(db, testNow-7200, testNow, "", "exec_count", 1)
+	require.NoError(t, err)
+	require.Equal(t, "digest-a", top[0].Digest)
+	_, err = queryTopDigests(db, testNow-7200, testNow, "", "digest; DROP TABLE x", 1)
+	require.Error(t, err)
+
+	trend, err := queryTrend(db, testNow-7200, testNow, "db", "digest-a", 1800)
+	require.NoError(t, err)
+	require.Equal(t, []TrendPoint{
+		{Time: testNow - 3600, ExecCount: 40, AvgLatency: 175, MaxLatency: 500, SumErrors: 1, AvgMem: 175},
+		{Time: testNow - 1800, ExecCount: 20, AvgLatency: 100, MaxLatency: 100, AvgMem: 100},
+	}, trend)
+
+	// Windows are summed into a bucket of an hour.
+	trend, err = queryTrend(db, testNow-7200, testNow, "db", "digest-a", 3600)
+	require.NoError(t, err)
+	require.Equal(t, []TrendPoint{
+		{Time: testNow - 3600, ExecCount: 60, AvgLatency: 150, MaxLatency: 500, SumErrors: 1, AvgMem: 150},
+	}, trend)
+}
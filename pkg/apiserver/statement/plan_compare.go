@@ -0,0 +1,186 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package statement
+
+import (
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/utils"
+	"github.com/pingcap/tidb-dashboard/util/rest"
+)
+
+type PlanOperator struct {
+	// Name is the operator without the ID suffix, e.g. `IndexLookUp` for `IndexLookUp_10`.
+	Name  string `json:"name"`
+	Task  string `json:"task"`
+	Depth int    `json:"depth"`
+}
+
+func (o PlanOperator) label() string {
+	if o.Task == "" {
+		return o.Name
+	}
+	return o.Name + " (" + o.Task + ")"
+}
+
+// PlanDiff is the difference of operators compared to the baseline plan. Operators are labeled as
+// `Name (task)`, and are repeated when appeared multiple times.
+type PlanDiff struct {
+	Added   []string `json:"added"`
+	Removed []string `json:"removed"`
+}
+
+type ComparedPlan struct {
+	PlanDigest       string `json:"plan_digest"`
+	ExecCount        int    `json:"exec_count"`
+	AvgLatency       int    `json:"avg_latency"`
+	MaxLatency       int    `json:"max_latency"`
+	MinLatency       int    `json:"min_latency"`
+	AvgAffectedRows  int    `json:"avg_affected_rows"`
+	AvgProcessedKeys int    `json:"avg_processed_keys"`
+	AvgMem           int    `json:"avg_mem"`
+	FirstSeen        int    `json:"first_seen"`
+	LastSeen         int    `json:"last_seen"`
+
+	Operators []PlanOperator `json:"operators"`
+	// AvgLatencyRatio is the average latency compared to the baseline plan, e.g. 2 means two times slower.
+	AvgLatencyRatio float64  `json:"avg_latency_ratio"`
+	Diff            PlanDiff `json:"diff"`
+}
+
+type PlanComparison struct {
+	// BaselinePlanDigest is the most executed plan, which other plans are compared to.
+	BaselinePlanDigest string         `json:"baseline_plan_digest"`
+	Plans              []ComparedPlan `json:"plans"`
+}
+
+var operatorIDSuffixRegex = regexp.MustCompile(`_\d+$`)
+
+// parsePlanOperators parses operators from the plan text in statements summary, which is a table whose columns
+// are separated by tabs, and the first columns are the operator ID and the task. The header row is skipped.
+func parsePlanOperators(plan string) []PlanOperator {
+	operators := make([]PlanOperator, 0)
+	for _, line := range strings.Split(plan, "\n") {
+		columns := strings.Split(strings.TrimPrefix(line, "\t"), "\t")
+		if len(columns) < 2 {
+			continue
+		}
+		id := strings.TrimRight(columns[0], " ")
+		name := strings.TrimLeft(id, " │├└─")
+		if name == "" || name == "id" {
+			continue
+		}
+		// Each level of the tree is indented by 2 characters.
+		depth := len([]rune(id)) - len([]rune(name))
+		operators = append(operators, PlanOperator{
+			Name:  operatorIDSuffixRegex.ReplaceAllString(name, ""),
+			Task:  strings.TrimSpace(columns[1]),
+			Depth: depth / 2,
+		})
+	}
+	return operators
+}
+
+// diffOperators returns operators added or removed compared to the baseline, regardless of the plan structure.
+func diffOperators(baseline, operators []PlanOperator) PlanDiff {
+	counts := make(map[string]int)
+	for _, o := range baseline {
+		counts[o.label()]--
+	}
+	for _, o := range operators {
+		counts[o.label()]++
+	}
+	diff := PlanDiff{Added: make([]string, 0), Removed: make([]string, 0)}
+	for label, count := range counts {
+		for ; count > 0; count-- {
+			diff.Added = append(diff.Added, label)
+		}
+		for ; count < 0; count++ {
+			diff.Removed = append(diff.Removed, label)
+		}
+	}
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	return diff
+}
+
+func comparePlans(plans []Model) PlanComparison {
+	result := PlanComparison{Plans: make([]ComparedPlan, 0, len(plans))}
+	if len(plans) == 0 {
+		return result
+	}
+	baseline := plans[0]
+	for _, p := range plans[1:] {
+		if p.AggExecCount > baseline.AggExecCount {
+			baseline = p
+		}
+	}
+	result.BaselinePlanDigest = baseline.AggPlanDigest
+	baselineOperators := parsePlanOperators(baseline.AggPlan)
+
+	for _, p := range plans {
+		cp := ComparedPlan{
+			PlanDigest:       p.AggPlanDigest,
+			ExecCount:        p.AggExecCount,
+			AvgLatency:       p.AggAvgLatency,
+			MaxLatency:       p.AggMaxLatency,
+			MinLatency:       p.AggMinLatency,
+			AvgAffectedRows:  p.AggAvgAffectedRows,
+			AvgProcessedKeys: p.AggAvgProcessedKeys,
+			AvgMem:           p.AggAvgMem,
+			FirstSeen:        p.AggFirstSeen,
+			LastSeen:         p.AggLastSeen,
+			Operators:        parsePlanOperators(p.AggPlan),
+		}
+		if baseline.AggAvgLatency > 0 {
+			cp.AvgLatencyRatio = float64(p.AggAvgLatency) / float64(baseline.AggAvgLatency)
+		}
+		cp.Diff = diffOperators(baselineOperators, cp.Operators)
+		result.Plans = append(result.Plans, cp)
+	}
+	// Recently seen plans first, which are more likely to be regressions.
+	sort.SliceStable(result.Plans, func(i, j int) bool {
+		return result.Plans[i].LastSeen > result.Plans[j].LastSeen
+	})
+	return result
+}
+
+// @ID statementsPlansCompareGet
+// @Summary Compare execution plans of a statement
+// @Description Each plan is compared to the most executed plan, with its metrics and operator differences.
+// @Param q query GetPlansRequest true "Query"
+// @Success 200 {object} PlanComparison
+// @Router /statements/plans/compare [get]
+// @Security JwtAuth
+// @Failure 401 {object} rest.ErrorResponse
+func (s *Service) comparePlansHandler(c *gin.Context) {
+	var req GetPlansRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		rest.Error(c, rest.ErrBadRequest.NewWithNoMessage())
+		return
+	}
+	db := utils.GetTiDBConnection(c)
+	plans, err := s.queryPlansWithFields(db, req.BeginTime, req.EndTime, req.SchemaName, req.Digest, []string{
+		"plan_digest",
+		"plan",
+		"exec_count",
+		"avg_latency",
+		"max_latency",
+		"min_latency",
+		"avg_affected_rows",
+		"avg_processed_keys",
+		"avg_mem",
+		"first_seen",
+		"last_seen",
+	})
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, comparePlans(plans))
+}
@@ -0,0 +1,53 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package statement
+
+import (
+	. "github.com/pingcap/check"
+)
+
+var _ = Suite(&testPlanCompareSuite{})
+
+type testPlanCompareSuite struct{}
+
+const (
+	indexPlan = "\tid                 \ttask     \testRows\toperator info\n" +
+		"\tIndexLookUp_10     \troot     \t10     \t\n" +
+		"\t├─IndexRangeScan_8 \tcop[tikv]\t10     \ttable:t, index:idx(a), range:[1,1]\n" +
+		"\t└─TableRowIDScan_9 \tcop[tikv]\t10     \ttable:t"
+	fullScanPlan = "\tid                   \ttask     \testRows\toperator info\n" +
+		"\tTableReader_7        \troot     \t10     \tdata:Selection_6\n" +
+		"\t└─Selection_6        \tcop[tikv]\t10     \teq(test.t.a, 1)\n" +
+		"\t  └─TableFullScan_5  \tcop[tikv]\t10000  \ttable:t, keep order:false"
+)
+
+func (t *testPlanCompareSuite) TestParsePlanOperators(c *C) {
+	c.Assert(parsePlanOperators(fullScanPlan), DeepEquals, []PlanOperator{
+		{Name: "TableReader", Task: "root", Depth: 0},
+		{Name: "Selection", Task: "cop[tikv]", Depth: 1},
+		{Name: "TableFullScan", Task: "cop[tikv]", Depth: 2},
+	})
+	c.Assert(parsePlanOperators(""), HasLen, 0)
+}
+
+func (t *testPlanCompareSuite) TestComparePlans(c *C) {
+	result := comparePlans([]Model{
+		{AggPlanDigest: "p1", AggPlan: indexPlan, AggExecCount: 100, AggAvgLatency: 1000, AggLastSeen: 10},
+		{AggPlanDigest: "p2", AggPlan: fullScanPlan, AggExecCount: 5, AggAvgLatency: 30000, AggLastSeen: 20},
+	})
+	c.Assert(result.BaselinePlanDigest, Equals, "p1")
+	c.Assert(result.Plans, HasLen, 2)
+
+	regressed := result.Plans[0]
+	c.Assert(regressed.PlanDigest, Equals, "p2")
+	c.Assert(regressed.AvgLatencyRatio, Equals, 30.0)
+	c.Assert(regressed.Diff.Added, DeepEquals, []string{"Selection (cop[tikv])", "TableFullScan (cop[tikv])", "TableReader (root)"})
+	c.Assert(regressed.Diff.Removed, DeepEquals, []string{"IndexLookUp (root)", "IndexRangeScan (cop[tikv])", "TableRowIDScan (cop[tikv])"})
+
+	baseline := result.Plans[1]
+	c.Assert(baseline.AvgLatencyRatio, Equals, 1.0)
+	c.Assert(baseline.Diff.Added, HasLen, 0)
+	c.Assert(baseline.Diff.Removed, HasLen, 0)
+
+	c.Assert(comparePlans(nil).Plans, HasLen, 0)
+}
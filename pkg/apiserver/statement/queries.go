@@ -96,12 +96,7 @@ func (s *Service) queryPlans(
 	beginTime, endTime int,
 	schemaName, digest string,
 ) (result []Model, err error) {
-	tableColumns, err := s.params.SysSchema.GetTableColumnNames(db, statementsTable)
-	if err != nil {
-		return nil, err
-	}
-
-	selectStmt, err := s.genSelectStmt(tableColumns, []string{
+	return s.queryPlansWithFields(db, beginTime, endTime, schemaName, digest, []string{
 		"plan_digest",
 		"schema_name",
 		"digest_text",
@@ -116,6 +111,21 @@ func (s *Service) queryPlans(
 		"stmt_type", // required by quick plan binding
 		"plan_hint", // required by quick plan binding, only available in TiDB 6.6.0+, could be filter out by `tableColumns`
 	})
+}
+
+// queryPlansWithFields queries the statement grouped by plans, with only the specified fields.
+func (s *Service) queryPlansWithFields(
+	db *gorm.DB,
+	beginTime, endTime int,
+	schemaName, digest string,
+	fields []string,
+) (result []Model, err error) {
+	tableColumns, err := s.params.SysSchema.GetTableColumnNames(db, statementsTable)
+	if err != nil {
+		return nil, err
+	}
+
+	selectStmt, err := s.genSelectStmt(tableColumns, fields)
 	if err != nil {
 		return nil, err
 	}
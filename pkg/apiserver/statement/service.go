@@ -14,6 +14,7 @@ import (
 	"github.com/pingcap/errors"
 	"go.uber.org/fx"
 
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/audit"
 	"github.com/pingcap/tidb-dashboard/pkg/apiserver/user"
 	"github.com/pingcap/tidb-dashboard/pkg/apiserver/utils"
 	"github.com/pingcap/tidb-dashboard/pkg/tidb"
@@ -42,21 +43,22 @@ func newService(p ServiceParams, ff *featureflag.Registry) *Service {
 	return &Service{params: p, planBindingFeatureFlag: ff.Register("plan_binding", ">= 6.5.0")}
 }
 
-func registerRouter(r *gin.RouterGroup, auth *user.AuthService, s *Service) {
+func registerRouter(r *gin.RouterGroup, auth *user.AuthService, a *audit.Service, s *Service) {
 	endpoint := r.Group("/statements")
 	{
 		endpoint.GET("/download", s.downloadHandler)
 
-		endpoint.Use(auth.MWAuthRequired())
+		endpoint.Use(auth.MWAuthRequiredFor(utils.CapabilityViewStatements))
 		endpoint.Use(utils.MWConnectTiDB(s.params.TiDBClient))
 		{
 			endpoint.POST("/download/token", s.downloadTokenHandler)
 
 			endpoint.GET("/config", s.configHandler)
-			endpoint.POST("/config", auth.MWRequireWritePriv(), s.modifyConfigHandler)
+			endpoint.POST("/config", auth.MWRequireWritePriv(), a.MWRecord("statement.modify_config"), s.modifyConfigHandler)
 			endpoint.GET("/stmt_types", s.stmtTypesHandler)
 			endpoint.GET("/list", s.listHandler)
 			endpoint.GET("/plans", s.plansHandler)
+			endpoint.GET("/plans/compare", s.comparePlansHandler)
 			endpoint.GET("/plan/detail", s.planDetailHandler)
 
 			endpoint.GET("/available_fields", s.getAvailableFields)
@@ -65,8 +67,8 @@ func registerRouter(r *gin.RouterGroup, auth *user.AuthService, s *Service) {
 			binding.Use(s.planBindingFeatureFlag.VersionGuard())
 			{
 				binding.GET("", s.getPlanBindingHandler)
-				binding.POST("", s.createPlanBindingHandler)
-				binding.DELETE("", s.dropPlanBindingHandler)
+				binding.POST("", auth.MWRequireWritePriv(), a.MWRecord("statement.create_plan_binding"), s.createPlanBindingHandler)
+				binding.DELETE("", auth.MWRequireWritePriv(), a.MWRecord("statement.drop_plan_binding"), s.dropPlanBindingHandler)
 			}
 		}
 	}
@@ -78,8 +80,31 @@ type EditableConfig struct {
 	HistorySize     int  `json:"history_size" gorm:"column:tidb_stmt_summary_history_size"`
 	MaxSize         int  `json:"max_size" gorm:"column:tidb_stmt_summary_max_stmt_count"`
 	InternalQuery   bool `json:"internal_query" gorm:"column:tidb_stmt_summary_internal_query"`
+	// MaxSQLLength is the max length of the SQL text and the normalized SQL of digests kept in the summary.
+	MaxSQLLength int `json:"max_sql_length" gorm:"column:tidb_stmt_summary_max_sql_length"`
 }
 
+// fieldsToUpdate returns the fields to be set when statement summary is enabled. Numeric fields are left
+// unchanged when they are 0, e.g. not provided by the request.
+func (c *EditableConfig) fieldsToUpdate() ([]string, error) {
+	fields := []string{"Enable", "InternalQuery"}
+	for name, value := range map[string]int{
+		"RefreshInterval": c.RefreshInterval,
+		"HistorySize":     c.HistorySize,
+		"MaxSize":         c.MaxSize,
+		"MaxSQLLength":    c.MaxSQLLength,
+	} {
+		if value < 0 {
+			return nil, rest.ErrBadRequest.New("%s must not be negative", name)
+		}
+		if value > 0 {
+			fields = append(fields, name)
+		}
+	}
+	return fields, nil
+}
+
+// @ID statementsConfigGet
 // @Summary Get statement configurations
 // @Success 200 {object} statement.EditableConfig
 // @Router /statements/config [get]
@@ -96,12 +121,16 @@ func (s *Service) configHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, cfg)
 }
 
+// @ID statementsConfigPost
 // @Summary Update statement configurations
+// @Description Configurations are set globally, i.e. for all TiDB instances. Only `enable` is set when disabling, and numeric configurations are kept unchanged when they are 0.
 // @Param request body statement.EditableConfig true "Request body"
 // @Success 204 {object} string
 // @Router /statements/config [post]
 // @Security JwtAuth
+// @Failure 400 {object} rest.ErrorResponse
 // @Failure 401 {object} rest.ErrorResponse
+// @Failure 403 {object} rest.ErrorResponse
 func (s *Service) modifyConfigHandler(c *gin.Context) {
 	var config EditableConfig
 	if err := c.ShouldBindJSON(&config); err != nil {
@@ -114,7 +143,12 @@ func (s *Service) modifyConfigHandler(c *gin.Context) {
 	if !config.Enable {
 		sqlWithNamedArgument = buildGlobalConfigNamedArgsUpdateSQL(&config, "Enable")
 	} else {
-		sqlWithNamedArgument = buildGlobalConfigNamedArgsUpdateSQL(&config)
+		fields, err := config.fieldsToUpdate()
+		if err != nil {
+			rest.Error(c, err)
+			return
+		}
+		sqlWithNamedArgument = buildGlobalConfigNamedArgsUpdateSQL(&config, fields...)
 	}
 	err := db.Exec(sqlWithNamedArgument, &config).Error
 	if err != nil {
@@ -125,6 +159,7 @@ func (s *Service) modifyConfigHandler(c *gin.Context) {
 	c.Status(http.StatusNoContent)
 }
 
+// @ID statementsStmtTypesGet
 // @Summary Get all statement types
 // @Success 200 {array} string
 // @Router /statements/stmt_types [get]
@@ -149,6 +184,7 @@ type GetStatementsRequest struct {
 	Fields    string   `json:"fields" form:"fields"`
 }
 
+// @ID statementsListGet
 // @Summary Get a list of statements
 // @Param q query GetStatementsRequest true "Query"
 // @Success 200 {array} Model
@@ -188,6 +224,7 @@ type GetPlansRequest struct {
 	EndTime    int    `json:"end_time" form:"end_time"`
 }
 
+// @ID statementsPlansGet
 // @Summary Get execution plans of a statement
 // @Param q query GetPlansRequest true "Query"
 // @Success 200 {array} Model
@@ -214,6 +251,7 @@ type GetPlanDetailRequest struct {
 	Plans []string `json:"plans" form:"plans"`
 }
 
+// @ID statementsPlanDetailGet
 // @Summary Get details of a statement in an execution plan
 // @Param q query GetPlanDetailRequest true "Query"
 // @Success 200 {object} Model
@@ -247,6 +285,7 @@ func (s *Service) planDetailHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, result)
 }
 
+// @ID statementsPlanBindingGet
 // @Summary	Get the bound plan digest (if exists) of a statement
 // @Param	sql_digest	query	string	true	"query template id"
 // @Param	begin_time	query	int	true	"begin time"
@@ -291,12 +330,14 @@ func (s *Service) getPlanBindingHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, result)
 }
 
+// @ID statementsPlanBindingPost
 // @Summary	Create a binding for a statement and a plan
 // @Param	plan_digest	query	string	true	"plan digest id"
 // @Success	200	{string}	string	"success"
 // @Router	/statements/plan/binding	[post]
 // @Security	JwtAuth
 // @Failure	401	{object}	rest.ErrorResponse
+// @Failure	403	{object}	rest.ErrorResponse
 func (s *Service) createPlanBindingHandler(c *gin.Context) {
 	digest := c.Query("plan_digest")
 	if digest == "" {
@@ -314,12 +355,14 @@ func (s *Service) createPlanBindingHandler(c *gin.Context) {
 	c.String(http.StatusOK, "success")
 }
 
+// @ID statementsPlanBindingDelete
 // @Summary	Drop all manually created bindings for a statement
 // @Param	sql_digest	query	string	true	"query template ID (a.k.a. sql digest)"
 // @Success	200	{string}	string	"success"
 // @Router	/statements/plan/binding	[delete]
 // @Security	JwtAuth
 // @Failure	401	{object}	rest.ErrorResponse
+// @Failure	403	{object}	rest.ErrorResponse
 func (s *Service) dropPlanBindingHandler(c *gin.Context) {
 	digest := c.Query("sql_digest")
 	if digest == "" {
@@ -337,6 +380,7 @@ func (s *Service) dropPlanBindingHandler(c *gin.Context) {
 	c.String(http.StatusOK, "success")
 }
 
+// @ID statementsDownloadTokenPost
 // @Router /statements/download/token [post]
 // @Summary Generate a download token for exported statements
 // @Produce plain
@@ -371,6 +415,9 @@ func (s *Service) downloadTokenHandler(c *gin.Context) {
 		rest.Error(c, ErrNoData.NewWithNoMessage())
 		return
 	}
+	if len(overviews) > utils.MaxExportRows {
+		overviews = overviews[:utils.MaxExportRows]
+	}
 
 	// interface{} tricky
 	rawData := make([]interface{}, len(overviews))
@@ -395,10 +442,12 @@ func (s *Service) downloadTokenHandler(c *gin.Context) {
 	c.String(http.StatusOK, token)
 }
 
+// @ID statementsDownloadGet
 // @Router /statements/download [get]
 // @Summary Download statements
 // @Produce text/csv
 // @Param token query string true "download token"
+// @Success 200 {string} string "CSV file"
 // @Failure 400 {object} rest.ErrorResponse
 // @Failure 401 {object} rest.ErrorResponse
 func (s *Service) downloadHandler(c *gin.Context) {
@@ -406,6 +455,7 @@ func (s *Service) downloadHandler(c *gin.Context) {
 	utils.DownloadByToken(token, "statements/download", c)
 }
 
+// @ID statementsAvailableFieldsGet
 // @Summary Get available field names
 // @Description Get available field names by statements table columns
 // @Success 200 {array} string
@@ -0,0 +1,167 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package watch
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/audit"
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/user"
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/utils"
+	"github.com/pingcap/tidb-dashboard/util/rest"
+)
+
+func registerRouter(r *gin.RouterGroup, auth *user.AuthService, a *audit.Service, s *Service) {
+	endpoint := r.Group("/statements/watches")
+	endpoint.Use(auth.MWAuthRequired())
+	endpoint.GET("", s.listHandler)
+	endpoint.POST("", auth.MWRequireWritePriv(), a.MWRecord("statement.watch.create"), s.createHandler)
+	endpoint.DELETE("/:id", auth.MWRequireWritePriv(), a.MWRecord("statement.watch.delete"), s.deleteHandler)
+	endpoint.GET("/notifications", s.listNotificationsHandler)
+	endpoint.POST("/notifications/:id/ack", s.acknowledgeHandler)
+}
+
+func parseID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		rest.Error(c, rest.ErrBadRequest.New("invalid id %s", c.Param("id")))
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// @ID statementListWatches
+// @Summary List watched statements
+// @Security JwtAuth
+// @Success 200 {array} WatchModel
+// @Failure 401 {object} rest.ErrorResponse
+// @Router /statements/watches [get]
+func (s *Service) listHandler(c *gin.Context) {
+	watches, err := s.List()
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, watches)
+}
+
+type CreateRequest struct {
+	Digest          string  `json:"digest" binding:"required"`
+	Comment         string  `json:"comment"`
+	MaxAvgLatencyMs float64 `json:"max_avg_latency_ms"`
+	MaxExecCount    int64   `json:"max_exec_count"`
+	WebhookURL      string  `json:"webhook_url"`
+}
+
+// @ID statementCreateWatch
+// @Summary Watch a statement digest with thresholds
+// @Description The statement summary of the digest is checked every minute with the SQL user of the current
+// @Description session. A notification is raised when a threshold is crossed in a summary window.
+// @Param request body CreateRequest true "Request body"
+// @Security JwtAuth
+// @Success 200 {object} WatchModel
+// @Failure 400 {object} rest.ErrorResponse
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 403 {object} rest.ErrorResponse
+// @Router /statements/watches [post]
+func (s *Service) createHandler(c *gin.Context) {
+	var req CreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		rest.Error(c, rest.ErrBadRequest.NewWithNoMessage())
+		return
+	}
+	w := &WatchModel{
+		Digest:          req.Digest,
+		Comment:         req.Comment,
+		MaxAvgLatencyMs: req.MaxAvgLatencyMs,
+		MaxExecCount:    req.MaxExecCount,
+		WebhookURL:      req.WebhookURL,
+	}
+	if err := s.Create(utils.GetSession(c), w); err != nil {
+		rest.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, w)
+}
+
+// @ID statementDeleteWatch
+// @Summary Delete a watch and its notifications
+// @Param id path integer true "Watch ID"
+// @Security JwtAuth
+// @Success 200 {string} string
+// @Failure 400 {object} rest.ErrorResponse
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 403 {object} rest.ErrorResponse
+// @Failure 404 {object} rest.ErrorResponse
+// @Router /statements/watches/{id} [delete]
+func (s *Service) deleteHandler(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	found, err := s.Delete(id)
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	if !found {
+		rest.Error(c, rest.ErrNotFound.New("watch %d not found", id))
+		return
+	}
+	c.JSON(http.StatusOK, nil)
+}
+
+type ListNotificationsRequest struct {
+	Unacknowledged bool `json:"unacknowledged" form:"unacknowledged"`
+}
+
+// @ID statementListWatchNotifications
+// @Summary List notifications of watched statements, latest first
+// @Param q query ListNotificationsRequest true "Query"
+// @Security JwtAuth
+// @Success 200 {array} NotificationModel
+// @Failure 400 {object} rest.ErrorResponse
+// @Failure 401 {object} rest.ErrorResponse
+// @Router /statements/watches/notifications [get]
+func (s *Service) listNotificationsHandler(c *gin.Context) {
+	var req ListNotificationsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		rest.Error(c, rest.ErrBadRequest.NewWithNoMessage())
+		return
+	}
+	notifications, err := s.ListNotifications(req.Unacknowledged)
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, notifications)
+}
+
+// @ID statementAcknowledgeWatchNotification
+// @Summary Acknowledge a notification of watched statements
+// @Param id path integer true "Notification ID"
+// @Security JwtAuth
+// @Success 200 {string} string
+// @Failure 400 {object} rest.ErrorResponse
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 404 {object} rest.ErrorResponse
+// @Router /statements/watches/notifications/{id}/ack [post]
+func (s *Service) acknowledgeHandler(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	found, err := s.Acknowledge(id)
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	if !found {
+		rest.Error(c, rest.ErrNotFound.New("notification %d not found", id))
+		return
+	}
+	c.JSON(http.StatusOK, nil)
+}
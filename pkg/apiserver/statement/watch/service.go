@@ -0,0 +1,382 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+// Package watch implements the statement watchlist. Users register SQL digests with thresholds, and the
+// digests are evaluated against the statement summary in background, raising notifications when the thresholds
+// are crossed.
+package watch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"time"
+
+	"github.com/gtank/cryptopasta"
+	"github.com/joomcode/errorx"
+	"github.com/pingcap/log"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/notification"
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/utils"
+	"github.com/pingcap/tidb-dashboard/pkg/config"
+	"github.com/pingcap/tidb-dashboard/pkg/dbstore"
+	"github.com/pingcap/tidb-dashboard/pkg/httpc"
+	"github.com/pingcap/tidb-dashboard/pkg/tidb"
+	"github.com/pingcap/tidb-dashboard/util/rest"
+)
+
+var (
+	ErrNS             = errorx.NewNamespace("error.api.statement.watch")
+	ErrWebhookRequest = ErrNS.NewType("webhook_request")
+)
+
+const (
+	evaluateInterval = time.Minute
+	webhookTimeout   = 10 * time.Second
+
+	MetricAvgLatency = "avg_latency"
+	MetricExecCount  = "exec_count"
+)
+
+// WatchModel is a watched SQL digest. Thresholds that are 0 are not checked.
+type WatchModel struct {
+	ID     uint   `json:"id" gorm:"primary_key"`
+	Digest string `json:"digest" gorm:"index"`
+	// Comment describes the watched statement, e.g. the normalized SQL.
+	Comment string `json:"comment"`
+	// MaxAvgLatencyMs is the max average latency of the digest in a summary window.
+	MaxAvgLatencyMs float64 `json:"max_avg_latency_ms"`
+	// MaxExecCount is the max execution count of the digest in a summary window.
+	MaxExecCount int64 `json:"max_exec_count"`
+	// WebhookURL receives notifications of the watch in JSON when not empty.
+	WebhookURL string    `json:"webhook_url"`
+	CreatedBy  string    `json:"created_by"`
+	CreatedAt  time.Time `json:"created_at"`
+
+	// EncryptedCredential is the SQL user of the creator, which is used to read the statement summary in background.
+	EncryptedCredential []byte `json:"-"`
+}
+
+func (WatchModel) TableName() string {
+	return "statement_watches"
+}
+
+// NotificationModel is raised when a threshold of a watch is crossed in a summary window.
+type NotificationModel struct {
+	ID          uint      `json:"id" gorm:"primary_key"`
+	WatchID     uint      `json:"watch_id" gorm:"index"`
+	Digest      string    `json:"digest"`
+	Metric      string    `json:"metric"`
+	Value       float64   `json:"value"`
+	Threshold   float64   `json:"threshold"`
+	WindowBegin time.Time `json:"window_begin"`
+	WindowEnd   time.Time `json:"window_end"`
+	CreatedAt   time.Time `json:"created_at"`
+
+	Acknowledged bool `json:"acknowledged"`
+}
+
+func (NotificationModel) TableName() string {
+	return "statement_watch_notifications"
+}
+
+type credential struct {
+	Username string
+	Password string
+}
+
+// windowStats is the statistics of a digest in a summary window, summed over all instances.
+type windowStats struct {
+	WindowBegin time.Time `gorm:"column:summary_begin_time"`
+	WindowEnd   time.Time `gorm:"column:summary_end_time"`
+	ExecCount   int64     `gorm:"column:exec_count"`
+	// SumLatency is in nanoseconds.
+	SumLatency int64 `gorm:"column:sum_latency"`
+}
+
+const selectWindowStatsSQL = "SELECT summary_begin_time, summary_end_time, " +
+	"SUM(exec_count) AS exec_count, SUM(sum_latency) AS sum_latency " +
+	"FROM INFORMATION_SCHEMA.CLUSTER_STATEMENTS_SUMMARY WHERE digest = ? " +
+	"GROUP BY summary_begin_time, summary_end_time"
+
+type ServiceParams struct {
+	fx.In
+	Config     *config.Config
+	LocalStore *dbstore.DB
+	TiDBClient *tidb.Client
+	HTTPClient *httpc.Client
+	Notifier   *notification.Service
+}
+
+type Service struct {
+	params ServiceParams
+
+	encKey *utils.EncKeyFile
+	// openSQLConn and sendWebhook are replaced in tests.
+	openSQLConn func(c credential) (*gorm.DB, error)
+	sendWebhook func(ctx context.Context, url string, n *NotificationModel) error
+}
+
+func newService(lc fx.Lifecycle, p ServiceParams) (*Service, error) {
+	if err := p.LocalStore.AutoMigrate(&WatchModel{}, &NotificationModel{}); err != nil {
+		return nil, err
+	}
+	s := &Service{
+		params: p,
+		encKey: utils.NewEncKeyFile(path.Join(p.Config.DataDir, "stmt_watch_ek.bin")),
+	}
+	s.openSQLConn = func(c credential) (*gorm.DB, error) {
+		return p.TiDBClient.OpenSQLConn(c.Username, c.Password)
+	}
+	s.sendWebhook = s.postWebhook
+
+	ctx, cancel := context.WithCancel(context.Background())
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go s.evaluateLoop(ctx)
+			return nil
+		},
+		OnStop: func(context.Context) error {
+			cancel()
+			return nil
+		},
+	})
+	return s, nil
+}
+
+var Module = fx.Options(
+	fx.Provide(newService),
+	fx.Invoke(registerRouter),
+)
+
+func validateWebhookURL(webhookURL string) error {
+	if webhookURL == "" {
+		return nil
+	}
+	u, err := url.Parse(webhookURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return rest.ErrBadRequest.New("invalid webhook url %s", webhookURL)
+	}
+	return nil
+}
+
+// Create creates a watch evaluated with the SQL user of the session.
+func (s *Service) Create(u *utils.SessionUser, w *WatchModel) error {
+	if !u.HasTiDBAuth {
+		return rest.ErrBadRequest.New("watches can only be created by sessions signed in with a SQL user")
+	}
+	if w.Digest == "" {
+		return rest.ErrBadRequest.New("digest is required")
+	}
+	if w.MaxAvgLatencyMs < 0 || w.MaxExecCount < 0 {
+		return rest.ErrBadRequest.New("thresholds must not be negative")
+	}
+	if w.MaxAvgLatencyMs == 0 && w.MaxExecCount == 0 {
+		return rest.ErrBadRequest.New("at least one threshold is required")
+	}
+	if err := validateWebhookURL(w.WebhookURL); err != nil {
+		return err
+	}
+
+	key, err := s.encKey.GetOrCreate()
+	if err != nil {
+		return err
+	}
+	plain, err := json.Marshal(credential{Username: u.TiDBUsername, Password: u.TiDBPassword})
+	if err != nil {
+		return err
+	}
+	encrypted, err := cryptopasta.Encrypt(plain, key)
+	if err != nil {
+		return err
+	}
+
+	w.ID = 0
+	w.CreatedBy = u.DisplayName
+	w.CreatedAt = time.Now()
+	w.EncryptedCredential = encrypted
+	return s.params.LocalStore.Create(w).Error
+}
+
+func (s *Service) List() ([]WatchModel, error) {
+	var watches []WatchModel
+	if err := s.params.LocalStore.Order("id").Find(&watches).Error; err != nil {
+		return nil, err
+	}
+	return watches, nil
+}
+
+// Delete deletes a watch with its notifications. It returns false if the watch does not exist.
+func (s *Service) Delete(id uint) (bool, error) {
+	var found bool
+	err := s.params.LocalStore.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ?", id).Delete(&WatchModel{})
+		if result.Error != nil {
+			return result.Error
+		}
+		found = result.RowsAffected > 0
+		return tx.Where("watch_id = ?", id).Delete(&NotificationModel{}).Error
+	})
+	return found, err
+}
+
+func (s *Service) ListNotifications(unacknowledgedOnly bool) ([]NotificationModel, error) {
+	var notifications []NotificationModel
+	db := s.params.LocalStore.Order("id DESC")
+	if unacknowledgedOnly {
+		db = db.Where("acknowledged = ?", false)
+	}
+	if err := db.Find(&notifications).Error; err != nil {
+		return nil, err
+	}
+	return notifications, nil
+}
+
+// Acknowledge marks a notification as acknowledged. It returns false if the notification does not exist.
+func (s *Service) Acknowledge(id uint) (bool, error) {
+	result := s.params.LocalStore.Model(&NotificationModel{}).Where("id = ?", id).Update("acknowledged", true)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// evaluate returns the notifications of thresholds crossed in the windows.
+func evaluate(w *WatchModel, windows []windowStats) []NotificationModel {
+	var notifications []NotificationModel
+	raise := func(win windowStats, metric string, value, threshold float64) {
+		notifications = append(notifications, NotificationModel{
+			WatchID:     w.ID,
+			Digest:      w.Digest,
+			Metric:      metric,
+			Value:       value,
+			Threshold:   threshold,
+			WindowBegin: win.WindowBegin,
+			WindowEnd:   win.WindowEnd,
+		})
+	}
+	for _, win := range windows {
+		if win.ExecCount == 0 {
+			continue
+		}
+		if w.MaxExecCount > 0 && win.ExecCount > w.MaxExecCount {
+			raise(win, MetricExecCount, float64(win.ExecCount), float64(w.MaxExecCount))
+		}
+		avgLatencyMs := float64(win.SumLatency) / float64(win.ExecCount) / float64(time.Millisecond)
+		if w.MaxAvgLatencyMs > 0 && avgLatencyMs > w.MaxAvgLatencyMs {
+			raise(win, MetricAvgLatency, avgLatencyMs, w.MaxAvgLatencyMs)
+		}
+	}
+	return notifications
+}
+
+func (s *Service) evaluateLoop(ctx context.Context) {
+	ticker := time.NewTicker(evaluateInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.evaluateAll(ctx)
+		}
+	}
+}
+
+func (s *Service) evaluateAll(ctx context.Context) {
+	watches, err := s.List()
+	if err != nil {
+		log.Warn("Failed to list statement watches", zap.Error(err))
+		return
+	}
+	for i := range watches {
+		if err := s.evaluateWatch(ctx, &watches[i]); err != nil {
+			log.Warn("Failed to evaluate statement watch", zap.Uint("id", watches[i].ID), zap.Error(err))
+		}
+	}
+}
+
+func (s *Service) evaluateWatch(ctx context.Context, w *WatchModel) error {
+	key, err := s.encKey.GetOrCreate()
+	if err != nil {
+		return err
+	}
+	plain, err := cryptopasta.Decrypt(w.EncryptedCredential, key)
+	if err != nil {
+		return err
+	}
+	var cred credential
+	if err := json.Unmarshal(plain, &cred); err != nil {
+		return err
+	}
+
+	db, err := s.openSQLConn(cred)
+	if err != nil {
+		return err
+	}
+	defer utils.CloseTiDBConnection(db) //nolint:errcheck
+	var windows []windowStats
+	if err := db.WithContext(ctx).Raw(selectWindowStatsSQL, w.Digest).Scan(&windows).Error; err != nil {
+		return err
+	}
+
+	for _, n := range evaluate(w, windows) {
+		n := n
+		// Each threshold is notified once in a window.
+		var count int64
+		err := s.params.LocalStore.Model(&NotificationModel{}).
+			Where("watch_id = ? AND metric = ? AND window_begin = ?", n.WatchID, n.Metric, n.WindowBegin).
+			Count(&count).Error
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			continue
+		}
+		n.CreatedAt = time.Now()
+		if err := s.params.LocalStore.Create(&n).Error; err != nil {
+			return err
+		}
+		s.params.Notifier.Publish(notification.Event{
+			Type:  notification.EventStatementWatchTriggered,
+			Title: "Statement watch triggered",
+			Message: fmt.Sprintf("The %s of statement %s is %.2f in the window from %s, exceeding the threshold %.2f.",
+				n.Metric, n.Digest, n.Value, n.WindowBegin.Format(time.RFC3339), n.Threshold),
+			Details: map[string]interface{}{
+				"watch_id":        n.WatchID,
+				"notification_id": n.ID,
+				"digest":          n.Digest,
+				"metric":          n.Metric,
+				"value":           n.Value,
+				"threshold":       n.Threshold,
+				"window_begin":    n.WindowBegin,
+				"window_end":      n.WindowEnd,
+			},
+		})
+		if w.WebhookURL != "" {
+			if err := s.sendWebhook(ctx, w.WebhookURL, &n); err != nil {
+				log.Warn("Failed to send statement watch webhook", zap.Uint("id", w.ID), zap.Error(err))
+			}
+		}
+	}
+	return nil
+}
+
+func (s *Service) postWebhook(ctx context.Context, url string, n *NotificationModel) error {
+	body, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, webhookTimeout)
+	defer cancel()
+	_, err = s.params.HTTPClient.
+		CloneAndAddRequestHeader("Content-Type", "application/json").
+		SendRequest(ctx, url, http.MethodPost, bytes.NewReader(body), ErrWebhookRequest, "webhook")
+	return err
+}
@@ -0,0 +1,142 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package watch
+
+import (
+	"context"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/utils"
+	"github.com/pingcap/tidb-dashboard/pkg/config"
+	"github.com/pingcap/tidb-dashboard/pkg/dbstore/dbstoretest"
+)
+
+func newTestService(t *testing.T) *Service {
+	dir := t.TempDir()
+	db, err := dbstoretest.Open(dir)
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&WatchModel{}, &NotificationModel{}))
+	return &Service{
+		params: ServiceParams{Config: &config.Config{DataDir: dir}, LocalStore: db},
+		encKey: utils.NewEncKeyFile(path.Join(dir, "stmt_watch_ek.bin")),
+	}
+}
+
+// openTestSummaryDB opens a SQLite database in dir mocking the statement summary of TiDB.
+func openTestSummaryDB(dir string) (*gorm.DB, error) {
+	db, err := gorm.Open(sqlite.Open(path.Join(dir, "tidb.sqlite.db")))
+	if err != nil {
+		return nil, err
+	}
+	// INFORMATION_SCHEMA is attached to the connection, thus only one connection is used.
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	sqlDB.SetMaxOpenConns(1)
+	if err := db.Exec("ATTACH DATABASE ? AS INFORMATION_SCHEMA", path.Join(dir, "is.sqlite.db")).Error; err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+// newTestSummaryDir creates the mocked statement summary of TiDB, and returns its dir.
+func newTestSummaryDir(t *testing.T, windows []windowStats) string {
+	dir := t.TempDir()
+	db, err := openTestSummaryDB(dir)
+	require.NoError(t, err)
+	require.NoError(t, db.Exec("CREATE TABLE INFORMATION_SCHEMA.CLUSTER_STATEMENTS_SUMMARY "+
+		"(instance TEXT, digest TEXT, summary_begin_time DATETIME, summary_end_time DATETIME, exec_count INTEGER, sum_latency INTEGER)").Error)
+	for _, w := range windows {
+		// Each window is split into two instances.
+		for _, instance := range []string{"tidb-0", "tidb-1"} {
+			require.NoError(t, db.Exec("INSERT INTO INFORMATION_SCHEMA.CLUSTER_STATEMENTS_SUMMARY VALUES (?, ?, ?, ?, ?, ?)",
+				instance, "digest-a", w.WindowBegin, w.WindowEnd, w.ExecCount/2, w.SumLatency/2).Error)
+		}
+	}
+	require.NoError(t, utils.CloseTiDBConnection(db))
+	return dir
+}
+
+func TestEvaluate(t *testing.T) {
+	begin := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	w := &WatchModel{ID: 1, Digest: "digest-a", MaxAvgLatencyMs: 100, MaxExecCount: 1000}
+	notifications := evaluate(w, []windowStats{
+		{WindowBegin: begin, ExecCount: 10, SumLatency: int64(10 * 50 * time.Millisecond)},
+		{WindowBegin: begin.Add(30 * time.Minute), ExecCount: 2000, SumLatency: int64(2000 * 150 * time.Millisecond)},
+		{WindowBegin: begin.Add(time.Hour)},
+	})
+	require.Len(t, notifications, 2)
+	require.Equal(t, MetricExecCount, notifications[0].Metric)
+	require.Equal(t, float64(2000), notifications[0].Value)
+	require.Equal(t, float64(1000), notifications[0].Threshold)
+	require.Equal(t, MetricAvgLatency, notifications[1].Metric)
+	require.InDelta(t, 150, notifications[1].Value, 0.001)
+	require.Equal(t, begin.Add(30*time.Minute), notifications[1].WindowBegin)
+}
+
+func TestCreateValidation(t *testing.T) {
+	s := newTestService(t)
+	u := &utils.SessionUser{HasTiDBAuth: true, TiDBUsername: "root"}
+
+	require.Error(t, s.Create(&utils.SessionUser{}, &WatchModel{Digest: "digest-a", MaxExecCount: 1}))
+	require.Error(t, s.Create(u, &WatchModel{Digest: "digest-a"}))
+	require.Error(t, s.Create(u, &WatchModel{Digest: "digest-a", MaxExecCount: -1}))
+	require.Error(t, s.Create(u, &WatchModel{Digest: "digest-a", MaxExecCount: 1, WebhookURL: "ftp://example.com"}))
+	require.NoError(t, s.Create(u, &WatchModel{Digest: "digest-a", MaxExecCount: 1, WebhookURL: "https://example.com/hook"}))
+}
+
+func TestEvaluateWatch(t *testing.T) {
+	s := newTestService(t)
+	begin := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	summaryDir := newTestSummaryDir(t, []windowStats{
+		{WindowBegin: begin, WindowEnd: begin.Add(30 * time.Minute), ExecCount: 2000, SumLatency: int64(2000 * time.Millisecond)},
+	})
+	var usedCredential credential
+	s.openSQLConn = func(c credential) (*gorm.DB, error) {
+		usedCredential = c
+		return openTestSummaryDB(summaryDir)
+	}
+	var webhooks []NotificationModel
+	s.sendWebhook = func(ctx context.Context, url string, n *NotificationModel) error {
+		webhooks = append(webhooks, *n)
+		return nil
+	}
+
+	u := &utils.SessionUser{DisplayName: "root", HasTiDBAuth: true, TiDBUsername: "root", TiDBPassword: "secret"}
+	w := &WatchModel{Digest: "digest-a", MaxExecCount: 1000, WebhookURL: "https://example.com/hook"}
+	require.NoError(t, s.Create(u, w))
+
+	// Evaluating again does not notify the same window twice.
+	require.NoError(t, s.evaluateWatch(context.Background(), w))
+	require.NoError(t, s.evaluateWatch(context.Background(), w))
+	require.Equal(t, credential{Username: "root", Password: "secret"}, usedCredential)
+	require.Len(t, webhooks, 1)
+
+	notifications, err := s.ListNotifications(true)
+	require.NoError(t, err)
+	require.Len(t, notifications, 1)
+	require.Equal(t, MetricExecCount, notifications[0].Metric)
+	require.Equal(t, float64(2000), notifications[0].Value)
+	require.True(t, begin.Equal(notifications[0].WindowBegin))
+
+	found, err := s.Acknowledge(notifications[0].ID)
+	require.NoError(t, err)
+	require.True(t, found)
+	notifications, err = s.ListNotifications(true)
+	require.NoError(t, err)
+	require.Empty(t, notifications)
+
+	found, err = s.Delete(w.ID)
+	require.NoError(t, err)
+	require.True(t, found)
+	notifications, err = s.ListNotifications(false)
+	require.NoError(t, err)
+	require.Empty(t, notifications)
+}
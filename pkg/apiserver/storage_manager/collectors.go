@@ -0,0 +1,255 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package storagemanager
+
+import (
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/diagnose"
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/logsearch"
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/profiling"
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/statement/history"
+	"github.com/pingcap/tidb-dashboard/pkg/dbstore"
+	"github.com/pingcap/tidb-dashboard/pkg/keyvisual/storage"
+)
+
+const (
+	// Reports not finished within this time are considered failed, so that they can be removed.
+	reportGenerationTimeout = 24 * time.Hour
+	// snapshotRowBytes is the estimated size of the numeric columns of a statement history snapshot.
+	snapshotRowBytes = 128
+)
+
+func parseUintIDs(ids []string) []uint {
+	result := make([]uint, 0, len(ids))
+	for _, id := range ids {
+		if v, err := strconv.ParseUint(id, 10, 64); err == nil {
+			result = append(result, uint(v))
+		}
+	}
+	return result
+}
+
+func fileSize(path string) int64 {
+	if path == "" {
+		return 0
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
+
+// profilingCollector collects profiling task groups together with their profile files.
+type profilingCollector struct {
+	db *dbstore.DB
+}
+
+func (c profilingCollector) Items() ([]Item, error) {
+	var groups []profiling.TaskGroupModel
+	if err := c.db.Find(&groups).Error; err != nil {
+		return nil, err
+	}
+	var tasks []profiling.TaskModel
+	if err := c.db.Select("task_group_id, file_path").Find(&tasks).Error; err != nil {
+		return nil, err
+	}
+	sizes := make(map[uint]int64, len(groups))
+	for _, t := range tasks {
+		sizes[t.TaskGroupID] += fileSize(t.FilePath)
+	}
+	items := make([]Item, 0, len(groups))
+	for _, g := range groups {
+		items = append(items, Item{
+			ID:        strconv.FormatUint(uint64(g.ID), 10),
+			CreatedAt: time.Unix(g.StartedAt, 0),
+			Size:      sizes[g.ID],
+			InUse:     g.State == profiling.TaskStateRunning,
+		})
+	}
+	return items, nil
+}
+
+func (c profilingCollector) Remove(ids []string) error {
+	groupIDs := parseUintIDs(ids)
+	var tasks []profiling.TaskModel
+	if err := c.db.Where("task_group_id IN ?", groupIDs).Find(&tasks).Error; err != nil {
+		return err
+	}
+	for _, t := range tasks {
+		if t.FilePath != "" {
+			_ = os.Remove(t.FilePath)
+		}
+	}
+	if err := c.db.Where("task_group_id IN ?", groupIDs).Delete(&profiling.TaskModel{}).Error; err != nil {
+		return err
+	}
+	return c.db.Where("id IN ?", groupIDs).Delete(&profiling.TaskGroupModel{}).Error
+}
+
+// logSearchCollector collects log search task groups together with their downloaded logs.
+type logSearchCollector struct {
+	db *dbstore.DB
+}
+
+func (c logSearchCollector) Items() ([]Item, error) {
+	var groups []logsearch.TaskGroupModel
+	if err := c.db.Find(&groups).Error; err != nil {
+		return nil, err
+	}
+	var tasks []logsearch.TaskModel
+	if err := c.db.Select("task_group_id, size").Find(&tasks).Error; err != nil {
+		return nil, err
+	}
+	sizes := make(map[uint]int64, len(groups))
+	for _, t := range tasks {
+		sizes[t.TaskGroupID] += t.Size
+	}
+	items := make([]Item, 0, len(groups))
+	for _, g := range groups {
+		item := Item{
+			ID:    strconv.FormatUint(uint64(g.ID), 10),
+			Size:  sizes[g.ID],
+			InUse: g.State == logsearch.TaskGroupStateRunning,
+		}
+		// Task groups do not record the creation time, so that the time of the log directory is used.
+		if g.LogStoreDir != nil {
+			if info, err := os.Stat(*g.LogStoreDir); err == nil {
+				item.CreatedAt = info.ModTime()
+			}
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func (c logSearchCollector) Remove(ids []string) error {
+	var groups []*logsearch.TaskGroupModel
+	if err := c.db.Where("id IN ?", parseUintIDs(ids)).Find(&groups).Error; err != nil {
+		return err
+	}
+	for _, g := range groups {
+		g.Delete(c.db)
+	}
+	return nil
+}
+
+// diagnoseReportCollector collects diagnosis reports.
+type diagnoseReportCollector struct {
+	db *dbstore.DB
+}
+
+func (c diagnoseReportCollector) Items() ([]Item, error) {
+	var reports []struct {
+		ID        string
+		CreatedAt time.Time
+		Progress  int
+		Size      int64
+	}
+	err := c.db.
+		Model(&diagnose.Report{}).
+		Select("id, created_at, progress, LENGTH(content) AS size").
+		Scan(&reports).Error
+	if err != nil {
+		return nil, err
+	}
+	items := make([]Item, 0, len(reports))
+	for _, r := range reports {
+		items = append(items, Item{
+			ID:        r.ID,
+			CreatedAt: r.CreatedAt,
+			Size:      r.Size,
+			InUse:     r.Progress < 100 && time.Since(r.CreatedAt) < reportGenerationTimeout,
+		})
+	}
+	return items, nil
+}
+
+func (c diagnoseReportCollector) Remove(ids []string) error {
+	return c.db.Where("id IN ?", ids).Delete(&diagnose.Report{}).Error
+}
+
+// keyVisualCollector collects persisted axes of the key visualizer heatmap. Axes are also removed by the key
+// visualizer itself when they are compacted, so that only stale axes are expected to be collected.
+type keyVisualCollector struct {
+	db *dbstore.DB
+}
+
+func (c keyVisualCollector) Items() ([]Item, error) {
+	if !c.db.Migrator().HasTable(&storage.AxisModel{}) {
+		return nil, nil
+	}
+	// Axes do not have a primary key, so that they are identified by the row ID of SQLite.
+	var axes []struct {
+		RowID int64
+		Time  time.Time
+		Size  int64
+	}
+	err := c.db.
+		Model(&storage.AxisModel{}).
+		Select("rowid AS row_id, time, LENGTH(axis) AS size").
+		Scan(&axes).Error
+	if err != nil {
+		return nil, err
+	}
+	items := make([]Item, 0, len(axes))
+	for _, a := range axes {
+		items = append(items, Item{
+			ID:        strconv.FormatInt(a.RowID, 10),
+			CreatedAt: a.Time,
+			Size:      a.Size,
+		})
+	}
+	return items, nil
+}
+
+func (c keyVisualCollector) Remove(ids []string) error {
+	return c.db.Where("rowid IN ?", parseUintIDs(ids)).Delete(&storage.AxisModel{}).Error
+}
+
+// statementHistoryCollector collects the persisted statement summary history. Snapshots of a summary window are
+// an item, identified by the begin time of the window.
+type statementHistoryCollector struct {
+	db *dbstore.DB
+}
+
+func (c statementHistoryCollector) Items() ([]Item, error) {
+	if !c.db.Migrator().HasTable(&history.SnapshotModel{}) {
+		return nil, nil
+	}
+	var windows []struct {
+		BeginTime int64
+		RowCount  int64
+		TextSize  int64
+	}
+	err := c.db.
+		Model(&history.SnapshotModel{}).
+		Select("begin_time, COUNT(*) AS row_count, SUM(LENGTH(digest_text)) AS text_size").
+		Group("begin_time").
+		Scan(&windows).Error
+	if err != nil {
+		return nil, err
+	}
+	items := make([]Item, 0, len(windows))
+	for _, w := range windows {
+		items = append(items, Item{
+			ID:        strconv.FormatInt(w.BeginTime, 10),
+			CreatedAt: time.Unix(w.BeginTime, 0),
+			Size:      w.TextSize + w.RowCount*snapshotRowBytes,
+		})
+	}
+	return items, nil
+}
+
+func (c statementHistoryCollector) Remove(ids []string) error {
+	beginTimes := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		if v, err := strconv.ParseInt(id, 10, 64); err == nil {
+			beginTimes = append(beginTimes, v)
+		}
+	}
+	return c.db.Where("begin_time IN ?", beginTimes).Delete(&history.SnapshotModel{}).Error
+}
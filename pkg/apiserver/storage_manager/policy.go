@@ -0,0 +1,57 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package storagemanager
+
+import (
+	"sort"
+	"time"
+)
+
+// Item is a unit of data kept by a feature, e.g. a profiling task group, which is removed as a whole.
+type Item struct {
+	ID        string
+	CreatedAt time.Time
+	Size      int64
+	// InUse items, e.g. running tasks, are counted in the usage but never removed.
+	InUse bool
+}
+
+// Collector lists and removes the data of a feature kept in the local storage.
+type Collector interface {
+	Items() ([]Item, error)
+	Remove(ids []string) error
+}
+
+// Policy limits the data kept by a feature. Zero means unlimited.
+type Policy struct {
+	MaxAge   time.Duration
+	MaxBytes int64
+}
+
+// selectExpiredItems returns the items to be removed by the policy, oldest first. Items older than the max age
+// are removed, after which the oldest items are removed until the total size is within the max bytes.
+func selectExpiredItems(items []Item, p Policy, now time.Time) []Item {
+	sorted := append([]Item(nil), items...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].CreatedAt.Before(sorted[j].CreatedAt)
+	})
+
+	var totalSize int64
+	for _, item := range sorted {
+		totalSize += item.Size
+	}
+	expired := make([]Item, 0)
+	for _, item := range sorted {
+		if item.InUse {
+			continue
+		}
+		tooOld := p.MaxAge > 0 && now.Sub(item.CreatedAt) > p.MaxAge
+		tooLarge := p.MaxBytes > 0 && totalSize > p.MaxBytes
+		if !tooOld && !tooLarge {
+			continue
+		}
+		expired = append(expired, item)
+		totalSize -= item.Size
+	}
+	return expired
+}
@@ -0,0 +1,233 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package storagemanager
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/joomcode/errorx"
+	"github.com/pingcap/log"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/audit"
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/user"
+	"github.com/pingcap/tidb-dashboard/pkg/dbstore"
+	"github.com/pingcap/tidb-dashboard/util/rest"
+)
+
+const gcInterval = time.Hour
+
+const (
+	FeatureProfiling      = "profiling"
+	FeatureLogSearch      = "log_search"
+	FeatureDiagnoseReport = "diagnose_report"
+	FeatureKeyVisual      = "key_visual"
+	// FeatureStatementHistory is the statement summary history persisted by the collector.
+	FeatureStatementHistory = "statement_history"
+)
+
+var (
+	ErrNS        = errorx.NewNamespace("error.api.storage_manager")
+	ErrGCFailed  = ErrNS.NewType("gc_failed")
+	ErrListItems = ErrNS.NewType("list_items_failed")
+)
+
+// feature is a feature keeping data in the local storage, with its retention policy.
+type feature struct {
+	name      string
+	policy    Policy
+	collector Collector
+}
+
+type Service struct {
+	features []feature
+	// gcMu prevents the background GC and manual GC from running at the same time.
+	gcMu sync.Mutex
+	now  func() time.Time
+}
+
+func newService(lc fx.Lifecycle, db *dbstore.DB) *Service {
+	s := &Service{
+		features: []feature{
+			{name: FeatureProfiling, policy: Policy{MaxAge: 7 * 24 * time.Hour, MaxBytes: 1 << 30}, collector: profilingCollector{db: db}},
+			{name: FeatureLogSearch, policy: Policy{MaxAge: 7 * 24 * time.Hour, MaxBytes: 2 << 30}, collector: logSearchCollector{db: db}},
+			{name: FeatureDiagnoseReport, policy: Policy{MaxAge: 30 * 24 * time.Hour, MaxBytes: 512 << 20}, collector: diagnoseReportCollector{db: db}},
+			{name: FeatureKeyVisual, policy: Policy{MaxAge: 30 * 24 * time.Hour}, collector: keyVisualCollector{db: db}},
+			{name: FeatureStatementHistory, policy: Policy{MaxAge: 30 * 24 * time.Hour, MaxBytes: 1 << 30}, collector: statementHistoryCollector{db: db}},
+		},
+		now: time.Now,
+	}
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			go s.gcLoop(ctx)
+			return nil
+		},
+	})
+	return s
+}
+
+func registerRouter(r *gin.RouterGroup, auth *user.AuthService, a *audit.Service, s *Service) {
+	endpoint := r.Group("/storage")
+	endpoint.Use(auth.MWAuthRequired())
+	endpoint.GET("/usage", s.getUsage)
+	endpoint.POST("/gc", auth.MWRequireWritePriv(), a.MWRecord("storage.gc"), s.runGC)
+}
+
+func (s *Service) gcLoop(ctx context.Context) {
+	ticker := time.NewTicker(gcInterval)
+	defer ticker.Stop()
+	for {
+		for _, r := range s.gc("") {
+			if r.Error != nil {
+				log.Warn("Failed to collect outdated data", zap.String("feature", r.Feature), zap.String("error", r.Error.Message))
+			} else if r.RemovedItems > 0 {
+				log.Info("Collected outdated data", zap.String("feature", r.Feature), zap.Int("items", r.RemovedItems), zap.Int64("bytes", r.RemovedBytes))
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+type FeatureUsage struct {
+	Feature string `json:"feature"`
+	Items   int    `json:"items"`
+	Bytes   int64  `json:"bytes"`
+	// OldestTime is the creation time of the oldest item in unix seconds, or 0 when there are no items.
+	OldestTime int64 `json:"oldest_time"`
+	// MaxAgeSecs and MaxBytes are the retention policy of the feature. Zero means unlimited.
+	MaxAgeSecs int64               `json:"max_age_secs"`
+	MaxBytes   int64               `json:"max_bytes"`
+	Error      *rest.ErrorResponse `json:"error,omitempty"`
+}
+
+type GCResult struct {
+	Feature      string              `json:"feature"`
+	RemovedItems int                 `json:"removed_items"`
+	RemovedBytes int64               `json:"removed_bytes"`
+	Error        *rest.ErrorResponse `json:"error,omitempty"`
+}
+
+func newErrorResponse(err error) *rest.ErrorResponse {
+	r := rest.NewErrorResponse(err)
+	return &r
+}
+
+func (s *Service) usage() []FeatureUsage {
+	result := make([]FeatureUsage, 0, len(s.features))
+	for _, f := range s.features {
+		u := FeatureUsage{
+			Feature:    f.name,
+			MaxAgeSecs: int64(f.policy.MaxAge / time.Second),
+			MaxBytes:   f.policy.MaxBytes,
+		}
+		items, err := f.collector.Items()
+		if err != nil {
+			u.Error = newErrorResponse(ErrListItems.WrapWithNoMessage(err))
+		}
+		for _, item := range items {
+			u.Items++
+			u.Bytes += item.Size
+			if t := item.CreatedAt.Unix(); u.OldestTime == 0 || t < u.OldestTime {
+				u.OldestTime = t
+			}
+		}
+		result = append(result, u)
+	}
+	return result
+}
+
+// gc removes the data exceeding the retention policy of the feature, or of all features when the feature is empty.
+func (s *Service) gc(featureName string) []GCResult {
+	s.gcMu.Lock()
+	defer s.gcMu.Unlock()
+
+	now := s.now()
+	result := make([]GCResult, 0, len(s.features))
+	for _, f := range s.features {
+		if featureName != "" && f.name != featureName {
+			continue
+		}
+		r := GCResult{Feature: f.name}
+		items, err := f.collector.Items()
+		if err != nil {
+			r.Error = newErrorResponse(ErrListItems.WrapWithNoMessage(err))
+			result = append(result, r)
+			continue
+		}
+		expired := selectExpiredItems(items, f.policy, now)
+		if len(expired) == 0 {
+			result = append(result, r)
+			continue
+		}
+		ids := make([]string, 0, len(expired))
+		for _, item := range expired {
+			ids = append(ids, item.ID)
+			r.RemovedBytes += item.Size
+		}
+		if err := f.collector.Remove(ids); err != nil {
+			r.RemovedBytes = 0
+			r.Error = newErrorResponse(ErrGCFailed.WrapWithNoMessage(err))
+		} else {
+			r.RemovedItems = len(ids)
+		}
+		result = append(result, r)
+	}
+	return result
+}
+
+func (s *Service) hasFeature(name string) bool {
+	for _, f := range s.features {
+		if f.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// @ID storageGetUsage
+// @Summary Get the local storage usage and retention policy of each feature
+// @Success 200 {array} FeatureUsage
+// @Router /storage/usage [get]
+// @Security JwtAuth
+// @Failure 401 {object} rest.ErrorResponse
+func (s *Service) getUsage(c *gin.Context) {
+	c.JSON(http.StatusOK, s.usage())
+}
+
+type RunGCRequest struct {
+	// Feature is the feature to collect. All features are collected when empty.
+	Feature string `json:"feature" form:"feature"`
+}
+
+// @ID storageRunGC
+// @Summary Remove the data exceeding the retention policy immediately
+// @Description Data is also collected in background every hour.
+// @Param q query RunGCRequest true "Query"
+// @Success 200 {array} GCResult
+// @Router /storage/gc [post]
+// @Security JwtAuth
+// @Failure 400 {object} rest.ErrorResponse
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 403 {object} rest.ErrorResponse
+func (s *Service) runGC(c *gin.Context) {
+	var req RunGCRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		rest.Error(c, rest.ErrBadRequest.NewWithNoMessage())
+		return
+	}
+	if req.Feature != "" && !s.hasFeature(req.Feature) {
+		rest.Error(c, rest.ErrBadRequest.New("unknown feature %s", req.Feature))
+		return
+	}
+	c.JSON(http.StatusOK, s.gc(req.Feature))
+}
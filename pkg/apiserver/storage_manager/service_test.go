@@ -0,0 +1,92 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package storagemanager
+
+import (
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/diagnose"
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/profiling"
+	"github.com/pingcap/tidb-dashboard/pkg/dbstore"
+	"github.com/pingcap/tidb-dashboard/pkg/dbstore/dbstoretest"
+)
+
+func itemIDs(items []Item) []string {
+	ids := make([]string, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.ID)
+	}
+	return ids
+}
+
+func TestSelectExpiredItems(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	items := []Item{
+		{ID: "new", CreatedAt: now.Add(-time.Hour), Size: 30},
+		{ID: "old", CreatedAt: now.Add(-10 * 24 * time.Hour), Size: 10},
+		{ID: "running", CreatedAt: now.Add(-20 * 24 * time.Hour), Size: 50, InUse: true},
+		{ID: "mid", CreatedAt: now.Add(-2 * 24 * time.Hour), Size: 20},
+	}
+
+	require.Empty(t, selectExpiredItems(items, Policy{}, now))
+	require.Equal(t, []string{"old"}, itemIDs(selectExpiredItems(items, Policy{MaxAge: 7 * 24 * time.Hour}, now)))
+	// Items in use are counted in the size, but never removed.
+	require.Equal(t, []string{"old", "mid"}, itemIDs(selectExpiredItems(items, Policy{MaxBytes: 80}, now)))
+	require.Equal(t, []string{"old", "mid", "new"}, itemIDs(selectExpiredItems(items, Policy{MaxBytes: 10}, now)))
+}
+
+func newTestDB(t *testing.T) *dbstore.DB {
+	db := dbstoretest.New(t)
+	require.NoError(t, db.AutoMigrate(&profiling.TaskGroupModel{}, &profiling.TaskModel{}, &diagnose.Report{}))
+	return db
+}
+
+func TestGC(t *testing.T) {
+	db := newTestDB(t)
+	now := time.Now()
+
+	profilePath := path.Join(t.TempDir(), "cpu.proto")
+	require.NoError(t, os.WriteFile(profilePath, make([]byte, 100), 0o600))
+	require.NoError(t, db.Create(&[]profiling.TaskGroupModel{
+		{ID: 1, State: profiling.TaskStateFinish, StartedAt: now.Add(-8 * 24 * time.Hour).Unix()},
+		{ID: 2, State: profiling.TaskStateRunning, StartedAt: now.Add(-8 * 24 * time.Hour).Unix()},
+	}).Error)
+	require.NoError(t, db.Create(&profiling.TaskModel{TaskGroupID: 1, FilePath: profilePath}).Error)
+	require.NoError(t, db.Create(&diagnose.Report{ID: "r1", CreatedAt: now, Progress: 100, Content: "report"}).Error)
+
+	s := &Service{
+		features: []feature{
+			{name: FeatureProfiling, policy: Policy{MaxAge: 7 * 24 * time.Hour}, collector: profilingCollector{db: db}},
+			{name: FeatureDiagnoseReport, policy: Policy{MaxAge: 7 * 24 * time.Hour}, collector: diagnoseReportCollector{db: db}},
+		},
+		now: time.Now,
+	}
+
+	usage := s.usage()
+	require.Len(t, usage, 2)
+	require.Equal(t, 2, usage[0].Items)
+	require.Equal(t, int64(100), usage[0].Bytes)
+	require.Equal(t, 1, usage[1].Items)
+	require.Equal(t, int64(len("report")), usage[1].Bytes)
+
+	require.Equal(t, []GCResult{
+		{Feature: FeatureProfiling, RemovedItems: 1, RemovedBytes: 100},
+	}, s.gc(FeatureProfiling))
+	_, err := os.Stat(profilePath)
+	require.True(t, os.IsNotExist(err))
+
+	var groups []profiling.TaskGroupModel
+	require.NoError(t, db.Find(&groups).Error)
+	require.Len(t, groups, 1)
+	require.Equal(t, uint(2), groups[0].ID)
+
+	require.Equal(t, []GCResult{
+		{Feature: FeatureProfiling},
+		{Feature: FeatureDiagnoseReport},
+	}, s.gc(""))
+}
@@ -0,0 +1,74 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package ticdc
+
+import "time"
+
+// The following are the subset of the TiCDC open API v2 responses used by the dashboard.
+
+type cdcCaptures struct {
+	Items []struct {
+		ID      string `json:"id"`
+		IsOwner bool   `json:"is_owner"`
+		Address string `json:"address"`
+	} `json:"items"`
+}
+
+type cdcRunningError struct {
+	Time    string `json:"time"`
+	Addr    string `json:"addr"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type cdcChangefeed struct {
+	Namespace     string           `json:"namespace"`
+	ID            string           `json:"id"`
+	State         string           `json:"state"`
+	CheckpointTSO uint64           `json:"checkpoint_tso"`
+	Error         *cdcRunningError `json:"error"`
+}
+
+type cdcChangefeeds struct {
+	Items []cdcChangefeed `json:"items"`
+}
+
+type cdcChangefeedDetail struct {
+	Namespace    string `json:"namespace"`
+	ID           string `json:"id"`
+	State        string `json:"state"`
+	CheckpointTS uint64 `json:"checkpoint_ts"`
+	ResolvedTS   uint64 `json:"resolved_ts"`
+	TaskStatus   []struct {
+		CaptureID string  `json:"capture_id"`
+		TableIDs  []int64 `json:"table_ids"`
+	} `json:"task_status"`
+}
+
+type Changefeed struct {
+	Namespace      string    `json:"namespace"`
+	ID             string    `json:"id"`
+	State          string    `json:"state"`
+	CheckpointTSO  uint64    `json:"checkpoint_tso"`
+	CheckpointTime time.Time `json:"checkpoint_time"`
+	// CheckpointLagSeconds is how far the checkpoint falls behind the current time.
+	CheckpointLagSeconds float64 `json:"checkpoint_lag_seconds"`
+	Error                string  `json:"error,omitempty"`
+}
+
+type TableReplication struct {
+	TableID int64 `json:"table_id"`
+	// DB and Table are empty when the table is no longer found in TiDB, e.g. dropped.
+	DB        string `json:"db"`
+	Table     string `json:"table"`
+	CaptureID string `json:"capture_id"`
+}
+
+type GetTablesResponse struct {
+	Namespace      string             `json:"namespace"`
+	ID             string             `json:"id"`
+	State          string             `json:"state"`
+	CheckpointTime time.Time          `json:"checkpoint_time"`
+	ResolvedTime   time.Time          `json:"resolved_time"`
+	Tables         []TableReplication `json:"tables"`
+}
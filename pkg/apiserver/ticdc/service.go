@@ -0,0 +1,291 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package ticdc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/joomcode/errorx"
+	"go.uber.org/fx"
+	"gorm.io/gorm"
+
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/audit"
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/cluster"
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/user"
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/utils"
+	"github.com/pingcap/tidb-dashboard/pkg/pd"
+	cdc "github.com/pingcap/tidb-dashboard/pkg/ticdc"
+	"github.com/pingcap/tidb-dashboard/pkg/tidb"
+	"github.com/pingcap/tidb-dashboard/pkg/utils/topology"
+	"github.com/pingcap/tidb-dashboard/util/netutil"
+	"github.com/pingcap/tidb-dashboard/util/rest"
+)
+
+const defaultNamespace = "default"
+
+var (
+	ErrNS              = errorx.NewNamespace("error.api.ticdc")
+	ErrOwnerNotFound   = ErrNS.NewType("owner_not_found")
+	ErrTiCDCRequest    = ErrNS.NewType("ticdc_request_failed")
+	ErrResolveFailed   = ErrNS.NewType("resolve_table_failed")
+	ErrInvalidResponse = ErrNS.NewType("invalid_response")
+)
+
+type ServiceParams struct {
+	fx.In
+	EtcdClients *pd.EtcdClientManager
+	TiCDCClient *cdc.Client
+	TiDBClient  *tidb.Client
+	Registry    *cluster.Registry
+}
+
+type Service struct {
+	params       ServiceParams
+	lifecycleCtx context.Context
+}
+
+func newService(lc fx.Lifecycle, p ServiceParams) *Service {
+	s := &Service{params: p}
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			s.lifecycleCtx = ctx
+			return nil
+		},
+	})
+	return s
+}
+
+func registerRouter(r *gin.RouterGroup, auth *user.AuthService, a *audit.Service, s *Service) {
+	endpoint := r.Group("/ticdc")
+	endpoint.Use(auth.MWAuthRequired(), s.params.Registry.MWResolveCluster())
+	{
+		endpoint.GET("/changefeeds", s.getChangefeeds)
+		endpoint.GET("/changefeeds/:id/tables", utils.MWConnectTiDB(s.params.TiDBClient), s.getChangefeedTables)
+		endpoint.POST("/changefeeds/:id/pause", auth.MWRequireWritePriv(), a.MWRecord("ticdc.pause"), s.pauseChangefeed)
+		endpoint.POST("/changefeeds/:id/resume", auth.MWRequireWritePriv(), a.MWRecord("ticdc.resume"), s.resumeChangefeed)
+	}
+}
+
+// tsoToTime returns the physical time of a TSO.
+func tsoToTime(tso uint64) time.Time {
+	return time.UnixMilli(int64(tso >> 18))
+}
+
+// findOwner returns the address of the TiCDC owner of the cluster selected in the session. Any live capture knows
+// the owner.
+func (s *Service) findOwner(c *gin.Context) (string, error) {
+	captures, err := topology.FetchTiCDCTopology(s.lifecycleCtx, cluster.EtcdClientOf(c, s.params.EtcdClients))
+	if err != nil {
+		return "", err
+	}
+	var lastErr error
+	for _, capture := range captures {
+		data, err := s.params.TiCDCClient.SendGetRequest(capture.IP, int(capture.StatusPort), "/api/v2/captures")
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		var resp cdcCaptures
+		if err := json.Unmarshal(data, &resp); err != nil {
+			lastErr = ErrInvalidResponse.Wrap(err, "failed to parse TiCDC captures")
+			continue
+		}
+		for _, item := range resp.Items {
+			if item.IsOwner {
+				return item.Address, nil
+			}
+		}
+	}
+	if lastErr != nil {
+		return "", ErrTiCDCRequest.Wrap(lastErr, "failed to find TiCDC owner")
+	}
+	return "", ErrOwnerNotFound.New("TiCDC owner is not found").WithProperty(rest.HTTPCodeProperty(http.StatusNotFound))
+}
+
+func (s *Service) sendToOwner(c *gin.Context, method, path string, query url.Values, body string) ([]byte, error) {
+	owner, err := s.findOwner(c)
+	if err != nil {
+		return nil, err
+	}
+	host, port, err := netutil.ParseHostAndPortFromAddress(owner)
+	if err != nil {
+		return nil, ErrInvalidResponse.Wrap(err, "invalid TiCDC owner address %s", owner)
+	}
+	uri := path
+	if len(query) > 0 {
+		uri += "?" + query.Encode()
+	}
+	if method == http.MethodPost {
+		return s.params.TiCDCClient.SendPostRequest(host, int(port), uri, strings.NewReader(body))
+	}
+	return s.params.TiCDCClient.SendGetRequest(host, int(port), uri)
+}
+
+func namespaceQuery(c *gin.Context) url.Values {
+	return url.Values{"namespace": []string{c.DefaultQuery("namespace", defaultNamespace)}}
+}
+
+func changefeedPath(c *gin.Context, action string) string {
+	return "/api/v2/changefeeds/" + url.PathEscape(c.Param("id")) + action
+}
+
+func toChangefeed(cf cdcChangefeed, now time.Time) Changefeed {
+	r := Changefeed{
+		Namespace:     cf.Namespace,
+		ID:            cf.ID,
+		State:         cf.State,
+		CheckpointTSO: cf.CheckpointTSO,
+	}
+	if cf.CheckpointTSO > 0 {
+		r.CheckpointTime = tsoToTime(cf.CheckpointTSO)
+		r.CheckpointLagSeconds = now.Sub(r.CheckpointTime).Seconds()
+	}
+	if cf.Error != nil {
+		r.Error = cf.Error.Message
+	}
+	return r
+}
+
+// @ID ticdcGetChangefeeds
+// @Summary List changefeeds with their checkpoint lag
+// @Success 200 {array} Changefeed
+// @Router /ticdc/changefeeds [get]
+// @Security JwtAuth
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 404 {object} rest.ErrorResponse
+func (s *Service) getChangefeeds(c *gin.Context) {
+	data, err := s.sendToOwner(c, http.MethodGet, "/api/v2/changefeeds", nil, "")
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	var resp cdcChangefeeds
+	if err := json.Unmarshal(data, &resp); err != nil {
+		rest.Error(c, ErrInvalidResponse.Wrap(err, "failed to parse TiCDC changefeeds"))
+		return
+	}
+	now := time.Now()
+	result := make([]Changefeed, 0, len(resp.Items))
+	for _, cf := range resp.Items {
+		result = append(result, toChangefeed(cf, now))
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// @ID ticdcGetChangefeedTables
+// @Summary Get the replication status of each table in a changefeed
+// @Param id path string true "Changefeed ID"
+// @Param namespace query string false "Changefeed namespace"
+// @Success 200 {object} GetTablesResponse
+// @Router /ticdc/changefeeds/{id}/tables [get]
+// @Security JwtAuth
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 404 {object} rest.ErrorResponse
+func (s *Service) getChangefeedTables(c *gin.Context) {
+	data, err := s.sendToOwner(c, http.MethodGet, changefeedPath(c, ""), namespaceQuery(c), "")
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	var detail cdcChangefeedDetail
+	if err := json.Unmarshal(data, &detail); err != nil {
+		rest.Error(c, ErrInvalidResponse.Wrap(err, "failed to parse TiCDC changefeed"))
+		return
+	}
+
+	tables := make([]TableReplication, 0)
+	tableIDs := make([]int64, 0)
+	for _, task := range detail.TaskStatus {
+		for _, id := range task.TableIDs {
+			tables = append(tables, TableReplication{TableID: id, CaptureID: task.CaptureID})
+			tableIDs = append(tableIDs, id)
+		}
+	}
+	names, err := resolveTableNames(utils.GetTiDBConnection(c), tableIDs)
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	for i := range tables {
+		if name, ok := names[tables[i].TableID]; ok {
+			tables[i].DB, tables[i].Table = name[0], name[1]
+		}
+	}
+	sort.Slice(tables, func(i, j int) bool {
+		return tables[i].TableID < tables[j].TableID
+	})
+
+	c.JSON(http.StatusOK, GetTablesResponse{
+		Namespace:      detail.Namespace,
+		ID:             detail.ID,
+		State:          detail.State,
+		CheckpointTime: tsoToTime(detail.CheckpointTS),
+		ResolvedTime:   tsoToTime(detail.ResolvedTS),
+		Tables:         tables,
+	})
+}
+
+// resolveTableNames returns the schema and table name of each table or partition ID.
+func resolveTableNames(db *gorm.DB, ids []int64) (map[int64][2]string, error) {
+	names := make(map[int64][2]string)
+	if len(ids) == 0 {
+		return names, nil
+	}
+	var rows []struct {
+		ID     int64  `gorm:"column:ID"`
+		Schema string `gorm:"column:TABLE_SCHEMA"`
+		Table  string `gorm:"column:TABLE_NAME"`
+	}
+	err := db.Raw(`SELECT TIDB_TABLE_ID AS ID, TABLE_SCHEMA, TABLE_NAME FROM INFORMATION_SCHEMA.TABLES WHERE TIDB_TABLE_ID IN (?)
+		UNION ALL
+		SELECT TIDB_PARTITION_ID AS ID, TABLE_SCHEMA, CONCAT(TABLE_NAME, '/', PARTITION_NAME) AS TABLE_NAME FROM INFORMATION_SCHEMA.PARTITIONS WHERE TIDB_PARTITION_ID IN (?)`,
+		ids, ids).Scan(&rows).Error
+	if err != nil {
+		return nil, ErrResolveFailed.Wrap(err, "failed to resolve table names")
+	}
+	for _, r := range rows {
+		names[r.ID] = [2]string{r.Schema, r.Table}
+	}
+	return names, nil
+}
+
+// @ID ticdcPauseChangefeed
+// @Summary Pause a changefeed
+// @Param id path string true "Changefeed ID"
+// @Param namespace query string false "Changefeed namespace"
+// @Success 200 {string} string
+// @Router /ticdc/changefeeds/{id}/pause [post]
+// @Security JwtAuth
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 403 {object} rest.ErrorResponse
+func (s *Service) pauseChangefeed(c *gin.Context) {
+	if _, err := s.sendToOwner(c, http.MethodPost, changefeedPath(c, "/pause"), namespaceQuery(c), ""); err != nil {
+		rest.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, nil)
+}
+
+// @ID ticdcResumeChangefeed
+// @Summary Resume a paused changefeed from its checkpoint
+// @Param id path string true "Changefeed ID"
+// @Param namespace query string false "Changefeed namespace"
+// @Success 200 {string} string
+// @Router /ticdc/changefeeds/{id}/resume [post]
+// @Security JwtAuth
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 403 {object} rest.ErrorResponse
+func (s *Service) resumeChangefeed(c *gin.Context) {
+	if _, err := s.sendToOwner(c, http.MethodPost, changefeedPath(c, "/resume"), namespaceQuery(c), "{}"); err != nil {
+		rest.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, nil)
+}
@@ -0,0 +1,34 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package ticdc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestToChangefeed(t *testing.T) {
+	checkpoint := time.UnixMilli(1700000000000)
+	now := checkpoint.Add(90 * time.Second)
+	tso := uint64(checkpoint.UnixMilli())<<18 | 5
+
+	cf := toChangefeed(cdcChangefeed{
+		Namespace:     "default",
+		ID:            "cf-1",
+		State:         "failed",
+		CheckpointTSO: tso,
+		Error:         &cdcRunningError{Code: "CDC:ErrSinkURIInvalid", Message: "sink uri invalid"},
+	}, now)
+	require.Equal(t, "cf-1", cf.ID)
+	require.True(t, checkpoint.Equal(cf.CheckpointTime))
+	require.Equal(t, 90.0, cf.CheckpointLagSeconds)
+	require.Equal(t, "sink uri invalid", cf.Error)
+
+	// Changefeeds not started yet have no checkpoint.
+	cf = toChangefeed(cdcChangefeed{ID: "cf-2", State: "normal"}, now)
+	require.True(t, cf.CheckpointTime.IsZero())
+	require.Zero(t, cf.CheckpointLagSeconds)
+	require.Empty(t, cf.Error)
+}
@@ -0,0 +1,177 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+// timeline aggregates significant cluster events from different sources, for incident investigations.
+package timeline
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/joomcode/errorx"
+	"go.uber.org/fx"
+
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/audit"
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/cluster"
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/clusterinfo"
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/user"
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/utils"
+	"github.com/pingcap/tidb-dashboard/pkg/pd"
+	"github.com/pingcap/tidb-dashboard/pkg/tidb"
+	"github.com/pingcap/tidb-dashboard/util/rest"
+	"github.com/pingcap/tidb-dashboard/util/topo"
+)
+
+var (
+	ErrNS                = errorx.NewNamespace("error.api.timeline")
+	ErrFetchEventsFailed = ErrNS.NewType("fetch_events_failed")
+)
+
+// maxEventsPerSource bounds the number of events fetched from each source.
+const maxEventsPerSource = 1000
+
+type EventType string
+
+const (
+	EventTypeScheduling EventType = "scheduling"
+	EventTypeConfig     EventType = "config"
+	EventTypeDDL        EventType = "ddl"
+	EventTypeTopology   EventType = "topology"
+)
+
+type Event struct {
+	Time time.Time `json:"time"`
+	Type EventType `json:"type"`
+	// Component is empty when the event is not about a specific component.
+	Component topo.Kind `json:"component,omitempty"`
+	Instance  string    `json:"instance,omitempty"`
+	Summary   string    `json:"summary"`
+	// User is the dashboard user triggering the event, if known.
+	User string `json:"user,omitempty"`
+}
+
+type ServiceParams struct {
+	fx.In
+	PDClient    *pd.Client
+	TiDBClient  *tidb.Client
+	ClusterInfo *clusterinfo.Service
+	Audit       *audit.Service
+	Registry    *cluster.Registry
+}
+
+type Service struct {
+	params ServiceParams
+}
+
+func newService(p ServiceParams) *Service {
+	return &Service{params: p}
+}
+
+var Module = fx.Options(
+	fx.Provide(newService),
+	fx.Invoke(registerRouter),
+)
+
+func registerRouter(r *gin.RouterGroup, auth *user.AuthService, s *Service) {
+	endpoint := r.Group("/timeline")
+	endpoint.Use(auth.MWAuthRequired(), s.params.Registry.MWResolveCluster())
+	endpoint.Use(utils.MWConnectTiDB(s.params.TiDBClient))
+	endpoint.GET("", s.getTimeline)
+}
+
+type GetTimelineRequest struct {
+	// BeginTime and EndTime are unix seconds.
+	BeginTime int64 `json:"begin_time" form:"begin_time" binding:"required"`
+	EndTime   int64 `json:"end_time" form:"end_time" binding:"required"`
+	// Types and Components are not filtered when empty.
+	Types      []EventType `json:"types" form:"types"`
+	Components []topo.Kind `json:"components" form:"components"`
+}
+
+type GetTimelineResponse struct {
+	Events []Event `json:"events"`
+	// Errors contains the sources failed to fetch. Events from other sources are still returned.
+	Errors []rest.ErrorResponse `json:"errors"`
+}
+
+// @ID timelineGet
+// @Summary Get cluster events in a time range, latest first
+// @Param q query GetTimelineRequest true "Query"
+// @Success 200 {object} GetTimelineResponse
+// @Router /timeline [get]
+// @Security JwtAuth
+// @Failure 400 {object} rest.ErrorResponse
+// @Failure 401 {object} rest.ErrorResponse
+func (s *Service) getTimeline(c *gin.Context) {
+	var req GetTimelineRequest
+	if err := c.ShouldBindQuery(&req); err != nil || req.BeginTime > req.EndTime {
+		rest.Error(c, rest.ErrBadRequest.NewWithNoMessage())
+		return
+	}
+
+	db := utils.GetTiDBConnection(c)
+	pdClient := cluster.PDClientOf(c, s.params.PDClient)
+	sources := map[EventType]func() ([]Event, error){
+		EventTypeScheduling: func() ([]Event, error) { return fetchSchedulingEvents(pdClient, req.BeginTime) },
+		EventTypeConfig:     func() ([]Event, error) { return s.fetchConfigEvents(req.BeginTime, req.EndTime) },
+		EventTypeDDL:        func() ([]Event, error) { return fetchDDLEvents(db, req.BeginTime, req.EndTime) },
+		EventTypeTopology: func() ([]Event, error) {
+			return topologyEvents(s.params.ClusterInfo.TopologyEventsForUser(c)), nil
+		},
+	}
+
+	resp := GetTimelineResponse{
+		Events: make([]Event, 0),
+		Errors: make([]rest.ErrorResponse, 0),
+	}
+	for eventType, fetch := range sources {
+		if len(req.Types) > 0 && !containsType(req.Types, eventType) {
+			continue
+		}
+		events, err := fetch()
+		if err != nil {
+			resp.Errors = append(resp.Errors, rest.NewErrorResponse(err))
+			continue
+		}
+		resp.Events = append(resp.Events, events...)
+	}
+	resp.Events = filterEvents(resp.Events, req)
+	c.JSON(http.StatusOK, resp)
+}
+
+func containsType(types []EventType, t EventType) bool {
+	for _, v := range types {
+		if v == t {
+			return true
+		}
+	}
+	return false
+}
+
+// filterEvents keeps events in the time range and of the requested components, ordered latest first.
+func filterEvents(events []Event, req GetTimelineRequest) []Event {
+	begin := time.Unix(req.BeginTime, 0)
+	end := time.Unix(req.EndTime, 0)
+	components := make(map[topo.Kind]struct{}, len(req.Components))
+	for _, c := range req.Components {
+		components[c] = struct{}{}
+	}
+
+	filtered := make([]Event, 0, len(events))
+	for _, e := range events {
+		if e.Time.Before(begin) || e.Time.After(end) {
+			continue
+		}
+		if len(components) > 0 {
+			if _, ok := components[e.Component]; !ok {
+				continue
+			}
+		}
+		filtered = append(filtered, e)
+	}
+	sort.SliceStable(filtered, func(i, j int) bool {
+		return filtered[i].Time.After(filtered[j].Time)
+	})
+	return filtered
+}
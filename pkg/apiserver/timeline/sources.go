@@ -0,0 +1,165 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package timeline
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/audit"
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/clusterinfo"
+	"github.com/pingcap/tidb-dashboard/pkg/pd"
+	"github.com/pingcap/tidb-dashboard/util/topo"
+)
+
+var (
+	operatorRecordRegex   = regexp.MustCompile(`^(\S+) \{([^}]*)\}`)
+	operatorCreateAtRegex = regexp.MustCompile(`createAt:([^,]+),`)
+)
+
+// PD operator records are serialized as strings, e.g.
+// `balance-leader {transfer leader: store 1 to 2} (kind:leader, region:2(1, 1), createAt:2024-01-01 00:00:00 +0000 UTC, ...)`.
+const operatorTimeLayout = "2006-01-02 15:04:05.999999999 -0700 MST"
+
+// parseOperatorRecord parses a PD operator record. Records not recognized are skipped.
+func parseOperatorRecord(record string) (Event, bool) {
+	m := operatorRecordRegex.FindStringSubmatch(record)
+	if len(m) != 3 {
+		return Event{}, false
+	}
+	t := operatorCreateAtRegex.FindStringSubmatch(record)
+	if len(t) != 2 {
+		return Event{}, false
+	}
+	// Drop the monotonic clock reading, e.g. ` m=+1.000000001`.
+	createAt := strings.SplitN(strings.TrimSpace(t[1]), " m=", 2)[0]
+	ts, err := time.Parse(operatorTimeLayout, createAt)
+	if err != nil {
+		return Event{}, false
+	}
+	return Event{
+		Time:      ts,
+		Type:      EventTypeScheduling,
+		Component: topo.KindPD,
+		Summary:   fmt.Sprintf("%s: %s", m[1], m[2]),
+	}, true
+}
+
+func fetchSchedulingEvents(pdClient *pd.Client, beginTime int64) ([]Event, error) {
+	data, err := pdClient.SendGetRequest(fmt.Sprintf("/operators/records?from=%d", beginTime))
+	if err != nil {
+		return nil, ErrFetchEventsFailed.Wrap(err, "Failed to fetch PD operator records")
+	}
+	// PD responds null when there is no record.
+	var records []string
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, ErrFetchEventsFailed.Wrap(err, "Failed to decode PD operator records")
+	}
+	events := make([]Event, 0, len(records))
+	for _, r := range records {
+		if e, ok := parseOperatorRecord(r); ok {
+			events = append(events, e)
+		}
+		if len(events) >= maxEventsPerSource {
+			break
+		}
+	}
+	return events, nil
+}
+
+// configActions are the audited actions changing cluster configurations.
+var configActions = map[string]struct{}{
+	"configuration.edit":   {},
+	"profiling.set_config": {},
+	"conprof.set_config":   {},
+	"topsql.set_config":    {},
+}
+
+func configEvents(entries []audit.EntryModel) []Event {
+	events := make([]Event, 0)
+	for _, e := range entries {
+		if _, ok := configActions[e.Action]; !ok || e.Error != "" {
+			continue
+		}
+		events = append(events, Event{
+			Time:    e.Time,
+			Type:    EventTypeConfig,
+			Summary: fmt.Sprintf("%s %s", e.Action, e.Params),
+			User:    e.User,
+		})
+	}
+	return events
+}
+
+func (s *Service) fetchConfigEvents(beginTime, endTime int64) ([]Event, error) {
+	entries, err := s.params.Audit.ListEntries(audit.ListFilter{
+		BeginTime: beginTime,
+		EndTime:   endTime,
+		Limit:     maxEventsPerSource,
+	})
+	if err != nil {
+		return nil, ErrFetchEventsFailed.Wrap(err, "Failed to list audit entries")
+	}
+	return configEvents(entries), nil
+}
+
+type ddlJob struct {
+	JobID     int64     `gorm:"column:JOB_ID"`
+	DBName    string    `gorm:"column:DB_NAME"`
+	TableName string    `gorm:"column:TABLE_NAME"`
+	JobType   string    `gorm:"column:JOB_TYPE"`
+	State     string    `gorm:"column:STATE"`
+	StartTime time.Time `gorm:"column:START_TIME"`
+	Query     string    `gorm:"column:QUERY"`
+}
+
+func ddlEvents(jobs []ddlJob) []Event {
+	events := make([]Event, 0, len(jobs))
+	for _, j := range jobs {
+		summary := fmt.Sprintf("%s on %s.%s (job %d, %s)", j.JobType, j.DBName, j.TableName, j.JobID, j.State)
+		if j.Query != "" {
+			summary = fmt.Sprintf("%s: %s", summary, j.Query)
+		}
+		events = append(events, Event{
+			Time:      j.StartTime,
+			Type:      EventTypeDDL,
+			Component: topo.KindTiDB,
+			Summary:   summary,
+		})
+	}
+	return events
+}
+
+func fetchDDLEvents(db *gorm.DB, beginTime, endTime int64) ([]Event, error) {
+	var jobs []ddlJob
+	err := db.
+		Table("INFORMATION_SCHEMA.DDL_JOBS").
+		Select("JOB_ID, DB_NAME, TABLE_NAME, JOB_TYPE, STATE, START_TIME, QUERY").
+		Where("START_TIME BETWEEN FROM_UNIXTIME(?) AND FROM_UNIXTIME(?)", beginTime, endTime).
+		Order("START_TIME DESC").
+		Limit(maxEventsPerSource).
+		Scan(&jobs).Error
+	if err != nil {
+		return nil, ErrFetchEventsFailed.Wrap(err, "Failed to query DDL jobs")
+	}
+	return ddlEvents(jobs), nil
+}
+
+func topologyEvents(topologyEvents []clusterinfo.TopologyEvent) []Event {
+	events := make([]Event, 0, len(topologyEvents))
+	for _, e := range topologyEvents {
+		events = append(events, Event{
+			Time:      e.Time,
+			Type:      EventTypeTopology,
+			Component: e.Component,
+			Instance:  e.Address,
+			Summary:   fmt.Sprintf("%s %s", e.Component, e.Type),
+		})
+	}
+	return events
+}
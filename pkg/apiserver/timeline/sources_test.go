@@ -0,0 +1,62 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package timeline
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/audit"
+	"github.com/pingcap/tidb-dashboard/util/topo"
+)
+
+func TestParseOperatorRecord(t *testing.T) {
+	e, ok := parseOperatorRecord("balance-leader {transfer leader: store 1 to 2} (kind:leader, region:2(1, 1), " +
+		"createAt:2024-01-02 03:04:05.123 +0800 CST m=+1.000000001, startAt:2024-01-02 03:04:05.2 +0800 CST, " +
+		"currentStep:1, size:1, steps:[transfer leader from store 1 to store 2]) (finishAt:2024-01-02 03:04:06 +0800 CST, duration:1s)")
+	require.True(t, ok)
+	require.Equal(t, EventTypeScheduling, e.Type)
+	require.Equal(t, topo.KindPD, e.Component)
+	require.Equal(t, "balance-leader: transfer leader: store 1 to 2", e.Summary)
+	require.True(t, e.Time.Equal(time.Date(2024, 1, 1, 19, 4, 5, 123000000, time.UTC)))
+
+	_, ok = parseOperatorRecord("unknown")
+	require.False(t, ok)
+}
+
+func TestConfigEvents(t *testing.T) {
+	now := time.Now()
+	events := configEvents([]audit.EntryModel{
+		{Time: now, User: "root", Action: "configuration.edit", Params: `{"body":"{}"}`},
+		{Time: now, User: "root", Action: "configuration.edit", Error: "edit failed"},
+		{Time: now, User: "root", Action: "logsearch.create"},
+	})
+	require.Equal(t, []Event{{Time: now, Type: EventTypeConfig, Summary: `configuration.edit {"body":"{}"}`, User: "root"}}, events)
+}
+
+func TestFilterEvents(t *testing.T) {
+	base := time.Unix(1000, 0)
+	events := []Event{
+		{Time: base.Add(10 * time.Second), Component: topo.KindPD, Summary: "a"},
+		{Time: base.Add(30 * time.Second), Component: topo.KindTiDB, Summary: "b"},
+		{Time: base.Add(20 * time.Second), Component: topo.KindTiKV, Summary: "c"},
+		{Time: base.Add(time.Hour), Component: topo.KindPD, Summary: "d"},
+		{Time: base.Add(15 * time.Second), Summary: "e"},
+	}
+	summaries := func(events []Event) []string {
+		result := make([]string, 0, len(events))
+		for _, e := range events {
+			result = append(result, e.Summary)
+		}
+		return result
+	}
+
+	require.Equal(t, []string{"b", "c", "e", "a"}, summaries(filterEvents(events, GetTimelineRequest{BeginTime: 1000, EndTime: 1100})))
+	require.Equal(t, []string{"b", "a"}, summaries(filterEvents(events, GetTimelineRequest{
+		BeginTime:  1000,
+		EndTime:    1100,
+		Components: []topo.Kind{topo.KindPD, topo.KindTiDB},
+	})))
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/joomcode/errorx"
 	"go.uber.org/fx"
 
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/audit"
 	"github.com/pingcap/tidb-dashboard/pkg/apiserver/user"
 	"github.com/pingcap/tidb-dashboard/pkg/apiserver/utils"
 	"github.com/pingcap/tidb-dashboard/pkg/tidb"
@@ -34,7 +35,7 @@ func newService(p ServiceParams, ff *featureflag.Registry) *Service {
 	return &Service{params: p, FeatureTopSQL: ff.Register("topsql", ">= 5.4.0")}
 }
 
-func registerRouter(r *gin.RouterGroup, auth *user.AuthService, s *Service) {
+func registerRouter(r *gin.RouterGroup, auth *user.AuthService, a *audit.Service, s *Service) {
 	endpoint := r.Group("/topsql")
 	endpoint.Use(
 		auth.MWAuthRequired(),
@@ -43,7 +44,7 @@ func registerRouter(r *gin.RouterGroup, auth *user.AuthService, s *Service) {
 	)
 	{
 		endpoint.GET("/config", s.GetConfig)
-		endpoint.POST("/config", auth.MWRequireWritePriv(), s.UpdateConfig)
+		endpoint.POST("/config", auth.MWRequireWritePriv(), a.MWRecord("topsql.set_config"), s.UpdateConfig)
 		endpoint.GET("/instances", s.params.NgmProxy.Route("/topsql/v1/instances"))
 		endpoint.GET("/summary", s.params.NgmProxy.Route("/topsql/v1/summary"))
 	}
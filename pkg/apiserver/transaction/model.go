@@ -0,0 +1,60 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package transaction
+
+import "time"
+
+const (
+	ClusterTrxTable        = "INFORMATION_SCHEMA.CLUSTER_TIDB_TRX"
+	TrxTable               = "INFORMATION_SCHEMA.TIDB_TRX"
+	ClusterTrxSummaryTable = "INFORMATION_SCHEMA.CLUSTER_TRX_SUMMARY"
+)
+
+// Transaction is a running transaction of a TiDB instance.
+type Transaction struct {
+	// Instance is the status address of the TiDB instance.
+	Instance string `gorm:"column:INSTANCE" json:"instance"`
+	// ID is the start ts of the transaction.
+	ID               uint64     `gorm:"column:ID" json:"id"`
+	StartTime        time.Time  `gorm:"column:START_TIME" json:"start_time"`
+	CurrentSQLDigest string     `gorm:"column:CURRENT_SQL_DIGEST" json:"current_sql_digest"`
+	CurrentSQL       string     `gorm:"column:CURRENT_SQL_DIGEST_TEXT" json:"current_sql"`
+	State            string     `gorm:"column:STATE" json:"state"`
+	WaitingStartTime *time.Time `gorm:"column:WAITING_START_TIME" json:"waiting_start_time"`
+	MemBufferKeys    int64      `gorm:"column:MEM_BUFFER_KEYS" json:"mem_buffer_keys"`
+	MemBufferBytes   int64      `gorm:"column:MEM_BUFFER_BYTES" json:"mem_buffer_bytes"`
+	SessionID        uint64     `gorm:"column:SESSION_ID" json:"session_id"`
+	User             string     `gorm:"column:USER" json:"user"`
+	DB               string     `gorm:"column:DB" json:"db"`
+	// AllSQLDigests is a JSON array of the digests of statements executed in the transaction.
+	AllSQLDigests string  `gorm:"column:ALL_SQL_DIGESTS" json:"-"`
+	DurationSecs  float64 `gorm:"column:DURATION_SECS" json:"duration_secs"`
+
+	// Digests are the digests of statements executed in the transaction, in the order of execution.
+	Digests []string `gorm:"-" json:"digests"`
+	// BlockingGC is true when the transaction started before the GC life time, thus holding back the GC safe point.
+	BlockingGC bool `gorm:"-" json:"blocking_gc"`
+}
+
+// Summary is a recently committed or rolled back transaction recorded in the transaction summary.
+type Summary struct {
+	Instance string `gorm:"column:INSTANCE" json:"instance"`
+	// Digest identifies the statements executed in the transaction.
+	Digest        string   `gorm:"column:DIGEST" json:"digest"`
+	AllSQLDigests string   `gorm:"column:ALL_SQL_DIGESTS" json:"-"`
+	Digests       []string `gorm:"-" json:"digests"`
+}
+
+type ListResponse struct {
+	Transactions []Transaction `json:"transactions"`
+	// GCLifeTimeSecs is empty when it can not be read.
+	GCLifeTimeSecs *float64 `json:"gc_life_time_secs,omitempty"`
+}
+
+type KillRequest struct {
+	Instance string `json:"instance" binding:"required"`
+	// ID is the start ts of the transaction, which is checked before killing, so that a reused session ID is not
+	// killed by mistake.
+	ID        uint64 `json:"id" binding:"required"`
+	SessionID uint64 `json:"session_id" binding:"required"`
+}
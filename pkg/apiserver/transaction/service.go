@@ -0,0 +1,242 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package transaction
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/joomcode/errorx"
+	"go.uber.org/fx"
+	"gorm.io/gorm"
+
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/audit"
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/cluster"
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/user"
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/utils"
+	"github.com/pingcap/tidb-dashboard/pkg/pd"
+	"github.com/pingcap/tidb-dashboard/pkg/tidb"
+	"github.com/pingcap/tidb-dashboard/pkg/utils/topology"
+	"github.com/pingcap/tidb-dashboard/util/rest"
+)
+
+const (
+	defaultListLimit = 100
+
+	transactionColumns = "INSTANCE, ID, START_TIME, CURRENT_SQL_DIGEST, CURRENT_SQL_DIGEST_TEXT, STATE, " +
+		"WAITING_START_TIME, MEM_BUFFER_KEYS, MEM_BUFFER_BYTES, SESSION_ID, USER, DB, ALL_SQL_DIGESTS, " +
+		"TIMESTAMPDIFF(MICROSECOND, START_TIME, NOW(6)) / 1000000 AS DURATION_SECS"
+)
+
+var (
+	ErrNS          = errorx.NewNamespace("error.api.transaction")
+	ErrQueryFailed = ErrNS.NewType("query_failed")
+	ErrKillFailed  = ErrNS.NewType("kill_failed")
+)
+
+type ServiceParams struct {
+	fx.In
+	TiDBClient  *tidb.Client
+	EtcdClients *pd.EtcdClientManager
+	Registry    *cluster.Registry
+}
+
+type Service struct {
+	params ServiceParams
+}
+
+func newService(p ServiceParams) *Service {
+	return &Service{params: p}
+}
+
+func registerRouter(r *gin.RouterGroup, auth *user.AuthService, a *audit.Service, s *Service) {
+	endpoint := r.Group("/transaction")
+	endpoint.Use(auth.MWAuthRequired(), s.params.Registry.MWResolveCluster())
+	{
+		endpoint.GET("/list", utils.MWConnectTiDB(s.params.TiDBClient), s.getList)
+		endpoint.GET("/summary", utils.MWConnectTiDB(s.params.TiDBClient), s.getSummary)
+		endpoint.POST("/kill", auth.MWRequireWritePriv(), a.MWRecord("transaction.kill"), s.kill)
+	}
+}
+
+// parseDigests parses the JSON array of digests, which is empty if invalid.
+func parseDigests(allSQLDigests string) []string {
+	digests := make([]string, 0)
+	if allSQLDigests == "" {
+		return digests
+	}
+	if err := json.Unmarshal([]byte(allSQLDigests), &digests); err != nil {
+		return make([]string, 0)
+	}
+	return digests
+}
+
+// fillTransactions fills the fields derived from the columns. GC is not checked when gcLifeTime is 0.
+func fillTransactions(transactions []Transaction, gcLifeTime time.Duration) {
+	for i := range transactions {
+		t := &transactions[i]
+		t.Digests = parseDigests(t.AllSQLDigests)
+		t.BlockingGC = gcLifeTime > 0 && t.DurationSecs > gcLifeTime.Seconds()
+	}
+}
+
+// readGCLifeTime reads tidb_gc_life_time, which is a duration like `10m0s`.
+func readGCLifeTime(db *gorm.DB) (time.Duration, error) {
+	var value string
+	if err := db.Raw("SELECT @@GLOBAL.tidb_gc_life_time").Row().Scan(&value); err != nil {
+		return 0, err
+	}
+	return time.ParseDuration(value)
+}
+
+type GetListRequest struct {
+	// MinDurationSecs lists transactions running for at least the duration only.
+	MinDurationSecs int `json:"min_duration_secs" form:"min_duration_secs"`
+	Limit           int `json:"limit" form:"limit"`
+}
+
+// @ID transactionGetList
+// @Summary List running transactions of all TiDB instances, longest running first
+// @Description Transactions started before the GC life time are marked, as they hold back the GC safe point.
+// @Param q query GetListRequest true "Query"
+// @Success 200 {object} ListResponse
+// @Router /transaction/list [get]
+// @Security JwtAuth
+// @Failure 400 {object} rest.ErrorResponse
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 403 {object} rest.ErrorResponse
+func (s *Service) getList(c *gin.Context) {
+	var req GetListRequest
+	if err := c.ShouldBindQuery(&req); err != nil || req.MinDurationSecs < 0 {
+		rest.Error(c, rest.ErrBadRequest.NewWithNoMessage())
+		return
+	}
+	if req.Limit <= 0 {
+		req.Limit = defaultListLimit
+	}
+	db := utils.GetTiDBConnection(c)
+
+	transactions := make([]Transaction, 0)
+	query := db.Table(ClusterTrxTable).Select(transactionColumns).Order("START_TIME").Limit(req.Limit)
+	if req.MinDurationSecs > 0 {
+		query = query.Where("START_TIME <= DATE_SUB(NOW(), INTERVAL ? SECOND)", req.MinDurationSecs)
+	}
+	if err := query.Find(&transactions).Error; err != nil {
+		rest.Error(c, ErrQueryFailed.WrapWithNoMessage(err))
+		return
+	}
+
+	resp := ListResponse{Transactions: transactions}
+	// The GC life time is only informative.
+	gcLifeTime, err := readGCLifeTime(db)
+	if err == nil {
+		secs := gcLifeTime.Seconds()
+		resp.GCLifeTimeSecs = &secs
+	}
+	fillTransactions(resp.Transactions, gcLifeTime)
+	c.JSON(http.StatusOK, resp)
+}
+
+// @ID transactionGetSummary
+// @Summary List the transaction summary of all TiDB instances
+// @Success 200 {array} Summary
+// @Router /transaction/summary [get]
+// @Security JwtAuth
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 403 {object} rest.ErrorResponse
+func (s *Service) getSummary(c *gin.Context) {
+	summaries := make([]Summary, 0)
+	err := utils.GetTiDBConnection(c).Table(ClusterTrxSummaryTable).Select("INSTANCE, DIGEST, ALL_SQL_DIGESTS").Find(&summaries).Error
+	if err != nil {
+		rest.Error(c, ErrQueryFailed.WrapWithNoMessage(err))
+		return
+	}
+	for i := range summaries {
+		summaries[i].Digests = parseDigests(summaries[i].AllSQLDigests)
+	}
+	c.JSON(http.StatusOK, summaries)
+}
+
+// killStatement returns the statement killing the session. The id is an integer, so that it is safe to interpolate.
+func killStatement(sessionID uint64) string {
+	return fmt.Sprintf("KILL TIDB %d", sessionID)
+}
+
+// findTiDBByStatusAddress returns the TiDB instance whose status address is the address.
+func findTiDBByStatusAddress(instances []topology.TiDBInfo, address string) (*topology.TiDBInfo, bool) {
+	for i := range instances {
+		if net.JoinHostPort(instances[i].IP, strconv.Itoa(int(instances[i].StatusPort))) == address {
+			return &instances[i], true
+		}
+	}
+	return nil, false
+}
+
+// @ID transactionKill
+// @Summary Kill the session of a running transaction
+// @Description The session is killed on the TiDB instance running the transaction, only if the transaction is
+// @Description still running in the session.
+// @Param request body KillRequest true "Request body"
+// @Success 200 {string} string
+// @Router /transaction/kill [post]
+// @Security JwtAuth
+// @Failure 400 {object} rest.ErrorResponse
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 403 {object} rest.ErrorResponse
+// @Failure 404 {object} rest.ErrorResponse
+func (s *Service) kill(c *gin.Context) {
+	var req KillRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		rest.Error(c, rest.ErrBadRequest.NewWithNoMessage())
+		return
+	}
+	u := utils.GetSession(c)
+	if !u.HasTiDBAuth {
+		rest.Error(c, rest.ErrForbidden.NewWithNoMessage())
+		return
+	}
+	if u.ClusterID != "" {
+		// The TiDB credentials in the session are of the default cluster.
+		rest.Error(c, rest.ErrBadRequest.New("SQL is not available for the selected cluster"))
+		return
+	}
+
+	instances, err := topology.FetchTiDBTopology(c.Request.Context(), cluster.EtcdClientOf(c, s.params.EtcdClients))
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	instance, ok := findTiDBByStatusAddress(instances, req.Instance)
+	if !ok {
+		rest.Error(c, rest.ErrNotFound.New("TiDB instance %s not found", req.Instance))
+		return
+	}
+
+	// Session IDs are local to the instance without global kill, so that the session is killed on the instance.
+	db, err := s.params.TiDBClient.WithSQLAPIAddress(instance.IP, int(instance.Port)).OpenSQLConn(u.TiDBUsername, u.TiDBPassword)
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	defer utils.CloseTiDBConnection(db) //nolint:errcheck
+
+	var count int64
+	if err := db.Table(TrxTable).Where("ID = ? AND SESSION_ID = ?", req.ID, req.SessionID).Count(&count).Error; err != nil {
+		rest.Error(c, ErrQueryFailed.WrapWithNoMessage(err))
+		return
+	}
+	if count == 0 {
+		rest.Error(c, rest.ErrNotFound.New("transaction %d is not running in session %d", req.ID, req.SessionID))
+		return
+	}
+	if err := db.Exec(killStatement(req.SessionID)).Error; err != nil {
+		rest.Error(c, ErrKillFailed.WrapWithNoMessage(err))
+		return
+	}
+	c.JSON(http.StatusOK, nil)
+}
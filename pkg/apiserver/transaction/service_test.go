@@ -0,0 +1,48 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package transaction
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pingcap/tidb-dashboard/pkg/utils/topology"
+)
+
+func TestFillTransactions(t *testing.T) {
+	transactions := []Transaction{
+		{ID: 1, DurationSecs: 1200, AllSQLDigests: `["d1", "d2"]`},
+		{ID: 2, DurationSecs: 5},
+		{ID: 3, DurationSecs: 5, AllSQLDigests: "invalid"},
+	}
+	fillTransactions(transactions, 10*time.Minute)
+	require.True(t, transactions[0].BlockingGC)
+	require.Equal(t, []string{"d1", "d2"}, transactions[0].Digests)
+	require.False(t, transactions[1].BlockingGC)
+	require.Empty(t, transactions[1].Digests)
+	require.NotNil(t, transactions[2].Digests)
+
+	// GC is not checked without the GC life time.
+	fillTransactions(transactions, 0)
+	require.False(t, transactions[0].BlockingGC)
+}
+
+func TestFindTiDBByStatusAddress(t *testing.T) {
+	instances := []topology.TiDBInfo{
+		{IP: "10.0.1.1", Port: 4000, StatusPort: 10080},
+		{IP: "10.0.1.2", Port: 4000, StatusPort: 10080},
+	}
+	instance, ok := findTiDBByStatusAddress(instances, "10.0.1.2:10080")
+	require.True(t, ok)
+	require.Equal(t, "10.0.1.2", instance.IP)
+
+	// The SQL address is not the instance address of cluster tables.
+	_, ok = findTiDBByStatusAddress(instances, "10.0.1.2:4000")
+	require.False(t, ok)
+}
+
+func TestKillStatement(t *testing.T) {
+	require.Equal(t, "KILL TIDB 42", killStatement(42))
+}
@@ -0,0 +1,111 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package apikey
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/audit"
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/user"
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/utils"
+	"github.com/pingcap/tidb-dashboard/util/rest"
+)
+
+func registerRouter(r *gin.RouterGroup, auth *user.AuthService, a *audit.Service, s *Service) {
+	endpoint := r.Group("/user/api_keys")
+	endpoint.Use(auth.MWAuthRequired(), auth.MWRequireWritePriv())
+	endpoint.GET("", s.listHandler)
+	// Keys carry the session as sharing codes do, so that sessions not allowed to share, e.g. signed in by
+	// sharing codes, can not create keys.
+	endpoint.POST("", auth.MWRequireSharePriv(), a.MWRecord("user.api_key.create"), s.createHandler)
+	endpoint.DELETE("/:id", a.MWRecord("user.api_key.revoke"), s.revokeHandler)
+}
+
+// @ID userListAPIKeys
+// @Summary List API keys
+// @Security JwtAuth
+// @Success 200 {array} KeyModel
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 403 {object} rest.ErrorResponse
+// @Router /user/api_keys [get]
+func (s *Service) listHandler(c *gin.Context) {
+	keys, err := s.List()
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, keys)
+}
+
+type CreateRequest struct {
+	Name string `json:"name" binding:"required"`
+	// Scope is either `read_only` or `admin`.
+	Scope string `json:"scope" binding:"required"`
+	// ExpireInSeconds is the lifetime of the key. The key never expires when it is 0.
+	ExpireInSeconds int64 `json:"expire_in_sec"`
+}
+
+type CreateResponse struct {
+	KeyModel
+	// Key is the API key to be sent in the `X-API-Key` header. It is only returned once.
+	Key string `json:"key"`
+}
+
+// @ID userCreateAPIKey
+// @Summary Create an API key carrying the current session
+// @Description The key can be used in the `X-API-Key` header instead of signing in. Keys in the `read_only` scope
+// @Description do not have write privileges. Sessions signed in by sharing codes can not create keys.
+// @Param request body CreateRequest true "Request body"
+// @Security JwtAuth
+// @Success 200 {object} CreateResponse
+// @Failure 400 {object} rest.ErrorResponse
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 403 {object} rest.ErrorResponse
+// @Router /user/api_keys [post]
+func (s *Service) createHandler(c *gin.Context) {
+	var req CreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		rest.Error(c, rest.ErrBadRequest.NewWithNoMessage())
+		return
+	}
+	if req.Scope != ScopeReadOnly && req.Scope != ScopeAdmin {
+		rest.Error(c, rest.ErrBadRequest.New("invalid scope %s", req.Scope))
+		return
+	}
+	if req.ExpireInSeconds < 0 {
+		rest.Error(c, rest.ErrBadRequest.New("invalid expiry"))
+		return
+	}
+
+	m, key, err := s.Create(c, utils.GetSession(c), req.Name, req.Scope, time.Duration(req.ExpireInSeconds)*time.Second)
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, CreateResponse{KeyModel: *m, Key: key})
+}
+
+// @ID userRevokeAPIKey
+// @Summary Revoke an API key
+// @Param id path string true "API key ID"
+// @Security JwtAuth
+// @Success 200 {string} string
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 403 {object} rest.ErrorResponse
+// @Failure 404 {object} rest.ErrorResponse
+// @Router /user/api_keys/{id} [delete]
+func (s *Service) revokeHandler(c *gin.Context) {
+	found, err := s.Revoke(c.Param("id"))
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	if !found {
+		rest.Error(c, rest.ErrNotFound.New("API key not found"))
+		return
+	}
+	c.JSON(http.StatusOK, nil)
+}
@@ -0,0 +1,244 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package apikey
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gtank/cryptopasta"
+	"github.com/joomcode/errorx"
+	"github.com/pingcap/log"
+	"github.com/vmihailenco/msgpack/v5"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/user"
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/utils"
+	"github.com/pingcap/tidb-dashboard/pkg/dbstore"
+)
+
+var (
+	ErrNS           = errorx.NewNamespace("error.api.user.api_key")
+	ErrCreateFailed = ErrNS.NewType("create_failed")
+)
+
+const (
+	ScopeReadOnly = "read_only"
+	ScopeAdmin    = "admin"
+
+	// keyPrefix makes API keys recognizable, e.g. by secret scanners.
+	keyPrefix = "tdak"
+
+	// lastUsedUpdateInterval limits how often the last used time of a key is written.
+	lastUsedUpdateInterval = time.Minute
+)
+
+// noExpireAt is the expiry of sessions of keys that never expire.
+var noExpireAt = time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC)
+
+// KeyModel is an API key. The secret of the key is not stored: only its hash is kept for verification, and the
+// session of the key is encrypted by a key derived from the secret.
+type KeyModel struct {
+	ID         string     `json:"id" gorm:"primary_key;size:32"`
+	Name       string     `json:"name"`
+	Scope      string     `json:"scope"`
+	CreatedBy  string     `json:"created_by"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpireAt   *time.Time `json:"expire_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+
+	SecretHash       string `json:"-"`
+	EncryptedSession []byte `json:"-"`
+	// AuthFrom and SessionID of the session are not serialized in EncryptedSession, thus they are kept here.
+	AuthFrom  utils.AuthType `json:"-"`
+	SessionID string         `json:"-" gorm:"size:32"`
+}
+
+func (KeyModel) TableName() string {
+	return "api_keys"
+}
+
+type Service struct {
+	db  *dbstore.DB
+	now func() time.Time
+	// trackSession tracks the session of a created key, and untrackSession revokes it with the key. They are nil
+	// when sessions are not tracked.
+	trackSession   func(c *gin.Context, u *utils.SessionUser, expire time.Time) error
+	untrackSession func(id string) error
+}
+
+func NewService(db *dbstore.DB) *Service {
+	if err := db.AutoMigrate(&KeyModel{}); err != nil {
+		log.Fatal("Failed to initialize database", zap.Error(err))
+	}
+	return &Service{db: db, now: time.Now}
+}
+
+func registerVerifier(s *Service, authService *user.AuthService) {
+	authService.RegisterAPIKeyVerifier(s)
+	s.trackSession = authService.TrackSession
+	s.untrackSession = authService.UntrackSession
+}
+
+var Module = fx.Options(
+	fx.Provide(NewService),
+	fx.Invoke(registerVerifier, registerRouter),
+)
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func hashSecret(secret string) string {
+	h := sha256.Sum256([]byte("hash:" + secret))
+	return hex.EncodeToString(h[:])
+}
+
+func sessionKey(secret string) *[32]byte {
+	k := sha256.Sum256([]byte("session:" + secret))
+	return &k
+}
+
+// parseKey splits a key in the form of `tdak_<id>_<secret>`.
+func parseKey(key string) (id string, secret string, ok bool) {
+	parts := strings.Split(key, "_")
+	if len(parts) != 3 || parts[0] != keyPrefix || parts[1] == "" || parts[2] == "" {
+		return "", "", false
+	}
+	return parts[1], parts[2], true
+}
+
+// Create mints a key carrying the session, which is returned only once. Write privileges are dropped from the
+// session for read-only keys. The key has its own session, which is tracked until the key expires.
+func (s *Service) Create(c *gin.Context, session *utils.SessionUser, name, scope string, expireIn time.Duration) (*KeyModel, string, error) {
+	id, err := randomHex(8)
+	if err != nil {
+		return nil, "", ErrCreateFailed.WrapWithNoMessage(err)
+	}
+	secret, err := randomHex(32)
+	if err != nil {
+		return nil, "", ErrCreateFailed.WrapWithNoMessage(err)
+	}
+	sessionID, err := randomHex(16)
+	if err != nil {
+		return nil, "", ErrCreateFailed.WrapWithNoMessage(err)
+	}
+
+	keySession := *session
+	keySession.SessionID = sessionID
+	keySession.DisplayName = fmt.Sprintf("API key %s (%s)", name, session.DisplayName)
+	keySession.IsShareable = false
+	keySession.IsAPIKey = true
+	if scope == ScopeReadOnly {
+		keySession.RevokeWritePriv()
+	}
+	b, err := msgpack.Marshal(&keySession)
+	if err != nil {
+		return nil, "", ErrCreateFailed.New("failed to serialize session")
+	}
+	encrypted, err := cryptopasta.Encrypt(b, sessionKey(secret))
+	if err != nil {
+		return nil, "", ErrCreateFailed.WrapWithNoMessage(err)
+	}
+
+	m := &KeyModel{
+		ID:               id,
+		Name:             name,
+		Scope:            scope,
+		CreatedBy:        session.DisplayName,
+		CreatedAt:        s.now(),
+		SecretHash:       hashSecret(secret),
+		EncryptedSession: encrypted,
+		AuthFrom:         keySession.AuthFrom,
+		SessionID:        sessionID,
+	}
+	sessionExpireAt := noExpireAt
+	if expireIn > 0 {
+		expireAt := m.CreatedAt.Add(expireIn)
+		m.ExpireAt = &expireAt
+		sessionExpireAt = expireAt
+	}
+	if s.trackSession != nil {
+		if err := s.trackSession(c, &keySession, sessionExpireAt); err != nil {
+			return nil, "", err
+		}
+	}
+	if err := s.db.Create(m).Error; err != nil {
+		return nil, "", err
+	}
+	return m, fmt.Sprintf("%s_%s_%s", keyPrefix, id, secret), nil
+}
+
+func (s *Service) List() ([]KeyModel, error) {
+	var keys []KeyModel
+	err := s.db.Order("created_at DESC").Find(&keys).Error
+	return keys, err
+}
+
+// Revoke deletes the key and revokes its session, returning whether the key exists.
+func (s *Service) Revoke(id string) (bool, error) {
+	var m KeyModel
+	if err := s.db.Where("id = ?", id).Limit(1).Find(&m).Error; err != nil {
+		return false, err
+	}
+	if m.ID == "" {
+		return false, nil
+	}
+	if err := s.db.Where("id = ?", id).Delete(&KeyModel{}).Error; err != nil {
+		return false, err
+	}
+	if s.untrackSession != nil && m.SessionID != "" {
+		if err := s.untrackSession(m.SessionID); err != nil {
+			return true, err
+		}
+	}
+	return true, nil
+}
+
+// VerifyAPIKey implements user.APIKeyVerifier.
+func (s *Service) VerifyAPIKey(key string) *utils.SessionUser {
+	id, secret, ok := parseKey(key)
+	if !ok {
+		return nil
+	}
+	var m KeyModel
+	if err := s.db.Where("id = ?", id).First(&m).Error; err != nil {
+		return nil
+	}
+	if subtle.ConstantTimeCompare([]byte(hashSecret(secret)), []byte(m.SecretHash)) != 1 {
+		return nil
+	}
+	now := s.now()
+	if m.ExpireAt != nil && now.After(*m.ExpireAt) {
+		return nil
+	}
+	b, err := cryptopasta.Decrypt(m.EncryptedSession, sessionKey(secret))
+	if err != nil {
+		return nil
+	}
+	var session utils.SessionUser
+	if err := msgpack.Unmarshal(b, &session); err != nil {
+		return nil
+	}
+	session.AuthFrom = m.AuthFrom
+	session.SessionID = m.SessionID
+	session.IsAPIKey = true
+
+	if m.LastUsedAt == nil || now.Sub(*m.LastUsedAt) >= lastUsedUpdateInterval {
+		if err := s.db.Model(&KeyModel{}).Where("id = ?", id).Update("last_used_at", now).Error; err != nil {
+			log.Warn("Failed to update the last used time of API key", zap.String("id", id), zap.Error(err))
+		}
+	}
+	return &session
+}
@@ -0,0 +1,99 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package apikey
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/utils"
+	"github.com/pingcap/tidb-dashboard/pkg/dbstore/dbstoretest"
+)
+
+func newTestService(t *testing.T) *Service {
+	return NewService(dbstoretest.New(t))
+}
+
+func TestAPIKey(t *testing.T) {
+	s := newTestService(t)
+	session := &utils.SessionUser{
+		Version:      utils.SessionVersion,
+		DisplayName:  "root",
+		HasTiDBAuth:  true,
+		TiDBUsername: "root",
+		TiDBPassword: "secret",
+		IsShareable:  true,
+		IsWriteable:  true,
+		Capabilities: []utils.Capability{utils.CapabilityView, utils.CapabilityWrite},
+		AuthFrom:     2,
+		SessionID:    "signed-in",
+	}
+	var tracked *utils.SessionUser
+	var trackedExpireAt time.Time
+	s.trackSession = func(c *gin.Context, u *utils.SessionUser, expire time.Time) error {
+		tracked, trackedExpireAt = u, expire
+		return nil
+	}
+
+	m, key, err := s.Create(nil, session, "monitoring", ScopeReadOnly, 0)
+	require.NoError(t, err)
+	require.Nil(t, m.ExpireAt)
+	// The key has its own session, which does not end with the signed in session.
+	require.NotNil(t, tracked)
+	require.NotEqual(t, "signed-in", tracked.SessionID)
+	require.Equal(t, noExpireAt, trackedExpireAt)
+
+	u := s.VerifyAPIKey(key)
+	require.NotNil(t, u)
+	require.Equal(t, "API key monitoring (root)", u.DisplayName)
+	require.Equal(t, "secret", u.TiDBPassword)
+	require.False(t, u.IsWriteable)
+	require.False(t, u.IsShareable)
+	require.Equal(t, []utils.Capability{utils.CapabilityView}, u.Capabilities)
+	require.Equal(t, utils.AuthType(2), u.AuthFrom)
+	require.Equal(t, tracked.SessionID, u.SessionID)
+	require.True(t, tracked.IsAPIKey)
+	require.True(t, u.IsAPIKey)
+
+	keys, err := s.List()
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	require.NotNil(t, keys[0].LastUsedAt)
+
+	// Tampered or malformed keys are rejected.
+	require.Nil(t, s.VerifyAPIKey(key+"0"))
+	require.Nil(t, s.VerifyAPIKey("tdak_"+m.ID))
+	require.Nil(t, s.VerifyAPIKey("invalid"))
+
+	var untracked string
+	s.untrackSession = func(id string) error {
+		untracked = id
+		return nil
+	}
+	found, err := s.Revoke(m.ID)
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Nil(t, s.VerifyAPIKey(key))
+	// The session of the key is revoked with the key.
+	require.Equal(t, tracked.SessionID, untracked)
+	found, err = s.Revoke(m.ID)
+	require.NoError(t, err)
+	require.False(t, found)
+}
+
+func TestAPIKeyExpiry(t *testing.T) {
+	s := newTestService(t)
+	session := &utils.SessionUser{DisplayName: "root", IsWriteable: true}
+
+	_, key, err := s.Create(nil, session, "admin", ScopeAdmin, time.Hour)
+	require.NoError(t, err)
+	u := s.VerifyAPIKey(key)
+	require.NotNil(t, u)
+	require.True(t, u.IsWriteable)
+
+	s.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	require.Nil(t, s.VerifyAPIKey(key))
+}
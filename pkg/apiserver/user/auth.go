@@ -3,8 +3,10 @@
 package user
 
 import (
+	"crypto/rand"
 	"crypto/rsa"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"net/http"
@@ -20,6 +22,7 @@ import (
 	"go.uber.org/zap"
 
 	"github.com/pingcap/tidb-dashboard/pkg/apiserver/utils"
+	"github.com/pingcap/tidb-dashboard/pkg/config"
 	"github.com/pingcap/tidb-dashboard/util/featureflag"
 	"github.com/pingcap/tidb-dashboard/util/rest"
 )
@@ -29,6 +32,8 @@ var (
 	ErrUnsupportedAuthType = ErrNS.NewType("unsupported_auth_type")
 	ErrNSSignIn            = ErrNS.NewSubNamespace("signin")
 	ErrSignInOther         = ErrNSSignIn.NewType("other")
+	// ErrSignInTooManyAttempts is returned when the source IP or the username is throttled or locked out.
+	ErrSignInTooManyAttempts = ErrNSSignIn.NewType("too_many_attempts")
 )
 
 type AuthService struct {
@@ -36,6 +41,9 @@ type AuthService struct {
 
 	middleware     *jwt.GinJWTMiddleware
 	authenticators map[utils.AuthType]Authenticator
+	apiKeyVerifier APIKeyVerifier
+	sessionTracker SessionTracker
+	loginLimiter   *loginLimiter
 
 	RsaPublicKey  *rsa.PublicKey
 	RsaPrivateKey *rsa.PrivateKey
@@ -49,8 +57,9 @@ type AuthenticateForm struct {
 }
 
 type TokenResponse struct {
-	Token  string    `json:"token"`
-	Expire time.Time `json:"expire"`
+	Token        string             `json:"token"`
+	Expire       time.Time          `json:"expire"`
+	Capabilities []utils.Capability `json:"capabilities"`
 }
 
 type SignOutInfo struct {
@@ -64,6 +73,24 @@ type Authenticator interface {
 	SignOutInfo(u *utils.SessionUser, redirectURL string) (*SignOutInfo, error)
 }
 
+// APIKeyHeader is the request header carrying an API key, which is accepted in place of the authentication token.
+const APIKeyHeader = "X-API-Key"
+
+// APIKeyVerifier resolves the session of an API key. It returns nil if the key is invalid, expired or revoked.
+// The session is then validated the same as sessions in tokens, thus it must carry AuthFrom and SessionID.
+type APIKeyVerifier interface {
+	VerifyAPIKey(key string) *utils.SessionUser
+}
+
+// SessionTracker keeps sessions signed in, so that they can be listed and revoked.
+type SessionTracker interface {
+	TrackSession(c *gin.Context, u *utils.SessionUser, expire time.Time) error
+	// UntrackSession revokes the session, e.g. when the API key carrying the session is revoked.
+	UntrackSession(id string) error
+	// IsSessionActive returns false if the session is revoked or expired.
+	IsSessionActive(id string) bool
+}
+
 type BaseAuthenticator struct{}
 
 func (a BaseAuthenticator) IsEnabled() (bool, error) {
@@ -78,7 +105,7 @@ func (a BaseAuthenticator) SignOutInfo(u *utils.SessionUser, redirectURL string)
 	return &SignOutInfo{}, nil
 }
 
-func NewAuthService(featureFlags *featureflag.Registry) *AuthService {
+func NewAuthService(featureFlags *featureflag.Registry, config *config.Config) *AuthService {
 	var secret *[32]byte
 
 	secretStr := os.Getenv("DASHBOARD_SESSION_SECRET")
@@ -103,6 +130,7 @@ func NewAuthService(featureFlags *featureflag.Registry) *AuthService {
 		FeatureFlagNonRootLogin: featureFlags.Register("nonRootLogin", ">= 5.3.0"),
 		middleware:              nil,
 		authenticators:          map[utils.AuthType]Authenticator{},
+		loginLimiter:            newLoginLimiter(config),
 		RsaPrivateKey:           privateKey,
 		RsaPublicKey:            publicKey,
 	}
@@ -122,6 +150,11 @@ func NewAuthService(featureFlags *featureflag.Registry) *AuthService {
 			if err != nil {
 				return nil, errorx.Decorate(err, "authenticate failed")
 			}
+			if u.SessionID, err = newSessionID(); err != nil {
+				return nil, ErrSignInOther.WrapWithNoMessage(err)
+			}
+			// Keep the user for LoginResponse, which only receives the token.
+			c.Set(utils.SessionUserKey, u)
 			// TODO: uncomment it after thinking clearly
 			// if form.Type == 0 {
 			// 	// generate new rsa key pair for each sql auth login
@@ -172,20 +205,9 @@ func NewAuthService(featureFlags *featureflag.Registry) *AuthService {
 			if err := json.Unmarshal(decrypted, &user); err != nil {
 				return nil
 			}
-
-			// Force expire schema outdated sessions.
-			if user.Version != utils.SessionVersion {
-				return nil
-			}
-
-			a, ok := service.authenticators[user.AuthFrom]
-			if !ok {
-				return nil
-			}
-			if !a.ProcessSession(&user) {
+			if !service.isSessionValid(&user) {
 				return nil
 			}
-
 			return &user
 		},
 		Authorizator: func(data interface{}, c *gin.Context) bool {
@@ -194,13 +216,23 @@ func NewAuthService(featureFlags *featureflag.Registry) *AuthService {
 				return false
 			}
 			user := data.(*utils.SessionUser)
-			return user != nil
+			if user == nil {
+				return false
+			}
+			if !canAccess(user, utils.Capability(c.GetString(requiredCapabilityKey))) {
+				// The session is valid, thus it is rejected as forbidden in HTTPStatusMessageFunc.
+				c.Set(capabilityDeniedKey, true)
+				return false
+			}
+			return true
 		},
 		HTTPStatusMessageFunc: func(e error, c *gin.Context) string {
 			var err error
 			if errorxErr := errorx.Cast(e); errorxErr != nil {
 				// If the error is an errorx, use it directly.
 				err = e
+			} else if errors.Is(e, jwt.ErrForbidden) && c.GetBool(capabilityDeniedKey) {
+				err = errInsufficientCapability()
 			} else if errors.Is(e, jwt.ErrFailedTokenCreation) {
 				// Try to catch other sign in failure errors.
 				err = ErrSignInOther.WrapWithNoMessage(e)
@@ -215,10 +247,20 @@ func NewAuthService(featureFlags *featureflag.Registry) *AuthService {
 			c.Status(code)
 		},
 		LoginResponse: func(c *gin.Context, code int, token string, expire time.Time) {
-			c.JSON(http.StatusOK, TokenResponse{
+			resp := TokenResponse{
 				Token:  token,
 				Expire: expire,
-			})
+			}
+			if u := utils.GetSession(c); u != nil {
+				resp.Capabilities = u.Capabilities
+				if service.sessionTracker != nil {
+					if err := service.sessionTracker.TrackSession(c, u, expire); err != nil {
+						rest.Error(c, err)
+						return
+					}
+				}
+			}
+			c.JSON(http.StatusOK, resp)
 		},
 	})
 	if err != nil {
@@ -231,6 +273,33 @@ func NewAuthService(featureFlags *featureflag.Registry) *AuthService {
 	return service
 }
 
+// isSessionValid checks the session decoded from a token or an API key.
+func (s *AuthService) isSessionValid(u *utils.SessionUser) bool {
+	// Force expire schema outdated sessions.
+	if u.Version != utils.SessionVersion {
+		return false
+	}
+	a, ok := s.authenticators[u.AuthFrom]
+	if !ok {
+		return false
+	}
+	if !a.ProcessSession(u) {
+		return false
+	}
+	if s.sessionTracker != nil && !s.sessionTracker.IsSessionActive(u.SessionID) {
+		return false
+	}
+	return true
+}
+
+func newSessionID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
 func (s *AuthService) authForm(f AuthenticateForm) (*utils.SessionUser, error) {
 	a, ok := s.authenticators[f.Type]
 	if !ok {
@@ -248,14 +317,61 @@ func registerRouter(r *gin.RouterGroup, s *AuthService) {
 	endpoint := r.Group("/user")
 	endpoint.GET("/login_info", s.GetLoginInfoHandler)
 	endpoint.POST("/login", s.LoginHandler)
-	endpoint.GET("/sign_out_info", s.MWAuthRequired(), s.getSignOutInfoHandler)
+	endpoint.GET("/sign_out_info", s.MWAuthRequiredFor(""), s.getSignOutInfoHandler)
+}
+
+const (
+	// requiredCapabilityKey is the key of the capability required by MWAuthRequiredFor in the gin Context.
+	requiredCapabilityKey = "requiredCapability"
+	// capabilityDeniedKey is set in the gin Context when the session lacks the required capability.
+	capabilityDeniedKey = "capabilityDenied"
+)
+
+// canAccess returns whether the user has the capability, which is implied by utils.CapabilityView.
+func canAccess(u *utils.SessionUser, capability utils.Capability) bool {
+	return capability == "" || u.HasCapability(utils.CapabilityView) || u.HasCapability(capability)
+}
+
+func errInsufficientCapability() error {
+	return rest.ErrForbidden.New("insufficient privileges to access the endpoint")
 }
 
 // MWAuthRequired creates a middleware that verifies the authentication token (JWT) in the request. If the token
 // is valid, identity information will be attached in the context. If there is no authentication token, or the
 // token is invalid, subsequent handlers will be skipped and errors will be generated.
+// When an API key verifier is registered, requests carrying an API key in the APIKeyHeader are verified by the
+// API key instead.
+// Users without utils.CapabilityView are rejected. See MWAuthRequiredFor for endpoints available to them.
 func (s *AuthService) MWAuthRequired() gin.HandlerFunc {
-	return s.middleware.MiddlewareFunc()
+	return s.MWAuthRequiredFor(utils.CapabilityView)
+}
+
+// MWAuthRequiredFor is MWAuthRequired for endpoints also available to users without utils.CapabilityView, e.g. SQL
+// users without the dashboard privileges, which requires the capability instead. Any signed in user is accepted
+// when the capability is empty.
+func (s *AuthService) MWAuthRequiredFor(capability utils.Capability) gin.HandlerFunc {
+	jwtMiddleware := s.middleware.MiddlewareFunc()
+	return func(c *gin.Context) {
+		c.Set(requiredCapabilityKey, string(capability))
+		key := c.GetHeader(APIKeyHeader)
+		if key == "" || s.apiKeyVerifier == nil {
+			jwtMiddleware(c)
+			return
+		}
+		u := s.apiKeyVerifier.VerifyAPIKey(key)
+		if u == nil || !s.isSessionValid(u) {
+			rest.Error(c, rest.ErrUnauthenticated.NewWithNoMessage())
+			c.Abort()
+			return
+		}
+		if !canAccess(u, capability) {
+			rest.Error(c, errInsufficientCapability())
+			c.Abort()
+			return
+		}
+		c.Set(utils.SessionUserKey, u)
+		c.Next()
+	}
 }
 
 // TODO: Make these MWRequireXxxPriv more general to use.
@@ -293,11 +409,57 @@ func (s *AuthService) MWRequireWritePriv() gin.HandlerFunc {
 	}
 }
 
+// MWRequireCapability creates a middleware that rejects users without the specified capability.
+func (s *AuthService) MWRequireCapability(capability utils.Capability) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		u := utils.GetSession(c)
+		if u == nil {
+			rest.Error(c, rest.ErrUnauthenticated.NewWithNoMessage())
+			c.Abort()
+			return
+		}
+		if !u.HasCapability(capability) {
+			rest.Error(c, rest.ErrForbidden.NewWithNoMessage())
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
 // RegisterAuthenticator registers an authenticator in the authenticate pipeline.
 func (s *AuthService) RegisterAuthenticator(typeID utils.AuthType, a Authenticator) {
 	s.authenticators[typeID] = a
 }
 
+// RegisterSessionTracker tracks signed in sessions. Sessions not tracked, e.g. signed in before the tracker is
+// registered, are rejected in MWAuthRequired.
+func (s *AuthService) RegisterSessionTracker(t SessionTracker) {
+	s.sessionTracker = t
+}
+
+// TrackSession tracks a session not signed in by LoginHandler, e.g. the session carried by an API key, so that it
+// is accepted in MWAuthRequired.
+func (s *AuthService) TrackSession(c *gin.Context, u *utils.SessionUser, expire time.Time) error {
+	if s.sessionTracker == nil {
+		return nil
+	}
+	return s.sessionTracker.TrackSession(c, u, expire)
+}
+
+// UntrackSession revokes a session tracked by TrackSession.
+func (s *AuthService) UntrackSession(id string) error {
+	if s.sessionTracker == nil {
+		return nil
+	}
+	return s.sessionTracker.UntrackSession(id)
+}
+
+// RegisterAPIKeyVerifier enables authenticating requests by API keys in MWAuthRequired.
+func (s *AuthService) RegisterAPIKeyVerifier(v APIKeyVerifier) {
+	s.apiKeyVerifier = v
+}
+
 type GetLoginInfoResponse struct {
 	SupportedAuthTypes []int  `json:"supported_auth_types"`
 	SQLAuthPublicKey   string `json:"sql_auth_public_key"`
@@ -339,9 +501,37 @@ func (s *AuthService) GetLoginInfoHandler(c *gin.Context) {
 // @Param message body AuthenticateForm true "Credentials"
 // @Success 200 {object} TokenResponse
 // @Failure 401 {object} rest.ErrorResponse
+// @Failure 429 {object} rest.ErrorResponse
 // @Router /user/login [post]
 func (s *AuthService) LoginHandler(c *gin.Context) {
+	ip := c.ClientIP()
+	username := peekLoginUsername(c)
+	if wait := s.loginLimiter.allow(ip, username); wait > 0 {
+		rest.Error(c, ErrSignInTooManyAttempts.New("too many sign in attempts, please retry later").
+			WithProperty(rest.HTTPCodeProperty(http.StatusTooManyRequests)).
+			WithProperty(rest.RetryAfterProperty(wait)))
+		return
+	}
 	s.middleware.LoginHandler(c)
+	// Signing in may still fail after authenticated, e.g. when the session cannot be tracked.
+	if rest.ResponseStatus(c) == http.StatusOK {
+		s.loginLimiter.recordSuccess(ip, username)
+	} else {
+		s.loginLimiter.recordFailure(ip, username)
+	}
+}
+
+// IssueToken issues a new session token for the user, e.g. after the session is changed.
+func (s *AuthService) IssueToken(u *utils.SessionUser) (*TokenResponse, error) {
+	token, expire, err := s.middleware.TokenGenerator(u)
+	if err != nil {
+		return nil, err
+	}
+	return &TokenResponse{
+		Token:        token,
+		Expire:       expire,
+		Capabilities: u.Capabilities,
+	}, nil
 }
 
 type GetSignOutInfoRequest struct {
@@ -0,0 +1,144 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package user
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/pingcap/check"
+
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/utils"
+	"github.com/pingcap/tidb-dashboard/pkg/config"
+	"github.com/pingcap/tidb-dashboard/util/featureflag"
+	"github.com/pingcap/tidb-dashboard/util/rest"
+)
+
+var _ = Suite(&testAuthSuite{})
+
+type testAuthSuite struct{}
+
+// testCapabilityAuthenticator signs in `root` with CapabilityView, and other users as restricted users.
+type testCapabilityAuthenticator struct {
+	BaseAuthenticator
+}
+
+func (a testCapabilityAuthenticator) Authenticate(f AuthenticateForm) (*utils.SessionUser, error) {
+	capabilities := restrictedCapabilities()
+	if f.Username == "root" {
+		capabilities = []utils.Capability{utils.CapabilityView}
+	}
+	return &utils.SessionUser{Version: utils.SessionVersion, DisplayName: f.Username, Capabilities: capabilities}, nil
+}
+
+func (t *testAuthSuite) Test_MWAuthRequiredFor(c *C) {
+	gin.SetMode(gin.TestMode)
+	s := NewAuthService(featureflag.NewRegistry("v6.0.0"), &config.Config{})
+	s.RegisterAuthenticator(0, testCapabilityAuthenticator{})
+	engine := gin.New()
+	engine.Use(rest.ErrorHandlerFn())
+	registerRouter(&engine.RouterGroup, s)
+	ok := func(c *gin.Context) { c.JSON(http.StatusOK, nil) }
+	engine.GET("/view", s.MWAuthRequired(), ok)
+	engine.GET("/statements", s.MWAuthRequiredFor(utils.CapabilityViewStatements), ok)
+	engine.GET("/any", s.MWAuthRequiredFor(""), ok)
+
+	login := func(username string) TokenResponse {
+		r := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/user/login", strings.NewReader(`{"username":"`+username+`"}`))
+		engine.ServeHTTP(r, req)
+		c.Assert(r.Code, Equals, http.StatusOK)
+		var resp TokenResponse
+		c.Assert(json.Unmarshal(r.Body.Bytes(), &resp), IsNil)
+		return resp
+	}
+	get := func(path, token string) *httptest.ResponseRecorder {
+		r := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		engine.ServeHTTP(r, req)
+		return r
+	}
+
+	// CapabilityView implies other viewing capabilities.
+	root := login("root")
+	c.Assert(get("/view", root.Token).Code, Equals, http.StatusOK)
+	c.Assert(get("/statements", root.Token).Code, Equals, http.StatusOK)
+	c.Assert(get("/any", root.Token).Code, Equals, http.StatusOK)
+
+	restricted := login("guest")
+	c.Assert(restricted.Capabilities, DeepEquals, []utils.Capability{utils.CapabilityViewStatements})
+	r := get("/view", restricted.Token)
+	c.Assert(r.Code, Equals, http.StatusForbidden)
+	var resp rest.ErrorResponse
+	c.Assert(json.Unmarshal(r.Body.Bytes(), &resp), IsNil)
+	c.Assert(resp.Code, Equals, "common.forbidden")
+	c.Assert(get("/statements", restricted.Token).Code, Equals, http.StatusOK)
+	c.Assert(get("/any", restricted.Token).Code, Equals, http.StatusOK)
+
+	c.Assert(get("/any", "invalid").Code, Equals, http.StatusUnauthorized)
+}
+
+type testAPIKeyVerifier map[string]*utils.SessionUser
+
+func (v testAPIKeyVerifier) VerifyAPIKey(key string) *utils.SessionUser {
+	return v[key]
+}
+
+type testSessionTracker map[string]bool
+
+func (t testSessionTracker) TrackSession(c *gin.Context, u *utils.SessionUser, expire time.Time) error {
+	t[u.SessionID] = true
+	return nil
+}
+
+func (t testSessionTracker) UntrackSession(id string) error {
+	delete(t, id)
+	return nil
+}
+
+func (t testSessionTracker) IsSessionActive(id string) bool {
+	return t[id]
+}
+
+func (t *testAuthSuite) Test_MWAuthRequiredAPIKey(c *C) {
+	gin.SetMode(gin.TestMode)
+	s := NewAuthService(featureflag.NewRegistry("v6.0.0"), &config.Config{})
+	s.RegisterAuthenticator(0, testCapabilityAuthenticator{})
+	s.RegisterSessionTracker(testSessionTracker{"active": true})
+	session := func(version int, authFrom utils.AuthType, sessionID string) *utils.SessionUser {
+		return &utils.SessionUser{
+			Version:      version,
+			AuthFrom:     authFrom,
+			SessionID:    sessionID,
+			Capabilities: []utils.Capability{utils.CapabilityView},
+		}
+	}
+	s.RegisterAPIKeyVerifier(testAPIKeyVerifier{
+		"valid":         session(utils.SessionVersion, 0, "active"),
+		"outdated":      session(utils.SessionVersion-1, 0, "active"),
+		"unknown_auth":  session(utils.SessionVersion, 9, "active"),
+		"inactive":      session(utils.SessionVersion, 0, "revoked"),
+		"empty_session": session(utils.SessionVersion, 0, ""),
+	})
+	engine := gin.New()
+	engine.Use(rest.ErrorHandlerFn())
+	engine.GET("/view", s.MWAuthRequired(), func(c *gin.Context) { c.JSON(http.StatusOK, nil) })
+
+	get := func(key string) int {
+		r := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/view", nil)
+		req.Header.Set(APIKeyHeader, key)
+		engine.ServeHTTP(r, req)
+		return r.Code
+	}
+	c.Assert(get("valid"), Equals, http.StatusOK)
+	// Sessions of API keys are validated the same as sessions in tokens.
+	for _, key := range []string{"outdated", "unknown_auth", "inactive", "empty_session", "missing"} {
+		c.Assert(get(key), Equals, http.StatusUnauthorized, Commentf("key %s", key))
+	}
+}
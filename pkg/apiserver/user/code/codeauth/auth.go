@@ -45,5 +45,8 @@ func (a *Authenticator) Authenticate(f user.AuthenticateForm) (*utils.SessionUse
 }
 
 func (a *Authenticator) ProcessSession(user *utils.SessionUser) bool {
+	if a.sharingCodeService.IsSessionRevoked(user) {
+		return false
+	}
 	return !time.Now().After(user.SharedSessionExpireAt)
 }
@@ -8,15 +8,17 @@ import (
 
 	"github.com/gin-gonic/gin"
 
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/audit"
 	"github.com/pingcap/tidb-dashboard/pkg/apiserver/user"
 	"github.com/pingcap/tidb-dashboard/pkg/apiserver/utils"
 	"github.com/pingcap/tidb-dashboard/util/rest"
 )
 
-func registerRouter(r *gin.RouterGroup, auth *user.AuthService, s *Service) {
+func registerRouter(r *gin.RouterGroup, auth *user.AuthService, a *audit.Service, s *Service) {
 	endpoint := r.Group("/user/share")
 	endpoint.Use(auth.MWAuthRequired())
-	endpoint.POST("/code", auth.MWRequireSharePriv(), s.ShareHandler)
+	endpoint.POST("/code", auth.MWRequireSharePriv(), a.MWRecord("user.share.create"), s.ShareHandler)
+	endpoint.POST("/revoke", auth.MWRequireWritePriv(), a.MWRecord("user.share.revoke_all"), s.RevokeHandler)
 }
 
 type ShareRequest struct {
@@ -57,3 +59,15 @@ func (s *Service) ShareHandler(c *gin.Context) {
 
 	c.JSON(http.StatusOK, ShareResponse{Code: *code})
 }
+
+// @ID userRevokeSharedSessions
+// @Summary Revoke all sharing codes by rotating the sharing secret
+// @Security JwtAuth
+// @Success 200 {string} string
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 403 {object} rest.ErrorResponse
+// @Router /user/share/revoke [post]
+func (s *Service) RevokeHandler(c *gin.Context) {
+	s.RotateSharingSecret()
+	c.JSON(http.StatusOK, nil)
+}
@@ -3,8 +3,10 @@
 package code
 
 import (
+	"crypto/rand"
 	"encoding/hex"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/gtank/cryptopasta"
@@ -26,7 +28,10 @@ const (
 )
 
 type Service struct {
+	mu            sync.RWMutex
 	sharingSecret *[32]byte
+	// sharingSecretID is a random ID of the sharing secret, which is kept in sessions signed in with sharing codes.
+	sharingSecretID string
 }
 
 type sharedSession struct {
@@ -36,9 +41,9 @@ type sharedSession struct {
 }
 
 func NewService() *Service {
-	return &Service{
-		sharingSecret: cryptopasta.NewEncryptionKey(),
-	}
+	s := &Service{}
+	s.RotateSharingSecret()
+	return s
 }
 
 var Module = fx.Options(
@@ -46,13 +51,38 @@ var Module = fx.Options(
 	fx.Invoke(registerRouter),
 )
 
+// RotateSharingSecret replaces the key used to encrypt sharing codes, so that all sharing codes
+// issued before are no longer accepted, and sessions signed in with them are no longer valid.
+func (s *Service) RotateSharingSecret() {
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sharingSecret = cryptopasta.NewEncryptionKey()
+	s.sharingSecretID = hex.EncodeToString(id)
+}
+
+func (s *Service) secret() (*[32]byte, string) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.sharingSecret, s.sharingSecretID
+}
+
+// IsSessionRevoked returns whether the session signed in with a sharing code is revoked by rotating the secret.
+func (s *Service) IsSessionRevoked(session *utils.SessionUser) bool {
+	_, id := s.secret()
+	return session.SharingSecretID != id
+}
+
 func (s *Service) NewSessionFromSharingCode(codeInHex string) *utils.SessionUser {
 	encrypted, err := hex.DecodeString(codeInHex)
 	if err != nil {
 		return nil
 	}
 
-	b, err := cryptopasta.Decrypt(encrypted, s.sharingSecret)
+	secret, secretID := s.secret()
+	b, err := cryptopasta.Decrypt(encrypted, secret)
 	if err != nil {
 		return nil
 	}
@@ -67,10 +97,11 @@ func (s *Service) NewSessionFromSharingCode(codeInHex string) *utils.SessionUser
 	}
 
 	shared.Session.SharedSessionExpireAt = shared.ExpireAt
+	shared.Session.SharingSecretID = secretID
 	shared.Session.DisplayName = fmt.Sprintf("Shared from %s", shared.Session.DisplayName)
 	shared.Session.IsShareable = false
 	if shared.RevokeWritePriv {
-		shared.Session.IsWriteable = false
+		shared.Session.RevokeWritePriv()
 	}
 
 	return shared.Session
@@ -99,7 +130,8 @@ func (s *Service) SharingCodeFromSession(session *utils.SessionUser, expireIn ti
 		return nil
 	}
 
-	encrypted, err := cryptopasta.Encrypt(b, s.sharingSecret)
+	secret, _ := s.secret()
+	encrypted, err := cryptopasta.Encrypt(b, secret)
 	if err != nil {
 		return nil
 	}
@@ -0,0 +1,41 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package code
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/utils"
+)
+
+func TestSharingCode(t *testing.T) {
+	s := NewService()
+	session := &utils.SessionUser{
+		DisplayName:  "root",
+		IsShareable:  true,
+		IsWriteable:  true,
+		Capabilities: []utils.Capability{utils.CapabilityView, utils.CapabilityWrite, utils.CapabilityManageTopology},
+	}
+
+	code := s.SharingCodeFromSession(session, time.Hour, true)
+	require.NotNil(t, code)
+	shared := s.NewSessionFromSharingCode(*code)
+	require.NotNil(t, shared)
+	require.Equal(t, "Shared from root", shared.DisplayName)
+	require.False(t, shared.IsWriteable)
+	require.Equal(t, []utils.Capability{utils.CapabilityView}, shared.Capabilities)
+	require.False(t, shared.IsShareable)
+	require.False(t, s.IsSessionRevoked(shared))
+
+	require.Nil(t, s.SharingCodeFromSession(session, MaxSessionShareExpiry+time.Second, false))
+
+	// Codes issued before the rotation are rejected, and sessions signed in with them are revoked.
+	s.RotateSharingSecret()
+	require.Nil(t, s.NewSessionFromSharingCode(*code))
+	require.True(t, s.IsSessionRevoked(shared))
+	// Sessions without the ID of a sharing secret are treated as revoked.
+	require.True(t, s.IsSessionRevoked(session))
+}
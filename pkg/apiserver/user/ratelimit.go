@@ -0,0 +1,190 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package user
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/pingcap/tidb-dashboard/pkg/config"
+)
+
+// loginRateWindow is the window of LoginRateLimitPerIP, which is also the interval of sweeping stale entries.
+const loginRateWindow = time.Minute
+
+// loginLimiter throttles sign in attempts of each source IP, and locks out source IPs and usernames with too many
+// consecutive failed attempts, in order to slow down guessing passwords.
+type loginLimiter struct {
+	ratePerIP          int
+	maxFailuresPerIP   int
+	maxFailuresPerUser int
+	lockout            time.Duration
+	now                func() time.Time
+
+	mu        sync.Mutex
+	entries   map[string]*loginEntry
+	lastSweep time.Time
+}
+
+type loginEntry struct {
+	// windowStart and attempts count attempts in the current window.
+	windowStart time.Time
+	attempts    int
+	// failures are consecutive failed attempts, which are forgotten once lockout has passed since the last one.
+	failures     int
+	lastFailedAt time.Time
+	lockedUntil  time.Time
+}
+
+func newLoginLimiter(cfg *config.Config) *loginLimiter {
+	return &loginLimiter{
+		ratePerIP:          cfg.LoginRateLimitPerIP,
+		maxFailuresPerIP:   cfg.LoginMaxFailuresPerIP,
+		maxFailuresPerUser: cfg.LoginMaxFailuresPerUser,
+		lockout:            cfg.LoginLockoutDuration,
+		now:                time.Now,
+		entries:            map[string]*loginEntry{},
+	}
+}
+
+func ipLimitKey(ip string) string {
+	return "ip/" + ip
+}
+
+func userLimitKey(username string) string {
+	return "user/" + username
+}
+
+func (e *loginEntry) isStale(now time.Time, lockout time.Duration) bool {
+	return !now.Before(e.lockedUntil) &&
+		now.Sub(e.windowStart) >= loginRateWindow &&
+		(e.failures == 0 || now.Sub(e.lastFailedAt) >= lockout)
+}
+
+// sweep removes stale entries, at most once in a window.
+func (l *loginLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < loginRateWindow {
+		return
+	}
+	l.lastSweep = now
+	for key, e := range l.entries {
+		if e.isStale(now, l.lockout) {
+			delete(l.entries, key)
+		}
+	}
+}
+
+func (l *loginLimiter) entry(key string) *loginEntry {
+	e, ok := l.entries[key]
+	if !ok {
+		e = &loginEntry{}
+		l.entries[key] = e
+	}
+	return e
+}
+
+// allow records a sign in attempt. It returns how long to wait before retrying if the attempt is rejected, or 0 if
+// the attempt is allowed.
+func (l *loginLimiter) allow(ip, username string) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	var wait time.Duration
+	if e, ok := l.entries[ipLimitKey(ip)]; ok {
+		wait = e.lockedUntil.Sub(now)
+	}
+	if username != "" {
+		if e, ok := l.entries[userLimitKey(username)]; ok && e.lockedUntil.Sub(now) > wait {
+			wait = e.lockedUntil.Sub(now)
+		}
+	}
+	if wait > 0 {
+		return wait
+	}
+
+	if l.ratePerIP > 0 {
+		e := l.entry(ipLimitKey(ip))
+		if now.Sub(e.windowStart) >= loginRateWindow {
+			e.windowStart = now
+			e.attempts = 0
+		}
+		if e.attempts >= l.ratePerIP {
+			return e.windowStart.Add(loginRateWindow).Sub(now)
+		}
+		e.attempts++
+	}
+	return 0
+}
+
+func (l *loginLimiter) recordFailureOf(key string, maxFailures int, now time.Time) {
+	if maxFailures <= 0 || l.lockout <= 0 {
+		return
+	}
+	e := l.entry(key)
+	if now.Sub(e.lastFailedAt) >= l.lockout {
+		e.failures = 0
+	}
+	e.failures++
+	e.lastFailedAt = now
+	if e.failures >= maxFailures {
+		e.lockedUntil = now.Add(l.lockout)
+		e.failures = 0
+	}
+}
+
+// recordFailure counts a failed sign in attempt, which may lock out the source IP or the username.
+func (l *loginLimiter) recordFailure(ip, username string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.recordFailureOf(ipLimitKey(ip), l.maxFailuresPerIP, now)
+	if username != "" {
+		l.recordFailureOf(userLimitKey(username), l.maxFailuresPerUser, now)
+	}
+}
+
+// recordSuccess forgets the consecutive failed sign in attempts of the source IP and the username.
+func (l *loginLimiter) recordSuccess(ip, username string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if e, ok := l.entries[ipLimitKey(ip)]; ok {
+		e.failures = 0
+	}
+	if username != "" {
+		if e, ok := l.entries[userLimitKey(username)]; ok {
+			e.failures = 0
+		}
+	}
+}
+
+// maxLoginBodySize caps the sign in form, which is read before the sender is authenticated.
+const maxLoginBodySize = 64 << 10
+
+// peekLoginUsername reads the username in the sign in form, leaving the request body intact for the authenticator.
+// No username is returned when the form exceeds maxLoginBodySize.
+func peekLoginUsername(c *gin.Context) string {
+	if c.Request.Body == nil {
+		return ""
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxLoginBodySize))
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return ""
+	}
+	var form struct {
+		Username string `json:"username"`
+	}
+	_ = json.Unmarshal(body, &form)
+	return form.Username
+}
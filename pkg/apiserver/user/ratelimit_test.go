@@ -0,0 +1,193 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package user
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/pingcap/check"
+
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/utils"
+	"github.com/pingcap/tidb-dashboard/pkg/config"
+	"github.com/pingcap/tidb-dashboard/util/featureflag"
+	"github.com/pingcap/tidb-dashboard/util/rest"
+)
+
+var _ = Suite(&testLoginLimiterSuite{})
+
+type testLoginLimiterSuite struct{}
+
+func newTestLoginLimiter(cfg *config.Config) (*loginLimiter, *time.Time) {
+	now := time.Unix(1704067200, 0)
+	l := newLoginLimiter(cfg)
+	l.now = func() time.Time { return now }
+	return l, &now
+}
+
+func (t *testLoginLimiterSuite) Test_rateLimit(c *C) {
+	l, now := newTestLoginLimiter(&config.Config{LoginRateLimitPerIP: 3})
+	for i := 0; i < 3; i++ {
+		c.Assert(l.allow("10.0.0.1", "root"), Equals, time.Duration(0))
+	}
+	*now = now.Add(20 * time.Second)
+	c.Assert(l.allow("10.0.0.1", "root"), Equals, 40*time.Second)
+	// Other source IPs are not affected.
+	c.Assert(l.allow("10.0.0.2", "root"), Equals, time.Duration(0))
+
+	*now = now.Add(40 * time.Second)
+	c.Assert(l.allow("10.0.0.1", "root"), Equals, time.Duration(0))
+}
+
+func (t *testLoginLimiterSuite) Test_lockoutUser(c *C) {
+	l, now := newTestLoginLimiter(&config.Config{LoginMaxFailuresPerUser: 3, LoginLockoutDuration: 5 * time.Minute})
+	for i := 0; i < 2; i++ {
+		c.Assert(l.allow("10.0.0.1", "root"), Equals, time.Duration(0))
+		l.recordFailure("10.0.0.1", "root")
+	}
+	// Failures are consecutive, which are reset by a success.
+	l.recordSuccess("10.0.0.1", "root")
+	for i := 0; i < 3; i++ {
+		c.Assert(l.allow("10.0.0.1", "root"), Equals, time.Duration(0))
+		l.recordFailure("10.0.0.1", "root")
+	}
+	*now = now.Add(time.Minute)
+	c.Assert(l.allow("10.0.0.2", "root"), Equals, 4*time.Minute)
+	c.Assert(l.allow("10.0.0.1", "admin"), Equals, time.Duration(0))
+
+	*now = now.Add(4 * time.Minute)
+	c.Assert(l.allow("10.0.0.2", "root"), Equals, time.Duration(0))
+}
+
+func (t *testLoginLimiterSuite) Test_lockoutIP(c *C) {
+	l, now := newTestLoginLimiter(&config.Config{LoginMaxFailuresPerIP: 2, LoginLockoutDuration: time.Minute})
+	l.recordFailure("10.0.0.1", "a")
+	// Failures earlier than the lockout duration are forgotten.
+	*now = now.Add(2 * time.Minute)
+	l.recordFailure("10.0.0.1", "b")
+	c.Assert(l.allow("10.0.0.1", "c"), Equals, time.Duration(0))
+	l.recordFailure("10.0.0.1", "c")
+	c.Assert(l.allow("10.0.0.1", "d"), Equals, time.Minute)
+	c.Assert(l.allow("10.0.0.2", "d"), Equals, time.Duration(0))
+}
+
+func (t *testLoginLimiterSuite) Test_sweep(c *C) {
+	l, now := newTestLoginLimiter(&config.Config{
+		LoginRateLimitPerIP:     10,
+		LoginMaxFailuresPerUser: 3,
+		LoginLockoutDuration:    5 * time.Minute,
+	})
+	l.allow("10.0.0.1", "root")
+	l.recordFailure("10.0.0.1", "root")
+	c.Assert(l.entries, HasLen, 2)
+
+	*now = now.Add(2 * time.Minute)
+	l.allow("10.0.0.2", "")
+	c.Assert(l.entries, HasLen, 2)
+
+	*now = now.Add(4 * time.Minute)
+	l.allow("10.0.0.2", "")
+	c.Assert(l.entries, HasLen, 1)
+}
+
+type testPasswordAuthenticator struct {
+	BaseAuthenticator
+}
+
+func (a testPasswordAuthenticator) Authenticate(f AuthenticateForm) (*utils.SessionUser, error) {
+	if f.Password != "pass" {
+		return nil, rest.ErrUnauthenticated.New("bad password")
+	}
+	return &utils.SessionUser{Version: utils.SessionVersion, DisplayName: f.Username}, nil
+}
+
+func (t *testLoginLimiterSuite) Test_LoginHandler(c *C) {
+	gin.SetMode(gin.TestMode)
+	s := NewAuthService(featureflag.NewRegistry("v6.0.0"), &config.Config{
+		LoginMaxFailuresPerUser: 2,
+		LoginLockoutDuration:    time.Minute,
+	})
+	s.RegisterAuthenticator(0, testPasswordAuthenticator{})
+	engine := gin.New()
+	engine.Use(rest.ErrorHandlerFn())
+	registerRouter(&engine.RouterGroup, s)
+
+	login := func(password string) *httptest.ResponseRecorder {
+		r := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/user/login", strings.NewReader(`{"username":"root","password":"`+password+`"}`))
+		engine.ServeHTTP(r, req)
+		return r
+	}
+
+	c.Assert(login("bad").Code, Equals, http.StatusUnauthorized)
+	c.Assert(login("pass").Code, Equals, http.StatusOK)
+	c.Assert(login("bad").Code, Equals, http.StatusUnauthorized)
+	c.Assert(login("bad").Code, Equals, http.StatusUnauthorized)
+
+	// Even the correct password is rejected when locked out.
+	r := login("pass")
+	c.Assert(r.Code, Equals, http.StatusTooManyRequests)
+	c.Assert(r.Header().Get("Retry-After"), Equals, "60")
+	var resp rest.ErrorResponse
+	c.Assert(json.Unmarshal(r.Body.Bytes(), &resp), IsNil)
+	c.Assert(resp.Code, Equals, "api.user.signin.too_many_attempts")
+	c.Assert(resp.RetryAfterSec, Equals, int64(60))
+}
+
+type testFailingSessionTracker struct {
+	testSessionTracker
+}
+
+func (t testFailingSessionTracker) TrackSession(c *gin.Context, u *utils.SessionUser, expire time.Time) error {
+	return rest.ErrBadRequest.New("cannot track session")
+}
+
+func (t *testLoginLimiterSuite) Test_LoginHandlerTrackSessionFailed(c *C) {
+	gin.SetMode(gin.TestMode)
+	s := NewAuthService(featureflag.NewRegistry("v6.0.0"), &config.Config{
+		LoginMaxFailuresPerUser: 1,
+		LoginLockoutDuration:    time.Minute,
+	})
+	s.RegisterAuthenticator(0, testPasswordAuthenticator{})
+	s.RegisterSessionTracker(testFailingSessionTracker{testSessionTracker{}})
+	engine := gin.New()
+	engine.Use(rest.ErrorHandlerFn())
+	registerRouter(&engine.RouterGroup, s)
+
+	login := func() int {
+		r := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/user/login", strings.NewReader(`{"username":"root","password":"pass"}`))
+		engine.ServeHTTP(r, req)
+		return r.Code
+	}
+
+	// The failed sign in is counted, so that the user is locked out.
+	c.Assert(login(), Equals, http.StatusBadRequest)
+	c.Assert(login(), Equals, http.StatusTooManyRequests)
+}
+
+func (t *testLoginLimiterSuite) Test_peekLoginUsername(c *C) {
+	gin.SetMode(gin.TestMode)
+	peek := func(body string) (string, string) {
+		ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+		ctx.Request, _ = http.NewRequest(http.MethodPost, "/user/login", strings.NewReader(body))
+		username := peekLoginUsername(ctx)
+		remaining, _ := io.ReadAll(ctx.Request.Body)
+		return username, string(remaining)
+	}
+
+	body := `{"username":"root","password":"pass"}`
+	username, remaining := peek(body)
+	c.Assert(username, Equals, "root")
+	c.Assert(remaining, Equals, body)
+
+	body = `{"username":"root","password":"` + strings.Repeat("x", maxLoginBodySize) + `"}`
+	username, remaining = peek(body)
+	c.Assert(username, Equals, "")
+	c.Assert(remaining, HasLen, maxLoginBodySize)
+}
@@ -0,0 +1,125 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package session
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/audit"
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/user"
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/utils"
+	"github.com/pingcap/tidb-dashboard/util/rest"
+)
+
+func registerRouter(r *gin.RouterGroup, auth *user.AuthService, a *audit.Service, s *Service) {
+	endpoint := r.Group("/user/sessions")
+	endpoint.Use(auth.MWAuthRequiredFor(""))
+	endpoint.GET("", s.listHandler)
+	endpoint.DELETE("", a.MWRecord("user.session.revoke_all"), s.revokeAllHandler)
+	endpoint.DELETE("/:id", a.MWRecord("user.session.revoke"), s.revokeHandler)
+
+	r.DELETE("/user/all_sessions",
+		auth.MWAuthRequired(),
+		auth.MWRequireWritePriv(),
+		a.MWRecord("user.session.revoke_all_users"),
+		s.revokeAllUsersHandler)
+}
+
+type SessionInfo struct {
+	SessionModel
+	// Current is whether it is the session of the request.
+	Current bool `json:"current"`
+}
+
+// @ID userListSessions
+// @Summary List active sessions of the current user
+// @Security JwtAuth
+// @Success 200 {array} SessionInfo
+// @Failure 401 {object} rest.ErrorResponse
+// @Router /user/sessions [get]
+func (s *Service) listHandler(c *gin.Context) {
+	u := utils.GetSession(c)
+	sessions, err := s.List(u.DisplayName)
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	resp := make([]SessionInfo, 0, len(sessions))
+	for _, m := range sessions {
+		resp = append(resp, SessionInfo{SessionModel: m, Current: m.ID == u.SessionID})
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// @ID userRevokeSession
+// @Summary Revoke a session of the current user
+// @Param id path string true "Session ID"
+// @Security JwtAuth
+// @Success 200 {string} string
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 404 {object} rest.ErrorResponse
+// @Router /user/sessions/{id} [delete]
+func (s *Service) revokeHandler(c *gin.Context) {
+	found, err := s.Revoke(utils.GetSession(c).DisplayName, c.Param("id"))
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	if !found {
+		rest.Error(c, rest.ErrNotFound.New("session not found"))
+		return
+	}
+	c.JSON(http.StatusOK, nil)
+}
+
+type RevokeAllRequest struct {
+	// ExceptCurrent keeps the session of the request signed in.
+	ExceptCurrent bool `form:"except_current"`
+}
+
+type RevokeAllResponse struct {
+	Revoked int64 `json:"revoked"`
+}
+
+func (s *Service) revokeAll(c *gin.Context, owner string) {
+	var req RevokeAllRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		rest.Error(c, rest.ErrBadRequest.WrapWithNoMessage(err))
+		return
+	}
+	exceptID := ""
+	if req.ExceptCurrent {
+		exceptID = utils.GetSession(c).SessionID
+	}
+	revoked, err := s.RevokeAll(owner, exceptID)
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, RevokeAllResponse{Revoked: revoked})
+}
+
+// @ID userRevokeAllSessions
+// @Summary Revoke all sessions of the current user
+// @Param q query RevokeAllRequest true "Query"
+// @Security JwtAuth
+// @Success 200 {object} RevokeAllResponse
+// @Failure 401 {object} rest.ErrorResponse
+// @Router /user/sessions [delete]
+func (s *Service) revokeAllHandler(c *gin.Context) {
+	s.revokeAll(c, utils.GetSession(c).DisplayName)
+}
+
+// @ID userRevokeAllUsersSessions
+// @Summary Revoke sessions of all users
+// @Param q query RevokeAllRequest true "Query"
+// @Security JwtAuth
+// @Success 200 {object} RevokeAllResponse
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 403 {object} rest.ErrorResponse
+// @Router /user/all_sessions [delete]
+func (s *Service) revokeAllUsersHandler(c *gin.Context) {
+	s.revokeAll(c, "")
+}
@@ -0,0 +1,120 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+// Package session tracks signed in sessions in the local store, so that users can list and revoke them.
+package session
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pingcap/log"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/user"
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/utils"
+	"github.com/pingcap/tidb-dashboard/pkg/dbstore"
+)
+
+// SessionModel is a signed in session. It is deleted when revoked, and purged after expired. Sessions of API keys
+// are tracked as well, but they are neither listed nor revoked with signed in sessions: they are only revoked
+// together with their keys.
+type SessionModel struct {
+	ID string `json:"id" gorm:"primary_key;size:32"`
+	// Owner is the display name of the signed in user.
+	Owner     string         `json:"owner" gorm:"index"`
+	AuthType  utils.AuthType `json:"auth_type"`
+	ClientIP  string         `json:"client_ip"`
+	UserAgent string         `json:"user_agent" gorm:"type:text"`
+	CreatedAt time.Time      `json:"created_at"`
+	ExpireAt  time.Time      `json:"expire_at" gorm:"index"`
+	IsAPIKey  bool           `json:"-" gorm:"not null;default:false"`
+}
+
+func (SessionModel) TableName() string {
+	return "user_sessions"
+}
+
+type Service struct {
+	db  *dbstore.DB
+	now func() time.Time
+}
+
+func NewService(db *dbstore.DB) *Service {
+	if err := db.AutoMigrate(&SessionModel{}); err != nil {
+		log.Fatal("Failed to initialize database", zap.Error(err))
+	}
+	return &Service{db: db, now: time.Now}
+}
+
+func registerTracker(s *Service, authService *user.AuthService) {
+	authService.RegisterSessionTracker(s)
+}
+
+var Module = fx.Options(
+	fx.Provide(NewService),
+	fx.Invoke(registerTracker, registerRouter),
+)
+
+// TrackSession implements user.SessionTracker. Expired sessions are purged meanwhile.
+func (s *Service) TrackSession(c *gin.Context, u *utils.SessionUser, expire time.Time) error {
+	now := s.now()
+	if err := s.db.Where("expire_at <= ?", now).Delete(&SessionModel{}).Error; err != nil {
+		log.Warn("Failed to purge expired sessions", zap.Error(err))
+	}
+	return s.db.Create(&SessionModel{
+		ID:        u.SessionID,
+		Owner:     u.DisplayName,
+		AuthType:  u.AuthFrom,
+		ClientIP:  c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+		CreatedAt: now,
+		ExpireAt:  expire,
+		IsAPIKey:  u.IsAPIKey,
+	}).Error
+}
+
+// UntrackSession implements user.SessionTracker.
+func (s *Service) UntrackSession(id string) error {
+	return s.db.Where("id = ?", id).Delete(&SessionModel{}).Error
+}
+
+// IsSessionActive implements user.SessionTracker.
+func (s *Service) IsSessionActive(id string) bool {
+	if id == "" {
+		return false
+	}
+	var count int64
+	err := s.db.Model(&SessionModel{}).Where("id = ? AND expire_at > ?", id, s.now()).Count(&count).Error
+	if err != nil {
+		log.Warn("Failed to check the session", zap.String("id", id), zap.Error(err))
+		return false
+	}
+	return count > 0
+}
+
+// List lists active signed in sessions of the owner, the latest first.
+func (s *Service) List(owner string) ([]SessionModel, error) {
+	var sessions []SessionModel
+	err := s.db.Where("owner = ? AND expire_at > ? AND is_api_key = ?", owner, s.now(), false).
+		Order("created_at DESC").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+// Revoke deletes the signed in session of the owner, returning whether the session exists.
+func (s *Service) Revoke(owner, id string) (bool, error) {
+	tx := s.db.Where("id = ? AND owner = ? AND is_api_key = ?", id, owner, false).Delete(&SessionModel{})
+	return tx.RowsAffected > 0, tx.Error
+}
+
+// RevokeAll deletes all signed in sessions of the owner, or sessions of all users when the owner is empty. The
+// session of exceptID is kept if it is not empty. It returns the number of revoked sessions.
+func (s *Service) RevokeAll(owner, exceptID string) (int64, error) {
+	tx := s.db.Where("id <> ? AND is_api_key = ?", exceptID, false)
+	if owner != "" {
+		tx = tx.Where("owner = ?", owner)
+	}
+	tx = tx.Delete(&SessionModel{})
+	return tx.RowsAffected, tx.Error
+}
@@ -0,0 +1,170 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package session
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/user"
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/utils"
+	"github.com/pingcap/tidb-dashboard/pkg/config"
+	"github.com/pingcap/tidb-dashboard/pkg/dbstore/dbstoretest"
+	"github.com/pingcap/tidb-dashboard/util/featureflag"
+	"github.com/pingcap/tidb-dashboard/util/rest"
+)
+
+func newTestService(t *testing.T) *Service {
+	return NewService(dbstoretest.New(t))
+}
+
+func newTestContext(ip string) *gin.Context {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request, _ = http.NewRequest(http.MethodPost, "/", nil)
+	c.Request.RemoteAddr = ip + ":12345"
+	c.Request.Header.Set("User-Agent", "test")
+	return c
+}
+
+func TestSession(t *testing.T) {
+	s := newTestService(t)
+	now := time.Now()
+	c := newTestContext("10.0.0.1")
+
+	require.NoError(t, s.TrackSession(c, &utils.SessionUser{SessionID: "a", DisplayName: "root"}, now.Add(time.Hour)))
+	require.NoError(t, s.TrackSession(c, &utils.SessionUser{SessionID: "b", DisplayName: "root"}, now.Add(time.Hour)))
+	require.NoError(t, s.TrackSession(c, &utils.SessionUser{SessionID: "c", DisplayName: "guest"}, now.Add(time.Hour)))
+	require.NoError(t, s.TrackSession(c, &utils.SessionUser{SessionID: "d", DisplayName: "root"}, now.Add(-time.Second)))
+
+	require.True(t, s.IsSessionActive("a"))
+	require.False(t, s.IsSessionActive("d"))
+	require.False(t, s.IsSessionActive("x"))
+	require.False(t, s.IsSessionActive(""))
+
+	sessions, err := s.List("root")
+	require.NoError(t, err)
+	require.Len(t, sessions, 2)
+	require.Equal(t, "10.0.0.1", sessions[0].ClientIP)
+	require.Equal(t, "test", sessions[0].UserAgent)
+
+	// Sessions of other users cannot be revoked.
+	found, err := s.Revoke("root", "c")
+	require.NoError(t, err)
+	require.False(t, found)
+	found, err = s.Revoke("root", "a")
+	require.NoError(t, err)
+	require.True(t, found)
+	require.False(t, s.IsSessionActive("a"))
+
+	// Expired sessions are purged when a session is tracked.
+	require.NoError(t, s.TrackSession(c, &utils.SessionUser{SessionID: "e", DisplayName: "root"}, now.Add(time.Hour)))
+	var count int64
+	require.NoError(t, s.db.Model(&SessionModel{}).Where("id = ?", "d").Count(&count).Error)
+	require.Zero(t, count)
+
+	revoked, err := s.RevokeAll("root", "e")
+	require.NoError(t, err)
+	require.Equal(t, int64(1), revoked)
+	require.True(t, s.IsSessionActive("e"))
+	require.True(t, s.IsSessionActive("c"))
+
+	revoked, err = s.RevokeAll("", "")
+	require.NoError(t, err)
+	require.Equal(t, int64(2), revoked)
+	require.False(t, s.IsSessionActive("c"))
+}
+
+func TestAPIKeySession(t *testing.T) {
+	s := newTestService(t)
+	now := time.Now()
+	c := newTestContext("10.0.0.1")
+
+	require.NoError(t, s.TrackSession(c, &utils.SessionUser{SessionID: "a", DisplayName: "root"}, now.Add(time.Hour)))
+	require.NoError(t, s.TrackSession(c, &utils.SessionUser{SessionID: "k", DisplayName: "root", IsAPIKey: true}, now.Add(time.Hour)))
+
+	// Sessions of API keys are neither listed nor revoked with signed in sessions.
+	sessions, err := s.List("root")
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	require.Equal(t, "a", sessions[0].ID)
+	found, err := s.Revoke("root", "k")
+	require.NoError(t, err)
+	require.False(t, found)
+	revoked, err := s.RevokeAll("root", "")
+	require.NoError(t, err)
+	require.Equal(t, int64(1), revoked)
+	revoked, err = s.RevokeAll("", "")
+	require.NoError(t, err)
+	require.Zero(t, revoked)
+	require.True(t, s.IsSessionActive("k"))
+
+	// They are revoked together with their keys.
+	require.NoError(t, s.UntrackSession("k"))
+	require.False(t, s.IsSessionActive("k"))
+}
+
+type testAuthenticator struct {
+	user.BaseAuthenticator
+}
+
+func (a testAuthenticator) Authenticate(f user.AuthenticateForm) (*utils.SessionUser, error) {
+	return &utils.SessionUser{
+		Version:      utils.SessionVersion,
+		DisplayName:  f.Username,
+		Capabilities: []utils.Capability{utils.CapabilityView},
+	}, nil
+}
+
+func TestRevokedSessionRejected(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := newTestService(t)
+	auth := user.NewAuthService(featureflag.NewRegistry("v6.0.0"), &config.Config{})
+	auth.RegisterAuthenticator(0, testAuthenticator{})
+	registerTracker(s, auth)
+
+	engine := gin.New()
+	engine.Use(rest.ErrorHandlerFn())
+	engine.POST("/user/login", auth.LoginHandler)
+	engine.GET("/user/sessions", auth.MWAuthRequired(), s.listHandler)
+
+	login := func() string {
+		r := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/user/login", strings.NewReader(`{"username":"root"}`))
+		engine.ServeHTTP(r, req)
+		require.Equal(t, http.StatusOK, r.Code)
+		var resp user.TokenResponse
+		require.NoError(t, json.Unmarshal(r.Body.Bytes(), &resp))
+		return resp.Token
+	}
+	listSessions := func(token string) (int, []SessionInfo) {
+		r := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/user/sessions", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		engine.ServeHTTP(r, req)
+		var resp []SessionInfo
+		_ = json.Unmarshal(r.Body.Bytes(), &resp)
+		return r.Code, resp
+	}
+
+	token1 := login()
+	token2 := login()
+	code, sessions := listSessions(token1)
+	require.Equal(t, http.StatusOK, code)
+	require.Len(t, sessions, 2)
+	require.NotEqual(t, sessions[0].Current, sessions[1].Current)
+
+	// Revoked sessions are rejected like outdated sessions.
+	_, err := s.RevokeAll("root", "")
+	require.NoError(t, err)
+	code, _ = listSessions(token1)
+	require.Equal(t, http.StatusForbidden, code)
+	code, _ = listSessions(token2)
+	require.Equal(t, http.StatusForbidden, code)
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/pingcap/tidb-dashboard/pkg/apiserver/user"
 	"github.com/pingcap/tidb-dashboard/pkg/apiserver/utils"
+	"github.com/pingcap/tidb-dashboard/pkg/config"
 	"github.com/pingcap/tidb-dashboard/pkg/tidb"
 )
 
@@ -17,11 +18,13 @@ type Authenticator struct {
 	user.BaseAuthenticator
 	tidbClient  *tidb.Client
 	authService *user.AuthService
+	config      *config.Config
 }
 
-func NewAuthenticator(tidbClient *tidb.Client) *Authenticator {
+func NewAuthenticator(tidbClient *tidb.Client, config *config.Config) *Authenticator {
 	return &Authenticator{
 		tidbClient: tidbClient,
+		config:     config,
 	}
 }
 
@@ -41,7 +44,7 @@ func (a *Authenticator) Authenticate(f user.AuthenticateForm) (*utils.SessionUse
 		return nil, user.ErrSignInOther.WrapWithNoMessage(err)
 	}
 
-	writeable, err := user.VerifySQLUser(a.tidbClient, f.Username, plainPwd)
+	capabilities, err := user.VerifySQLUser(a.tidbClient, f.Username, plainPwd, a.config.EnableRestrictedLogin)
 	if err != nil {
 		if errorx.Cast(err) == nil {
 			return nil, user.ErrSignInOther.WrapWithNoMessage(err)
@@ -62,6 +65,7 @@ func (a *Authenticator) Authenticate(f user.AuthenticateForm) (*utils.SessionUse
 		TiDBPassword: plainPwd,
 		DisplayName:  f.Username,
 		IsShareable:  true,
-		IsWriteable:  writeable,
+		IsWriteable:  utils.HasCapability(capabilities, utils.CapabilityWrite),
+		Capabilities: capabilities,
 	}, nil
 }
@@ -8,20 +8,21 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/joomcode/errorx"
 
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/audit"
 	"github.com/pingcap/tidb-dashboard/pkg/apiserver/user"
 	"github.com/pingcap/tidb-dashboard/pkg/config"
 	"github.com/pingcap/tidb-dashboard/util/rest"
 )
 
-func registerRouter(r *gin.RouterGroup, auth *user.AuthService, s *Service) {
+func registerRouter(r *gin.RouterGroup, auth *user.AuthService, a *audit.Service, s *Service) {
 	endpoint := r.Group("/user/sso")
 	endpoint.GET("/auth_url", s.getAuthURLHandler)
 	endpoint.Use(auth.MWAuthRequired())
 	// TODO: Forbid modifying config when signed in as SSO.
 	endpoint.GET("/impersonations/list", s.listImpersonationHandler)
-	endpoint.POST("/impersonation", auth.MWRequireWritePriv(), s.createImpersonationHandler)
+	endpoint.POST("/impersonation", auth.MWRequireWritePriv(), a.MWRecord("user.sso.create_impersonation", audit.RedactJSONFields("password")), s.createImpersonationHandler)
 	endpoint.GET("/config", s.getConfig)
-	endpoint.PUT("/config", auth.MWRequireWritePriv(), s.setConfig)
+	endpoint.PUT("/config", auth.MWRequireWritePriv(), a.MWRecord("user.sso.set_config", audit.RedactJSONFields("client_secret")), s.setConfig)
 }
 
 type GetAuthURLRequest struct {
@@ -174,7 +174,7 @@ func (s *Service) newSessionFromImpersonation(userInfo *oAuthUserInfo, idToken s
 	}
 
 	// Check whether this user can access dashboard
-	writeable, err := user.VerifySQLUser(s.params.TiDBClient, userName, password)
+	capabilities, err := user.VerifySQLUser(s.params.TiDBClient, userName, password, false)
 	if err != nil {
 		if errorx.IsOfType(err, tidb.ErrTiDBAuthFailed) {
 			_ = s.updateImpersonationStatus(userName, ImpersonateStatusAuthFail)
@@ -188,22 +188,27 @@ func (s *Service) newSessionFromImpersonation(userInfo *oAuthUserInfo, idToken s
 	}
 	_ = s.updateImpersonationStatus(userName, ImpersonateStatusSuccess)
 
-	return &utils.SessionUser{
+	u := &utils.SessionUser{
 		Version:      utils.SessionVersion,
 		HasTiDBAuth:  true,
 		TiDBUsername: userName,
 		TiDBPassword: password,
 		DisplayName:  userInfo.Email,
 		IsShareable:  true,
-		IsWriteable:  writeable && !dc.SSO.CoreConfig.IsReadOnly,
+		IsWriteable:  utils.HasCapability(capabilities, utils.CapabilityWrite),
+		Capabilities: capabilities,
 		OIDCIDToken:  idToken,
-	}, nil
+	}
+	if dc.SSO.CoreConfig.IsReadOnly {
+		u.RevokeWritePriv()
+	}
+	return u, nil
 }
 
 func (s *Service) createImpersonation(userName string, password string) (*SSOImpersonationModel, error) {
 	{
 		// Check whether this user can access dashboard
-		_, err := user.VerifySQLUser(s.params.TiDBClient, userName, password)
+		_, err := user.VerifySQLUser(s.params.TiDBClient, userName, password, false)
 		if err != nil {
 			if errorx.IsOfType(err, tidb.ErrTiDBAuthFailed) {
 				return nil, ErrInvalidImpersonateCredential.Wrap(err, "Invalid SQL credential")
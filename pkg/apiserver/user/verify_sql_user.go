@@ -29,10 +29,12 @@ type tidbSEMConfig struct {
 	SkipGrantTable bool `json:"skip-grant-table"`
 }
 
-func VerifySQLUser(tidbClient *tidb.Client, userName, password string) (writeable bool, err error) {
+// VerifySQLUser checks whether the SQL user can access the dashboard, and returns its capabilities. When
+// allowRestricted is true, users without the dashboard privileges are accepted with restrictedCapabilities.
+func VerifySQLUser(tidbClient *tidb.Client, userName, password string, allowRestricted bool) (capabilities []utils.Capability, err error) {
 	db, err := tidbClient.OpenSQLConn(userName, password)
 	if err != nil {
-		return false, err
+		return nil, err
 	}
 	defer utils.CloseTiDBConnection(db) //nolint:errcheck
 
@@ -40,32 +42,35 @@ func VerifySQLUser(tidbClient *tidb.Client, userName, password string) (writeabl
 	// 1. Get TiDB config
 	resData, err := tidbClient.SendGetRequest("/config")
 	if err != nil {
-		return false, err
+		return nil, err
 	}
 	var config tidbSecurityConfig
 	err = json.Unmarshal(resData, &config)
 	if err != nil {
-		return false, err
+		return nil, err
 	}
 	// 2. Check SkipGrantTable
 	// Note: Currently, if TiDB enable the skip-grant-table, running `show grants` will get error.
 	// So this is a workaround before the above bug is fixed.
 	if config.Security.SkipGrantTable {
-		return true, nil
+		return capabilitiesFromPrivs(map[string]struct{}{"ALL PRIVILEGES": {}}), nil
 	}
 	// 3. Get grants
 	var grantRows []string
 	err = db.Raw("show grants for current_user()").Find(&grantRows).Error
 	if err != nil {
-		return false, err
+		return nil, err
 	}
 	grants := parseUserGrants(grantRows)
 	// 4. Check grants
 	if !checkDashboardPriv(grants, config.Security.EnableSEM) {
-		return false, ErrInsufficientPrivs.NewWithNoMessage()
+		if allowRestricted {
+			return restrictedCapabilities(), nil
+		}
+		return nil, ErrInsufficientPrivs.NewWithNoMessage()
 	}
 
-	return checkWriteablePriv(grants), nil
+	return capabilitiesFromPrivs(grants), nil
 }
 
 var grantRegex = regexp.MustCompile(`GRANT (.+) ON`)
@@ -157,6 +162,29 @@ func checkWriteablePriv(privs map[string]struct{}) bool {
 	return false
 }
 
+// capabilitiesFromPrivs maps SQL privileges to dashboard capabilities. The dashboard privileges are
+// assumed to be checked already.
+// - view: always
+// - write: see checkWriteablePriv
+// - manage_topology: ALL PRIVILEGES or SUPER.
+// - manage_scheduling: ALL PRIVILEGES or SUPER.
+func capabilitiesFromPrivs(privs map[string]struct{}) []utils.Capability {
+	capabilities := []utils.Capability{utils.CapabilityView}
+	if checkWriteablePriv(privs) {
+		capabilities = append(capabilities, utils.CapabilityWrite)
+	}
+	if hasPriv("ALL PRIVILEGES", privs) || hasPriv("SUPER", privs) {
+		capabilities = append(capabilities, utils.CapabilityManageTopology, utils.CapabilityManageScheduling)
+	}
+	return capabilities
+}
+
+// restrictedCapabilities are capabilities of users without the dashboard privileges, who are only able to view
+// statements and slow queries visible to them in TiDB.
+func restrictedCapabilities() []utils.Capability {
+	return []utils.Capability{utils.CapabilityViewStatements}
+}
+
 func hasPriv(priv string, privs map[string]struct{}) bool {
 	_, ok := privs[priv]
 	return ok
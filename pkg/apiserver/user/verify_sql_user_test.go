@@ -6,6 +6,8 @@ import (
 	"testing"
 
 	. "github.com/pingcap/check"
+
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/utils"
 )
 
 func TestT(t *testing.T) {
@@ -219,3 +221,39 @@ func (t *testVerifySQLUserSuite) Test_checkWriteablePriv(c *C) {
 		c.Assert(actual, DeepEquals, v.expected, Commentf("check %s (index: %d) failed", v.desc, i))
 	}
 }
+
+func (t *testVerifySQLUserSuite) Test_capabilitiesFromPrivs(c *C) {
+	cases := []struct {
+		desc     string
+		grants   []string
+		expected []utils.Capability
+	}{
+		// 0
+		{
+			desc:     "ALL privileges",
+			grants:   []string{"ALL PRIVILEGES"},
+			expected: []utils.Capability{utils.CapabilityView, utils.CapabilityWrite, utils.CapabilityManageTopology, utils.CapabilityManageScheduling},
+		},
+		// 1
+		{
+			desc:     "SYSTEM_VARIABLES_ADMIN privileges",
+			grants:   []string{"PROCESS", "SHOW DATABASES", "CONFIG", "DASHBOARD_CLIENT", "SYSTEM_VARIABLES_ADMIN"},
+			expected: []utils.Capability{utils.CapabilityView, utils.CapabilityWrite},
+		},
+		// 2
+		{
+			desc:     "base privileges",
+			grants:   []string{"PROCESS", "SHOW DATABASES", "CONFIG", "DASHBOARD_CLIENT"},
+			expected: []utils.Capability{utils.CapabilityView},
+		},
+	}
+
+	for i, v := range cases {
+		grants := map[string]struct{}{}
+		for _, grant := range v.grants {
+			grants[grant] = struct{}{}
+		}
+		actual := capabilitiesFromPrivs(grants)
+		c.Assert(actual, DeepEquals, v.expected, Commentf("check %s (index: %d) failed", v.desc, i))
+	}
+}
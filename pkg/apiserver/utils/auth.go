@@ -10,12 +10,36 @@ import (
 
 type AuthType int
 
-const SessionVersion = 2
+const SessionVersion = 3
+
+// Capability is an operation class in the dashboard, derived from the SQL privileges of the signed in user.
+type Capability string
+
+const (
+	// CapabilityView allows viewing all dashboard pages, which implies other viewing capabilities. Every signed in
+	// user with the dashboard privileges has it.
+	CapabilityView Capability = "view"
+	// CapabilityWrite allows modifying configurations and running statements.
+	CapabilityWrite Capability = "write"
+	// CapabilityManageTopology allows destructive topology operations, e.g. removing nodes.
+	CapabilityManageTopology Capability = "manage_topology"
+	// CapabilityManageScheduling allows changing PD scheduling, e.g. pausing schedulers or adding operators.
+	CapabilityManageScheduling Capability = "manage_scheduling"
+	// CapabilityViewStatements allows viewing statements and slow queries, which are filtered by TiDB according to
+	// the SQL privileges of the user. It is the only capability of SQL users without the dashboard privileges.
+	CapabilityViewStatements Capability = "view_statements"
+)
+
+// readOnlyCapabilities are capabilities kept by RevokeWritePriv.
+var readOnlyCapabilities = map[Capability]struct{}{
+	CapabilityView:           {},
+	CapabilityViewStatements: {},
+}
 
 // The content of this structure will be encrypted and stored as both Session Token and Sharing Token.
 // For fields that don't need to be cloned during session sharing, mark fields as `msgpack:"-"`.
 type SessionUser struct {
-	// Must be 2. This field is used to invalidate outdated sessions after schema change.
+	// Must be 3. This field is used to invalidate outdated sessions after schema change.
 	Version int
 
 	DisplayName string
@@ -24,8 +48,11 @@ type SessionUser struct {
 	TiDBUsername string
 	TiDBPassword string
 
-	// This field only exists for CodeAuth.
+	// These fields only exist for CodeAuth.
 	SharedSessionExpireAt time.Time `msgpack:"-" json:",omitempty"`
+	// SharingSecretID identifies the sharing secret of the code signed in with, so that the session is revoked
+	// together with the code when the secret is rotated.
+	SharingSecretID string `msgpack:"-" json:",omitempty"`
 
 	// This field only exists for SSOAuth
 	OIDCIDToken string `json:",omitempty"`
@@ -36,6 +63,41 @@ type SessionUser struct {
 	// TODO: Make them table fields
 	IsShareable bool
 	IsWriteable bool
+
+	Capabilities []Capability
+
+	// ClusterID is the cluster selected in the session. Empty means the cluster of the dashboard itself.
+	ClusterID string `json:",omitempty"`
+
+	// SessionID identifies the signed in session, which is tracked in order to be listed and revoked.
+	SessionID string `msgpack:"-" json:",omitempty"`
+	// IsAPIKey is true for the session carried by an API key, which is only revoked together with the key.
+	IsAPIKey bool `msgpack:"-" json:",omitempty"`
+}
+
+func HasCapability(capabilities []Capability, c Capability) bool {
+	for _, owned := range capabilities {
+		if owned == c {
+			return true
+		}
+	}
+	return false
+}
+
+func (u *SessionUser) HasCapability(c Capability) bool {
+	return HasCapability(u.Capabilities, c)
+}
+
+// RevokeWritePriv drops all capabilities other than viewing.
+func (u *SessionUser) RevokeWritePriv() {
+	u.IsWriteable = false
+	capabilities := make([]Capability, 0, len(u.Capabilities))
+	for _, c := range u.Capabilities {
+		if _, ok := readOnlyCapabilities[c]; ok {
+			capabilities = append(capabilities, c)
+		}
+	}
+	u.Capabilities = capabilities
 }
 
 const (
@@ -0,0 +1,26 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package utils
+
+import (
+	. "github.com/pingcap/check"
+)
+
+var _ = Suite(&testAuthSuite{})
+
+type testAuthSuite struct{}
+
+func (t *testAuthSuite) Test_RevokeWritePriv(c *C) {
+	u := &SessionUser{
+		IsWriteable:  true,
+		Capabilities: []Capability{CapabilityView, CapabilityWrite, CapabilityManageTopology},
+	}
+	u.RevokeWritePriv()
+	c.Assert(u.IsWriteable, IsFalse)
+	c.Assert(u.Capabilities, DeepEquals, []Capability{CapabilityView})
+
+	// Restricted users are not granted CapabilityView.
+	u = &SessionUser{Capabilities: []Capability{CapabilityViewStatements}}
+	u.RevokeWritePriv()
+	c.Assert(u.Capabilities, DeepEquals, []Capability{CapabilityViewStatements})
+}
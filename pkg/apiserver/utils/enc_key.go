@@ -0,0 +1,46 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package utils
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/gtank/cryptopasta"
+)
+
+// EncKeyFile is an encryption key persisted in a file under the data dir, e.g. for encrypting the SQL credentials
+// kept in the local store. The key is created on first use. This struct is concurrent-safe.
+type EncKeyFile struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewEncKeyFile(path string) *EncKeyFile {
+	return &EncKeyFile{path: path}
+}
+
+// GetOrCreate returns the key in the file, or creates the file with a new key if it does not exist.
+func (f *EncKeyFile) GetOrCreate() (*[32]byte, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	b, err := os.ReadFile(f.path)
+	if err == nil {
+		if len(b) != 32 {
+			return nil, fmt.Errorf("encryption key is broken")
+		}
+		var key [32]byte
+		copy(key[:], b)
+		return &key, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, err
+	}
+	key := cryptopasta.NewEncryptionKey()
+	if err := os.WriteFile(f.path, key[:], 0o400); err != nil { // read only for owner
+		return nil, fmt.Errorf("persist key failed: %v", err)
+	}
+	return key, nil
+}
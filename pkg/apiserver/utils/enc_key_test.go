@@ -0,0 +1,27 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncKeyFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ek.bin")
+	key, err := NewEncKeyFile(path).GetOrCreate()
+	require.NoError(t, err)
+
+	// The key is reused across restarts.
+	key2, err := NewEncKeyFile(path).GetOrCreate()
+	require.NoError(t, err)
+	require.Equal(t, key, key2)
+
+	brokenPath := filepath.Join(t.TempDir(), "ek.bin")
+	require.NoError(t, os.WriteFile(brokenPath, []byte("short"), 0o600))
+	_, err = NewEncKeyFile(brokenPath).GetOrCreate()
+	require.Error(t, err)
+}
@@ -0,0 +1,83 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/pingcap/check"
+
+	"github.com/pingcap/tidb-dashboard/util/rest"
+)
+
+var _ = Suite(&testETagSuite{})
+
+type testETagSuite struct{}
+
+func newETagTestEngine(content *string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(rest.ErrorHandlerFn())
+	engine.Use(MWConditionalGet())
+	engine.GET("/content", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"content": *content})
+	})
+	engine.GET("/error", func(c *gin.Context) {
+		rest.Error(c, rest.ErrBadRequest.New("bad"))
+	})
+	return engine
+}
+
+func serveETagTest(engine *gin.Engine, path string, ifNoneMatch string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if ifNoneMatch != "" {
+		req.Header.Set("If-None-Match", ifNoneMatch)
+	}
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	return w
+}
+
+func (t *testETagSuite) Test_MWConditionalGet(c *C) {
+	content := "v1"
+	engine := newETagTestEngine(&content)
+
+	w := serveETagTest(engine, "/content", "")
+	c.Assert(w.Code, Equals, http.StatusOK)
+	c.Assert(w.Body.String(), Equals, `{"content":"v1"}`)
+	etag := w.Header().Get("ETag")
+	c.Assert(etag, Matches, `W/"[0-9a-f]{32}"`)
+
+	w = serveETagTest(engine, "/content", etag)
+	c.Assert(w.Code, Equals, http.StatusNotModified)
+	c.Assert(w.Body.Len(), Equals, 0)
+	c.Assert(w.Header().Get("ETag"), Equals, etag)
+
+	content = "v2"
+	w = serveETagTest(engine, "/content", etag)
+	c.Assert(w.Code, Equals, http.StatusOK)
+	c.Assert(w.Body.String(), Equals, `{"content":"v2"}`)
+	c.Assert(w.Header().Get("ETag"), Not(Equals), etag)
+}
+
+func (t *testETagSuite) Test_MWConditionalGetError(c *C) {
+	content := ""
+	engine := newETagTestEngine(&content)
+
+	w := serveETagTest(engine, "/error", "*")
+	c.Assert(w.Code, Equals, http.StatusBadRequest)
+	c.Assert(w.Header().Get("ETag"), Equals, "")
+	c.Assert(w.Body.Len() > 0, IsTrue)
+}
+
+func (t *testETagSuite) Test_etagMatches(c *C) {
+	etag := `W/"abc"`
+	c.Assert(etagMatches("", etag), IsFalse)
+	c.Assert(etagMatches(`W/"abc"`, etag), IsTrue)
+	c.Assert(etagMatches(`"abc"`, etag), IsTrue)
+	c.Assert(etagMatches(`"xyz", W/"abc"`, etag), IsTrue)
+	c.Assert(etagMatches(`"xyz"`, etag), IsFalse)
+	c.Assert(etagMatches(`*`, etag), IsTrue)
+}
@@ -23,6 +23,9 @@ import (
 	"github.com/pingcap/tidb-dashboard/util/rest"
 )
 
+// MaxExportRows is the max number of rows in an exported file, to bound the memory used by exporting.
+const MaxExportRows = 100000
+
 // TODO: Better to be a streaming interface.
 func GenerateCSVFromRaw(rawData []interface{}, fields []string, timeFields []string) (data [][]string) {
 	timeFieldsMap := make(map[string]struct{})
@@ -14,11 +14,11 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/joomcode/errorx"
-	"go.etcd.io/etcd/clientv3"
 	"go.uber.org/fx"
 	"golang.org/x/sync/singleflight"
 
 	"github.com/pingcap/tidb-dashboard/pkg/config"
+	"github.com/pingcap/tidb-dashboard/pkg/pd"
 	"github.com/pingcap/tidb-dashboard/pkg/utils/topology"
 	"github.com/pingcap/tidb-dashboard/util/rest"
 )
@@ -48,16 +48,16 @@ type ngmAddrCacheEntity struct {
 
 type NgmProxy struct {
 	lifecycleCtx context.Context
-	etcdClient   *clientv3.Client
+	etcdClients  *pd.EtcdClientManager
 	ngmReqGroup  singleflight.Group
 	ngmAddrCache atomic.Value
 	timeout      time.Duration
 }
 
-func NewNgmProxy(lc fx.Lifecycle, etcdClient *clientv3.Client, config *config.Config) (*NgmProxy, error) {
+func NewNgmProxy(lc fx.Lifecycle, etcdClients *pd.EtcdClientManager, config *config.Config) (*NgmProxy, error) {
 	s := &NgmProxy{
-		etcdClient: etcdClient,
-		timeout:    time.Duration(config.NgmTimeout) * time.Second,
+		etcdClients: etcdClients,
+		timeout:     time.Duration(config.NgmTimeout) * time.Second,
 	}
 	lc.Append(fx.Hook{
 		OnStart: func(ctx context.Context) error {
@@ -125,7 +125,7 @@ func (n *NgmProxy) getNgmAddrFromCache() (string, error) {
 }
 
 func (n *NgmProxy) resolveNgmAddress() (string, error) {
-	addr, err := topology.FetchNgMonitoringTopology(n.lifecycleCtx, n.etcdClient)
+	addr, err := topology.FetchNgMonitoringTopology(n.lifecycleCtx, n.etcdClients.Client())
 	if err == nil && addr != "" {
 		return fmt.Sprintf("http://%s", addr), nil
 	}
@@ -20,6 +20,9 @@ const (
 // information attached in the context. If a connection cannot be established, subsequent handlers will be skipped
 // and errors will be generated.
 //
+// The TiDB credentials in the session are of the default cluster, so that the connection is rejected when another
+// cluster is selected in the session.
+//
 // This middleware must be placed after the `MWAuthRequired()` middleware, otherwise it will panic.
 func MWConnectTiDB(tidbClient *tidb.Client) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -35,6 +38,11 @@ func MWConnectTiDB(tidbClient *tidb.Client) gin.HandlerFunc {
 			c.Abort()
 			return
 		}
+		if sessionUser.ClusterID != "" {
+			rest.Error(c, rest.ErrBadRequest.New("SQL is not available for the selected cluster"))
+			c.Abort()
+			return
+		}
 
 		db, err := tidbClient.OpenSQLConn(sessionUser.TiDBUsername, sessionUser.TiDBPassword)
 		if err != nil {
@@ -6,6 +6,7 @@ import (
 	"crypto/tls"
 	"net/url"
 	"strings"
+	"time"
 
 	"go.etcd.io/etcd/pkg/transport"
 
@@ -18,13 +19,20 @@ const (
 	UIPathPrefix      = "/dashboard/"
 	APIPathPrefix     = "/dashboard/api/"
 	SwaggerPathPrefix = "/dashboard/api/swagger/"
+	SpecPath          = "/dashboard/api/spec.json"
+	// MetricsPath serves metrics of the dashboard server itself in the standalone mode.
+	MetricsPath = "/metrics"
 )
 
 type Config struct {
 	DataDir          string
 	TempDir          string
 	PDEndPoint       string
-	PublicPathPrefix string
+	PublicPathPrefix string // path prefix of the dashboard seen by browsers, e.g. when served under a sub-path of a reverse proxy
+
+	// CORSAllowedOrigins are origins allowed to call the API cross-origin, e.g. `https://*.example.com`.
+	// Any origin is allowed when empty.
+	CORSAllowedOrigins []string
 
 	ClusterTLSConfig *tls.Config        // TLS config for mTLS authentication between TiDB components.
 	ClusterTLSInfo   *transport.TLSInfo // TLS info for mTLS authentication between TiDB components.
@@ -33,8 +41,65 @@ type Config struct {
 	EnableTelemetry    bool
 	EnableExperimental bool
 	FeatureVersion     string // assign the target TiDB version when running TiDB Dashboard as standalone mode
+	// DisabledFeatures are names of features disabled in this deployment, e.g. `profiling`, `log_search`.
+	DisabledFeatures []string
 
 	NgmTimeout int // in seconds
+
+	SlowRequestThreshold time.Duration // API requests slower than it are logged as warnings and kept, 0 disables it
+	SlowRequestCapacity  int           // max number of recent slow API requests kept in memory
+
+	// EnableRestrictedLogin allows SQL users without the dashboard privileges to sign in, who are only able to view
+	// statements and slow queries visible to them.
+	EnableRestrictedLogin bool
+	// LoginRateLimitPerIP is the max number of sign in attempts per minute from a source IP, 0 disables it.
+	LoginRateLimitPerIP int
+	// LoginMaxFailuresPerIP and LoginMaxFailuresPerUser are the max number of consecutive failed sign in attempts
+	// from a source IP or of a username, before it is locked out for LoginLockoutDuration. 0 disables the lockout.
+	LoginMaxFailuresPerIP   int
+	LoginMaxFailuresPerUser int
+	LoginLockoutDuration    time.Duration
+	// TrustedProxies are IPs or CIDRs of reverse proxies whose X-Forwarded-For and X-Real-IP headers are trusted to
+	// get the client IP, e.g. for the sign in limits. No proxy is trusted by default.
+	TrustedProxies []string
+
+	// AdvertiseAddress is the `host:port` address of the Dashboard Server seen by other components.
+	AdvertiseAddress string
+	// ExcludeSelfFromTopology excludes nodes listening on AdvertiseAddress from the topology.
+	ExcludeSelfFromTopology bool
+
+	TopologyCacheTTL         time.Duration // how long topology responses are cached, 0 means no cache
+	TopologyProbeConcurrency int           // max number of concurrent liveness probes
+	TopologyEventsCapacity   int           // max number of recent topology change events kept in memory
+	TopologyDownGraceProbes  int           // number of consecutive failed liveness probes before a node is reported down
+	TopologyProbeInterval    time.Duration // interval of probing the liveness of all nodes in background, 0 disables it
+	// TopologyProbeHistoryRetention is how long the probe history of a node vanished from the topology is kept.
+	TopologyProbeHistoryRetention time.Duration
+
+	// TopologyFetchMaxRetries and TopologyFetchRetryBudget limit the retries of fetching
+	// each component's topology. The limits apply to each component independently.
+	TopologyFetchMaxRetries  int
+	TopologyFetchRetryBudget time.Duration
+	// TopologyDependencyAwareFetch skips fetching components whose dependencies are unavailable, e.g. TiKV when PD is down.
+	TopologyDependencyAwareFetch bool
+	// TopologyFetchTimeout limits the whole topology fetch, while TopologyFetchSourceTimeout limits each source.
+	// Components not fetched in time are reported as errors, with other components still returned.
+	TopologyFetchTimeout       time.Duration
+	TopologyFetchSourceTimeout time.Duration
+	// TopologyDisabledComponents are components not to be discovered, e.g. `alert_manager`.
+	TopologyDisabledComponents []string
+
+	// TopologyStaticFile loads the topology from a JSON file instead of etcd and PD when specified.
+	TopologyStaticFile string
+	// TopologyTiUPMetaFile loads the topology from a tiup cluster `meta.yaml` instead of etcd and PD when specified.
+	TopologyTiUPMetaFile string
+	// TopologyStoreInventory is the URL or file path of the authoritative store list to be compared with PD.
+	TopologyStoreInventory string
+	// TopologyMaskAddresses masks node IPs in topology responses for users without the write privilege.
+	TopologyMaskAddresses bool
+	// TopologyExpectedMinimum is the minimum number of up nodes of each component, keyed by the component kind
+	// like `tikv`. Components with fewer up nodes are reported as alarms.
+	TopologyExpectedMinimum map[string]int
 }
 
 func Default() *Config {
@@ -50,6 +115,27 @@ func Default() *Config {
 		EnableExperimental: false,
 		FeatureVersion:     version.PDVersion,
 		NgmTimeout:         30, // s
+
+		SlowRequestThreshold: 3 * time.Second,
+		SlowRequestCapacity:  100,
+
+		LoginRateLimitPerIP:     30,
+		LoginMaxFailuresPerIP:   20,
+		LoginMaxFailuresPerUser: 5,
+		LoginLockoutDuration:    5 * time.Minute,
+
+		TopologyCacheTTL:         3 * time.Second,
+		TopologyProbeConcurrency: 16,
+		TopologyEventsCapacity:   256,
+		TopologyDownGraceProbes:  3,
+		TopologyProbeInterval:    30 * time.Second,
+		TopologyFetchMaxRetries:  2,
+		TopologyFetchRetryBudget: 2 * time.Second,
+
+		TopologyFetchTimeout:       10 * time.Second,
+		TopologyFetchSourceTimeout: 5 * time.Second,
+
+		TopologyProbeHistoryRetention: 10 * time.Minute,
 	}
 }
 
@@ -81,3 +167,12 @@ func (c *Config) NormalizePublicPathPrefix() {
 	}
 	c.PublicPathPrefix = strings.TrimRight(c.PublicPathPrefix, "/")
 }
+
+// PublicURL returns the path seen by browsers of the path under the dashboard, e.g. `/` or `/api/info/info`.
+// It should be used in redirects and links instead of UIPathPrefix or APIPathPrefix.
+func (c *Config) PublicURL(path string) string {
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return c.PublicPathPrefix + path
+}
@@ -36,13 +36,14 @@ type DynamicConfigManager struct {
 
 	lifecycleCtx context.Context
 	config       *Config
-	etcdClient   *clientv3.Client
+	// etcdClient returns the current etcd client, which may be replaced after reconnecting.
+	etcdClient func() *clientv3.Client
 
 	dynamicConfig *DynamicConfig
 	pushChannels  []chan *DynamicConfig
 }
 
-func NewDynamicConfigManager(lc fx.Lifecycle, config *Config, etcdClient *clientv3.Client) *DynamicConfigManager {
+func NewDynamicConfigManager(lc fx.Lifecycle, config *Config, etcdClient func() *clientv3.Client) *DynamicConfigManager {
 	m := &DynamicConfigManager{
 		config:     config,
 		etcdClient: etcdClient,
@@ -154,7 +155,7 @@ func (m *DynamicConfigManager) Modify(opts ...DynamicConfigOption) error {
 func (m *DynamicConfigManager) load() (*DynamicConfig, error) {
 	ctx, cancel := context.WithTimeout(m.lifecycleCtx, Timeout)
 	defer cancel()
-	resp, err := m.etcdClient.Get(ctx, DynamicConfigPath)
+	resp, err := m.etcdClient().Get(ctx, DynamicConfigPath)
 	if err != nil {
 		log.Warn("Failed to load dynamic config from etcd", zap.Error(err))
 		return nil, ErrUnableToLoad.WrapWithNoMessage(err)
@@ -185,7 +186,7 @@ func (m *DynamicConfigManager) store(dc *DynamicConfig) error {
 
 	ctx, cancel := context.WithTimeout(m.lifecycleCtx, Timeout)
 	defer cancel()
-	_, err = m.etcdClient.Put(ctx, DynamicConfigPath, string(bs))
+	_, err = m.etcdClient().Put(ctx, DynamicConfigPath, string(bs))
 	// the log contains the sso client secret, so we should not log it
 	// log.Info("Save dynamic config to etcd", zap.ByteString("json", bs))
 
@@ -6,8 +6,10 @@ import (
 	"context"
 	"os"
 	"path"
+	"sync/atomic"
 
 	"github.com/pingcap/log"
+	"github.com/prometheus/client_golang/prometheus"
 	"go.uber.org/fx"
 	"go.uber.org/zap"
 	"gorm.io/driver/sqlite"
@@ -16,6 +18,31 @@ import (
 
 	"github.com/pingcap/tidb-dashboard/pkg/apiserver/utils"
 	"github.com/pingcap/tidb-dashboard/pkg/config"
+	commonUtils "github.com/pingcap/tidb-dashboard/pkg/utils"
+)
+
+var (
+	// storagePath is the path of the storage file in use, which is read when the size is collected.
+	storagePath atomic.Value
+
+	storageSizeGauge = prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: "tidb_dashboard",
+		Subsystem: "storage",
+		Name:      "size_bytes",
+		Help:      "Size of the local storage file, including the write-ahead log.",
+	}, func() float64 {
+		p, _ := storagePath.Load().(string)
+		if p == "" {
+			return 0
+		}
+		var size int64
+		for _, f := range []string{p, p + "-wal"} {
+			if info, err := os.Stat(f); err == nil {
+				size += info.Size()
+			}
+		}
+		return float64(size)
+	})
 )
 
 type DB struct {
@@ -40,6 +67,8 @@ func NewDBStore(lc fx.Lifecycle, config *config.Config) (*DB, error) {
 	}
 
 	db := &DB{gormDB}
+	storagePath.Store(p)
+	commonUtils.RegisterMetrics(storageSizeGauge)
 
 	lc.Append(fx.Hook{
 		OnStop: func(context.Context) error {
@@ -0,0 +1,31 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+// Package dbstoretest provides local storages backed by temporary SQLite files for tests.
+package dbstoretest
+
+import (
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"github.com/pingcap/tidb-dashboard/pkg/dbstore"
+)
+
+// Open opens a local storage in the directory.
+func Open(dir string) (*dbstore.DB, error) {
+	gormDB, err := gorm.Open(sqlite.Open(path.Join(dir, "test.sqlite.db")))
+	if err != nil {
+		return nil, err
+	}
+	return &dbstore.DB{DB: gormDB}, nil
+}
+
+// New opens a local storage in a temporary directory removed after the test.
+func New(t testing.TB) *dbstore.DB {
+	db, err := Open(t.TempDir())
+	require.NoError(t, err)
+	return db
+}
@@ -12,16 +12,25 @@ import (
 
 	"github.com/joomcode/errorx"
 	"github.com/pingcap/log"
+	"github.com/prometheus/client_golang/prometheus"
 	"go.uber.org/fx"
 	"go.uber.org/zap"
 
 	"github.com/pingcap/tidb-dashboard/pkg/config"
+	"github.com/pingcap/tidb-dashboard/pkg/utils"
 )
 
 const (
 	defaultTimeout = time.Second * 10
 )
 
+var requestErrorCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "tidb_dashboard",
+	Subsystem: "http_client",
+	Name:      "request_errors_total",
+	Help:      "Number of failed HTTP API requests to each component, e.g. PD.",
+}, []string{"component"})
+
 type Client struct {
 	http.Client
 
@@ -29,6 +38,8 @@ type Client struct {
 }
 
 func NewHTTPClient(lc fx.Lifecycle, config *config.Config) *Client {
+	utils.RegisterMetrics(requestErrorCounter)
+
 	cli := http.Client{
 		Transport: &http.Transport{
 			DialTLS: func(network, addr string) (net.Conn, error) {
@@ -103,6 +114,7 @@ func (c *Client) Send(
 	if err != nil {
 		e := errType.Wrap(err, "Failed to build %s API request", errOriginComponent)
 		log.Warn("SendRequest failed", zap.String("uri", uri), zap.Error(err))
+		requestErrorCounter.WithLabelValues(errOriginComponent).Inc()
 		return nil, e
 	}
 	req.Header = c.header
@@ -111,6 +123,7 @@ func (c *Client) Send(
 	if err != nil {
 		e := errType.Wrap(err, "Failed to send %s API request", errOriginComponent)
 		log.Warn("SendRequest failed", zap.String("uri", uri), zap.Error(err))
+		requestErrorCounter.WithLabelValues(errOriginComponent).Inc()
 		return nil, e
 	}
 
@@ -119,6 +132,7 @@ func (c *Client) Send(
 		data, _ := io.ReadAll(resp.Body)
 		e := errType.New("Request failed with status code %d from %s API: %s", resp.StatusCode, errOriginComponent, string(data))
 		log.Warn("SendRequest failed", zap.String("uri", uri), zap.Error(err))
+		requestErrorCounter.WithLabelValues(errOriginComponent).Inc()
 		return nil, e
 	}
 
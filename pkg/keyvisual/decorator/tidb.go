@@ -9,19 +9,19 @@ import (
 	"sync"
 	"time"
 
-	"go.etcd.io/etcd/clientv3"
 	"go.uber.org/fx"
 
 	"github.com/pingcap/tidb-dashboard/pkg/config"
 	"github.com/pingcap/tidb-dashboard/pkg/keyvisual/region"
+	"github.com/pingcap/tidb-dashboard/pkg/pd"
 	"github.com/pingcap/tidb-dashboard/pkg/tidb"
 	"github.com/pingcap/tidb-dashboard/pkg/tidb/model"
 )
 
 // TiDBLabelStrategy implements the LabelStrategy interface. It obtains Label Information from TiDB.
-func TiDBLabelStrategy(lc fx.Lifecycle, wg *sync.WaitGroup, etcdClient *clientv3.Client, tidbClient *tidb.Client) LabelStrategy {
+func TiDBLabelStrategy(lc fx.Lifecycle, wg *sync.WaitGroup, etcdClients *pd.EtcdClientManager, tidbClient *tidb.Client) LabelStrategy {
 	s := &tidbLabelStrategy{
-		EtcdClient:    etcdClient,
+		EtcdClients:   etcdClients,
 		tidbClient:    tidbClient,
 		SchemaVersion: -1,
 	}
@@ -48,8 +48,8 @@ type tableDetail struct {
 }
 
 type tidbLabelStrategy struct {
-	Config     *config.Config
-	EtcdClient *clientv3.Client
+	Config      *config.Config
+	EtcdClients *pd.EtcdClientManager
 
 	TableMap      sync.Map
 	tidbClient    *tidb.Client
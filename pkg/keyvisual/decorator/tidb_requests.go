@@ -32,7 +32,7 @@ var (
 func (s *tidbLabelStrategy) updateMap(ctx context.Context) {
 	// check schema version
 	ectx, cancel := context.WithTimeout(ctx, etcdGetTimeout)
-	resp, err := s.EtcdClient.Get(ectx, schemaVersionPath)
+	resp, err := s.EtcdClients.Client().Get(ectx, schemaVersionPath)
 	cancel()
 	if err != nil || len(resp.Kvs) != 1 {
 		if s.SchemaVersion != -1 {
@@ -14,10 +14,10 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/joomcode/errorx"
 	"github.com/pingcap/log"
-	"go.etcd.io/etcd/clientv3"
 	"go.uber.org/fx"
 	"go.uber.org/zap"
 
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/audit"
 	"github.com/pingcap/tidb-dashboard/pkg/apiserver/user"
 	"github.com/pingcap/tidb-dashboard/pkg/config"
 	"github.com/pingcap/tidb-dashboard/pkg/dbstore"
@@ -65,7 +65,7 @@ type Service struct {
 	keyVisualCfg   *config.KeyVisualConfig
 	cfgManager     *config.DynamicConfigManager
 	customProvider *region.DataProvider
-	etcdClient     *clientv3.Client
+	etcdClients    *pd.EtcdClientManager
 	pdClient       *pd.Client
 	db             *dbstore.DB
 	tidbClient     *tidb.Client
@@ -81,7 +81,7 @@ func NewService(
 	cfg *config.Config,
 	cfgManager *config.DynamicConfigManager,
 	customProvider *region.DataProvider,
-	etcdClient *clientv3.Client,
+	etcdClients *pd.EtcdClientManager,
 	pdClient *pd.Client,
 	db *dbstore.DB,
 	tidbClient *tidb.Client,
@@ -91,7 +91,7 @@ func NewService(
 		config:         cfg,
 		cfgManager:     cfgManager,
 		customProvider: customProvider,
-		etcdClient:     etcdClient,
+		etcdClients:    etcdClients,
 		pdClient:       pdClient,
 		db:             db,
 		tidbClient:     tidbClient,
@@ -102,12 +102,12 @@ func NewService(
 	return s
 }
 
-func RegisterRouter(r *gin.RouterGroup, auth *user.AuthService, s *Service) {
+func RegisterRouter(r *gin.RouterGroup, auth *user.AuthService, a *audit.Service, s *Service) {
 	endpoint := r.Group("/keyvisual")
 	endpoint.Use(auth.MWAuthRequired())
 
 	endpoint.GET("/config", s.getDynamicConfig)
-	endpoint.PUT("/config", auth.MWRequireWritePriv(), s.setDynamicConfig)
+	endpoint.PUT("/config", auth.MWRequireWritePriv(), a.MWRecord("keyvisual.set_config"), s.setDynamicConfig)
 
 	endpoint.Use(s.status.MWHandleStopped(stoppedHandler))
 	endpoint.GET("/heatmaps", s.heatmaps)
@@ -153,13 +153,13 @@ func (s *Service) Start(ctx context.Context) error {
 func (s *Service) newLabelStrategy(
 	lc fx.Lifecycle,
 	wg *sync.WaitGroup,
-	etcdClient *clientv3.Client,
+	etcdClients *pd.EtcdClientManager,
 	tidbClient *tidb.Client,
 ) decorator.LabelStrategy {
 	switch s.keyVisualCfg.Policy {
 	case config.KeyVisualDBPolicy:
 		log.Debug("New LabelStrategy", zap.String("policy", s.keyVisualCfg.Policy))
-		return decorator.TiDBLabelStrategy(lc, wg, etcdClient, tidbClient)
+		return decorator.TiDBLabelStrategy(lc, wg, etcdClients, tidbClient)
 	case config.KeyVisualKVPolicy:
 		log.Debug("New LabelStrategy", zap.String("policy", s.keyVisualCfg.Policy),
 			zap.String("separator", s.keyVisualCfg.PolicyKVSeparator))
@@ -291,8 +291,8 @@ func (s *Service) heatmaps(c *gin.Context) {
 	c.JSON(http.StatusOK, resp)
 }
 
-func (s *Service) provideLocals() (*config.Config, *clientv3.Client, *pd.Client, *dbstore.DB, *tidb.Client) {
-	return s.config, s.etcdClient, s.pdClient, s.db, s.tidbClient
+func (s *Service) provideLocals() (*config.Config, *pd.EtcdClientManager, *pd.Client, *dbstore.DB, *tidb.Client) {
+	return s.config, s.etcdClients, s.pdClient, s.db, s.tidbClient
 }
 
 func newWaitGroup(lc fx.Lifecycle) *sync.WaitGroup {
@@ -321,7 +321,7 @@ func newStrategy(lc fx.Lifecycle, wg *sync.WaitGroup, labelStrategy decorator.La
 func newStat(
 	lc fx.Lifecycle,
 	wg *sync.WaitGroup,
-	etcdClient *clientv3.Client,
+	etcdClients *pd.EtcdClientManager,
 	db *dbstore.DB,
 	in input.StatInput,
 	strategy *matrix.Strategy,
@@ -8,10 +8,9 @@ import (
 	"time"
 
 	. "github.com/pingcap/check"
-	"gorm.io/driver/sqlite"
-	"gorm.io/gorm"
 
 	"github.com/pingcap/tidb-dashboard/pkg/dbstore"
+	"github.com/pingcap/tidb-dashboard/pkg/dbstore/dbstoretest"
 	"github.com/pingcap/tidb-dashboard/pkg/keyvisual/matrix"
 )
 
@@ -28,11 +27,11 @@ type testDbstoreSuite struct {
 
 func (t *testDbstoreSuite) SetUpTest(c *C) {
 	t.dir = c.MkDir()
-	gormDB, err := gorm.Open(sqlite.Open(path.Join(t.dir, "test.sqlite.db")))
+	db, err := dbstoretest.Open(t.dir)
 	if err != nil {
 		c.Errorf("Open %s error: %v", path.Join(t.dir, "test.sqlite.db"), err)
 	}
-	t.db = &dbstore.DB{DB: gormDB}
+	t.db = db
 }
 
 func (t *testDbstoreSuite) TestCreateTableAxisModelIfNotExists(c *C) {
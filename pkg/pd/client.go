@@ -101,3 +101,8 @@ func (c *Client) SendPostRequest(relativeURI string, body io.Reader) ([]byte, er
 	uri := fmt.Sprintf("%s%s%s", c.baseURL, c.getPrefix(), relativeURI)
 	return c.httpClient.WithTimeout(c.timeout).SendRequest(c.lifecycleCtx, uri, http.MethodPost, body, ErrPDClientRequestFailed, distro.R().PD)
 }
+
+func (c *Client) SendDeleteRequest(relativeURI string) ([]byte, error) {
+	uri := fmt.Sprintf("%s%s%s", c.baseURL, c.getPrefix(), relativeURI)
+	return c.httpClient.WithTimeout(c.timeout).SendRequest(c.lifecycleCtx, uri, http.MethodDelete, nil, ErrPDClientRequestFailed, distro.R().PD)
+}
@@ -4,10 +4,12 @@ package pd
 
 import (
 	"context"
+	"sync"
 	"time"
 
 	"github.com/pingcap/log"
 	"go.etcd.io/etcd/clientv3"
+	"go.etcd.io/etcd/etcdserver/api/v3rpc/rpctypes"
 	"go.uber.org/fx"
 	"go.uber.org/zap"
 
@@ -15,12 +17,28 @@ import (
 	"github.com/pingcap/tidb-dashboard/pkg/utils"
 )
 
-func NewEtcdClient(lc fx.Lifecycle, config *config.Config) (*clientv3.Client, error) {
+const (
+	etcdHealthCheckInterval = 30 * time.Second
+	etcdHealthCheckTimeout  = 5 * time.Second
+	etcdMaxHealthFailures   = 3
+	// etcdRetryMinInterval is the first interval of health checks after a failure. Intervals are doubled after
+	// each failure until reaching etcdHealthCheckInterval, so that the client reconnects quickly after an outage.
+	etcdRetryMinInterval = time.Second
+	// The replaced client is closed after a delay, so that in-flight requests can finish.
+	etcdClientCloseDelay = time.Minute
+)
+
+func newEtcdClient(config *config.Config) (*clientv3.Client, error) {
+	return NewEtcdClientForEndpoint(config, config.PDEndPoint)
+}
+
+// NewEtcdClientForEndpoint creates an etcd client of the PD at the endpoint, with the cluster TLS config.
+func NewEtcdClientForEndpoint(config *config.Config, endpoint string) (*clientv3.Client, error) {
 	zapCfg := zap.NewProductionConfig()
 	zapCfg.Encoding = log.ZapEncodingName
 
 	cli, err := clientv3.New(clientv3.Config{
-		Endpoints:            []string{config.PDEndPoint},
+		Endpoints:            []string{endpoint},
 		AutoSyncInterval:     30 * time.Second,
 		DialTimeout:          5 * time.Second,
 		DialKeepAliveTime:    utils.DefaultGRPCKeepaliveParams.Time,
@@ -30,12 +48,193 @@ func NewEtcdClient(lc fx.Lifecycle, config *config.Config) (*clientv3.Client, er
 		TLS:                  config.ClusterTLSConfig,
 		LogConfig:            &zapCfg,
 	})
+	if err != nil {
+		return nil, err
+	}
+	instrumentKV(cli)
+	return cli, nil
+}
+
+// checkEtcdHealth checks whether the etcd client can serve requests, in the same way as `etcdctl endpoint health`.
+func checkEtcdHealth(ctx context.Context, cli *clientv3.Client) error {
+	_, err := cli.Get(ctx, "health")
+	if err == nil || err == rpctypes.ErrPermissionDenied {
+		return nil
+	}
+	return err
+}
+
+// EtcdConnectionState is the state of the etcd connection observed by health checks.
+type EtcdConnectionState struct {
+	// Healthy is true until a health check fails.
+	Healthy             bool       `json:"healthy"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	LastCheckedAt       *time.Time `json:"last_checked_at"`
+	LastHealthyAt       *time.Time `json:"last_healthy_at"`
+	LastError           string     `json:"last_error,omitempty"`
+	// Rebuilds is the number of times the client is rebuilt after repeated failures.
+	Rebuilds int `json:"rebuilds"`
+}
 
+// EtcdClientManager holds a long-lived etcd client, which is probed periodically and rebuilt
+// from the configured endpoints when it fails repeatedly, e.g. wedged after network events.
+// Components must get the client by Client() for each use, so that they switch to the rebuilt client.
+type EtcdClientManager struct {
+	mu    sync.RWMutex
+	cli   *clientv3.Client
+	state EtcdConnectionState
+
+	newClient   func() (*clientv3.Client, error)
+	healthCheck func(ctx context.Context, cli *clientv3.Client) error
+	maxFailures int
+	failures    int
+}
+
+func NewEtcdClientManager(lc fx.Lifecycle, config *config.Config) (*EtcdClientManager, error) {
+	m := newEtcdClientManager(func() (*clientv3.Client, error) {
+		return newEtcdClient(config)
+	}, checkEtcdHealth, etcdMaxHealthFailures)
+	if err := m.init(); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
 	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go m.run(ctx, etcdHealthCheckInterval)
+			return nil
+		},
 		OnStop: func(context.Context) error {
-			return cli.Close()
+			cancel()
+			return m.close()
 		},
 	})
+	return m, nil
+}
+
+func newEtcdClientManager(
+	newClient func() (*clientv3.Client, error),
+	healthCheck func(ctx context.Context, cli *clientv3.Client) error,
+	maxFailures int,
+) *EtcdClientManager {
+	return &EtcdClientManager{
+		newClient:   newClient,
+		healthCheck: healthCheck,
+		maxFailures: maxFailures,
+		state:       EtcdConnectionState{Healthy: true},
+	}
+}
+
+func (m *EtcdClientManager) init() error {
+	cli, err := m.newClient()
+	if err != nil {
+		return err
+	}
+	m.cli = cli
+	return nil
+}
+
+func (m *EtcdClientManager) close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.cli.Close()
+}
+
+// Client returns the current etcd client. Callers should not keep the client for long,
+// as it may be replaced after being rebuilt.
+func (m *EtcdClientManager) Client() *clientv3.Client {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.cli
+}
+
+// State returns the state of the etcd connection.
+func (m *EtcdClientManager) State() EtcdConnectionState {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.state
+}
+
+func (m *EtcdClientManager) run(ctx context.Context, interval time.Duration) {
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+			m.checkOnce(ctx)
+			timer.Reset(m.nextCheckDelay(interval))
+		}
+	}
+}
+
+// nextCheckDelay returns the interval before the next health check, which backs off exponentially
+// from etcdRetryMinInterval to interval while the connection is unhealthy.
+func (m *EtcdClientManager) nextCheckDelay(interval time.Duration) time.Duration {
+	failures := m.State().ConsecutiveFailures
+	if failures == 0 {
+		return interval
+	}
+	delay := etcdRetryMinInterval
+	for i := 1; i < failures && delay < interval; i++ {
+		delay *= 2
+	}
+	if delay > interval {
+		delay = interval
+	}
+	return delay
+}
+
+// checkOnce probes the current client, and rebuilds it after too many consecutive failures.
+func (m *EtcdClientManager) checkOnce(ctx context.Context) {
+	cli := m.Client()
+	checkCtx, cancel := context.WithTimeout(ctx, etcdHealthCheckTimeout)
+	err := m.healthCheck(checkCtx, cli)
+	cancel()
+	m.recordCheck(err)
+	if err == nil {
+		m.failures = 0
+		return
+	}
+
+	m.failures++
+	log.Warn("etcd client health check failed", zap.Int("failures", m.failures), zap.Error(err))
+	if m.failures < m.maxFailures {
+		return
+	}
+
+	newCli, err := m.newClient()
+	if err != nil {
+		log.Warn("Failed to rebuild etcd client", zap.Error(err))
+		return
+	}
+	m.mu.Lock()
+	oldCli := m.cli
+	m.cli = newCli
+	m.state.Rebuilds++
+	m.mu.Unlock()
+	m.failures = 0
+	log.Info("etcd client is rebuilt after repeated health check failures")
+
+	time.AfterFunc(etcdClientCloseDelay, func() {
+		_ = oldCli.Close()
+	})
+}
 
-	return cli, err
+func (m *EtcdClientManager) recordCheck(err error) {
+	now := time.Now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.state.LastCheckedAt = &now
+	if err == nil {
+		m.state.Healthy = true
+		m.state.ConsecutiveFailures = 0
+		m.state.LastHealthyAt = &now
+		m.state.LastError = ""
+		return
+	}
+	m.state.Healthy = false
+	m.state.ConsecutiveFailures++
+	m.state.LastError = err.Error()
 }
@@ -0,0 +1,97 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package pd
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.etcd.io/etcd/clientv3"
+
+	"github.com/pingcap/tidb-dashboard/pkg/utils"
+)
+
+var etcdRequestErrorCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "tidb_dashboard",
+	Subsystem: "etcd",
+	Name:      "request_errors_total",
+	Help:      "Number of failed etcd requests of each operation.",
+}, []string{"operation"})
+
+// instrumentKV counts errors of the etcd client. The KV is wrapped instead of adding a gRPC interceptor, which
+// would replace the retry interceptor of the client.
+func instrumentKV(cli *clientv3.Client) {
+	utils.RegisterMetrics(etcdRequestErrorCounter)
+	cli.KV = &instrumentedKV{KV: cli.KV}
+}
+
+func countEtcdError(operation string, err error) {
+	if err != nil {
+		etcdRequestErrorCounter.WithLabelValues(operation).Inc()
+	}
+}
+
+type instrumentedKV struct {
+	clientv3.KV
+}
+
+func (kv *instrumentedKV) Put(ctx context.Context, key, val string, opts ...clientv3.OpOption) (*clientv3.PutResponse, error) {
+	resp, err := kv.KV.Put(ctx, key, val, opts...)
+	countEtcdError("put", err)
+	return resp, err
+}
+
+func (kv *instrumentedKV) Get(ctx context.Context, key string, opts ...clientv3.OpOption) (*clientv3.GetResponse, error) {
+	resp, err := kv.KV.Get(ctx, key, opts...)
+	countEtcdError("get", err)
+	return resp, err
+}
+
+func (kv *instrumentedKV) Delete(ctx context.Context, key string, opts ...clientv3.OpOption) (*clientv3.DeleteResponse, error) {
+	resp, err := kv.KV.Delete(ctx, key, opts...)
+	countEtcdError("delete", err)
+	return resp, err
+}
+
+func (kv *instrumentedKV) Compact(ctx context.Context, rev int64, opts ...clientv3.CompactOption) (*clientv3.CompactResponse, error) {
+	resp, err := kv.KV.Compact(ctx, rev, opts...)
+	countEtcdError("compact", err)
+	return resp, err
+}
+
+func (kv *instrumentedKV) Do(ctx context.Context, op clientv3.Op) (clientv3.OpResponse, error) {
+	resp, err := kv.KV.Do(ctx, op)
+	countEtcdError("do", err)
+	return resp, err
+}
+
+func (kv *instrumentedKV) Txn(ctx context.Context) clientv3.Txn {
+	return &instrumentedTxn{Txn: kv.KV.Txn(ctx)}
+}
+
+type instrumentedTxn struct {
+	clientv3.Txn
+}
+
+// If, Then and Else return the wrapped Txn, so that they are wrapped again to count errors of Commit.
+
+func (txn *instrumentedTxn) If(cs ...clientv3.Cmp) clientv3.Txn {
+	txn.Txn = txn.Txn.If(cs...)
+	return txn
+}
+
+func (txn *instrumentedTxn) Then(ops ...clientv3.Op) clientv3.Txn {
+	txn.Txn = txn.Txn.Then(ops...)
+	return txn
+}
+
+func (txn *instrumentedTxn) Else(ops ...clientv3.Op) clientv3.Txn {
+	txn.Txn = txn.Txn.Else(ops...)
+	return txn
+}
+
+func (txn *instrumentedTxn) Commit() (*clientv3.TxnResponse, error) {
+	resp, err := txn.Txn.Commit()
+	countEtcdError("txn", err)
+	return resp, err
+}
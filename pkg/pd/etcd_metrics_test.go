@@ -0,0 +1,54 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package pd
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/etcd/clientv3"
+)
+
+type failingKV struct {
+	clientv3.KV
+}
+
+func (failingKV) Get(context.Context, string, ...clientv3.OpOption) (*clientv3.GetResponse, error) {
+	return nil, errors.New("unavailable")
+}
+
+func (failingKV) Txn(context.Context) clientv3.Txn {
+	return failingTxn{}
+}
+
+type failingTxn struct {
+	clientv3.Txn
+}
+
+func (t failingTxn) If(...clientv3.Cmp) clientv3.Txn { return t }
+
+func (t failingTxn) Then(...clientv3.Op) clientv3.Txn { return t }
+
+func (failingTxn) Commit() (*clientv3.TxnResponse, error) {
+	return nil, errors.New("unavailable")
+}
+
+func TestInstrumentKV(t *testing.T) {
+	cli := clientv3.NewCtxClient(context.Background())
+	cli.KV = failingKV{}
+	instrumentKV(cli)
+
+	gets := testutil.ToFloat64(etcdRequestErrorCounter.WithLabelValues("get"))
+	_, err := cli.Get(context.Background(), "key")
+	require.Error(t, err)
+	require.Equal(t, gets+1, testutil.ToFloat64(etcdRequestErrorCounter.WithLabelValues("get")))
+
+	// Errors of transactions are counted when committed, after building the transaction.
+	txns := testutil.ToFloat64(etcdRequestErrorCounter.WithLabelValues("txn"))
+	_, err = cli.Txn(context.Background()).If().Then().Commit()
+	require.Error(t, err)
+	require.Equal(t, txns+1, testutil.ToFloat64(etcdRequestErrorCounter.WithLabelValues("txn")))
+}
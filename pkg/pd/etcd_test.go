@@ -0,0 +1,106 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package pd
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/etcd/clientv3"
+)
+
+func TestEtcdClientManagerRebuildsWedgedClient(t *testing.T) {
+	wedged := clientv3.NewCtxClient(context.Background())
+	healthy := clientv3.NewCtxClient(context.Background())
+	clients := []*clientv3.Client{wedged, healthy}
+
+	newClient := func() (*clientv3.Client, error) {
+		cli := clients[0]
+		clients = clients[1:]
+		return cli, nil
+	}
+	healthChecks := 0
+	healthCheck := func(ctx context.Context, cli *clientv3.Client) error {
+		healthChecks++
+		if cli == wedged {
+			return errors.New("context deadline exceeded")
+		}
+		return nil
+	}
+
+	m := newEtcdClientManager(newClient, healthCheck, 3)
+	require.NoError(t, m.init())
+	require.Same(t, wedged, m.Client())
+
+	// The client is kept until failing for maxFailures consecutive checks.
+	m.checkOnce(context.Background())
+	m.checkOnce(context.Background())
+	require.Same(t, wedged, m.Client())
+	m.checkOnce(context.Background())
+	require.Same(t, healthy, m.Client())
+
+	// The rebuilt client recovers, so that it is kept.
+	m.checkOnce(context.Background())
+	m.checkOnce(context.Background())
+	m.checkOnce(context.Background())
+	require.Same(t, healthy, m.Client())
+	require.Equal(t, 0, m.failures)
+	require.Equal(t, 6, healthChecks)
+}
+
+func TestEtcdClientManagerKeepsClientWhenRebuildFails(t *testing.T) {
+	wedged := clientv3.NewCtxClient(context.Background())
+	built := false
+	newClient := func() (*clientv3.Client, error) {
+		if built {
+			return nil, errors.New("dial failed")
+		}
+		built = true
+		return wedged, nil
+	}
+	healthCheck := func(ctx context.Context, cli *clientv3.Client) error {
+		return errors.New("context deadline exceeded")
+	}
+
+	m := newEtcdClientManager(newClient, healthCheck, 1)
+	require.NoError(t, m.init())
+	m.checkOnce(context.Background())
+	require.Same(t, wedged, m.Client())
+}
+
+func TestEtcdClientManagerStateAndBackoff(t *testing.T) {
+	cli := clientv3.NewCtxClient(context.Background())
+	var healthErr error
+	healthCheck := func(ctx context.Context, cli *clientv3.Client) error {
+		return healthErr
+	}
+	m := newEtcdClientManager(func() (*clientv3.Client, error) { return cli, nil }, healthCheck, 3)
+	require.NoError(t, m.init())
+	require.True(t, m.State().Healthy)
+	require.Equal(t, time.Minute, m.nextCheckDelay(time.Minute))
+
+	healthErr = errors.New("connection refused")
+	for i := 0; i < 4; i++ {
+		m.checkOnce(context.Background())
+	}
+	state := m.State()
+	require.False(t, state.Healthy)
+	require.Equal(t, 4, state.ConsecutiveFailures)
+	require.Equal(t, 1, state.Rebuilds)
+	require.Equal(t, "connection refused", state.LastError)
+	require.Nil(t, state.LastHealthyAt)
+	// Checks back off from 1s after failures, and never exceed the interval.
+	require.Equal(t, 8*time.Second, m.nextCheckDelay(time.Minute))
+	require.Equal(t, 5*time.Second, m.nextCheckDelay(5*time.Second))
+
+	healthErr = nil
+	m.checkOnce(context.Background())
+	state = m.State()
+	require.True(t, state.Healthy)
+	require.Equal(t, 0, state.ConsecutiveFailures)
+	require.NotNil(t, state.LastHealthyAt)
+	require.Equal(t, time.Minute, m.nextCheckDelay(time.Minute))
+}
@@ -0,0 +1,351 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package swaggerserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/swaggo/swag"
+)
+
+const (
+	openAPIVersion     = "3.0.3"
+	defaultContentType = "application/json"
+)
+
+var (
+	specOnce sync.Once
+	spec     []byte
+	specErr  error
+)
+
+// SpecHandler serves the OpenAPI 3 spec converted from the generated swagger spec, so that client SDKs can be
+// generated against the API.
+func SpecHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The generated spec does not change, so that it is only converted once.
+		specOnce.Do(func() {
+			var doc string
+			doc, specErr = swag.ReadDoc()
+			if specErr != nil {
+				return
+			}
+			spec, specErr = ConvertToOpenAPI3([]byte(doc))
+		})
+		if specErr != nil {
+			http.Error(w, specErr.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(spec)
+	})
+}
+
+// ConvertToOpenAPI3 converts a swagger 2.0 spec to an OpenAPI 3.0 spec. Only the subset of swagger 2.0 generated
+// by swag is supported.
+func ConvertToOpenAPI3(swagger2 []byte) ([]byte, error) {
+	var src map[string]interface{}
+	if err := json.Unmarshal(swagger2, &src); err != nil {
+		return nil, err
+	}
+
+	dst := map[string]interface{}{
+		"openapi": openAPIVersion,
+		"info":    src["info"],
+		"paths":   map[string]interface{}{},
+	}
+	if url := serverURL(src); url != "" {
+		dst["servers"] = []interface{}{map[string]interface{}{"url": url}}
+	}
+	if tags, ok := src["tags"]; ok {
+		dst["tags"] = tags
+	}
+
+	components := map[string]interface{}{}
+	if definitions, ok := src["definitions"].(map[string]interface{}); ok {
+		components["schemas"] = definitions
+	}
+	if securityDefinitions, ok := src["securityDefinitions"].(map[string]interface{}); ok {
+		schemes := map[string]interface{}{}
+		for name, d := range securityDefinitions {
+			schemes[name] = convertSecurityScheme(asMap(d))
+		}
+		components["securitySchemes"] = schemes
+	}
+	if len(components) > 0 {
+		dst["components"] = components
+	}
+
+	consumes := asStrings(src["consumes"])
+	produces := asStrings(src["produces"])
+	if paths, ok := src["paths"].(map[string]interface{}); ok {
+		dstPaths := dst["paths"].(map[string]interface{})
+		for path, item := range paths {
+			dstPaths[path] = convertPathItem(asMap(item), consumes, produces)
+		}
+	}
+
+	rewriteRefs(dst)
+	return json.Marshal(dst)
+}
+
+func serverURL(src map[string]interface{}) string {
+	basePath, _ := src["basePath"].(string)
+	host, _ := src["host"].(string)
+	if host == "" {
+		return basePath
+	}
+	scheme := "http"
+	if schemes := asStrings(src["schemes"]); len(schemes) > 0 {
+		scheme = schemes[0]
+	}
+	return scheme + "://" + host + basePath
+}
+
+func convertSecurityScheme(d map[string]interface{}) map[string]interface{} {
+	switch d["type"] {
+	case "basic":
+		return map[string]interface{}{"type": "http", "scheme": "basic", "description": d["description"]}
+	case "oauth2":
+		flow := map[string]interface{}{"scopes": d["scopes"]}
+		if d["authorizationUrl"] != nil {
+			flow["authorizationUrl"] = d["authorizationUrl"]
+		}
+		if d["tokenUrl"] != nil {
+			flow["tokenUrl"] = d["tokenUrl"]
+		}
+		flowName, _ := d["flow"].(string)
+		switch flowName {
+		case "application":
+			flowName = "clientCredentials"
+		case "accessCode":
+			flowName = "authorizationCode"
+		}
+		return map[string]interface{}{"type": "oauth2", "flows": map[string]interface{}{flowName: flow}}
+	default:
+		// apiKey is the same in both versions.
+		return d
+	}
+}
+
+var httpMethods = []string{"get", "put", "post", "delete", "options", "head", "patch"}
+
+func convertPathItem(item map[string]interface{}, consumes, produces []string) map[string]interface{} {
+	dst := map[string]interface{}{}
+	if params, ok := item["parameters"].([]interface{}); ok {
+		dst["parameters"] = convertParameters(params)
+	}
+	for _, method := range httpMethods {
+		if op, ok := item[method].(map[string]interface{}); ok {
+			dst[method] = convertOperation(op, consumes, produces)
+		}
+	}
+	return dst
+}
+
+// convertParameters converts parameters other than body and form parameters, which are request bodies in OpenAPI 3.
+func convertParameters(params []interface{}) []interface{} {
+	result := make([]interface{}, 0, len(params))
+	for _, p := range params {
+		param := asMap(p)
+		if param["in"] == "body" || param["in"] == "formData" {
+			continue
+		}
+		dst := map[string]interface{}{"schema": parameterSchema(param)}
+		for _, key := range []string{"name", "in", "description", "required"} {
+			if v, ok := param[key]; ok {
+				dst[key] = v
+			}
+		}
+		// Arrays in the query are repeated, which is `multi` in swagger 2.0.
+		if param["collectionFormat"] == "multi" {
+			dst["style"] = "form"
+			dst["explode"] = true
+		}
+		result = append(result, dst)
+	}
+	return result
+}
+
+// parameterSchema moves the schema fields of a non-body parameter into a schema.
+func parameterSchema(param map[string]interface{}) map[string]interface{} {
+	schema := map[string]interface{}{}
+	for _, key := range []string{"type", "format", "items", "enum", "default", "minimum", "maximum", "maxLength", "minLength", "pattern"} {
+		if v, ok := param[key]; ok {
+			schema[key] = v
+		}
+	}
+	if schema["type"] == "file" {
+		schema["type"] = "string"
+		schema["format"] = "binary"
+	}
+	return schema
+}
+
+func convertOperation(op map[string]interface{}, consumes, produces []string) map[string]interface{} {
+	dst := map[string]interface{}{}
+	for key, v := range op {
+		switch key {
+		case "consumes", "produces", "parameters", "responses", "schemes":
+		default:
+			dst[key] = v
+		}
+	}
+	if c := asStrings(op["consumes"]); len(c) > 0 {
+		consumes = c
+	}
+	if p := asStrings(op["produces"]); len(p) > 0 {
+		produces = p
+	}
+
+	params, _ := op["parameters"].([]interface{})
+	if converted := convertParameters(params); len(converted) > 0 {
+		dst["parameters"] = converted
+	}
+	if body := convertRequestBody(params, consumes); body != nil {
+		dst["requestBody"] = body
+	}
+
+	responses := map[string]interface{}{}
+	if src, ok := op["responses"].(map[string]interface{}); ok {
+		for code, r := range src {
+			responses[code] = convertResponse(asMap(r), produces)
+		}
+	}
+	dst["responses"] = responses
+	return dst
+}
+
+func convertRequestBody(params []interface{}, consumes []string) map[string]interface{} {
+	var body map[string]interface{}
+	form := map[string]interface{}{"type": "object", "properties": map[string]interface{}{}}
+	var formRequired []interface{}
+	hasForm, hasFile := false, false
+
+	for _, p := range params {
+		param := asMap(p)
+		switch param["in"] {
+		case "body":
+			body = map[string]interface{}{"content": mediaTypes(consumes, param["schema"])}
+			if description, ok := param["description"]; ok {
+				body["description"] = description
+			}
+			if required, ok := param["required"]; ok {
+				body["required"] = required
+			}
+		case "formData":
+			hasForm = true
+			schema := parameterSchema(param)
+			if param["type"] == "file" {
+				hasFile = true
+			}
+			if description, ok := param["description"]; ok {
+				schema["description"] = description
+			}
+			name, _ := param["name"].(string)
+			form["properties"].(map[string]interface{})[name] = schema
+			if required, _ := param["required"].(bool); required {
+				formRequired = append(formRequired, name)
+			}
+		}
+	}
+	if body != nil || !hasForm {
+		return body
+	}
+
+	if len(formRequired) > 0 {
+		form["required"] = formRequired
+	}
+	contentType := "application/x-www-form-urlencoded"
+	if hasFile {
+		contentType = "multipart/form-data"
+	}
+	return map[string]interface{}{"content": map[string]interface{}{contentType: map[string]interface{}{"schema": form}}}
+}
+
+func convertResponse(r map[string]interface{}, produces []string) map[string]interface{} {
+	// Description is required in both versions.
+	description, _ := r["description"].(string)
+	dst := map[string]interface{}{"description": description}
+	if schema, ok := r["schema"]; ok {
+		dst["content"] = mediaTypes(produces, schema)
+	}
+	if headers, ok := r["headers"].(map[string]interface{}); ok {
+		dstHeaders := map[string]interface{}{}
+		for name, h := range headers {
+			header := asMap(h)
+			dstHeader := map[string]interface{}{"schema": parameterSchema(header)}
+			if d, ok := header["description"]; ok {
+				dstHeader["description"] = d
+			}
+			dstHeaders[name] = dstHeader
+		}
+		dst["headers"] = dstHeaders
+	}
+	return dst
+}
+
+// mediaTypes returns the content of each media type. A string in a media type other than text or JSON is binary.
+func mediaTypes(types []string, schema interface{}) map[string]interface{} {
+	if len(types) == 0 {
+		types = []string{defaultContentType}
+	}
+	content := map[string]interface{}{}
+	for _, t := range types {
+		s := schema
+		if m, ok := schema.(map[string]interface{}); ok && m["type"] == "string" && isBinaryMediaType(t) {
+			binary := map[string]interface{}{}
+			for k, v := range m {
+				binary[k] = v
+			}
+			binary["format"] = "binary"
+			s = binary
+		}
+		content[t] = map[string]interface{}{"schema": s}
+	}
+	return content
+}
+
+func isBinaryMediaType(t string) bool {
+	return !strings.HasPrefix(t, "text/") && !strings.HasSuffix(t, "json")
+}
+
+// rewriteRefs points references to definitions to the component schemas.
+func rewriteRefs(v interface{}) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if ref, ok := value.(string); ok && key == "$ref" {
+				v[key] = strings.Replace(ref, "#/definitions/", "#/components/schemas/", 1)
+				continue
+			}
+			rewriteRefs(value)
+		}
+	case []interface{}:
+		for _, value := range v {
+			rewriteRefs(value)
+		}
+	}
+}
+
+func asMap(v interface{}) map[string]interface{} {
+	m, _ := v.(map[string]interface{})
+	if m == nil {
+		return map[string]interface{}{}
+	}
+	return m
+}
+
+func asStrings(v interface{}) []string {
+	items, _ := v.([]interface{})
+	result := make([]string, 0, len(items))
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			result = append(result, s)
+		}
+	}
+	return result
+}
@@ -0,0 +1,149 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package swaggerserver
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testSwagger2 = `{
+  "swagger": "2.0",
+  "info": {"title": "Dashboard API", "version": "1.0"},
+  "basePath": "/dashboard/api",
+  "paths": {
+    "/statements/config": {
+      "post": {
+        "operationId": "statementsConfigPost",
+        "security": [{"JwtAuth": []}],
+        "parameters": [
+          {"in": "body", "name": "request", "required": true, "description": "Request body",
+           "schema": {"$ref": "#/definitions/statement.EditableConfig"}}
+        ],
+        "responses": {
+          "200": {"description": "OK", "schema": {"type": "string"}},
+          "400": {"description": "Bad Request", "schema": {"$ref": "#/definitions/rest.ErrorResponse"}}
+        }
+      }
+    },
+    "/statements/list": {
+      "get": {
+        "operationId": "statementsListGet",
+        "parameters": [
+          {"in": "query", "name": "fields", "type": "array", "items": {"type": "string"}, "collectionFormat": "multi"},
+          {"in": "query", "name": "begin_time", "type": "integer", "required": true}
+        ],
+        "responses": {
+          "200": {"description": "OK", "schema": {"type": "array", "items": {"$ref": "#/definitions/statement.Model"}}}
+        }
+      }
+    },
+    "/profiling/group/download": {
+      "get": {
+        "produces": ["application/x-gzip"],
+        "parameters": [{"in": "query", "name": "token", "type": "string", "required": true}],
+        "responses": {"200": {"description": "Archive", "schema": {"type": "string"}}}
+      }
+    },
+    "/upload": {
+      "post": {
+        "consumes": ["multipart/form-data"],
+        "parameters": [
+          {"in": "formData", "name": "file", "type": "file", "required": true},
+          {"in": "formData", "name": "note", "type": "string"}
+        ],
+        "responses": {"200": {"description": "OK"}}
+      }
+    }
+  },
+  "definitions": {
+    "statement.EditableConfig": {"type": "object", "properties": {"enable": {"type": "boolean"}}},
+    "statement.Model": {"type": "object", "properties": {"digest": {"type": "string"}}},
+    "rest.ErrorResponse": {"type": "object", "properties": {"code": {"type": "string"}}}
+  },
+  "securityDefinitions": {
+    "JwtAuth": {"type": "apiKey", "name": "Authorization", "in": "header"}
+  }
+}`
+
+func convertTestSpec(t *testing.T) map[string]interface{} {
+	converted, err := ConvertToOpenAPI3([]byte(testSwagger2))
+	require.NoError(t, err)
+	var spec map[string]interface{}
+	require.NoError(t, json.Unmarshal(converted, &spec))
+	return spec
+}
+
+func TestConvertToOpenAPI3Document(t *testing.T) {
+	spec := convertTestSpec(t)
+	require.Equal(t, openAPIVersion, spec["openapi"])
+	require.Equal(t, []interface{}{map[string]interface{}{"url": "/dashboard/api"}}, spec["servers"])
+
+	components := spec["components"].(map[string]interface{})
+	require.Len(t, components["schemas"], 3)
+	require.Equal(t, map[string]interface{}{"type": "apiKey", "name": "Authorization", "in": "header"},
+		components["securitySchemes"].(map[string]interface{})["JwtAuth"])
+}
+
+func TestConvertToOpenAPI3Operations(t *testing.T) {
+	paths := convertTestSpec(t)["paths"].(map[string]interface{})
+
+	post := paths["/statements/config"].(map[string]interface{})["post"].(map[string]interface{})
+	require.Equal(t, "statementsConfigPost", post["operationId"])
+	require.NotContains(t, post, "parameters")
+	require.Equal(t, map[string]interface{}{
+		"description": "Request body",
+		"required":    true,
+		"content": map[string]interface{}{
+			"application/json": map[string]interface{}{
+				"schema": map[string]interface{}{"$ref": "#/components/schemas/statement.EditableConfig"},
+			},
+		},
+	}, post["requestBody"])
+	badRequest := post["responses"].(map[string]interface{})["400"].(map[string]interface{})
+	require.Equal(t, "#/components/schemas/rest.ErrorResponse",
+		badRequest["content"].(map[string]interface{})["application/json"].(map[string]interface{})["schema"].(map[string]interface{})["$ref"])
+
+	get := paths["/statements/list"].(map[string]interface{})["get"].(map[string]interface{})
+	require.Equal(t, []interface{}{
+		map[string]interface{}{
+			"name": "fields", "in": "query", "style": "form", "explode": true,
+			"schema": map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+		},
+		map[string]interface{}{
+			"name": "begin_time", "in": "query", "required": true,
+			"schema": map[string]interface{}{"type": "integer"},
+		},
+	}, get["parameters"])
+}
+
+func TestConvertToOpenAPI3Binary(t *testing.T) {
+	paths := convertTestSpec(t)["paths"].(map[string]interface{})
+
+	download := paths["/profiling/group/download"].(map[string]interface{})["get"].(map[string]interface{})
+	ok := download["responses"].(map[string]interface{})["200"].(map[string]interface{})
+	require.Equal(t, map[string]interface{}{
+		"application/x-gzip": map[string]interface{}{
+			"schema": map[string]interface{}{"type": "string", "format": "binary"},
+		},
+	}, ok["content"])
+
+	upload := paths["/upload"].(map[string]interface{})["post"].(map[string]interface{})
+	require.Equal(t, map[string]interface{}{
+		"content": map[string]interface{}{
+			"multipart/form-data": map[string]interface{}{
+				"schema": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"file": map[string]interface{}{"type": "string", "format": "binary"},
+						"note": map[string]interface{}{"type": "string"},
+					},
+					"required": []interface{}{"file"},
+				},
+			},
+		},
+	}, upload["requestBody"])
+	require.Equal(t, map[string]interface{}{"description": "OK"}, upload["responses"].(map[string]interface{})["200"])
+}
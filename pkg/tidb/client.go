@@ -6,6 +6,7 @@ import (
 	"context"
 	"database/sql/driver"
 	"fmt"
+	"io"
 	"net"
 	"net/http"
 	"os"
@@ -16,7 +17,6 @@ import (
 	"github.com/VividCortex/mysqlerr"
 	"github.com/go-sql-driver/mysql"
 	"github.com/pingcap/log"
-	"go.etcd.io/etcd/clientv3"
 	"go.uber.org/fx"
 	"go.uber.org/zap"
 	mysqlDriver "gorm.io/driver/mysql"
@@ -24,6 +24,7 @@ import (
 
 	"github.com/pingcap/tidb-dashboard/pkg/config"
 	"github.com/pingcap/tidb-dashboard/pkg/httpc"
+	"github.com/pingcap/tidb-dashboard/pkg/pd"
 	"github.com/pingcap/tidb-dashboard/util/distro"
 )
 
@@ -57,7 +58,7 @@ type Client struct {
 	sqlAPIAddress            string // Empty means to use address provided by forwarder
 }
 
-func NewTiDBClient(lc fx.Lifecycle, config *config.Config, etcdClient *clientv3.Client, httpClient *httpc.Client) *Client {
+func NewTiDBClient(lc fx.Lifecycle, config *config.Config, etcdClients *pd.EtcdClientManager, httpClient *httpc.Client) *Client {
 	sqlAPITLSKey := ""
 	if config.TiDBTLSConfig != nil {
 		sqlAPITLSKey = "tidb"
@@ -66,7 +67,7 @@ func NewTiDBClient(lc fx.Lifecycle, config *config.Config, etcdClient *clientv3.
 
 	client := &Client{
 		lifecycleCtx:             nil,
-		forwarder:                newForwarder(lc, etcdClient),
+		forwarder:                newForwarder(lc, etcdClients),
 		statusAPIHTTPScheme:      config.GetClusterHTTPScheme(),
 		statusAPIAddress:         "",
 		enforceStatusAPIAddresss: false,
@@ -170,6 +171,10 @@ func (c *Client) OpenSQLConn(user string, pass string) (*gorm.DB, error) {
 }
 
 func (c *Client) Get(relativeURI string) (*httpc.Response, error) {
+	return c.send(http.MethodGet, relativeURI, nil)
+}
+
+func (c *Client) send(method string, relativeURI string, body io.Reader) (*httpc.Response, error) {
 	var err error
 
 	overrideEndpoint := os.Getenv(tidbOverrideStatusEndpointEnvVar)
@@ -197,7 +202,7 @@ func (c *Client) Get(relativeURI string) (*httpc.Response, error) {
 	uri := fmt.Sprintf("%s://%s%s", c.statusAPIHTTPScheme, addr, relativeURI)
 	res, err := c.statusAPIHTTPClient.
 		WithTimeout(c.statusAPITimeout).
-		Send(c.lifecycleCtx, uri, http.MethodGet, nil, ErrTiDBClientRequestFailed, distro.R().TiDB)
+		Send(c.lifecycleCtx, uri, method, body, ErrTiDBClientRequestFailed, distro.R().TiDB)
 	if err != nil && c.forwarder.statusProxy.noAliveRemote.Load() {
 		return nil, ErrNoAliveTiDB.NewWithNoMessage()
 	}
@@ -212,3 +217,11 @@ func (c *Client) SendGetRequest(relativeURI string) ([]byte, error) {
 	}
 	return res.Body()
 }
+
+func (c *Client) SendPostRequest(relativeURI string, body io.Reader) ([]byte, error) {
+	res, err := c.send(http.MethodPost, relativeURI, body)
+	if err != nil {
+		return nil, err
+	}
+	return res.Body()
+}
@@ -11,9 +11,9 @@ import (
 
 	"github.com/cenkalti/backoff/v4"
 	"github.com/pingcap/log"
-	"go.etcd.io/etcd/clientv3"
 	"go.uber.org/fx"
 
+	"github.com/pingcap/tidb-dashboard/pkg/pd"
 	"github.com/pingcap/tidb-dashboard/pkg/utils/topology"
 	"github.com/pingcap/tidb-dashboard/util/distro"
 )
@@ -30,8 +30,8 @@ type forwarderConfig struct {
 type Forwarder struct {
 	lifecycleCtx context.Context
 
-	config     *forwarderConfig
-	etcdClient *clientv3.Client
+	config      *forwarderConfig
+	etcdClients *pd.EtcdClientManager
 
 	sqlProxy    *proxy
 	sqlPort     int
@@ -78,7 +78,7 @@ func (f *Forwarder) pollingForTiDB() {
 		var allTiDB []topology.TiDBInfo
 		err := backoff.Retry(func() error {
 			var err error
-			allTiDB, err = topology.FetchTiDBTopology(bo.Context(), f.etcdClient)
+			allTiDB, err = topology.FetchTiDBTopology(bo.Context(), f.etcdClients.Client())
 			return err
 		}, bo)
 		if err == nil {
@@ -110,7 +110,7 @@ func (f *Forwarder) getEndpointAddr(port int) (string, error) {
 	return fmt.Sprintf("127.0.0.1:%d", port), nil
 }
 
-func newForwarder(lc fx.Lifecycle, etcdClient *clientv3.Client) *Forwarder {
+func newForwarder(lc fx.Lifecycle, etcdClients *pd.EtcdClientManager) *Forwarder {
 	f := &Forwarder{
 		config: &forwarderConfig{
 			TiDBRetrieveTimeout: time.Second,
@@ -118,7 +118,7 @@ func newForwarder(lc fx.Lifecycle, etcdClient *clientv3.Client) *Forwarder {
 			ProxyTimeout:        3 * time.Second,
 			ProxyCheckInterval:  2 * time.Second,
 		},
-		etcdClient: etcdClient,
+		etcdClients: etcdClients,
 	}
 	lc.Append(fx.Hook{
 		OnStart: f.Start,
@@ -0,0 +1,22 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package utils
+
+import (
+	"github.com/pingcap/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+// RegisterMetrics registers the collectors to the default registry, which is served by the dashboard server at
+// `/metrics`, or by PD when the dashboard is embedded. Collectors already registered, e.g. when the apiserver is
+// restarted, are skipped.
+func RegisterMetrics(collectors ...prometheus.Collector) {
+	for _, c := range collectors {
+		if err := prometheus.Register(c); err != nil {
+			if _, ok := err.(prometheus.AlreadyRegisteredError); !ok {
+				log.Warn("Failed to register metrics", zap.Error(err))
+			}
+		}
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package topology
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/pingcap/log"
+	"go.etcd.io/etcd/clientv3"
+	"go.uber.org/zap"
+
+	"github.com/pingcap/tidb-dashboard/util/distro"
+	"github.com/pingcap/tidb-dashboard/util/netutil"
+)
+
+// TiDB Binlog nodes register themselves in PD etcd, keyed by node ID.
+const (
+	pumpTopologyKeyPrefix    = "/tidb-binlog/v1/pumps/"
+	drainerTopologyKeyPrefix = "/tidb-binlog/v1/drainers/"
+)
+
+func FetchPumpTopology(ctx context.Context, etcdClient *clientv3.Client) ([]BinlogInfo, error) {
+	return fetchBinlogTopology(ctx, etcdClient, pumpTopologyKeyPrefix)
+}
+
+func FetchDrainerTopology(ctx context.Context, etcdClient *clientv3.Client) ([]BinlogInfo, error) {
+	return fetchBinlogTopology(ctx, etcdClient, drainerTopologyKeyPrefix)
+}
+
+func fetchBinlogTopology(ctx context.Context, etcdClient *clientv3.Client, prefix string) ([]BinlogInfo, error) {
+	ctx2, cancel := context.WithTimeout(ctx, defaultFetchTimeout)
+	defer cancel()
+
+	resp, err := etcdClient.Get(ctx2, prefix, clientv3.WithPrefix())
+	if err != nil {
+		return nil, ErrEtcdRequestFailed.Wrap(err, "failed to get key %s from %s etcd", prefix, distro.R().PD)
+	}
+
+	nodes := make([]BinlogInfo, 0)
+	for _, kv := range resp.Kvs {
+		key := string(kv.Key)
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		nodeInfo, err := parseBinlogInfo(kv.Value)
+		if err != nil {
+			log.Warn("Ignored invalid binlog topology info entry",
+				zap.String("key", key),
+				zap.String("value", string(kv.Value)),
+				zap.Error(err))
+			continue
+		}
+		nodes = append(nodes, *nodeInfo)
+	}
+
+	sort.Slice(nodes, func(i, j int) bool {
+		if nodes[i].IP < nodes[j].IP {
+			return true
+		}
+		if nodes[i].IP > nodes[j].IP {
+			return false
+		}
+		return nodes[i].Port < nodes[j].Port
+	})
+
+	return nodes, nil
+}
+
+func parseBinlogInfo(value []byte) (*BinlogInfo, error) {
+	ds := struct {
+		NodeID      string `json:"nodeId"`
+		Host        string `json:"host"`
+		State       string `json:"state"`
+		MaxCommitTS int64  `json:"maxCommitTS"`
+		UpdateTS    int64  `json:"updateTS"`
+	}{}
+
+	err := json.Unmarshal(value, &ds)
+	if err != nil {
+		return nil, ErrInvalidTopologyData.Wrap(err, "binlog node info unmarshal failed")
+	}
+	hostname, port, err := netutil.ParseHostAndPortFromAddress(ds.Host)
+	if err != nil {
+		return nil, ErrInvalidTopologyData.Wrap(err, "binlog node info address parse failed")
+	}
+
+	return &BinlogInfo{
+		NodeID:      ds.NodeID,
+		IP:          hostname,
+		Port:        port,
+		State:       ds.State,
+		Status:      binlogComponentStatus(ds.State),
+		MaxCommitTS: ds.MaxCommitTS,
+		UpdateTS:    ds.UpdateTS,
+	}, nil
+}
+
+// binlogComponentStatus maps the state reported by the binlog node itself.
+func binlogComponentStatus(state string) ComponentStatus {
+	switch state {
+	case "online":
+		return ComponentStatusUp
+	case "pausing", "closing":
+		return ComponentStatusOffline
+	case "paused":
+		return ComponentStatusDown
+	case "offline":
+		return ComponentStatusTombstone
+	default:
+		return ComponentStatusUnreachable
+	}
+}
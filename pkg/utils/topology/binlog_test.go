@@ -0,0 +1,30 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package topology
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseBinlogInfo(t *testing.T) {
+	info, err := parseBinlogInfo([]byte(`{"nodeId":"pump-1:8250","host":"10.0.1.5:8250","state":"paused","maxCommitTS":429,"updateTS":430}`))
+	require.NoError(t, err)
+	require.Equal(t, &BinlogInfo{
+		NodeID:      "pump-1:8250",
+		IP:          "10.0.1.5",
+		Port:        8250,
+		State:       "paused",
+		Status:      ComponentStatusDown,
+		MaxCommitTS: 429,
+		UpdateTS:    430,
+	}, info)
+
+	info, err = parseBinlogInfo([]byte(`{"nodeId":"drainer-1","host":"10.0.1.6:8249","state":"online"}`))
+	require.NoError(t, err)
+	require.Equal(t, ComponentStatusUp, info.Status)
+
+	_, err = parseBinlogInfo([]byte(`{"nodeId":"drainer-1","host":"bad"}`))
+	require.Error(t, err)
+}
@@ -0,0 +1,37 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package topology
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/fx/fxtest"
+
+	"github.com/pingcap/tidb-dashboard/pkg/config"
+	"github.com/pingcap/tidb-dashboard/pkg/httpc"
+	"github.com/pingcap/tidb-dashboard/pkg/pd"
+)
+
+// newTestPDClient creates a PD client that sends requests to a mocked PD server,
+// which responds the content in `responses` by request path.
+func newTestPDClient(t *testing.T, responses map[string]string) *pd.Client {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resp, ok := responses[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(resp))
+	}))
+	t.Cleanup(ts.Close)
+
+	lc := fxtest.NewLifecycle(t)
+	cfg := &config.Config{PDEndPoint: ts.URL}
+	c := pd.NewPDClient(lc, httpc.NewHTTPClient(lc, cfg), cfg)
+	require.NoError(t, lc.Start(context.Background()))
+	return c
+}
@@ -13,6 +13,7 @@ const (
 )
 
 type PDInfo struct {
+	MemberID       uint64          `json:"member_id"`
 	GitHash        string          `json:"git_hash"`
 	Version        string          `json:"version"`
 	IP             string          `json:"ip"`
@@ -20,6 +21,9 @@ type PDInfo struct {
 	DeployPath     string          `json:"deploy_path"`
 	Status         ComponentStatus `json:"status"`
 	StartTimestamp int64           `json:"start_timestamp"` // Ts = 0 means unknown
+
+	// GrafanaURL links to the Grafana dashboard of the instance. Empty when Grafana is not deployed.
+	GrafanaURL string `json:"grafana_url,omitempty"`
 }
 
 type TiDBInfo struct {
@@ -31,6 +35,11 @@ type TiDBInfo struct {
 	Status         ComponentStatus `json:"status"`
 	StatusPort     uint            `json:"status_port"`
 	StartTimestamp int64           `json:"start_timestamp"`
+
+	// ConnectionCount is only filled when the load is requested. -1 means unavailable.
+	ConnectionCount int `json:"connection_count"`
+
+	GrafanaURL string `json:"grafana_url,omitempty"`
 }
 
 type TiCDCInfo struct {
@@ -43,6 +52,8 @@ type TiCDCInfo struct {
 	Status         ComponentStatus `json:"status"`
 	StatusPort     uint            `json:"status_port"`
 	StartTimestamp int64           `json:"start_timestamp"`
+
+	GrafanaURL string `json:"grafana_url,omitempty"`
 }
 
 type TiProxyInfo struct {
@@ -54,10 +65,25 @@ type TiProxyInfo struct {
 	Status         ComponentStatus `json:"status"`
 	StatusPort     uint            `json:"status_port"`
 	StartTimestamp int64           `json:"start_timestamp"`
+
+	GrafanaURL string `json:"grafana_url,omitempty"`
+}
+
+// BinlogInfo may be a TiDB Binlog Pump or Drainer node.
+type BinlogInfo struct {
+	NodeID string          `json:"node_id"`
+	IP     string          `json:"ip"`
+	Port   uint            `json:"port"`
+	State  string          `json:"state"` // The raw state reported by the node, e.g. `online` or `paused`
+	Status ComponentStatus `json:"status"`
+	// MaxCommitTS and UpdateTS are TSOs reported by the node.
+	MaxCommitTS int64 `json:"max_commit_ts"`
+	UpdateTS    int64 `json:"update_ts"`
 }
 
 // Store may be a TiKV store or TiFlash store.
 type StoreInfo struct {
+	StoreID        int               `json:"store_id"`
 	GitHash        string            `json:"git_hash"`
 	Version        string            `json:"version"`
 	IP             string            `json:"ip"`
@@ -67,6 +93,32 @@ type StoreInfo struct {
 	StatusPort     uint              `json:"status_port"`
 	Labels         map[string]string `json:"labels"`
 	StartTimestamp int64             `json:"start_timestamp"`
+
+	// EngineVariant is the storage engine variant of a TiKV store. Empty for classic stores.
+	EngineVariant string `json:"engine_variant,omitempty"`
+	// Role is the role of a disaggregated TiFlash store, `compute` or `write`. Empty for classic stores.
+	Role string `json:"role,omitempty"`
+
+	PendingPeerCount int `json:"pending_peer_count"`
+	DownPeerCount    int `json:"down_peer_count"`
+
+	// The balance status of the store reported by PD. Sizes are in bytes.
+	RegionCount int     `json:"region_count"`
+	LeaderCount int     `json:"leader_count"`
+	RegionScore float64 `json:"region_score"`
+	LeaderScore float64 `json:"leader_score"`
+	Capacity    uint64  `json:"capacity"`
+	Available   uint64  `json:"available"`
+	UsedSize    uint64  `json:"used_size"`
+
+	// The flow of the store in bytes per second, which is zero when PD does not report it.
+	ReadBytesPerSec  float64 `json:"read_bytes_per_sec"`
+	WriteBytesPerSec float64 `json:"write_bytes_per_sec"`
+
+	// Warnings describes abnormal states of the store, e.g. having down peers.
+	Warnings []string `json:"warnings,omitempty"`
+
+	GrafanaURL string `json:"grafana_url,omitempty"`
 }
 
 type StoreLabels struct {
@@ -64,6 +64,7 @@ func FetchPDTopology(pdClient *pd.Client) ([]PDInfo, error) {
 		}
 
 		nodes = append(nodes, PDInfo{
+			MemberID:       ds.MemberID,
 			GitHash:        ds.GitHash,
 			Version:        ds.BinaryVersion,
 			IP:             hostname,
@@ -145,3 +146,34 @@ func fetchLocationLabels(pdClient *pd.Client) ([]string, error) {
 	labels := strings.Split(replicateConfig.LocationLabels, ",")
 	return labels, nil
 }
+
+type RegionSplitConfig struct {
+	RegionMaxSize string
+	RegionMaxKeys int64
+}
+
+// FetchRegionSplitConfig returns the region split settings, which are reported by TiKV
+// and maintained by PD as a part of the store config.
+func FetchRegionSplitConfig(pdClient *pd.Client) (*RegionSplitConfig, error) {
+	data, err := pdClient.SendGetRequest("/config")
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg struct {
+		Store struct {
+			Coprocessor struct {
+				RegionMaxSize string `json:"region-max-size"`
+				RegionMaxKeys int64  `json:"region-max-keys"`
+			} `json:"coprocessor"`
+		} `json:"store"`
+	}
+	err = json.Unmarshal(data, &cfg)
+	if err != nil {
+		return nil, ErrInvalidTopologyData.Wrap(err, "%s config API unmarshal failed", distro.R().PD)
+	}
+	return &RegionSplitConfig{
+		RegionMaxSize: cfg.Store.Coprocessor.RegionMaxSize,
+		RegionMaxKeys: cfg.Store.Coprocessor.RegionMaxKeys,
+	}, nil
+}
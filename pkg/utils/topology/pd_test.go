@@ -0,0 +1,42 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package topology
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFetchRegionSplitConfig(t *testing.T) {
+	pdClient := newTestPDClient(t, map[string]string{
+		"/pd/api/v1/config": `{
+			"schedule": {"max-merge-region-size": 20, "max-merge-region-keys": 200000},
+			"replication": {"max-replicas": 3, "location-labels": ""},
+			"store": {
+				"coprocessor": {
+					"region-max-size": "144MiB",
+					"region-split-size": "96MiB",
+					"region-max-keys": 1440000,
+					"region-split-keys": 960000
+				}
+			}
+		}`,
+	})
+
+	cfg, err := FetchRegionSplitConfig(pdClient)
+	require.NoError(t, err)
+	require.Equal(t, "144MiB", cfg.RegionMaxSize)
+	require.Equal(t, int64(1440000), cfg.RegionMaxKeys)
+}
+
+func TestFetchRegionSplitConfigUnavailable(t *testing.T) {
+	pdClient := newTestPDClient(t, map[string]string{
+		"/pd/api/v1/config": `{"schedule": {}, "replication": {}}`,
+	})
+
+	cfg, err := FetchRegionSplitConfig(pdClient)
+	require.NoError(t, err)
+	require.Equal(t, "", cfg.RegionMaxSize)
+	require.Equal(t, int64(0), cfg.RegionMaxKeys)
+}
@@ -0,0 +1,48 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package topology
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/pingcap/tidb-dashboard/pkg/pd"
+	"github.com/pingcap/tidb-dashboard/util/distro"
+)
+
+// FetchRegionLeaderStore returns the TiKV store that hosts the leader of the region.
+func FetchRegionLeaderStore(pdClient *pd.Client, regionID uint64) (*StoreInfo, error) {
+	data, err := pdClient.SendGetRequest(fmt.Sprintf("/region/id/%d", regionID))
+	if err != nil {
+		return nil, err
+	}
+
+	// PD responds `null` when the region does not exist.
+	var region *struct {
+		ID     uint64 `json:"id"`
+		Leader *struct {
+			StoreID int `json:"store_id"`
+		} `json:"leader"`
+	}
+	if err = json.Unmarshal(data, &region); err != nil {
+		return nil, ErrInvalidTopologyData.Wrap(err, "%s region API unmarshal failed", distro.R().PD)
+	}
+	if region == nil || region.ID == 0 {
+		return nil, ErrRegionNotFound.New("region %d not found", regionID)
+	}
+	if region.Leader == nil || region.Leader.StoreID == 0 {
+		return nil, ErrStoreNotFound.New("region %d has no leader", regionID)
+	}
+
+	tikv, _, err := FetchStoreTopology(pdClient)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range tikv {
+		if s.StoreID == region.Leader.StoreID {
+			s := s
+			return &s, nil
+		}
+	}
+	return nil, ErrStoreNotFound.New("leader store %d of region %d not found", region.Leader.StoreID, regionID)
+}
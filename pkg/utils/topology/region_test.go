@@ -0,0 +1,31 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package topology
+
+import (
+	"testing"
+
+	"github.com/joomcode/errorx"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFetchRegionLeaderStore(t *testing.T) {
+	pdClient := newTestPDClient(t, map[string]string{
+		"/pd/api/v1/stores":        testStoresResponse,
+		"/pd/api/v1/region/id/100": `{"id": 100, "leader": {"id": 1002, "store_id": 2}}`,
+		"/pd/api/v1/region/id/101": `{"id": 101, "leader": {"id": 1012, "store_id": 9}}`,
+		"/pd/api/v1/region/id/102": `null`,
+	})
+
+	store, err := FetchRegionLeaderStore(pdClient, 100)
+	require.NoError(t, err)
+	require.Equal(t, 2, store.StoreID)
+	require.Equal(t, "10.0.2.2", store.IP)
+	require.Equal(t, uint(20160), store.Port)
+
+	_, err = FetchRegionLeaderStore(pdClient, 101)
+	require.True(t, errorx.IsOfType(err, ErrStoreNotFound))
+
+	_, err = FetchRegionLeaderStore(pdClient, 102)
+	require.True(t, errorx.IsOfType(err, ErrRegionNotFound))
+}
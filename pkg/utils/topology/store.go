@@ -4,7 +4,9 @@ package topology
 
 import (
 	"encoding/json"
+	"fmt"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/pingcap/log"
@@ -22,6 +24,20 @@ func FetchStoreTopology(pdClient *pd.Client) ([]StoreInfo, []StoreInfo, error) {
 		return nil, nil, err
 	}
 
+	peerStats, err := fetchStorePeerStats(pdClient)
+	if err != nil {
+		// Peer stats are only informative, so that topology is still returned.
+		log.Warn("Failed to fetch store peer stats", zap.Error(err))
+		peerStats = map[int]*storePeerStats{}
+	}
+
+	flows, err := fetchStoreFlows(pdClient)
+	if err != nil {
+		// Same as peer stats, flows are only informative.
+		log.Warn("Failed to fetch store flows", zap.Error(err))
+		flows = &storeFlows{}
+	}
+
 	tiKVStores := make([]store, 0, len(stores))
 	tiFlashStores := make([]store, 0, len(stores))
 	for _, store := range stores {
@@ -38,7 +54,20 @@ func FetchStoreTopology(pdClient *pd.Client) ([]StoreInfo, []StoreInfo, error) {
 		}
 	}
 
-	return buildStoreTopology(tiKVStores), buildStoreTopology(tiFlashStores), nil
+	return buildStoreTopology(tiKVStores, peerStats, flows), buildStoreTopology(tiFlashStores, peerStats, flows), nil
+}
+
+// FetchStoreAddresses returns the sorted addresses of all stores, including TiKV and TiFlash stores.
+func FetchStoreAddresses(pdClient *pd.Client) ([]string, error) {
+	stores, err := fetchStores(pdClient)
+	if err != nil {
+		return nil, err
+	}
+	addresses := make([]string, 0, len(stores))
+	for _, s := range stores {
+		addresses = append(addresses, s.Address)
+	}
+	return addresses, nil
 }
 
 func FetchStoreLocation(pdClient *pd.Client) (*StoreLocation, error) {
@@ -72,7 +101,7 @@ func FetchStoreLocation(pdClient *pd.Client) (*StoreLocation, error) {
 	return &storeLocation, nil
 }
 
-func buildStoreTopology(stores []store) []StoreInfo {
+func buildStoreTopology(stores []store, peerStats map[int]*storePeerStats, flows *storeFlows) []StoreInfo {
 	nodes := make([]StoreInfo, 0, len(stores))
 	for _, v := range stores {
 		hostname, port, err := netutil.ParseHostAndPortFromAddress(v.Address)
@@ -92,6 +121,7 @@ func buildStoreTopology(stores []store) []StoreInfo {
 			version = "v" + version
 		}
 		node := StoreInfo{
+			StoreID:        v.ID,
 			Version:        version,
 			IP:             hostname,
 			Port:           port,
@@ -101,16 +131,69 @@ func buildStoreTopology(stores []store) []StoreInfo {
 			StatusPort:     statusPort,
 			Labels:         map[string]string{},
 			StartTimestamp: v.StartTimestamp,
+
+			RegionCount: v.status.RegionCount,
+			LeaderCount: v.status.LeaderCount,
+			RegionScore: v.status.RegionScore,
+			LeaderScore: v.status.LeaderScore,
+			Capacity:    uint64(v.status.Capacity),
+			Available:   uint64(v.status.Available),
+			UsedSize:    uint64(v.status.UsedSize),
+
+			ReadBytesPerSec:  flows.BytesReadStats[v.ID],
+			WriteBytesPerSec: flows.BytesWriteStats[v.ID],
 		}
 		for _, v := range v.Labels {
 			node.Labels[v.Key] = v.Value
 		}
+		node.EngineVariant = parseEngineVariant(node.Labels)
+		node.Role = parseTiFlashRole(node.Labels)
+		if stats, ok := peerStats[v.ID]; ok {
+			node.PendingPeerCount = stats.pendingPeerCount
+			node.DownPeerCount = stats.downPeerCount
+		}
+		if node.DownPeerCount > 0 {
+			node.Warnings = append(node.Warnings, fmt.Sprintf("store has %d down peers", node.DownPeerCount))
+		}
 		nodes = append(nodes, node)
 	}
 
 	return nodes
 }
 
+// parseEngineVariant returns the storage engine variant of a TiKV store, e.g. `partitioned-raft-kv`.
+// It is empty for classic TiKV stores and TiFlash stores.
+func parseEngineVariant(labels map[string]string) string {
+	if v, ok := labels["engine_variant"]; ok {
+		return v
+	}
+	switch engine := labels["engine"]; engine {
+	case "", "tikv", "tiflash", "tiflash_compute":
+		return ""
+	default:
+		return engine
+	}
+}
+
+const (
+	TiFlashRoleCompute = "compute"
+	TiFlashRoleWrite   = "write"
+)
+
+// parseTiFlashRole returns the role of a disaggregated TiFlash store. Compute nodes register
+// with the `tiflash_compute` engine, while write nodes register with `engine_role=write`.
+func parseTiFlashRole(labels map[string]string) string {
+	switch labels["engine"] {
+	case "tiflash_compute":
+		return TiFlashRoleCompute
+	case "tiflash":
+		if labels["engine_role"] == TiFlashRoleWrite {
+			return TiFlashRoleWrite
+		}
+	}
+	return ""
+}
+
 type store struct {
 	Address string `json:"address"`
 	ID      int    `json:"id"`
@@ -124,6 +207,62 @@ type store struct {
 	GitHash        string `json:"git_hash"`
 	DeployPath     string `json:"deploy_path"`
 	StartTimestamp int64  `json:"start_timestamp"`
+
+	status storeStatus
+}
+
+// storeStatus is the status of a store reported in its heartbeats.
+type storeStatus struct {
+	Capacity    byteSize `json:"capacity"`
+	Available   byteSize `json:"available"`
+	UsedSize    byteSize `json:"used_size"`
+	LeaderCount int      `json:"leader_count"`
+	LeaderScore float64  `json:"leader_score"`
+	RegionCount int      `json:"region_count"`
+	RegionScore float64  `json:"region_score"`
+}
+
+var byteSizeUnits = map[string]float64{
+	"":    1,
+	"B":   1,
+	"KiB": 1 << 10,
+	"MiB": 1 << 20,
+	"GiB": 1 << 30,
+	"TiB": 1 << 40,
+	"PiB": 1 << 50,
+	"EiB": 1 << 60,
+}
+
+// byteSize is a size in bytes, which is humanized by PD, e.g. `1.819TiB`.
+type byteSize uint64
+
+func (b *byteSize) UnmarshalJSON(data []byte) error {
+	var n float64
+	if err := json.Unmarshal(data, &n); err == nil {
+		*b = byteSize(n)
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	s = strings.TrimSpace(s)
+	numEnd := strings.IndexFunc(s, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if numEnd < 0 {
+		numEnd = len(s)
+	}
+	unit, ok := byteSizeUnits[strings.TrimSpace(s[numEnd:])]
+	if !ok {
+		return fmt.Errorf("invalid byte size %s", s)
+	}
+	n, err := strconv.ParseFloat(s[:numEnd], 64)
+	if err != nil {
+		return fmt.Errorf("invalid byte size %s", s)
+	}
+	*b = byteSize(n * unit)
+	return nil
 }
 
 func fetchStores(pdClient *pd.Client) ([]store, error) {
@@ -135,7 +274,8 @@ func fetchStores(pdClient *pd.Client) ([]store, error) {
 	storeResp := struct {
 		Count  int `json:"count"`
 		Stores []struct {
-			Store store
+			Store  store
+			Status storeStatus
 		} `json:"stores"`
 	}{}
 	err = json.Unmarshal(data, &storeResp)
@@ -145,6 +285,7 @@ func fetchStores(pdClient *pd.Client) ([]store, error) {
 
 	ret := make([]store, 0, storeResp.Count)
 	for _, s := range storeResp.Stores {
+		s.Store.status = s.Status
 		ret = append(ret, s.Store)
 	}
 
@@ -172,3 +313,81 @@ func parseStoreState(state string) ComponentStatus {
 		return ComponentStatusUnreachable
 	}
 }
+
+type storePeerStats struct {
+	pendingPeerCount int
+	downPeerCount    int
+}
+
+// fetchStorePeerStats counts pending peers and down peers of each store.
+func fetchStorePeerStats(pdClient *pd.Client) (map[int]*storePeerStats, error) {
+	stats := map[int]*storePeerStats{}
+	get := func(storeID int) *storePeerStats {
+		if _, ok := stats[storeID]; !ok {
+			stats[storeID] = &storePeerStats{}
+		}
+		return stats[storeID]
+	}
+
+	data, err := pdClient.SendGetRequest("/regions/check/pending-peer")
+	if err != nil {
+		return nil, err
+	}
+	pendingResp := struct {
+		Regions []struct {
+			PendingPeers []struct {
+				StoreID int `json:"store_id"`
+			} `json:"pending_peers"`
+		} `json:"regions"`
+	}{}
+	if err = json.Unmarshal(data, &pendingResp); err != nil {
+		return nil, ErrInvalidTopologyData.Wrap(err, "%s pending peer regions API unmarshal failed", distro.R().PD)
+	}
+	for _, r := range pendingResp.Regions {
+		for _, p := range r.PendingPeers {
+			get(p.StoreID).pendingPeerCount++
+		}
+	}
+
+	data, err = pdClient.SendGetRequest("/regions/check/down-peer")
+	if err != nil {
+		return nil, err
+	}
+	downResp := struct {
+		Regions []struct {
+			DownPeers []struct {
+				Peer struct {
+					StoreID int `json:"store_id"`
+				} `json:"peer"`
+			} `json:"down_peers"`
+		} `json:"regions"`
+	}{}
+	if err = json.Unmarshal(data, &downResp); err != nil {
+		return nil, ErrInvalidTopologyData.Wrap(err, "%s down peer regions API unmarshal failed", distro.R().PD)
+	}
+	for _, r := range downResp.Regions {
+		for _, p := range r.DownPeers {
+			get(p.Peer.StoreID).downPeerCount++
+		}
+	}
+
+	return stats, nil
+}
+
+// storeFlows are the flows of each store in bytes per second.
+type storeFlows struct {
+	BytesReadStats  map[int]float64 `json:"bytes_read_stats"`
+	BytesWriteStats map[int]float64 `json:"bytes_write_stats"`
+}
+
+func fetchStoreFlows(pdClient *pd.Client) (*storeFlows, error) {
+	data, err := pdClient.SendGetRequest("/hotspot/stores")
+	if err != nil {
+		return nil, err
+	}
+	flows := &storeFlows{}
+	if err = json.Unmarshal(data, flows); err != nil {
+		return nil, ErrInvalidTopologyData.Wrap(err, "%s hotspot stores API unmarshal failed", distro.R().PD)
+	}
+	return flows, nil
+}
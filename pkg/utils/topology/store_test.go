@@ -0,0 +1,158 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package topology
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testStoresResponse = `{
+  "count": 2,
+  "stores": [
+    {"store": {"id": 1, "address": "10.0.2.1:20160", "status_address": "10.0.2.1:20180", "state_name": "Up", "version": "7.5.0"}},
+    {"store": {"id": 2, "address": "10.0.2.2:20160", "status_address": "10.0.2.2:20180", "state_name": "Up", "version": "7.5.0"}}
+  ]
+}`
+
+func TestFetchStoreTopologyPeerCounts(t *testing.T) {
+	pdClient := newTestPDClient(t, map[string]string{
+		"/pd/api/v1/stores": testStoresResponse,
+		"/pd/api/v1/regions/check/pending-peer": `{"count": 1, "regions": [
+			{"id": 10, "pending_peers": [{"id": 101, "store_id": 1}]}
+		]}`,
+		"/pd/api/v1/regions/check/down-peer": `{"count": 2, "regions": [
+			{"id": 11, "down_peers": [{"peer": {"id": 111, "store_id": 2}, "down_seconds": 100}]},
+			{"id": 12, "down_peers": [{"peer": {"id": 121, "store_id": 2}, "down_seconds": 200}]}
+		]}`,
+	})
+
+	tikv, tiflash, err := FetchStoreTopology(pdClient)
+	require.NoError(t, err)
+	require.Len(t, tiflash, 0)
+	require.Len(t, tikv, 2)
+
+	require.Equal(t, 1, tikv[0].PendingPeerCount)
+	require.Equal(t, 0, tikv[0].DownPeerCount)
+	require.Empty(t, tikv[0].Warnings)
+
+	require.Equal(t, 0, tikv[1].PendingPeerCount)
+	require.Equal(t, 2, tikv[1].DownPeerCount)
+	require.Len(t, tikv[1].Warnings, 1)
+}
+
+func TestFetchStoreTopologyWithoutPeerStats(t *testing.T) {
+	pdClient := newTestPDClient(t, map[string]string{
+		"/pd/api/v1/stores": testStoresResponse,
+	})
+
+	tikv, _, err := FetchStoreTopology(pdClient)
+	require.NoError(t, err)
+	require.Len(t, tikv, 2)
+	require.Equal(t, 0, tikv[1].DownPeerCount)
+}
+
+func TestFetchStoreTopologyEngineVariant(t *testing.T) {
+	pdClient := newTestPDClient(t, map[string]string{
+		"/pd/api/v1/stores": `{
+  "count": 4,
+  "stores": [
+    {"store": {"id": 1, "address": "10.0.2.1:20160", "status_address": "10.0.2.1:20180", "state_name": "Up", "version": "7.5.0"}},
+    {"store": {"id": 2, "address": "10.0.2.2:20160", "status_address": "10.0.2.2:20180", "state_name": "Up", "version": "7.5.0",
+      "labels": [{"key": "engine", "value": "partitioned-raft-kv"}]}},
+    {"store": {"id": 3, "address": "10.0.2.3:20160", "status_address": "10.0.2.3:20180", "state_name": "Up", "version": "7.5.0",
+      "labels": [{"key": "zone", "value": "z1"}, {"key": "engine_variant", "value": "raft-kv2"}]}},
+    {"store": {"id": 100, "address": "10.0.2.9:3930", "status_address": "10.0.2.9:20292", "state_name": "Up", "version": "7.5.0",
+      "labels": [{"key": "engine", "value": "tiflash"}]}}
+  ]
+}`,
+	})
+
+	tikv, tiflash, err := FetchStoreTopology(pdClient)
+	require.NoError(t, err)
+	require.Len(t, tikv, 3)
+	require.Equal(t, "", tikv[0].EngineVariant)
+	require.Equal(t, "partitioned-raft-kv", tikv[1].EngineVariant)
+	require.Equal(t, "raft-kv2", tikv[2].EngineVariant)
+	require.Len(t, tiflash, 1)
+	require.Equal(t, "", tiflash[0].EngineVariant)
+}
+
+func TestFetchStoreTopologyTiFlashRole(t *testing.T) {
+	pdClient := newTestPDClient(t, map[string]string{
+		"/pd/api/v1/stores": `{
+  "count": 4,
+  "stores": [
+    {"store": {"id": 1, "address": "10.0.2.1:20160", "status_address": "10.0.2.1:20180", "state_name": "Up", "version": "7.5.0"}},
+    {"store": {"id": 100, "address": "10.0.2.7:3930", "status_address": "10.0.2.7:20292", "state_name": "Up", "version": "7.5.0",
+      "labels": [{"key": "engine", "value": "tiflash"}]}},
+    {"store": {"id": 101, "address": "10.0.2.8:3930", "status_address": "10.0.2.8:20292", "state_name": "Up", "version": "7.5.0",
+      "labels": [{"key": "engine", "value": "tiflash"}, {"key": "engine_role", "value": "write"}]}},
+    {"store": {"id": 102, "address": "10.0.2.9:3930", "status_address": "10.0.2.9:20292", "state_name": "Up", "version": "7.5.0",
+      "labels": [{"key": "engine", "value": "tiflash_compute"}]}}
+  ]
+}`,
+	})
+
+	tikv, tiflash, err := FetchStoreTopology(pdClient)
+	require.NoError(t, err)
+	require.Len(t, tikv, 1)
+	require.Equal(t, "", tikv[0].Role)
+	require.Len(t, tiflash, 3)
+	require.Equal(t, "", tiflash[0].Role)
+	require.Equal(t, TiFlashRoleWrite, tiflash[1].Role)
+	require.Equal(t, TiFlashRoleCompute, tiflash[2].Role)
+}
+
+func TestFetchStoreTopologyBalance(t *testing.T) {
+	pdClient := newTestPDClient(t, map[string]string{
+		"/pd/api/v1/stores": `{
+  "count": 2,
+  "stores": [
+    {"store": {"id": 1, "address": "10.0.2.1:20160", "status_address": "10.0.2.1:20180", "state_name": "Up", "version": "7.5.0"},
+     "status": {"capacity": "1TiB", "available": "512GiB", "used_size": "1.5GiB", "leader_count": 10, "leader_score": 10,
+       "region_count": 30, "region_score": 1024.5}},
+    {"store": {"id": 2, "address": "10.0.2.2:20160", "status_address": "10.0.2.2:20180", "state_name": "Down", "version": "7.5.0"},
+     "status": {"capacity": "0B", "available": "0B"}}
+  ]
+}`,
+		"/pd/api/v1/hotspot/stores": `{"bytes_read_stats": {"1": 100.5}, "bytes_write_stats": {"1": 2048}, "keys_read_stats": {}}`,
+	})
+
+	tikv, _, err := FetchStoreTopology(pdClient)
+	require.NoError(t, err)
+	require.Len(t, tikv, 2)
+	require.Equal(t, 30, tikv[0].RegionCount)
+	require.Equal(t, 10, tikv[0].LeaderCount)
+	require.Equal(t, 1024.5, tikv[0].RegionScore)
+	require.Equal(t, float64(10), tikv[0].LeaderScore)
+	require.Equal(t, uint64(1<<40), tikv[0].Capacity)
+	require.Equal(t, uint64(512<<30), tikv[0].Available)
+	require.Equal(t, uint64(1.5*(1<<30)), tikv[0].UsedSize)
+	require.Equal(t, 100.5, tikv[0].ReadBytesPerSec)
+	require.Equal(t, float64(2048), tikv[0].WriteBytesPerSec)
+
+	require.Equal(t, 0, tikv[1].RegionCount)
+	require.Equal(t, uint64(0), tikv[1].Capacity)
+	require.Equal(t, float64(0), tikv[1].WriteBytesPerSec)
+}
+
+func TestByteSizeUnmarshal(t *testing.T) {
+	for input, expected := range map[string]uint64{
+		`"1.5KiB"`: 1536,
+		`"2 MiB"`:  2 << 20,
+		`"100B"`:   100,
+		`"7"`:      7,
+		`4096`:     4096,
+	} {
+		var b byteSize
+		require.NoError(t, json.Unmarshal([]byte(input), &b), input)
+		require.Equal(t, expected, uint64(b), input)
+	}
+	for _, input := range []string{`"1.5KB"`, `"abc"`, `true`} {
+		var b byteSize
+		require.Error(t, json.Unmarshal([]byte(input), &b), input)
+	}
+}
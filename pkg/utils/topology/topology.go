@@ -20,6 +20,8 @@ var (
 	ErrEtcdRequestFailed   = ErrNS.NewType("pd_etcd_request_failed")
 	ErrInvalidTopologyData = ErrNS.NewType("invalid_topology_data")
 	ErrInstanceNotAlive    = ErrNS.NewType("instance_not_alive")
+	ErrRegionNotFound      = ErrNS.NewType("region_not_found")
+	ErrStoreNotFound       = ErrNS.NewType("store_not_found")
 )
 
 const defaultFetchTimeout = 2 * time.Second
@@ -13,12 +13,30 @@ import (
 	"github.com/pingcap/tidb-dashboard/util/rest"
 )
 
-var ErrFeatureUnsupported = errorx.CommonErrors.NewType("feature_unsupported")
+var (
+	ErrFeatureUnsupported = errorx.CommonErrors.NewType("feature_unsupported")
+	ErrFeatureDisabled    = errorx.CommonErrors.NewType("feature_disabled")
+)
 
 type FeatureFlag struct {
 	name        string
 	constraints []string
 	isSupported bool
+	// isDisabled is true when the feature is disabled by the deployment.
+	isDisabled bool
+}
+
+// Status is the status of a feature flag reported to the frontend.
+type Status struct {
+	Name string `json:"name"`
+	// Constraints are the versions supporting the feature. The feature is supported in all versions when empty.
+	Constraints []string `json:"constraints"`
+	// Supported is whether the feature is supported in the target version.
+	Supported bool `json:"supported"`
+	// Disabled is whether the feature is disabled by the deployment.
+	Disabled bool `json:"disabled"`
+	// Enabled is true when the feature is supported and not disabled.
+	Enabled bool `json:"enabled"`
 }
 
 func newFeatureFlag(name, targetVersion string, constraints ...string) *FeatureFlag {
@@ -35,10 +53,35 @@ func (f *FeatureFlag) IsSupported() bool {
 	return f.isSupported
 }
 
+// IsEnabled returns whether the feature is supported in the target version and not disabled by the deployment.
+func (f *FeatureFlag) IsEnabled() bool {
+	return f.isSupported && !f.isDisabled
+}
+
+func (f *FeatureFlag) Status() Status {
+	constraints := f.constraints
+	if constraints == nil {
+		constraints = []string{}
+	}
+	return Status{
+		Name:        f.name,
+		Constraints: constraints,
+		Supported:   f.isSupported,
+		Disabled:    f.isDisabled,
+		Enabled:     f.IsEnabled(),
+	}
+}
+
 // VersionGuard returns gin.HandlerFunc as guard middleware.
-// It will determine if features are available in the target version.
+// It will determine if features are available in the target version, and not disabled by the deployment.
+// Requests to unavailable features are rejected with 403, whose error code tells the reason.
 func (f *FeatureFlag) VersionGuard() gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if f.isDisabled {
+			rest.Error(c, ErrFeatureDisabled.New(f.name).WithProperty(rest.HTTPCodeProperty(http.StatusForbidden)))
+			c.Abort()
+			return
+		}
 		if !f.isSupported {
 			rest.Error(c, ErrFeatureUnsupported.New(f.name).WithProperty(rest.HTTPCodeProperty(http.StatusForbidden)))
 			c.Abort()
@@ -52,7 +95,11 @@ func (f *FeatureFlag) VersionGuard() gin.HandlerFunc {
 // IsSupportedIn checks if a semantic version fits within a set of constraints
 // pdVersion, standaloneVersion examples: "v5.2.2", "v5.3.0", "v5.4.0-alpha-xxx", "5.3.0" (semver can handle `v` prefix by itself)
 // constraints examples: "~5.2.2", ">= 5.3.0", see semver docs to get more information.
+// Features without constraints are supported in all versions.
 func (f *FeatureFlag) isSupportedIn(targetVersion string) bool {
+	if len(f.constraints) == 0 {
+		return true
+	}
 	// drop "-alpha-xxx" suffix
 	versionWithoutSuffix := strings.Split(targetVersion, "-")[0]
 	v, err := semver.NewVersion(versionWithoutSuffix)
@@ -100,3 +100,27 @@ func Test_VersionGuardWith_ErrorHandlerFn(t *testing.T) {
 
 	r.Equal(http.StatusForbidden, w2.Code)
 }
+
+func Test_WithoutConstraints(t *testing.T) {
+	require.True(t, newFeatureFlag("testFeature", "v5.3.0").IsSupported())
+	require.True(t, newFeatureFlag("testFeature", "N/A").IsSupported())
+}
+
+func Test_VersionGuard_Disabled(t *testing.T) {
+	r := require.New(t)
+	f := newFeatureFlag("testFeature", "v5.3.0", ">= 5.3.0")
+	f.isDisabled = true
+
+	e := gin.Default()
+	e.Use(rest.ErrorHandlerFn())
+	e.Use(f.VersionGuard())
+	e.GET("/ping", func(c *gin.Context) {
+		c.String(http.StatusOK, "pong")
+	})
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/ping", nil)
+	e.ServeHTTP(w, req)
+
+	r.Equal(http.StatusForbidden, w.Code)
+	r.Contains(w.Body.String(), ErrFeatureDisabled.FullName())
+}
@@ -10,6 +10,7 @@ type Registry struct {
 	version           string
 	flags             map[string]*FeatureFlag
 	supportedFeatures map[string]struct{}
+	disabledFeatures  map[string]struct{}
 }
 
 func NewRegistry(version string) *Registry {
@@ -17,6 +18,19 @@ func NewRegistry(version string) *Registry {
 		version:           version,
 		flags:             map[string]*FeatureFlag{},
 		supportedFeatures: map[string]struct{}{},
+		disabledFeatures:  map[string]struct{}{},
+	}
+}
+
+// Disable disables features by name, no matter whether they are registered yet.
+// It should be called before serving requests.
+func (m *Registry) Disable(names ...string) {
+	for _, name := range names {
+		m.disabledFeatures[name] = struct{}{}
+		if f, ok := m.flags[name]; ok {
+			f.isDisabled = true
+			delete(m.supportedFeatures, name)
+		}
 	}
 }
 
@@ -27,14 +41,15 @@ func (m *Registry) Register(name string, constraints ...string) *FeatureFlag {
 	}
 
 	nf := newFeatureFlag(name, m.version, constraints...)
+	_, nf.isDisabled = m.disabledFeatures[name]
 	m.flags[name] = nf
-	if nf.IsSupported() {
+	if nf.IsEnabled() {
 		m.supportedFeatures[nf.Name()] = struct{}{}
 	}
 	return nf
 }
 
-// SupportedFeatures returns supported feature's names.
+// SupportedFeatures returns names of features supported in the target version and not disabled.
 func (m *Registry) SupportedFeatures() []string {
 	sf := make([]string, 0, len(m.supportedFeatures))
 	for k := range m.supportedFeatures {
@@ -43,3 +58,15 @@ func (m *Registry) SupportedFeatures() []string {
 	sort.Strings(sf)
 	return sf
 }
+
+// Features returns the status of all registered features, ordered by name.
+func (m *Registry) Features() []Status {
+	features := make([]Status, 0, len(m.flags))
+	for _, f := range m.flags {
+		features = append(features, f.Status())
+	}
+	sort.Slice(features, func(i, j int) bool {
+		return features[i].Name < features[j].Name
+	})
+	return features
+}
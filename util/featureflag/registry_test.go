@@ -56,3 +56,24 @@ func Test_SupportedFeatures(t *testing.T) {
 
 	require.Equal(t, []string{"testFeature1", "testFeature2"}, m.SupportedFeatures())
 }
+
+func Test_Disable(t *testing.T) {
+	m := NewRegistry("v5.3.0")
+	f1 := m.Register("testFeature1", ">= 5.3.0")
+	m.Disable("testFeature1", "testFeature2")
+	f2 := m.Register("testFeature2")
+	f3 := m.Register("testFeature3", ">= 5.3.1")
+
+	require.True(t, f1.IsSupported())
+	require.False(t, f1.IsEnabled())
+	require.True(t, f2.IsSupported())
+	require.False(t, f2.IsEnabled())
+	require.Empty(t, m.SupportedFeatures())
+
+	require.Equal(t, []Status{
+		{Name: "testFeature1", Constraints: []string{">= 5.3.0"}, Supported: true, Disabled: true},
+		{Name: "testFeature2", Constraints: []string{}, Supported: true, Disabled: true},
+		{Name: "testFeature3", Constraints: []string{">= 5.3.1"}},
+	}, m.Features())
+	require.False(t, f3.IsEnabled())
+}
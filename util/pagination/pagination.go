@@ -0,0 +1,101 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+// Package pagination defines the limit / cursor contract of list APIs that may return a large number of items.
+//
+// A request specifies at most how many items to return (`limit`) and where to continue (`cursor`). Cursors are
+// opaque to clients: the next cursor is responded by the API, and an empty next cursor means no more items.
+package pagination
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/pingcap/tidb-dashboard/util/rest"
+)
+
+// NextCursorHeader carries the next cursor for APIs whose response body is an array.
+const NextCursorHeader = "X-Next-Cursor"
+
+// flushInterval is the number of items written before flushing to the client.
+const flushInterval = 256
+
+type Request struct {
+	Cursor string `json:"cursor" form:"cursor"`
+	Limit  int    `json:"limit" form:"limit"`
+}
+
+// Normalize applies the default limit when the limit is not specified, and caps it to the max limit.
+func (r *Request) Normalize(defaultLimit, maxLimit int) {
+	if r.Limit <= 0 {
+		r.Limit = defaultLimit
+	}
+	if r.Limit > maxLimit {
+		r.Limit = maxLimit
+	}
+}
+
+// EncodeOffsetCursor encodes the offset of the next item as the cursor, for sources that can only be paged
+// by offsets.
+func EncodeOffsetCursor(offset int) string {
+	return strconv.Itoa(offset)
+}
+
+// DecodeOffsetCursor decodes a cursor encoded by EncodeOffsetCursor. Empty cursor is the offset 0.
+func DecodeOffsetCursor(cursor string) (int, error) {
+	if cursor == "" {
+		return 0, nil
+	}
+	offset, err := strconv.Atoi(cursor)
+	if err != nil || offset < 0 {
+		return 0, rest.ErrBadRequest.New("invalid cursor")
+	}
+	return offset, nil
+}
+
+// NextOffsetCursor returns the cursor after a page of n items starting from the offset, or empty when the page
+// is not full, i.e. there are no more items.
+func NextOffsetCursor(offset, limit, n int) string {
+	if n < limit {
+		return ""
+	}
+	return EncodeOffsetCursor(offset + n)
+}
+
+// WriteArray writes n items as a JSON array with the status code 200, in chunked encoding. Items are encoded one
+// by one, so that the whole response is never held in memory. The next cursor is written in NextCursorHeader.
+//
+// Items are encoded in the same way as `gin.Context.JSON`. As the status is written before encoding, errors
+// occurred in the middle can only abort the connection.
+func WriteArray(c *gin.Context, nextCursor string, n int, item func(i int) interface{}) {
+	if nextCursor != "" {
+		c.Header(NextCursorHeader, nextCursor)
+	}
+	c.Header("Content-Type", "application/json; charset=utf-8")
+	c.Status(http.StatusOK)
+
+	w := c.Writer
+	enc := json.NewEncoder(w)
+	if _, err := w.WriteString("["); err != nil {
+		return
+	}
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			if _, err := w.WriteString(","); err != nil {
+				return
+			}
+		}
+		if err := enc.Encode(item(i)); err != nil {
+			// The response is truncated, which can be recognized by clients as an invalid JSON.
+			_ = c.Error(err)
+			return
+		}
+		if (i+1)%flushInterval == 0 {
+			w.Flush()
+		}
+	}
+	_, _ = w.WriteString("]")
+	w.Flush()
+}
@@ -0,0 +1,76 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package pagination
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalize(t *testing.T) {
+	r := Request{}
+	r.Normalize(100, 1000)
+	require.Equal(t, 100, r.Limit)
+	r.Limit = 5000
+	r.Normalize(100, 1000)
+	require.Equal(t, 1000, r.Limit)
+	r.Limit = 10
+	r.Normalize(100, 1000)
+	require.Equal(t, 10, r.Limit)
+}
+
+func TestOffsetCursor(t *testing.T) {
+	offset, err := DecodeOffsetCursor("")
+	require.NoError(t, err)
+	require.Equal(t, 0, offset)
+
+	offset, err = DecodeOffsetCursor(EncodeOffsetCursor(300))
+	require.NoError(t, err)
+	require.Equal(t, 300, offset)
+
+	_, err = DecodeOffsetCursor("-1")
+	require.Error(t, err)
+	_, err = DecodeOffsetCursor("abc")
+	require.Error(t, err)
+
+	require.Equal(t, "200", NextOffsetCursor(100, 100, 100))
+	require.Equal(t, "", NextOffsetCursor(100, 100, 99))
+}
+
+func TestWriteArray(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	type item struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}
+	items := make([]item, 1000)
+	for i := range items {
+		items[i] = item{ID: i, Name: "<a>"}
+	}
+
+	engine := gin.New()
+	engine.GET("/items", func(c *gin.Context) {
+		WriteArray(c, "1000", len(items), func(i int) interface{} { return items[i] })
+	})
+	engine.GET("/empty", func(c *gin.Context) {
+		WriteArray(c, "", 0, nil)
+	})
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/items", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "1000", w.Header().Get(NextCursorHeader))
+	var decoded []item
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &decoded))
+	require.Equal(t, items, decoded)
+
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/empty", nil))
+	require.Equal(t, "[]", w.Body.String())
+	require.Empty(t, w.Header().Get(NextCursorHeader))
+}
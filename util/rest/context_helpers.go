@@ -41,3 +41,14 @@ func MustBind(c *gin.Context, obj interface{}) bool {
 	}
 	return true
 }
+
+// ResponseStatus returns the status code that will be responded to the client when called after the handlers.
+// For errors attached by `Error`, the status code is not written yet, thus it is derived from the error the same
+// way as `ErrorHandlerFn`.
+func ResponseStatus(c *gin.Context) int {
+	statusCode := c.Writer.Status()
+	if err := c.Errors.Last(); err != nil && statusCode == http.StatusOK && c.Writer.Size() <= 0 {
+		statusCode = extractHTTPCodeFromError(err.Err)
+	}
+	return statusCode
+}
@@ -4,6 +4,8 @@ package rest
 
 import (
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joomcode/errorx"
@@ -17,14 +19,38 @@ var (
 	ErrBadRequest      = errorx.CommonErrors.NewType("bad_request")
 	ErrNotFound        = errorx.CommonErrors.NewType("not_found")
 
-	errInternal  = errorx.CommonErrors.NewType("internal")
-	propHTTPCode = errorx.RegisterProperty("http_code")
+	errInternal    = errorx.CommonErrors.NewType("internal")
+	propHTTPCode   = errorx.RegisterProperty("http_code")
+	propRetryAfter = errorx.RegisterProperty("retry_after")
 )
 
 func HTTPCodeProperty(code int) (errorx.Property, int) {
 	return propHTTPCode, code
 }
 
+// RetryAfterProperty tells the client how long to wait before retrying, which is sent in the `Retry-After` header
+// and the response.
+func RetryAfterProperty(d time.Duration) (errorx.Property, time.Duration) {
+	return propRetryAfter, d
+}
+
+// extractRetryAfterSecFromError returns the seconds in the RetryAfterProperty rounded up, or 0 if there is none.
+func extractRetryAfterSecFromError(err error) int64 {
+	ex := errorx.Cast(err)
+	if ex == nil {
+		return 0
+	}
+	v, ok := ex.Property(propRetryAfter)
+	if !ok {
+		return 0
+	}
+	d := v.(time.Duration)
+	if d <= 0 {
+		return 0
+	}
+	return int64((d + time.Second - 1) / time.Second)
+}
+
 func extractHTTPCodeFromError(err error) int {
 	if err == nil {
 		return http.StatusOK
@@ -85,6 +111,9 @@ func ErrorHandlerFn() gin.HandlerFunc {
 		}
 
 		errResponse := NewErrorResponse(err.Err)
+		if errResponse.RetryAfterSec > 0 {
+			c.Header("Retry-After", strconv.FormatInt(errResponse.RetryAfterSec, 10))
+		}
 
 		log.Warn("Error when handling request",
 			zap.String("uri", c.Request.RequestURI),
@@ -14,6 +14,8 @@ type ErrorResponse struct {
 	Message  string `json:"message"`
 	Code     string `json:"code"`
 	FullText string `json:"full_text"`
+	// RetryAfterSec is the seconds to wait before retrying, if the request is rejected temporarily.
+	RetryAfterSec int64 `json:"retry_after_sec,omitempty"`
 }
 
 // buildSimpleMessage traverses through the error chain and builds a simple error message.
@@ -100,5 +102,6 @@ func NewErrorResponse(err error) ErrorResponse {
 		Code:    removeErrorPrefix(buildCode(err)),
 		// For security reasons, we need to hide detailed stacktrace info.
 		// FullText: buildDetailMessage(err),
+		RetryAfterSec: extractRetryAfterSecFromError(err),
 	}
 }
@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joomcode/errorx"
@@ -146,6 +147,23 @@ func (suite *ErrorHandlerFnTestSuite) TestNextMiddleware() {
 	suite.Require().True(middlewareCalled.Load())
 }
 
+func (suite *ErrorHandlerFnTestSuite) TestRetryAfter() {
+	engine := gin.New()
+	engine.Use(ErrorHandlerFn())
+	engine.GET("/test", func(c *gin.Context) {
+		Error(c, ErrBadRequest.NewWithNoMessage().
+			WithProperty(HTTPCodeProperty(http.StatusTooManyRequests)).
+			WithProperty(RetryAfterProperty(1500*time.Millisecond)))
+	})
+
+	r := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/test", nil)
+	engine.ServeHTTP(r, req)
+	suite.Require().Equal(http.StatusTooManyRequests, r.Code)
+	suite.Require().Equal("2", r.Header().Get("Retry-After"))
+	assertutil.RequireJSONContains(suite.T(), r.Body.String(), `{"error":true,"code":"common.bad_request","retry_after_sec":2}`)
+}
+
 // When panic happened, ErrorHandlerFn will not be invoked.
 func (suite *ErrorHandlerFnTestSuite) TestWithRecoveryMiddleware() {
 	engine := gin.New()
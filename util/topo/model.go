@@ -22,6 +22,8 @@ const (
 	KindTiFlash      Kind = "tiflash"
 	KindTiCDC        Kind = "ticdc"
 	KindTiProxy      Kind = "tiproxy"
+	KindPump         Kind = "pump"
+	KindDrainer      Kind = "drainer"
 	KindAlertManager Kind = "alert_manager"
 	KindGrafana      Kind = "grafana"
 	KindPrometheus   Kind = "prometheus"